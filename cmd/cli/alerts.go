@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/fleet"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/metrics"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	alertsEnvironments string
+	alertsFormat       string
+	alertsName         string
+	alertsNamespace    string
+	alertsOutput       string
+)
+
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Generate alerting rules",
+}
+
+var alertsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate Prometheus alerting rules from the fleet planning config",
+	Long: `Writes alerting rules on the metrics kube-upgrade-server exposes on /metrics, parameterized by the
+environments file of 'fleet plan':
+
+  KubeUpgradeCriticalFindings  a cluster still has critical findings for the target version
+                               alerts.criticalFindingsDays (default 14) before its environment's
+                               upgradeDate
+  KubeUpgradeVersionEndOfLife  a cluster runs a Kubernetes release that reaches end of life within
+                               alerts.eolDays (default 30), per knowledge-base/releases.json and
+                               alerts.eol
+
+Formats:
+  prometheusrule  a prometheus-operator PrometheusRule (default)
+  rules           a Prometheus rule file
+  alertmanager    Alertmanager routes sending each environment's alerts to its receiver`,
+	Args: cobra.NoArgs,
+	Run:  runAlertsGenerate,
+}
+
+func init() {
+	alertsGenerateCmd.Flags().StringVar(&alertsEnvironments, "environments", "environments.yaml", "YAML file mapping clusters to environments, with upgrade dates and receivers")
+	alertsGenerateCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required for rules)")
+	alertsGenerateCmd.Flags().StringVar(&alertsFormat, "format", "prometheusrule", "Output format: prometheusrule, rules or alertmanager")
+	alertsGenerateCmd.Flags().StringVar(&alertsName, "name", "kube-upgrade-advisor", "PrometheusRule name")
+	alertsGenerateCmd.Flags().StringVar(&alertsNamespace, "namespace", "", "PrometheusRule namespace")
+	alertsGenerateCmd.Flags().StringVarP(&alertsOutput, "output", "o", "-", "File to write, or - for stdout")
+
+	alertsCmd.AddCommand(alertsGenerateCmd)
+	rootCmd.AddCommand(alertsCmd)
+}
+
+func runAlertsGenerate(cmd *cobra.Command, args []string) {
+	switch alertsFormat {
+	case "prometheusrule", "rules", "alertmanager":
+	default:
+		fatal(usageErrorf("Invalid --format value %q: must be prometheusrule, rules or alertmanager", alertsFormat))
+	}
+	if alertsFormat != "alertmanager" && targetVersion == "" {
+		fatal(usageErrorf("--target is required"))
+	}
+
+	config, err := fleet.LoadConfig(alertsEnvironments)
+	if err != nil {
+		fatalf("Failed to load environments: %v", err)
+	}
+
+	var output any
+	if alertsFormat == "alertmanager" {
+		output, err = metrics.AlertmanagerRoute(config)
+		if err != nil {
+			fatalf("Failed to generate Alertmanager routes: %v", err)
+		}
+	} else {
+		releases := knowledge.NewReleaseKnowledgeBase()
+		if err := releases.LoadFromFile(knowledgeFile("releases.json")); err != nil {
+			log.Printf("Warning: failed to load release knowledge, skipping end of life alerts: %v", err)
+			releases = nil
+		}
+		groups, err := metrics.AlertRules(config, targetVersion, releases)
+		if err != nil {
+			fatalf("Failed to generate alerting rules: %v", err)
+		}
+		if alertsFormat == "rules" {
+			output = metrics.RuleFile(groups)
+		} else {
+			output = metrics.PrometheusRule(alertsName, alertsNamespace, groups)
+		}
+	}
+
+	data, err := yaml.Marshal(output)
+	if err != nil {
+		fatalf("Failed to render alerts: %v", err)
+	}
+	if alertsOutput == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(alertsOutput, data, 0o644); err != nil {
+		fatalf("Failed to write %s: %v", alertsOutput, err)
+	}
+	fmt.Printf("Wrote %s to %s\n", alertsFormat, alertsOutput)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiUsageQuery  inventory.APIQuery
+	apiUsageFormat string
+)
+
+var impactAPICmd = &cobra.Command{
+	Use:   "api",
+	Short: "Report every cluster, manifest and release still using an API",
+	Long: `Reports the CRDs, manifests, live objects and Helm releases of every cluster in the database that use
+an API, whatever the target version, and the clusters serving it. Use it when an API is deprecated outside the Kubernetes release
+cycle, e.g. by an internal platform or an operator. --group defaults to the core group; without
+--kind, every kind of the group and version is reported.`,
+	Args: cobra.NoArgs,
+	Run:  runImpactAPI,
+}
+
+func init() {
+	impactAPICmd.Flags().StringVar(&apiUsageQuery.Group, "group", "", "API group; empty for the core group")
+	impactAPICmd.Flags().StringVar(&apiUsageQuery.Version, "version", "", "API version, e.g. v1beta1 (required)")
+	impactAPICmd.Flags().StringVar(&apiUsageQuery.Kind, "kind", "", "Kind, e.g. Ingress (default: all kinds of the group and version)")
+	impactAPICmd.Flags().StringVar(&apiUsageFormat, "format", "text", "Output format: text, json or yaml")
+	impactAPICmd.MarkFlagRequired("version")
+	impactCmd.AddCommand(impactAPICmd)
+}
+
+func runImpactAPI(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if apiUsageFormat != "text" && apiUsageFormat != "json" && apiUsageFormat != "yaml" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json, yaml)", apiUsageFormat))
+	}
+	if serverURL != "" {
+		fatal(usageErrorf("impact api reads the local database; --server is not supported"))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	usage, err := store.FindAPIUsage(ctx, apiUsageQuery)
+	if err != nil {
+		fatalf("Failed to find API usage: %v", err)
+	}
+
+	if apiUsageFormat != "text" {
+		if err := printStructured(usage, apiUsageFormat); err != nil {
+			fatalf("Failed to print API usage: %v", err)
+		}
+		return
+	}
+	printAPIUsage(usage)
+}
+
+// printAPIUsage prints the clusters using an API with their CRDs, manifests, releases and objects
+func printAPIUsage(usage *inventory.APIUsage) {
+	fmt.Printf("=== Usage of %s ===\n", usage.API)
+	if len(usage.Clusters) == 0 {
+		fmt.Println("\nNo cluster in the database serves it, and no manifest or release uses it.")
+		return
+	}
+
+	for _, c := range usage.Clusters {
+		served := "not served"
+		if c.Served {
+			served = "served"
+		}
+		fmt.Printf("\nCluster %s (Kubernetes %s, %s)\n", c.ClusterID, c.KubeVersion, served)
+		if len(c.CRDs) == 0 && len(c.Manifests) == 0 && len(c.Releases) == 0 && len(c.Resources) == 0 {
+			fmt.Println("  Nothing stored uses it")
+		}
+		if len(c.CRDs) > 0 {
+			fmt.Printf("  CRDs: %s\n", strings.Join(c.CRDs, ", "))
+		}
+		for _, manifest := range c.Manifests {
+			line := fmt.Sprintf("  Manifests (%s): %s", manifest.Source, manifest.Kind)
+			if len(manifest.Namespaces) > 0 {
+				line += " in " + strings.Join(manifest.Namespaces, ", ")
+			}
+			if manifest.Templated {
+				line += " (templated)"
+			}
+			fmt.Println(line)
+		}
+		if len(c.Releases) > 0 {
+			fmt.Printf("  Helm releases: %s\n", strings.Join(c.Releases, ", "))
+		}
+		if len(c.Resources) > 0 {
+			fmt.Printf("  Objects (%d):\n", len(c.Resources))
+			for _, resource := range c.Resources {
+				name := resource.Name
+				if resource.Namespace != "" {
+					name = resource.Namespace + "/" + resource.Name
+				}
+				location := resource.Source
+				if resource.File != "" {
+					location += " " + resource.File
+				}
+				if resource.Line > 0 {
+					location += fmt.Sprintf(":%d", resource.Line)
+				}
+				fmt.Printf("    - %s %s (%s)\n", resource.Kind, name, location)
+			}
+		}
+	}
+	fmt.Printf("\n%d cluster(s) serve or use %s\n", len(usage.Clusters), usage.API)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	auditClusterID string
+	auditAction    string
+	auditActor     string
+	auditSince     string
+	auditLimit     int
+	auditFormat    string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Review the audit log of advisor actions",
+	Long: `Scans, impact analyses, plan generations, approvals and executions and fixes written in place are recorded in the audit log of
+the database with who ran them, when, their flags and a summary of their result, as evidence for change
+management. Actions run through a kube-upgrade-server are recorded in its database.`,
+}
+
+var auditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List audit log entries, newest first",
+	Args:  cobra.NoArgs,
+	Run:   runAuditList,
+}
+
+func init() {
+	auditListCmd.Flags().StringVar(&auditClusterID, "cluster", "", "Only list actions on this cluster ID")
+	auditListCmd.Flags().StringVar(&auditAction, "action", "", "Only list this action: scan, impact, plan, fix, approve or execute")
+	auditListCmd.Flags().StringVar(&auditActor, "actor", "", "Only list actions of this user or API token")
+	auditListCmd.Flags().StringVar(&auditSince, "since", "", "Only list actions since a date (2006-01-02), time (RFC 3339) or duration ago (e.g. 720h)")
+	auditListCmd.Flags().IntVar(&auditLimit, "limit", 50, "Maximum number of entries; 0 for all")
+	auditListCmd.Flags().StringVar(&auditFormat, "format", "text", "Output format: text or json")
+
+	auditCmd.AddCommand(auditListCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAuditList(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if auditFormat != "text" && auditFormat != "json" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json)", auditFormat))
+	}
+	filter := inventory.AuditFilter{ClusterID: auditClusterID, Action: auditAction, Actor: auditActor, Limit: auditLimit}
+	if auditSince != "" {
+		since, err := parseSince(auditSince, time.Now())
+		if err != nil {
+			fatal(usageErrorf("Invalid --since value: %w", err))
+		}
+		filter.Since = since
+	}
+
+	var entries []api.AuditLogInfo
+	if serverURL != "" {
+		var err error
+		entries, err = newAPIClient().AuditLogs(ctx, filter)
+		if err != nil {
+			fatalf("Failed to list audit log: %v", err)
+		}
+	} else {
+		store, err := openStore()
+		if err != nil {
+			fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		stored, err := store.ListAuditLogs(ctx, filter)
+		if err != nil {
+			fatalf("Failed to list audit log: %v", err)
+		}
+		for _, entry := range stored {
+			entries = append(entries, api.NewAuditLogInfo(entry))
+		}
+	}
+
+	if auditFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if entries == nil {
+			entries = []api.AuditLogInfo{}
+		}
+		if err := encoder.Encode(entries); err != nil {
+			fatalf("Failed to encode audit log: %v", err)
+		}
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit log entries")
+		return
+	}
+	fmt.Printf("%-6s %-20s %-8s %-16s %-16s %-7s %s\n", "ID", "FINISHED", "ACTION", "ACTOR", "CLUSTER", "RESULT", "SUMMARY")
+	for _, entry := range entries {
+		result, summary := "ok", entry.Summary
+		if !entry.Succeeded {
+			result, summary = "failed", entry.Error
+		}
+		actor := entry.Actor
+		if entry.Origin == inventory.AuditOriginServer {
+			actor += " (server)"
+		}
+		fmt.Printf("%-6d %-20s %-8s %-16s %-16s %-7s %s\n", entry.ID, entry.FinishedAt.Local().Format("2006-01-02 15:04:05"),
+			entry.Action, actor, entry.ClusterID, result, summary)
+		if params := formatParameters(entry.Parameters); params != "" {
+			fmt.Printf("       %s\n", params)
+		}
+	}
+}
+
+// parseSince parses a date, an RFC 3339 time or a duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if ago, err := time.ParseDuration(value); err == nil {
+		return now.Add(-ago), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date, an RFC 3339 time nor a duration", value)
+	}
+	return since, nil
+}
+
+// formatParameters prints parameters as --flag=value in a stable order
+func formatParameters(parameters map[string]string) string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "args" {
+			formatted = append(formatted, parameters[name])
+			continue
+		}
+		formatted = append(formatted, fmt.Sprintf("--%s=%s", name, parameters[name]))
+	}
+	return strings.Join(formatted, " ")
+}
+
+// currentAudit is the audit log entry of the running command, written when it exits; nil for
+// commands that aren't audited
+var currentAudit *inventory.AuditEntry
+
+// startAudit starts recording the running command in the audit log, with the flags set on the
+// command line and its arguments as parameters
+func startAudit(cmd *cobra.Command, args []string, action, clusterID string) {
+	parameters := make(map[string]string)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		parameters[flag.Name] = flag.Value.String()
+	})
+	if len(args) > 0 {
+		parameters["args"] = strings.Join(args, " ")
+	}
+
+	currentAudit = &inventory.AuditEntry{
+		Action:     action,
+		Actor:      auditUser(),
+		Origin:     inventory.AuditOriginCLI,
+		ClusterID:  clusterID,
+		Parameters: parameters,
+		StartedAt:  time.Now(),
+	}
+}
+
+// auditCluster sets the cluster of the running command, once resolved, e.g. from a --selector
+func auditCluster(clusterID string) {
+	if currentAudit != nil {
+		currentAudit.ClusterID = clusterID
+	}
+}
+
+// auditSummary sets the result summary of the running command
+func auditSummary(format string, args ...interface{}) {
+	if currentAudit != nil {
+		currentAudit.Summary = fmt.Sprintf(format, args...)
+	}
+}
+
+// auditFailure records why the running command failed; the first failure is kept
+func auditFailure(message string) {
+	if currentAudit != nil && currentAudit.Error == "" {
+		currentAudit.Error = message
+	}
+}
+
+// finishAudit writes the entry of the running command to the audit log of the --db. A failure to
+// write only logs a warning, so the log never changes the outcome of a command.
+func finishAudit(code int) {
+	if currentAudit == nil || readOnlyDB {
+		return
+	}
+	entry := *currentAudit
+	currentAudit = nil
+	entry.Succeeded = code == 0 && entry.Error == ""
+	if !entry.Succeeded && entry.Error == "" {
+		entry.Error = fmt.Sprintf("exited with code %d", code)
+	}
+
+	store, err := inventory.NewStore(dbPath)
+	if err != nil {
+		log.Printf("Warning: failed to record audit log entry: %v", err)
+		return
+	}
+	defer store.Close()
+	if _, err := store.RecordAudit(context.Background(), entry); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// auditUser is the user recorded as actor: $KUBE_ADVISOR_ACTOR, e.g. set by a CI pipeline to the
+// person who triggered it, or the OS user
+func auditUser() string {
+	if actor := os.Getenv("KUBE_ADVISOR_ACTOR"); actor != "" {
+		return actor
+	}
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+)
+
+// Chart cache flags of impact, for the charts --render-charts and --drift render
+var (
+	useChartCache bool
+	chartCacheDir string
+	chartCacheTTL time.Duration
+	refreshCharts bool
+)
+
+// chartCache is the cache of --chart-cache-dir; nil unless enabled with --chart-cache
+func chartCache() *manifests.ChartCache {
+	if !useChartCache || chartCacheDir == "" {
+		return nil
+	}
+	cache := manifests.NewChartCache(chartCacheDir, chartCacheTTL)
+	cache.Refresh = refreshCharts
+	return cache
+}
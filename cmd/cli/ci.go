@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/ci"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciProvider        string
+	ciPath            string
+	ciFailOn          string
+	ciCodeQualityPath string
+	ciDotenvPath      string
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Check the checked-out repository in a CI pipeline",
+	Long: `Detects GitHub Actions or GitLab CI, analyzes the manifests of the checked-out repository,
+annotates findings (GitHub check annotations or a GitLab code quality report), sets pipeline
+outputs and exits non-zero when a finding reaches the --fail-on threshold`,
+	Run: runCI,
+}
+
+func init() {
+	ciCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	ciCmd.MarkFlagRequired("target")
+	ciCmd.Flags().StringVar(&ciPath, "path", "", "Manifest folder or file to check (default: the CI workspace)")
+	ciCmd.Flags().StringSliceVar(&renderers, "render", nil, "Render templated sources before parsing: jsonnet, cue, helmfile (comma-separated)")
+	ciCmd.Flags().BoolVar(&tolerateTemplates, "tolerate-templates", false, "Recover apiVersion and kind from un-rendered Go templates, e.g. raw chart templates, as lower-confidence findings")
+	ciCmd.Flags().BoolVar(&scanConfigMaps, "scan-configmaps", false, "Also parse manifests embedded in the data of ConfigMaps")
+	ciCmd.Flags().StringVar(&ciProvider, "provider", "", "CI provider: github, gitlab or generic (default: autodetect)")
+	ciCmd.Flags().StringVar(&ciFailOn, "fail-on", "high", "Fail when a finding has at least this severity: critical, high, medium, low or none")
+	ciCmd.Flags().StringVar(&baselinePath, "baseline", "", "Baseline file of accepted findings; only new findings fail the run")
+	ciCmd.Flags().StringVar(&ciCodeQualityPath, "codequality-report", "gl-code-quality-report.json", "GitLab code quality report path")
+	ciCmd.Flags().StringVar(&ciDotenvPath, "dotenv-report", "kube-advisor.env", "GitLab dotenv report path for pipeline outputs")
+
+	rootCmd.AddCommand(ciCmd)
+}
+
+func runCI(cmd *cobra.Command, args []string) {
+	env := ci.Detect()
+	if ciProvider != "" {
+		provider, err := ci.ParseProvider(ciProvider)
+		if err != nil {
+			fatal(usageErrorf("Invalid --provider value: %w", err))
+		}
+		env.Provider = provider
+	}
+
+	failOn, err := analysis.ParseImpactLevel(ciFailOn)
+	if err != nil {
+		fatal(usageErrorf("Invalid --fail-on value: %w", err))
+	}
+
+	path := ciPath
+	if path == "" {
+		path = env.Workspace
+	}
+
+	fmt.Printf("=== Kube Upgrade Advisor - CI (%s) ===\n\n", env.Provider)
+
+	parser := manifests.NewParser()
+	parser.Offline = offline
+	parser.TolerateTemplates = tolerateTemplates
+	parser.ScanConfigMaps = scanConfigMaps
+	if err := parser.EnableRenderers(renderers); err != nil {
+		fatal(usageErrorf("Invalid --render value: %w", err))
+	}
+	resources, err := parser.ParseInput(path)
+	if err != nil {
+		fatalf("Failed to parse manifests: %v", err)
+	}
+	fmt.Printf("Found %d resources in %s\n", len(resources), path)
+
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, knowledgeFile("chart-matrix.json"), nil)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+	analyzer.SetLocalizer(localizer)
+	loadKnowledge(analyzer)
+
+	assessment := analyzer.ComputeManifestImpact(parser.ToResourceEntries(resources), path, targetVersion)
+	if baselinePath != "" {
+		baseline, err := analysis.LoadBaseline(baselinePath)
+		if err != nil {
+			fatalf("Failed to load baseline: %v", err)
+		}
+		assessment.Baseline = baseline.Compare(assessment)
+	}
+
+	report := analyzer.GenerateReport(assessment)
+	fmt.Println(report)
+
+	result := ci.Evaluate(assessment, resources, env.Workspace, failOn)
+	outputs := result.Outputs()
+
+	switch env.Provider {
+	case ci.ProviderGitHub:
+		ci.WriteGitHubAnnotations(os.Stdout, result.Annotations, failOn)
+		if err := ci.WriteGitHubOutputs(outputs); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if err := ci.WriteGitHubSummary(report); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	case ci.ProviderGitLab:
+		if err := ci.WriteGitLabCodeQuality(ciCodeQualityPath, result.Annotations); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if err := ci.WriteDotenv(ciDotenvPath, outputs); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	default:
+		for _, key := range []string{"risk-level", "findings", "new-findings", "critical-count", "high-count", "medium-count", "low-count", "failed"} {
+			fmt.Printf("%s=%s\n", key, outputs[key])
+		}
+	}
+
+	if result.Failed {
+		fmt.Fprintf(os.Stderr, "Upgrade readiness check failed: findings at or above %s severity\n", failOn)
+		exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// matchingClusters returns the clusters matching the --selector flag
+func matchingClusters(ctx context.Context, store *inventory.Store) []*ent.Cluster {
+	parsed, err := inventory.ParseSelector(selector)
+	if err != nil {
+		fatal(usageErrorf("Invalid --selector value: %w", err))
+	}
+
+	clusters, err := store.ListClustersMatching(ctx, parsed)
+	if err != nil {
+		fatalf("Failed to list clusters: %v", err)
+	}
+	return clusters
+}
+
+// selectCluster resolves the --selector flag to the ID of the single matching cluster
+func selectCluster(ctx context.Context, store *inventory.Store) string {
+	clusters := matchingClusters(ctx, store)
+	switch len(clusters) {
+	case 0:
+		fatal(noDataErrorf("No cluster matches selector %q", selector))
+	case 1:
+		return clusters[0].ID
+	}
+
+	ids := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		ids[i] = cluster.ID
+	}
+	fatal(usageErrorf("Selector %q matches %d clusters (%s); narrow it down or use 'fleet impact --selector'", selector, len(clusters), strings.Join(ids, ", ")))
+	return ""
+}
+
+// listClusters prints the clusters matching the --selector flag
+func listClusters(ctx context.Context, store *inventory.Store) {
+	clusters := matchingClusters(ctx, store)
+	infos := make([]api.ClusterInfo, len(clusters))
+	for i, cluster := range clusters {
+		infos[i] = api.NewClusterInfo(cluster)
+	}
+	printClusters(infos)
+}
+
+// printClusters prints the clusters matching the --selector flag as a table
+func printClusters(clusters []api.ClusterInfo) {
+	if len(clusters) == 0 {
+		fmt.Printf("No cluster matches selector %q\n", selector)
+		return
+	}
+
+	fmt.Printf("%-20s %-20s %-12s %-12s %s\n", "CLUSTER", "NAME", "VERSION", "SCAN", "LABELS")
+	for _, cluster := range clusters {
+		fmt.Printf("%-20s %-20s %-12s %-12s %s\n", cluster.ID, cluster.Name, cluster.Version, scanStatus(cluster.Incomplete, cluster.ScanErrors), inventory.FormatLabels(cluster.Labels))
+	}
+}
+
+// scanStatus summarizes whether a cluster's last scan completed
+func scanStatus(incomplete string, scanErrors map[string]string) string {
+	switch {
+	case incomplete != "":
+		return "incomplete"
+	case len(scanErrors) > 0:
+		return "partial"
+	default:
+		return "complete"
+	}
+}
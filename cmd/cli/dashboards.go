@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dashboardTitle  string
+	dashboardUID    string
+	dashboardOutput string
+)
+
+var dashboardsCmd = &cobra.Command{
+	Use:   "dashboards",
+	Short: "Generate monitoring dashboards",
+}
+
+var dashboardsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a Grafana dashboard for the server's Prometheus metrics",
+	Long: `Writes a Grafana dashboard JSON for the metrics kube-upgrade-server exposes on /metrics: fleet
+readiness, findings by severity and a row of panels per cluster. Import it in Grafana or provision it
+from a file; the Prometheus data source is picked on the dashboard.`,
+	Args: cobra.NoArgs,
+	Run:  runDashboardsGenerate,
+}
+
+func init() {
+	dashboardsGenerateCmd.Flags().StringVar(&dashboardTitle, "title", "Kubernetes Upgrade Readiness", "Dashboard title")
+	dashboardsGenerateCmd.Flags().StringVar(&dashboardUID, "uid", "kube-upgrade-advisor", "Dashboard UID; keep it stable to update an imported dashboard")
+	dashboardsGenerateCmd.Flags().StringVarP(&dashboardOutput, "output", "o", "-", "File to write, or - for stdout")
+
+	dashboardsCmd.AddCommand(dashboardsGenerateCmd)
+	rootCmd.AddCommand(dashboardsCmd)
+}
+
+func runDashboardsGenerate(cmd *cobra.Command, args []string) {
+	dashboard := metrics.Dashboard(metrics.DashboardOptions{Title: dashboardTitle, UID: dashboardUID})
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		fatalf("Failed to render dashboard: %v", err)
+	}
+	data = append(data, '\n')
+
+	if dashboardOutput == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(dashboardOutput, data, 0o644); err != nil {
+		fatalf("Failed to write %s: %v", dashboardOutput, err)
+	}
+	fmt.Printf("Wrote Grafana dashboard to %s\n", dashboardOutput)
+}
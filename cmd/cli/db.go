@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database: schema migrations, backups and retention",
+	Long: `The database schema is versioned. A new database is created at the current version, but an existing
+one is never altered implicitly: commands refuse a database older than the binary until it is migrated
+with 'db migrate', and a database migrated by a newer binary is refused altogether. Assessments
+accumulate with every impact analysis; 'db prune' deletes old ones and 'db vacuum' shrinks the file.`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version of the database and pending migrations",
+	Args:  cobra.NoArgs,
+	Run:   runDBStatus,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations; back the database up first",
+	Args:  cobra.NoArgs,
+	Run:   runDBMigrate,
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Write a consistent copy of the database, also while it is in use",
+	Args:  cobra.ExactArgs(1),
+	Run:   runDBBackup,
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Rebuild the database file to return the space of deleted records",
+	Args:  cobra.NoArgs,
+	Run:   runDBVacuum,
+}
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old assessments and draft plans",
+	Long: `Deletes the assessments beyond --max-assessments per cluster or older than --max-age, with the draft
+plans generated from them, and draft plans older than --max-age. Assessments that approved or executed
+plans were generated from, and the audit log, are kept. Run 'db vacuum' afterwards to shrink the file.`,
+	Args: cobra.NoArgs,
+	Run:  runDBPrune,
+}
+
+var (
+	pruneMaxAssessments int
+	pruneMaxAge         time.Duration
+)
+
+func init() {
+	dbPruneCmd.Flags().IntVar(&pruneMaxAssessments, "max-assessments", 0, "Assessments kept per cluster, newest first; 0 keeps all")
+	dbPruneCmd.Flags().DurationVar(&pruneMaxAge, "max-age", 0, "Delete assessments and draft plans older than this (e.g. 2160h); 0 keeps all")
+
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	dbCmd.AddCommand(dbPruneCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	migrator, err := inventory.NewMigrator(dbPath)
+	if err != nil {
+		fatalf("Failed to open database: %v", err)
+	}
+	defer migrator.Close()
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		fatalf("Failed to read schema version: %v", err)
+	}
+
+	fmt.Printf("Database: %s\n", dbPath)
+	switch {
+	case status.Empty:
+		fmt.Printf("Schema version: none, the database is empty (this binary: %d)\n", status.Latest)
+	case status.Current == 0:
+		fmt.Printf("Schema version: unversioned, created before schema versioning (this binary: %d)\n", status.Latest)
+	default:
+		fmt.Printf("Schema version: %d (this binary: %d)\n", status.Current, status.Latest)
+	}
+	if status.Current > status.Latest {
+		fmt.Println("The database was migrated by a newer kube-upgrade-advisor; upgrade this binary to use it.")
+	}
+
+	if len(status.Applied) > 0 {
+		fmt.Println("\nApplied migrations:")
+		for _, migration := range status.Applied {
+			fmt.Printf("  %d  %s  %s\n", migration.Version, migration.AppliedAt.Local().Format("2006-01-02 15:04:05"), migration.Description)
+		}
+	}
+	if len(status.Pending) > 0 {
+		fmt.Println("\nPending migrations:")
+		for _, migration := range status.Pending {
+			fmt.Printf("  %d  %s\n", migration.Version, migration.Description)
+		}
+		if !status.Empty {
+			fmt.Println("\nBack up the database, then run 'kube-upgrade-advisor db migrate'.")
+		}
+	}
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	migrator, err := inventory.NewMigrator(dbPath)
+	if err != nil {
+		fatalf("Failed to open database: %v", err)
+	}
+	defer migrator.Close()
+
+	applied, err := migrator.Migrate(ctx)
+	for _, migration := range applied {
+		fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+	}
+	if err != nil {
+		fatalf("Failed to migrate database: %v", err)
+	}
+	if len(applied) == 0 {
+		fmt.Printf("Database is up to date at schema version %d\n", inventory.SchemaVersion())
+		return
+	}
+	fmt.Printf("Database is at schema version %d\n", inventory.SchemaVersion())
+}
+
+func runDBBackup(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	migrator, err := inventory.NewMigrator(dbPath)
+	if err != nil {
+		fatalf("Failed to open database: %v", err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.Backup(ctx, args[0]); err != nil {
+		fatalf("Failed to back up database: %v", err)
+	}
+	fmt.Printf("Backed up %s to %s (%s)\n", dbPath, args[0], fileSize(args[0]))
+}
+
+func runDBVacuum(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	migrator, err := inventory.NewMigrator(dbPath)
+	if err != nil {
+		fatalf("Failed to open database: %v", err)
+	}
+	defer migrator.Close()
+
+	before := fileSize(dbPath)
+	if err := migrator.Vacuum(ctx); err != nil {
+		fatalf("Failed to vacuum database: %v", err)
+	}
+	fmt.Printf("Vacuumed %s: %s -> %s\n", dbPath, before, fileSize(dbPath))
+}
+
+func runDBPrune(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if pruneMaxAssessments < 0 || pruneMaxAge < 0 {
+		fatal(usageErrorf("Invalid retention: --max-assessments and --max-age must not be negative"))
+	}
+	policy := inventory.RetentionPolicy{MaxAssessments: pruneMaxAssessments, MaxAge: pruneMaxAge}
+	if !policy.Enabled() {
+		fatal(usageErrorf("Nothing to prune: set --max-assessments or --max-age"))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	result, err := store.Prune(ctx, policy)
+	if err != nil {
+		fatalf("Failed to prune database: %v", err)
+	}
+	fmt.Printf("Deleted %d assessments and %d draft plans\n", result.Assessments, result.Plans)
+}
+
+// openStore opens the --db, read-only with --read-only
+func openStore() (*inventory.Store, error) {
+	if readOnlyDB {
+		return inventory.NewReadOnlyStore(dbPath)
+	}
+	return inventory.NewStore(dbPath)
+}
+
+// fileSize returns the size of a file for display, e.g. "12.4 MB"
+func fileSize(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "size unknown"
+	}
+	size := float64(info.Size())
+	for _, unit := range []string{"B", "KB", "MB", "GB"} {
+		if size < 1024 || unit == "GB" {
+			if unit == "B" {
+				return fmt.Sprintf("%.0f %s", size, unit)
+			}
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+		size /= 1024
+	}
+	return ""
+}
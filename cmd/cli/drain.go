@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/spf13/cobra"
+)
+
+var drainCheckCmd = &cobra.Command{
+	Use:   "drain-check",
+	Short: "Simulate draining every node",
+	Long:  `Simulates draining each node (respecting PDBs, local storage and DaemonSets) without evicting anything, and reports nodes that would block a rolling upgrade`,
+	Run:   runDrainCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(drainCheckCmd)
+}
+
+func runDrainCheck(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	fmt.Println("=== Kube Upgrade Advisor - Drain Check ===\n")
+
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		fatalf("Failed to create kube client: %v", err)
+	}
+
+	state, err := kubeClient.CollectLiveState(ctx)
+	if err != nil {
+		fatalf("Failed to collect live cluster state: %v", err)
+	}
+
+	results := analysis.SimulateNodeDrains(state)
+
+	blocked := 0
+	for _, node := range results {
+		status := "✅ drainable"
+		if !node.Drainable {
+			status = "❌ blocked"
+			blocked++
+		}
+		if node.AlreadyCordoned {
+			status += " (cordoned)"
+		}
+
+		fmt.Printf("%s: %s\n", node.Node, status)
+		fmt.Printf("   Evictable Pods: %d, DaemonSet Pods: %d\n", node.EvictablePods, node.DaemonSetPods)
+		for _, blocker := range node.Blockers {
+			fmt.Printf("   - %s/%s [%s] %s\n", blocker.Namespace, blocker.Pod, blocker.Reason, blocker.Detail)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Nodes checked: %d, blocked: %d\n", len(results), blocked)
+}
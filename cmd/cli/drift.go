@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+)
+
+// driftPath is the folder of Flux HelmReleases and Argo CD Applications to compare releases against
+var driftPath string
+
+// releaseDrift compares every Helm release declared under path with the release deployed in the
+// current cluster, rendering charts for the cluster's Kubernetes version. A release that can't be
+// compared is reported with its error, not dropped.
+func releaseDrift(ctx context.Context, path, kubeVersion string, progress io.Writer) []analysis.ReleaseDrift {
+	parser := manifests.NewParser()
+	parser.Progress = progress
+	parser.ChartCache = chartCache()
+	resources, err := parser.ParseInput(path)
+	if err != nil {
+		fatalf("Failed to parse GitOps manifests: %v", err)
+	}
+	sources := manifests.ReleaseSources(resources)
+	if len(sources) == 0 {
+		log.Printf("Warning: no Flux HelmRelease or Argo CD Application with a chart found in %s", path)
+		return nil
+	}
+
+	helmClient, err := newHelmClient("")
+	if err != nil {
+		fatalf("Failed to create Helm client: %v", err)
+	}
+
+	drifts := make([]analysis.ReleaseDrift, 0, len(sources))
+	for _, source := range sources {
+		fmt.Fprintf(progress, "Comparing Helm release %s with %s...\n", source, source.SourceFile)
+		drift := analysis.ReleaseDrift{
+			Release:       source.Name,
+			Namespace:     source.Namespace,
+			Chart:         source.Chart,
+			PinnedVersion: source.Version,
+			Origin:        source.Origin,
+			SourceFile:    source.SourceFile,
+		}
+		if err := compareRelease(ctx, helmClient, parser, source, kubeVersion, &drift); err != nil {
+			drift.Error = err.Error()
+			drift.Severity = analysis.ImpactNone
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+// compareRelease fills in the drift of one release between its deployed and rendered manifests. The
+// deployed release is read from the namespace Helm stores it in.
+func compareRelease(ctx context.Context, helmClient *cluster.HelmClient, parser *manifests.Parser, source manifests.ReleaseSource, kubeVersion string, drift *analysis.ReleaseDrift) error {
+	release, err := helmClient.GetRelease(ctx, source.Name, source.StorageNamespace)
+	if err != nil {
+		return err
+	}
+	drift.DeployedVersion = release.ChartVersion
+
+	manifest, err := helmClient.GetReleaseManifest(ctx, source.Name, source.StorageNamespace)
+	if err != nil {
+		return err
+	}
+	deployed, err := parser.ParseStream(strings.NewReader(manifest))
+	if err != nil {
+		return fmt.Errorf("failed to parse the deployed manifest: %w", err)
+	}
+	desired, err := parser.RenderRelease(source, kubeVersion)
+	if err != nil {
+		return fmt.Errorf("failed to render chart %s: %w", source.Chart, err)
+	}
+
+	analysis.CompareRelease(drift, driftObjects(deployed), driftObjects(desired))
+	return nil
+}
+
+func driftObjects(resources []manifests.Resource) []map[string]interface{} {
+	objects := make([]map[string]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		objects = append(objects, resource.Object)
+	}
+	return objects
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	effortHours   map[string]string
+	effortCSVPath string
+)
+
+var planEffortCmd = &cobra.Command{
+	Use:   "effort <id>",
+	Short: "Estimate the engineering hours of a stored plan per team",
+	Long: `Estimates the engineering hours of a stored plan: each step is weighed by its type, scaled by its impact,
+and attributed to the teams owning the namespaces and releases it changes, from the --owners map. Cluster-wide
+steps go to the map's default team, "platform" unless set. With --csv, writes a row per step and team with
+team and grand totals, for staffing spreadsheets.
+
+Example --owners file:
+  default: platform
+  namespaces:
+    payments-*: payments
+    monitoring: observability
+  releases:
+    shared/ingress-nginx: networking`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPlanEffort,
+}
+
+func init() {
+	planEffortCmd.Flags().StringVar(&ownersPath, "owners", "", "YAML file mapping namespaces and releases to owning teams")
+	planEffortCmd.Flags().StringToStringVar(&effortHours, "hours", nil, "Hours per step type at medium impact, e.g. chart_upgrade=6,api_migration=2")
+	planEffortCmd.Flags().StringVar(&effortCSVPath, "csv", "", "CSV file to export the estimate to, or - for stdout")
+	planCmd.AddCommand(planEffortCmd)
+}
+
+func runPlanEffort(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validatePlanFormat(planFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fatal(usageErrorf("Invalid plan ID %q: %w", args[0], err))
+	}
+
+	weights, err := planner.ParseEffortWeights(effortHours)
+	if err != nil {
+		fatal(usageErrorf("Invalid --hours value: %w", err))
+	}
+
+	var ownership *analysis.OwnershipMap
+	if ownersPath != "" {
+		if ownership, err = analysis.LoadOwnershipMap(ownersPath); err != nil {
+			fatal(usageErrorf("Invalid --owners value: %w", err))
+		}
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	stored, err := store.GetPlan(ctx, id)
+	if err != nil {
+		fatalf("Failed to get plan %d: %v", id, err)
+	}
+
+	var plan planner.UpgradePlan
+	if err := json.Unmarshal([]byte(stored.Document), &plan); err != nil {
+		fatalf("Failed to unmarshal plan %d: %v", id, err)
+	}
+
+	estimate := planner.EstimateEffort(&plan, ownership, weights)
+
+	if effortCSVPath != "" {
+		var buf bytes.Buffer
+		if err := estimate.WriteCSV(&buf); err != nil {
+			fatalf("Failed to export estimate: %v", err)
+		}
+		if effortCSVPath == "-" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := os.WriteFile(effortCSVPath, buf.Bytes(), 0o644); err != nil {
+			fatalf("Failed to write %s: %v", effortCSVPath, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote estimate to %s\n", effortCSVPath)
+	}
+
+	if planFormat != "text" {
+		if err := printStructured(estimate, planFormat); err != nil {
+			fatalf("Failed to print estimate: %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("Effort estimate for plan %d (%s -> %s)\n\n", stored.ID, estimate.FromVersion, estimate.ToVersion)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TEAM\tSTEPS\tHOURS")
+	for _, team := range estimate.Teams {
+		fmt.Fprintf(writer, "%s\t%d\t%.1f\n", team.Team, team.Steps, team.Hours)
+	}
+	fmt.Fprintf(writer, "Total\t%d\t%.1f\n", len(plan.Steps), estimate.TotalHours)
+	writer.Flush()
+	if ownersPath == "" {
+		fmt.Printf("\nAll steps are attributed to %s; pass --owners to split them by team\n", analysis.DefaultOwner)
+	}
+}
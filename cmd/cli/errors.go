@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// Exit codes of failed commands, so scripts can branch on the failure mode
+const (
+	exitFailure       = 1
+	exitUsage         = 2
+	exitConnection    = 3
+	exitPermission    = 4
+	exitKnowledgeBase = 5
+	exitNoData        = 6
+	exitInterrupted   = 130
+)
+
+// cliError is a failure of a command with its exit code and a hint on how to resolve it
+type cliError struct {
+	code int
+	hint string
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf is an invalid flag or argument
+func usageErrorf(format string, args ...interface{}) error {
+	return &cliError{code: exitUsage, hint: "Run with --help for usage", err: fmt.Errorf(format, args...)}
+}
+
+// noDataErrorf is a missing cluster, assessment or plan
+func noDataErrorf(format string, args ...interface{}) error {
+	return &cliError{code: exitNoData, hint: noDataHint, err: fmt.Errorf(format, args...)}
+}
+
+const (
+	connectionHint = "Check that the cluster (--kubeconfig, --context) or --server is reachable; raise --timeout for slow clusters"
+	permissionHint = "The credentials lack permissions; check them with 'kubectl auth can-i --list' or the server's access rules"
+	knowledgeHint  = "Run from the directory containing knowledge-base/, pass --api-knowledge, or pull one with 'kube-upgrade-advisor knowledge pull'"
+	noDataHint     = "Run 'kube-upgrade-advisor scan' first, or check --cluster and --db"
+	migrateHint    = "Back up the database, then run 'kube-upgrade-advisor db migrate'"
+	readOnlyHint   = "The command writes to the database; run it without --read-only against a writable --db"
+	schemaHint     = "The database was migrated by a newer kube-upgrade-advisor; upgrade this binary or point --db at another database"
+	credentialHint = "The kubeconfig's credential plugin failed; check it is installed and on the PATH and log in again, e.g. 'gcloud auth login', 'aws sso login' or 'kubelogin'"
+)
+
+// classifyError maps an error to its exit code and hint, from an explicit cliError in its chain or
+// from its cause
+func classifyError(err error) *cliError {
+	var cliErr *cliError
+	if errors.As(err, &cliErr) {
+		return cliErr
+	}
+
+	var statusErr *api.StatusError
+	var pathErr *fs.PathError
+	var urlErr *url.Error
+	var netErr *net.OpError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &cliError{code: exitInterrupted, err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &cliError{code: exitConnection, hint: connectionHint, err: err}
+	case cluster.IsCredentialError(err):
+		return &cliError{code: exitPermission, hint: credentialHint, err: err}
+	case cluster.IsPermissionError(err):
+		return &cliError{code: exitPermission, hint: permissionHint, err: err}
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+		return &cliError{code: exitPermission, hint: permissionHint, err: err}
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		return &cliError{code: exitNoData, hint: noDataHint, err: err}
+	case errors.Is(err, inventory.ErrMigrationRequired):
+		return &cliError{code: exitFailure, hint: migrateHint, err: err}
+	case errors.Is(err, inventory.ErrSchemaTooNew):
+		return &cliError{code: exitFailure, hint: schemaHint, err: err}
+	case errors.Is(err, inventory.ErrReadOnly):
+		return &cliError{code: exitFailure, hint: readOnlyHint, err: err}
+	case ent.IsNotFound(err):
+		return &cliError{code: exitNoData, hint: noDataHint, err: err}
+	case errors.As(err, &pathErr) && errors.Is(err, fs.ErrNotExist) && isKnowledgePath(pathErr.Path):
+		return &cliError{code: exitKnowledgeBase, hint: knowledgeHint, err: err}
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return &cliError{code: exitConnection, hint: connectionHint, err: err}
+	}
+	return &cliError{code: exitFailure, err: err}
+}
+
+// isKnowledgePath reports whether a file is part of a knowledge base
+func isKnowledgePath(path string) bool {
+	return path == apiKnowledgePath || filepath.Base(filepath.Dir(path)) == "knowledge-base"
+}
+
+// fatal logs a failed command with its hint and exits with the code of its error
+func fatal(err error) {
+	classified := classifyError(err)
+	log.Print(err)
+	auditFailure(err.Error())
+	exitWith(classified)
+}
+
+// fatalf logs like log.Fatalf and exits with the code of the first error among args
+func fatalf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	auditFailure(fmt.Sprintf(format, args...))
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			exitWith(classifyError(err))
+		}
+	}
+	exit(exitFailure)
+}
+
+// exitWith prints the hint of a classified error and exits with its code
+func exitWith(classified *cliError) {
+	if classified.hint != "" {
+		log.Printf("Hint: %s", classified.hint)
+	}
+	exit(classified.code)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// eventsNamespace is the namespace of the event anchor; no events are emitted when empty
+var eventsNamespace string
+
+// eventTimeout bounds emitting an event, which also runs after an interrupted scan
+const eventTimeout = 30 * time.Second
+
+// emitEvent records a Kubernetes Event on the anchor ConfigMap in --events-namespace. Events are
+// best effort: a failure is only a warning.
+func emitEvent(ctx context.Context, eventType, reason, message string) {
+	ctx, cancel := context.WithTimeout(ctx, eventTimeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient()
+	if err == nil {
+		err = kubeClient.NewEventRecorder(eventsNamespace).Emit(ctx, eventType, reason, message)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to emit %s event: %v", reason, err)
+	}
+}
+
+// emitRiskChange emits a RiskLevelChanged event when the overall risk differs from the previous
+// stored assessment for the same cluster and target version; a raised risk is a warning
+func emitRiskChange(ctx context.Context, previous *ent.Assessment, assessment *analysis.ImpactAssessment) {
+	if previous == nil || analysis.ImpactLevel(previous.OverallRisk) == assessment.OverallRisk {
+		return
+	}
+	eventType := cluster.EventNormal
+	if assessment.OverallRisk.AtLeast(analysis.ImpactLevel(previous.OverallRisk)) {
+		eventType = cluster.EventWarning
+	}
+	emitEvent(ctx, eventType, cluster.EventReasonRiskLevelChanged,
+		fmt.Sprintf("Upgrade risk of cluster %s for Kubernetes %s changed from %s to %s (%d issues)",
+			assessment.ClusterID, assessment.TargetVersion, previous.OverallRisk, assessment.OverallRisk, assessment.TotalIssues))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainClusterID string
+	explainFormat    string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <finding-id>",
+	Short: "Explain a finding of the last assessment",
+	Long: `Explains one finding of the last stored assessment of a cluster for a target version: what breaks
+if it is left, the affected objects, the remediation options best first and the plan steps generated
+for it. The finding ID is the rule ID and resource separated by "|", as in baseline files, e.g.
+"KUA-API-001|batch/v1beta1/CronJob", or just the rule ID when the assessment has one finding of it.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runExplain,
+}
+
+func init() {
+	explainCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	explainCmd.MarkFlagRequired("target")
+	explainCmd.Flags().StringVar(&explainClusterID, "cluster", "cluster-1", "Cluster ID in the database")
+	explainCmd.Flags().StringVar(&explainFormat, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(explainCmd)
+}
+
+// explainedFinding is the structured output of explain
+type explainedFinding struct {
+	*analysis.Explanation
+	PlanSteps []planner.UpgradeStep `json:"planSteps,omitempty"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if explainFormat != "text" && explainFormat != "json" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json)", explainFormat))
+	}
+
+	assessment, plan, err := lastAssessment(ctx, explainClusterID, targetVersion)
+	if err != nil {
+		fatal(err)
+	}
+
+	matches := assessment.FindFindings(args[0])
+	switch {
+	case len(matches) == 0:
+		fatal(noDataErrorf("No finding %s in the last assessment of %s targeting %s", args[0], explainClusterID, targetVersion))
+	case len(matches) > 1:
+		ids := make([]string, 0, len(matches))
+		for _, finding := range matches {
+			ids = append(ids, finding.Key())
+		}
+		fatal(usageErrorf("%d findings match %s, pass one of:\n  %s", len(matches), args[0], strings.Join(ids, "\n  ")))
+	}
+
+	explained := explainedFinding{Explanation: matches[0].Explain()}
+	if plan != nil {
+		explained.PlanSteps = plan.StepsFor(matches[0])
+	}
+
+	if explainFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(explained); err != nil {
+			fatalf("Failed to encode explanation: %v", err)
+		}
+		return
+	}
+	printExplanation(explained, plan != nil)
+}
+
+// lastAssessment loads the last stored assessment of a cluster for a target version and its
+// newest plan, from the --server when set
+func lastAssessment(ctx context.Context, clusterID, target string) (*analysis.ImpactAssessment, *planner.UpgradePlan, error) {
+	if serverURL != "" {
+		client := newAPIClient()
+		assessments, err := client.Assessments(ctx, clusterID, target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list assessments: %w", err)
+		}
+		if len(assessments) == 0 {
+			return nil, nil, noDataErrorf("no stored assessment for %s targeting %s, run 'kube-upgrade-advisor impact --target %s' first", clusterID, target, target)
+		}
+		response, err := client.Assessment(ctx, assessments[0].ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch assessment %d: %w", assessments[0].ID, err)
+		}
+		return response.ImpactAssessment, response.UpgradePlan, nil
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create store: %w", err)
+	}
+	defer store.Close()
+
+	assessment, plan, _, err := loadCachedAssessment(ctx, store, clusterID, target)
+	return assessment, plan, err
+}
+
+// printExplanation prints an explanation as text
+func printExplanation(explained explainedFinding, planned bool) {
+	finding := explained.Finding
+	fmt.Printf("=== %s: %s ===\n", finding.RuleID, explained.Rule.Title)
+	fmt.Printf("Resource: %s\n", finding.Resource)
+	fmt.Printf("Severity: %s\n", finding.Severity)
+	fmt.Printf("Category: %s\n", explained.Rule.Category)
+	fmt.Printf("Summary: %s\n", finding.Summary)
+
+	fmt.Println("\nWhy it matters:")
+	if explained.Consequence != "" {
+		fmt.Printf("  %s\n", explained.Consequence)
+	}
+	for _, detail := range explained.Details {
+		fmt.Printf("  - %s\n", detail)
+	}
+
+	if len(explained.Affected) > 0 {
+		fmt.Printf("\nAffected objects (%d):\n", len(explained.Affected))
+		for _, affected := range explained.Affected {
+			fmt.Printf("  - %s\n", affected)
+		}
+	}
+
+	fmt.Println("\nRemediation options (best first):")
+	for i, option := range explained.Options {
+		fmt.Printf("  %d. %s\n", i+1, option)
+	}
+	if explained.Manual {
+		fmt.Println("  Manual review required: the fix can't be fully automated")
+	}
+
+	fmt.Println("\nPlan steps:")
+	switch {
+	case !planned:
+		fmt.Printf("  No plan stored for this assessment. Run 'kube-upgrade-advisor plan --target %s' to generate one.\n", targetVersion)
+	case len(explained.PlanSteps) == 0:
+		fmt.Println("  No step of the plan addresses this finding directly")
+	}
+	for _, step := range explained.PlanSteps {
+		fmt.Printf("  [%s] %s\n", step.ID, step.Description)
+		for _, action := range step.Actions {
+			if action.Command != "" {
+				fmt.Printf("      $ %s\n", action.Command)
+			}
+		}
+	}
+
+	fmt.Println("\nDocs:")
+	fmt.Printf("  %s\n", explained.Rule.DocsURL())
+	for _, url := range finding.UpstreamDocs {
+		fmt.Printf("  %s\n", url)
+	}
+}
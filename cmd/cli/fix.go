@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/convert"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+	"github.com/spf13/cobra"
+)
+
+var fixWrite bool
+
+var fixCmd = &cobra.Command{
+	Use:   "fix <path>",
+	Short: "Convert manifests from removed APIs with the built-in converters",
+	Long: `Rewrites resources of removed APIs that have a built-in converter to their replacement API:
+Ingress (extensions/v1beta1, networking.k8s.io/v1beta1), PodDisruptionBudget (policy/v1beta1),
+CronJob (batch/v1beta1) and HorizontalPodAutoscaler (autoscaling/v2beta2). Deprecated node
+labels in the node selectors, affinities and topology spread constraints of pod templates, e.g.
+beta.kubernetes.io/os, are rewritten to their replacements from knowledge-base/pod-fields.json.
+
+Files and folders are only reported unless --write is set. With - the YAML stream on stdin is
+converted to stdout. Each converted resource is listed with a diff of its fields: ~ renamed or
+modified, + added, - removed, and ! for fields the target version doesn't have, which need manual
+attention. Converted documents are re-encoded but keep their comments and key order.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runFix,
+}
+
+func init() {
+	fixCmd.Flags().BoolVar(&fixWrite, "write", false, "Write converted files in place")
+
+	rootCmd.AddCommand(fixCmd)
+}
+
+func runFix(cmd *cobra.Command, args []string) {
+	path := args[0]
+	rewrites := labelRewrites()
+
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatalf("Failed to read stdin: %v", err)
+		}
+		converted, results, err := convert.ConvertYAML(data, rewrites)
+		if err != nil {
+			fatalf("Failed to convert stdin: %v", err)
+		}
+		if _, err := os.Stdout.Write(converted); err != nil {
+			fatalf("Failed to write stdout: %v", err)
+		}
+		printFixResults(os.Stderr, "-", results)
+		return
+	}
+
+	// only rewriting files changes anything worth recording
+	if fixWrite {
+		startAudit(cmd, args, inventory.AuditFix, "")
+	}
+
+	files, err := manifests.NewParser().YAMLFiles(path)
+	if err != nil {
+		fatalf("Failed to list manifests: %v", err)
+	}
+
+	convertedResources, convertedFiles := 0, 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("Warning: failed to read %s: %v", file, err)
+			continue
+		}
+		converted, results, err := convert.ConvertYAML(data, rewrites)
+		if err != nil {
+			log.Printf("Warning: failed to convert %s: %v", file, err)
+			continue
+		}
+		if len(results) == 0 {
+			continue
+		}
+
+		printFixResults(os.Stdout, file, results)
+		convertedResources += len(results)
+		convertedFiles++
+
+		if fixWrite {
+			info, err := os.Stat(file)
+			if err != nil {
+				fatalf("Failed to stat %s: %v", file, err)
+			}
+			if err := os.WriteFile(file, converted, info.Mode().Perm()); err != nil {
+				fatalf("Failed to write %s: %v", file, err)
+			}
+		}
+	}
+
+	switch {
+	case convertedResources == 0:
+		fmt.Println("No resources to convert")
+	case fixWrite:
+		fmt.Printf("\nConverted %d resources in %d files\n", convertedResources, convertedFiles)
+		auditSummary("converted %d resources in %d files", convertedResources, convertedFiles)
+	default:
+		fmt.Printf("\n%d resources in %d files can be converted. Run again with --write to apply.\n", convertedResources, convertedFiles)
+	}
+}
+
+// labelRewrites loads the replacements of deprecated node labels; without them labels are kept
+func labelRewrites() map[string]string {
+	podFieldKB := knowledge.NewPodFieldKnowledgeBase()
+	if err := podFieldKB.LoadFromFile(knowledgeFile("pod-fields.json")); err != nil {
+		log.Printf("Warning: skipping node label rewrites: %v", err)
+		return nil
+	}
+	return podFieldKB.Rewrites()
+}
+
+// printFixResults prints the resources converted in a file with a diff of their fields; lines
+// starting with ! and notes need manual attention
+func printFixResults(w io.Writer, file string, results []*convert.Result) {
+	for _, result := range results {
+		if result.FromAPIVersion == result.ToAPIVersion {
+			fmt.Fprintf(w, "%s: %s node labels\n", file, result.Resource())
+		} else {
+			fmt.Fprintf(w, "%s: %s %s -> %s\n", file, result.Resource(), result.FromAPIVersion, result.ToAPIVersion)
+		}
+		for _, change := range result.Changes {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+		for _, note := range result.Notes {
+			fmt.Fprintf(w, "  note: %s\n", note)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/fleet"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	environmentsPath string
+	fleetFormat      string
+	fleetSort        string
+	fleetMinClusters int
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Analyze and plan upgrades across all clusters",
+	Long:  `Runs the upgrade analysis over multiple clusters in the database`,
+}
+
+var fleetPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Generate a fleet plan that upgrades environments in waves",
+	Long: `Assesses every cluster listed in the environments file and sequences their upgrades in waves,
+in promotion order (e.g. dev, then staging after a soak time, then prod)`,
+	Args: cobra.NoArgs,
+	Run:  runFleetPlan,
+}
+
+var fleetImpactCmd = &cobra.Command{
+	Use:   "impact",
+	Short: "Analyze upgrade impact across every cluster in the database",
+	Long: `Runs the impact analysis over every scanned cluster and reports findings per cluster,
+blockers shared across the fleet and a readiness ranking`,
+	Args: cobra.NoArgs,
+	Run:  runFleetImpact,
+}
+
+func init() {
+	fleetCmd.PersistentFlags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	fleetCmd.MarkPersistentFlagRequired("target")
+	fleetCmd.PersistentFlags().StringVar(&fleetFormat, "format", "text", "Output format: text, json or yaml")
+	fleetCmd.PersistentFlags().StringVarP(&selector, "selector", "l", "", "Only include clusters matching a label selector, e.g. env=prod,region=eu")
+
+	fleetPlanCmd.Flags().StringVar(&environmentsPath, "environments", "environments.yaml", "YAML file mapping clusters to environments in promotion order")
+	fleetPlanCmd.Flags().StringVar(&customStepsPath, "custom-steps", "", "YAML file of operational steps to inject into each cluster plan")
+
+	fleetImpactCmd.Flags().StringVar(&fleetSort, "sort", "readiness", "Rank clusters by: "+strings.Join(fleet.SortKeys, ", "))
+	fleetImpactCmd.Flags().IntVar(&fleetMinClusters, "min-clusters", 2, "Report findings shared by at least this many clusters as common blockers")
+
+	fleetCmd.AddCommand(fleetPlanCmd)
+	fleetCmd.AddCommand(fleetImpactCmd)
+	rootCmd.AddCommand(fleetCmd)
+}
+
+func runFleetPlan(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validatePlanFormat(fleetFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	config, err := fleet.LoadConfig(environmentsPath)
+	if err != nil {
+		fatalf("Failed to load environments: %v", err)
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if selector != "" {
+		config = filterEnvironments(config, matchingClusters(ctx, store))
+	}
+
+	var clusterIDs []string
+	for _, env := range config.Environments {
+		clusterIDs = append(clusterIDs, env.Clusters...)
+	}
+
+	results := assessClusters(ctx, store, clusterIDs)
+	plan := fleet.BuildPlan(config, targetVersion, results)
+
+	if fleetFormat != "text" {
+		if err := printStructured(plan, fleetFormat); err != nil {
+			fatalf("Failed to print fleet plan: %v", err)
+		}
+		return
+	}
+	printFleetPlan(plan)
+}
+
+func runFleetImpact(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validatePlanFormat(fleetFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	clusters := matchingClusters(ctx, store)
+	if len(clusters) == 0 {
+		if selector != "" {
+			fmt.Printf("No cluster matches selector %q\n", selector)
+			return
+		}
+		fmt.Println("No clusters in the database. Run 'kube-upgrade-advisor scan' first.")
+		return
+	}
+
+	clusterIDs := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		clusterIDs[i] = cluster.ID
+	}
+
+	results := assessClusters(ctx, store, clusterIDs)
+	report := fleet.BuildReport(targetVersion, results, fleetMinClusters)
+	if err := report.Sort(fleetSort); err != nil {
+		fatal(usageErrorf("Invalid --sort value: %w", err))
+	}
+
+	if fleetFormat != "text" {
+		if err := printStructured(report, fleetFormat); err != nil {
+			fatalf("Failed to print fleet report: %v", err)
+		}
+		return
+	}
+	printFleetReport(report)
+}
+
+// filterEnvironments keeps only the given clusters in each environment, dropping environments left empty
+func filterEnvironments(config *fleet.Config, clusters []*ent.Cluster) *fleet.Config {
+	keep := make(map[string]bool, len(clusters))
+	for _, cluster := range clusters {
+		keep[cluster.ID] = true
+	}
+
+	filtered := &fleet.Config{}
+	for _, env := range config.Environments {
+		var ids []string
+		for _, id := range env.Clusters {
+			if keep[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			env.Clusters = ids
+			filtered.Environments = append(filtered.Environments, env)
+		}
+	}
+	if len(filtered.Environments) == 0 {
+		fatal(noDataErrorf("No cluster in %s matches selector %q", environmentsPath, selector))
+	}
+	return filtered
+}
+
+// assessClusters computes the impact and upgrade plan of each cluster and stores them. Clusters
+// that cannot be assessed (e.g. never scanned) are reported with an error instead of failing the run.
+func assessClusters(ctx context.Context, store *inventory.Store, clusterIDs []string) map[string]fleet.ClusterResult {
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, knowledgeFile("chart-matrix.json"), store)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+	analyzer.SetLocalizer(localizer)
+	loadKnowledge(analyzer)
+
+	options := planner.Options{ConversionTool: planner.DetectConversionTool()}
+	results := make(map[string]fleet.ClusterResult, len(clusterIDs))
+	for _, clusterID := range clusterIDs {
+		assessment, err := analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+		if err != nil {
+			results[clusterID] = fleet.ClusterResult{ClusterID: clusterID, Error: err.Error()}
+			continue
+		}
+
+		planGenerator := planner.NewPlanner()
+		planGenerator.SetLocalizer(localizer)
+		planGenerator.SetOptions(options)
+		setCustomSteps(planGenerator)
+		plan, err := planGenerator.GeneratePlan(assessment)
+		if err != nil {
+			log.Printf("Warning: failed to generate upgrade plan for %s: %v", clusterID, err)
+		}
+
+		if !store.ReadOnly() {
+			if stored, err := saveAssessment(ctx, store, assessment, false); err != nil {
+				log.Printf("Warning: failed to store assessment for %s: %v", clusterID, err)
+			} else if plan != nil {
+				if _, err := savePlan(ctx, store, clusterID, stored.ID, plan, options); err != nil {
+					log.Printf("Warning: failed to store plan for %s: %v", clusterID, err)
+				}
+			}
+		}
+
+		results[clusterID] = fleet.ClusterResult{ClusterID: clusterID, Assessment: assessment, Plan: plan}
+	}
+	return results
+}
+
+// printFleetPlan prints the waves of a fleet plan
+func printFleetPlan(plan *fleet.Plan) {
+	fmt.Printf("🚀 FLEET UPGRADE PLAN -> %s\n", plan.TargetVersion)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Clusters: %d | Waves: %d | Overall risk: %s | Estimated duration: %s\n\n",
+		plan.TotalClusters, len(plan.Waves), strings.ToUpper(string(plan.OverallRisk)), plan.Duration)
+
+	for _, wave := range plan.Waves {
+		fmt.Printf("Wave %d: %s (starts after %s, risk %s)\n", wave.Number, wave.Environment, wave.StartOffset, wave.OverallRisk)
+		for _, cluster := range wave.Clusters {
+			if cluster.Error != "" {
+				fmt.Printf("   ⛔ %-20s %s\n", cluster.ClusterID, cluster.Error)
+				continue
+			}
+			marker := "✅"
+			if cluster.Blocked {
+				marker = "⛔"
+			}
+			steps := 0
+			if cluster.Plan != nil {
+				steps = cluster.Plan.TotalSteps
+			}
+			fmt.Printf("   %s %-20s %s -> %s  risk %-8s issues %-3d steps %-3d ~%s\n",
+				marker, cluster.ClusterID, cluster.CurrentVersion, plan.TargetVersion, cluster.OverallRisk, cluster.TotalIssues, steps, cluster.Duration)
+		}
+		if wave.Gate != "" {
+			fmt.Printf("   ⏸  %s\n", wave.Gate)
+		}
+		fmt.Println()
+	}
+
+	if len(plan.Blocked) > 0 {
+		fmt.Printf("⚠️  Resolve critical findings or assessment errors before upgrading: %s\n", strings.Join(plan.Blocked, ", "))
+	}
+	fmt.Println("Run 'kube-upgrade-advisor plan show <id>' for the step-by-step plan of each cluster (see 'plan list').")
+}
+
+// printFleetReport prints the readiness ranking and the common blockers of a fleet
+func printFleetReport(report *fleet.Report) {
+	fmt.Printf("🌐 FLEET IMPACT -> %s\n", report.TargetVersion)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Clusters: %d | Findings: %d | Overall risk: %s\n\n",
+		len(report.Clusters), report.TotalFindings, strings.ToUpper(string(report.OverallRisk)))
+
+	fmt.Println("📊 READINESS RANKING")
+	fmt.Printf("   %-4s %-20s %-10s %-9s %-9s %-5s %-5s %-5s %-5s %s\n", "#", "CLUSTER", "VERSION", "RISK", "FINDINGS", "CRIT", "HIGH", "MED", "LOW", "READINESS")
+	for i, cluster := range report.Clusters {
+		if cluster.Error != "" {
+			fmt.Printf("   %-4d %-20s ⛔ %s\n", i+1, cluster.ClusterID, cluster.Error)
+			continue
+		}
+		fmt.Printf("   %-4d %-20s %-10s %-9s %-9d %-5d %-5d %-5d %-5d %d%%\n",
+			i+1, cluster.ClusterID, cluster.CurrentVersion, cluster.OverallRisk, cluster.TotalFindings,
+			cluster.Critical, cluster.High, cluster.Medium, cluster.Low, cluster.Readiness)
+	}
+	fmt.Println()
+
+	if len(report.CommonBlockers) == 0 {
+		fmt.Println("✅ No findings shared across clusters")
+		return
+	}
+	fmt.Println("🧱 COMMON BLOCKERS")
+	for _, blocker := range report.CommonBlockers {
+		fmt.Printf("   [%s] %s on %d clusters (%s): %s\n",
+			strings.ToUpper(string(blocker.Severity)), blocker.Subject, len(blocker.Clusters), blocker.RuleID, strings.Join(blocker.Clusters, ", "))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// saveAssessment persists an assessment so it can be reused with --from-cache and shown by the server
+func saveAssessment(ctx context.Context, store *inventory.Store, assessment *analysis.ImpactAssessment, live bool) (*ent.Assessment, error) {
+	snapshot, err := json.Marshal(assessment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal assessment: %w", err)
+	}
+
+	return store.SaveAssessment(ctx, assessment.ClusterID, inventory.AssessmentEntry{
+		TargetVersion:  assessment.TargetVersion,
+		CurrentVersion: assessment.CurrentVersion,
+		OverallRisk:    string(assessment.OverallRisk),
+		TotalIssues:    assessment.TotalIssues,
+		Live:           live,
+		Snapshot:       string(snapshot),
+	})
+}
+
+// savePlan persists a plan generated from a stored assessment
+func savePlan(ctx context.Context, store *inventory.Store, clusterID string, assessmentID int, plan *planner.UpgradePlan, options planner.Options) (*ent.Plan, error) {
+	document, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	return store.SavePlan(ctx, clusterID, inventory.PlanEntry{
+		TargetVersion:   plan.ToVersion,
+		FromVersion:     plan.FromVersion,
+		SkipBackup:      options.SkipBackup,
+		IncludeRollback: options.IncludeRollback,
+		TotalSteps:      plan.TotalSteps,
+		Timeline:        plan.Timeline,
+		Document:        string(document),
+		AssessmentID:    assessmentID,
+	})
+}
+
+// loadCachedAssessment returns the most recent stored assessment of a cluster for a target
+// version and the newest plan generated from it, if any
+func loadCachedAssessment(ctx context.Context, store *inventory.Store, clusterID, target string) (*analysis.ImpactAssessment, *planner.UpgradePlan, *ent.Assessment, error) {
+	stored, err := store.LatestAssessment(ctx, clusterID, target)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil, nil, noDataErrorf("no stored assessment for %s targeting %s, run 'kube-upgrade-advisor impact --target %s' first", clusterID, target, target)
+		}
+		return nil, nil, nil, fmt.Errorf("failed to load stored assessment: %w", err)
+	}
+
+	var assessment analysis.ImpactAssessment
+	if err := json.Unmarshal([]byte(stored.Snapshot), &assessment); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal stored assessment %d: %w", stored.ID, err)
+	}
+	// baseline comparisons are made per run
+	assessment.Baseline = nil
+
+	if len(stored.Edges.Plans) == 0 {
+		return &assessment, nil, stored, nil
+	}
+	var plan planner.UpgradePlan
+	if err := json.Unmarshal([]byte(stored.Edges.Plans[0].Document), &plan); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal stored plan %d: %w", stored.Edges.Plans[0].ID, err)
+	}
+	return &assessment, &plan, stored, nil
+}
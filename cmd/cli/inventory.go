@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/signature"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportPath      string
+	importClusterID string
+	importSignature string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Move inventories between databases",
+	Long:  `Exports a cluster's inventory as portable JSON and imports it into another database`,
+}
+
+var inventoryExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a cluster's inventory as JSON",
+	Args:  cobra.NoArgs,
+	Run:   runInventoryExport,
+}
+
+var inventoryImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import an inventory exported as JSON, or - for stdin",
+	Long:  `Imports an exported inventory or a kua-collector bundle, replacing the cluster's Helm releases, CRDs and manifest APIs`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runInventoryImport,
+}
+
+func init() {
+	inventoryExportCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	inventoryExportCmd.Flags().StringVarP(&exportPath, "output", "o", "-", "File to write, or - for stdout")
+	inventoryImportCmd.Flags().StringVar(&importClusterID, "cluster", "", "Import under this cluster ID instead of the exported one")
+	inventoryImportCmd.Flags().StringVar(&verifyKeyPath, "verify-key", os.Getenv("KUBE_ADVISOR_VERIFY_KEY"), "Public key the export must be signed with, e.g. cosign.pub (default: $KUBE_ADVISOR_VERIFY_KEY)")
+	inventoryImportCmd.Flags().StringVar(&importSignature, "signature", "", "Signature file of the export, as written by 'cosign sign-blob' (default: <file>.sig)")
+
+	inventoryCmd.AddCommand(inventoryExportCmd)
+	inventoryCmd.AddCommand(inventoryImportCmd)
+	rootCmd.AddCommand(inventoryCmd)
+}
+
+func runInventoryExport(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	export, err := store.ExportInventory(ctx, clusterIDFlag)
+	if err != nil {
+		fatalf("Failed to export inventory: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if exportPath != "-" {
+		file, err := os.Create(exportPath)
+		if err != nil {
+			fatalf("Failed to create %s: %v", exportPath, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := export.Write(out); err != nil {
+		fatalf("Failed to export inventory: %v", err)
+	}
+	if exportPath != "-" {
+		fmt.Printf("Exported %s (%d Helm releases, %d CRDs, %d manifest APIs) to %s\n",
+			export.Cluster.ID, len(export.HelmReleases), len(export.CRDs), len(export.ManifestAPIs), exportPath)
+	}
+}
+
+func runInventoryImport(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			fatalf("Failed to open %s: %v", args[0], err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		fatalf("Failed to read inventory: %v", err)
+	}
+
+	// A bundle written by kua-collector carries the inventory together with its signature
+	bundle, isBundle := inventory.ReadBundle(data)
+	if isBundle {
+		data = bundle.Inventory
+	}
+
+	if verifyKeyPath != "" {
+		verifier, err := signature.NewVerifier(verifyKeyPath)
+		if err != nil {
+			fatalf("Failed to load verification key: %v", err)
+		}
+		if isBundle && importSignature == "" {
+			if bundle.Signature == "" {
+				fatalf("Failed to verify inventory: the bundle is not signed")
+			}
+			err = verifier.Verify(data, []byte(bundle.Signature))
+		} else {
+			signaturePath := importSignature
+			if signaturePath == "" {
+				if args[0] == "-" {
+					fatal(usageErrorf("Failed to verify inventory: --signature is required when reading from stdin"))
+				}
+				signaturePath = args[0] + ".sig"
+			}
+			err = verifier.VerifyFile(data, signaturePath)
+		}
+		if err != nil {
+			fatalf("Failed to verify inventory: %v", err)
+		}
+	} else if isBundle && bundle.Signature != "" {
+		log.Printf("Warning: the bundle's signature is not verified; pass --verify-key to verify it")
+	}
+
+	export, err := inventory.ReadExport(bytes.NewReader(data))
+	if err != nil {
+		fatalf("Failed to read inventory: %v", err)
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	imported, err := store.ImportInventory(ctx, export, importClusterID)
+	if err != nil {
+		fatalf("Failed to import inventory: %v", err)
+	}
+	fmt.Printf("Imported %s (version %s): %d Helm releases, %d CRDs, %d manifest APIs\n",
+		imported.ID, imported.KubeVersion, len(export.HelmReleases), len(export.CRDs), len(export.ManifestAPIs))
+	fmt.Printf("Run 'kube-upgrade-advisor impact --cluster %s --target <version>' to analyze it\n", imported.ID)
+}
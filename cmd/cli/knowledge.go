@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/signature"
+	"github.com/spf13/cobra"
+)
+
+var (
+	knowledgeDir       string
+	knowledgeSources   string
+	knowledgeUpdatePin bool
+	verifyKeyPath      string
+)
+
+var knowledgeCmd = &cobra.Command{
+	Use:   "knowledge",
+	Short: "Distribute knowledge bases as OCI artifacts",
+	Long: `Publishes and pulls the knowledge base files (apis.json, chart-matrix.json, addons.json, ...) as OCI
+artifacts, so curated knowledge can be distributed through an internal registry. Pulled artifacts are
+pinned by digest in the sources file.`,
+}
+
+var knowledgePushCmd = &cobra.Command{
+	Use:   "push <oci://registry/repository:tag>",
+	Short: "Publish the knowledge base directory as an OCI artifact",
+	Args:  cobra.ExactArgs(1),
+	Run:   runKnowledgePush,
+}
+
+var knowledgePullCmd = &cobra.Command{
+	Use:   "pull [oci://registry/repository:tag]",
+	Short: "Pull a knowledge base artifact, or every pinned one",
+	Long: `Pulls a knowledge base artifact into the knowledge base directory. A reference pinned in the sources
+file is pulled at its pinned digest; the first pull of a reference pins it. Without a reference, every
+pinned source is pulled.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runKnowledgePull,
+}
+
+func init() {
+	knowledgeCmd.PersistentFlags().StringVar(&knowledgeDir, "dir", "knowledge-base", "Knowledge base directory")
+	knowledgeCmd.PersistentFlags().StringVar(&knowledgeSources, "sources", "knowledge-sources.yaml", "File pinning pulled artifacts to their digests")
+	knowledgePullCmd.Flags().BoolVar(&knowledgeUpdatePin, "update-pin", false, "Pull the reference's current tag and move its pin to the new digest")
+	knowledgePullCmd.Flags().StringVar(&verifyKeyPath, "verify-key", os.Getenv("KUBE_ADVISOR_VERIFY_KEY"), "Public key artifacts must be signed with by 'cosign sign', e.g. cosign.pub (default: verifyKey in the sources file, or $KUBE_ADVISOR_VERIFY_KEY)")
+
+	knowledgeCmd.AddCommand(knowledgePushCmd)
+	knowledgeCmd.AddCommand(knowledgePullCmd)
+	rootCmd.AddCommand(knowledgeCmd)
+}
+
+func runKnowledgePush(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+	requireNetwork("knowledge push")
+
+	digest, err := knowledge.PushOCI(ctx, args[0], knowledgeDir)
+	if err != nil {
+		fatalf("Failed to push knowledge base: %v", err)
+	}
+	fmt.Printf("Pushed %s to %s\n", knowledgeDir, args[0])
+	fmt.Printf("Digest: %s\n", digest)
+}
+
+func runKnowledgePull(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+	requireNetwork("knowledge pull")
+
+	sources, err := knowledge.LoadKnowledgeSources(knowledgeSources)
+	if err != nil {
+		fatalf("Failed to load knowledge sources: %v", err)
+	}
+
+	// The flag takes precedence over the key configured in the sources file
+	keyPath := sources.VerifyKey
+	if cmd.Flags().Changed("verify-key") || keyPath == "" {
+		keyPath = verifyKeyPath
+	}
+	var verifier *signature.Verifier
+	if keyPath != "" {
+		verifier, err = signature.NewVerifier(keyPath)
+		if err != nil {
+			fatalf("Failed to load verification key: %v", err)
+		}
+	}
+
+	refs := args
+	if len(refs) == 0 {
+		for _, source := range sources.Sources {
+			refs = append(refs, source.Ref)
+		}
+		if len(refs) == 0 {
+			fatal(usageErrorf("No knowledge sources pinned in %s; pull a reference first", knowledgeSources))
+		}
+	}
+
+	for _, ref := range refs {
+		pinned := ""
+		if source := sources.Find(ref); source != nil && !knowledgeUpdatePin {
+			pinned = source.Digest
+		}
+
+		digest, err := knowledge.PullOCI(ctx, ref, pinned, knowledgeDir, verifier)
+		if err != nil {
+			fatalf("Failed to pull knowledge base: %v", err)
+		}
+		sources.Pin(ref, digest, time.Now().UTC())
+
+		if pinned == "" {
+			fmt.Printf("Pulled %s into %s, pinned at %s\n", ref, knowledgeDir, digest)
+		} else {
+			fmt.Printf("Pulled %s@%s into %s\n", ref, digest, knowledgeDir)
+		}
+		if verifier != nil {
+			fmt.Printf("Verified signature with %s\n", keyPath)
+		}
+	}
+
+	if err := sources.Save(knowledgeSources); err != nil {
+		fatalf("Failed to save knowledge sources: %v", err)
+	}
+}
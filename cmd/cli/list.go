@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listGroup   string
+	listKind    string
+	listChart   string
+	listSource  string
+	listSort    string
+	listColumns []string
+	listOutput  string
+)
+
+var listHelmCmd = &cobra.Command{
+	Use:   "helm",
+	Short: "List Helm releases",
+	Long: `Lists the Helm releases of the cluster, filtered by --namespace and --chart.
+Columns: namespace, name, chart, version, app-version, status, revision, deployed, source.`,
+	Args: cobra.NoArgs,
+	Run:  runListHelm,
+}
+
+var listCRDsCmd = &cobra.Command{
+	Use:   "crds",
+	Short: "List custom resource definitions",
+	Long: `Lists the CRDs of the cluster, filtered by --group and --kind.
+Columns: name, group, kind, versions, stored, scope, instances, owner.`,
+	Args: cobra.NoArgs,
+	Run:  runListCRDs,
+}
+
+var listAPIsCmd = &cobra.Command{
+	Use:   "apis",
+	Short: "List the APIs used by manifests",
+	Long: `Lists the APIs used by the scanned manifests, filtered by --namespace, --group and --kind.
+Columns: group, version, kind, source, templated, namespaces.`,
+	Args: cobra.NoArgs,
+	Run:  runListAPIs,
+}
+
+var listResourcesCmd = &cobra.Command{
+	Use:   "resources",
+	Short: "List live and manifest resources",
+	Long: `Lists the resources of the cluster and its manifests, filtered by --namespace, --group, --kind and
+--source (cluster, git, local, terraform or dump).
+Columns: api-version, kind, namespace, name, source, file, owner.`,
+	Args: cobra.NoArgs,
+	Run:  runListResources,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{listHelmCmd, listCRDsCmd, listAPIsCmd, listResourcesCmd} {
+		cmd.Flags().StringVar(&listSort, "sort", "", "Sort by this column, descending when prefixed with - (default: all columns in order)")
+		cmd.Flags().StringSliceVar(&listColumns, "columns", nil, "Columns to show, comma-separated (default: all)")
+		cmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: table, json or yaml")
+		listCmd.AddCommand(cmd)
+	}
+	listHelmCmd.Flags().StringVar(&listChart, "chart", "", "Only list releases of this chart")
+	for _, cmd := range []*cobra.Command{listCRDsCmd, listAPIsCmd, listResourcesCmd} {
+		cmd.Flags().StringVar(&listGroup, "group", "", "Only list this API group; use core for the core group")
+		cmd.Flags().StringVar(&listKind, "kind", "", "Only list this kind")
+	}
+	listResourcesCmd.Flags().StringVar(&listSource, "source", "", "Only list resources from this source: cluster, git, local, terraform or dump")
+}
+
+// listTable is the result of a list subcommand: the values of its rows by column, and the inventory
+// items the rows were made from for structured output
+type listTable struct {
+	what    string
+	columns []string
+	rows    []map[string]string
+	items   []interface{}
+}
+
+// add appends a row
+func (t *listTable) add(item interface{}, values ...string) {
+	row := make(map[string]string, len(t.columns))
+	for i, column := range t.columns {
+		row[column] = values[i]
+	}
+	t.rows = append(t.rows, row)
+	t.items = append(t.items, item)
+}
+
+// loadInventory loads the inventory of --cluster from --server or the database
+func loadInventory(ctx context.Context) *inventory.Export {
+	if serverURL != "" {
+		export, err := newAPIClient().Inventory(ctx, clusterIDFlag)
+		if err != nil {
+			fatalf("Failed to get inventory: %v", err)
+		}
+		return export
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	export, err := store.ExportInventory(ctx, clusterIDFlag)
+	if err != nil {
+		fatalf("Failed to get inventory: %v", err)
+	}
+	return export
+}
+
+// matchesGroup reports whether group matches --group, where "core" matches the core group
+func matchesGroup(group string) bool {
+	return listGroup == "" || group == listGroup || (group == "" && listGroup == "core")
+}
+
+func runListHelm(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	table := &listTable{what: "Helm releases", columns: []string{"namespace", "name", "chart", "version", "app-version", "status", "revision", "deployed", "source"}}
+	for _, release := range loadInventory(ctx).HelmReleases {
+		if (namespace != "" && release.Namespace != namespace) || (listChart != "" && release.Chart != listChart) {
+			continue
+		}
+		deployed := ""
+		if release.LastDeployed != nil {
+			deployed = release.LastDeployed.Local().Format("2006-01-02 15:04")
+		}
+		table.add(release, release.Namespace, release.Name, release.Chart, release.ChartVersion, release.AppVersion,
+			release.Status, strconv.Itoa(release.Revision), deployed, release.Source)
+	}
+	printListTable(table)
+}
+
+func runListCRDs(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	table := &listTable{what: "CRDs", columns: []string{"name", "group", "kind", "versions", "stored", "scope", "instances", "owner"}}
+	for _, crd := range loadInventory(ctx).CRDs {
+		if !matchesGroup(crd.Group) || (listKind != "" && crd.Kind != listKind) {
+			continue
+		}
+		owner := ""
+		if crd.HelmOwnerName != "" {
+			owner = crd.HelmOwnerNamespace + "/" + crd.HelmOwnerName
+		}
+		table.add(crd, crd.Name, crd.Group, crd.Kind, strings.Join(crd.Versions, ","), strings.Join(crd.StoredVersions, ","),
+			crd.Scope, strconv.Itoa(crd.InstanceCount), owner)
+	}
+	printListTable(table)
+}
+
+func runListAPIs(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	table := &listTable{what: "manifest APIs", columns: []string{"group", "version", "kind", "source", "templated", "namespaces"}}
+	for _, api := range loadInventory(ctx).ManifestAPIs {
+		if !matchesGroup(api.Group) || (listKind != "" && api.Kind != listKind) {
+			continue
+		}
+		if namespace != "" && !containsString(api.Namespaces, namespace) {
+			continue
+		}
+		table.add(api, api.Group, api.Version, api.Kind, api.Source, strconv.FormatBool(api.Templated), strings.Join(api.Namespaces, ","))
+	}
+	printListTable(table)
+}
+
+func runListResources(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	table := &listTable{what: "resources", columns: []string{"api-version", "kind", "namespace", "name", "source", "file", "owner"}}
+	for _, resource := range loadInventory(ctx).Resources {
+		group := ""
+		if before, _, ok := strings.Cut(resource.APIVersion, "/"); ok {
+			group = before
+		}
+		if !matchesGroup(group) || (listKind != "" && resource.Kind != listKind) ||
+			(namespace != "" && resource.Namespace != namespace) || (listSource != "" && resource.Source != listSource) {
+			continue
+		}
+		file := resource.File
+		if file != "" && resource.Line > 0 {
+			file = fmt.Sprintf("%s:%d", file, resource.Line)
+		}
+		owner := ""
+		if len(resource.OwnerReferences) > 0 {
+			owner = resource.OwnerReferences[0].Kind + "/" + resource.OwnerReferences[0].Name
+		}
+		table.add(resource, resource.APIVersion, resource.Kind, resource.Namespace, resource.Name, resource.Source, file, owner)
+	}
+	printListTable(table)
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// printListTable sorts the table by --sort and prints its --columns in the -o/--output format
+func printListTable(table *listTable) {
+	if listOutput != "table" && listOutput != "json" && listOutput != "yaml" {
+		fatal(usageErrorf("Invalid --output value: unsupported format %q (supported: table, json, yaml)", listOutput))
+	}
+	columns := table.columns
+	if len(listColumns) > 0 {
+		columns = listColumns
+		for _, column := range columns {
+			if !containsString(table.columns, column) {
+				fatal(usageErrorf("Invalid --columns value: unknown column %q (columns: %s)", column, strings.Join(table.columns, ", ")))
+			}
+		}
+	}
+	sortKeys := table.columns
+	descending := false
+	if listSort != "" {
+		column := strings.TrimPrefix(listSort, "-")
+		if !containsString(table.columns, column) {
+			fatal(usageErrorf("Invalid --sort value: unknown column %q (columns: %s)", column, strings.Join(table.columns, ", ")))
+		}
+		sortKeys = []string{column}
+		descending = column != listSort
+	}
+
+	order := make([]int, len(table.rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := table.rows[order[i]], table.rows[order[j]]
+		for _, key := range sortKeys {
+			if a[key] == b[key] {
+				continue
+			}
+			less := compareListValues(a[key], b[key])
+			if descending {
+				return !less
+			}
+			return less
+		}
+		return false
+	})
+
+	if listOutput != "table" {
+		output := make([]interface{}, len(order))
+		for i, index := range order {
+			if len(listColumns) == 0 {
+				output[i] = table.items[index]
+				continue
+			}
+			selected := make(map[string]string, len(columns))
+			for _, column := range columns {
+				selected[column] = table.rows[index][column]
+			}
+			output[i] = selected
+		}
+		if err := printStructured(output, listOutput); err != nil {
+			fatalf("Failed to print %s: %v", table.what, err)
+		}
+		return
+	}
+
+	if len(order) == 0 {
+		fmt.Printf("No %s found in cluster %s\n", table.what, clusterIDFlag)
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, index := range order {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = table.rows[index][column]
+		}
+		fmt.Fprintln(writer, strings.Join(values, "\t"))
+	}
+	writer.Flush()
+}
+
+// compareListValues orders numbers numerically and other values lexically
+func compareListValues(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
@@ -3,31 +3,87 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"strings"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/dump"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/redact"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/report"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
 	"github.com/spf13/cobra"
 )
 
 var (
-	kubeconfig       string
-	dbPath           string
-	manifestPath     string
-	targetVersion    string
-	apiKnowledgePath string
-	manifestOnly     bool
+	kubeconfig        string
+	kubeContext       string
+	namespace         string
+	helmDrivers       []string
+	outputFormat      string
+	lang              string
+	dbPath            string
+	readOnlyDB        bool
+	serverURL         string
+	manifestPath      string
+	terraformPath     string
+	renderers         []string
+	tolerateTemplates bool
+	scanConfigMaps    bool
+	excludePatterns   []string
+	includePatterns   []string
+	followSymlinks    bool
+	readArchives      bool
+	parseWorkers      int
+	impactManifests   []string
+	renderCharts      bool
+	criticalityPath   string
+	ownersPath        string
+	baselinePath      string
+	writeBaseline     bool
+	reportTemplate    string
+	fromCache         bool
+	customStepsPath   string
+	clusterIDFlag     string
+	selector          string
+	clusterLabels     []string
+	targetVersion     string
+	apiKnowledgePath  string
+	manifestOnly      bool
+	incrementalScan   bool
+	releaseFilter     cluster.ReleaseFilter
+	fromDump          string
+	liveChecks        bool
+	surgeNodes        int
+	redactOutput      bool
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "kube-upgrade-advisor",
 	Short: "Kubernetes cluster upgrade advisor",
 	Long:  `A tool to analyze Kubernetes clusters for upgrade compatibility issues`,
+	// Commands defining their own PersistentPreRun must call setupCommand
+	PersistentPreRun: setupCommand,
+}
+
+// kubeClientOptions are the rate limits, deadline and retries of Kubernetes API requests
+var kubeClientOptions = cluster.DefaultClientOptions()
+
+// setupCommand applies the network settings and Kubernetes client options and sets up telemetry
+// before a command runs
+func setupCommand(cmd *cobra.Command, args []string) {
+	setupNetwork()
+	cluster.SetClientOptions(kubeClientOptions)
+	setupTelemetry(cmd, args)
 }
 
 var scanCmd = &cobra.Command{
@@ -47,23 +103,84 @@ var impactCmd = &cobra.Command{
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List inventory",
-	Long:  `Lists all scanned resources in the database`,
-	Run:   runList,
+	Long: `Lists all scanned resources in the database. The helm, crds, apis and resources subcommands query
+one kind of inventory with filters, sorting, column selection and table, JSON or YAML output.`,
+	Run: runList,
 }
 
 func init() {
 	// Root flags
-	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig file (default: $HOME/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or $HOME/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Name of the kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Only scan Helm releases in this namespace (default: all namespaces)")
+	rootCmd.PersistentFlags().StringSliceVar(&helmDrivers, "helm-driver", cluster.DefaultHelmDrivers(), "Helm storage drivers to read releases from: secret, configmap, sql (comma-separated; default: $HELM_DRIVER or secret)")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", i18n.DefaultLanguage, "Language of the generated report and plan: "+strings.Join(i18n.Languages(), ", "))
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "kube-advisor.db", "Path to database file")
-	rootCmd.PersistentFlags().StringVar(&apiKnowledgePath, "api-knowledge", "knowledge-base/apis.json", "Path to API knowledge base")
+	rootCmd.PersistentFlags().BoolVar(&readOnlyDB, "read-only", false, "Open --db read-only, e.g. a copy handed to stakeholders: impact, list and reports work, writes fail and impact stores no assessment")
+	rootCmd.PersistentFlags().StringVar(&serverURL, "server", os.Getenv("KUBE_ADVISOR_SERVER"), "Query a kube-upgrade-server instead of the local database (impact, list and trend; scan only when given explicitly; default: $KUBE_ADVISOR_SERVER)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Abort cluster, server and analysis calls after this long, e.g. 10m; scan keeps the partial inventory (default: no limit)")
+	rootCmd.PersistentFlags().StringVar(&otlpEndpoint, "otlp-endpoint", "", "Export traces and metrics of scans and analyses to this OTLP endpoint, e.g. http://localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", offlineDefault(), "Make no network calls besides the Kubernetes API; features needing connectivity fail (default: $KUBE_ADVISOR_OFFLINE)")
+	rootCmd.PersistentFlags().Float32Var(&kubeClientOptions.QPS, "kube-qps", kubeClientOptions.QPS, "Maximum Kubernetes API requests per second; lower it for API servers with strict priority and fairness")
+	rootCmd.PersistentFlags().IntVar(&kubeClientOptions.Burst, "kube-burst", kubeClientOptions.Burst, "Kubernetes API requests allowed in a burst above --kube-qps")
+	rootCmd.PersistentFlags().DurationVar(&kubeClientOptions.RequestTimeout, "request-timeout", kubeClientOptions.RequestTimeout, "Abort a single Kubernetes API request after this long; 0 for no limit")
+	rootCmd.PersistentFlags().IntVar(&kubeClientOptions.MaxRetries, "kube-retries", kubeClientOptions.MaxRetries, "Retry Kubernetes API reads answered with 429 or 5xx this often, with exponential backoff")
+	rootCmd.PersistentFlags().StringVar(&apiKnowledgePath, "api-knowledge", knowledgeFile("apis.json"), "Path to API knowledge base")
 
 	// Scan flags
-	scanCmd.Flags().StringVar(&manifestPath, "manifests", "./manifests", "Path to manifest folder")
+	scanCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID to store the inventory under")
+	scanCmd.Flags().StringSliceVar(&clusterLabels, "label", nil, "Label the cluster, e.g. --label env=prod --label region=eu (an empty value removes the label)")
+	scanCmd.Flags().StringVar(&manifestPath, "manifests", "./manifests", "Path to manifest folder, file or .tar.gz/.zip archive, an https:// URL of raw manifests, or - to read from stdin")
+	scanCmd.Flags().StringSliceVar(&renderers, "render", nil, "Render templated sources before parsing: jsonnet, cue, helmfile (comma-separated)")
+	scanCmd.Flags().BoolVar(&tolerateTemplates, "tolerate-templates", false, "Recover apiVersion and kind from un-rendered Go templates, e.g. raw chart templates, as lower-confidence findings")
+	scanCmd.Flags().BoolVar(&scanConfigMaps, "scan-configmaps", false, "Also parse manifests embedded in the data of ConfigMaps")
+	scanCmd.Flags().StringSliceVar(&excludePatterns, "exclude", nil, "Skip manifest files and folders matching these gitignore-style patterns, relative to --manifests (repeatable)")
+	scanCmd.Flags().StringSliceVar(&includePatterns, "include", nil, "Only parse manifest files matching one of these gitignore-style patterns, relative to --manifests (repeatable)")
+	scanCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Follow symlinked files and folders in --manifests, skipping link cycles")
+	scanCmd.Flags().BoolVar(&readArchives, "archives", false, "Also parse the .tar.gz, .tgz and .zip archives found in --manifests")
+	scanCmd.Flags().IntVar(&parseWorkers, "parse-workers", 0, "Number of manifest files parsed at once (default: number of CPUs)")
+	scanCmd.Flags().StringVar(&terraformPath, "terraform", "", "Path to a Terraform state file or plan/state JSON from 'terraform show -json'")
 	scanCmd.Flags().BoolVar(&manifestOnly, "manifest-only", false, "Only scan manifests (skip cluster scan)")
+	scanCmd.Flags().BoolVar(&incrementalScan, "incremental", false, "Only process CRDs, nodes and Helm releases changed since the last scan, updating the inventory in place")
+	scanCmd.Flags().StringVar(&eventsNamespace, "events-namespace", "", "Emit a Kubernetes Event when the scan finishes, on the kube-upgrade-advisor ConfigMap in this namespace")
+	scanCmd.Flags().StringSliceVar(&releaseFilter.Statuses, "release-status", nil, "Only store Helm releases with these statuses, e.g. deployed,failed (default: all)")
+	scanCmd.Flags().BoolVar(&releaseFilter.DeployedOnly, "deployed-only", false, "Only store deployed Helm releases, skipping failed, superseded and pending ones")
+	scanCmd.Flags().DurationVar(&releaseFilter.MinAge, "release-min-age", 0, "Only store Helm releases last deployed at least this long ago, e.g. 1h to skip rollouts in progress")
+	scanCmd.Flags().StringVar(&fromDump, "from-dump", "", "Read the cluster from a 'kubectl cluster-info dump' archive, directory or file instead of the API server")
 
 	// Impact flags
 	impactCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
 	impactCmd.MarkFlagRequired("target")
+	impactCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	impactCmd.Flags().StringVarP(&selector, "selector", "l", "", "Select the cluster by label instead of ID, e.g. env=prod,region=eu (must match exactly one)")
+	impactCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text, json, yaml, junit, pdf or csv")
+	impactCmd.Flags().StringArrayVar(&impactManifests, "manifests", nil, "Analyze a manifest file, folder, or - for stdin directly, without a scanned database (repeatable; resources found in several sources are reported once)")
+	impactCmd.Flags().BoolVar(&tolerateTemplates, "tolerate-templates", false, "Recover apiVersion and kind from un-rendered Go templates in --manifests, as lower-confidence findings")
+	impactCmd.Flags().BoolVar(&scanConfigMaps, "scan-configmaps", false, "Also parse manifests embedded in the data of ConfigMaps in --manifests")
+	impactCmd.Flags().StringVar(&baselinePath, "baseline", "", "Baseline file of accepted findings; exit non-zero only when new findings are introduced")
+	impactCmd.Flags().BoolVar(&writeBaseline, "write-baseline", false, "Write the current findings to the baseline file (default "+defaultBaselinePath+") instead of comparing")
+	impactCmd.Flags().StringVar(&reportTemplate, "report-template", "", "Render the assessment and plan with a Go text/template file instead of the built-in report")
+	impactCmd.Flags().StringVar(&customStepsPath, "custom-steps", "", "YAML file of operational steps to inject into the upgrade plan")
+	impactCmd.Flags().BoolVar(&fromCache, "from-cache", false, "Reuse the last stored assessment and plan for the target version instead of recomputing")
+	impactCmd.Flags().BoolVar(&liveChecks, "live", false, "Run live cluster checks (node drain, capacity headroom, scheduling, storage, ingress annotations, addons) against the current cluster")
+	impactCmd.Flags().IntVar(&surgeNodes, "surge", 1, "Number of nodes taken out of service at once during the rolling upgrade (used with --live)")
+	impactCmd.Flags().StringVar(&validatePath, "validate", "", "Apply a manifest file or folder to the current cluster with a server-side dry run and report rejected resources")
+	impactCmd.Flags().BoolVar(&renderCharts, "render-charts", false, "Render candidate chart versions with helm template, recommending only versions that emit no API removed in the target version")
+	impactCmd.Flags().BoolVar(&useChartCache, "chart-cache", false, "Cache the chart repository indexes and archives --render-charts and --drift fetch in --chart-cache-dir")
+	impactCmd.Flags().StringVar(&chartCacheDir, "chart-cache-dir", manifests.DefaultChartCacheDir(), "Directory of the chart cache (used with --chart-cache)")
+	impactCmd.Flags().DurationVar(&chartCacheTTL, "chart-cache-ttl", manifests.DefaultChartCacheTTL, "Age after which a cached chart repository index is fetched again")
+	impactCmd.Flags().BoolVar(&refreshCharts, "refresh", false, "Fetch cached chart repository indexes and archives again regardless of their age (used with --chart-cache)")
+	impactCmd.Flags().StringVar(&criticalityPath, "criticality", "", "YAML file of namespace and release criticalities that incompatible charts are weighed by, taking precedence over namespace labels")
+	impactCmd.Flags().StringVar(&ownersPath, "owners", "", "YAML file mapping namespaces and releases to owning teams, for the owner column of -o csv")
+	impactCmd.Flags().StringVar(&policyDir, "policy-dir", "", "Write the ValidatingAdmissionPolicies generated to replace PodSecurityPolicies in --manifests to this folder")
+	impactCmd.Flags().StringVar(&driftPath, "drift", "", "Compare the Helm releases declared by Flux HelmReleases or Argo CD Applications in this folder with the deployed releases")
+	impactCmd.Flags().StringVar(&eventsNamespace, "events-namespace", "", "Emit a Kubernetes Event when the overall risk changes since the last assessment, on the kube-upgrade-advisor ConfigMap in this namespace")
+	impactCmd.Flags().BoolVar(&redactOutput, "redact", false, "Replace namespace, workload, node, team and cluster names with placeholders and remove other values not known to be safe, keeping GVKs, versions, counts and chart names, to share the assessment externally")
+	impactCmd.Flags().BoolVar(&validateConverted, "converted", false, "Validate resources as converted by the built-in converters (used with --validate)")
+
+	// List flags
+	listCmd.PersistentFlags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	listCmd.Flags().StringVarP(&selector, "selector", "l", "", "List the clusters matching a label selector, e.g. env=prod,region!=us")
 
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(impactCmd)
@@ -71,208 +188,715 @@ func init() {
 }
 
 func main() {
+	configurePluginMode()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(exitUsage)
 	}
+	finishAudit(0)
+	shutdownTelemetry()
 }
 
 func runScan(cmd *cobra.Command, args []string) {
-	ctx := context.Background()
+	ctx, cancel := commandContext()
+	defer cancel()
+	ctx, scanOp := telemetry.StartScan(ctx, "all")
+	// Writes outlive an interrupt, so the inventory scanned so far is kept
+	storeCtx := context.WithoutCancel(ctx)
 
 	fmt.Println("=== Kube Upgrade Advisor - Scan ===\n")
 
+	labels, err := inventory.ParseLabels(clusterLabels)
+	if err != nil {
+		fatal(usageErrorf("Invalid --label value: %w", err))
+	}
+	if manifestOnly && fromDump != "" {
+		fatal(usageErrorf("--manifest-only cannot be combined with --from-dump"))
+	}
+	// Only an explicit --server scans remotely, so $KUBE_ADVISOR_SERVER keeps scans local
+	if cmd.Flags().Changed("server") {
+		for _, local := range []string{"manifests", "manifest-only", "from-dump", "terraform", "render", "label", "events-namespace"} {
+			if cmd.Flags().Changed(local) {
+				fatal(usageErrorf("--server scans the cluster the server runs in and cannot be combined with --%s", local))
+			}
+		}
+		remoteScan(ctx)
+		scanOp.End(nil)
+		return
+	}
+	if parseWorkers < 0 {
+		fatal(usageErrorf("Invalid --parse-workers value: must not be negative"))
+	}
+	if incrementalScan && (manifestOnly || fromDump != "") {
+		fatal(usageErrorf("--incremental needs a cluster scan and cannot be combined with --manifest-only or --from-dump"))
+	}
+	if err := releaseFilter.Validate(); err != nil {
+		fatal(usageErrorf("Invalid Helm release filter: %w", err))
+	}
+	if eventsNamespace != "" && (manifestOnly || fromDump != "") {
+		fatal(usageErrorf("--events-namespace needs a cluster scan and cannot be combined with --manifest-only or --from-dump"))
+	}
+
+	startAudit(cmd, args, inventory.AuditScan, clusterIDFlag)
+
 	// Create inventory store
 	fmt.Println("Initializing database...")
-	store, err := inventory.NewStore(dbPath)
+	store, err := openStore()
 	if err != nil {
-		log.Fatalf("Failed to create store: %v", err)
+		fatalf("Failed to create store: %v", err)
 	}
 	defer store.Close()
 
-	var clusterID string
+	clusterID := clusterIDFlag
 	var version string
-
-	if !manifestOnly {
-		// Get kubeconfig path
-		if kubeconfig == "" {
-			kubeconfig = filepath.Join(os.Getenv("HOME"), ".kube", "config")
-			if kc := os.Getenv("KUBECONFIG"); kc != "" {
-				kubeconfig = kc
+	var scanErrors map[string]string
+	// interrupted is set when SIGINT or --timeout stops the scan; later steps are skipped
+	var interrupted error
+
+	if fromDump != "" {
+		dumpCtx, op := telemetry.StartScan(storeCtx, "dump")
+		version, scanErrors, err = scanDump(dumpCtx, store, clusterID, fromDump)
+		op.End(err)
+		if err != nil {
+			fatalf("Failed to load cluster dump: %v", err)
+		}
+	} else if !manifestOnly {
+		version, scanErrors, err = scanCluster(ctx, store, clusterID)
+		if err != nil {
+			if ctx.Err() == nil {
+				fatalf("Failed to scan cluster: %v", err)
+			}
+			if version == "" {
+				fatalf("Scan interrupted before the cluster was stored: %v", err)
 			}
+			interrupted = err
 		}
+	} else {
+		// Manifest-only mode - create a dummy cluster
+		fmt.Println("Running in manifest-only mode (no cluster connection)\n")
+		version = "1.21.0" // Default version for testing
 
-		// Create Kube client
-		fmt.Println("Connecting to Kubernetes cluster...")
-		kubeClient, err := cluster.NewKubeClient(kubeconfig)
+		clusterRec, err := store.SaveCluster(storeCtx, clusterID, "test-cluster", version)
 		if err != nil {
-			log.Fatalf("Failed to create kube client: %v", err)
+			fatalf("Failed to save cluster: %v", err)
 		}
+		fmt.Printf("Created test cluster: %s (version: %s)\n\n", clusterRec.ID, clusterRec.KubeVersion)
+	}
 
-		// Get cluster version
-		version, err = kubeClient.GetClusterVersion(ctx)
+	// Apply grouping labels
+	if len(labels) > 0 {
+		clusterRec, err := store.SetClusterLabels(storeCtx, clusterID, labels)
 		if err != nil {
-			log.Fatalf("Failed to get cluster version: %v", err)
+			fatalf("Failed to label cluster: %v", err)
+		}
+		fmt.Printf("Cluster labels: %s\n\n", inventory.FormatLabels(clusterRec.Labels))
+	}
+
+	// Parse local manifests
+	if _, err := os.Stat(manifestPath); interrupted == nil && (err == nil || manifestPath == "-" || manifests.IsURL(manifestPath)) {
+		if manifests.IsURL(manifestPath) {
+			requireNetwork("--manifests with a URL")
+		}
+		fmt.Printf("Parsing manifests from %s...\n", manifestPath)
+		parser := manifests.NewParser()
+		parser.Offline = offline
+		parser.TolerateTemplates = tolerateTemplates
+		parser.ScanConfigMaps = scanConfigMaps
+		parser.FollowSymlinks = followSymlinks
+		parser.ReadArchives = readArchives
+		parser.Workers = parseWorkers
+		if err := parser.EnableRenderers(renderers); err != nil {
+			fatal(usageErrorf("Invalid --render value: %w", err))
+		}
+		if err := parser.SetFilters(excludePatterns, includePatterns); err != nil {
+			fatal(usageErrorf("Invalid --exclude or --include value: %w", err))
 		}
-		fmt.Printf("Cluster version: %s\n\n", version)
+		manifestsCtx, op := telemetry.StartScan(ctx, "manifests")
+		err = parser.StoreManifestsToInventory(manifestsCtx, manifestPath, clusterID, store, "local")
+		op.End(err)
+		if err != nil && ctx.Err() == nil {
+			fatalf("Failed to store manifests: %v", err)
+		}
+		interrupted = err
+		fmt.Println()
+	} else if interrupted == nil {
+		fmt.Printf("Skipping manifest parsing (folder not found: %s)\n\n", manifestPath)
+	}
 
-		// Save cluster info
-		clusterID = "cluster-1"
-		clusterRec, err := store.SaveCluster(ctx, clusterID, "my-cluster", version)
-		if err != nil {
-			log.Fatalf("Failed to save cluster: %v", err)
+	// Parse Terraform-managed resources
+	if terraformPath != "" && interrupted == nil {
+		fmt.Printf("Scanning Terraform data from %s...\n", terraformPath)
+		scanner := manifests.NewTerraformScanner()
+		terraformCtx, op := telemetry.StartScan(ctx, "terraform")
+		err = scanner.StoreTerraformToInventory(terraformCtx, terraformPath, clusterID, store)
+		op.End(err)
+		if err != nil && ctx.Err() == nil {
+			fatalf("Failed to store Terraform resources: %v", err)
+		}
+		interrupted = err
+		fmt.Println()
+	}
+
+	// Mark a partial inventory, so analyses built on it say so
+	reason := ""
+	if interrupted != nil {
+		reason = interrupted.Error()
+	}
+	if err := store.SetIncompleteReason(storeCtx, clusterID, reason); err != nil {
+		fatalf("Failed to store scan status: %v", err)
+	}
+	if err := store.SetScanErrors(storeCtx, clusterID, scanErrors); err != nil {
+		fatalf("Failed to store scan status: %v", err)
+	}
+	if eventsNamespace != "" {
+		eventType, eventReason, message := cluster.ScanEvent(clusterID, version, scanErrors, reason)
+		emitEvent(storeCtx, eventType, eventReason, message)
+	}
+	scanOp.End(interrupted)
+
+	if interrupted != nil {
+		fmt.Println("=== Scan Incomplete ===")
+		fmt.Printf("Database: %s\n", dbPath)
+		log.Printf("Warning: scan stopped early (%v); the partial inventory is stored and marked incomplete", interrupted)
+		auditFailure(fmt.Sprintf("scan stopped early: %v", interrupted))
+		exit(classifyError(interrupted).code)
+	}
+
+	if len(scanErrors) > 0 {
+		fmt.Println("=== Scan Complete (partial) ===")
+		fmt.Printf("Database: %s\n", dbPath)
+		log.Printf("Warning: partial scan, these parts could not be scanned: %s", strings.Join(inventory.ScanErrorSubsystems(scanErrors), ", "))
+		auditSummary("scanned Kubernetes %s, partially: %s not scanned", version, strings.Join(inventory.ScanErrorSubsystems(scanErrors), ", "))
+		fmt.Println("\nRun 'kube-upgrade-advisor impact --target <version>' to analyze upgrade impact")
+		return
+	}
+
+	auditSummary("scanned Kubernetes %s", version)
+	fmt.Println("=== Scan Complete! ===")
+	fmt.Printf("Database: %s\n", dbPath)
+	fmt.Println("\nRun 'kube-upgrade-advisor impact --target <version>' to analyze upgrade impact")
+}
+
+// scanCluster stores the cluster's version, served APIs, node platforms, CRDs and Helm releases,
+// printing the progress, as scan.Cluster
+func scanCluster(ctx context.Context, store *inventory.Store, clusterID string) (string, map[string]string, error) {
+	fmt.Println("Connecting to Kubernetes cluster...")
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	version, scanErrors, err := scan.Cluster(ctx, store, kubeClient, clusterID, scan.Options{
+		Kubeconfig:  kubeconfig,
+		Context:     kubeContext,
+		Namespace:   namespace,
+		HelmDrivers: helmDrivers,
+		HelmFilter:  releaseFilter,
+		Incremental: incrementalScan,
+		Progress:    printScanProgress(),
+	})
+	fmt.Println()
+	return version, scanErrors, err
+}
+
+// printScanProgress prints the messages of a scan, with a blank line between phases, and logs
+// skipped subsystems as warnings; the cluster package prints the items stored
+func printScanProgress() func(scan.Event) {
+	phase := ""
+	return func(event scan.Event) {
+		if phase != "" && event.Phase != phase {
+			fmt.Println()
+		}
+		phase = event.Phase
+		switch {
+		case event.Error != "" && event.Phase == scan.PhaseDone:
+			log.Printf("Warning: %s", event.Error)
+		case event.Error != "":
+			log.Printf("Warning: skipping %s: %s", event.Phase, event.Error)
+		case event.Message != "":
+			fmt.Println(event.Message)
+		}
+	}
+}
+
+// scanDump stores the cluster recorded in a dump in place of a live scan. Parts the dump holds no
+// data for are returned as scan errors.
+func scanDump(ctx context.Context, store *inventory.Store, clusterID, path string) (string, map[string]string, error) {
+	fmt.Printf("Loading cluster dump from %s...\n", path)
+	export, err := dump.Load(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Keep the labels of a cluster scanned before
+	if existing, err := store.GetCluster(ctx, clusterID); err == nil {
+		export.Cluster.Labels = existing.Labels
+	}
+	clusterRec, err := store.ImportInventory(ctx, export, clusterID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store dump: %w", err)
+	}
+	fmt.Printf("Saved cluster: %s (version: %s)\n", clusterRec.ID, clusterRec.KubeVersion)
+	fmt.Printf("Found %d served group versions, %d CRDs, %d Helm releases and %d applied API types\n\n",
+		len(export.Cluster.ServedAPIs), len(export.CRDs), len(export.HelmReleases), len(export.ManifestAPIs))
+
+	for _, subsystem := range inventory.ScanErrorSubsystems(export.Cluster.ScanErrors) {
+		log.Printf("Warning: skipping %s: %s", subsystem, export.Cluster.ScanErrors[subsystem])
+	}
+	return clusterRec.KubeVersion, export.Cluster.ScanErrors, nil
+}
+
+func runImpact(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validateOutputFormat(outputFormat); err != nil {
+		fatal(usageErrorf("Invalid --output value: %w", err))
+	}
+	if fromCache && (len(impactManifests) > 0 || liveChecks || validatePath != "" || driftPath != "") {
+		fatal(usageErrorf("--from-cache cannot be combined with --manifests, --live, --validate or --drift"))
+	}
+	if serverURL != "" && (len(impactManifests) > 0 || liveChecks || validatePath != "" || driftPath != "" || renderCharts || criticalityPath != "" || customStepsPath != "") {
+		fatal(usageErrorf("--server cannot be combined with --manifests, --live, --validate, --drift, --render-charts, --criticality or --custom-steps"))
+	}
+	if driftPath != "" {
+		requireNetwork("--drift")
+	}
+	if renderCharts {
+		requireNetwork("--render-charts")
+	}
+	if eventsNamespace != "" && (serverURL != "" || len(impactManifests) > 0 || fromCache) {
+		fatal(usageErrorf("--events-namespace cannot be combined with --server, --manifests or --from-cache"))
+	}
+	var ownership *analysis.OwnershipMap
+	if ownersPath != "" {
+		var err error
+		if ownership, err = analysis.LoadOwnershipMap(ownersPath); err != nil {
+			fatal(usageErrorf("Invalid --owners value: %w", err))
 		}
-		fmt.Printf("Saved cluster: %s (version: %s)\n\n", clusterRec.ID, clusterRec.KubeVersion)
+	}
+
+	// Keep stdout clean for machine-readable output
+	var progress io.Writer = os.Stdout
+	if outputFormat != "text" {
+		progress = os.Stderr
+	}
 
-		// Create CRD client
-		fmt.Println("Fetching CRDs...")
-		crdClient, err := cluster.NewCRDClientFromKubeClient(kubeClient)
+	fmt.Fprintln(progress, "=== Kube Upgrade Advisor - Impact Analysis ===\n")
+
+	// Manifests analyzed directly belong to no cluster of the database
+	auditedCluster := clusterIDFlag
+	if len(impactManifests) > 0 {
+		auditedCluster = ""
+	}
+	startAudit(cmd, args, inventory.AuditImpact, auditedCluster)
+
+	// Create inventory store, unless analyzing manifests directly or querying a server
+	var store *inventory.Store
+	if len(impactManifests) == 0 && serverURL == "" {
+		var err error
+		store, err = openStore()
 		if err != nil {
-			log.Fatalf("Failed to create CRD client: %v", err)
+			fatalf("Failed to create store: %v", err)
 		}
+		defer store.Close()
+	}
 
-		// List and store CRDs
-		err = crdClient.StoreCRDsToInventory(ctx, clusterID, store)
+	// create analyzer with both knowledge bases
+	chartKnowledgePath := knowledgeFile("chart-matrix.json")
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, chartKnowledgePath, store)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+	if outputFormat == "pdf" {
+		if err := report.CheckPDFLanguage(localizer.Lang()); err != nil {
+			fatal(usageErrorf("Invalid --lang value for -o pdf: %w", err))
+		}
+	}
+	analyzer.SetLocalizer(localizer)
+
+	fmt.Fprintf(progress, "Analyzing upgrade impact for target version: %s\n", targetVersion)
+
+	var assessment *analysis.ImpactAssessment
+	var plan *planner.UpgradePlan
+	if serverURL != "" {
+		fmt.Fprintf(progress, "Querying server %s\n", serverURL)
+		assessment, plan = remoteImpact(ctx, progress)
+	} else {
+		assessment, plan = localImpact(ctx, store, analyzer, localizer, progress)
+	}
+	if len(impactManifests) == 0 {
+		auditCluster(assessment.ClusterID)
+	}
+	auditSummary("Kubernetes %s, overall risk %s, %d issues", assessment.TargetVersion, assessment.OverallRisk, assessment.TotalIssues)
+
+	finishImpact(assessment, plan, analyzer, localizer, ownership, progress)
+}
+
+// chartAPIRenderer renders chart versions with helm template and returns the APIs they emit; a
+// chart that fails to render is reported and checked against the chart matrix only
+func chartAPIRenderer(progress io.Writer) analysis.ChartRenderer {
+	parser := manifests.NewParser()
+	parser.Progress = progress
+	parser.ChartCache = chartCache()
+	return func(repository, chart, version, kubeVersion string) ([]string, error) {
+		fmt.Fprintf(progress, "Rendering chart %s %s for Kubernetes %s...\n", chart, version, kubeVersion)
+		resources, err := parser.RenderChart(repository, chart, version, kubeVersion)
 		if err != nil {
-			log.Fatalf("Failed to store CRDs: %v", err)
+			log.Printf("Warning: failed to render chart %s %s: %v", chart, version, err)
+			return nil, err
 		}
-		fmt.Println()
+		apis := make([]string, 0, len(resources))
+		for _, resource := range resources {
+			apis = append(apis, resource.APIVersion+"/"+resource.Kind)
+		}
+		return apis, nil
+	}
+}
+
+// localImpact computes the assessment and plan from the local database or --manifests, and
+// stores them for --from-cache and trend
+func localImpact(ctx context.Context, store *inventory.Store, analyzer *analysis.Analyzer, localizer *i18n.Localizer, progress io.Writer) (*analysis.ImpactAssessment, *planner.UpgradePlan) {
+	loadKnowledge(analyzer)
 
-		// Create Helm client
-		fmt.Println("Fetching Helm releases...")
-		helmClient, err := cluster.NewHelmClientWithKubeconfig(kubeconfig)
+	if renderCharts {
+		analyzer.SetChartRenderer(chartAPIRenderer(progress))
+	}
+	if criticalityPath != "" {
+		criticality, err := analysis.LoadCriticalityMap(criticalityPath)
 		if err != nil {
-			log.Fatalf("Failed to create Helm client: %v", err)
+			fatalf("Failed to load criticality map: %v", err)
 		}
+		analyzer.SetCriticality(criticality)
+	}
+
+	// compute impact
+	clusterID := clusterIDFlag
+	if store != nil && selector != "" {
+		clusterID = selectCluster(ctx, store)
+	}
 
-		// List and store Helm releases
-		err = helmClient.StoreReleasesToInventory(ctx, clusterID, store)
+	var assessment *analysis.ImpactAssessment
+	var cachedPlan *planner.UpgradePlan
+	var err error
+	if len(impactManifests) > 0 {
+		parser := manifests.NewParser()
+		parser.Progress = progress
+		parser.Offline = offline
+		parser.TolerateTemplates = tolerateTemplates
+		parser.ScanConfigMaps = scanConfigMaps
+		var entries []inventory.ResourceEntry
+		var sources []string
+		for _, path := range impactManifests {
+			resources, err := parser.ParseInput(path)
+			if err != nil {
+				fatalf("Failed to parse manifests: %v", err)
+			}
+			if path == "-" {
+				path = "stdin"
+			}
+			for _, entry := range parser.ToResourceEntries(resources) {
+				if entry.Source == "" {
+					entry.Source = path
+				}
+				entries = append(entries, entry)
+			}
+			sources = append(sources, path)
+		}
+		assessment = analyzer.ComputeManifestImpact(entries, strings.Join(sources, ", "), targetVersion)
+	} else if fromCache {
+		var cached *ent.Assessment
+		assessment, cachedPlan, cached, err = loadCachedAssessment(ctx, store, clusterID, targetVersion)
 		if err != nil {
-			log.Fatalf("Failed to store Helm releases: %v", err)
+			fatalf("Failed to load cached assessment: %v", err)
 		}
-		fmt.Println()
+		fmt.Fprintf(progress, "Using stored assessment #%d from %s\n", cached.ID, cached.CreatedAt.Format("2006-01-02 15:04:05"))
 	} else {
-		// Manifest-only mode - create a dummy cluster
-		fmt.Println("Running in manifest-only mode (no cluster connection)\n")
-		clusterID = "cluster-1"
-		version = "1.21.0" // Default version for testing
+		assessment, err = analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+		if err != nil {
+			fatalf("Failed to compute impact: %v", err)
+		}
+	}
 
-		clusterRec, err := store.SaveCluster(ctx, clusterID, "test-cluster", version)
+	// run live cluster checks
+	if liveChecks {
+		fmt.Fprintln(progress, "Running live cluster checks...")
+		kubeClient, err := newKubeClient()
 		if err != nil {
-			log.Fatalf("Failed to save cluster: %v", err)
+			fatalf("Failed to create kube client: %v", err)
 		}
-		fmt.Printf("Created test cluster: %s (version: %s)\n\n", clusterRec.ID, clusterRec.KubeVersion)
+
+		liveCtx, op := telemetry.StartScan(ctx, "live-state")
+		state, err := kubeClient.CollectLiveState(liveCtx)
+		op.End(err)
+		if err != nil {
+			fatalf("Failed to collect live cluster state: %v", err)
+		}
+
+		storageKnowledgePath := knowledgeFile("storage.json")
+		if err := analyzer.LoadStorageKnowledge(storageKnowledgePath); err != nil {
+			log.Printf("Warning: skipping storage checks: %v", err)
+		}
+
+		ingressKnowledgePath := knowledgeFile("ingress-annotations.json")
+		if err := analyzer.LoadIngressKnowledge(ingressKnowledgePath); err != nil {
+			log.Printf("Warning: skipping ingress annotation checks: %v", err)
+		}
+
+		addonKnowledgePath := knowledgeFile("addons.json")
+		if err := analyzer.LoadAddonKnowledge(addonKnowledgePath); err != nil {
+			log.Printf("Warning: skipping addon checks: %v", err)
+		}
+
+		nodeConfigKnowledgePath := knowledgeFile("node-config.json")
+		if err := analyzer.LoadNodeConfigKnowledge(nodeConfigKnowledgePath); err != nil {
+			log.Printf("Warning: skipping kubelet and kube-proxy configuration checks: %v", err)
+		}
+
+		devicePluginKnowledgePath := knowledgeFile("device-plugins.json")
+		if err := analyzer.LoadDevicePluginKnowledge(devicePluginKnowledgePath); err != nil {
+			log.Printf("Warning: skipping device plugin checks: %v", err)
+		}
+
+		opts := analysis.DefaultLiveCheckOptions()
+		opts.SurgeNodes = surgeNodes
+		analyzer.ApplyLiveState(assessment, state, opts)
 	}
 
-	// Parse local manifests
-	if _, err := os.Stat(manifestPath); err == nil {
-		fmt.Printf("Parsing manifests from %s...\n", manifestPath)
-		parser := manifests.NewParser()
-		err = parser.StoreManifestsToInventory(ctx, manifestPath, clusterID, store, "local")
+	// validate manifests against the current cluster
+	if validatePath != "" {
+		fmt.Fprintln(progress, "Validating manifests with a server-side dry run...")
+		analysis.ApplyValidationResults(assessment, serverDryRunManifests(ctx, validatePath, validateConverted))
+	}
+
+	// compare Helm releases with their GitOps sources
+	if driftPath != "" {
+		fmt.Fprintln(progress, "Comparing Helm releases with their Git sources...")
+		analysis.ApplyReleaseDrift(assessment, releaseDrift(ctx, driftPath, assessment.CurrentVersion, progress))
+	}
+
+	// persist the result so it can be reused with --from-cache
+	var storedAssessment *ent.Assessment
+	if store != nil && !fromCache && !store.ReadOnly() {
+		var previous *ent.Assessment
+		if eventsNamespace != "" {
+			// Assessments are listed newest first
+			if stored, err := store.ListAssessments(ctx, clusterID, targetVersion); err != nil {
+				log.Printf("Warning: failed to load the previous assessment: %v", err)
+			} else if len(stored) > 0 {
+				previous = stored[0]
+			}
+		}
+		storedAssessment, err = saveAssessment(ctx, store, assessment, liveChecks || validatePath != "" || driftPath != "")
 		if err != nil {
-			log.Fatalf("Failed to store manifests: %v", err)
+			log.Printf("Warning: failed to store assessment: %v", err)
+		}
+		if eventsNamespace != "" {
+			emitRiskChange(ctx, previous, assessment)
 		}
-		fmt.Println()
-	} else {
-		fmt.Printf("Skipping manifest parsing (folder not found: %s)\n\n", manifestPath)
 	}
 
-	fmt.Println("=== Scan Complete! ===")
-	fmt.Printf("Database: %s\n", dbPath)
-	fmt.Println("\nRun 'kube-upgrade-advisor impact --target <version>' to analyze upgrade impact")
+	//generate upgrade plan
+	options := planner.Options{ConversionTool: planner.DetectConversionTool()}
+	plan := cachedPlan
+	if plan == nil {
+		planGenerator := planner.NewPlanner()
+		planGenerator.SetLocalizer(localizer)
+		planGenerator.SetOptions(options)
+		setCustomSteps(planGenerator)
+		plan, err = planGenerator.GeneratePlan(assessment)
+		if err != nil {
+			log.Printf("Warning: Failed to generate upgrade plan: %v", err)
+		}
+	}
+	if storedAssessment != nil && plan != nil {
+		if _, err := savePlan(ctx, store, clusterID, storedAssessment.ID, plan, options); err != nil {
+			log.Printf("Warning: failed to store plan: %v", err)
+		}
+	}
+
+	return assessment, plan
 }
 
-func runImpact(cmd *cobra.Command, args []string) {
-	ctx := context.Background()
+// finishImpact applies the baseline and prints the assessment and plan in the --output format
+func finishImpact(assessment *analysis.ImpactAssessment, plan *planner.UpgradePlan, analyzer *analysis.Analyzer, localizer *i18n.Localizer, ownership *analysis.OwnershipMap, progress io.Writer) {
+	// record the current findings as accepted, or compare against a previously recorded baseline
+	if writeBaseline {
+		path := baselinePath
+		if path == "" {
+			path = defaultBaselinePath
+		}
+		baseline := analysis.NewBaseline(assessment)
+		if err := baseline.WriteFile(path); err != nil {
+			fatalf("Failed to write baseline: %v", err)
+		}
+		fmt.Fprintf(progress, "Wrote baseline with %d accepted findings to %s\n", len(baseline.Findings), path)
+	} else if baselinePath != "" {
+		baseline, err := analysis.LoadBaseline(baselinePath)
+		if err != nil {
+			fatalf("Failed to load baseline: %v", err)
+		}
+		assessment.Baseline = baseline.Compare(assessment)
+	}
 
-	fmt.Println("=== Kube Upgrade Advisor - Impact Analysis ===\n")
+	if policyDir != "" {
+		writeGeneratedPolicies(assessment, policyDir, progress)
+	}
 
-	// Create inventory store
-	store, err := inventory.NewStore(dbPath)
-	if err != nil {
-		log.Fatalf("Failed to create store: %v", err)
+	// redact only the output: the baseline, generated policies and CSV owners need the real names
+	var csvRows []analysis.CSVRow
+	if outputFormat == "csv" {
+		csvRows = analysis.CSVRows(assessment, ownership)
+	}
+	if redactOutput {
+		if err := redact.NewRedactor().Redact(assessment, plan, &csvRows); err != nil {
+			fatalf("Failed to redact assessment: %v", err)
+		}
 	}
-	defer store.Close()
 
-	// create analyzer with both knowledge bases
-	chartKnowledgePath := "knowledge-base/chart-matrix.json"
-	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, chartKnowledgePath, store)
-	if err != nil {
-		log.Fatalf("Failed to create analyzer: %v", err)
+	if reportTemplate != "" {
+		if err := report.RenderTemplate(os.Stdout, reportTemplate, report.NewData(assessment, plan)); err != nil {
+			fatalf("Failed to render report: %v", err)
+		}
+		exitOnNewFindings(assessment)
+		return
 	}
 
-	// compute impact
-	clusterID := "cluster-1"
-	fmt.Printf("Analyzing upgrade impact for target version: %s\n", targetVersion)
+	if outputFormat == "pdf" {
+		if err := report.WritePDF(os.Stdout, report.NewData(assessment, plan)); err != nil {
+			fatalf("Failed to render PDF report: %v", err)
+		}
+		exitOnNewFindings(assessment)
+		return
+	}
 
-	assessment, err := analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
-	if err != nil {
-		log.Fatalf("Failed to compute impact: %v", err)
+	if outputFormat == "csv" {
+		data, err := analysis.RenderCSV(csvRows)
+		if err != nil {
+			fatalf("Failed to render CSV: %v", err)
+		}
+		os.Stdout.Write(data)
+		exitOnNewFindings(assessment)
+		return
 	}
 
-	//generate upgrade plan
-	planGenerator := planner.NewPlanner()
-	plan, err := planGenerator.GeneratePlan(assessment)
-	if err != nil {
-		log.Printf("Warning: Failed to generate upgrade plan: %v", err)
+	if outputFormat == "junit" {
+		data, err := analysis.RenderJUnit(assessment)
+		if err != nil {
+			fatalf("Failed to render JUnit report: %v", err)
+		}
+		fmt.Println(string(data))
+		exitOnNewFindings(assessment)
+		return
+	}
+
+	if outputFormat != "text" {
+		response := &planner.UpgradeAssessmentWithPlan{
+			ImpactAssessment: assessment,
+		}
+		if plan != nil {
+			response.OrderedUpgradeSteps = plan.OrderedUpgradeSteps
+			response.UpgradePlan = plan
+		}
+		if err := printStructured(response, outputFormat); err != nil {
+			fatalf("Failed to print result: %v", err)
+		}
+		exitOnNewFindings(assessment)
+		return
 	}
 
 	// generate and print report
-	report := analyzer.GenerateReport(assessment)
-	fmt.Println(report)
+	textReport := analyzer.GenerateReport(assessment)
+	fmt.Println(textReport)
 
 	// print upgrade plan
 	if plan != nil && len(plan.OrderedUpgradeSteps) > 0 {
-		fmt.Println("📋 UPGRADE PLAN")
+		fmt.Println(localizer.T("📋 UPGRADE PLAN"))
 		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 		for _, step := range plan.OrderedUpgradeSteps {
 			fmt.Printf("   %s\n", step)
 		}
-		fmt.Printf("\nEstimated Timeline: %s\n", plan.Timeline)
+		fmt.Print(localizer.T("\nEstimated Timeline: %s\n", plan.Timeline))
 		fmt.Println()
 	}
+
+	exitOnNewFindings(assessment)
+}
+
+// defaultBaselinePath is where --write-baseline writes when --baseline is not set
+const defaultBaselinePath = "baseline.json"
+
+// exitOnNewFindings fails the run when a baseline was given and findings not in it were introduced
+func exitOnNewFindings(assessment *analysis.ImpactAssessment) {
+	if assessment.Baseline == nil || len(assessment.Baseline.NewFindings) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d new finding(s) not in the baseline\n", len(assessment.Baseline.NewFindings))
+	auditFailure(fmt.Sprintf("%d new finding(s) not in the baseline", len(assessment.Baseline.NewFindings)))
+	exit(1)
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	ctx := context.Background()
+	ctx, cancel := commandContext()
+	defer cancel()
 
-	store, err := inventory.NewStore(dbPath)
-	if err != nil {
-		log.Fatalf("Failed to create store: %v", err)
+	if selector != "" && serverURL != "" {
+		printClusters(matchingRemoteClusters(ctx, newAPIClient()))
+		return
 	}
-	defer store.Close()
+	if selector != "" {
+		store, err := openStore()
+		if err != nil {
+			fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
 
-	clusterID := "cluster-1"
-	cluster, err := store.GetCluster(ctx, clusterID)
-	if err != nil {
-		log.Fatalf("Failed to get cluster: %v", err)
+		listClusters(ctx, store)
+		return
 	}
+	printInventory(loadInventory(ctx))
+}
 
+// printInventory prints a cluster's inventory, grouping manifest APIs by group, version and kind
+func printInventory(export *inventory.Export) {
 	fmt.Printf("=== Cluster Inventory ===\n")
-	fmt.Printf("Cluster: %s\n", cluster.ID)
-	fmt.Printf("Version: %s\n\n", cluster.KubeVersion)
+	fmt.Printf("Cluster: %s\n", export.Cluster.ID)
+	fmt.Printf("Version: %s\n", export.Cluster.KubeVersion)
+	if len(export.Cluster.Labels) > 0 {
+		fmt.Printf("Labels: %s\n", inventory.FormatLabels(export.Cluster.Labels))
+	}
+	fmt.Printf("Scan: %s\n", scanStatus(export.Cluster.IncompleteReason, export.Cluster.ScanErrors))
+	if export.Cluster.IncompleteReason != "" {
+		fmt.Printf("  stopped early: %s\n", export.Cluster.IncompleteReason)
+	}
+	for _, subsystem := range inventory.ScanErrorSubsystems(export.Cluster.ScanErrors) {
+		fmt.Printf("  %s not scanned: %s\n", subsystem, export.Cluster.ScanErrors[subsystem])
+	}
+	fmt.Println()
 
 	// List Helm Releases
-	helmReleases, _ := cluster.QueryHelmReleases().All(ctx)
-	fmt.Printf("Helm Releases (%d):\n", len(helmReleases))
-	for _, hr := range helmReleases {
+	fmt.Printf("Helm Releases (%d):\n", len(export.HelmReleases))
+	for _, hr := range export.HelmReleases {
 		fmt.Printf("  - %s/%s (chart: %s-%s)\n", hr.Namespace, hr.Name, hr.Chart, hr.ChartVersion)
 	}
 	fmt.Println()
 
 	// List CRDs
-	crds, _ := cluster.QueryCrds().All(ctx)
-	fmt.Printf("CRDs (%d):\n", len(crds))
-	for _, crd := range crds {
+	fmt.Printf("CRDs (%d):\n", len(export.CRDs))
+	for _, crd := range export.CRDs {
 		fmt.Printf("  - %s (group: %s, kind: %s)\n", crd.Name, crd.Group, crd.Kind)
 	}
 	fmt.Println()
 
 	// List Manifest APIs
-	manifestAPIs, _ := cluster.QueryManifestApis().All(ctx)
-	fmt.Printf("Manifest APIs (%d):\n", len(manifestAPIs))
+	fmt.Printf("Manifest APIs (%d):\n", len(export.ManifestAPIs))
 	apiMap := make(map[string]int)
-	for _, api := range manifestAPIs {
+	for _, api := range export.ManifestAPIs {
 		key := fmt.Sprintf("%s/%s %s", api.Group, api.Version, api.Kind)
 		if api.Group == "" {
 			key = fmt.Sprintf("%s %s", api.Version, api.Kind)
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+// networkOptions are the outbound connection flags, defaulting to their environment variables
+var networkOptions = network.OptionsFromEnv()
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&networkOptions.Proxy, "proxy", networkOptions.Proxy, "Proxy URL for all outbound connections, e.g. http://proxy.corp:3128 (default: $KUBE_ADVISOR_PROXY, else $HTTPS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&networkOptions.NoProxy, "no-proxy", networkOptions.NoProxy, "Comma-separated hosts, domains and CIDRs reached without --proxy (default: $KUBE_ADVISOR_NO_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&networkOptions.CAFile, "ca-file", networkOptions.CAFile, "PEM bundle of CA certificates to trust besides the system ones, e.g. of a TLS-intercepting proxy (default: $KUBE_ADVISOR_CA_FILE)")
+	rootCmd.PersistentFlags().BoolVar(&networkOptions.InsecureSkipTLSVerify, "insecure-skip-tls-verify", networkOptions.InsecureSkipTLSVerify, "Skip TLS certificate verification of all outbound connections; insecure (default: $KUBE_ADVISOR_INSECURE_SKIP_TLS_VERIFY)")
+}
+
+// setupNetwork applies the outbound connection flags, before any client is created
+func setupNetwork() {
+	if err := network.Configure(networkOptions); err != nil {
+		fatal(usageErrorf("Invalid network settings: %w", err))
+	}
+	if networkOptions.InsecureSkipTLSVerify {
+		log.Printf("Warning: TLS certificate verification is disabled for all connections")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// offline forbids network access besides the Kubernetes API, for air-gapped and regulated environments
+var offline bool
+
+// offlineDefault reads the default of --offline from $KUBE_ADVISOR_OFFLINE
+func offlineDefault() bool {
+	value, _ := strconv.ParseBool(os.Getenv("KUBE_ADVISOR_OFFLINE"))
+	return value
+}
+
+// requireNetwork exits when a feature needing network access beyond the Kubernetes API is used
+// with --offline
+func requireNetwork(feature string) {
+	if offline {
+		fatal(usageErrorf("%s needs network access beyond the Kubernetes API, which --offline forbids", feature))
+	}
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planClusterID       string
+	planFormat          string
+	planSkipBackup      bool
+	planIncludeRollback bool
+	planImagesMirror    string
+	planImagesTool      string
+	requireApproval     bool
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Generate and manage upgrade plans",
+	Long: `Generates an upgrade plan for a scanned cluster and stores it in the database, so it can be listed, shown and deleted later.
+Stored plans are drafts until a reviewer approves them, and are marked as executed once carried out, so
+upgrades can follow a change-control process.`,
+	Run: runPlan,
+}
+
+var planListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored upgrade plans",
+	Args:  cobra.NoArgs,
+	Run:   runPlanList,
+}
+
+var planShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a stored upgrade plan",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPlanShow,
+}
+
+var planDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a stored upgrade plan",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPlanDelete,
+}
+
+var planImagesCmd = &cobra.Command{
+	Use:   "images <id>",
+	Short: "List the container images a stored plan needs",
+	Long: `Lists the images the upgrade pulls (Kubernetes components, recommended chart versions and addon upgrades),
+so air-gapped environments can mirror them before the upgrade window. With --mirror, prints skopeo or crane
+commands copying them into the mirror registry instead.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPlanImages,
+}
+
+var planApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a draft upgrade plan",
+	Long: `Approves a draft plan, recording the reviewer: $KUBE_ADVISOR_ACTOR or the OS user, or the holder of the API
+token with --server.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPlanApprove,
+}
+
+var planExecuteCmd = &cobra.Command{
+	Use:   "execute <id>",
+	Short: "Mark an upgrade plan as executed",
+	Long: `Marks a plan as executed once its runbook has been carried out, recording who executed it. The plan's
+commands are not run by the advisor. With --require-approval, or when the server requires approval, plans
+that weren't approved are refused.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPlanExecute,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	planCmd.MarkFlagRequired("target")
+	planCmd.Flags().BoolVar(&planSkipBackup, "skip-backup", false, "Leave out the backup step, e.g. when backups are taken out of band")
+	planCmd.Flags().StringVar(&customStepsPath, "custom-steps", "", "YAML file of operational steps to inject into the plan")
+	planCmd.Flags().BoolVar(&planIncludeRollback, "include-rollback", false, "Add a contingency step restoring the pre-upgrade state if validation fails")
+	planCmd.PersistentFlags().StringVar(&planClusterID, "cluster", "cluster-1", "Cluster ID in the database")
+	planCmd.PersistentFlags().StringVar(&planFormat, "format", "text", "Output format: text, json or yaml")
+
+	planCmd.AddCommand(planListCmd)
+	planCmd.AddCommand(planShowCmd)
+	planCmd.AddCommand(planDeleteCmd)
+	planCmd.AddCommand(planApproveCmd)
+
+	requireApprovalDefault, _ := strconv.ParseBool(os.Getenv("KUBE_ADVISOR_REQUIRE_APPROVAL"))
+	planExecuteCmd.Flags().BoolVar(&requireApproval, "require-approval", requireApprovalDefault, "Refuse to execute plans no reviewer approved (default: $KUBE_ADVISOR_REQUIRE_APPROVAL)")
+	planCmd.AddCommand(planExecuteCmd)
+
+	planImagesCmd.Flags().StringVar(&planImagesMirror, "mirror", "", "Mirror registry to print copy commands for, e.g. registry.internal:5000/k8s")
+	planImagesCmd.Flags().StringVar(&planImagesTool, "tool", "skopeo", "Copy tool for --mirror: skopeo or crane")
+	planCmd.AddCommand(planImagesCmd)
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validatePlanFormat(planFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+
+	startAudit(cmd, args, inventory.AuditPlan, planClusterID)
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, knowledgeFile("chart-matrix.json"), store)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+	analyzer.SetLocalizer(localizer)
+	loadKnowledge(analyzer)
+
+	assessment, err := analyzer.ComputeUpgradeImpact(ctx, planClusterID, targetVersion)
+	if err != nil {
+		fatalf("Failed to compute impact: %v", err)
+	}
+
+	storedAssessment, err := saveAssessment(ctx, store, assessment, false)
+	if err != nil {
+		fatalf("Failed to store assessment: %v", err)
+	}
+
+	options := planner.Options{
+		SkipBackup:      planSkipBackup,
+		IncludeRollback: planIncludeRollback,
+		ConversionTool:  planner.DetectConversionTool(),
+	}
+	planGenerator := planner.NewPlanner()
+	planGenerator.SetLocalizer(localizer)
+	planGenerator.SetOptions(options)
+	setCustomSteps(planGenerator)
+	plan, err := planGenerator.GeneratePlan(assessment)
+	if err != nil {
+		fatalf("Failed to generate upgrade plan: %v", err)
+	}
+
+	saved, err := savePlan(ctx, store, planClusterID, storedAssessment.ID, plan, options)
+	if err != nil {
+		fatalf("Failed to store plan: %v", err)
+	}
+	auditSummary("plan %d from %s to %s with %d steps", saved.ID, plan.FromVersion, plan.ToVersion, plan.TotalSteps)
+
+	if err := printPlan(saved.ID, planClusterID, plan, localizer); err != nil {
+		fatalf("Failed to print plan: %v", err)
+	}
+}
+
+func runPlanList(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	clusterID := ""
+	if cmd.Flags().Changed("cluster") {
+		clusterID = planClusterID
+	}
+	plans, err := store.ListPlans(ctx, clusterID)
+	if err != nil {
+		fatalf("Failed to list plans: %v", err)
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("No plans stored. Run 'kube-upgrade-advisor plan --target <version>' to generate one.")
+		return
+	}
+
+	fmt.Printf("%-6s %-16s %-10s %-10s %-6s %-9s %-20s %s\n", "ID", "CLUSTER", "FROM", "TARGET", "STEPS", "STATE", "CREATED", "OPTIONS")
+	for _, plan := range plans {
+		fmt.Printf("%-6d %-16s %-10s %-10s %-6d %-9s %-20s %s\n",
+			plan.ID, planCluster(plan), plan.FromVersion, plan.TargetVersion, plan.TotalSteps, plan.State,
+			plan.CreatedAt.Format("2006-01-02 15:04:05"), planOptions(plan))
+	}
+}
+
+func runPlanShow(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	if err := validatePlanFormat(planFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fatal(usageErrorf("Invalid plan ID %q: %w", args[0], err))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	stored, err := store.GetPlan(ctx, id)
+	if err != nil {
+		fatalf("Failed to get plan %d: %v", id, err)
+	}
+
+	var plan planner.UpgradePlan
+	if err := json.Unmarshal([]byte(stored.Document), &plan); err != nil {
+		fatalf("Failed to unmarshal plan %d: %v", id, err)
+	}
+
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+	info := api.NewPlanInfo(stored)
+	if planFormat != "text" {
+		output := &storedPlan{
+			ID:         stored.ID,
+			ClusterID:  info.ClusterID,
+			State:      info.State,
+			ApprovedBy: info.ApprovedBy,
+			ApprovedAt: info.ApprovedAt,
+			ExecutedBy: info.ExecutedBy,
+			ExecutedAt: info.ExecutedAt,
+			Plan:       &plan,
+		}
+		if err := printStructured(output, planFormat); err != nil {
+			fatalf("Failed to print plan: %v", err)
+		}
+		return
+	}
+	if err := printPlan(stored.ID, info.ClusterID, &plan, localizer); err != nil {
+		fatalf("Failed to print plan: %v", err)
+	}
+	fmt.Printf("\nState: %s\n", describePlanState(info))
+}
+
+func runPlanDelete(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fatal(usageErrorf("Invalid plan ID %q: %w", args[0], err))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.DeletePlan(ctx, id); err != nil {
+		fatalf("Failed to delete plan %d: %v", id, err)
+	}
+	fmt.Printf("Deleted plan %d\n", id)
+}
+
+func runPlanApprove(cmd *cobra.Command, args []string) {
+	transitionPlan(cmd, args, inventory.AuditApprove, func(ctx context.Context, id int) (*api.PlanInfo, error) {
+		if serverURL != "" {
+			return newAPIClient().ApprovePlan(ctx, id)
+		}
+		return updateLocalPlan(ctx, func(store *inventory.Store) (*ent.Plan, error) {
+			return store.ApprovePlan(ctx, id, auditUser())
+		})
+	})
+}
+
+func runPlanExecute(cmd *cobra.Command, args []string) {
+	transitionPlan(cmd, args, inventory.AuditExecute, func(ctx context.Context, id int) (*api.PlanInfo, error) {
+		if serverURL != "" {
+			return newAPIClient().ExecutePlan(ctx, id, requireApproval)
+		}
+		return updateLocalPlan(ctx, func(store *inventory.Store) (*ent.Plan, error) {
+			return store.ExecutePlan(ctx, id, auditUser(), requireApproval)
+		})
+	})
+}
+
+// transitionPlan changes the state of the plan given as argument and prints its new state
+func transitionPlan(cmd *cobra.Command, args []string, action string, transition func(context.Context, int) (*api.PlanInfo, error)) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fatal(usageErrorf("Invalid plan ID %q: %w", args[0], err))
+	}
+	if serverURL == "" {
+		startAudit(cmd, args, action, "")
+	}
+
+	plan, err := transition(ctx, id)
+	if err != nil {
+		fatalf("Failed to update plan %d: %v", id, err)
+	}
+	auditCluster(plan.ClusterID)
+	auditSummary("plan %d from %s to %s %s", plan.ID, plan.FromVersion, plan.TargetVersion, plan.State)
+
+	fmt.Printf("Plan %d: %s\n", plan.ID, describePlanState(*plan))
+}
+
+// updateLocalPlan changes a plan in the local database
+func updateLocalPlan(ctx context.Context, update func(*inventory.Store) (*ent.Plan, error)) (*api.PlanInfo, error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+	defer store.Close()
+
+	plan, err := update(store)
+	if err != nil {
+		return nil, err
+	}
+	info := api.NewPlanInfo(plan)
+	return &info, nil
+}
+
+// describePlanState describes the state of a plan with who approved and executed it when
+func describePlanState(plan api.PlanInfo) string {
+	description := plan.State
+	if plan.ApprovedAt != nil {
+		description += fmt.Sprintf(", approved by %s on %s", plan.ApprovedBy, plan.ApprovedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if plan.ExecutedAt != nil {
+		description += fmt.Sprintf(", executed by %s on %s", plan.ExecutedBy, plan.ExecutedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	return description
+}
+
+func runPlanImages(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	if err := validatePlanFormat(planFormat); err != nil {
+		fatal(usageErrorf("Invalid --format value: %w", err))
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fatal(usageErrorf("Invalid plan ID %q: %w", args[0], err))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	stored, err := store.GetPlan(ctx, id)
+	if err != nil {
+		fatalf("Failed to get plan %d: %v", id, err)
+	}
+
+	var plan planner.UpgradePlan
+	if err := json.Unmarshal([]byte(stored.Document), &plan); err != nil {
+		fatalf("Failed to unmarshal plan %d: %v", id, err)
+	}
+
+	if len(plan.Images) == 0 && len(plan.ImageCommands) == 0 {
+		fatal(noDataErrorf("Plan %d has no image list; regenerate it with 'kube-upgrade-advisor plan --target %s'", id, plan.ToVersion))
+	}
+
+	var mirrorCommands []string
+	if planImagesMirror != "" {
+		mirrorCommands, err = planner.MirrorCommands(plan.Images, planImagesTool, planImagesMirror)
+		if err != nil {
+			fatal(usageErrorf("Invalid --tool value: %w", err))
+		}
+	}
+
+	if planFormat != "text" {
+		list := &planImageList{
+			PlanID:         stored.ID,
+			Images:         plan.Images,
+			Commands:       plan.ImageCommands,
+			MirrorCommands: mirrorCommands,
+		}
+		if err := printStructured(list, planFormat); err != nil {
+			fatalf("Failed to print images: %v", err)
+		}
+		return
+	}
+
+	// Keep stdout a plain list, to pipe into mirroring scripts
+	if planImagesMirror != "" {
+		for _, command := range mirrorCommands {
+			fmt.Println(command)
+		}
+	} else {
+		for _, image := range plan.Images {
+			fmt.Println(image.Reference)
+		}
+	}
+	if len(plan.ImageCommands) > 0 {
+		fmt.Fprintln(os.Stderr, "Also mirror the images listed by:")
+		for _, command := range plan.ImageCommands {
+			fmt.Fprintf(os.Stderr, "  $ %s\n", command)
+		}
+	}
+}
+
+// planImageList is the structured output of plan images
+type planImageList struct {
+	PlanID         int             `json:"planId"`
+	Images         []planner.Image `json:"images"`
+	Commands       []string        `json:"commands,omitempty"`
+	MirrorCommands []string        `json:"mirrorCommands,omitempty"`
+}
+
+// validatePlanFormat checks the --format flag of the plan commands
+func validatePlanFormat(format string) error {
+	switch format {
+	case "text", "json", "yaml":
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q (supported: text, json, yaml)", format)
+	}
+}
+
+// storedPlan is the structured output of a stored plan
+type storedPlan struct {
+	ID         int                  `json:"id"`
+	ClusterID  string               `json:"clusterId"`
+	State      string               `json:"state,omitempty"`
+	ApprovedBy string               `json:"approvedBy,omitempty"`
+	ApprovedAt *time.Time           `json:"approvedAt,omitempty"`
+	ExecutedBy string               `json:"executedBy,omitempty"`
+	ExecutedAt *time.Time           `json:"executedAt,omitempty"`
+	Plan       *planner.UpgradePlan `json:"plan"`
+}
+
+// printPlan prints a plan with its steps and actions in the --format format
+func printPlan(id int, clusterID string, plan *planner.UpgradePlan, localizer *i18n.Localizer) error {
+	if planFormat != "text" {
+		return printStructured(&storedPlan{ID: id, ClusterID: clusterID, Plan: plan}, planFormat)
+	}
+
+	writePlanText(os.Stdout, id, clusterID, plan, localizer)
+	return nil
+}
+
+// writePlanText writes a plan as a numbered runbook
+func writePlanText(w io.Writer, id int, clusterID string, plan *planner.UpgradePlan, localizer *i18n.Localizer) {
+	fmt.Fprintf(w, "%s #%d: %s (%s -> %s)\n", localizer.T("📋 UPGRADE PLAN"), id, clusterID, plan.FromVersion, plan.ToVersion)
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	steps := make([]planner.UpgradeStep, len(plan.Steps))
+	copy(steps, plan.Steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Order < steps[j].Order
+	})
+
+	for i, step := range steps {
+		fmt.Fprintf(w, "%d. [%s] %s\n", i+1, step.Type, step.Description)
+		for _, action := range step.Actions {
+			optional := ""
+			if !action.Required {
+				optional = " (optional)"
+			}
+			fmt.Fprintf(w, "   - %s%s\n", action.Description, optional)
+			if action.Command != "" {
+				fmt.Fprintf(w, "     $ %s\n", action.Command)
+			}
+		}
+	}
+	fmt.Fprint(w, localizer.T("\nEstimated Timeline: %s\n", plan.Timeline))
+}
+
+// planCluster returns the ID of the cluster a stored plan belongs to
+func planCluster(plan *ent.Plan) string {
+	if plan.Edges.Cluster == nil {
+		return ""
+	}
+	return plan.Edges.Cluster.ID
+}
+
+// planOptions describes the options a stored plan was generated with
+func planOptions(plan *ent.Plan) string {
+	options := ""
+	if plan.SkipBackup {
+		options += "skip-backup "
+	}
+	if plan.IncludeRollback {
+		options += "include-rollback"
+	}
+	if options == "" {
+		return "-"
+	}
+	return options
+}
+
+// setCustomSteps loads the --custom-steps file into the planner
+func setCustomSteps(planGenerator *planner.Planner) {
+	if customStepsPath == "" {
+		return
+	}
+	steps, err := planner.LoadCustomSteps(customStepsPath)
+	if err != nil {
+		fatalf("Failed to load custom steps: %v", err)
+	}
+	planGenerator.SetCustomSteps(steps)
+}
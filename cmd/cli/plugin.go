@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// pluginName is the command name when installed as a kubectl plugin (binary kubectl-upgrade_advisor)
+const pluginName = "kubectl upgrade-advisor"
+
+// configurePluginMode makes help and usage read "kubectl upgrade-advisor ..." when run as a kubectl plugin
+func configurePluginMode() {
+	if !strings.HasPrefix(filepath.Base(os.Args[0]), "kubectl-") {
+		return
+	}
+	if rootCmd.Annotations == nil {
+		rootCmd.Annotations = make(map[string]string)
+	}
+	rootCmd.Annotations[cobra.CommandDisplayNameAnnotation] = pluginName
+}
+
+// newKubeClient creates a Kubernetes client honoring --kubeconfig, $KUBECONFIG and --context like kubectl
+func newKubeClient() (*cluster.KubeClient, error) {
+	kubeClient, err := cluster.NewKubeClientFromFlags(kubeconfig, kubeContext)
+	if err != nil {
+		return nil, &cliError{code: exitConnection, hint: connectionHint, err: err}
+	}
+	return kubeClient, nil
+}
+
+// newHelmClient creates a Helm client for the cluster of newKubeClient reading releases with --helm-driver,
+// scoped to a namespace when set
+func newHelmClient(namespace string) (*cluster.HelmClient, error) {
+	helmClient, err := cluster.NewHelmClientWithOptions(cluster.HelmOptions{
+		Kubeconfig: kubeconfig,
+		Context:    kubeContext,
+		Namespace:  namespace,
+		Drivers:    helmDrivers,
+	})
+	if err != nil {
+		return nil, usageErrorf("Invalid --helm-driver value: %w", err)
+	}
+	return helmClient, nil
+}
+
+// knowledgeFile resolves a knowledge base file from ./knowledge-base, falling back to the
+// knowledge-base folder installed next to the binary (as in the krew archive)
+func knowledgeFile(name string) string {
+	local := filepath.Join("knowledge-base", name)
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		installed := filepath.Join(filepath.Dir(exe), "knowledge-base", name)
+		if _, err := os.Stat(installed); err == nil {
+			return installed
+		}
+	}
+
+	return local
+}
+
+// loadKnowledge loads the knowledge bases shared by every analysis, warning about missing ones
+func loadKnowledge(analyzer *analysis.Analyzer) {
+	for _, err := range analyzer.LoadKnowledge(knowledgeFile) {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// validateOutputFormat checks the -o flag against the supported formats
+func validateOutputFormat(format string) error {
+	switch format {
+	case "text", "json", "yaml", "junit", "pdf", "csv":
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: text, json, yaml, junit, pdf, csv)", format)
+	}
+}
+
+// printStructured writes v to stdout as JSON or YAML, using the JSON field names for both
+func printStructured(v interface{}, format string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	if format == "json" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to convert output: %w", err)
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output as YAML: %w", err)
+	}
+	fmt.Print(string(out))
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// policyDir is the folder to write the policies generated from PodSecurityPolicies to
+var policyDir string
+
+// writeGeneratedPolicies writes each ValidatingAdmissionPolicy generated to replace a
+// PodSecurityPolicy to its own file in dir
+func writeGeneratedPolicies(assessment *analysis.ImpactAssessment, dir string, progress io.Writer) {
+	var policies []analysis.GeneratedPolicy
+	if assessment.PolicyMigration != nil {
+		policies = assessment.PolicyMigration.Manifests()
+	}
+	if len(policies) == 0 {
+		fmt.Fprintln(progress, "No policies generated: needs PodSecurityPolicy manifests, no policy engine and a target of at least 1.28")
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fatalf("Failed to create policy directory: %v", err)
+	}
+
+	for _, policy := range policies {
+		path := filepath.Join(dir, policy.Name+".yaml")
+		if err := os.WriteFile(path, []byte(policy.Manifest), 0o644); err != nil {
+			fatalf("Failed to write policy %s: %v", policy.Name, err)
+		}
+	}
+	fmt.Fprintf(progress, "Wrote %d generated policies to %s\n", len(policies), dir)
+}
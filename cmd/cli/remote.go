@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// newAPIClient creates a client for the --server URL
+func newAPIClient() *api.Client {
+	requireNetwork("--server")
+	client, err := api.NewClient(serverURL)
+	if err != nil {
+		fatal(usageErrorf("Invalid --server value: %w", err))
+	}
+	// read from the environment only, so the token doesn't show up in process listings
+	client.SetToken(os.Getenv("KUBE_ADVISOR_TOKEN"))
+	return client
+}
+
+// remoteImpact fetches the assessment and plan of a cluster from the --server, either freshly
+// computed or, with --from-cache, the last one stored there
+func remoteImpact(ctx context.Context, progress io.Writer) (*analysis.ImpactAssessment, *planner.UpgradePlan) {
+	client := newAPIClient()
+
+	clusterID := clusterIDFlag
+	if selector != "" {
+		clusterID = selectRemoteCluster(ctx, client)
+	}
+
+	var result *planner.UpgradeAssessmentWithPlan
+	if fromCache {
+		stored, err := client.Assessments(ctx, clusterID, targetVersion)
+		if err != nil {
+			fatalf("Failed to list stored assessments: %v", err)
+		}
+		if len(stored) == 0 {
+			fatal(noDataErrorf("Failed to load cached assessment: no stored assessment for %s targeting %s on %s", clusterID, targetVersion, serverURL))
+		}
+
+		result, err = client.Assessment(ctx, stored[0].ID)
+		if err != nil {
+			fatalf("Failed to load cached assessment: %v", err)
+		}
+		// baseline comparisons are made per run
+		result.ImpactAssessment.Baseline = nil
+		fmt.Fprintf(progress, "Using stored assessment #%d from %s\n", stored[0].ID, stored[0].CreatedAt.Format("2006-01-02 15:04:05"))
+	} else {
+		var err error
+		result, err = client.Impact(ctx, clusterID, targetVersion, lang)
+		if err != nil {
+			fatalf("Failed to compute impact: %v", err)
+		}
+	}
+
+	if result.ImpactAssessment == nil {
+		fatalf("Failed to compute impact: server returned no assessment")
+	}
+	return result.ImpactAssessment, result.UpgradePlan
+}
+
+// remoteScan starts a scan on the --server and prints its progress until it finishes
+func remoteScan(ctx context.Context) {
+	client := newAPIClient()
+
+	status, err := client.StartScan(ctx, clusterIDFlag, incrementalScan)
+	if err != nil {
+		fatalf("Failed to start scan: %v", err)
+	}
+	id := status.ID
+	fmt.Printf("Started scan #%d of %s on %s\n\n", id, status.ClusterID, serverURL)
+
+	status, err = client.FollowScan(ctx, id, printScanProgress())
+	if err != nil {
+		fatalf("Failed to follow scan #%d: %v", id, err)
+	}
+	fmt.Println()
+
+	switch status.State {
+	case api.ScanFailed:
+		fatalf("Scan failed on the server: %s", status.Error)
+	case api.ScanPartial:
+		fmt.Println("=== Scan Complete (partial) ===")
+		log.Printf("Warning: partial scan, these parts could not be scanned: %s", strings.Join(inventory.ScanErrorSubsystems(status.ScanErrors), ", "))
+	default:
+		fmt.Println("=== Scan Complete! ===")
+	}
+	fmt.Printf("Server: %s\n", serverURL)
+	fmt.Printf("\nRun 'kube-upgrade-advisor impact --server %s --cluster %s --target <version>' to analyze upgrade impact\n", serverURL, status.ClusterID)
+}
+
+// matchingRemoteClusters returns the clusters on the --server matching the --selector flag
+func matchingRemoteClusters(ctx context.Context, client *api.Client) []api.ClusterInfo {
+	clusters, err := client.Clusters(ctx, selector)
+	if err != nil {
+		fatalf("Failed to list clusters: %v", err)
+	}
+	return clusters
+}
+
+// selectRemoteCluster resolves the --selector flag to the ID of the single matching cluster on the --server
+func selectRemoteCluster(ctx context.Context, client *api.Client) string {
+	clusters := matchingRemoteClusters(ctx, client)
+	switch len(clusters) {
+	case 0:
+		fatal(noDataErrorf("No cluster matches selector %q", selector))
+	case 1:
+		return clusters[0].ID
+	}
+
+	ids := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		ids[i] = cluster.ID
+	}
+	fatal(usageErrorf("Selector %q matches %d clusters (%s); narrow it down or use 'fleet impact --selector'", selector, len(clusters), strings.Join(ids, ", ")))
+	return ""
+}
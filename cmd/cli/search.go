@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchClusterID string
+	searchTypes     []string
+	searchLimit     int
+	searchFormat    string
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <pattern>",
+	Short: "Search chart names, CRDs, GVKs and manifest file paths across the fleet",
+	Long: `Searches the Helm releases, CRDs, manifest APIs and resources of every cluster in the database, or of
+the server with --server. The pattern matches case-insensitively anywhere in release and chart names,
+CRD names, GVKs like "batch/v1beta1 CronJob", resource names and manifest file paths; * matches any
+characters. For example, 'search batch/v1beta1' finds everything still using batch/v1beta1.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchClusterID, "cluster", "", "Only search this cluster (default: all clusters)")
+	searchCmd.Flags().StringSliceVar(&searchTypes, "type", nil, "Only search these types, comma-separated: "+strings.Join(inventory.SearchTypes, ", "))
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 200, "Show at most this many matches; 0 for all")
+	searchCmd.Flags().StringVar(&searchFormat, "format", "text", "Output format: text, json or yaml")
+	rootCmd.AddCommand(searchCmd)
+}
+
+func runSearch(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if searchFormat != "text" && searchFormat != "json" && searchFormat != "yaml" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json, yaml)", searchFormat))
+	}
+	filter := inventory.SearchFilter{Pattern: args[0], ClusterID: searchClusterID, Types: searchTypes, Limit: searchLimit}
+	if err := filter.Validate(); err != nil {
+		fatal(usageErrorf("Invalid search: %w", err))
+	}
+
+	var result *inventory.SearchResult
+	if serverURL != "" {
+		var err error
+		result, err = newAPIClient().Search(ctx, filter)
+		if err != nil {
+			fatalf("Failed to search inventory: %v", err)
+		}
+	} else {
+		store, err := openStore()
+		if err != nil {
+			fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		result, err = store.Search(ctx, filter)
+		if err != nil {
+			fatalf("Failed to search inventory: %v", err)
+		}
+	}
+
+	if searchFormat != "text" {
+		if err := printStructured(result, searchFormat); err != nil {
+			fatalf("Failed to print search result: %v", err)
+		}
+		return
+	}
+
+	if result.Total == 0 {
+		fmt.Printf("Nothing matches %q\n", args[0])
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CLUSTER\tTYPE\tNAME\tDETAIL\tSOURCE\tFILE")
+	for _, match := range result.Matches {
+		file := match.File
+		if file != "" && match.Line > 0 {
+			file = fmt.Sprintf("%s:%d", file, match.Line)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", match.ClusterID, match.Type, match.Name, match.Detail, match.Source, file)
+	}
+	writer.Flush()
+	if len(result.Matches) < result.Total {
+		fmt.Printf("\nShowing %d of %d matches; raise --limit to see more\n", len(result.Matches), result.Total)
+	}
+}
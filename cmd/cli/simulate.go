@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/spf13/cobra"
+)
+
+var simulateFormat string
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Predict the cluster's state after an upgrade that follows the plan",
+	Long: `Predicts the post-upgrade state of a cluster: the APIs that stop being served, the releases that remain
+incompatible even after upgrading to their recommended chart versions, and the findings and risk that
+remain once the plan has upgraded the charts and migrated deprecated APIs to their replacements. Use it
+to validate that the plan fully de-risks the upgrade. Nothing is stored.`,
+	Args: cobra.NoArgs,
+	Run:  runSimulate,
+}
+
+func init() {
+	simulateCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	simulateCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	simulateCmd.Flags().StringVar(&simulateFormat, "format", "text", "Output format: text, json or yaml")
+	simulateCmd.MarkFlagRequired("target")
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func runSimulate(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if simulateFormat != "text" && simulateFormat != "json" && simulateFormat != "yaml" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json, yaml)", simulateFormat))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	simulation, err := newClusterAnalyzer(store).Simulate(ctx, clusterIDFlag, targetVersion)
+	if err != nil {
+		fatalf("Failed to simulate upgrade: %v", err)
+	}
+
+	if simulateFormat != "text" {
+		if err := printStructured(simulation, simulateFormat); err != nil {
+			fatalf("Failed to print simulation: %v", err)
+		}
+		return
+	}
+	printSimulation(simulation)
+}
+
+// printSimulation prints the predicted post-upgrade state
+func printSimulation(simulation *analysis.Simulation) {
+	fmt.Printf("=== Simulated upgrade of %s: %s -> %s ===\n", simulation.ClusterID, simulation.CurrentVersion, simulation.TargetVersion)
+
+	fmt.Printf("\nAPIs no longer served (%d):\n", len(simulation.UnservedAPIs))
+	for _, api := range simulation.UnservedAPIs {
+		line := fmt.Sprintf("  - %s/%s %s (removed in %s)", api.Group, api.Version, api.Kind, api.RemovedIn)
+		if api.Group == "" {
+			line = fmt.Sprintf("  - %s %s (removed in %s)", api.Version, api.Kind, api.RemovedIn)
+		}
+		if api.ReplacementAPI != "" {
+			line += ", use " + api.ReplacementAPI
+		}
+		if api.InUse {
+			line += " [in use]"
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\nRecommended chart upgrades (%d):\n", len(simulation.ChartUpgrades))
+	for _, release := range simulation.ChartUpgrades {
+		fmt.Printf("  - %s/%s %s %s -> %s: %s\n", release.Namespace, release.Release, release.Chart, release.CurrentVersion,
+			release.ChartVersion, chartWhatIfState(release.After, release.IncompatibleAfter))
+	}
+
+	if len(simulation.RemainingIncompatible) > 0 {
+		fmt.Printf("\nReleases still incompatible (%d):\n", len(simulation.RemainingIncompatible))
+		for _, chart := range simulation.RemainingIncompatible {
+			fmt.Printf("  - %s/%s %s %s: %s\n", chart.Namespace, chart.Release, chart.ChartName, chart.CurrentVersion, chart.Message)
+		}
+	}
+
+	if len(simulation.ResidualFindings) > 0 {
+		fmt.Printf("\nResidual findings (%d):\n", len(simulation.ResidualFindings))
+		for _, finding := range simulation.ResidualFindings {
+			fmt.Printf("  - [%s] %s %s: %s\n", finding.RuleID, finding.Severity, finding.Resource, finding.Summary)
+		}
+	}
+
+	fmt.Printf("\nRisk: %s now, %s after the plan\n", simulation.RiskBefore, simulation.ResidualRisk)
+	if simulation.FullyDerisked() {
+		fmt.Println("The plan fully de-risks the upgrade.")
+	} else {
+		fmt.Println("The plan leaves residual findings; resolve them before the upgrade window.")
+	}
+}
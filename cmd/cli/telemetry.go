@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// otlpEndpoint overrides $OTEL_EXPORTER_OTLP_ENDPOINT
+var otlpEndpoint string
+
+// shutdownTelemetry flushes pending spans and metrics; a no-op until telemetry is set up
+var shutdownTelemetry = func() {}
+
+// telemetryFlushTimeout bounds flushing telemetry on exit
+const telemetryFlushTimeout = 5 * time.Second
+
+// setupTelemetry exports traces and metrics over OTLP when an endpoint is configured. A failure to
+// set up only disables telemetry.
+func setupTelemetry(cmd *cobra.Command, args []string) {
+	opts := telemetry.Options{ServiceName: "kube-upgrade-advisor", Endpoint: otlpEndpoint}
+	if !opts.Enabled() {
+		return
+	}
+	requireNetwork("OTLP export")
+
+	shutdown, err := telemetry.Setup(context.Background(), opts)
+	if err != nil {
+		log.Printf("Warning: telemetry disabled: %v", err)
+		return
+	}
+	shutdownTelemetry = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Warning: failed to export telemetry: %v", err)
+		}
+	}
+}
+
+// exit records the command in the audit log, flushes telemetry and exits with code
+func exit(code int) {
+	finishAudit(code)
+	shutdownTelemetry()
+	os.Exit(code)
+}
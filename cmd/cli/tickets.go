@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/tickets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ticketSink      string
+	ticketProject   string
+	ticketGroupBy   string
+	ticketIssueType string
+	ticketLabels    []string
+	ticketDryRun    bool
+	ticketFormat    string
+	jiraURL         string
+	jiraUser        string
+)
+
+var ticketsCmd = &cobra.Command{
+	Use:   "tickets",
+	Short: "File findings as tickets in a ticketing system",
+}
+
+var ticketsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create or update a ticket per owning team or per rule",
+	Long: `Analyzes the upgrade of a cluster and files its findings as tickets, one per team owning them (from the
+--owners map) or one per rule. Every ticket is recorded in the database under a key made of the cluster,
+the target version and the group, so running the command again updates the tickets it filed before instead
+of creating duplicates. Tickets of groups without findings anymore are updated to say so.
+
+The jira sink authenticates with $KUBE_ADVISOR_JIRA_TOKEN: an API token together with --jira-user on Jira
+Cloud, or a personal access token on Jira Data Center.`,
+	Args: cobra.NoArgs,
+	Run:  runTicketsCreate,
+}
+
+func init() {
+	ticketsCreateCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	ticketsCreateCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	ticketsCreateCmd.Flags().StringVar(&ticketSink, "sink", "", "Ticketing system: jira (required)")
+	ticketsCreateCmd.Flags().StringVar(&ticketProject, "project", "", "Project key to file tickets in, e.g. K8SUP (required)")
+	ticketsCreateCmd.Flags().StringVar(&ticketGroupBy, "group-by", tickets.GroupByOwner, "File a ticket per owner or per rule")
+	ticketsCreateCmd.Flags().StringVar(&ownersPath, "owners", "", "YAML file mapping namespaces and releases to owning teams")
+	ticketsCreateCmd.Flags().StringVar(&ticketIssueType, "issue-type", "Task", "Issue type of created tickets")
+	ticketsCreateCmd.Flags().StringSliceVar(&ticketLabels, "label", []string{"kube-upgrade-advisor"}, "Labels of the tickets (repeatable)")
+	ticketsCreateCmd.Flags().BoolVar(&ticketDryRun, "dry-run", false, "Show the tickets that would be created and updated without filing them")
+	ticketsCreateCmd.Flags().StringVar(&ticketFormat, "format", "text", "Output format: text, json or yaml")
+	ticketsCreateCmd.Flags().StringVar(&jiraURL, "jira-url", os.Getenv("KUBE_ADVISOR_JIRA_URL"), "Base URL of the Jira site (default: $KUBE_ADVISOR_JIRA_URL)")
+	ticketsCreateCmd.Flags().StringVar(&jiraUser, "jira-user", os.Getenv("KUBE_ADVISOR_JIRA_USER"), "Jira user the API token belongs to; empty to send it as a personal access token (default: $KUBE_ADVISOR_JIRA_USER)")
+	ticketsCreateCmd.MarkFlagRequired("target")
+	ticketsCreateCmd.MarkFlagRequired("sink")
+	ticketsCreateCmd.MarkFlagRequired("project")
+
+	ticketsCmd.AddCommand(ticketsCreateCmd)
+	rootCmd.AddCommand(ticketsCmd)
+}
+
+func runTicketsCreate(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if ticketFormat != "text" && ticketFormat != "json" && ticketFormat != "yaml" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json, yaml)", ticketFormat))
+	}
+	if ticketSink != tickets.SinkJira {
+		fatal(usageErrorf("Invalid --sink value: unsupported sink %q (supported: %s)", ticketSink, tickets.SinkJira))
+	}
+	if serverURL != "" {
+		fatal(usageErrorf("tickets create records tickets in the local database and cannot be combined with --server"))
+	}
+	if !ticketDryRun {
+		requireNetwork("tickets create")
+	}
+
+	var ownership *analysis.OwnershipMap
+	if ownersPath != "" {
+		var err error
+		if ownership, err = analysis.LoadOwnershipMap(ownersPath); err != nil {
+			fatal(usageErrorf("Invalid --owners value: %w", err))
+		}
+	}
+
+	// a dry run sends nothing, so it works without Jira settings
+	sink, err := tickets.NewJiraSink(tickets.JiraOptions{
+		URL:       jiraURL,
+		User:      jiraUser,
+		Token:     os.Getenv("KUBE_ADVISOR_JIRA_TOKEN"),
+		IssueType: ticketIssueType,
+		Labels:    ticketLabels,
+	})
+	if err != nil && !ticketDryRun {
+		fatal(usageErrorf("Invalid Jira settings: %w", err))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	if store.ReadOnly() && !ticketDryRun {
+		fatal(usageErrorf("tickets create records the tickets it files and needs a writable database; use --dry-run with --read-only"))
+	}
+
+	assessment, err := newClusterAnalyzer(store).ComputeUpgradeImpact(ctx, clusterIDFlag, targetVersion)
+	if err != nil {
+		fatalf("Failed to compute impact: %v", err)
+	}
+	built, err := tickets.Build(assessment, ownership, ticketGroupBy)
+	if err != nil {
+		fatal(usageErrorf("Invalid --group-by value: %w", err))
+	}
+
+	results, err := tickets.Publish(ctx, store, sink, ticketProject, clusterIDFlag, targetVersion, built, ticketDryRun)
+	if err != nil {
+		// report the tickets filed before the failure, so they aren't filed by hand
+		printTicketResults(results)
+		fatalf("Failed to file tickets: %v", err)
+	}
+
+	if ticketFormat != "text" {
+		if err := printStructured(results, ticketFormat); err != nil {
+			fatalf("Failed to print tickets: %v", err)
+		}
+		return
+	}
+	if len(results) == 0 {
+		fmt.Printf("No findings for the upgrade of %s to %s; no tickets filed\n", clusterIDFlag, targetVersion)
+		return
+	}
+	if ticketDryRun {
+		fmt.Println("Dry run: no tickets were filed")
+	}
+	printTicketResults(results)
+}
+
+// printTicketResults prints what was done to each ticket
+func printTicketResults(results []tickets.Result) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ACTION\tTICKET\tFINDINGS\tTITLE")
+	for _, result := range results {
+		key := result.ExternalKey
+		if key == "" {
+			key = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\n", result.Action, key, len(result.Findings), result.Title)
+	}
+	writer.Flush()
+}
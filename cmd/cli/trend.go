@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var trendCmd = &cobra.Command{
+	Use:   "trend",
+	Short: "Show how upgrade readiness evolved",
+	Long: `Lists the stored assessments of a cluster for a target version, oldest first, with the change
+in issues since the previous assessment, to track progress on the upgrade blockers`,
+	Args: cobra.NoArgs,
+	Run:  runTrend,
+}
+
+func init() {
+	trendCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	trendCmd.MarkFlagRequired("target")
+	trendCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+
+	rootCmd.AddCommand(trendCmd)
+}
+
+func runTrend(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var assessments []api.AssessmentInfo
+	if serverURL != "" {
+		var err error
+		assessments, err = newAPIClient().Assessments(ctx, clusterIDFlag, targetVersion)
+		if err != nil {
+			fatalf("Failed to list assessments: %v", err)
+		}
+	} else {
+		store, err := openStore()
+		if err != nil {
+			fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		stored, err := store.ListAssessments(ctx, clusterIDFlag, targetVersion)
+		if err != nil {
+			fatalf("Failed to list assessments: %v", err)
+		}
+		for _, assessment := range stored {
+			assessments = append(assessments, api.NewAssessmentInfo(assessment))
+		}
+	}
+
+	if len(assessments) == 0 {
+		fmt.Printf("No assessments of %s targeting %s. Run 'kube-upgrade-advisor impact --target %s' to record one.\n",
+			clusterIDFlag, targetVersion, targetVersion)
+		return
+	}
+
+	fmt.Printf("=== Upgrade Readiness Trend: %s -> %s ===\n\n", clusterIDFlag, targetVersion)
+	fmt.Printf("%-6s %-20s %-10s %-10s %-7s %s\n", "ID", "CREATED", "FROM", "RISK", "ISSUES", "CHANGE")
+
+	// assessments are listed newest first
+	for i := len(assessments) - 1; i >= 0; i-- {
+		assessment := assessments[i]
+		change := "-"
+		if i < len(assessments)-1 {
+			change = fmt.Sprintf("%+d", assessment.TotalIssues-assessments[i+1].TotalIssues)
+		}
+		fmt.Printf("%-6d %-20s %-10s %-10s %-7d %s\n",
+			assessment.ID, assessment.CreatedAt.Format("2006-01-02 15:04:05"), assessment.CurrentVersion,
+			assessment.OverallRisk, assessment.TotalIssues, change)
+	}
+
+	first, last := assessments[len(assessments)-1], assessments[0]
+	fmt.Printf("\n%d assessments, issues %d -> %d (%+d), risk %s -> %s\n",
+		len(assessments), first.TotalIssues, last.TotalIssues, last.TotalIssues-first.TotalIssues, first.OverallRisk, last.OverallRisk)
+}
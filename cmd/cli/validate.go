@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/convert"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	serverDryRun      bool
+	validateConverted bool
+	validatePath      string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <path>",
+	Short: "Validate manifests against the live cluster",
+	Long: `With --server-dry-run, applies every resource in a manifest file, folder, or - for stdin to the
+current cluster with dryRun=All. Schema validation and admission webhooks and policies run, but
+nothing is persisted. With --converted, resources are validated as the fix command would convert
+them, without rewriting any file. Exits non-zero when a resource is rejected.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runValidate,
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&serverDryRun, "server-dry-run", false, "Apply the manifests to the current cluster with a server-side dry run")
+	validateCmd.Flags().BoolVar(&validateConverted, "converted", false, "Validate resources as converted by the built-in converters")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if !serverDryRun {
+		fatal(usageErrorf("Nothing to validate: pass --server-dry-run to submit the manifests to the cluster"))
+	}
+
+	fmt.Println("=== Kube Upgrade Advisor - Server-Side Dry Run ===\n")
+
+	results := serverDryRunManifests(ctx, args[0], validateConverted)
+
+	rejected := 0
+	for _, result := range results {
+		status := "✅ passed"
+		if !result.Passed {
+			status = fmt.Sprintf("❌ rejected (%s)", result.Reason)
+			rejected++
+		}
+		if result.Converted {
+			status += " [converted]"
+		}
+
+		fmt.Printf("%s %s: %s\n", result.APIVersion, result.Resource(), status)
+		if result.SourceFile != "" {
+			fmt.Printf("   File: %s\n", result.SourceFile)
+		}
+		if !result.Passed {
+			fmt.Printf("   %s\n", result.Message)
+		}
+	}
+
+	fmt.Printf("\nResources validated: %d, rejected: %d\n", len(results), rejected)
+	if rejected > 0 {
+		exit(1)
+	}
+}
+
+// serverDryRunManifests applies the resources of a manifest path to the current cluster with a
+// server-side dry run, optionally converting them with the built-in converters first
+func serverDryRunManifests(ctx context.Context, path string, converted bool) []analysis.ValidationResult {
+	objects, err := readManifestObjects(path)
+	if err != nil {
+		fatalf("Failed to read manifests: %v", err)
+	}
+
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		fatalf("Failed to create kube client: %v", err)
+	}
+	dryRunClient, err := cluster.NewDryRunClient(kubeClient)
+	if err != nil {
+		fatalf("Failed to create dry-run client: %v", err)
+	}
+
+	results := make([]analysis.ValidationResult, 0, len(objects))
+	for _, object := range objects {
+		result := analysis.ValidationResult{SourceFile: object.file}
+		if converted {
+			conversion, err := convert.Convert(object.object)
+			if err != nil {
+				log.Printf("Warning: failed to convert a resource in %s: %v", object.file, err)
+			}
+			result.Converted = conversion != nil
+		}
+
+		result.APIVersion, _ = object.object["apiVersion"].(string)
+		result.Kind, _ = object.object["kind"].(string)
+		if metadata, ok := object.object["metadata"].(convert.Object); ok {
+			result.Name, _ = metadata["name"].(string)
+			result.Namespace, _ = metadata["namespace"].(string)
+		}
+
+		if err := dryRunClient.Apply(ctx, object.object); err != nil {
+			result.Reason = cluster.DryRunReason(err)
+			result.Message = err.Error()
+		} else {
+			result.Passed = true
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// manifestObject is a decoded manifest document and the file it came from, empty for stdin
+type manifestObject struct {
+	object convert.Object
+	file   string
+}
+
+// readManifestObjects decodes every document with an apiVersion and kind from a manifest file,
+// folder, or - for stdin. Unlike the parser it keeps every field, as the API server needs them.
+func readManifestObjects(path string) ([]manifestObject, error) {
+	if path == "-" {
+		return decodeManifestObjects(os.Stdin, "")
+	}
+
+	files, err := manifests.NewParser().YAMLFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []manifestObject
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		decoded, err := decodeManifestObjects(f, file)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		objects = append(objects, decoded...)
+	}
+	return objects, nil
+}
+
+// decodeManifestObjects decodes a multi-document YAML stream
+func decodeManifestObjects(r io.Reader, file string) ([]manifestObject, error) {
+	var objects []manifestObject
+	decoder := yaml.NewDecoder(r)
+	for {
+		var object convert.Object
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if object["apiVersion"] == nil || object["kind"] == nil {
+			continue
+		}
+		objects = append(objects, manifestObject{object: object, file: file})
+	}
+}
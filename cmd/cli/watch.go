@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchLive   bool
+	watchWindow time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Re-assess the upgrade impact as the cluster changes",
+	Long: `Keeps informers open on CRDs, Helm release Secrets and, with --live, workloads and
+PodDisruptionBudgets, updates the inventory in place and re-runs the impact analysis on every change,
+printing the findings fixed and introduced, to follow remediation in real time. Runs until interrupted.`,
+	Run: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	watchCmd.MarkFlagRequired("target")
+	watchCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID to store the inventory under")
+	watchCmd.Flags().BoolVar(&watchLive, "live", true, "Run live cluster checks, watching workloads and PodDisruptionBudgets too")
+	watchCmd.Flags().IntVar(&surgeNodes, "surge", 1, "Number of nodes taken out of service at once during the rolling upgrade (used with --live)")
+	watchCmd.Flags().DurationVar(&watchWindow, "window", 5*time.Second, "Collect changes for this long before re-assessing")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watcher re-assesses the cluster on changes
+type watcher struct {
+	store      *inventory.Store
+	analyzer   *analysis.Analyzer
+	kubeClient *cluster.KubeClient
+	crdClient  *cluster.CRDClient
+	helmClient *cluster.HelmClient
+	helm       cluster.ResourceType
+}
+
+func runWatch(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if watchWindow <= 0 {
+		fatal(usageErrorf("Invalid --window value: must be positive"))
+	}
+
+	fmt.Println("=== Kube Upgrade Advisor - Watch ===\n")
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, knowledgeFile("chart-matrix.json"), store)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+	analyzer.SetLocalizer(localizer)
+	loadKnowledge(analyzer)
+	if watchLive {
+		if err := analyzer.LoadStorageKnowledge(knowledgeFile("storage.json")); err != nil {
+			log.Printf("Warning: skipping storage checks: %v", err)
+		}
+		if err := analyzer.LoadIngressKnowledge(knowledgeFile("ingress-annotations.json")); err != nil {
+			log.Printf("Warning: skipping ingress annotation checks: %v", err)
+		}
+		if err := analyzer.LoadAddonKnowledge(knowledgeFile("addons.json")); err != nil {
+			log.Printf("Warning: skipping addon checks: %v", err)
+		}
+		if err := analyzer.LoadNodeConfigKnowledge(knowledgeFile("node-config.json")); err != nil {
+			log.Printf("Warning: skipping kubelet and kube-proxy configuration checks: %v", err)
+		}
+		if err := analyzer.LoadDevicePluginKnowledge(knowledgeFile("device-plugins.json")); err != nil {
+			log.Printf("Warning: skipping device plugin checks: %v", err)
+		}
+	}
+
+	w := &watcher{store: store, analyzer: analyzer, helm: cluster.HelmReleaseResources(namespace)}
+	if w.kubeClient, err = newKubeClient(); err != nil {
+		fatalf("Failed to create kube client: %v", err)
+	}
+	if w.crdClient, err = cluster.NewCRDClientFromKubeClient(w.kubeClient); err != nil {
+		fatalf("Failed to create CRD client: %v", err)
+	}
+	if w.helmClient, err = newHelmClient(namespace); err != nil {
+		fatalf("Failed to create Helm client: %v", err)
+	}
+
+	// Start the informers first, so no change made while the inventory is updated is missed
+	types := []cluster.ResourceType{cluster.CRDResources, w.helm}
+	if watchLive {
+		types = append(types, cluster.WorkloadResources...)
+	}
+	batches, err := w.kubeClient.WatchChanges(ctx, types, watchWindow)
+	if err != nil {
+		fatalf("Failed to watch the cluster: %v", err)
+	}
+
+	fmt.Println("Updating the inventory since the last scan...")
+	incrementalScan = true
+	_, scanErrors, err := scanCluster(ctx, store, clusterIDFlag)
+	if err != nil {
+		fatalf("Failed to scan cluster: %v", err)
+	}
+	storeCtx := context.WithoutCancel(ctx)
+	if err := store.SetIncompleteReason(storeCtx, clusterIDFlag, ""); err != nil {
+		fatalf("Failed to store scan status: %v", err)
+	}
+	if err := store.SetScanErrors(storeCtx, clusterIDFlag, scanErrors); err != nil {
+		fatalf("Failed to store scan status: %v", err)
+	}
+
+	previous, err := w.assess(ctx)
+	if err != nil {
+		fatalf("Failed to compute impact: %v", err)
+	}
+	printWatchUpdate(nil, previous)
+	fmt.Println("\nWatching for changes, press Ctrl+C to stop")
+
+	for batch := range batches {
+		fmt.Printf("\n[%s] Changed: %s\n", time.Now().Format("15:04:05"), formatChangeCounts(batch))
+		if err := w.apply(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Warning: failed to update the inventory: %v", err)
+		}
+
+		current, err := w.assess(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Warning: failed to compute impact: %v", err)
+			continue
+		}
+		printWatchUpdate(previous, current)
+		previous = current
+	}
+
+	fmt.Println("\nStopped watching")
+}
+
+// apply updates the inventory with the changed CRDs and Helm releases; workloads are read again
+// by the live checks
+func (w *watcher) apply(ctx context.Context, batch map[string][]cluster.Change) error {
+	if changes := batch[cluster.CRDResources.Name]; len(changes) > 0 {
+		if err := w.crdClient.ApplyCRDChanges(ctx, clusterIDFlag, w.store, changes); err != nil {
+			return err
+		}
+	}
+	if changes := batch[w.helm.Name]; len(changes) > 0 {
+		if err := w.helmClient.ApplyReleaseChanges(ctx, clusterIDFlag, w.store, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assess computes the impact from the inventory, with live checks against the current cluster
+func (w *watcher) assess(ctx context.Context) (*analysis.ImpactAssessment, error) {
+	assessment, err := w.analyzer.ComputeUpgradeImpact(ctx, clusterIDFlag, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !watchLive {
+		return assessment, nil
+	}
+
+	state, err := w.kubeClient.CollectLiveState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect live cluster state: %w", err)
+	}
+	opts := analysis.DefaultLiveCheckOptions()
+	opts.SurgeNodes = surgeNodes
+	w.analyzer.ApplyLiveState(assessment, state, opts)
+	return assessment, nil
+}
+
+// formatChangeCounts summarizes a batch of changes, e.g. "crds 1, deployments 3"
+func formatChangeCounts(batch map[string][]cluster.Change) string {
+	names := make([]string, 0, len(batch))
+	for name := range batch {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := make([]string, 0, len(names))
+	for _, name := range names {
+		counts = append(counts, fmt.Sprintf("%s %d", name, len(batch[name])))
+	}
+	return strings.Join(counts, ", ")
+}
+
+// printWatchUpdate prints the overall risk and issue count, and the findings fixed and introduced
+// since the previous assessment
+func printWatchUpdate(previous, current *analysis.ImpactAssessment) {
+	timestamp := time.Now().Format("15:04:05")
+	if previous == nil {
+		fmt.Printf("\n[%s] Overall risk: %s, %d issues\n", timestamp, current.OverallRisk, current.TotalIssues)
+		return
+	}
+
+	before := make(map[string]bool)
+	for _, finding := range previous.Findings() {
+		before[finding.Key()] = true
+	}
+	after := make(map[string]bool)
+	var introduced []analysis.FindingRef
+	for _, finding := range current.Findings() {
+		after[finding.Key()] = true
+		if !before[finding.Key()] {
+			introduced = append(introduced, finding)
+		}
+	}
+	var fixed []analysis.FindingRef
+	for _, finding := range previous.Findings() {
+		if !after[finding.Key()] {
+			fixed = append(fixed, finding)
+		}
+	}
+
+	if len(introduced) == 0 && len(fixed) == 0 && current.OverallRisk == previous.OverallRisk && current.TotalIssues == previous.TotalIssues {
+		fmt.Printf("[%s] No change in findings\n", timestamp)
+		return
+	}
+	fmt.Printf("[%s] Overall risk: %s, %d issues (%+d)\n", timestamp, current.OverallRisk, current.TotalIssues, current.TotalIssues-previous.TotalIssues)
+	for _, finding := range fixed {
+		fmt.Printf("   ✅ fixed: %s %s: %s\n", finding.RuleID, finding.Resource, finding.Summary)
+	}
+	for _, finding := range introduced {
+		fmt.Printf("   ❌ new: %s %s: %s\n", finding.RuleID, finding.Resource, finding.Summary)
+	}
+}
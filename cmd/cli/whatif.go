@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	whatIfRelease      string
+	whatIfChartVersion string
+	whatIfFormat       string
+)
+
+var whatIfCmd = &cobra.Command{
+	Use:   "what-if",
+	Short: "Analyze the upgrade as if a Helm release ran another chart version",
+	Long: `Re-runs the impact analysis with a hypothetical chart version substituted for a Helm release and
+compares it with the actual analysis: whether the chart's incompatibility and known issues would be
+resolved, and which findings would be resolved or introduced, before anyone touches the cluster.
+Nothing is stored.`,
+	Args: cobra.NoArgs,
+	Run:  runWhatIf,
+}
+
+func init() {
+	whatIfCmd.Flags().StringVarP(&targetVersion, "target", "t", "", "Target Kubernetes version (required)")
+	whatIfCmd.Flags().StringVar(&clusterIDFlag, "cluster", "cluster-1", "Cluster ID in the database")
+	whatIfCmd.Flags().StringVar(&whatIfRelease, "release", "", "Helm release as namespace/name (required)")
+	whatIfCmd.Flags().StringVar(&whatIfChartVersion, "chart-version", "", "Hypothetical chart version of the release (required)")
+	whatIfCmd.Flags().StringVar(&whatIfFormat, "format", "text", "Output format: text, json or yaml")
+	whatIfCmd.MarkFlagRequired("target")
+	whatIfCmd.MarkFlagRequired("release")
+	whatIfCmd.MarkFlagRequired("chart-version")
+	rootCmd.AddCommand(whatIfCmd)
+}
+
+func runWhatIf(cmd *cobra.Command, args []string) {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if whatIfFormat != "text" && whatIfFormat != "json" && whatIfFormat != "yaml" {
+		fatal(usageErrorf("Invalid --format value: unsupported format %q (supported: text, json, yaml)", whatIfFormat))
+	}
+	releaseNamespace, releaseName, ok := strings.Cut(whatIfRelease, "/")
+	if !ok || releaseNamespace == "" || releaseName == "" {
+		fatal(usageErrorf("Invalid --release value %q: expected namespace/name", whatIfRelease))
+	}
+
+	store, err := openStore()
+	if err != nil {
+		fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	overrides := []analysis.ChartOverride{{Namespace: releaseNamespace, Release: releaseName, ChartVersion: whatIfChartVersion}}
+	result, err := newClusterAnalyzer(store).WhatIf(ctx, clusterIDFlag, targetVersion, overrides)
+	if err != nil {
+		fatalf("Failed to analyze what-if: %v", err)
+	}
+
+	if whatIfFormat != "text" {
+		if err := printStructured(result, whatIfFormat); err != nil {
+			fatalf("Failed to print what-if result: %v", err)
+		}
+		return
+	}
+	printWhatIf(result)
+}
+
+// newClusterAnalyzer creates an analyzer of the inventory with the chart, operator and service
+// mesh knowledge bases, for analyses comparing hypothetical chart versions
+func newClusterAnalyzer(store *inventory.Store) *analysis.Analyzer {
+	localizer, err := i18n.New(lang)
+	if err != nil {
+		fatal(usageErrorf("Invalid --lang value: %w", err))
+	}
+	analyzer, err := analysis.NewAnalyzer(apiKnowledgePath, knowledgeFile("chart-matrix.json"), store)
+	if err != nil {
+		fatalf("Failed to create analyzer: %v", err)
+	}
+	analyzer.SetLocalizer(localizer)
+	loadKnowledge(analyzer)
+	return analyzer
+}
+
+// printWhatIf prints the chart findings of each release before and after, and the findings the
+// hypothetical versions resolve and introduce
+func printWhatIf(result *analysis.WhatIfResult) {
+	fmt.Printf("=== What if: upgrade to Kubernetes %s ===\n", result.TargetVersion)
+	for _, release := range result.Releases {
+		fmt.Printf("\nRelease %s/%s, chart %s: %s -> %s\n", release.Namespace, release.Release, release.Chart, release.CurrentVersion, release.ChartVersion)
+		fmt.Printf("  Now:     %s\n", chartWhatIfState(release.Before, release.IncompatibleBefore))
+		fmt.Printf("  What if: %s\n", chartWhatIfState(release.After, release.IncompatibleAfter))
+		switch {
+		case !release.Known:
+			fmt.Printf("  The chart matrix doesn't list %s %s; its compatibility is unknown, verify it manually.\n", release.Chart, release.ChartVersion)
+		case release.Resolved() && release.Before != nil:
+			fmt.Println("  Resolved: the version is compatible and has no known issues.")
+		case release.After != nil && release.After.RecommendedVersion != "" && release.After.RecommendedVersion != release.ChartVersion:
+			fmt.Printf("  Not resolved; the recommended version is %s.\n", release.After.RecommendedVersion)
+		}
+	}
+
+	fmt.Printf("\nOverall risk: %s -> %s\n", result.OverallRiskBefore, result.OverallRiskAfter)
+	fmt.Printf("Total issues: %d -> %d\n", result.TotalIssuesBefore, result.TotalIssuesAfter)
+	if len(result.ResolvedFindings) > 0 {
+		fmt.Printf("\nResolved findings (%d):\n", len(result.ResolvedFindings))
+		for _, finding := range result.ResolvedFindings {
+			fmt.Printf("  - [%s] %s: %s\n", finding.RuleID, finding.Resource, finding.Summary)
+		}
+	}
+	if len(result.IntroducedFindings) > 0 {
+		fmt.Printf("\nIntroduced findings (%d):\n", len(result.IntroducedFindings))
+		for _, finding := range result.IntroducedFindings {
+			fmt.Printf("  - [%s] %s: %s\n", finding.RuleID, finding.Resource, finding.Summary)
+		}
+	}
+}
+
+// chartWhatIfState summarizes the chart finding of a release at a version
+func chartWhatIfState(chart *analysis.ChartImpact, incompatible bool) string {
+	if chart == nil {
+		return "compatible, no known issues"
+	}
+	state := "compatible with known issues"
+	if incompatible {
+		state = fmt.Sprintf("incompatible (%s)", chart.ImpactLevel)
+	}
+	if len(chart.Issues) > 0 {
+		state += ": " + strings.Join(chart.Issues, "; ")
+	}
+	return state
+}
@@ -0,0 +1,246 @@
+// Command kua-collector gathers a cluster's inventory and writes it as a signed JSON bundle for
+// 'kube-upgrade-advisor inventory import'. It keeps no database and runs no analysis, so it builds
+// without cgo and runs as a Job in locked-down clusters with read-only access.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/signature"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+)
+
+var (
+	clusterID   string
+	clusterName string
+	labels      []string
+	namespace   string
+	kubeconfig  string
+	kubeContext string
+	helmDrivers string
+	outputPath  string
+	signingKey  string
+	eventsNS    string
+	timeout     time.Duration
+	verbose     bool
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("kua-collector: ")
+
+	flag.StringVar(&clusterID, "cluster", envOr("KUA_CLUSTER_ID", "cluster-1"), "Cluster ID to record the inventory under (default: $KUA_CLUSTER_ID)")
+	flag.StringVar(&clusterName, "name", os.Getenv("KUA_CLUSTER_NAME"), "Cluster display name; the cluster ID when empty (default: $KUA_CLUSTER_NAME)")
+	flag.Func("label", "Label the cluster as key=value (repeatable)", func(value string) error {
+		labels = append(labels, value)
+		return nil
+	})
+	flag.StringVar(&namespace, "namespace", "", "Only collect Helm releases in this namespace")
+	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig; in-cluster config when running in a pod")
+	flag.StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
+	flag.StringVar(&helmDrivers, "helm-driver", envOr("HELM_DRIVER", "secret"), "Helm storage drivers to read releases from: secret, configmap, sql (comma-separated; default: $HELM_DRIVER)")
+	flag.StringVar(&outputPath, "output", "-", "File to write the bundle to, or - for stdout")
+	flag.StringVar(&signingKey, "signing-key", os.Getenv("KUA_SIGNING_KEY"), "PEM private key to sign the bundle with (default: $KUA_SIGNING_KEY)")
+	flag.StringVar(&eventsNS, "events-namespace", os.Getenv("KUA_EVENTS_NAMESPACE"), "Emit a Kubernetes Event when done, on the kube-upgrade-advisor ConfigMap in this namespace (default: $KUA_EVENTS_NAMESPACE)")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "Stop collecting after this long")
+	flag.BoolVar(&verbose, "v", false, "Log progress to stderr; off by default, so a Job's log is just the bundle")
+	flag.Parse()
+
+	parsedLabels, err := inventory.ParseLabels(labels)
+	if err != nil {
+		log.Fatalf("Invalid -label value: %v", err)
+	}
+	// Proxy and CA settings come from $KUBE_ADVISOR_PROXY, $KUBE_ADVISOR_CA_FILE and related variables
+	if err := network.Configure(network.OptionsFromEnv()); err != nil {
+		log.Fatalf("Invalid network settings: %v", err)
+	}
+	// Load the key first, so a bad key fails before the cluster is read
+	var signer *signature.Signer
+	if signingKey != "" {
+		if signer, err = signature.NewSigner(signingKey); err != nil {
+			log.Fatalf("Failed to load signing key: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Telemetry is configured with the OTEL_* environment variables only
+	flush := func() {}
+	if opts := (telemetry.Options{ServiceName: "kua-collector"}); opts.Enabled() {
+		shutdown, err := telemetry.Setup(context.Background(), opts)
+		if err != nil {
+			log.Printf("Warning: telemetry disabled: %v", err)
+		} else {
+			flush = func() { flushTelemetry(shutdown) }
+		}
+	}
+	defer flush()
+
+	ctx, op := telemetry.StartScan(ctx, "all")
+	export, err := collect(ctx, parsedLabels)
+	op.End(err)
+	if export == nil {
+		log.Fatalf("Failed to collect inventory: %v", err)
+	}
+	if err != nil {
+		// Keep what was collected, marked incomplete like an interrupted scan
+		export.Cluster.IncompleteReason = err.Error()
+	}
+	if len(export.Cluster.ScanErrors) == 0 {
+		export.Cluster.ScanErrors = nil
+	}
+
+	if writeErr := writeBundle(export, signer); writeErr != nil {
+		log.Fatalf("Failed to write bundle: %v", writeErr)
+	}
+	if eventsNS != "" {
+		emitScanEvent(export)
+	}
+	if err != nil {
+		// log.Fatalf skips the deferred flush
+		flush()
+		log.Fatalf("Collection stopped early, the bundle is marked incomplete: %v", err)
+	}
+	for _, subsystem := range inventory.ScanErrorSubsystems(export.Cluster.ScanErrors) {
+		log.Printf("Warning: %s not collected: %s", subsystem, export.Cluster.ScanErrors[subsystem])
+	}
+}
+
+// collect scans the cluster as the scan command does, into an export instead of a database. A
+// subsystem that fails, e.g. for lack of RBAC permissions, is recorded in the scan errors. When ctx
+// is done mid-way, the inventory collected so far is returned with the error.
+func collect(ctx context.Context, labels map[string]string) (*inventory.Export, error) {
+	kubeClient, err := cluster.NewKubeClientFromFlags(kubeconfig, kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	name := clusterName
+	if name == "" {
+		name = clusterID
+	}
+	builder := inventory.NewExportBuilder(inventory.ExportedCluster{
+		ID:     clusterID,
+		Name:   name,
+		Labels: labels,
+	})
+	// The bundle may go to stdout, so the items stored are only logged with -v
+	itemLog := io.Discard
+	if verbose {
+		itemLog = os.Stderr
+	}
+	version, scanErrors, err := scan.Cluster(ctx, builder, kubeClient, clusterID, scan.Options{
+		Kubeconfig:  kubeconfig,
+		Context:     kubeContext,
+		Namespace:   namespace,
+		HelmDrivers: strings.Split(helmDrivers, ","),
+		Progress:    logScanEvent,
+		Log:         itemLog,
+	})
+	if version == "" {
+		return nil, err
+	}
+
+	export := builder.Export()
+	export.Cluster.ScanErrors = scanErrors
+	return export, err
+}
+
+// logScanEvent logs the messages of the scan and its skipped subsystems with -v
+func logScanEvent(event scan.Event) {
+	switch {
+	case event.Error != "":
+		logf("Warning: skipping %s: %s", event.Phase, event.Error)
+	case event.Message != "":
+		logf("%s", event.Message)
+	}
+}
+
+// writeBundle writes the inventory as a bundle, signed when a signer is given
+func writeBundle(export *inventory.Export, signer *signature.Signer) error {
+	bundle, err := inventory.NewBundle(export)
+	if err != nil {
+		return err
+	}
+	if signer != nil {
+		sig, err := signer.Sign(bundle.Inventory)
+		if err != nil {
+			return err
+		}
+		bundle.Signature = string(sig)
+	}
+
+	var out io.Writer = os.Stdout
+	if outputPath != "-" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", outputPath, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := bundle.Write(out); err != nil {
+		return err
+	}
+	if outputPath != "-" {
+		log.Printf("Wrote %s (%d Helm releases, %d CRDs) to %s", export.Cluster.ID, len(export.HelmReleases), len(export.CRDs), outputPath)
+	}
+	return nil
+}
+
+// emitScanEvent records the outcome of the collection as a Kubernetes Event; a failure is only a
+// warning, since the bundle is already written
+func emitScanEvent(export *inventory.Export) {
+	// The collection context may have timed out already
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	kubeClient, err := cluster.NewKubeClientFromFlags(kubeconfig, kubeContext)
+	if err == nil {
+		eventType, reason, message := cluster.ScanEvent(export.Cluster.ID, export.Cluster.KubeVersion, export.Cluster.ScanErrors, export.Cluster.IncompleteReason)
+		err = kubeClient.NewEventRecorder(eventsNS).Emit(ctx, eventType, reason, message)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to emit event: %v", err)
+	}
+}
+
+// flushTelemetry exports pending spans and metrics
+func flushTelemetry(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("Warning: failed to export telemetry: %v", err)
+	}
+}
+
+// logf logs progress with -v
+func logf(format string, args ...any) {
+	if verbose {
+		log.Printf(format, args...)
+	}
+}
+
+// envOr returns an environment variable, or def when it is unset
+func envOr(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
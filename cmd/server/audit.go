@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// newAuditEntry starts the audit log entry of an action requested by a caller, with the request's
+// query as its parameters
+func newAuditEntry(r *http.Request, action, clusterID string) inventory.AuditEntry {
+	parameters := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		parameters[key] = values[len(values)-1]
+	}
+	return inventory.AuditEntry{
+		Action:     action,
+		Actor:      callerOf(r).name,
+		Origin:     inventory.AuditOriginServer,
+		ClusterID:  clusterID,
+		Parameters: parameters,
+		StartedAt:  time.Now(),
+	}
+}
+
+// recordAudit appends a finished action to the audit log, even if its client went away; a failure
+// to record only logs a warning
+func recordAudit(entry inventory.AuditEntry) {
+	if _, err := store.RecordAudit(context.Background(), entry); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// auditHandler lists audit log entries newest first, filtered by cluster, action, actor, since
+// (RFC 3339) and limit
+func auditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := inventory.AuditFilter{
+		ClusterID: query.Get("cluster"),
+		Action:    query.Get("action"),
+		Actor:     query.Get("actor"),
+	}
+	if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+		filter.Since = parsed
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			http.Error(w, fmt.Sprintf("Invalid limit: %q", limit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	entries, err := store.ListAuditLogs(r.Context(), filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list audit log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	infos := make([]api.AuditLogInfo, len(entries))
+	for i, entry := range entries {
+		infos[i] = api.NewAuditLogInfo(entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
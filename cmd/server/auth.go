@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Roles of API tokens
+const (
+	// RoleAdmin can use every endpoint and sees assessments in full
+	RoleAdmin = "admin"
+	// RoleExternal, e.g. a vendor or consultant, can read stored assessments with internal names
+	// redacted, but not compute or change anything
+	RoleExternal = "external"
+)
+
+// TokenConfig is the file of API tokens named by $API_TOKENS_FILE
+type TokenConfig struct {
+	Tokens []APIToken `yaml:"tokens"`
+	// SlackUsers may run Slack slash commands, which carry no API token
+	SlackUsers []SlackUser `yaml:"slackUsers"`
+}
+
+// APIToken is a bearer token, the name of its holder and its role
+type APIToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"`
+}
+
+// identity is the caller of a request; anonymous callers are admins when no tokens are configured
+type identity struct {
+	name string
+	role string
+}
+
+// SlackUser gives a Slack user, by member ID, a role for slash commands
+type SlackUser struct {
+	ID   string `yaml:"id"`
+	Role string `yaml:"role"`
+}
+
+type identityKey struct{}
+
+// tokens are the configured API tokens; nil leaves the server open, as before tokens existed
+var tokens []APIToken
+
+// slackUsers are the Slack users given a role in the token file
+var slackUsers []SlackUser
+
+// LoadTokenConfig reads and validates an API token file
+func LoadTokenConfig(path string) (*TokenConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API tokens: %w", err)
+	}
+
+	var config TokenConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API tokens: %w", err)
+	}
+	if len(config.Tokens) == 0 {
+		return nil, fmt.Errorf("no tokens in %s", path)
+	}
+	for i, token := range config.Tokens {
+		if token.Name == "" || token.Token == "" {
+			return nil, fmt.Errorf("token %d: name and token are required", i+1)
+		}
+		if token.Role != RoleAdmin && token.Role != RoleExternal {
+			return nil, fmt.Errorf("token %s: unknown role %q (supported: %s, %s)", token.Name, token.Role, RoleAdmin, RoleExternal)
+		}
+	}
+	for i, user := range config.SlackUsers {
+		if user.ID == "" {
+			return nil, fmt.Errorf("slack user %d: id is required", i+1)
+		}
+		if user.Role != RoleAdmin && user.Role != RoleExternal {
+			return nil, fmt.Errorf("slack user %s: unknown role %q (supported: %s, %s)", user.ID, user.Role, RoleAdmin, RoleExternal)
+		}
+	}
+	return &config, nil
+}
+
+// authorize wraps a handler with bearer token authentication when tokens are configured. Only
+// admins may call it unless external callers are allowed, who then get redacted responses.
+func authorize(handler http.HandlerFunc, allowExternal bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller := identity{name: "anonymous", role: RoleAdmin}
+		if tokens != nil {
+			token, ok := lookupToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kube-upgrade-server"`)
+				http.Error(w, "Missing or invalid API token", http.StatusUnauthorized)
+				return
+			}
+			caller = identity{name: token.Name, role: token.Role}
+		}
+		if caller.role == RoleExternal && !allowExternal {
+			http.Error(w, fmt.Sprintf("Role %s may not access %s", caller.role, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, caller)))
+	}
+}
+
+// lookupToken finds the configured token of a request's Authorization header
+func lookupToken(r *http.Request) (APIToken, bool) {
+	presented, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || presented == "" {
+		return APIToken{}, false
+	}
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token.Token)) == 1 {
+			return token, true
+		}
+	}
+	return APIToken{}, false
+}
+
+// slackCaller returns the identity of a Slack user: the role given to the user in the token file,
+// or admin when no tokens are configured, as for anonymous API callers. Unlisted users have none.
+func slackCaller(userID, userName string) (identity, bool) {
+	name := "slack:" + userName
+	if tokens == nil {
+		return identity{name: name, role: RoleAdmin}, true
+	}
+	for _, user := range slackUsers {
+		if user.ID == userID {
+			return identity{name: name, role: user.Role}, true
+		}
+	}
+	return identity{}, false
+}
+
+// callerOf returns the caller of a request passed through authorize
+func callerOf(r *http.Request) identity {
+	if caller, ok := r.Context().Value(identityKey{}).(identity); ok {
+		return caller
+	}
+	return identity{name: "anonymous", role: RoleAdmin}
+}
+
+// redacted reports whether a response must be redacted: always for external callers, and on
+// request with redact=true for anyone
+func redacted(r *http.Request) bool {
+	return callerOf(r).role == RoleExternal || r.URL.Query().Get("redact") == "true"
+}
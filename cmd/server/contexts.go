@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+	"gopkg.in/yaml.v3"
+)
+
+// ClusterContextConfig is the file of scannable clusters named by $CLUSTER_CONTEXTS_FILE
+type ClusterContextConfig struct {
+	Clusters []ClusterContext `yaml:"clusters"`
+}
+
+// ClusterContext is the kubeconfig and context the server scans a cluster ID with. Credential
+// plugins of the context, e.g. aws-iam-authenticator, must be installed on the server.
+type ClusterContext struct {
+	ID         string `yaml:"id"`
+	Kubeconfig string `yaml:"kubeconfig"`
+	Context    string `yaml:"context"`
+	// HelmDrivers are the Helm storage drivers of the cluster; empty uses $HELM_DRIVER
+	HelmDrivers []string `yaml:"helmDrivers"`
+}
+
+// clusterContexts are the configured clusters by ID; nil scans the cluster the server runs in
+// (in-cluster config or $KUBECONFIG) under any ID, as before contexts existed
+var clusterContexts map[string]ClusterContext
+
+// LoadClusterContexts reads and validates a cluster contexts file
+func LoadClusterContexts(path string) (map[string]ClusterContext, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster contexts: %w", err)
+	}
+
+	var config ClusterContextConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cluster contexts: %w", err)
+	}
+	if len(config.Clusters) == 0 {
+		return nil, fmt.Errorf("no clusters in %s", path)
+	}
+
+	contexts := make(map[string]ClusterContext, len(config.Clusters))
+	for i, clusterContext := range config.Clusters {
+		if clusterContext.ID == "" {
+			return nil, fmt.Errorf("cluster %d: id is required", i+1)
+		}
+		if clusterContext.Kubeconfig == "" && clusterContext.Context == "" {
+			return nil, fmt.Errorf("cluster %s: kubeconfig or context is required", clusterContext.ID)
+		}
+		if _, ok := contexts[clusterContext.ID]; ok {
+			return nil, fmt.Errorf("cluster %s is listed twice", clusterContext.ID)
+		}
+		contexts[clusterContext.ID] = clusterContext
+	}
+	return contexts, nil
+}
+
+// scannable reports whether the server can scan a cluster ID
+func scannable(clusterID string) bool {
+	if clusterContexts == nil {
+		return true
+	}
+	_, ok := clusterContexts[clusterID]
+	return ok
+}
+
+// kubeClientFor creates the client scanning a cluster ID, with its configured kubeconfig and
+// context; an empty kubeconfig loads $KUBECONFIG or the in-cluster config
+func kubeClientFor(clusterID string) (*cluster.KubeClient, error) {
+	clusterContext := clusterContexts[clusterID]
+	return cluster.NewKubeClientFromFlags(clusterContext.Kubeconfig, clusterContext.Context)
+}
+
+// scanOptionsFor returns the scan options of a cluster ID, so its Helm releases are read from the
+// same kubeconfig and context as the kube client scans
+func scanOptionsFor(clusterID string) scan.Options {
+	clusterContext := clusterContexts[clusterID]
+	return scan.Options{
+		Kubeconfig:  clusterContext.Kubeconfig,
+		Context:     clusterContext.Context,
+		HelmDrivers: clusterContext.HelmDrivers,
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScanOptionsFor(t *testing.T) {
+	saved := clusterContexts
+	t.Cleanup(func() { clusterContexts = saved })
+	clusterContexts = map[string]ClusterContext{
+		"prod":    {ID: "prod", Kubeconfig: "/etc/kube/prod.yaml", Context: "prod-admin", HelmDrivers: []string{"configmap"}},
+		"staging": {ID: "staging", Context: "staging"},
+	}
+
+	opts := scanOptionsFor("prod")
+	if opts.Kubeconfig != "/etc/kube/prod.yaml" || opts.Context != "prod-admin" || !slices.Equal(opts.HelmDrivers, []string{"configmap"}) {
+		t.Errorf("prod options = %+v, want its kubeconfig, context and drivers", opts)
+	}
+	if opts := scanOptionsFor("staging"); opts.Kubeconfig != "" || opts.Context != "staging" {
+		t.Errorf("staging options = %+v, want the staging context", opts)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/notify"
+)
+
+// digestCheckInterval is how often the server looks for digests that are due
+const digestCheckInterval = time.Minute
+
+// digestRetryDelay is how long a digest that failed to send waits before the next attempt
+const digestRetryDelay = 15 * time.Minute
+
+// digestTimeout bounds assessing and sending one digest, so a hung database query or mail server
+// doesn't block the digests after it
+const digestTimeout = 10 * time.Minute
+
+// runDigests sends each configured digest whenever its interval has passed since it was sent
+// last, as recorded in the database, so restarts neither skip nor repeat digests
+func runDigests(config *notify.Config, sink *notify.SMTPSink, releases *knowledge.ReleaseKnowledgeBase) {
+	retryAt := make(map[string]time.Time)
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, digest := range config.Digests {
+			if time.Now().Before(retryAt[digest.Name]) {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), digestTimeout)
+			err := sendDigestIfDue(ctx, digest, sink, releases)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: failed to send digest %s: %v", digest.Name, err)
+				retryAt[digest.Name] = time.Now().Add(digestRetryDelay)
+				continue
+			}
+			delete(retryAt, digest.Name)
+		}
+		<-ticker.C
+	}
+}
+
+// sendDigestIfDue sends a digest unless it was sent within its interval
+func sendDigestIfDue(ctx context.Context, config notify.DigestConfig, sink *notify.SMTPSink, releases *knowledge.ReleaseKnowledgeBase) error {
+	var since time.Time
+	var reported map[string][]string
+	last, err := store.LastDigest(ctx, config.Name)
+	switch {
+	case err == nil:
+		if time.Since(last.SentAt) < config.Interval() {
+			return nil
+		}
+		since, reported = last.SentAt, last.FindingKeys
+	case !ent.IsNotFound(err):
+		return fmt.Errorf("failed to get last digest: %w", err)
+	}
+
+	clusterIDs, err := digestClusters(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	digest := &notify.Digest{Name: config.Name, Target: config.Target, Since: since, GeneratedAt: now}
+	for _, clusterID := range clusterIDs {
+		assessment, err := analyzer.ComputeUpgradeImpact(ctx, clusterID, config.Target)
+		if err != nil {
+			digest.Clusters = append(digest.Clusters, notify.ClusterDigest{ClusterID: clusterID, Error: err.Error()})
+			continue
+		}
+		// the first digest of a cluster lists all its findings as new
+		digest.Clusters = append(digest.Clusters, notify.NewClusterDigest(assessment, reported[clusterID], releases, config.EOLDays, now))
+	}
+
+	if err := sink.Send(ctx, config.To, digest.Subject(), digest.Body()); err != nil {
+		return err
+	}
+	// a digest that was sent but not recorded would be sent again at the next check, so it is
+	// recorded even when the timeout has just passed
+	_, err = store.RecordDigest(context.WithoutCancel(ctx), inventory.DigestEntry{
+		Name:        config.Name,
+		FindingKeys: digest.FindingKeys(reported),
+		Recipients:  len(config.To),
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Sent digest %s of %d clusters to %d recipients", config.Name, len(digest.Clusters), len(config.To))
+	return nil
+}
+
+// digestClusters returns the IDs of the clusters a digest covers: the listed ones, else those
+// matching its selector, which without a selector is the whole fleet
+func digestClusters(ctx context.Context, config notify.DigestConfig) ([]string, error) {
+	if len(config.Clusters) > 0 {
+		return config.Clusters, nil
+	}
+	clusters, err := store.ListClustersMatching(ctx, config.ClusterSelector())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.ID)
+	}
+	return ids, nil
+}
@@ -1,30 +1,68 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/metrics"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/notify"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/redact"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/report"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
 )
 
 var (
-	analyzer *analysis.Analyzer
-	store    *inventory.Store
+	analyzer  *analysis.Analyzer
+	store     *inventory.Store
+	retention inventory.RetentionPolicy
+	ownership *analysis.OwnershipMap
 )
 
 func main() {
+	// Proxy and CA settings for outbound connections, from $KUBE_ADVISOR_PROXY, $KUBE_ADVISOR_CA_FILE
+	// and related variables
+	if err := network.Configure(network.OptionsFromEnv()); err != nil {
+		log.Fatalf("Invalid network settings: %v", err)
+	}
+
+	// Export traces and metrics over OTLP when OTEL_EXPORTER_OTLP_ENDPOINT is set; the batching
+	// exporters send them in the background while the server runs
+	if opts := (telemetry.Options{ServiceName: "kube-upgrade-server"}); opts.Enabled() {
+		if _, err := telemetry.Setup(context.Background(), opts); err != nil {
+			log.Printf("Warning: telemetry disabled: %v", err)
+		}
+	}
+
 	// Initialize store
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = "kube-advisor.db"
 	}
 
+	// Migrate the schema on startup only when asked to, e.g. from an init container after a backup
+	if migrate, _ := strconv.ParseBool(os.Getenv("DB_MIGRATE")); migrate {
+		if err := migrateDatabase(dbPath); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+
 	var err error
 	store, err = inventory.NewStore(dbPath)
 	if err != nil {
@@ -48,10 +86,95 @@ func main() {
 		log.Fatalf("Failed to create analyzer: %v", err)
 	}
 
-	// Setup routes
+	for _, err := range analyzer.LoadKnowledge(knowledgePath) {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Require API tokens when configured; without, the server is open to anyone who can reach it
+	if tokensPath := os.Getenv("API_TOKENS_FILE"); tokensPath != "" {
+		config, err := LoadTokenConfig(tokensPath)
+		if err != nil {
+			log.Fatalf("Failed to load API tokens: %v", err)
+		}
+		tokens = config.Tokens
+		slackUsers = config.SlackUsers
+	}
+
+	// Scan each registered cluster with its own kubeconfig context when configured
+	if contextsPath := os.Getenv("CLUSTER_CONTEXTS_FILE"); contextsPath != "" {
+		clusterContexts, err = LoadClusterContexts(contextsPath)
+		if err != nil {
+			log.Fatalf("Failed to load cluster contexts: %v", err)
+		}
+	}
+
+	requirePlanApproval, _ = strconv.ParseBool(os.Getenv("REQUIRE_PLAN_APPROVAL"))
+
+	// Attribute findings to teams in CSV results when configured
+	if ownershipPath := os.Getenv("OWNERSHIP_FILE"); ownershipPath != "" {
+		if ownership, err = analysis.LoadOwnershipMap(ownershipPath); err != nil {
+			log.Fatalf("Failed to load ownership map: %v", err)
+		}
+	}
+
+	// Email scheduled digests of the fleet's upgrade readiness when configured
+	if digestPath := os.Getenv("DIGEST_CONFIG_FILE"); digestPath != "" {
+		config, err := notify.LoadConfig(digestPath)
+		if err != nil {
+			log.Fatalf("Failed to load digest config: %v", err)
+		}
+		sink, err := notify.NewSMTPSink(config.SMTP)
+		if err != nil {
+			log.Fatalf("Invalid digest config: %v", err)
+		}
+
+		releaseKnowledgePath := os.Getenv("RELEASE_KNOWLEDGE_PATH")
+		if releaseKnowledgePath == "" {
+			releaseKnowledgePath = "knowledge-base/releases.json"
+		}
+		releases := knowledge.NewReleaseKnowledgeBase()
+		if err := releases.LoadFromFile(releaseKnowledgePath); err != nil {
+			log.Printf("Warning: digests skip end of life dates: %v", err)
+			releases = nil
+		}
+		go runDigests(config, sink, releases)
+	}
+
+	// Answer Slack slash commands when the Slack app's signing secret is configured
+	slackSigningSecret = os.Getenv("SLACK_SIGNING_SECRET")
+	publicURL = os.Getenv("PUBLIC_URL")
+
+	// Bound the assessments kept, which otherwise accumulate with every analysis
+	if value := os.Getenv("RETENTION_MAX_ASSESSMENTS"); value != "" {
+		if retention.MaxAssessments, err = strconv.Atoi(value); err != nil || retention.MaxAssessments < 0 {
+			log.Fatalf("Invalid RETENTION_MAX_ASSESSMENTS %q: must be a non-negative number", value)
+		}
+	}
+	if value := os.Getenv("RETENTION_MAX_AGE"); value != "" {
+		if retention.MaxAge, err = time.ParseDuration(value); err != nil || retention.MaxAge < 0 {
+			log.Fatalf("Invalid RETENTION_MAX_AGE %q: must be a non-negative duration, e.g. 2160h", value)
+		}
+	}
+
+	// Setup routes; health checks and badges stay open, as probes and embedded images send no token
 	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/impact", impactHandler)
-	http.HandleFunc("/clusters", clustersHandler)
+	// /impact computes and stores assessments, so external callers are limited to reading them
+	http.HandleFunc("/impact", authorize(impactHandler, false))
+	http.HandleFunc("/clusters", authorize(clustersHandler, false))
+	http.HandleFunc("/assessments", authorize(assessmentsHandler, true))
+	http.HandleFunc("/inventory", authorize(inventoryHandler, false))
+	http.HandleFunc("/search", authorize(searchHandler, false))
+	http.HandleFunc("/metrics", authorize(metricsHandler, false))
+	http.HandleFunc("/badge", badgeHandler)
+	http.HandleFunc("/audit", authorize(auditHandler, false))
+	http.HandleFunc("/plans", authorize(plansHandler, false))
+	http.HandleFunc("/plans/", authorize(planHandler, false))
+	http.HandleFunc("/scans", authorize(scansHandler, false))
+	http.HandleFunc("/scans/events", authorize(scanEventsHandler, false))
+	// Slack sends no API token; its requests are verified by their signature instead
+	if slackSigningSecret != "" {
+		http.HandleFunc("/slack/commands", slackCommandHandler)
+	}
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -63,6 +186,23 @@ func main() {
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
 
+// knowledgePathEnv are the variables overriding the paths of knowledge base files
+var knowledgePathEnv = map[string]string{
+	analysis.OperatorKnowledgeFile: "OPERATOR_KNOWLEDGE_PATH",
+	analysis.BehaviorKnowledgeFile: "BEHAVIOR_KNOWLEDGE_PATH",
+	analysis.PodFieldKnowledgeFile: "POD_FIELD_KNOWLEDGE_PATH",
+	analysis.MeshKnowledgeFile:     "MESH_KNOWLEDGE_PATH",
+}
+
+// knowledgePath returns the path of a knowledge base file: its variable if set, else the file in
+// knowledge-base/
+func knowledgePath(name string) string {
+	if path := os.Getenv(knowledgePathEnv[name]); path != "" {
+		return path
+	}
+	return "knowledge-base/" + name
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -87,17 +227,32 @@ func impactHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing required parameter: target", http.StatusBadRequest)
 		return
 	}
+	if _, err := resultFormat(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	planGenerator := planner.NewPlanner()
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		localizer, err := i18n.New(lang)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		planGenerator.SetLocalizer(localizer)
+	}
 
 	// Compute impact
 	ctx := context.Background()
+	audit := newAuditEntry(r, inventory.AuditImpact, clusterID)
 	assessment, err := analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
 	if err != nil {
+		audit.Error = err.Error()
+		recordAudit(audit)
 		http.Error(w, fmt.Sprintf("Failed to compute impact: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// generate upgrade plan
-	planGenerator := planner.NewPlanner()
 	plan, err := planGenerator.GeneratePlan(assessment)
 
 	// create combined response
@@ -110,7 +265,64 @@ func impactHandler(w http.ResponseWriter, r *http.Request) {
 		response.UpgradePlan = plan
 	}
 
-	// return JSON response
+	// keep the result for the assessment history, within the retention policy
+	if _, err := saveResult(ctx, assessment, response.UpgradePlan); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if retention.Enabled() {
+		if _, err := store.Prune(ctx, retention); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	audit.Succeeded = true
+	audit.Summary = fmt.Sprintf("Kubernetes %s, overall risk %s, %d issues", targetVersion, assessment.OverallRisk, assessment.TotalIssues)
+	recordAudit(audit)
+
+	writeResult(w, r, response)
+}
+
+// resultFormat returns the format an assessment is requested in: json, the default, or csv
+func resultFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		return "json", nil
+	case "csv":
+		return format, nil
+	default:
+		return "", fmt.Errorf("Invalid format %q (supported: json, csv)", format)
+	}
+}
+
+// writeResult writes an assessment with its plan as JSON, or its findings as CSV with
+// ?format=csv, redacted for callers that must not see internal names
+func writeResult(w http.ResponseWriter, r *http.Request, response *planner.UpgradeAssessmentWithPlan) {
+	format, err := resultFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// CSV owners are looked up by the real names, so the rows are made before redacting
+	var rows []analysis.CSVRow
+	if format == "csv" {
+		rows = analysis.CSVRows(response.ImpactAssessment, ownership)
+	}
+	if redacted(r) {
+		if err := redact.NewRedactor().Redact(response, &rows); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to redact assessment: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if format == "csv" {
+		data, err := analysis.RenderCSV(rows)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to render CSV: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write(data)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -121,29 +333,244 @@ func clustersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	labelSelector, err := inventory.ParseSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid selector: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	ctx := context.Background()
-	clusters, err := store.ListClusters(ctx)
+	clusters, err := store.ListClustersMatching(ctx, labelSelector)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to list clusters: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Convert to simple response
-	type ClusterInfo struct {
-		ID      string `json:"id"`
-		Name    string `json:"name"`
-		Version string `json:"version"`
+	clusterInfos := make([]api.ClusterInfo, len(clusters))
+	for i, cluster := range clusters {
+		clusterInfos[i] = api.NewClusterInfo(cluster)
 	}
 
-	clusterInfos := make([]ClusterInfo, len(clusters))
-	for i, cluster := range clusters {
-		clusterInfos[i] = ClusterInfo{
-			ID:      cluster.ID,
-			Name:    cluster.Name,
-			Version: cluster.KubeVersion,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clusterInfos)
+}
+
+// inventoryHandler returns the scanned inventory of a cluster in the export format
+func inventoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clusterID := r.URL.Query().Get("cluster")
+	if clusterID == "" {
+		clusterID = "cluster-1" // Default cluster
+	}
+
+	export, err := store.ExportInventory(context.Background(), clusterID)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("Cluster %s not found", clusterID), http.StatusNotFound)
+			return
 		}
+		http.Error(w, fmt.Sprintf("Failed to export inventory: %v", err), http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(clusterInfos)
+	json.NewEncoder(w).Encode(export)
+}
+
+// saveResult persists an assessment and the plan generated from it, returning the assessment's ID
+func saveResult(ctx context.Context, assessment *analysis.ImpactAssessment, plan *planner.UpgradePlan) (int, error) {
+	snapshot, err := json.Marshal(assessment)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal assessment: %w", err)
+	}
+
+	stored, err := store.SaveAssessment(ctx, assessment.ClusterID, inventory.AssessmentEntry{
+		TargetVersion:  assessment.TargetVersion,
+		CurrentVersion: assessment.CurrentVersion,
+		OverallRisk:    string(assessment.OverallRisk),
+		TotalIssues:    assessment.TotalIssues,
+		Snapshot:       string(snapshot),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if plan == nil {
+		return stored.ID, nil
+	}
+
+	document, err := json.Marshal(plan)
+	if err != nil {
+		return stored.ID, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	_, err = store.SavePlan(ctx, assessment.ClusterID, inventory.PlanEntry{
+		TargetVersion: plan.ToVersion,
+		FromVersion:   plan.FromVersion,
+		TotalSteps:    plan.TotalSteps,
+		Timeline:      plan.Timeline,
+		Document:      string(document),
+		AssessmentID:  stored.ID,
+	})
+	return stored.ID, err
+}
+
+// assessmentsHandler lists stored assessments (filtered by cluster and target), or returns one
+// stored assessment with its newest plan when an id is given
+func assessmentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	if idParam := r.URL.Query().Get("id"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid id: %v", err), http.StatusBadRequest)
+			return
+		}
+		assessmentHandler(w, r, ctx, id)
+		return
+	}
+
+	assessments, err := store.ListAssessments(ctx, r.URL.Query().Get("cluster"), r.URL.Query().Get("target"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list assessments: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	infos := make([]api.AssessmentInfo, len(assessments))
+	for i, assessment := range assessments {
+		infos[i] = api.NewAssessmentInfo(assessment)
+	}
+	if redacted(r) {
+		if err := redact.NewRedactor().Redact(&infos); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to redact assessments: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+// assessmentHandler returns a stored assessment in the same shape as /impact
+func assessmentHandler(w http.ResponseWriter, r *http.Request, ctx context.Context, id int) {
+	stored, err := store.GetAssessment(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("Assessment %d not found", id), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get assessment: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var assessment analysis.ImpactAssessment
+	if err := json.Unmarshal([]byte(stored.Snapshot), &assessment); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode assessment: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := &planner.UpgradeAssessmentWithPlan{
+		ImpactAssessment: &assessment,
+	}
+	if len(stored.Edges.Plans) > 0 {
+		var plan planner.UpgradePlan
+		if err := json.Unmarshal([]byte(stored.Edges.Plans[0].Document), &plan); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to decode plan: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response.OrderedUpgradeSteps = plan.OrderedUpgradeSteps
+		response.UpgradePlan = &plan
+	}
+
+	writeResult(w, r, response)
+}
+
+// metricsHandler exposes the latest assessment of each cluster and target version to Prometheus
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(r.Context(), &buf, store); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to collect metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// badgeHandler returns the upgrade readiness badge of the latest stored assessment of a cluster
+// for a target version, as SVG or, with format=json, in the shields.io endpoint schema
+func badgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clusterID := r.URL.Query().Get("cluster")
+	if clusterID == "" {
+		clusterID = "cluster-1" // Default cluster
+	}
+
+	targetVersion := r.URL.Query().Get("target")
+	if targetVersion == "" {
+		http.Error(w, "Missing required parameter: target", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "application/json") {
+		format = "json"
+	}
+	if format != "" && format != "svg" && format != "json" {
+		http.Error(w, fmt.Sprintf("Invalid format %q (supported: svg, json)", format), http.StatusBadRequest)
+		return
+	}
+
+	// a missing assessment is still a badge, so embedded images don't break before the first run
+	var badge report.Badge
+	assessment, err := store.LatestAssessment(r.Context(), clusterID, targetVersion)
+	switch {
+	case ent.IsNotFound(err):
+		badge = report.NewUnknownBadge(targetVersion)
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to get assessment: %v", err), http.StatusInternalServerError)
+		return
+	default:
+		badge = report.NewBadge(targetVersion, assessment.TotalIssues, analysis.ImpactLevel(assessment.OverallRisk))
+	}
+
+	// wikis and image proxies must not keep a stale badge once a new assessment is stored
+	w.Header().Set("Cache-Control", "no-cache, max-age=0")
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(badge)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(badge.SVG())
+}
+
+// migrateDatabase applies the pending schema migrations of the database
+func migrateDatabase(dbPath string) error {
+	migrator, err := inventory.NewMigrator(dbPath)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	applied, err := migrator.Migrate(context.Background())
+	for _, migration := range applied {
+		log.Printf("Applied migration %d: %s", migration.Version, migration.Description)
+	}
+	return err
 }
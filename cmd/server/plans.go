@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// requirePlanApproval makes the server refuse to execute plans no reviewer approved, set by
+// $REQUIRE_PLAN_APPROVAL
+var requirePlanApproval bool
+
+// plansHandler lists stored plans newest first with their approval, optionally of one cluster
+func plansHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	plans, err := store.ListPlans(r.Context(), r.URL.Query().Get("cluster"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list plans: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	infos := make([]api.PlanInfo, len(plans))
+	for i, plan := range plans {
+		infos[i] = api.NewPlanInfo(plan)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+// planHandler serves a stored plan: GET /plans/{id} returns it, POST /plans/{id}/approve approves
+// it as the caller and POST /plans/{id}/execute marks it as executed by the caller
+func planHandler(w http.ResponseWriter, r *http.Request) {
+	idParam, operation, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/plans/"), "/")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid plan ID %q", idParam), http.StatusBadRequest)
+		return
+	}
+
+	method := http.MethodPost
+	if operation == "" {
+		method = http.MethodGet
+	}
+	if r.Method != method {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var plan *ent.Plan
+	switch operation {
+	case "":
+		plan, err = store.GetPlan(r.Context(), id)
+	case "approve":
+		plan, err = transitionPlan(r, id, inventory.AuditApprove, func() (*ent.Plan, error) {
+			return store.ApprovePlan(r.Context(), id, callerOf(r).name)
+		})
+	case "execute":
+		required := requirePlanApproval || r.URL.Query().Get("requireApproval") == "true"
+		plan, err = transitionPlan(r, id, inventory.AuditExecute, func() (*ent.Plan, error) {
+			return store.ExecutePlan(r.Context(), id, callerOf(r).name, required)
+		})
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case ent.IsNotFound(err):
+		http.Error(w, fmt.Sprintf("Plan %d not found", id), http.StatusNotFound)
+		return
+	case errors.Is(err, inventory.ErrPlanState):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to update plan: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.NewPlanInfo(plan))
+}
+
+// transitionPlan changes the state of a plan and records the change in the audit log
+func transitionPlan(r *http.Request, id int, action string, transition func() (*ent.Plan, error)) (*ent.Plan, error) {
+	entry := newAuditEntry(r, action, "")
+	entry.Parameters["plan"] = strconv.Itoa(id)
+
+	plan, err := transition()
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Succeeded = true
+		entry.Summary = fmt.Sprintf("plan %d from %s to %s %s", id, plan.FromVersion, plan.TargetVersion, plan.State)
+		if plan.Edges.Cluster != nil {
+			entry.ClusterID = plan.Edges.Cluster.ID
+		}
+	}
+	recordAudit(entry)
+	return plan, err
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/api"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+)
+
+// keepAliveInterval is how often an idle event stream gets a comment, so proxies keep it open
+const keepAliveInterval = 15 * time.Second
+
+// scanJob is a scan triggered through the server, with the events it reported so far
+type scanJob struct {
+	mu     sync.Mutex
+	status api.ScanStatus
+	events []scan.Event
+	// changed is closed and replaced on every update, waking the streams following the scan
+	changed chan struct{}
+}
+
+// publish records a progress event of the scan
+func (j *scanJob) publish(event scan.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, event)
+	j.status.Phase = event.Phase
+	j.notify()
+}
+
+// finish records the outcome of the scan
+func (j *scanJob) finish(version string, scanErrors map[string]string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	finished := time.Now()
+	j.status.FinishedAt = &finished
+	j.status.Version = version
+	j.status.ScanErrors = scanErrors
+	j.status.Phase = scan.PhaseDone
+	switch {
+	case err != nil:
+		j.status.State = api.ScanFailed
+		j.status.Error = err.Error()
+	case len(scanErrors) > 0:
+		j.status.State = api.ScanPartial
+	default:
+		j.status.State = api.ScanCompleted
+	}
+	j.notify()
+}
+
+func (j *scanJob) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// since returns the events after the first n, the current status and a channel closed on the next update
+func (j *scanJob) since(n int) ([]scan.Event, api.ScanStatus, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if n > len(j.events) {
+		n = len(j.events)
+	}
+	return append([]scan.Event(nil), j.events[n:]...), j.status, j.changed
+}
+
+func (j *scanJob) snapshot() api.ScanStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// scanManager keeps the scans triggered since the server started, one running scan per cluster
+type scanManager struct {
+	mu     sync.Mutex
+	nextID int
+	jobs   map[int]*scanJob
+}
+
+var scans = &scanManager{nextID: 1, jobs: make(map[int]*scanJob)}
+
+// errScanRunning means the cluster is being scanned already
+var errScanRunning = errors.New("a scan of the cluster is already running")
+
+// start scans a cluster in the background with its configured context, or the cluster the server
+// runs in, recording it in the audit log when it finishes
+func (m *scanManager) start(clusterID string, incremental bool, audit inventory.AuditEntry) (*scanJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if status := job.snapshot(); status.ClusterID == clusterID && status.State == api.ScanRunning {
+			return nil, errScanRunning
+		}
+	}
+
+	job := &scanJob{
+		status: api.ScanStatus{
+			ID:        m.nextID,
+			ClusterID: clusterID,
+			State:     api.ScanRunning,
+			StartedAt: time.Now(),
+		},
+		changed: make(chan struct{}),
+	}
+	m.jobs[job.status.ID] = job
+	m.nextID++
+
+	go func() {
+		ctx := context.Background()
+		version, scanErrors, err := runScan(ctx, job, clusterID, incremental)
+		if err != nil {
+			log.Printf("Scan #%d of %s failed: %v", job.status.ID, clusterID, err)
+		}
+		job.finish(version, scanErrors, err)
+
+		switch {
+		case err != nil:
+			audit.Error = err.Error()
+		case len(scanErrors) > 0:
+			audit.Summary = fmt.Sprintf("scan #%d of Kubernetes %s, partially: %s not scanned", job.status.ID, version, strings.Join(inventory.ScanErrorSubsystems(scanErrors), ", "))
+		default:
+			audit.Summary = fmt.Sprintf("scan #%d of Kubernetes %s", job.status.ID, version)
+		}
+		audit.Succeeded = err == nil
+		recordAudit(audit)
+	}()
+	return job, nil
+}
+
+// runScan scans the cluster and stores the scan status with its inventory
+func runScan(ctx context.Context, job *scanJob, clusterID string, incremental bool) (string, map[string]string, error) {
+	kubeClient, err := kubeClientFor(clusterID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	opts := scanOptionsFor(clusterID)
+	opts.Incremental = incremental
+	opts.Progress = job.publish
+	version, scanErrors, err := scan.Cluster(ctx, store, kubeClient, clusterID, opts)
+	if err != nil {
+		return version, scanErrors, err
+	}
+	if err := store.SetIncompleteReason(ctx, clusterID, ""); err != nil {
+		return version, scanErrors, err
+	}
+	if err := store.SetScanErrors(ctx, clusterID, scanErrors); err != nil {
+		return version, scanErrors, err
+	}
+	return version, scanErrors, nil
+}
+
+func (m *scanManager) get(id int) *scanJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id]
+}
+
+// list returns the status of every scan, newest first
+func (m *scanManager) list() []api.ScanStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]api.ScanStatus, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		statuses = append(statuses, job.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID > statuses[j].ID
+	})
+	return statuses
+}
+
+// scansHandler starts a scan (POST, with cluster and incremental parameters), or lists the scans
+// started since the server started, or returns one when an id is given (GET)
+func scansHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		clusterID := r.URL.Query().Get("cluster")
+		if clusterID == "" {
+			clusterID = "cluster-1" // Default cluster
+		}
+		incremental, _ := strconv.ParseBool(r.URL.Query().Get("incremental"))
+		if !scannable(clusterID) {
+			http.Error(w, fmt.Sprintf("Cluster %s is not configured for scanning on this server", clusterID), http.StatusBadRequest)
+			return
+		}
+
+		job, err := scans.start(clusterID, incremental, newAuditEntry(r, inventory.AuditScan, clusterID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/scans?id=%d", job.snapshot().ID))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job.snapshot())
+
+	case http.MethodGet:
+		if idParam := r.URL.Query().Get("id"); idParam != "" {
+			job, ok := lookupScan(w, idParam)
+			if !ok {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(job.snapshot())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scans.list())
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// scanEventsHandler streams the progress of a scan as server-sent events: a "progress" event per
+// scan.Event from the start of the scan, or after the Last-Event-ID of a reconnecting client, and
+// a final "status" event once it has finished
+func scanEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	job, ok := lookupScan(w, r.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Event IDs are the positions of the events, so a reconnecting client resumes after the last one
+	sent := 0
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && lastID >= 0 {
+		sent = lastID + 1
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		events, status, changed := job.since(sent)
+		for _, event := range events {
+			writeEvent(w, sent, "progress", event)
+			sent++
+		}
+		if status.State != api.ScanRunning {
+			writeEvent(w, sent, "status", status)
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a server-sent event with a JSON payload
+func writeEvent(w http.ResponseWriter, id int, name string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Warning: failed to encode %s event: %v", name, err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, name, data)
+}
+
+// lookupScan resolves the id parameter of a scan, writing the error response when there is none
+func lookupScan(w http.ResponseWriter, idParam string) (*scanJob, bool) {
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid id: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	job := scans.get(id)
+	if job == nil {
+		http.Error(w, fmt.Sprintf("Scan %d not found", id), http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// searchHandler finds the inventory items matching the q pattern across the fleet, filtered by
+// cluster, type (comma-separated) and limit
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := inventory.SearchFilter{
+		Pattern:   query.Get("q"),
+		ClusterID: query.Get("cluster"),
+	}
+	if types := query.Get("type"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid limit: %q", limit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid search: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	result, err := store.Search(r.Context(), filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to search inventory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/redact"
+)
+
+// slackSigningSecret verifies that slash commands come from Slack; the /slack/commands endpoint
+// is only served when it is set
+var slackSigningSecret string
+
+// publicURL is the address the server is reached at, for links in Slack replies
+var publicURL string
+
+// slackMaxSkew is how old a slash command may be, limiting replays of captured requests
+const slackMaxSkew = 5 * time.Minute
+
+// slackTopFindings is how many findings a Slack reply lists
+const slackTopFindings = 5
+
+// slackResponseHost is the only host slash command replies are posted to
+const slackResponseHost = "hooks.slack.com"
+
+// slackMessage is a slash command reply; in_channel replies are visible to the whole channel,
+// ephemeral ones only to the user who ran the command
+type slackMessage struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// slackCommandHandler handles Slack slash commands, e.g. "/kua impact prod 1.29". Slack expects
+// an answer within 3 seconds, so the assessment is acknowledged at once and its result posted to
+// the command's response URL when done. Results are only shown to the user who ran the command,
+// unless "public" is added, which posts them to the channel redacted.
+func slackCommandHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		http.Error(w, "Failed to read request", http.StatusBadRequest)
+		return
+	}
+	if err := verifySlackRequest(r.Header, body, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	command := form.Get("command")
+	args := strings.Fields(form.Get("text"))
+	if len(args) == 0 || args[0] == "help" {
+		writeSlackMessage(w, slackMessage{ResponseType: "ephemeral", Text: slackUsage(command)})
+		return
+	}
+	public := len(args) == 4 && args[3] == "public"
+	if args[0] != "impact" || (len(args) != 3 && !public) {
+		writeSlackMessage(w, slackMessage{
+			ResponseType: "ephemeral",
+			Text:         fmt.Sprintf("Unknown command %q.\n%s", strings.Join(args, " "), slackUsage(command)),
+		})
+		return
+	}
+
+	// the same role check as /impact, which computes and stores assessments
+	caller, ok := slackCaller(form.Get("user_id"), form.Get("user_name"))
+	if !ok || caller.role != RoleAdmin {
+		writeSlackMessage(w, slackMessage{
+			ResponseType: "ephemeral",
+			Text:         "You may not run impact assessments; ask an admin to add your Slack member ID to the API token file.",
+		})
+		return
+	}
+
+	clusterID, targetVersion := args[1], args[2]
+	responseURL := form.Get("response_url")
+	if err := checkSlackResponseURL(responseURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit := inventory.AuditEntry{
+		Action:     inventory.AuditImpact,
+		Actor:      caller.name,
+		Origin:     inventory.AuditOriginServer,
+		ClusterID:  clusterID,
+		Parameters: map[string]string{"target": targetVersion, "channel": form.Get("channel_name"), "public": strconv.FormatBool(public)},
+		StartedAt:  time.Now(),
+	}
+	go func() {
+		reply := slackImpact(clusterID, targetVersion, public, &audit)
+		recordAudit(audit)
+		if err := postSlackReply(responseURL, reply); err != nil {
+			log.Printf("Warning: failed to reply to Slack: %v", err)
+		}
+	}()
+
+	writeSlackMessage(w, slackMessage{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("Assessing the upgrade of %s to %s...", clusterID, targetVersion),
+	})
+}
+
+// verifySlackRequest checks the signature Slack computes over the timestamp and body of a request
+// with the app's signing secret
+func verifySlackRequest(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid request timestamp")
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return fmt.Errorf("request timestamp too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(slackSigningSecret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid request signature")
+	}
+	return nil
+}
+
+// checkSlackResponseURL checks that a response URL points to Slack, so the server never posts
+// results anywhere else
+func checkSlackResponseURL(responseURL string) error {
+	u, err := url.Parse(responseURL)
+	if err != nil || u.Scheme != "https" || u.Host != slackResponseHost || u.User != nil {
+		return fmt.Errorf("response_url must be an https URL on %s", slackResponseHost)
+	}
+	return nil
+}
+
+// slackUsage describes the supported commands
+func slackUsage(command string) string {
+	if command == "" {
+		command = "/kua"
+	}
+	return fmt.Sprintf("Usage: `%s impact <cluster> <version> [public]`, e.g. `%s impact prod 1.29`; `public` posts the redacted result to the channel", command, command)
+}
+
+// slackImpact assesses the upgrade of a cluster from its stored inventory, keeps the result like
+// /impact does and summarizes it for the user, or redacted for the channel when public
+func slackImpact(clusterID, targetVersion string, public bool, audit *inventory.AuditEntry) slackMessage {
+	ctx := context.Background()
+	assessment, err := analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err != nil {
+		audit.Error = err.Error()
+		return slackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf("Failed to assess %s: %v", clusterID, err)}
+	}
+
+	response := &planner.UpgradeAssessmentWithPlan{ImpactAssessment: assessment}
+	if plan, err := planner.NewPlanner().GeneratePlan(assessment); err == nil && plan != nil {
+		response.OrderedUpgradeSteps = plan.OrderedUpgradeSteps
+		response.UpgradePlan = plan
+	}
+	id, err := saveResult(ctx, assessment, response.UpgradePlan)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if retention.Enabled() {
+		if _, err := store.Prune(ctx, retention); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	audit.Succeeded = true
+	audit.Summary = fmt.Sprintf("Kubernetes %s, overall risk %s, %d issues", targetVersion, assessment.OverallRisk, assessment.TotalIssues)
+
+	if !public {
+		return slackMessage{ResponseType: "ephemeral", Text: slackSummary(response, id)}
+	}
+	// everyone in the channel sees public replies, so they are redacted as for external callers
+	if err := redact.NewRedactor().Redact(response); err != nil {
+		return slackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf("Failed to redact the assessment of %s: %v", clusterID, err)}
+	}
+	return slackMessage{ResponseType: "in_channel", Text: slackSummary(response, id)}
+}
+
+// slackSummary formats an assessment as Slack mrkdwn: its risk, the most severe findings and a
+// link to the stored assessment, when it was stored and the server's public URL is known
+func slackSummary(response *planner.UpgradeAssessmentWithPlan, id int) string {
+	assessment := response.ImpactAssessment
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Upgrade of %s from %s to %s*: overall risk *%s*, %d issues",
+		assessment.ClusterID, assessment.CurrentVersion, assessment.TargetVersion, assessment.OverallRisk, assessment.TotalIssues)
+	if response.UpgradePlan != nil {
+		fmt.Fprintf(&b, ", %d plan steps", response.UpgradePlan.TotalSteps)
+	}
+	b.WriteString("\n")
+
+	findings := assessment.Findings()
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity != findings[j].Severity && findings[i].Severity.AtLeast(findings[j].Severity)
+	})
+	for i, finding := range findings {
+		if i == slackTopFindings {
+			fmt.Fprintf(&b, "…and %d more\n", len(findings)-slackTopFindings)
+			break
+		}
+		fmt.Fprintf(&b, "• [%s] %s `%s`: %s\n", finding.Severity, finding.RuleID, finding.Resource, finding.Summary)
+	}
+
+	if id != 0 && publicURL != "" {
+		fmt.Fprintf(&b, "<%s/assessments?id=%d|Full report>", strings.TrimSuffix(publicURL, "/"), id)
+	}
+	return b.String()
+}
+
+// writeSlackMessage answers a slash command directly
+func writeSlackMessage(w http.ResponseWriter, message slackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+// postSlackReply posts a delayed reply to the response URL of a slash command
+func postSlackReply(responseURL string, message slackMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reply: %w", err)
+	}
+	resp, err := network.Client(30*time.Second).Post(responseURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post reply: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signSlackRequest returns the headers Slack sends with a request body signed at a time
+func signSlackRequest(secret, body string, at time.Time) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerifySlackRequest(t *testing.T) {
+	slackSigningSecret = "signing-secret"
+	defer func() { slackSigningSecret = "" }()
+
+	body := "command=%2Fkua&text=impact+prod+1.29&user_id=U123"
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    string
+		wantErr bool
+	}{
+		{"valid", signSlackRequest("signing-secret", body, now), body, false},
+		{"valid with small skew", signSlackRequest("signing-secret", body, now.Add(-time.Minute)), body, false},
+		{"bad signature", signSlackRequest("other-secret", body, now), body, true},
+		{"tampered body", signSlackRequest("signing-secret", body, now), body + "&text=impact+staging+1.29", true},
+		{"stale timestamp", signSlackRequest("signing-secret", body, now.Add(-slackMaxSkew-time.Second)), body, true},
+		{"future timestamp", signSlackRequest("signing-secret", body, now.Add(slackMaxSkew+time.Second)), body, true},
+		{"missing headers", http.Header{}, body, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySlackRequest(tt.header, []byte(tt.body), now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySlackRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSlackResponseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://hooks.slack.com/commands/T123/456/abc", false},
+		{"http://hooks.slack.com/commands/T123/456/abc", true},
+		{"https://hooks.slack.com.evil.example/commands", true},
+		{"https://user@hooks.slack.com/commands", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := checkSlackResponseURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("checkSlackResponseURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSlackCaller(t *testing.T) {
+	defer func() { tokens, slackUsers = nil, nil }()
+
+	if caller, ok := slackCaller("U123", "alice"); !ok || caller.role != RoleAdmin {
+		t.Errorf("without tokens got %v %v, want an admin", caller, ok)
+	}
+
+	tokens = []APIToken{{Name: "platform", Token: "secret", Role: RoleAdmin}}
+	slackUsers = []SlackUser{{ID: "U123", Role: RoleAdmin}, {ID: "U456", Role: RoleExternal}}
+	if caller, ok := slackCaller("U123", "alice"); !ok || caller.role != RoleAdmin || caller.name != "slack:alice" {
+		t.Errorf("listed admin got %v %v", caller, ok)
+	}
+	if caller, ok := slackCaller("U456", "bob"); !ok || caller.role != RoleExternal {
+		t.Errorf("listed external user got %v %v", caller, ok)
+	}
+	if _, ok := slackCaller("U789", "mallory"); ok {
+		t.Error("unlisted user was given a role")
+	}
+}
@@ -0,0 +1,56 @@
+#!/bin/sh
+# Renders a krew plugin manifest for the release archives built by `make dist`.
+# Usage: hack/krew-manifest.sh <version> <dist-dir>
+set -e
+
+VERSION="$1"
+DIST="$2"
+PLUGIN="kubectl-upgrade_advisor"
+REPO="https://github.com/retr0-kernel/kube-upgrade-advisor"
+
+if [ -z "$VERSION" ] || [ -z "$DIST" ]; then
+  echo "usage: $0 <version> <dist-dir>" >&2
+  exit 1
+fi
+
+cat <<MANIFEST
+apiVersion: krew.googlecontainertools.github.com/v1alpha2
+kind: Plugin
+metadata:
+  name: upgrade-advisor
+spec:
+  version: ${VERSION}
+  homepage: ${REPO}
+  shortDescription: Analyze the impact of a Kubernetes version upgrade
+  description: |
+    Scans the cluster, Helm releases, CRDs and local manifests for deprecated
+    APIs and incompatible charts, then reports the impact of upgrading to a
+    target Kubernetes version together with an ordered upgrade plan.
+
+      kubectl upgrade-advisor scan
+      kubectl upgrade-advisor impact --target 1.29
+  platforms:
+MANIFEST
+
+for archive in "$DIST"/${PLUGIN}_${VERSION}_*.tar.gz; do
+  [ -e "$archive" ] || continue
+  name=$(basename "$archive" .tar.gz)
+  platform=${name#${PLUGIN}_${VERSION}_}
+  os=${platform%_*}
+  arch=${platform#*_}
+  sha=$(sha256sum "$archive" | cut -d' ' -f1)
+  bin=$PLUGIN
+  if [ "$os" = "windows" ]; then
+    bin="$PLUGIN.exe"
+  fi
+
+  cat <<PLATFORM
+  - selector:
+      matchLabels:
+        os: ${os}
+        arch: ${arch}
+    uri: ${REPO}/releases/download/${VERSION}/$(basename "$archive")
+    sha256: ${sha}
+    bin: ${bin}
+PLATFORM
+done
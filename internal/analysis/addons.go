@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// Platforms that run the control plane, detected from node labels
+const (
+	PlatformKubeadm = "kubeadm" // self-managed; the default
+	PlatformEKS     = "eks"
+	PlatformGKE     = "gke"
+	PlatformAKS     = "aks"
+)
+
+// platformNodeLabels identifies managed platforms by a label their nodes carry
+var platformNodeLabels = map[string]string{
+	"eks.amazonaws.com/nodegroup":   PlatformEKS,
+	"alpha.eksctl.io/cluster-name":  PlatformEKS,
+	"cloud.google.com/gke-nodepool": PlatformGKE,
+	"kubernetes.azure.com/cluster":  PlatformAKS,
+}
+
+// AddonFinding is a kube-system addon older than the version shipped with the target release
+type AddonFinding struct {
+	FindingMeta
+	Addon          string   `json:"addon"`
+	Namespace      string   `json:"namespace"`
+	Kind           string   `json:"kind"`
+	Workload       string   `json:"workload"`
+	CurrentVersion string   `json:"currentVersion"`
+	TargetVersion  string   `json:"targetVersion"`
+	Platform       string   `json:"platform"`
+	Managed        bool     `json:"managed"`            // the platform upgrades the addon with the control plane
+	Commands       []string `json:"commands,omitempty"` // upgrade commands for the platform
+	// node platforms (os/arch) the addon publishes no images for
+	MissingPlatforms []string `json:"missingPlatforms,omitempty"`
+	// upstream image of the target version, for platforms pulling addons from upstream registries
+	Image    string      `json:"image,omitempty"`
+	Notes    string      `json:"notes"`
+	Severity ImpactLevel `json:"severity"`
+}
+
+// LoadAddonKnowledge loads the kube-system addon knowledge base
+func (a *Analyzer) LoadAddonKnowledge(path string) error {
+	addonKB := knowledge.NewAddonKnowledgeBase()
+	if err := addonKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load addon knowledge base: %w", err)
+	}
+	a.addonKB = addonKB
+	return nil
+}
+
+// DetectPlatform guesses the platform running the control plane from node labels
+func DetectPlatform(state *inventory.LiveClusterState) string {
+	for _, node := range state.Nodes {
+		for label, platform := range platformNodeLabels {
+			if _, found := node.Labels[label]; found {
+				return platform
+			}
+		}
+	}
+	return PlatformKubeadm
+}
+
+// CheckAddons reports kube-system addons (CoreDNS, kube-proxy, konnectivity) older than the versions
+// shipped with the target release. Addons the platform upgrades with the control plane are low
+// severity; the others need an explicit upgrade step, as do addons lacking images for a node platform.
+func (a *Analyzer) CheckAddons(state *inventory.LiveClusterState, targetVersion string) []AddonFinding {
+	findings := make([]AddonFinding, 0)
+	if a.addonKB == nil {
+		return findings
+	}
+
+	platform := DetectPlatform(state)
+	nodePlatforms := inventory.NodePlatforms(state.Nodes)
+	for _, workload := range state.Workloads {
+		if workload.Namespace != "kube-system" || (workload.Kind != "Deployment" && workload.Kind != "DaemonSet") {
+			continue
+		}
+		for _, image := range workload.Images {
+			addon, version, found := a.addonKB.DetectAddon(image)
+			if !found || version == "" {
+				continue
+			}
+			expected := addon.VersionFor(targetVersion)
+			if expected == "" || knowledge.CompareVersions(version, expected) >= 0 {
+				continue
+			}
+
+			finding := AddonFinding{
+				Addon:            addon.Name,
+				Namespace:        workload.Namespace,
+				Kind:             workload.Kind,
+				Workload:         workload.Name,
+				CurrentVersion:   version,
+				TargetVersion:    expected,
+				Platform:         platform,
+				Managed:          addon.IsManagedBy(platform),
+				MissingPlatforms: addon.MissingPlatforms(nodePlatforms),
+				Notes:            addon.Notes,
+				Severity:         ImpactMedium,
+			}
+			if finding.Managed && len(finding.MissingPlatforms) == 0 {
+				finding.Severity = ImpactLow
+			}
+			// image tags need a patch release, e.g. kube-proxy for a 1.29 target
+			tag := expected
+			if strings.Count(tag, ".") < 2 {
+				tag += ".<patch>"
+			}
+			replacer := strings.NewReplacer(
+				"{version}", tag,
+				"{kubernetes}", strings.TrimPrefix(targetVersion, "v"),
+				"{namespace}", workload.Namespace,
+				"{kind}", strings.ToLower(workload.Kind),
+				"{workload}", workload.Name,
+			)
+			for _, command := range addon.Upgrade[platform] {
+				finding.Commands = append(finding.Commands, replacer.Replace(command))
+			}
+			// managed platforms pull addons from their own registries
+			if platform == PlatformKubeadm && addon.Image != "" && tag == expected {
+				finding.Image = replacer.Replace(addon.Image)
+			}
+			findings = append(findings, finding)
+			break
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Addon < findings[j].Addon
+	})
+
+	return findings
+}
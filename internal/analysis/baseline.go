@@ -0,0 +1,258 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// baselineFormatVersion is bumped when the baseline file layout changes incompatibly. Version 2
+// keys removed-API findings by object and chart findings by release.
+const baselineFormatVersion = 2
+
+// FindingRef identifies a single finding independently of the run that produced it
+type FindingRef struct {
+	RuleID   string      `json:"ruleId"`
+	Resource string      `json:"resource"`
+	Severity ImpactLevel `json:"severity"`
+	Summary  string      `json:"summary"`
+	// Group, Version and Kind are set for API findings so callers can locate the affected manifests
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	// UpstreamDocs links the upstream migration guides, see FindingMeta
+	UpstreamDocs []string `json:"upstreamDocs,omitempty"`
+
+	// source is the finding the reference was made from, for Explain
+	source findingSource
+}
+
+// Baseline is a set of accepted findings; only findings not in it fail CI
+type Baseline struct {
+	Version       int          `json:"version"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	TargetVersion string       `json:"targetVersion"`
+	Findings      []FindingRef `json:"findings"`
+}
+
+// BaselineResult splits an assessment's findings into new and pre-existing ones
+type BaselineResult struct {
+	NewFindings      []FindingRef `json:"newFindings"`
+	ExistingFindings []FindingRef `json:"existingFindings"`
+	FixedCount       int          `json:"fixedCount"`
+}
+
+// Remediation returns the fix metadata of the finding, nil when unknown, e.g. for references read
+// from a baseline file
+func (f FindingRef) Remediation() *Remediation {
+	if f.source == nil {
+		return nil
+	}
+	return f.source.meta().Remediation
+}
+
+// Key identifies a finding across runs; severity and wording may change without making it new
+func (f FindingRef) Key() string {
+	return f.RuleID + "|" + f.Resource
+}
+
+// Findings flattens every finding of the assessment into stable references
+func (assessment *ImpactAssessment) Findings() []FindingRef {
+	var findings []FindingRef
+	add := func(source findingSource, resource string, severity ImpactLevel, summary string) *FindingRef {
+		meta := source.meta()
+		if meta.RuleID == "" {
+			return nil
+		}
+		findings = append(findings, FindingRef{RuleID: meta.RuleID, Resource: resource, Severity: severity, Summary: summary,
+			UpstreamDocs: meta.UpstreamDocs, source: source})
+		return &findings[len(findings)-1]
+	}
+	addAPI := func(source findingSource, resource string, severity ImpactLevel, summary, group, version, kind string) {
+		if finding := add(source, resource, severity, summary); finding != nil {
+			finding.Group, finding.Version, finding.Kind = group, version, kind
+		}
+	}
+
+	// A removed API is a finding per affected object when the objects are known, so accepting
+	// the objects of a baseline does not accept new ones using the API
+	for _, api := range assessment.DeprecatedManifestAPIs {
+		gvk := fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)
+		summary := fmt.Sprintf("%s removed in %s", api.Kind, api.RemovedIn)
+		if len(api.Provenance) == 0 {
+			addAPI(api, gvk, api.ImpactLevel, summary, api.Group, api.Version, api.Kind)
+			continue
+		}
+		for _, resource := range api.Provenance {
+			object := api
+			object.AffectedCount = 1
+			object.Provenance = []ResourceProvenance{resource}
+			addAPI(object, gvk+":"+resource.Resource, api.ImpactLevel, summary, api.Group, api.Version, api.Kind)
+		}
+	}
+	for _, api := range assessment.DeprecatedCRDAPIs {
+		addAPI(api, fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind), api.ImpactLevel,
+			fmt.Sprintf("CRD version removed in %s", api.RemovedIn), api.Group, api.Version, api.Kind)
+	}
+	for _, api := range assessment.DeprecatedOperatorAPIs {
+		addAPI(api, fmt.Sprintf("%s:%s:%s/%s/%s", api.Source, api.Resource, api.Group, api.Version, api.Kind), api.ImpactLevel,
+			fmt.Sprintf("deprecated by %s", api.Operator), api.Group, api.Version, api.Kind)
+	}
+	for _, finding := range assessment.CRDFindings {
+		add(finding, fmt.Sprintf("%s:%s", finding.CRD, finding.Resource), finding.Severity, finding.Description)
+	}
+	if finding := assessment.PolicyMigration; finding != nil {
+		add(finding, "PodSecurityPolicy", finding.Severity, finding.Description)
+	}
+	for _, finding := range assessment.BehaviorChanges {
+		add(finding, fmt.Sprintf("%s:%s", finding.Change, finding.Kind), finding.Severity,
+			fmt.Sprintf("%d %s changed in %s", len(finding.Resources), finding.Kind, finding.ChangedIn))
+	}
+	for _, finding := range assessment.PodFields {
+		add(finding, fmt.Sprintf("%s:%s", finding.Field, finding.Kind), finding.Severity,
+			fmt.Sprintf("%d %s use %s", len(finding.Resources), finding.Kind, finding.Field))
+	}
+	for _, finding := range assessment.HPAs {
+		add(finding, finding.Resource, finding.Severity,
+			fmt.Sprintf("%s with %d notes", finding.APIVersion, len(finding.Notes)))
+	}
+	for _, finding := range assessment.Meshes {
+		add(finding, fmt.Sprintf("%s:%s", finding.Mesh, finding.Resource), finding.Severity,
+			fmt.Sprintf("%s %s supports Kubernetes up to %s", finding.Mesh, finding.Version, finding.MaxKubeVersion))
+	}
+	for _, chart := range assessment.IncompatibleCharts {
+		add(chart, chartResource(chart), chart.ImpactLevel, chart.Message)
+	}
+	for _, chart := range assessment.ChartCaveats {
+		add(chart, chartResource(chart), chart.ImpactLevel, strings.Join(chart.Issues, "; "))
+	}
+	for _, signal := range assessment.RiskSignals {
+		add(signal, signal.Resource, signal.Severity, signal.Description)
+	}
+	for _, node := range assessment.NodeDrainResults {
+		for _, blocker := range node.Blockers {
+			add(blocker, fmt.Sprintf("%s/%s", blocker.Namespace, blocker.Pod), ImpactHigh,
+				fmt.Sprintf("blocks draining %s: %s", node.Node, blocker.Detail))
+		}
+	}
+	for _, warning := range assessment.SchedulingWarnings {
+		add(warning, warning.Resource, warning.Severity, warning.Description)
+	}
+	for _, finding := range assessment.StorageFindings {
+		add(finding, finding.Resource, finding.Severity, finding.Description)
+	}
+	for _, finding := range assessment.IngressAnnotations {
+		add(finding, fmt.Sprintf("%s:%s", finding.Ingress, finding.Annotation), finding.Severity,
+			fmt.Sprintf("%s in %s %s", finding.Change, finding.Controller, finding.ChangedIn))
+	}
+	for _, finding := range assessment.Addons {
+		add(finding, fmt.Sprintf("%s/%s", finding.Namespace, finding.Workload), finding.Severity,
+			fmt.Sprintf("%s %s older than %s", finding.Addon, finding.CurrentVersion, finding.TargetVersion))
+	}
+	for _, finding := range assessment.NodeConfigs {
+		add(finding, fmt.Sprintf("%s:%s", finding.Setting, finding.Component), finding.Severity,
+			fmt.Sprintf("%s uses %s", finding.Component, finding.Flag))
+	}
+	for _, finding := range assessment.DevicePlugins {
+		add(finding, fmt.Sprintf("%s:%s/%s", finding.Plugin, finding.Namespace, finding.Workload), finding.Severity,
+			fmt.Sprintf("%s %s supports Kubernetes up to %s", finding.Plugin, finding.Version, finding.MaxKubeVersion))
+	}
+	for _, result := range assessment.ValidationResults {
+		add(result, fmt.Sprintf("%s %s", result.APIVersion, result.Resource()), ImpactHigh, result.Message)
+	}
+	for _, drift := range assessment.ReleaseDrift {
+		add(drift, fmt.Sprintf("%s/%s", drift.Namespace, drift.Release), drift.Severity,
+			fmt.Sprintf("%d added, %d removed, %d changed since %s", len(drift.Added), len(drift.Removed), len(drift.Changed), drift.SourceFile))
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Key() < findings[j].Key()
+	})
+
+	return findings
+}
+
+// chartResource identifies the release of a chart finding, or the chart where the release is
+// unknown, e.g. for charts found in manifests
+func chartResource(chart ChartImpact) string {
+	if chart.Release != "" {
+		return fmt.Sprintf("%s/%s", chart.Namespace, chart.Release)
+	}
+	return fmt.Sprintf("%s/%s", chart.Namespace, chart.ChartName)
+}
+
+// NewBaseline accepts all current findings of an assessment
+func NewBaseline(assessment *ImpactAssessment) *Baseline {
+	return &Baseline{
+		Version:       baselineFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		TargetVersion: assessment.TargetVersion,
+		Findings:      assessment.Findings(),
+	}
+}
+
+// LoadBaseline reads a baseline file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal baseline: %w", err)
+	}
+	if baseline.Version > baselineFormatVersion {
+		return nil, fmt.Errorf("baseline version %d is newer than supported version %d", baseline.Version, baselineFormatVersion)
+	}
+	if baseline.Version < baselineFormatVersion {
+		return nil, fmt.Errorf("baseline version %d keys findings by API and chart, not by object and release; write it again with --write-baseline", baseline.Version)
+	}
+
+	return &baseline, nil
+}
+
+// WriteFile writes the baseline as indented JSON
+func (b *Baseline) WriteFile(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Compare splits the assessment's findings into ones accepted by the baseline and new ones
+func (b *Baseline) Compare(assessment *ImpactAssessment) *BaselineResult {
+	accepted := make(map[string]bool, len(b.Findings))
+	for _, finding := range b.Findings {
+		accepted[finding.Key()] = true
+	}
+
+	result := &BaselineResult{
+		NewFindings:      make([]FindingRef, 0),
+		ExistingFindings: make([]FindingRef, 0),
+	}
+	seen := make(map[string]bool)
+	for _, finding := range assessment.Findings() {
+		seen[finding.Key()] = true
+		if accepted[finding.Key()] {
+			result.ExistingFindings = append(result.ExistingFindings, finding)
+		} else {
+			result.NewFindings = append(result.NewFindings, finding)
+		}
+	}
+
+	for key := range accepted {
+		if !seen[key] {
+			result.FixedCount++
+		}
+	}
+
+	return result
+}
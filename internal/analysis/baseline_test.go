@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// baselineAssessment has a removed API used by two objects, two releases of one chart and a
+// pod blocking a drain
+func baselineAssessment() *ImpactAssessment {
+	assessment := &ImpactAssessment{
+		ClusterID:     "prod",
+		TargetVersion: "1.22",
+		DeprecatedManifestAPIs: []DeprecatedAPIImpact{{
+			Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress", AffectedCount: 2, ImpactLevel: ImpactHigh, RemovedIn: "1.22",
+			Provenance: []ResourceProvenance{
+				{Resource: "shop/web", Sources: []string{"cluster"}},
+				{Resource: "shop/api", Sources: []string{"apps/api.yaml"}},
+			},
+		}},
+		IncompatibleCharts: []ChartImpact{
+			{ChartName: "ingress-nginx", Namespace: "ingress", Release: "public", ImpactLevel: ImpactHigh},
+			{ChartName: "ingress-nginx", Namespace: "ingress", Release: "internal", ImpactLevel: ImpactHigh},
+		},
+		NodeDrainResults: []NodeDrainResult{{
+			Node:     "node-1",
+			Blockers: []DrainBlocker{{Pod: "db-0", Namespace: "shop", Reason: DrainBlockPDB, Detail: "PodDisruptionBudget allows no disruption"}},
+		}},
+	}
+	AssignRuleIDs(assessment)
+	return assessment
+}
+
+func TestFindingsKeys(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, finding := range baselineAssessment().Findings() {
+		keys[finding.Key()] = true
+	}
+
+	for _, want := range []string{
+		RuleRemovedManifestAPI.ID + "|networking.k8s.io/v1beta1/Ingress:shop/web",
+		RuleRemovedManifestAPI.ID + "|networking.k8s.io/v1beta1/Ingress:shop/api",
+		RuleDrainPDB.ID + "|shop/db-0",
+	} {
+		if !keys[want] {
+			t.Errorf("no finding %s in %v", want, keys)
+		}
+	}
+	releases := 0
+	for key := range keys {
+		if strings.HasSuffix(key, "|ingress/public") || strings.HasSuffix(key, "|ingress/internal") {
+			releases++
+		}
+	}
+	if releases != 2 {
+		t.Errorf("found %d chart findings keyed by release, want 2 in %v", releases, keys)
+	}
+}
+
+func TestBaselineCompareNewObject(t *testing.T) {
+	baseline := NewBaseline(baselineAssessment())
+
+	assessment := baselineAssessment()
+	api := &assessment.DeprecatedManifestAPIs[0]
+	api.Provenance = append(api.Provenance, ResourceProvenance{Resource: "shop/admin", Sources: []string{"cluster"}})
+
+	result := baseline.Compare(assessment)
+	if len(result.NewFindings) != 1 || !strings.HasSuffix(result.NewFindings[0].Resource, ":shop/admin") {
+		t.Errorf("new findings = %+v, want only the new Ingress", result.NewFindings)
+	}
+	if result.FixedCount != 0 {
+		t.Errorf("fixed = %d, want 0", result.FixedCount)
+	}
+}
+
+func TestLoadBaselineVersions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	if err := NewBaseline(baselineAssessment()).WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadBaseline(path); err != nil {
+		t.Errorf("LoadBaseline: %v", err)
+	}
+
+	old := filepath.Join(dir, "old.json")
+	if err := os.WriteFile(old, []byte(`{"version": 1, "findings": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(old); err == nil {
+		t.Error("LoadBaseline accepted a baseline keyed by API")
+	}
+}
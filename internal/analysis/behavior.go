@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// BehaviorChangeFinding is a behavior change of the target version affecting resources of one kind,
+// e.g. CronJobs with a timeZone that was ignored before. It is an advisory: the resources keep
+// applying, but may act differently after the upgrade.
+type BehaviorChangeFinding struct {
+	FindingMeta
+	Change         string      `json:"change"`
+	Kind           string      `json:"kind"`
+	ChangedIn      string      `json:"changedIn"`
+	Resources      []string    `json:"resources"`
+	Severity       ImpactLevel `json:"severity"`
+	Description    string      `json:"description"`
+	Recommendation string      `json:"recommendation"`
+}
+
+// LoadBehaviorKnowledge loads the Kubernetes behavior change knowledge base
+func (a *Analyzer) LoadBehaviorKnowledge(path string) error {
+	behaviorKB := knowledge.NewBehaviorKnowledgeBase()
+	if err := behaviorKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load behavior change knowledge base: %w", err)
+	}
+	a.behaviorKB = behaviorKB
+	return nil
+}
+
+// checkBehaviorChanges reports the resources affected by behavior changes between the current and
+// target version, one finding per change and kind
+func (a *Analyzer) checkBehaviorChanges(subjects []resourceSubject, currentVersion, targetVersion string) []BehaviorChangeFinding {
+	if a.behaviorKB == nil {
+		return nil
+	}
+
+	var findings []BehaviorChangeFinding
+	for _, change := range a.behaviorKB.Changes(currentVersion, targetVersion) {
+		for _, match := range matchFields(&change.FieldMatcher, subjects) {
+			findings = append(findings, BehaviorChangeFinding{
+				FindingMeta:    withUpstreamDocs(change.DocsURL),
+				Change:         change.ID,
+				Kind:           match.kind,
+				ChangedIn:      change.ChangedIn,
+				Resources:      match.resources,
+				Severity:       ImpactMedium,
+				Description:    change.Description,
+				Recommendation: change.Recommendation,
+			})
+		}
+	}
+	return findings
+}
+
+// mergeBehaviorChanges adds findings to those already in an assessment, e.g. live workloads to
+// findings from manifests, so a change and kind is reported once
+func mergeBehaviorChanges(existing, added []BehaviorChangeFinding) []BehaviorChangeFinding {
+	for _, finding := range added {
+		merged := false
+		for i := range existing {
+			if existing[i].Change == finding.Change && existing[i].Kind == finding.Kind {
+				existing[i].Resources = mergeResources(existing[i].Resources, finding.Resources)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			existing = append(existing, finding)
+		}
+	}
+	return existing
+}
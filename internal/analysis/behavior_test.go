@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// podSpecWith returns a Deployment or Job spec whose pod template has one container
+func podSpecWith(container map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	spec := map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": []interface{}{container}},
+		},
+	}
+	for key, value := range fields {
+		spec[key] = value
+	}
+	return spec
+}
+
+func TestBehaviorChangesSkipOrdinaryWorkloads(t *testing.T) {
+	behaviorKB := knowledge.NewBehaviorKnowledgeBase()
+	if err := behaviorKB.LoadFromFile(filepath.Join("..", "..", "knowledge-base", "behavior-changes.json")); err != nil {
+		t.Fatal(err)
+	}
+	analyzer := &Analyzer{behaviorKB: behaviorKB}
+
+	ptrace := map[string]interface{}{
+		"name": "debugger",
+		"securityContext": map[string]interface{}{
+			"capabilities": map[string]interface{}{"add": []interface{}{"SYS_PTRACE"}},
+		},
+	}
+	pinned := map[string]interface{}{
+		"name": "debugger",
+		"securityContext": map[string]interface{}{
+			"capabilities":   map[string]interface{}{"add": []interface{}{"SYS_PTRACE"}},
+			"seccompProfile": map[string]interface{}{"type": "Unconfined"},
+		},
+	}
+	plain := map[string]interface{}{"name": "app", "image": "app:1.0"}
+
+	subjects := []resourceSubject{
+		{kind: "Deployment", name: "shop/web", spec: podSpecWith(plain, nil)},
+		{kind: "Deployment", name: "ops/debugger", spec: podSpecWith(ptrace, nil)},
+		{kind: "Deployment", name: "ops/pinned", spec: podSpecWith(pinned, nil)},
+		{kind: "Job", name: "shop/report", spec: podSpecWith(plain, map[string]interface{}{"parallelism": 4})},
+		{kind: "Job", name: "batch/render", spec: podSpecWith(plain, map[string]interface{}{"completions": 500})},
+	}
+
+	got := make(map[string][]string)
+	for _, finding := range analyzer.checkBehaviorChanges(subjects, "1.25", "1.28") {
+		got[finding.Change] = append(got[finding.Change], finding.Resources...)
+	}
+	want := map[string][]string{
+		"job-tracking-finalizers": {"batch/render"},
+		"seccomp-default":         {"ops/debugger"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("behavior changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// ChartRenderer renders a chart version as it would be installed on a Kubernetes version and
+// returns the APIs it emits, as group/version/kind
+type ChartRenderer func(repository, chart, version, kubeVersion string) ([]string, error)
+
+// SetChartRenderer makes chart recommendations also render candidate versions, catching APIs the
+// chart matrix does not list, e.g. ones a template only emits for some Kubernetes versions. A
+// version that fails to render is checked against the APIs in the matrix only.
+func (a *Analyzer) SetChartRenderer(renderer ChartRenderer) {
+	a.chartRenderer = renderer
+	a.renderedCharts = make(map[string][]string)
+}
+
+// chartRemovedAPIs checks chart versions for APIs removed in the target version, from the APIs
+// the chart matrix lists and, with a chart renderer, the APIs the version renders
+func (a *Analyzer) chartRemovedAPIs(targetVersion string) knowledge.RemovedAPIsFunc {
+	return func(chart knowledge.ChartInfo, version knowledge.ChartCompatibility) []string {
+		apis := append([]string(nil), version.APIs...)
+		if a.chartRenderer != nil && chart.Repository != "" {
+			key := strings.Join([]string{chart.ChartName, version.ChartVersion, targetVersion}, "@")
+			rendered, ok := a.renderedCharts[key]
+			if !ok {
+				rendered, _ = a.chartRenderer(chart.Repository, chart.ChartName, version.ChartVersion, targetVersion)
+				a.renderedCharts[key] = rendered
+			}
+			apis = append(apis, rendered...)
+		}
+
+		seen := make(map[string]bool)
+		var removed []string
+		for _, api := range apis {
+			i := strings.LastIndex(api, "/")
+			if i < 0 || seen[api] {
+				continue
+			}
+			seen[api] = true
+			group, apiVersion, ok := ParseAPIVersion(api[:i])
+			if ok && a.apiKB.IsAPIRemoved(group, apiVersion, api[i+1:], targetVersion) {
+				removed = append(removed, api)
+			}
+		}
+		sort.Strings(removed)
+		return removed
+	}
+}
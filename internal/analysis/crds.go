@@ -0,0 +1,163 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// CRD finding problems
+const (
+	CRDProblemScope                = "scope"
+	CRDProblemTerminatingNamespace = "terminating-namespace"
+	CRDProblemConversionWebhook    = "conversion-webhook"
+	CRDProblemDeprecatedVersion    = "deprecated-version"
+	CRDProblemStoredVersion        = "stored-version"
+)
+
+// CRDFinding is a custom resource or CRD that is likely to fail during the upgrade: a manifest
+// setting a namespace on a cluster-scoped kind, a custom resource applied to a namespace being
+// deleted, a conversion webhook without a service to serve it, a served version the CRD deprecates,
+// or objects still stored at a version other than the storage version
+type CRDFinding struct {
+	FindingMeta
+	CRD         string      `json:"crd"`
+	Resource    string      `json:"resource"`
+	Problem     string      `json:"problem"`
+	Severity    ImpactLevel `json:"severity"`
+	Description string      `json:"description"`
+}
+
+// checkCRDs checks the custom resources of the manifests against the scope of their CRDs and the
+// namespaces being deleted, and the CRDs for conversion webhooks that can't serve, deprecated
+// versions and objects awaiting storage version migration
+func checkCRDs(manifestAPIs []*ent.ManifestAPI, crds []*ent.CRD, terminatingNamespaces []string) []CRDFinding {
+	var findings []CRDFinding
+
+	byKind := make(map[string]*ent.CRD, len(crds))
+	for _, crd := range crds {
+		byKind[crd.Group+"/"+crd.Kind] = crd
+		if crd.ConversionWebhookError != "" {
+			findings = append(findings, CRDFinding{
+				CRD:      crd.Name,
+				Resource: crd.ConversionWebhook,
+				Problem:  CRDProblemConversionWebhook,
+				Severity: ImpactHigh,
+				Description: fmt.Sprintf("Conversion webhook of %s can't serve: %s; reads and writes of %s at other versions fail",
+					crd.Name, crd.ConversionWebhookError, crd.Kind),
+			})
+		}
+		findings = append(findings, checkCRDVersions(crd)...)
+	}
+
+	terminating := make(map[string]bool, len(terminatingNamespaces))
+	for _, namespace := range terminatingNamespaces {
+		terminating[namespace] = true
+	}
+
+	for _, api := range manifestAPIs {
+		crd, ok := byKind[api.Group+"/"+api.Kind]
+		if !ok || len(api.Namespaces) == 0 {
+			continue
+		}
+		resource := fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)
+
+		if crd.Scope == "Cluster" {
+			findings = append(findings, CRDFinding{
+				CRD:      crd.Name,
+				Resource: resource,
+				Problem:  CRDProblemScope,
+				Severity: ImpactMedium,
+				Description: fmt.Sprintf("%s is cluster-scoped, but manifests set a namespace on it (%s)",
+					api.Kind, strings.Join(api.Namespaces, ", ")),
+			})
+		}
+
+		var doomed []string
+		for _, namespace := range api.Namespaces {
+			if terminating[namespace] {
+				doomed = append(doomed, namespace)
+			}
+		}
+		if len(doomed) > 0 {
+			sort.Strings(doomed)
+			findings = append(findings, CRDFinding{
+				CRD:      crd.Name,
+				Resource: resource,
+				Problem:  CRDProblemTerminatingNamespace,
+				Severity: ImpactHigh,
+				Description: fmt.Sprintf("Manifests apply %s to namespaces being deleted: %s",
+					api.Kind, strings.Join(doomed, ", ")),
+			})
+		}
+	}
+
+	return findings
+}
+
+// checkCRDVersions finds the served versions a CRD deprecates and the versions objects may still be
+// stored at besides the storage version. Stored objects must be migrated before a CRD update drops
+// their version from the definition, or they can no longer be read.
+func checkCRDVersions(crd *ent.CRD) []CRDFinding {
+	var findings []CRDFinding
+	storage := ""
+	defined := make(map[string]inventory.CRDVersion, len(crd.VersionDetails))
+	for _, version := range crd.VersionDetails {
+		defined[version.Name] = version
+		if version.Storage {
+			storage = version.Name
+		}
+	}
+
+	for _, version := range crd.VersionDetails {
+		if !version.Served || !version.Deprecated {
+			continue
+		}
+		severity := ImpactLow
+		description := fmt.Sprintf("%s %s is deprecated by its CRD", crd.Kind, version.Name)
+		if version.DeprecationWarning != "" {
+			description += ": " + version.DeprecationWarning
+		}
+		if containsString(crd.StoredVersions, version.Name) {
+			severity = ImpactMedium
+			description += "; objects are still stored at it"
+		}
+		findings = append(findings, CRDFinding{
+			CRD:         crd.Name,
+			Resource:    crd.Group + "/" + version.Name,
+			Problem:     CRDProblemDeprecatedVersion,
+			Severity:    severity,
+			Description: description,
+		})
+	}
+
+	// Without version details, as in inventories from before they were recorded, the storage
+	// version is unknown
+	if storage == "" {
+		return findings
+	}
+	for _, stored := range crd.StoredVersions {
+		if stored == storage {
+			continue
+		}
+		severity := ImpactMedium
+		state := "still served"
+		if version, ok := defined[stored]; !ok {
+			severity, state = ImpactHigh, "no longer defined"
+		} else if !version.Served {
+			severity, state = ImpactHigh, "no longer served"
+		}
+		findings = append(findings, CRDFinding{
+			CRD:      crd.Name,
+			Resource: crd.Group + "/" + stored,
+			Problem:  CRDProblemStoredVersion,
+			Severity: severity,
+			Description: fmt.Sprintf("Objects of %s may still be stored at %s (%s) besides the storage version %s; migrate them before a CRD update removes %s",
+				crd.Kind, stored, state, storage, stored),
+		})
+	}
+	return findings
+}
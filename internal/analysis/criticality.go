@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// criticalityLevels maps workload criticalities to the impact of an incompatible chart. A chart
+// whose criticality is unknown keeps the default, high.
+var criticalityLevels = map[string]ImpactLevel{
+	"critical":    ImpactCritical,
+	"production":  ImpactHigh,
+	"prod":        ImpactHigh,
+	"high":        ImpactHigh,
+	"staging":     ImpactMedium,
+	"medium":      ImpactMedium,
+	"development": ImpactLow,
+	"dev":         ImpactLow,
+	"test":        ImpactLow,
+	"sandbox":     ImpactLow,
+	"low":         ImpactLow,
+}
+
+// CriticalityMap assigns criticalities to namespaces and Helm releases. It takes precedence over
+// the criticality namespaces declare with a label or annotation.
+type CriticalityMap struct {
+	// Namespaces maps namespace names or glob patterns, e.g. "dev-*", to criticalities
+	Namespaces map[string]string `yaml:"namespaces"`
+	// Releases maps releases, as namespace/name, to criticalities
+	Releases map[string]string `yaml:"releases"`
+}
+
+// LoadCriticalityMap reads a criticality map from a YAML file
+func LoadCriticalityMap(path string) (*CriticalityMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read criticality map: %w", err)
+	}
+
+	criticality := &CriticalityMap{}
+	if err := yaml.Unmarshal(data, criticality); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal criticality map: %w", err)
+	}
+
+	for _, entries := range []map[string]string{criticality.Namespaces, criticality.Releases} {
+		for key, value := range entries {
+			if _, ok := criticalityLevels[strings.ToLower(value)]; !ok {
+				return nil, fmt.Errorf("unknown criticality %q for %s", value, key)
+			}
+			entries[key] = strings.ToLower(value)
+		}
+	}
+	return criticality, nil
+}
+
+// SetCriticality sets the criticality map incompatible charts are weighed with
+func (a *Analyzer) SetCriticality(criticality *CriticalityMap) {
+	a.criticality = criticality
+}
+
+// releaseCriticality looks up the criticality of a release: from the criticality map by release,
+// then by namespace, and last from the criticality the namespace declares in the cluster
+func (a *Analyzer) releaseCriticality(namespace, release string, declared map[string]string) string {
+	if a.criticality != nil {
+		if value, ok := a.criticality.Releases[namespace+"/"+release]; ok {
+			return value
+		}
+		if value, ok := matchNamespace(a.criticality.Namespaces, namespace); ok {
+			return value
+		}
+	}
+	return declared[namespace]
+}
+
+// matchNamespace looks up a namespace in a map keyed by namespace names or glob patterns, preferring
+// the exact name, then the longest matching pattern as the most specific
+func matchNamespace(entries map[string]string, namespace string) (string, bool) {
+	if value, ok := entries[namespace]; ok {
+		return value, true
+	}
+	patterns := make([]string, 0, len(entries))
+	for pattern := range entries {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return entries[pattern], true
+		}
+	}
+	return "", false
+}
+
+// criticalityImpact is the impact of an incompatible chart of the given criticality
+func criticalityImpact(criticality string) ImpactLevel {
+	if level, ok := criticalityLevels[criticality]; ok {
+		return level
+	}
+	return ImpactHigh
+}
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// csvHeader are the columns of the CSV export of findings
+var csvHeader = []string{"cluster", "rule", "severity", "gvk", "object", "source", "summary", "remediation", "owner", "docs"}
+
+// CSVRow is a row of the CSV export of findings. Its JSON fields are those the redactor knows, so
+// rows can be redacted with the assessment they were made from.
+type CSVRow struct {
+	Cluster     string      `json:"clusterId"`
+	RuleID      string      `json:"ruleId"`
+	Severity    ImpactLevel `json:"severity"`
+	GVK         string      `json:"apiVersion"`
+	Object      string      `json:"resource"`
+	Source      string      `json:"sources"`
+	Summary     string      `json:"summary"`
+	Remediation string      `json:"recommendation"`
+	Owners      []string    `json:"teams"`
+	Docs        string      `json:"docsUrl"`
+}
+
+// CSVRows returns the rows of the CSV export of the findings of the assessment, one per finding
+// and affected object where a finding lists its objects. Owners come from the ownership map by the
+// object, or the namespaces and releases a finding affects; findings that affect none are
+// attributed to its default team. Rows are made before redacting, so owners match the real names.
+func CSVRows(assessment *ImpactAssessment, ownership *OwnershipMap) []CSVRow {
+	var rows []CSVRow
+	for _, finding := range assessment.Findings() {
+		row := CSVRow{
+			Cluster:     assessment.ClusterID,
+			RuleID:      finding.RuleID,
+			Severity:    finding.Severity,
+			Object:      finding.Resource,
+			Source:      findingOrigin(finding),
+			Summary:     finding.Summary,
+			Remediation: finding.Remediation().Summary(),
+			Owners:      ownership.FindingOwners(finding),
+		}
+		if finding.Kind != "" {
+			row.GVK = GroupVersion(finding.Group, finding.Version) + " " + finding.Kind
+		}
+		if rule, ok := RuleByID(finding.RuleID); ok {
+			row.Docs = rule.DocsURL()
+		}
+
+		objects := findingObjects(finding)
+		if len(objects) == 0 {
+			rows = append(rows, row)
+			continue
+		}
+		for _, object := range objects {
+			objectRow := row
+			objectRow.Object = object
+			if strings.Contains(object, "/") {
+				objectRow.Owners = []string{ownership.Owner(object)}
+			}
+			rows = append(rows, objectRow)
+		}
+	}
+	return rows
+}
+
+// RenderCSV renders rows of findings as CSV, for spreadsheets and bulk-importing tickets
+func RenderCSV(rows []CSVRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	records := [][]string{csvHeader}
+	for _, row := range rows {
+		records = append(records, []string{
+			row.Cluster,
+			row.RuleID,
+			string(row.Severity),
+			row.GVK,
+			row.Object,
+			row.Source,
+			row.Summary,
+			row.Remediation,
+			strings.Join(row.Owners, ";"),
+			row.Docs,
+		})
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// findingObjects returns the objects a finding lists, as namespace/name or name, or none for
+// findings about a single item
+func findingObjects(finding FindingRef) []string {
+	switch source := finding.source.(type) {
+	case DeprecatedAPIImpact:
+		objects := make([]string, 0, len(source.Provenance))
+		for _, resource := range source.Provenance {
+			objects = append(objects, resource.Resource)
+		}
+		return objects
+	case BehaviorChangeFinding:
+		return source.Resources
+	case PodFieldFinding:
+		return source.Resources
+	}
+	return nil
+}
+
+// findingTargets returns the workloads a finding affects, as namespace/name, or namespaces
+func findingTargets(finding FindingRef) []string {
+	switch source := finding.source.(type) {
+	case DeprecatedAPIImpact:
+		var targets []string
+		for _, resource := range source.Provenance {
+			if namespace, _, namespaced := strings.Cut(resource.Resource, "/"); namespaced {
+				targets = append(targets, namespace)
+			}
+		}
+		return targets
+	case ChartImpact:
+		if source.Release != "" {
+			return []string{source.Namespace + "/" + source.Release}
+		}
+		return []string{source.Namespace}
+	case AddonFinding:
+		return []string{source.Namespace + "/" + source.Workload}
+	case DevicePluginFinding:
+		return []string{source.Namespace + "/" + source.Workload}
+	case MeshFinding:
+		return []string{source.Resource}
+	case ReleaseDrift:
+		return []string{source.Namespace + "/" + source.Release}
+	}
+	return nil
+}
+
+// findingOrigin describes where a finding was found: the files or sources of the affected
+// resources, or the kind of inventory item
+func findingOrigin(finding FindingRef) string {
+	switch source := finding.source.(type) {
+	case DeprecatedAPIImpact:
+		var origins []string
+		seen := make(map[string]bool)
+		for _, resource := range source.Provenance {
+			for _, origin := range resource.Sources {
+				if !seen[origin] {
+					seen[origin] = true
+					origins = append(origins, origin)
+				}
+			}
+		}
+		if len(origins) > 0 {
+			return strings.Join(origins, ";")
+		}
+		return source.Source
+	case OperatorAPIImpact:
+		return source.Source
+	case ChartImpact:
+		return "helm"
+	case ReleaseDrift:
+		return source.SourceFile
+	}
+	return ""
+}
+
+// Summary describes a remediation in a line, empty for nil
+func (r *Remediation) Summary() string {
+	switch {
+	case r == nil:
+		return ""
+	case len(r.Commands) > 0:
+		return strings.Join(r.Commands, "; ")
+	case r.ReplacementVersion != "":
+		return fmt.Sprintf("Migrate to %s %s", GroupVersion(r.ReplacementGroup, r.ReplacementVersion), r.ReplacementKind)
+	case r.Manual:
+		return "Manual review required"
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestCSVRows(t *testing.T) {
+	ownership := &OwnershipMap{
+		Default:    "platform",
+		Namespaces: map[string]string{"shop": "payments"},
+		Releases:   map[string]string{"ingress/public": "edge"},
+	}
+	rows := CSVRows(baselineAssessment(), ownership)
+
+	owners := make(map[string]string)
+	for _, row := range rows {
+		if len(row.Owners) != 1 {
+			t.Errorf("%s has owners %v, want one", row.Object, row.Owners)
+			continue
+		}
+		owners[row.Object] = row.Owners[0]
+	}
+	want := map[string]string{
+		// a row per Ingress using the removed API
+		"shop/web":         "payments",
+		"shop/api":         "payments",
+		"ingress/public":   "edge",
+		"ingress/internal": "platform",
+		"shop/db-0":        "platform",
+	}
+	if len(rows) != len(want) {
+		t.Errorf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for object, owner := range want {
+		if owners[object] != owner {
+			t.Errorf("%s is owned by %q, want %q", object, owners[object], owner)
+		}
+	}
+
+	data, err := RenderCSV(rows)
+	if err != nil {
+		t.Fatalf("RenderCSV: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read the CSV: %v", err)
+	}
+	if len(records) != len(rows)+1 || records[0][4] != "object" {
+		t.Errorf("CSV has %d records with header %v, want a header and %d rows", len(records), records[0], len(rows))
+	}
+}
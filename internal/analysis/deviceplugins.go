@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// DevicePluginFinding is a device plugin or GPU operator whose version does not support the target
+// Kubernetes version. Nodes are the nodes advertising its extended resources and RuntimeClasses
+// those selecting its runtime handlers, i.e. what stops working when the plugin fails.
+type DevicePluginFinding struct {
+	FindingMeta
+	Plugin             string      `json:"plugin"`
+	Version            string      `json:"version"`
+	Namespace          string      `json:"namespace"`
+	Kind               string      `json:"kind"`
+	Workload           string      `json:"workload"`
+	Resources          []string    `json:"resources"`
+	Nodes              []string    `json:"nodes,omitempty"`
+	RuntimeClasses     []string    `json:"runtimeClasses,omitempty"`
+	MinKubeVersion     string      `json:"minKubeVersion"`
+	MaxKubeVersion     string      `json:"maxKubeVersion"`
+	RecommendedVersion string      `json:"recommendedVersion,omitempty"`
+	BeforeUpgrade      bool        `json:"beforeUpgrade"`
+	Commands           []string    `json:"commands,omitempty"`
+	Notes              string      `json:"notes"`
+	Severity           ImpactLevel `json:"severity"`
+}
+
+// LoadDevicePluginKnowledge loads the device plugin knowledge base
+func (a *Analyzer) LoadDevicePluginKnowledge(path string) error {
+	devicePluginKB := knowledge.NewDevicePluginKnowledgeBase()
+	if err := devicePluginKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load device plugin knowledge base: %w", err)
+	}
+	a.devicePluginKB = devicePluginKB
+	return nil
+}
+
+// CheckDevicePlugins reports device plugins and GPU operators, detected by their images, whose
+// version does not support the target version. Plugins deployed by another detected plugin, e.g.
+// the NVIDIA device plugin by the GPU operator, are upgraded through it and not reported. Findings
+// are high severity when nodes advertise the plugin's resources and medium otherwise.
+func (a *Analyzer) CheckDevicePlugins(state *inventory.LiveClusterState, assessment *ImpactAssessment) []DevicePluginFinding {
+	if a.devicePluginKB == nil {
+		return nil
+	}
+
+	type detection struct {
+		plugin   *knowledge.DevicePlugin
+		version  string
+		workload inventory.WorkloadEntry
+	}
+	var detections []detection
+	detected := make(map[string]bool)
+	for _, workload := range state.Workloads {
+		if workload.Kind != "Deployment" && workload.Kind != "DaemonSet" {
+			continue
+		}
+		for _, image := range workload.Images {
+			plugin, version, found := a.devicePluginKB.DetectDevicePlugin(image)
+			if !found || detected[plugin.Name] {
+				continue
+			}
+			detected[plugin.Name] = true
+			detections = append(detections, detection{plugin: plugin, version: version, workload: workload})
+			break
+		}
+	}
+
+	var findings []DevicePluginFinding
+	for _, d := range detections {
+		if d.plugin.ManagedBy != "" && detected[d.plugin.ManagedBy] {
+			continue
+		}
+		supported, known := d.plugin.Versions.Supports(d.version, assessment.TargetVersion)
+		if !known || supported {
+			continue
+		}
+
+		finding := DevicePluginFinding{
+			FindingMeta: withUpstreamDocs(d.plugin.DocsURL),
+			Plugin:      d.plugin.Name,
+			Version:     d.version,
+			Namespace:   d.workload.Namespace,
+			Kind:        d.workload.Kind,
+			Workload:    d.workload.Name,
+			Resources:   d.plugin.Resources,
+			Notes:       d.plugin.Notes,
+			Severity:    ImpactMedium,
+		}
+		for _, node := range state.Nodes {
+			for _, resource := range d.plugin.Resources {
+				if node.ExtendedResources[resource] > 0 {
+					finding.Nodes = append(finding.Nodes, node.Name)
+					break
+				}
+			}
+		}
+		if len(finding.Nodes) > 0 {
+			finding.Severity = ImpactHigh
+		}
+		for _, class := range state.RuntimeClasses {
+			if d.plugin.UsesRuntimeHandler(class.Handler) {
+				finding.RuntimeClasses = append(finding.RuntimeClasses, class.Name)
+			}
+		}
+		finding.MinKubeVersion, finding.MaxKubeVersion = d.plugin.Versions.Range(d.version)
+		finding.RecommendedVersion, finding.BeforeUpgrade = d.plugin.Versions.Recommend(d.version, assessment.CurrentVersion, assessment.TargetVersion)
+		if finding.RecommendedVersion != "" {
+			replacer := strings.NewReplacer(
+				"{version}", finding.RecommendedVersion,
+				"{namespace}", d.workload.Namespace,
+				"{kind}", strings.ToLower(d.workload.Kind),
+				"{workload}", d.workload.Name,
+			)
+			for _, command := range d.plugin.Upgrade {
+				finding.Commands = append(finding.Commands, replacer.Replace(command))
+			}
+		}
+		findings = append(findings, finding)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Plugin < findings[j].Plugin
+	})
+	return findings
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// servedAPIs indexes the kinds a cluster served at scan time by group/version
+type servedAPIs map[string]map[string]bool
+
+// newServedAPIs indexes the served APIs recorded on a cluster
+func newServedAPIs(served map[string][]string) servedAPIs {
+	index := make(servedAPIs, len(served))
+	for groupVersion, kinds := range served {
+		index[groupVersion] = make(map[string]bool, len(kinds))
+		for _, kind := range kinds {
+			index[groupVersion][kind] = true
+		}
+	}
+	return index
+}
+
+// serves checks whether a kind is served at a group/version
+func (s servedAPIs) serves(group, version, kind string) bool {
+	return s[GroupVersion(group, version)][kind]
+}
+
+// unservedSignals returns a risk signal for every manifest API the cluster doesn't serve today;
+// manifests using them fail to apply even before the upgrade
+func (s servedAPIs) unservedSignals(manifestAPIs []*ent.ManifestAPI) []RiskSignal {
+	signals := make([]RiskSignal, 0)
+	for _, api := range manifestAPIs {
+		if s.serves(api.Group, api.Version, api.Kind) {
+			continue
+		}
+
+		description := fmt.Sprintf("%s is not served by the cluster today - manifests using it are rejected even before the upgrade", GroupVersion(api.Group, api.Version))
+		if _, found := s[GroupVersion(api.Group, api.Version)]; found {
+			description = fmt.Sprintf("%s is served, but not for kind %s - check the kind or install the CRD", GroupVersion(api.Group, api.Version), api.Kind)
+		}
+		signals = append(signals, RiskSignal{
+			Type:        "unserved_api",
+			Severity:    ImpactHigh,
+			Description: description,
+			Resource:    fmt.Sprintf("%s %s", GroupVersion(api.Group, api.Version), api.Kind),
+		})
+	}
+	return signals
+}
+
+// markReplacements records whether the replacement of each removed API is served today, i.e.
+// whether resources can be migrated before the upgrade
+func (s servedAPIs) markReplacements(impacts []DeprecatedAPIImpact) {
+	for i := range impacts {
+		group, version, ok := ParseAPIVersion(impacts[i].ReplacementAPI)
+		if !ok {
+			continue
+		}
+		served := s.serves(group, version, impacts[i].Kind)
+		impacts[i].ReplacementServed = &served
+	}
+}
+
+// GroupVersion formats a group and version as an apiVersion, e.g. "apps/v1" or "v1"
+func GroupVersion(group, version string) string {
+	if group == "" {
+		return version
+	}
+	return group + "/" + version
+}
+
+// ParseAPIVersion splits an apiVersion such as "networking.k8s.io/v1" or "v1" into group and
+// version; ok is false for replacements that aren't an API, e.g. "Pod Security Admission"
+func ParseAPIVersion(apiVersion string) (group, version string, ok bool) {
+	group, version = "", apiVersion
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group, version = apiVersion[:i], apiVersion[i+1:]
+	}
+	if !strings.HasPrefix(version, "v") || strings.Contains(version, " ") {
+		return "", "", false
+	}
+	return group, version, true
+}
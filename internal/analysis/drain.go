@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// DrainBlockReason describes why a pod would block a node drain
+type DrainBlockReason string
+
+const (
+	DrainBlockPDB          DrainBlockReason = "pdb"
+	DrainBlockLocalStorage DrainBlockReason = "local_storage"
+	DrainBlockUnmanaged    DrainBlockReason = "unmanaged_pod"
+)
+
+// DrainBlocker represents a pod that would prevent a node from draining
+type DrainBlocker struct {
+	FindingMeta
+	Pod       string           `json:"pod"`
+	Namespace string           `json:"namespace"`
+	Reason    DrainBlockReason `json:"reason"`
+	Detail    string           `json:"detail"`
+}
+
+// NodeDrainResult represents the simulated drain outcome for a single node
+type NodeDrainResult struct {
+	Node            string         `json:"node"`
+	Drainable       bool           `json:"drainable"`
+	EvictablePods   int            `json:"evictablePods"`
+	DaemonSetPods   int            `json:"daemonSetPods"`
+	Blockers        []DrainBlocker `json:"blockers"`
+	AlreadyCordoned bool           `json:"alreadyCordoned"`
+}
+
+// SimulateNodeDrains simulates `kubectl drain --ignore-daemonsets` on every node
+// without evicting anything. Nodes are evaluated independently, as they would be
+// drained one at a time during a rolling upgrade.
+func SimulateNodeDrains(state *inventory.LiveClusterState) []NodeDrainResult {
+	budgets := make(map[string]int32)
+	for _, pdb := range state.PodDisruptionBudgets {
+		budgets[pdb.Namespace+"/"+pdb.Name] = pdb.DisruptionsAllowed
+	}
+
+	podsByNode := make(map[string][]inventory.PodEntry)
+	for _, pod := range state.Pods {
+		if pod.NodeName == "" {
+			continue
+		}
+		podsByNode[pod.NodeName] = append(podsByNode[pod.NodeName], pod)
+	}
+
+	results := make([]NodeDrainResult, 0, len(state.Nodes))
+	for _, node := range state.Nodes {
+		result := NodeDrainResult{
+			Node:            node.Name,
+			Blockers:        make([]DrainBlocker, 0),
+			AlreadyCordoned: node.Unschedulable,
+		}
+
+		// Remaining disruptions per PDB while draining this node
+		remaining := make(map[string]int32, len(budgets))
+		for key, allowed := range budgets {
+			remaining[key] = allowed
+		}
+
+		for _, pod := range podsByNode[node.Name] {
+			// Completed and static pods do not need eviction
+			if pod.Phase == "Succeeded" || pod.Phase == "Failed" || pod.IsMirror {
+				continue
+			}
+
+			if pod.OwnerKind == "DaemonSet" {
+				result.DaemonSetPods++
+				continue
+			}
+
+			if pod.OwnerKind == "" {
+				result.Blockers = append(result.Blockers, DrainBlocker{
+					Pod:       pod.Name,
+					Namespace: pod.Namespace,
+					Reason:    DrainBlockUnmanaged,
+					Detail:    "Pod has no controller and would be lost (requires --force)",
+				})
+				continue
+			}
+
+			if pod.HasLocalStorage {
+				result.Blockers = append(result.Blockers, DrainBlocker{
+					Pod:       pod.Name,
+					Namespace: pod.Namespace,
+					Reason:    DrainBlockLocalStorage,
+					Detail:    "Pod uses emptyDir volumes (requires --delete-emptydir-data)",
+				})
+				continue
+			}
+
+			if blocking := exhaustedBudget(pod, remaining); blocking != "" {
+				result.Blockers = append(result.Blockers, DrainBlocker{
+					Pod:       pod.Name,
+					Namespace: pod.Namespace,
+					Reason:    DrainBlockPDB,
+					Detail:    fmt.Sprintf("PodDisruptionBudget %s allows no further disruptions", blocking),
+				})
+				continue
+			}
+
+			for _, key := range pod.DisruptionBudgets {
+				remaining[key]--
+			}
+			result.EvictablePods++
+		}
+
+		result.Drainable = len(result.Blockers) == 0
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Node < results[j].Node
+	})
+
+	return results
+}
+
+// exhaustedBudget returns the first PDB covering the pod that has no disruptions left
+func exhaustedBudget(pod inventory.PodEntry, remaining map[string]int32) string {
+	for _, key := range pod.DisruptionBudgets {
+		if remaining[key] <= 0 {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ReleaseDrift compares a deployed Helm release with what its Git source renders at the pinned
+// chart version. Reconciling the release after the upgrade applies the Git version, so drift in
+// the cluster is undone, and some of it can only be undone destructively.
+type ReleaseDrift struct {
+	FindingMeta
+	Release         string      `json:"release"`
+	Namespace       string      `json:"namespace"`
+	Chart           string      `json:"chart"`
+	DeployedVersion string      `json:"deployedVersion"`
+	PinnedVersion   string      `json:"pinnedVersion"` // in Git; empty for the latest
+	Origin          string      `json:"origin"`        // "flux" or "argocd"
+	SourceFile      string      `json:"sourceFile,omitempty"`
+	Added           []string    `json:"added,omitempty"`   // rendered from Git only; reconciliation creates them
+	Removed         []string    `json:"removed,omitempty"` // deployed only; reconciliation deletes them
+	Changed         []string    `json:"changed,omitempty"`
+	Destructive     []DriftItem `json:"destructive,omitempty"`
+	Severity        ImpactLevel `json:"severity"`
+	// Error tells why the release could not be compared, e.g. the chart failed to render
+	Error string `json:"error,omitempty"`
+}
+
+// DriftItem is a drifted resource and why reconciling it is destructive
+type DriftItem struct {
+	Resource string `json:"resource"`
+	Reason   string `json:"reason"`
+}
+
+// Drifted reports whether the deployed release differs from its Git source
+func (d ReleaseDrift) Drifted() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// immutableFields are fields the API server refuses to update, per kind. Changing them makes the
+// upgrade fail, or, with a forced upgrade, deletes and recreates the resource.
+var immutableFields = map[string][][]string{
+	"Deployment":            {{"spec", "selector"}},
+	"DaemonSet":             {{"spec", "selector"}},
+	"ReplicaSet":            {{"spec", "selector"}},
+	"StatefulSet":           {{"spec", "selector"}, {"spec", "serviceName"}, {"spec", "volumeClaimTemplates"}, {"spec", "podManagementPolicy"}},
+	"Job":                   {{"spec", "selector"}, {"spec", "template"}},
+	"Service":               {{"spec", "clusterIP"}},
+	"PersistentVolumeClaim": {{"spec", "storageClassName"}, {"spec", "accessModes"}, {"spec", "volumeName"}},
+}
+
+// statefulKinds hold data that is lost when reconciliation deletes them
+var statefulKinds = map[string]bool{
+	"PersistentVolumeClaim":    true,
+	"Secret":                   true,
+	"CustomResourceDefinition": true,
+	"Namespace":                true,
+}
+
+// versionLabels change with every chart release, so they are not drift
+var versionLabels = []string{"helm.sh/chart", "chart", "app.kubernetes.io/version"}
+
+// CompareRelease fills in the drift between the deployed manifest of a release and the one its Git
+// source renders. Resources match by kind, namespace and name, so an apiVersion change alone is
+// not drift; resources without a namespace are in the release namespace.
+func CompareRelease(drift *ReleaseDrift, deployed, desired []map[string]interface{}) {
+	deployedByKey := driftObjects(deployed, drift.Namespace)
+	desiredByKey := driftObjects(desired, drift.Namespace)
+
+	for _, key := range sortedKeys(desiredByKey) {
+		if _, ok := deployedByKey[key]; !ok {
+			drift.Added = append(drift.Added, key)
+		}
+	}
+	for _, key := range sortedKeys(deployedByKey) {
+		current := deployedByKey[key]
+		wanted, ok := desiredByKey[key]
+		if !ok {
+			drift.Removed = append(drift.Removed, key)
+			reason := "deleted by reconciliation"
+			if statefulKinds[stringField(current, "kind")] {
+				reason = "deleted by reconciliation, with the data it holds"
+			}
+			drift.Destructive = append(drift.Destructive, DriftItem{Resource: key, Reason: reason})
+			continue
+		}
+		if reflect.DeepEqual(withoutRenderNoise(current), withoutRenderNoise(wanted)) {
+			continue
+		}
+		drift.Changed = append(drift.Changed, key)
+		for _, field := range immutableFields[stringField(current, "kind")] {
+			if !reflect.DeepEqual(fieldValue(current, field), fieldValue(wanted, field)) {
+				drift.Destructive = append(drift.Destructive, DriftItem{
+					Resource: key,
+					Reason:   fmt.Sprintf("immutable field %s differs: the upgrade fails, or a forced one recreates it", strings.Join(field, ".")),
+				})
+				break
+			}
+		}
+	}
+
+	switch {
+	case len(drift.Destructive) > 0:
+		drift.Severity = ImpactHigh
+	case drift.Drifted():
+		drift.Severity = ImpactLow
+	default:
+		drift.Severity = ImpactNone
+	}
+}
+
+// ApplyReleaseDrift adds drift results to an assessment. Every release whose reconciliation would
+// be destructive is a high-severity issue.
+func ApplyReleaseDrift(assessment *ImpactAssessment, drifts []ReleaseDrift) {
+	assessment.ReleaseDrift = append(assessment.ReleaseDrift, drifts...)
+	for _, drift := range drifts {
+		if drift.Severity != ImpactHigh {
+			continue
+		}
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, ImpactHigh)
+	}
+
+	AssignRuleIDs(assessment)
+}
+
+// DriftedReleases counts the releases that differ from their Git source
+func (assessment *ImpactAssessment) DriftedReleases() int {
+	drifted := 0
+	for _, drift := range assessment.ReleaseDrift {
+		if drift.Drifted() {
+			drifted++
+		}
+	}
+	return drifted
+}
+
+// driftObjects keys rendered objects by kind, namespace and name
+func driftObjects(objects []map[string]interface{}, namespace string) map[string]map[string]interface{} {
+	byKey := make(map[string]map[string]interface{}, len(objects))
+	for _, object := range objects {
+		metadata, _ := object["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		objectNamespace, _ := metadata["namespace"].(string)
+		if objectNamespace == "" {
+			objectNamespace = namespace
+		}
+		byKey[fmt.Sprintf("%s %s/%s", stringField(object, "kind"), objectNamespace, name)] = object
+	}
+	return byKey
+}
+
+// withoutRenderNoise strips what differs between renders of a chart version: the apiVersion,
+// metadata other than labels and annotations, and labels naming the chart version
+func withoutRenderNoise(object map[string]interface{}) map[string]interface{} {
+	stripped := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		if key != "apiVersion" && key != "metadata" {
+			stripped[key] = value
+		}
+	}
+	metadata, _ := object["metadata"].(map[string]interface{})
+	labels := make(map[string]interface{})
+	if values, ok := metadata["labels"].(map[string]interface{}); ok {
+		for key, value := range values {
+			labels[key] = value
+		}
+	}
+	for _, label := range versionLabels {
+		delete(labels, label)
+	}
+	stripped["labels"] = labels
+	stripped["annotations"] = metadata["annotations"]
+	return stripped
+}
+
+func fieldValue(object map[string]interface{}, path []string) interface{} {
+	var value interface{} = object
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func stringField(object map[string]interface{}, key string) string {
+	value, _ := object[key].(string)
+	return value
+}
+
+func sortedKeys(objects map[string]map[string]interface{}) []string {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
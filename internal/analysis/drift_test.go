@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// driftObject builds a rendered object with labels and a spec
+func driftObject(apiVersion, kind, name string, labels map[string]interface{}, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "labels": labels},
+		"spec":       spec,
+	}
+}
+
+func TestCompareRelease(t *testing.T) {
+	selector := func(app string) map[string]interface{} {
+		return map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": app}}}
+	}
+	deployed := []map[string]interface{}{
+		driftObject("apps/v1", "Deployment", "web", map[string]interface{}{"helm.sh/chart": "web-1.0.0"}, selector("web")),
+		driftObject("networking.k8s.io/v1beta1", "Ingress", "web", nil, map[string]interface{}{"rules": []interface{}{}}),
+		driftObject("v1", "PersistentVolumeClaim", "data", nil, nil),
+	}
+	desired := []map[string]interface{}{
+		driftObject("apps/v1", "Deployment", "web", map[string]interface{}{"helm.sh/chart": "web-2.0.0"}, selector("web-server")),
+		// Only the apiVersion changed, which is not drift
+		driftObject("networking.k8s.io/v1", "Ingress", "web", nil, map[string]interface{}{"rules": []interface{}{}}),
+		driftObject("v1", "ConfigMap", "settings", nil, nil),
+	}
+
+	drift := &ReleaseDrift{Release: "web", Namespace: "shop"}
+	CompareRelease(drift, deployed, desired)
+
+	if want := []string{"ConfigMap shop/settings"}; !reflect.DeepEqual(drift.Added, want) {
+		t.Errorf("added = %v, want %v", drift.Added, want)
+	}
+	if want := []string{"PersistentVolumeClaim shop/data"}; !reflect.DeepEqual(drift.Removed, want) {
+		t.Errorf("removed = %v, want %v", drift.Removed, want)
+	}
+	if want := []string{"Deployment shop/web"}; !reflect.DeepEqual(drift.Changed, want) {
+		t.Errorf("changed = %v, want %v", drift.Changed, want)
+	}
+	if len(drift.Destructive) != 2 {
+		t.Errorf("destructive = %+v, want the deleted claim and the changed selector", drift.Destructive)
+	}
+	if drift.Severity != ImpactHigh {
+		t.Errorf("severity = %s, want %s", drift.Severity, ImpactHigh)
+	}
+}
+
+func TestCompareReleaseWithoutDrift(t *testing.T) {
+	objects := []map[string]interface{}{
+		driftObject("v1", "Service", "web", map[string]interface{}{"app.kubernetes.io/version": "1.0"}, map[string]interface{}{"ports": []interface{}{}}),
+	}
+	rerendered := []map[string]interface{}{
+		driftObject("v1", "Service", "web", map[string]interface{}{"app.kubernetes.io/version": "1.1"}, map[string]interface{}{"ports": []interface{}{}}),
+	}
+
+	drift := &ReleaseDrift{Release: "web", Namespace: "shop"}
+	CompareRelease(drift, objects, rerendered)
+	if drift.Drifted() || drift.Severity != ImpactNone {
+		t.Errorf("drift = %+v, want none", drift)
+	}
+}
@@ -0,0 +1,311 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ruleConsequences tells what breaks when a finding of a rule is left in place during the upgrade
+var ruleConsequences = map[string]string{
+	RuleRemovedManifestAPI.ID:    "The API server rejects manifests applying this apiVersion after the upgrade, so deployments, GitOps syncs and Helm upgrades using them fail. Objects already in the cluster are kept and served at the replacement API.",
+	RuleRemovedCRDAPI.ID:         "Clients reading or writing custom resources at this version fail after the upgrade, and a CRD still storing objects at it can't be updated.",
+	RuleUnservedAPI.ID:           "The API server rejects these manifests already, before the upgrade.",
+	RuleOperatorAPI.ID:           "Once the operator is upgraded past the removal, custom resources at this version are rejected, and objects stored at it can't be read until they are migrated.",
+	RuleCRDScope.ID:              "Tools that check the scope reject the resources, and the namespace is ignored otherwise.",
+	RuleCRDTerminatingNS.ID:      "Creating resources in a namespace being deleted fails, so applying the manifests fails.",
+	RuleConversionWebhook.ID:     "Reads and writes at versions other than the stored one fail, including storage version migrations during the upgrade.",
+	RuleCRDDeprecatedVersion.ID:  "A later release of the operator can stop serving the version, failing clients and manifests that still use it.",
+	RuleCRDStoredVersion.ID:      "Once a CRD update removes the version, objects still stored at it can no longer be read or migrated.",
+	RulePolicyMigration.ID:       "After the upgrade pods are admitted without the restrictions the PodSecurityPolicies enforced.",
+	RuleBehaviorChange.ID:        "The resources are still accepted, but Kubernetes treats the field differently after the upgrade, e.g. schedules run at other times or are rejected.",
+	RuleRemovedPodField.ID:       "The field or value is rejected or silently has no effect after the upgrade, e.g. pods lose a security profile or can't be scheduled.",
+	RuleDeprecatedPodField.ID:    "Nothing breaks at the target version, but a later version removes the field or value.",
+	RuleHPAConversion.ID:         "Once the beta autoscaling API is removed, the HorizontalPodAutoscaler manifests are rejected and can't be updated.",
+	RuleHPAInvalidMetrics.ID:     "The API server rejects the HorizontalPodAutoscaler or drops the field, so it stops scaling on that metric.",
+	RuleMeshUnsupported.ID:       "The mesh control plane or its sidecar injection may fail on the unsupported version, which breaks traffic between meshed workloads.",
+	RuleIncompatibleChart.ID:     "The chart's resources may be rejected or misbehave after the upgrade, and the next helm upgrade of the release can fail.",
+	RuleUnknownChart.ID:          "Compatibility is unknown: the chart may render APIs the target version removed.",
+	RuleChartCaveats.ID:          "The release keeps working, but the known issues of its chart version apply after the upgrade.",
+	RuleUnhealthyRelease.ID:      "helm upgrade of the release fails or rolls back while its last operation is unresolved, so the chart can't be moved to a compatible version.",
+	RuleDrainBlocked.ID:          "A rolling node upgrade stalls on this node, since at least one pod on it blocks eviction.",
+	RuleDrainPDB.ID:              "Evictions are refused while the budget allows no disruptions, so draining the nodes running the workload stalls.",
+	RuleDrainLocalStorage.ID:     "Draining deletes the pod's emptyDir data.",
+	RuleDrainUnmanaged.ID:        "A bare pod is not recreated after eviction, so its workload stops.",
+	RuleInsufficientHeadroom.ID:  "Pods evicted from nodes taken out of service can't be rescheduled, so workloads lose replicas during the upgrade.",
+	RuleInterferingComponent.ID:  "The component adds, removes or rebalances nodes while they are being upgraded, which can evict pods twice or undo the upgrade order.",
+	RuleTopologySpread.ID:        "Evicted pods stay pending when no node satisfies the constraint, which stalls the drain.",
+	RulePodAntiAffinity.ID:       "Evicted pods stay pending when every other node already runs a replica, which stalls the drain.",
+	RuleInTreeStorageClass.ID:    "Once the in-tree plugin is removed, new volumes can't be provisioned from the StorageClass.",
+	RuleInTreeVolume.ID:          "Without the CSI driver installed, CSI migration can't serve the volume, so pods using it fail to start.",
+	RuleStatefulSetStorage.ID:    "New replicas can't get volumes once the plugin is removed.",
+	RuleDeprecatedStorageAnno.ID: "The annotation stops being honored in a later version.",
+	RuleIngressAnnoRemoved.ID:    "The controller ignores the annotation after its upgrade, so the routing or behavior it configured is lost.",
+	RuleIngressAnnoBehavior.ID:   "The controller interprets the annotation differently after its upgrade, which can change routing.",
+	RuleAddonOutdated.ID:         "The addon runs a version older than the one tested with the target version, unless the platform upgrades it.",
+	RuleRemovedNodeConfig.ID:     "The component refuses to start with the setting at the target version, so upgraded nodes don't join the cluster or lose service routing.",
+	RuleDeprecatedNodeConfig.ID:  "Nothing breaks at the target version, but a later version removes the setting.",
+	RuleDevicePlugin.ID:          "The plugin may fail on the unsupported version, so nodes stop advertising their devices and pods requesting them can't be scheduled.",
+	RuleDryRunRejected.ID:        "Applying the manifest fails the same way the dry run did.",
+	RuleMissingPlatformImage.ID:  "Pods of the recommended version fail to pull their images on nodes of the missing platform.",
+	RuleDestructiveDrift.ID:      "Reconciling the release after the upgrade deletes resources only deployed in the cluster, or fails on a changed immutable field.",
+	RuleReleaseDrift.ID:          "Reconciliation after the upgrade undoes changes made in the cluster.",
+}
+
+// Explanation gathers what an assessment knows about one finding, from every report section it
+// appears in, for the explain command
+type Explanation struct {
+	Finding FindingRef `json:"finding"`
+	Rule    Rule       `json:"rule"`
+	// Consequence tells what breaks when the finding is not addressed
+	Consequence string `json:"consequence,omitempty"`
+	// Details are facts of the finding from the report, e.g. the version an API is removed in
+	Details []string `json:"details,omitempty"`
+	// Affected lists the affected objects, with where they were found when known
+	Affected []string `json:"affected,omitempty"`
+	// Options are the remediation options, best first
+	Options []string `json:"options,omitempty"`
+	Manual  bool     `json:"manual"`
+}
+
+// FindFindings returns the findings matching a finding ID: the rule ID and resource separated by
+// "|" as in baselines (e.g. "KUA-API-001|batch/v1beta1/CronJob"), or a rule ID for all its findings
+func (assessment *ImpactAssessment) FindFindings(id string) []FindingRef {
+	var matches []FindingRef
+	for _, finding := range assessment.Findings() {
+		if finding.Key() == id || strings.EqualFold(finding.RuleID, id) {
+			matches = append(matches, finding)
+		}
+	}
+	return matches
+}
+
+// Explain gathers the explanation of a finding returned by Findings or FindFindings
+func (f FindingRef) Explain() *Explanation {
+	rule, _ := RuleByID(f.RuleID)
+	explanation := &Explanation{Finding: f, Rule: rule, Consequence: ruleConsequences[f.RuleID]}
+	if f.source == nil {
+		return explanation
+	}
+
+	// options specific to the finding come first, then the rule's remediation commands, then notes
+	var options, notes []string
+	detail := func(format string, args ...interface{}) {
+		explanation.Details = append(explanation.Details, fmt.Sprintf(format, args...))
+	}
+	note := func(text string) {
+		if text != "" {
+			notes = append(notes, text)
+		}
+	}
+
+	switch source := f.source.(type) {
+	case DeprecatedAPIImpact:
+		detail("%s %s is removed in %s", GroupVersion(source.Group, source.Version), source.Kind, source.RemovedIn)
+		if source.ReplacementServed != nil && !*source.ReplacementServed {
+			detail("The current cluster does not serve %s yet: migrate right after the control plane upgrade", source.ReplacementAPI)
+		}
+		if source.Templated {
+			detail("Found in un-rendered templates only; a template condition may pick another API")
+		}
+		for _, resource := range source.Provenance {
+			explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s %s (%s)", source.Kind, resource.Resource, strings.Join(resource.Sources, ", ")))
+		}
+		if len(source.Provenance) == 0 {
+			if source.Source == "crd" {
+				explanation.Affected = append(explanation.Affected, fmt.Sprintf("CustomResourceDefinition of %s", source.Kind))
+			} else {
+				explanation.Affected = append(explanation.Affected, fmt.Sprintf("%d %s resources", source.AffectedCount, source.Kind))
+			}
+		}
+		if source.ReplacementAPI != "" {
+			options = append(options, fmt.Sprintf("Move the manifests to %s", source.ReplacementAPI))
+		}
+		note(source.MigrationNotes)
+	case OperatorAPIImpact:
+		detail("%s/%s %s is deprecated in %s %s", source.Group, source.Version, source.Kind, source.Operator, source.DeprecatedIn)
+		if source.RemovedIn != "" {
+			detail("Removed in %s %s; installed: %s", source.Operator, source.RemovedIn, source.OperatorVersion)
+		}
+		affected := fmt.Sprintf("%s %s (%s)", source.Kind, source.Resource, source.Source)
+		if source.AffectedCount > 0 {
+			affected += fmt.Sprintf(", %d custom resources", source.AffectedCount)
+		}
+		if source.StoredVersion {
+			affected += ", stored at this version"
+		}
+		explanation.Affected = append(explanation.Affected, affected)
+		options = append(options, fmt.Sprintf("Move the custom resources to %s before upgrading %s", source.ReplacementAPI, source.Operator))
+		note(source.MigrationNotes)
+	case CRDFinding:
+		detail("%s", source.Description)
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s (CRD %s)", source.Resource, source.CRD))
+	case *PolicyMigrationFinding:
+		detail("%s", source.Description)
+		for _, name := range source.PodSecurityPolicies {
+			explanation.Affected = append(explanation.Affected, "PodSecurityPolicy "+name)
+		}
+		for _, policy := range source.Policies {
+			options = append(options, fmt.Sprintf("Apply the generated ValidatingAdmissionPolicy %s for %s (Pod Security level %s)", policy.Name, policy.PodSecurityPolicy, policy.Level))
+		}
+		if len(source.Policies) > 0 {
+			options = append(options, "kube-upgrade-advisor impact --manifests <dir> --policy-dir <out-dir>")
+		}
+		options = append(options, "Label namespaces for Pod Security Admission, or install Kyverno or Gatekeeper for finer rules")
+	case BehaviorChangeFinding:
+		detail("%s (changed in %s)", source.Description, source.ChangedIn)
+		for _, resource := range source.Resources {
+			explanation.Affected = append(explanation.Affected, source.Kind+" "+resource)
+		}
+		note(source.Recommendation)
+	case PodFieldFinding:
+		detail("%s", source.Description)
+		if source.RemovedIn != "" {
+			detail("Removed in %s", source.RemovedIn)
+		}
+		for _, resource := range source.Resources {
+			explanation.Affected = append(explanation.Affected, source.Kind+" "+resource)
+		}
+		for _, label := range source.RewriteLabels() {
+			options = append(options, fmt.Sprintf("Rewrite %s to %s with kube-upgrade-advisor fix --write <path>", label, source.Rewrites[label]))
+		}
+		if source.Replacement != "" {
+			options = append(options, fmt.Sprintf("Use %s", source.Replacement))
+		}
+	case HPAFinding:
+		if source.ReplacementAPI != "" {
+			detail("%s converts to %s with %d field changes", source.APIVersion, source.ReplacementAPI, len(source.Changes))
+		}
+		for _, text := range source.Notes {
+			detail("%s", text)
+		}
+		affected := "HorizontalPodAutoscaler " + source.Resource
+		if source.Source != "" {
+			affected += " (" + source.Source + ")"
+		}
+		explanation.Affected = append(explanation.Affected, affected)
+	case MeshFinding:
+		detail("%s %s supports Kubernetes %s to %s", source.Mesh, source.Version, source.MinKubeVersion, source.MaxKubeVersion)
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s control plane %s (%s)", source.Mesh, source.Resource, source.Source))
+		if source.RecommendedVersion != "" {
+			when := "right after the control plane"
+			if source.BeforeUpgrade {
+				when = "before the cluster"
+			}
+			options = append(options, fmt.Sprintf("Upgrade %s to %s %s", source.Mesh, source.RecommendedVersion, when))
+		}
+		note(source.Notes)
+	case ChartImpact:
+		detail("%s", source.Message)
+		if source.Criticality != "" {
+			detail("Criticality: %s", source.Criticality)
+		}
+		for _, issue := range source.Issues {
+			detail("Known issue: %s", issue)
+		}
+		if len(source.RemovedAPIs) > 0 {
+			detail("The recommended version still emits removed APIs: %s", strings.Join(source.RemovedAPIs, ", "))
+		}
+		if len(source.MissingPlatforms) > 0 {
+			detail("The recommended version publishes no images for: %s", strings.Join(source.MissingPlatforms, ", "))
+		}
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("Helm release of %s %s in %s", source.ChartName, source.CurrentVersion, source.Namespace))
+		if source.RecommendedVersion != "" {
+			options = append(options, fmt.Sprintf("Upgrade %s to %s", source.ChartName, source.RecommendedVersion))
+		}
+	case RiskSignal:
+		detail("%s", source.Description)
+		explanation.Affected = append(explanation.Affected, source.Resource)
+	case SchedulingWarning:
+		detail("%s", source.Description)
+		explanation.Affected = append(explanation.Affected, source.Resource)
+		note(source.Recommendation)
+	case StorageFinding:
+		detail("%s", source.Description)
+		explanation.Affected = append(explanation.Affected, source.Resource)
+		if source.Replacement != "" {
+			options = append(options, fmt.Sprintf("Use %s", source.Replacement))
+		}
+		note(source.MigrationNotes)
+	case IngressAnnotationFinding:
+		detail("%s %s in %s %s, upgrading %s -> %s", source.Annotation, source.Change, source.Controller, source.ChangedIn, source.FromVersion, source.ToVersion)
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("Ingress %s (%s: %s)", source.Ingress, source.Annotation, source.Value))
+		if source.Replacement != "" {
+			options = append(options, fmt.Sprintf("Use %s", source.Replacement))
+		}
+		note(source.Notes)
+	case AddonFinding:
+		detail("%s %s is older than %s", source.Addon, source.CurrentVersion, source.TargetVersion)
+		if source.Managed {
+			detail("Upgraded by %s with the control plane", source.Platform)
+		}
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s %s/%s", source.Kind, source.Namespace, source.Workload))
+		note(source.Notes)
+	case NodeConfigFinding:
+		detail("%s", source.Description)
+		if source.RemovedIn != "" {
+			detail("Removed in %s", source.RemovedIn)
+		}
+		flag := source.Flag
+		if len(source.Values) > 0 {
+			flag += " (" + strings.Join(source.Values, ", ") + ")"
+		}
+		for _, from := range source.Sources {
+			explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s %s: %s", source.Component, from, flag))
+		}
+		if source.Replacement != "" {
+			options = append(options, fmt.Sprintf("Use %s", source.Replacement))
+		}
+	case DevicePluginFinding:
+		detail("%s %s supports Kubernetes %s to %s", source.Plugin, source.Version, source.MinKubeVersion, source.MaxKubeVersion)
+		explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s %s/%s", source.Kind, source.Namespace, source.Workload))
+		for _, node := range source.Nodes {
+			explanation.Affected = append(explanation.Affected, fmt.Sprintf("Node %s (%s)", node, strings.Join(source.Resources, ", ")))
+		}
+		for _, runtimeClass := range source.RuntimeClasses {
+			explanation.Affected = append(explanation.Affected, "RuntimeClass "+runtimeClass)
+		}
+		if source.RecommendedVersion != "" {
+			when := "right after the control plane"
+			if source.BeforeUpgrade {
+				when = "before the cluster"
+			}
+			options = append(options, fmt.Sprintf("Upgrade %s to %s %s", source.Plugin, source.RecommendedVersion, when))
+		}
+		note(source.Notes)
+	case ValidationResult:
+		detail("%s: %s", source.Reason, source.Message)
+		affected := fmt.Sprintf("%s %s", source.APIVersion, source.Resource())
+		if source.SourceFile != "" {
+			affected += " (" + source.SourceFile + ")"
+		}
+		explanation.Affected = append(explanation.Affected, affected)
+	case ReleaseDrift:
+		detail("%s release %s %s differs from %s", source.Origin, source.Chart, source.DeployedVersion, source.SourceFile)
+		for _, item := range source.Destructive {
+			explanation.Affected = append(explanation.Affected, fmt.Sprintf("%s: %s", item.Resource, item.Reason))
+		}
+		for _, resource := range source.Removed {
+			explanation.Affected = append(explanation.Affected, resource+": deleted by reconciliation")
+		}
+		for _, resource := range source.Changed {
+			explanation.Affected = append(explanation.Affected, resource+": changed")
+		}
+		for _, resource := range source.Added {
+			explanation.Affected = append(explanation.Affected, resource+": created by reconciliation")
+		}
+		options = append(options, "Bring Git and the cluster back in line before the upgrade")
+	}
+
+	if remediation := f.source.meta().Remediation; remediation != nil {
+		explanation.Manual = remediation.Manual
+		options = append(options, remediation.Commands...)
+	}
+	seen := make(map[string]bool)
+	for _, option := range append(options, notes...) {
+		if !seen[option] {
+			seen[option] = true
+			explanation.Options = append(explanation.Options, option)
+		}
+	}
+	return explanation
+}
@@ -0,0 +1,236 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// resourceSubject is a resource whose fields are checked against the knowledge base
+type resourceSubject struct {
+	kind     string
+	name     string
+	spec     map[string]interface{}
+	metadata map[string]interface{} // labels and annotations, for pods
+}
+
+// fieldMatch is the resources of one kind a field matcher selects, with the values its pattern
+// matched
+type fieldMatch struct {
+	kind      string
+	resources []string
+	values    []string
+}
+
+// podTemplatePaths locates the pod template in the spec of each kind; a Pod is its own template
+var podTemplatePaths = map[string]string{
+	"Pod":         "",
+	"Deployment":  "template",
+	"StatefulSet": "template",
+	"DaemonSet":   "template",
+	"ReplicaSet":  "template",
+	"Job":         "template",
+	"CronJob":     "jobTemplate.spec.template",
+}
+
+// manifestSubjects returns the parsed manifests with a spec
+func manifestSubjects(resources []inventory.ResourceEntry) []resourceSubject {
+	subjects := make([]resourceSubject, 0, len(resources))
+	for _, resource := range resources {
+		if resource.Spec == nil {
+			continue
+		}
+		subjects = append(subjects, resourceSubject{
+			kind: resource.Kind,
+			name: qualifiedName(resource.Namespace, resource.Name),
+			spec: resource.Spec,
+			metadata: map[string]interface{}{
+				"labels":      stringMap(resource.Labels),
+				"annotations": stringMap(resource.Annotations),
+			},
+		})
+	}
+	return subjects
+}
+
+// liveSubjects returns the workloads of the live cluster state with a spec
+func liveSubjects(state *inventory.LiveClusterState) []resourceSubject {
+	subjects := make([]resourceSubject, 0, len(state.Workloads))
+	for _, workload := range state.Workloads {
+		if workload.Spec == nil {
+			continue
+		}
+		subjects = append(subjects, resourceSubject{kind: workload.Kind, name: qualifiedName(workload.Namespace, workload.Name), spec: workload.Spec})
+	}
+	return subjects
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func stringMap(values map[string]string) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+// mergeResources adds resource names to a sorted list of them
+func mergeResources(resources, added []string) []string {
+	for _, resource := range added {
+		if !containsString(resources, resource) {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// matchFields returns the subjects a field matcher selects, grouped by kind in sorted order
+func matchFields(matcher *knowledge.FieldMatcher, subjects []resourceSubject) []fieldMatch {
+	byKind := make(map[string]*fieldMatch)
+	var kinds []string
+	for _, subject := range subjects {
+		if !matcher.HasKind(subject.kind) {
+			continue
+		}
+		matched, values := fieldsMatch(matcher, subject)
+		if !matched {
+			continue
+		}
+		match, ok := byKind[subject.kind]
+		if !ok {
+			match = &fieldMatch{kind: subject.kind}
+			byKind[subject.kind] = match
+			kinds = append(kinds, subject.kind)
+		}
+		match.resources = mergeResources(match.resources, []string{subject.name})
+		match.values = mergeResources(match.values, values)
+	}
+
+	sort.Strings(kinds)
+	matches := make([]fieldMatch, 0, len(kinds))
+	for _, kind := range kinds {
+		matches = append(matches, *byKind[kind])
+	}
+	return matches
+}
+
+// fieldsMatch checks the fields of a matcher in a resource, returning the values its pattern matched
+func fieldsMatch(matcher *knowledge.FieldMatcher, subject resourceSubject) (bool, []string) {
+	if len(subjectValues(matcher.Unless, subject)) > 0 {
+		return false, nil
+	}
+	values := subjectValues(matcher.Fields, subject)
+
+	switch matcher.Match {
+	case knowledge.FieldMatchPresent:
+		return len(values) > 0, nil
+	case knowledge.FieldMatchAbsent:
+		return len(values) == 0, nil
+	case knowledge.FieldMatchPattern:
+		var matched []string
+		for _, value := range values {
+			var s string
+			switch value := value.(type) {
+			case string:
+				s = value
+			case int, int64, float64:
+				s = fmt.Sprint(value)
+			default:
+				continue
+			}
+			if matcher.MatchesValue(s) {
+				matched = append(matched, s)
+			}
+		}
+		return len(matched) > 0, matched
+	}
+	return false, nil
+}
+
+// subjectValues returns the values of fields set in a resource
+func subjectValues(fields []string, subject resourceSubject) []interface{} {
+	var values []interface{}
+	for _, field := range fields {
+		root, path, ok := resolveField(field, subject)
+		if !ok {
+			continue
+		}
+		values = append(values, fieldValues(root, strings.Split(path, "."))...)
+	}
+	return values
+}
+
+// resolveField returns the object a field path starts from and the path within it, resolving the
+// podSpec. and podMetadata. prefixes for the kind of the resource
+func resolveField(field string, subject resourceSubject) (map[string]interface{}, string, bool) {
+	prefix, rest, found := strings.Cut(field, ".")
+	if !found || (prefix != "podSpec" && prefix != "podMetadata") {
+		return subject.spec, field, true
+	}
+
+	template, known := podTemplatePaths[subject.kind]
+	if !known {
+		return nil, "", false
+	}
+	if template == "" {
+		if prefix == "podMetadata" {
+			return subject.metadata, rest, true
+		}
+		return subject.spec, rest, true
+	}
+	if prefix == "podMetadata" {
+		return subject.spec, template + ".metadata." + rest, true
+	}
+	return subject.spec, template + ".spec." + rest, true
+}
+
+// fieldValues returns the values set at a path, where a "[]" suffix descends into every element
+// of a list and a "{}" suffix returns the keys of a map
+func fieldValues(value interface{}, path []string) []interface{} {
+	if value == nil {
+		return nil
+	}
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	key := strings.TrimSuffix(strings.TrimSuffix(path[0], "[]"), "{}")
+	next, ok := object[key]
+	if !ok || next == nil {
+		return nil
+	}
+
+	var values []interface{}
+	switch {
+	case strings.HasSuffix(path[0], "[]"):
+		items, _ := next.([]interface{})
+		for _, item := range items {
+			values = append(values, fieldValues(item, path[1:])...)
+		}
+	case strings.HasSuffix(path[0], "{}"):
+		entries, _ := next.(map[string]interface{})
+		for entry := range entries {
+			values = append(values, entry)
+		}
+	default:
+		values = fieldValues(next, path[1:])
+	}
+	return values
+}
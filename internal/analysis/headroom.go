@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// HeadroomResult represents whether the cluster can absorb nodes being taken out of service
+type HeadroomResult struct {
+	SurgeNodes        int      `json:"surgeNodes"`
+	CordonedNodes     []string `json:"cordonedNodes"`
+	AvailableCPU      int64    `json:"availableCpuMillis"`
+	AvailableMemory   int64    `json:"availableMemoryBytes"`
+	RequiredCPU       int64    `json:"requiredCpuMillis"`
+	RequiredMemory    int64    `json:"requiredMemoryBytes"`
+	CPUShortfall      int64    `json:"cpuShortfallMillis"`
+	MemoryShortfall   int64    `json:"memoryShortfallBytes"`
+	Sufficient        bool     `json:"sufficient"`
+	SuggestedAddNodes int      `json:"suggestedAddNodes"`
+	Message           string   `json:"message"`
+}
+
+// CheckSurgeHeadroom verifies that the pods requested on the busiest surgeNodes nodes still fit
+// into the allocatable capacity of the remaining schedulable nodes. Capacity is compared in
+// aggregate, so the result is a lower bound: fragmentation can still prevent scheduling.
+func CheckSurgeHeadroom(state *inventory.LiveClusterState, surgeNodes int) *HeadroomResult {
+	if surgeNodes < 1 {
+		surgeNodes = 1
+	}
+
+	type nodeUsage struct {
+		node   inventory.NodeEntry
+		cpu    int64
+		memory int64
+	}
+
+	usage := make(map[string]*nodeUsage)
+	var schedulable []*nodeUsage
+	for _, node := range state.Nodes {
+		if !node.Ready || node.Unschedulable {
+			continue
+		}
+		u := &nodeUsage{node: node}
+		usage[node.Name] = u
+		schedulable = append(schedulable, u)
+	}
+
+	if len(schedulable) == 0 {
+		return nil
+	}
+
+	// DaemonSet pods are not rescheduled elsewhere when their node is cordoned
+	var totalCPU, totalMemory int64
+	for _, pod := range state.Pods {
+		if pod.Phase == "Succeeded" || pod.Phase == "Failed" || pod.OwnerKind == "DaemonSet" {
+			continue
+		}
+		u, ok := usage[pod.NodeName]
+		if !ok {
+			continue
+		}
+		u.cpu += pod.RequestedCPU
+		u.memory += pod.RequestedMemory
+		totalCPU += pod.RequestedCPU
+		totalMemory += pod.RequestedMemory
+	}
+
+	// Worst case: the busiest nodes are the ones being replaced
+	sort.Slice(schedulable, func(i, j int) bool {
+		if schedulable[i].cpu != schedulable[j].cpu {
+			return schedulable[i].cpu > schedulable[j].cpu
+		}
+		return schedulable[i].node.Name < schedulable[j].node.Name
+	})
+
+	if surgeNodes >= len(schedulable) {
+		surgeNodes = len(schedulable)
+	}
+
+	result := &HeadroomResult{
+		SurgeNodes:     surgeNodes,
+		CordonedNodes:  make([]string, 0, surgeNodes),
+		RequiredCPU:    totalCPU,
+		RequiredMemory: totalMemory,
+	}
+
+	var largestCPU, largestMemory int64
+	for i, u := range schedulable {
+		if i < surgeNodes {
+			result.CordonedNodes = append(result.CordonedNodes, u.node.Name)
+			continue
+		}
+		result.AvailableCPU += u.node.AllocatableCPU
+		result.AvailableMemory += u.node.AllocatableMemory
+	}
+	for _, u := range schedulable {
+		if u.node.AllocatableCPU > largestCPU {
+			largestCPU = u.node.AllocatableCPU
+		}
+		if u.node.AllocatableMemory > largestMemory {
+			largestMemory = u.node.AllocatableMemory
+		}
+	}
+
+	if totalCPU > result.AvailableCPU {
+		result.CPUShortfall = totalCPU - result.AvailableCPU
+	}
+	if totalMemory > result.AvailableMemory {
+		result.MemoryShortfall = totalMemory - result.AvailableMemory
+	}
+
+	result.Sufficient = result.CPUShortfall == 0 && result.MemoryShortfall == 0
+	if result.Sufficient {
+		result.Message = fmt.Sprintf("Cluster can tolerate %d node(s) out of service", surgeNodes)
+		return result
+	}
+
+	result.SuggestedAddNodes = maxInt(ceilDiv(result.CPUShortfall, largestCPU), ceilDiv(result.MemoryShortfall, largestMemory))
+	result.Message = fmt.Sprintf("Insufficient capacity with %d node(s) out of service (short %s CPU, %s memory)",
+		surgeNodes, FormatCPU(result.CPUShortfall), FormatMemory(result.MemoryShortfall))
+
+	return result
+}
+
+// FormatCPU formats millicores for display
+func FormatCPU(millis int64) string {
+	return fmt.Sprintf("%dm", millis)
+}
+
+// FormatMemory formats bytes as MiB for display
+func FormatMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", bytes/(1024*1024))
+}
+
+// ceilDiv divides rounding up, returning 0 for a zero divisor
+func ceilDiv(a, b int64) int {
+	if b <= 0 || a <= 0 {
+		return 0
+	}
+	return int((a + b - 1) / b)
+}
+
+// maxInt returns the larger of two ints
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,190 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/convert"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// hpaMetricsAPIs maps HorizontalPodAutoscaler metric types to the metrics API serving them
+var hpaMetricsAPIs = map[string]string{
+	"Resource":          "metrics.k8s.io",
+	"ContainerResource": "metrics.k8s.io",
+	"Pods":              "custom.metrics.k8s.io",
+	"Object":            "custom.metrics.k8s.io",
+	"External":          "external.metrics.k8s.io",
+}
+
+// HPAFinding is a HorizontalPodAutoscaler manifest still using autoscaling/v2beta1 or v2beta2,
+// with its conversion to autoscaling/v2, or an autoscaling/v2 one whose metrics the API rejects or
+// ignores, typically after a conversion by hand
+type HPAFinding struct {
+	FindingMeta
+	Resource       string                `json:"resource"`
+	Source         string                `json:"source,omitempty"`
+	APIVersion     string                `json:"apiVersion"`
+	ReplacementAPI string                `json:"replacementAPI,omitempty"`
+	Removed        bool                  `json:"removed"`
+	MetricTypes    []string              `json:"metricTypes,omitempty"`
+	MetricsAPIs    []HPAMetricsAPI       `json:"metricsAPIs,omitempty"`
+	Changes        []convert.FieldChange `json:"changes,omitempty"`
+	Notes          []string              `json:"notes,omitempty"`
+	Severity       ImpactLevel           `json:"severity"`
+}
+
+// HPAMetricsAPI is a metrics API a HorizontalPodAutoscaler reads, and the service of the
+// APIService serving it found in the manifests, e.g. a Prometheus adapter or KEDA
+type HPAMetricsAPI struct {
+	Group   string `json:"group"`
+	Adapter string `json:"adapter,omitempty"`
+}
+
+// checkHPAs analyzes the HorizontalPodAutoscalers with a spec, parsed from manifests or stored by
+// a scan from their last-applied configuration. Those on a beta API are converted
+// to autoscaling/v2 and listed with the field changes; they are high severity when the target
+// version removes their API, which is already counted as a removed API, and low otherwise.
+// autoscaling/v2 ones with invalid metrics are medium severity.
+func (a *Analyzer) checkHPAs(resources []inventory.ResourceEntry, targetVersion string) []HPAFinding {
+	adapters := metricsAdapters(resources)
+
+	var findings []HPAFinding
+	for _, resource := range resources {
+		if resource.Kind != "HorizontalPodAutoscaler" || resource.Spec == nil || resource.Templated {
+			continue
+		}
+		obj := convert.Object{
+			"apiVersion": resource.APIVersion,
+			"kind":       resource.Kind,
+			"metadata":   convert.Object{"name": resource.Name, "namespace": resource.Namespace},
+			"spec":       copyValue(resource.Spec),
+		}
+
+		finding := HPAFinding{
+			Resource:   qualifiedName(resource.Namespace, resource.Name),
+			Source:     resource.Source,
+			APIVersion: resource.APIVersion,
+		}
+		switch resource.APIVersion {
+		case "autoscaling/v2beta1", "autoscaling/v2beta2":
+			result, err := convert.Convert(obj)
+			if err != nil || result == nil {
+				continue
+			}
+			group, version, _ := strings.Cut(resource.APIVersion, "/")
+			if dep, found := a.apiKB.CheckDeprecation(group, version, resource.Kind); found {
+				finding.FindingMeta = withUpstreamDocs(dep.DocsURL)
+			}
+			finding.ReplacementAPI = result.ToAPIVersion
+			finding.Changes = result.Changes
+			finding.Notes = result.Notes
+			finding.Removed = a.apiKB.IsAPIRemoved(group, version, resource.Kind, targetVersion)
+			finding.Severity = ImpactLow
+			if finding.Removed {
+				finding.Severity = ImpactHigh
+			}
+		case "autoscaling/v2":
+			finding.Notes = convert.CheckHorizontalPodAutoscaler(obj)
+			if len(finding.Notes) == 0 {
+				continue
+			}
+			finding.Severity = ImpactMedium
+		default:
+			continue
+		}
+
+		finding.MetricTypes, finding.MetricsAPIs = hpaMetrics(obj, adapters)
+		findings = append(findings, finding)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Resource < findings[j].Resource
+	})
+	return findings
+}
+
+// hpaMetrics returns the metric types of a HorizontalPodAutoscaler and the metrics APIs they are
+// read from. Without metrics, the HPA scales on CPU utilization from metrics.k8s.io.
+func hpaMetrics(obj convert.Object, adapters map[string]string) ([]string, []HPAMetricsAPI) {
+	spec, _ := obj["spec"].(convert.Object)
+	metrics, _ := spec["metrics"].([]interface{})
+
+	var types []string
+	for _, metric := range metrics {
+		metric, _ := metric.(convert.Object)
+		if metricType, _ := metric["type"].(string); metricType != "" && !containsString(types, metricType) {
+			types = append(types, metricType)
+		}
+	}
+	if len(metrics) == 0 {
+		types = []string{"Resource"}
+	}
+	sort.Strings(types)
+
+	var apis []HPAMetricsAPI
+	for _, metricType := range types {
+		group, known := hpaMetricsAPIs[metricType]
+		if !known {
+			continue
+		}
+		api := HPAMetricsAPI{Group: group, Adapter: adapters[group]}
+		if !containsMetricsAPI(apis, api) {
+			apis = append(apis, api)
+		}
+	}
+	return types, apis
+}
+
+// metricsAdapters maps the metrics API groups served by APIServices in the manifests to the
+// namespace/name of the service backing them
+func metricsAdapters(resources []inventory.ResourceEntry) map[string]string {
+	adapters := make(map[string]string)
+	for _, resource := range resources {
+		if resource.Kind != "APIService" || resource.Spec == nil {
+			continue
+		}
+		group, _ := resource.Spec["group"].(string)
+		switch group {
+		case "metrics.k8s.io", "custom.metrics.k8s.io", "external.metrics.k8s.io":
+		default:
+			continue
+		}
+		service, _ := resource.Spec["service"].(map[string]interface{})
+		namespace, _ := service["namespace"].(string)
+		name, _ := service["name"].(string)
+		if name != "" {
+			adapters[group] = qualifiedName(namespace, name)
+		}
+	}
+	return adapters
+}
+
+func containsMetricsAPI(apis []HPAMetricsAPI, api HPAMetricsAPI) bool {
+	for _, existing := range apis {
+		if existing.Group == api.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// copyValue deep-copies a decoded YAML value, so a conversion leaves the original untouched
+func copyValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			copied[key] = copyValue(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, child := range value {
+			copied[i] = copyValue(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
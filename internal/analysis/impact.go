@@ -3,9 +3,15 @@ package analysis
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ImpactLevel represents the severity of upgrade impact
@@ -19,21 +25,61 @@ const (
 	ImpactNone     ImpactLevel = "none"
 )
 
+// AtLeast checks whether the level is as severe as the threshold
+func (l ImpactLevel) AtLeast(threshold ImpactLevel) bool {
+	return impactRank(l) >= impactRank(threshold)
+}
+
+// ParseImpactLevel validates an impact level name, e.g. from a --fail-on flag
+func ParseImpactLevel(value string) (ImpactLevel, error) {
+	level := ImpactLevel(strings.ToLower(value))
+	switch level {
+	case ImpactCritical, ImpactHigh, ImpactMedium, ImpactLow, ImpactNone:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown impact level %q (supported: critical, high, medium, low, none)", value)
+}
+
 // ImpactAssessment represents the analysis of upgrade impact
 type ImpactAssessment struct {
-	ClusterID              string                `json:"clusterId"`
-	CurrentVersion         string                `json:"currentVersion"`
-	TargetVersion          string                `json:"targetVersion"`
-	DeprecatedManifestAPIs []DeprecatedAPIImpact `json:"deprecatedManifestAPIs"`
-	DeprecatedCRDAPIs      []DeprecatedAPIImpact `json:"deprecatedCRDAPIs"`
-	IncompatibleCharts     []ChartImpact         `json:"incompatibleCharts"`
-	RiskSignals            []RiskSignal          `json:"riskSignals"`
-	OverallRisk            ImpactLevel           `json:"overallRisk"`
-	TotalIssues            int                   `json:"totalIssues"`
+	ClusterID              string                     `json:"clusterId"`
+	CurrentVersion         string                     `json:"currentVersion"`
+	TargetVersion          string                     `json:"targetVersion"`
+	DeprecatedManifestAPIs []DeprecatedAPIImpact      `json:"deprecatedManifestAPIs"`
+	DeprecatedCRDAPIs      []DeprecatedAPIImpact      `json:"deprecatedCRDAPIs"`
+	IncompatibleCharts     []ChartImpact              `json:"incompatibleCharts"`
+	ChartCaveats           []ChartImpact              `json:"chartCaveats,omitempty"` // compatible, with known issues
+	DeprecatedOperatorAPIs []OperatorAPIImpact        `json:"deprecatedOperatorAPIs,omitempty"`
+	CRDFindings            []CRDFinding               `json:"crdFindings,omitempty"`
+	PolicyMigration        *PolicyMigrationFinding    `json:"policyMigration,omitempty"`
+	Meshes                 []MeshFinding              `json:"meshes,omitempty"`
+	BehaviorChanges        []BehaviorChangeFinding    `json:"behaviorChanges,omitempty"` // advisories, not counted as issues
+	PodFields              []PodFieldFinding          `json:"podFields,omitempty"`
+	HPAs                   []HPAFinding               `json:"hpas,omitempty"`
+	RiskSignals            []RiskSignal               `json:"riskSignals"`
+	NodeDrainResults       []NodeDrainResult          `json:"nodeDrainResults,omitempty"`
+	Headroom               *HeadroomResult            `json:"headroom,omitempty"`
+	InterferingComponents  []InterferingComponent     `json:"interferingComponents,omitempty"`
+	SchedulingWarnings     []SchedulingWarning        `json:"schedulingWarnings,omitempty"`
+	StorageFindings        []StorageFinding           `json:"storageFindings,omitempty"`
+	IngressAnnotations     []IngressAnnotationFinding `json:"ingressAnnotations,omitempty"`
+	Addons                 []AddonFinding             `json:"addons,omitempty"`
+	NodeConfigs            []NodeConfigFinding        `json:"nodeConfigs,omitempty"`
+	DevicePlugins          []DevicePluginFinding      `json:"devicePlugins,omitempty"`
+	ValidationResults      []ValidationResult         `json:"validationResults,omitempty"`
+	ReleaseDrift           []ReleaseDrift             `json:"releaseDrift,omitempty"`
+	OverallRisk            ImpactLevel                `json:"overallRisk"`
+	TotalIssues            int                        `json:"totalIssues"`
+	Baseline               *BaselineResult            `json:"baseline,omitempty"`
+	// IncompleteInventory tells why the scan behind the assessment stopped early; findings may be missing
+	IncompleteInventory string `json:"incompleteInventory,omitempty"`
+	// ScanErrors holds the scan subsystems that failed, e.g. "helm", with their errors
+	ScanErrors map[string]string `json:"scanErrors,omitempty"`
 }
 
 // DeprecatedAPIImpact represents impact from deprecated APIs
 type DeprecatedAPIImpact struct {
+	FindingMeta
 	Group          string      `json:"group"`
 	Version        string      `json:"version"`
 	Kind           string      `json:"kind"`
@@ -43,32 +89,119 @@ type DeprecatedAPIImpact struct {
 	ReplacementAPI string      `json:"replacementAPI"`
 	MigrationNotes string      `json:"migrationNotes"`
 	Source         string      `json:"source"` // "manifest" or "crd"
+	// ReplacementServed tells whether the current cluster already serves the replacement API, so
+	// resources can be migrated before the upgrade; nil when unknown (no API discovery at scan time)
+	ReplacementServed *bool `json:"replacementServed,omitempty"`
+	// Templated marks a lower-confidence finding from un-rendered templates, where a template
+	// condition may pick another API when the chart is rendered
+	Templated bool `json:"templated,omitempty"`
+	// Provenance lists the affected resources and where each was found, when analyzing manifests
+	Provenance []ResourceProvenance `json:"provenance,omitempty"`
+}
+
+// ResourceProvenance attributes an affected resource to the sources it was found in. A resource in
+// several sources, e.g. a repo and a dump of the live cluster, is reported once with all of them.
+type ResourceProvenance struct {
+	Resource string   `json:"resource"` // namespace/name, or name for cluster-scoped resources
+	Sources  []string `json:"sources"`
 }
 
 // ChartImpact represents impact from incompatible charts
 type ChartImpact struct {
+	FindingMeta
 	ChartName          string      `json:"chartName"`
 	Namespace          string      `json:"namespace"`
+	Release            string      `json:"release,omitempty"`
 	CurrentVersion     string      `json:"currentVersion"`
 	RecommendedVersion string      `json:"recommendedVersion"`
 	ImpactLevel        ImpactLevel `json:"impactLevel"`
 	Issues             []string    `json:"issues"`
 	Message            string      `json:"message"`
+	// node platforms (os/arch) the recommended version publishes no images for
+	MissingPlatforms []string `json:"missingPlatforms,omitempty"`
+	// images the recommended version deploys by default, when known
+	RecommendedImages []string `json:"recommendedImages,omitempty"`
+	// APIs removed in the target version that the recommended version still emits, when no
+	// compatible version is free of them
+	RemovedAPIs []string `json:"removedAPIs,omitempty"`
+	// criticality of the release's workloads, e.g. "production", that ImpactLevel is weighed by
+	Criticality string `json:"criticality,omitempty"`
+	// values to set when upgrading to the recommended version, e.g. to disable a removed API
+	SuggestedValues []knowledge.ValueOverride `json:"suggestedValues,omitempty"`
+	// status, revision and last deploy of the release, when scanned from the cluster
+	ReleaseStatus string     `json:"releaseStatus,omitempty"`
+	Revision      int        `json:"revision,omitempty"`
+	LastDeployed  *time.Time `json:"lastDeployed,omitempty"`
+	// repository or source URL of the chart, when known
+	ChartURL string `json:"chartURL,omitempty"`
+}
+
+// withRelease sets the name, status, revision, last deploy and chart URL of a release
+func (c ChartImpact) withRelease(release *ent.HelmRelease) ChartImpact {
+	c.Release = release.Name
+	c.ReleaseStatus = release.Status
+	c.Revision = release.Revision
+	c.LastDeployed = release.LastDeployed
+	c.ChartURL = release.ChartURL
+	return c
+}
+
+// SetFlags formats the suggested values as helm upgrade flags, each with a leading space;
+// advisory values are left to the user
+func (c ChartImpact) SetFlags() string {
+	var flags string
+	for _, value := range c.SuggestedValues {
+		if !value.Advisory {
+			flags += " " + value.SetFlag()
+		}
+	}
+	return flags
 }
 
 // RiskSignal represents a risk factor
 type RiskSignal struct {
+	FindingMeta
 	Type        string      `json:"type"`
 	Severity    ImpactLevel `json:"severity"`
 	Description string      `json:"description"`
 	Resource    string      `json:"resource"`
 }
 
+// unhealthyRelease reports whether a release status is failed or pending, which blocks helm upgrade
+func unhealthyRelease(status string) bool {
+	return status == "failed" || strings.HasPrefix(status, "pending-")
+}
+
+// missingPlatformSignal flags a recommended version publishing no images for some node platforms
+func missingPlatformSignal(resource, version string, platforms []string) RiskSignal {
+	return RiskSignal{
+		Type:        "missing_platform_image",
+		Severity:    ImpactMedium,
+		Description: fmt.Sprintf("Version %s publishes no images for %s nodes", version, strings.Join(platforms, ", ")),
+		Resource:    resource,
+	}
+}
+
 // Analyzer performs upgrade impact analysis
 type Analyzer struct {
-	apiKB   *knowledge.APIKnowledgeBase
-	chartKB *knowledge.ChartKnowledgeBase
-	store   *inventory.Store
+	apiKB          *knowledge.APIKnowledgeBase
+	chartKB        *knowledge.ChartKnowledgeBase
+	storageKB      *knowledge.StorageKnowledgeBase
+	ingressKB      *knowledge.IngressKnowledgeBase
+	addonKB        *knowledge.AddonKnowledgeBase
+	operatorKB     *knowledge.OperatorKnowledgeBase
+	behaviorKB     *knowledge.BehaviorKnowledgeBase
+	podFieldKB     *knowledge.PodFieldKnowledgeBase
+	nodeConfigKB   *knowledge.NodeConfigKnowledgeBase
+	meshKB         *knowledge.MeshKnowledgeBase
+	devicePluginKB *knowledge.DevicePluginKnowledgeBase
+	store          *inventory.Store
+	localizer      *i18n.Localizer
+
+	chartRenderer  ChartRenderer
+	renderedCharts map[string][]string // APIs rendered per chart@version@target
+	criticality    *CriticalityMap
+	chartOverrides []ChartOverride
 }
 
 // NewAnalyzer creates a new impact analyzer
@@ -90,8 +223,51 @@ func NewAnalyzer(apiKnowledgeBasePath, chartKnowledgeBasePath string, store *inv
 	}, nil
 }
 
+// Knowledge base files loaded by LoadKnowledge
+const (
+	OperatorKnowledgeFile = "operator-apis.json"
+	BehaviorKnowledgeFile = "behavior-changes.json"
+	PodFieldKnowledgeFile = "pod-fields.json"
+	MeshKnowledgeFile     = "meshes.json"
+)
+
+// LoadKnowledge loads the knowledge bases every assessment uses besides the API and chart ones,
+// with path locating each file by name, so all entry points report the same findings. A knowledge
+// base that fails to load only skips its checks; the failures are returned as warnings.
+func (a *Analyzer) LoadKnowledge(path func(name string) string) []error {
+	var warnings []error
+	if err := a.LoadOperatorKnowledge(path(OperatorKnowledgeFile)); err != nil {
+		warnings = append(warnings, fmt.Errorf("skipping operator API checks: %w", err))
+	}
+	if err := a.LoadBehaviorKnowledge(path(BehaviorKnowledgeFile)); err != nil {
+		warnings = append(warnings, fmt.Errorf("skipping behavior change checks: %w", err))
+	}
+	if err := a.LoadPodFieldKnowledge(path(PodFieldKnowledgeFile)); err != nil {
+		warnings = append(warnings, fmt.Errorf("skipping pod template field checks: %w", err))
+	}
+	if err := a.LoadMeshKnowledge(path(MeshKnowledgeFile)); err != nil {
+		warnings = append(warnings, fmt.Errorf("skipping service mesh checks: %w", err))
+	}
+	return warnings
+}
+
+// SetLocalizer sets the language of generated reports
+func (a *Analyzer) SetLocalizer(localizer *i18n.Localizer) {
+	a.localizer = localizer
+}
+
 // ComputeUpgradeImpact analyzes the impact of upgrading to a target version
 func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVersion string) (*ImpactAssessment, error) {
+	ctx, op := telemetry.StartAnalysis(ctx, "upgrade-impact", clusterID, targetVersion)
+	assessment, err := a.computeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err == nil {
+		op.SetAttributes(attribute.String("kua.overall_risk", string(assessment.OverallRisk)), attribute.Int("kua.total_issues", assessment.TotalIssues))
+	}
+	op.End(err)
+	return assessment, err
+}
+
+func (a *Analyzer) computeUpgradeImpact(ctx context.Context, clusterID, targetVersion string) (*ImpactAssessment, error) {
 	// Get cluster info
 	cluster, err := a.store.GetCluster(ctx, clusterID)
 	if err != nil {
@@ -102,6 +278,8 @@ func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVe
 		ClusterID:              clusterID,
 		CurrentVersion:         cluster.KubeVersion,
 		TargetVersion:          targetVersion,
+		IncompleteInventory:    cluster.IncompleteReason,
+		ScanErrors:             cluster.ScanErrors,
 		DeprecatedManifestAPIs: make([]DeprecatedAPIImpact, 0),
 		DeprecatedCRDAPIs:      make([]DeprecatedAPIImpact, 0),
 		IncompatibleCharts:     make([]ChartImpact, 0),
@@ -114,23 +292,9 @@ func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVe
 		return nil, fmt.Errorf("failed to query manifest APIs: %w", err)
 	}
 
-	for _, api := range manifestAPIs {
-		if a.apiKB.IsAPIRemoved(api.Group, api.Version, api.Kind, targetVersion) {
-			dep, _ := a.apiKB.CheckDeprecation(api.Group, api.Version, api.Kind)
-
-			impact := DeprecatedAPIImpact{
-				Group:          api.Group,
-				Version:        api.Version,
-				Kind:           api.Kind,
-				AffectedCount:  1,
-				ImpactLevel:    ImpactCritical,
-				RemovedIn:      dep.RemovedIn,
-				ReplacementAPI: dep.ReplacementAPI,
-				MigrationNotes: dep.MigrationNotes,
-				Source:         "manifest",
-			}
-			assessment.DeprecatedManifestAPIs = append(assessment.DeprecatedManifestAPIs, impact)
-		}
+	assessment.DeprecatedManifestAPIs = a.checkManifestAPIs(manifestAPIs, nil, targetVersion)
+	if err := a.addStoredProvenance(ctx, clusterID, assessment.DeprecatedManifestAPIs); err != nil {
+		return nil, err
 	}
 
 	// Check CRDs
@@ -146,6 +310,7 @@ func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVe
 				dep, _ := a.apiKB.CheckDeprecation(crd.Group, version, crd.Kind)
 
 				impact := DeprecatedAPIImpact{
+					FindingMeta:    withUpstreamDocs(dep.DocsURL),
 					Group:          crd.Group,
 					Version:        version,
 					Kind:           crd.Kind,
@@ -166,26 +331,65 @@ func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVe
 	if err != nil {
 		return nil, fmt.Errorf("failed to query helm releases: %w", err)
 	}
+	if err := a.applyChartOverrides(helmReleases); err != nil {
+		return nil, err
+	}
 
 	for _, release := range helmReleases {
+		if unhealthyRelease(release.Status) {
+			assessment.RiskSignals = append(assessment.RiskSignals, RiskSignal{
+				Type:        "unhealthy_release",
+				Severity:    ImpactMedium,
+				Description: fmt.Sprintf("Helm release is %s; resolve it before upgrading its chart", release.Status),
+				Resource:    fmt.Sprintf("%s/%s", release.Namespace, release.Name),
+			})
+		}
+
 		recommendation := a.chartKB.FindCompatibleChartVersion(
 			release.Chart,
 			release.ChartVersion,
 			targetVersion,
+			cluster.NodePlatforms,
+			a.chartRemovedAPIs(targetVersion),
 		)
 
+		if recommendation.IsCompatible && len(recommendation.KnownIssues) > 0 {
+			assessment.ChartCaveats = append(assessment.ChartCaveats, ChartImpact{
+				FindingMeta:        withUpstreamDocs(recommendation.UpgradeNotes),
+				ChartName:          release.Chart,
+				Namespace:          release.Namespace,
+				CurrentVersion:     release.ChartVersion,
+				RecommendedVersion: recommendation.RecommendedVersion,
+				ImpactLevel:        ImpactLow,
+				Issues:             recommendation.KnownIssues,
+				Message:            recommendation.Message,
+			}.withRelease(release))
+		}
+
 		if !recommendation.IsCompatible {
+			criticality := a.releaseCriticality(release.Namespace, release.Name, cluster.NamespaceCriticality)
 			impact := ChartImpact{
+				FindingMeta:        withUpstreamDocs(recommendation.UpgradeNotes),
 				ChartName:          release.Chart,
 				Namespace:          release.Namespace,
 				CurrentVersion:     release.ChartVersion,
 				RecommendedVersion: recommendation.RecommendedVersion,
-				ImpactLevel:        ImpactHigh,
+				ImpactLevel:        criticalityImpact(criticality),
+				Criticality:        criticality,
 				Issues:             recommendation.KnownIssues,
 				Message:            recommendation.Message,
-			}
+				MissingPlatforms:   recommendation.MissingPlatforms,
+				RecommendedImages:  recommendation.RecommendedImages,
+				RemovedAPIs:        recommendation.RemovedAPIs,
+				SuggestedValues:    recommendation.SuggestedValues,
+			}.withRelease(release)
 			assessment.IncompatibleCharts = append(assessment.IncompatibleCharts, impact)
 
+			if len(impact.MissingPlatforms) > 0 {
+				assessment.RiskSignals = append(assessment.RiskSignals, missingPlatformSignal(
+					fmt.Sprintf("%s/%s", release.Namespace, release.Chart), impact.RecommendedVersion, impact.MissingPlatforms))
+			}
+
 			// Add risk signal if chart is unknown or outdated
 			if recommendation.RecommendedVersion == "" {
 				assessment.RiskSignals = append(assessment.RiskSignals, RiskSignal{
@@ -198,15 +402,254 @@ func (a *Analyzer) ComputeUpgradeImpact(ctx context.Context, clusterID, targetVe
 		}
 	}
 
+	// Check operator custom resource APIs
+	assessment.DeprecatedOperatorAPIs = a.checkOperatorAPIs(manifestAPIs, crds, helmReleases, assessment.IncompatibleCharts)
+
+	// Check service mesh control planes support the target version
+	assessment.Meshes = a.checkMeshReleases(helmReleases, cluster.KubeVersion, targetVersion)
+
+	// Check custom resources against the scope of their CRDs and namespaces being deleted
+	assessment.CRDFindings = checkCRDs(manifestAPIs, crds, cluster.TerminatingNamespaces)
+
+	// Check PodSecurityPolicies are replaced by a policy engine
+	psps, err := a.store.ListResources(ctx, clusterID, inventory.ResourceFilter{Groups: []string{"policy", "extensions"}, Kind: "PodSecurityPolicy"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodSecurityPolicies: %w", err)
+	}
+	assessment.PolicyMigration = a.checkPolicyMigration(assessment, podSecurityPolicies(psps), policyEngines(manifestAPIs, crds, helmReleases))
+
+	// Check HorizontalPodAutoscalers stored with their spec convert to autoscaling/v2
+	hpas, err := a.store.ListResources(ctx, clusterID, inventory.ResourceFilter{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HorizontalPodAutoscalers: %w", err)
+	}
+	assessment.HPAs = a.checkHPAs(hpas, targetVersion)
+
+	// Cross-check against the APIs the cluster served at scan time
+	unserved := 0
+	if len(cluster.ServedApis) > 0 {
+		served := newServedAPIs(cluster.ServedApis)
+		signals := served.unservedSignals(manifestAPIs)
+		assessment.RiskSignals = append(assessment.RiskSignals, signals...)
+		unserved = len(signals)
+		served.markReplacements(assessment.DeprecatedManifestAPIs)
+		served.markReplacements(assessment.DeprecatedCRDAPIs)
+	}
+
 	// Calculate overall risk
 	assessment.TotalIssues = len(assessment.DeprecatedManifestAPIs) +
 		len(assessment.DeprecatedCRDAPIs) +
-		len(assessment.IncompatibleCharts)
+		len(assessment.IncompatibleCharts) +
+		len(assessment.DeprecatedOperatorAPIs) +
+		len(assessment.CRDFindings) +
+		len(assessment.Meshes) +
+		unserved
 	assessment.OverallRisk = a.calculateOverallRisk(assessment)
+	for _, api := range assessment.DeprecatedOperatorAPIs {
+		raiseOverallRisk(assessment, api.ImpactLevel)
+	}
+	for _, finding := range assessment.CRDFindings {
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+	for _, finding := range assessment.Meshes {
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+	if unserved > 0 {
+		raiseOverallRisk(assessment, ImpactHigh)
+	}
+	for _, finding := range assessment.HPAs {
+		if finding.ReplacementAPI == "" {
+			assessment.TotalIssues++
+			raiseOverallRisk(assessment, finding.Severity)
+		}
+	}
+	if assessment.PolicyMigration != nil {
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, assessment.PolicyMigration.Severity)
+	}
+	AssignRuleIDs(assessment)
 
 	return assessment, nil
 }
 
+// ComputeManifestImpact analyzes parsed manifests directly, without a scanned cluster inventory
+func (a *Analyzer) ComputeManifestImpact(resources []inventory.ResourceEntry, source, targetVersion string) *ImpactAssessment {
+	assessment := &ImpactAssessment{
+		ClusterID:              source,
+		CurrentVersion:         "unknown",
+		TargetVersion:          targetVersion,
+		DeprecatedManifestAPIs: make([]DeprecatedAPIImpact, 0),
+		DeprecatedCRDAPIs:      make([]DeprecatedAPIImpact, 0),
+		IncompatibleCharts:     make([]ChartImpact, 0),
+		RiskSignals:            make([]RiskSignal, 0),
+	}
+
+	// Collapse resources to unique APIs, counting how many use each. The same resource found in
+	// several sources counts once, with each source recorded in its provenance.
+	counts := make(map[string]int)
+	byKey := make(map[string]*ent.ManifestAPI)
+	provenance := make(map[string][]ResourceProvenance)
+	seen := make(map[string]int)
+	var manifestAPIs []*ent.ManifestAPI
+	for _, resource := range resources {
+		group, version := "", resource.APIVersion
+		if i := strings.Index(resource.APIVersion, "/"); i >= 0 {
+			group, version = resource.APIVersion[:i], resource.APIVersion[i+1:]
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", group, version, resource.Kind)
+		name := resource.Name
+		if resource.Namespace != "" {
+			name = resource.Namespace + "/" + name
+		}
+		resourceSource := resource.Source
+		if resourceSource == "" {
+			resourceSource = source
+		}
+		// Names of templated resources are placeholders, so they are never taken for duplicates
+		identity := key + "/" + name
+		unique := resource.Name != "" && !resource.Templated
+		if i, ok := seen[identity]; ok && unique {
+			entry := &provenance[key][i]
+			if !containsString(entry.Sources, resourceSource) {
+				entry.Sources = append(entry.Sources, resourceSource)
+			}
+			continue
+		}
+		if unique {
+			seen[identity] = len(provenance[key])
+		}
+		provenance[key] = append(provenance[key], ResourceProvenance{Resource: name, Sources: []string{resourceSource}})
+
+		if counts[key] == 0 {
+			byKey[key] = &ent.ManifestAPI{Group: group, Version: version, Kind: resource.Kind, Templated: resource.Templated}
+			manifestAPIs = append(manifestAPIs, byKey[key])
+		}
+		// An API is templated only when no rendered manifest uses it
+		byKey[key].Templated = byKey[key].Templated && resource.Templated
+		counts[key]++
+	}
+
+	assessment.DeprecatedManifestAPIs = a.checkManifestAPIs(manifestAPIs, counts, targetVersion)
+	for i := range assessment.DeprecatedManifestAPIs {
+		api := &assessment.DeprecatedManifestAPIs[i]
+		api.Provenance = provenance[fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)]
+	}
+	assessment.DeprecatedOperatorAPIs = a.checkOperatorAPIs(manifestAPIs, nil, nil, nil)
+	for i := range assessment.DeprecatedOperatorAPIs {
+		api := &assessment.DeprecatedOperatorAPIs[i]
+		api.AffectedCount = counts[fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)]
+	}
+
+	assessment.PolicyMigration = a.checkPolicyMigration(assessment, podSecurityPolicies(resources), policyEngines(manifestAPIs, nil, nil))
+
+	subjects := manifestSubjects(resources)
+	assessment.BehaviorChanges = a.checkBehaviorChanges(subjects, "", targetVersion)
+	assessment.PodFields = a.checkPodFields(subjects, targetVersion)
+	assessment.HPAs = a.checkHPAs(resources, targetVersion)
+
+	assessment.TotalIssues = len(assessment.DeprecatedManifestAPIs) + len(assessment.DeprecatedOperatorAPIs)
+	assessment.OverallRisk = a.calculateOverallRisk(assessment)
+	for _, api := range assessment.DeprecatedOperatorAPIs {
+		raiseOverallRisk(assessment, api.ImpactLevel)
+	}
+	for _, finding := range assessment.PodFields {
+		if finding.Removed {
+			assessment.TotalIssues++
+			raiseOverallRisk(assessment, finding.Severity)
+		}
+	}
+	for _, finding := range assessment.HPAs {
+		if finding.ReplacementAPI == "" {
+			assessment.TotalIssues++
+			raiseOverallRisk(assessment, finding.Severity)
+		}
+	}
+	if assessment.PolicyMigration != nil {
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, assessment.PolicyMigration.Severity)
+	}
+	AssignRuleIDs(assessment)
+
+	return assessment
+}
+
+// addStoredProvenance lists the stored objects using each removed API as its provenance, found in
+// their files or, for live objects, in the cluster
+func (a *Analyzer) addStoredProvenance(ctx context.Context, clusterID string, apis []DeprecatedAPIImpact) error {
+	for i := range apis {
+		api := &apis[i]
+		resources, err := a.store.ListResources(ctx, clusterID, inventory.ResourceFilter{Group: api.Group, Kind: api.Kind})
+		if err != nil {
+			return fmt.Errorf("failed to list resources of %s: %w", api.Kind, err)
+		}
+		apiVersion := api.Version
+		if api.Group != "" {
+			apiVersion = api.Group + "/" + api.Version
+		}
+
+		seen := make(map[string]int)
+		for _, resource := range resources {
+			if resource.APIVersion != apiVersion {
+				continue
+			}
+			name := resource.Name
+			if resource.Namespace != "" {
+				name = resource.Namespace + "/" + name
+			}
+			source := resource.Source
+			if source == "" {
+				source = "cluster"
+			}
+			if j, ok := seen[name]; ok {
+				if !containsString(api.Provenance[j].Sources, source) {
+					api.Provenance[j].Sources = append(api.Provenance[j].Sources, source)
+				}
+				continue
+			}
+			seen[name] = len(api.Provenance)
+			api.Provenance = append(api.Provenance, ResourceProvenance{Resource: name, Sources: []string{source}})
+		}
+		if len(api.Provenance) > 0 {
+			api.AffectedCount = len(api.Provenance)
+		}
+	}
+	return nil
+}
+
+// checkManifestAPIs returns the manifest APIs removed in the target version; counts holds per-API
+// resource counts keyed by group/version/kind, and each API counts once when it is nil
+func (a *Analyzer) checkManifestAPIs(manifestAPIs []*ent.ManifestAPI, counts map[string]int, targetVersion string) []DeprecatedAPIImpact {
+	impacts := make([]DeprecatedAPIImpact, 0)
+
+	for _, api := range manifestAPIs {
+		if a.apiKB.IsAPIRemoved(api.Group, api.Version, api.Kind, targetVersion) {
+			dep, _ := a.apiKB.CheckDeprecation(api.Group, api.Version, api.Kind)
+
+			affected := 1
+			if counts != nil {
+				affected = counts[fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)]
+			}
+
+			impacts = append(impacts, DeprecatedAPIImpact{
+				FindingMeta:    withUpstreamDocs(dep.DocsURL),
+				Group:          api.Group,
+				Version:        api.Version,
+				Kind:           api.Kind,
+				AffectedCount:  affected,
+				ImpactLevel:    ImpactCritical,
+				RemovedIn:      dep.RemovedIn,
+				ReplacementAPI: dep.ReplacementAPI,
+				MigrationNotes: dep.MigrationNotes,
+				Source:         "manifest",
+				Templated:      api.Templated,
+			})
+		}
+	}
+
+	return impacts
+}
+
 // calculateOverallRisk determines the overall risk level
 func (a *Analyzer) calculateOverallRisk(assessment *ImpactAssessment) ImpactLevel {
 	if assessment.TotalIssues == 0 {
@@ -224,84 +667,546 @@ func (a *Analyzer) calculateOverallRisk(assessment *ImpactAssessment) ImpactLeve
 		return ImpactCritical
 	}
 
-	if len(assessment.DeprecatedCRDAPIs) > 0 || len(assessment.IncompatibleCharts) > 0 {
+	if len(assessment.DeprecatedCRDAPIs) > 0 {
 		return ImpactHigh
 	}
 
-	return ImpactMedium
+	// incompatible charts weigh as much as the workloads they back
+	risk := ImpactMedium
+	if len(assessment.DeprecatedManifestAPIs) == 0 && len(assessment.IncompatibleCharts) > 0 {
+		risk = ImpactLow
+	}
+	for _, chart := range assessment.IncompatibleCharts {
+		if impactRank(chart.ImpactLevel) > impactRank(risk) {
+			risk = chart.ImpactLevel
+		}
+	}
+	return risk
 }
 
 // GenerateReport generates a human-readable report
 func (a *Analyzer) GenerateReport(assessment *ImpactAssessment) string {
-	report := fmt.Sprintf("\n=== Upgrade Impact Assessment ===\n")
-	report += fmt.Sprintf("Cluster: %s\n", assessment.ClusterID)
-	report += fmt.Sprintf("Current Version: %s\n", assessment.CurrentVersion)
-	report += fmt.Sprintf("Target Version: %s\n", assessment.TargetVersion)
-	report += fmt.Sprintf("Overall Risk: %s\n", assessment.OverallRisk)
-	report += fmt.Sprintf("Total Issues: %d\n\n", assessment.TotalIssues)
+	l := a.localizer
+	report := l.T("\n=== Upgrade Impact Assessment ===\n")
+	report += l.T("Cluster: %s\n", assessment.ClusterID)
+	report += l.T("Current Version: %s\n", assessment.CurrentVersion)
+	report += l.T("Target Version: %s\n", assessment.TargetVersion)
+	report += l.T("Overall Risk: %s\n", assessment.OverallRisk)
+	report += l.T("Total Issues: %d\n\n", assessment.TotalIssues)
+
+	if assessment.IncompleteInventory != "" {
+		report += l.T("⚠️  INCOMPLETE INVENTORY: the last scan stopped early (%s); findings may be missing. Re-run scan to complete it.\n\n", assessment.IncompleteInventory)
+	}
+	if len(assessment.ScanErrors) > 0 {
+		report += l.T("⚠️  PARTIAL SCAN: findings depending on these parts may be missing:\n")
+		for _, subsystem := range inventory.ScanErrorSubsystems(assessment.ScanErrors) {
+			report += fmt.Sprintf("   - %s: %s\n", subsystem, assessment.ScanErrors[subsystem])
+		}
+		report += "\n"
+	}
+
+	if assessment.Baseline != nil {
+		result := assessment.Baseline
+		report += l.T("🆕 NEW FINDINGS (%d, %d pre-existing, %d fixed since baseline)\n",
+			len(result.NewFindings), len(result.ExistingFindings), result.FixedCount)
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range result.NewFindings {
+			report += l.T("%d. [%s] %s\n", i+1, finding.RuleID, finding.Resource)
+			report += l.T("   Severity: %s\n", finding.Severity)
+			report += l.T("   %s\n\n", finding.Summary)
+		}
+		if len(result.ExistingFindings) > 0 {
+			report += l.T("Pre-existing (accepted in baseline):\n")
+			for _, finding := range result.ExistingFindings {
+				report += l.T("   - [%s] %s (%s)\n", finding.RuleID, finding.Resource, finding.Severity)
+			}
+		}
+		report += "\n"
+	}
 
 	if len(assessment.DeprecatedManifestAPIs) > 0 {
-		report += fmt.Sprintf("⚠️  DEPRECATED MANIFEST APIs (%d)\n", len(assessment.DeprecatedManifestAPIs))
+		report += l.T("⚠️  DEPRECATED MANIFEST APIs (%d)\n", len(assessment.DeprecatedManifestAPIs))
 		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
 		for i, api := range assessment.DeprecatedManifestAPIs {
 			gv := api.Group + "/" + api.Version
 			if api.Group == "" {
 				gv = api.Version
 			}
-			report += fmt.Sprintf("%d. %s %s\n", i+1, gv, api.Kind)
-			report += fmt.Sprintf("   Impact: %s\n", api.ImpactLevel)
-			report += fmt.Sprintf("   Removed In: v%s\n", api.RemovedIn)
-			report += fmt.Sprintf("   Replacement: %s\n", api.ReplacementAPI)
-			report += fmt.Sprintf("   Migration: %s\n\n", api.MigrationNotes)
+			report += l.T("%d. [%s] %s %s\n", i+1, api.RuleID, gv, api.Kind)
+			report += l.T("   Impact: %s\n", api.ImpactLevel)
+			if api.Templated {
+				report += l.T("   Confidence: low, found in un-rendered templates only\n")
+			}
+			report += l.T("   Removed In: v%s\n", api.RemovedIn)
+			report += l.T("   Replacement: %s\n", api.ReplacementAPI)
+			report += replacementServedLine(l, api)
+			for _, resource := range api.Provenance {
+				report += l.T("   %s found in: %s\n", resource.Resource, strings.Join(resource.Sources, ", "))
+			}
+			report += upstreamDocsReport(l, api.FindingMeta)
+			report += l.T("   Migration: %s\n\n", api.MigrationNotes)
 		}
 	}
 
 	if len(assessment.DeprecatedCRDAPIs) > 0 {
-		report += fmt.Sprintf("⚠️  DEPRECATED CRD APIs (%d)\n", len(assessment.DeprecatedCRDAPIs))
+		report += l.T("⚠️  DEPRECATED CRD APIs (%d)\n", len(assessment.DeprecatedCRDAPIs))
 		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
 		for i, api := range assessment.DeprecatedCRDAPIs {
-			report += fmt.Sprintf("%d. %s/%s %s\n", i+1, api.Group, api.Version, api.Kind)
-			report += fmt.Sprintf("   Impact: %s\n", api.ImpactLevel)
-			report += fmt.Sprintf("   Removed In: v%s\n", api.RemovedIn)
-			report += fmt.Sprintf("   Replacement: %s\n", api.ReplacementAPI)
-			report += fmt.Sprintf("   Migration: %s\n\n", api.MigrationNotes)
+			report += l.T("%d. [%s] %s/%s %s\n", i+1, api.RuleID, api.Group, api.Version, api.Kind)
+			report += l.T("   Impact: %s\n", api.ImpactLevel)
+			report += l.T("   Removed In: v%s\n", api.RemovedIn)
+			report += l.T("   Replacement: %s\n", api.ReplacementAPI)
+			report += replacementServedLine(l, api)
+			report += upstreamDocsReport(l, api.FindingMeta)
+			report += l.T("   Migration: %s\n\n", api.MigrationNotes)
+		}
+	}
+
+	if len(assessment.DeprecatedOperatorAPIs) > 0 {
+		report += l.T("🧩 DEPRECATED OPERATOR APIs (%d)\n", len(assessment.DeprecatedOperatorAPIs))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, api := range assessment.DeprecatedOperatorAPIs {
+			report += l.T("%d. [%s] %s/%s %s (%s: %s)\n", i+1, api.RuleID, api.Group, api.Version, api.Kind, api.Source, api.Resource)
+			report += l.T("   Impact: %s\n", api.ImpactLevel)
+			operatorVersion := api.OperatorVersion
+			if operatorVersion == "" {
+				operatorVersion = l.T("not detected")
+			}
+			report += l.T("   Operator: %s (installed: %s)\n", api.Operator, operatorVersion)
+			if api.RemovedIn != "" {
+				report += l.T("   Removed In: %s %s\n", api.Operator, api.RemovedIn)
+			} else {
+				report += l.T("   Deprecated In: %s %s\n", api.Operator, api.DeprecatedIn)
+			}
+			if api.Source == "crd" {
+				report += l.T("   Custom Resources: %d", api.AffectedCount)
+				if api.StoredVersion {
+					report += l.T(" (stored at this version)")
+				}
+				report += "\n"
+			}
+			report += l.T("   Replacement: %s\n", api.ReplacementAPI)
+			report += upstreamDocsReport(l, api.FindingMeta)
+			report += l.T("   Migration: %s\n\n", api.MigrationNotes)
+		}
+	}
+
+	if len(assessment.CRDFindings) > 0 {
+		report += l.T("🧷 CUSTOM RESOURCE DEFINITION ISSUES (%d)\n", len(assessment.CRDFindings))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.CRDFindings {
+			report += l.T("%d. [%s] %s\n", i+1, finding.RuleID, finding.Description)
+			report += l.T("   Resource: %s\n", finding.Resource)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
+	if finding := assessment.PolicyMigration; finding != nil {
+		report += l.T("🛡️  POD SECURITY POLICY MIGRATION\n")
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		report += l.T("[%s] %s\n", finding.RuleID, finding.Description)
+		if len(finding.PodSecurityPolicies) > 0 {
+			report += l.T("   PodSecurityPolicies: %s\n", strings.Join(finding.PodSecurityPolicies, ", "))
+		}
+		for _, policy := range finding.Policies {
+			if policy.Manifest != "" {
+				report += l.T("   Generated ValidatingAdmissionPolicy %s (Pod Security level: %s)\n", policy.Name, policy.Level)
+			} else {
+				report += l.T("   %s: Pod Security level %s; ValidatingAdmissionPolicy needs Kubernetes 1.28, use Kyverno or Gatekeeper for finer rules\n", policy.PodSecurityPolicy, policy.Level)
+			}
+			if len(policy.Unsupported) > 0 {
+				report += l.T("     Not enforced: %s\n", strings.Join(policy.Unsupported, ", "))
+			}
+		}
+		if len(finding.Manifests()) > 0 {
+			report += l.T("   Write the generated policies with --policy-dir\n")
+		}
+		report += l.T("   Severity: %s\n\n", finding.Severity)
+	}
+
+	if len(assessment.Meshes) > 0 {
+		report += l.T("🕸️  SERVICE MESH (%d)\n", len(assessment.Meshes))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.Meshes {
+			report += l.T("%d. [%s] %s %s (%s)\n", i+1, finding.RuleID, finding.Mesh, finding.Version, finding.Resource)
+			report += l.T("   Supports Kubernetes %s to %s\n", finding.MinKubeVersion, finding.MaxKubeVersion)
+			if finding.RecommendedVersion != "" {
+				report += l.T("   Recommended Version: %s\n", finding.RecommendedVersion)
+				if len(finding.Hops) > 0 {
+					hops := make([]string, 0, len(finding.Hops))
+					for _, hop := range finding.Hops {
+						hops = append(hops, hop.Version)
+					}
+					report += l.T("   Upgrade through %s first\n", strings.Join(hops, ", "))
+				}
+				if finding.BeforeUpgrade {
+					report += l.T("   Upgrade the mesh before the cluster\n")
+				} else {
+					report += l.T("   No version supports both the current and target version: upgrade the mesh right after the control plane\n")
+				}
+			}
+			if finding.Canary {
+				report += l.T("   Canary control plane upgrade with a new revision\n")
+			}
+			report += l.T("   Notes: %s\n", finding.Notes)
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
 		}
 	}
 
 	if len(assessment.IncompatibleCharts) > 0 {
-		report += fmt.Sprintf("📦 INCOMPATIBLE HELM CHARTS (%d)\n", len(assessment.IncompatibleCharts))
+		report += l.T("📦 INCOMPATIBLE HELM CHARTS (%d)\n", len(assessment.IncompatibleCharts))
 		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
 		for i, chart := range assessment.IncompatibleCharts {
-			report += fmt.Sprintf("%d. %s (namespace: %s)\n", i+1, chart.ChartName, chart.Namespace)
-			report += fmt.Sprintf("   Current Version: %s\n", chart.CurrentVersion)
+			report += l.T("%d. [%s] %s (namespace: %s)\n", i+1, chart.RuleID, chart.ChartName, chart.Namespace)
+			report += l.T("   Current Version: %s\n", chart.CurrentVersion)
+			report += chartReleaseReport(l, chart)
 			if chart.RecommendedVersion != "" {
-				report += fmt.Sprintf("   Recommended Version: %s\n", chart.RecommendedVersion)
+				report += l.T("   Recommended Version: %s\n", chart.RecommendedVersion)
+			}
+			if len(chart.MissingPlatforms) > 0 {
+				report += l.T("   No images for node platforms: %s\n", strings.Join(chart.MissingPlatforms, ", "))
+			}
+			if len(chart.RemovedAPIs) > 0 {
+				report += l.T("   Recommended version still emits removed APIs: %s\n", strings.Join(chart.RemovedAPIs, ", "))
 			}
-			report += fmt.Sprintf("   Impact: %s\n", chart.ImpactLevel)
-			report += fmt.Sprintf("   Message: %s\n", chart.Message)
+			if len(chart.SuggestedValues) > 0 {
+				report += l.T("   Suggested values:\n")
+				for _, value := range chart.SuggestedValues {
+					if value.Advisory {
+						report += l.T("     %s (advisory, not in the upgrade command): %s\n", value.SetFlag(), value.Reason)
+					} else {
+						report += fmt.Sprintf("     %s: %s\n", value.SetFlag(), value.Reason)
+					}
+				}
+			}
+			report += l.T("   Impact: %s\n", chart.ImpactLevel)
+			if chart.Criticality != "" {
+				report += l.T("   Criticality: %s\n", chart.Criticality)
+			}
+			report += l.T("   Message: %s\n", chart.Message)
 			if len(chart.Issues) > 0 {
-				report += fmt.Sprintf("   Known Issues:\n")
+				report += l.T("   Known Issues:\n")
 				for _, issue := range chart.Issues {
-					report += fmt.Sprintf("     - %s\n", issue)
+					report += l.T("     - %s\n", issue)
 				}
 			}
+			report += upstreamDocsReport(l, chart.FindingMeta)
 			report += "\n"
 		}
 	}
 
+	if len(assessment.ChartCaveats) > 0 {
+		report += l.T("📝 CHARTS COMPATIBLE WITH CAVEATS (%d)\n", len(assessment.ChartCaveats))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, chart := range assessment.ChartCaveats {
+			report += l.T("%d. [%s] %s (namespace: %s)\n", i+1, chart.RuleID, chart.ChartName, chart.Namespace)
+			report += l.T("   Current Version: %s\n", chart.CurrentVersion)
+			report += chartReleaseReport(l, chart)
+			if chart.RecommendedVersion != "" {
+				report += l.T("   Version without known issues: %s\n", chart.RecommendedVersion)
+			}
+			report += l.T("   Known Issues:\n")
+			for _, issue := range chart.Issues {
+				report += l.T("     - %s\n", issue)
+			}
+			report += upstreamDocsReport(l, chart.FindingMeta)
+			report += "\n"
+		}
+	}
+
+	if len(assessment.PodFields) > 0 {
+		report += l.T("🧩 DEPRECATED POD TEMPLATE FIELDS (%d)\n", len(assessment.PodFields))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.PodFields {
+			report += l.T("%d. [%s] %s\n", i+1, finding.RuleID, finding.Description)
+			report += l.T("   Affected %s (%d): %s\n", finding.Kind, len(finding.Resources), strings.Join(finding.Resources, ", "))
+			report += l.T("   Replacement: %s\n", finding.Replacement)
+			for _, label := range finding.RewriteLabels() {
+				report += l.T("   Rewrite: %s -> %s (kube-upgrade-advisor fix)\n", label, finding.Rewrites[label])
+			}
+			if finding.RemovedIn != "" {
+				report += l.T("   Removed In: v%s\n", finding.RemovedIn)
+			}
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
+	if len(assessment.HPAs) > 0 {
+		report += l.T("📈 HORIZONTAL POD AUTOSCALERS (%d)\n", len(assessment.HPAs))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.HPAs {
+			if finding.ReplacementAPI != "" {
+				report += l.T("%d. [%s] %s: %s -> %s\n", i+1, finding.RuleID, finding.Resource, finding.APIVersion, finding.ReplacementAPI)
+			} else {
+				report += l.T("%d. [%s] %s: invalid %s metrics\n", i+1, finding.RuleID, finding.Resource, finding.APIVersion)
+			}
+			if finding.Source != "" {
+				report += l.T("   Source: %s\n", finding.Source)
+			}
+			report += l.T("   Metrics: %s\n", strings.Join(finding.MetricTypes, ", "))
+			for _, api := range finding.MetricsAPIs {
+				if api.Adapter != "" {
+					report += l.T("   Metrics API: %s, served by %s\n", api.Group, api.Adapter)
+				} else if api.Group != "metrics.k8s.io" {
+					report += l.T("   Metrics API: %s, no adapter in the manifests; check it supports the target version\n", api.Group)
+				}
+			}
+			if len(finding.Changes) > 0 {
+				report += l.T("   Conversion:\n")
+				for _, change := range finding.Changes {
+					report += fmt.Sprintf("     %s\n", change)
+				}
+			}
+			for _, note := range finding.Notes {
+				report += l.T("   Note: %s\n", note)
+			}
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
+	if len(assessment.BehaviorChanges) > 0 {
+		report += l.T("🔀 BEHAVIOR CHANGES (%d)\n", len(assessment.BehaviorChanges))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.BehaviorChanges {
+			report += l.T("%d. [%s] %s\n", i+1, finding.RuleID, finding.Description)
+			report += l.T("   Changed in: %s\n", finding.ChangedIn)
+			report += l.T("   Affected %s (%d): %s\n", finding.Kind, len(finding.Resources), strings.Join(finding.Resources, ", "))
+			report += l.T("   Recommendation: %s\n", finding.Recommendation)
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
 	if len(assessment.RiskSignals) > 0 {
-		report += fmt.Sprintf("⚠️  RISK SIGNALS (%d)\n", len(assessment.RiskSignals))
+		report += l.T("⚠️  RISK SIGNALS (%d)\n", len(assessment.RiskSignals))
 		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
 		for i, risk := range assessment.RiskSignals {
-			report += fmt.Sprintf("%d. [%s] %s\n", i+1, risk.Type, risk.Description)
-			report += fmt.Sprintf("   Resource: %s\n", risk.Resource)
-			report += fmt.Sprintf("   Severity: %s\n\n", risk.Severity)
+			report += l.T("%d. [%s] %s\n", i+1, risk.Type, risk.Description)
+			report += l.T("   Resource: %s\n", risk.Resource)
+			report += l.T("   Severity: %s\n\n", risk.Severity)
+		}
+	}
+
+	if len(assessment.StorageFindings) > 0 {
+		report += l.T("💾 STORAGE RISKS (%d)\n", len(assessment.StorageFindings))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.StorageFindings {
+			report += l.T("%d. [%s] %s\n", i+1, finding.Type, finding.Resource)
+			report += l.T("   Impact: %s\n", finding.Severity)
+			report += l.T("   %s\n", finding.Description)
+			if finding.Replacement != "" {
+				report += l.T("   Replacement: %s\n", finding.Replacement)
+			}
+			report += l.T("   Migration: %s\n\n", finding.MigrationNotes)
+		}
+	}
+
+	if len(assessment.IngressAnnotations) > 0 {
+		report += l.T("🌐 INGRESS ANNOTATION CHANGES (%d)\n", len(assessment.IngressAnnotations))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.IngressAnnotations {
+			report += l.T("%d. %s: %s\n", i+1, finding.Ingress, finding.Annotation)
+			report += l.T("   Impact: %s (%s in %s %s, upgrading %s -> %s)\n", finding.Severity, finding.Change, finding.Controller, finding.ChangedIn, finding.FromVersion, finding.ToVersion)
+			if finding.Replacement != "" {
+				report += l.T("   Replacement: %s\n", finding.Replacement)
+			}
+			report += l.T("   Notes: %s\n\n", finding.Notes)
+		}
+	}
+
+	if len(assessment.Addons) > 0 {
+		report += l.T("🧩 KUBE-SYSTEM ADDONS (%d)\n", len(assessment.Addons))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.Addons {
+			report += l.T("%d. %s (%s/%s): %s -> %s\n", i+1, finding.Addon, finding.Namespace, finding.Workload, finding.CurrentVersion, finding.TargetVersion)
+			report += l.T("   Impact: %s\n", finding.Severity)
+			if finding.Managed {
+				report += l.T("   Upgraded by %s with the control plane; verify after the upgrade\n", finding.Platform)
+			} else {
+				report += l.T("   Not upgraded by %s: needs an explicit upgrade step\n", finding.Platform)
+			}
+			if len(finding.MissingPlatforms) > 0 {
+				report += l.T("   No images for node platforms: %s\n", strings.Join(finding.MissingPlatforms, ", "))
+			}
+			report += l.T("   Notes: %s\n\n", finding.Notes)
+		}
+	}
+
+	if len(assessment.NodeConfigs) > 0 {
+		report += l.T("🖥️  KUBELET AND KUBE-PROXY CONFIGURATION (%d)\n", len(assessment.NodeConfigs))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.NodeConfigs {
+			report += l.T("%d. [%s] %s\n", i+1, finding.RuleID, finding.Description)
+			report += l.T("   Component: %s (%s)\n", finding.Component, strings.Join(finding.Sources, ", "))
+			if len(finding.Values) > 0 {
+				report += l.T("   Flag: %s (%s)\n", finding.Flag, strings.Join(finding.Values, ", "))
+			} else {
+				report += l.T("   Flag: %s\n", finding.Flag)
+			}
+			report += l.T("   Replacement: %s\n", finding.Replacement)
+			if finding.RemovedIn != "" {
+				report += l.T("   Removed In: v%s\n", finding.RemovedIn)
+			}
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
+	if len(assessment.DevicePlugins) > 0 {
+		report += l.T("🎮 DEVICE PLUGINS (%d)\n", len(assessment.DevicePlugins))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, finding := range assessment.DevicePlugins {
+			report += l.T("%d. [%s] %s %s (%s)\n", i+1, finding.RuleID, finding.Plugin, finding.Version, finding.Namespace+"/"+finding.Workload)
+			report += l.T("   Supports Kubernetes %s to %s\n", finding.MinKubeVersion, finding.MaxKubeVersion)
+			report += l.T("   Nodes with %s: %d\n", strings.Join(finding.Resources, ", "), len(finding.Nodes))
+			if len(finding.RuntimeClasses) > 0 {
+				report += l.T("   RuntimeClasses: %s\n", strings.Join(finding.RuntimeClasses, ", "))
+			}
+			if finding.RecommendedVersion != "" {
+				report += l.T("   Recommended Version: %s\n", finding.RecommendedVersion)
+				if finding.BeforeUpgrade {
+					report += l.T("   Upgrade the device plugin before the cluster\n")
+				} else {
+					report += l.T("   No version supports both the current and target version: upgrade the device plugin right after the control plane\n")
+				}
+			}
+			report += l.T("   Notes: %s\n", finding.Notes)
+			report += upstreamDocsReport(l, finding.FindingMeta)
+			report += l.T("   Severity: %s\n\n", finding.Severity)
+		}
+	}
+
+	if len(assessment.ValidationResults) > 0 {
+		report += l.T("🧪 SERVER-SIDE DRY RUN (%d of %d rejected)\n", assessment.RejectedValidations(), len(assessment.ValidationResults))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		rejected := 0
+		for _, result := range assessment.ValidationResults {
+			if result.Passed {
+				continue
+			}
+			rejected++
+			report += l.T("%d. [%s] %s %s\n", rejected, result.Reason, result.APIVersion, result.Resource())
+			if result.SourceFile != "" {
+				report += l.T("   File: %s\n", result.SourceFile)
+			}
+			report += l.T("   %s\n\n", result.Message)
+		}
+		if rejected == 0 {
+			report += l.T("   All resources passed\n\n")
+		}
+	}
+
+	if len(assessment.ReleaseDrift) > 0 {
+		report += l.T("🔀 HELM RELEASE DRIFT (%d of %d releases drifted)\n", assessment.DriftedReleases(), len(assessment.ReleaseDrift))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, drift := range assessment.ReleaseDrift {
+			report += l.T("%d. %s/%s (%s, %s)\n", i+1, drift.Namespace, drift.Release, drift.Origin, drift.SourceFile)
+			if drift.Error != "" {
+				report += l.T("   Not compared: %s\n\n", drift.Error)
+				continue
+			}
+			pinned := drift.PinnedVersion
+			if pinned == "" {
+				pinned = l.T("latest")
+			}
+			report += l.T("   Chart: %s %s deployed, %s in Git\n", drift.Chart, drift.DeployedVersion, pinned)
+			if !drift.Drifted() {
+				report += l.T("   In sync\n\n")
+				continue
+			}
+			report += l.T("   Impact: %s\n", drift.Severity)
+			report += l.T("   Drift: %d added, %d removed, %d changed\n", len(drift.Added), len(drift.Removed), len(drift.Changed))
+			for _, item := range drift.Destructive {
+				report += l.T("     - %s: %s\n", item.Resource, item.Reason)
+			}
+			report += "\n"
+		}
+	}
+
+	if len(assessment.NodeDrainResults) > 0 {
+		report += l.T("🚧 NODE DRAIN CHECK (%d nodes)\n", len(assessment.NodeDrainResults))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, node := range assessment.NodeDrainResults {
+			status := l.T("drainable")
+			if !node.Drainable {
+				status = l.T("BLOCKED")
+			}
+			report += l.T("%d. %s: %s\n", i+1, node.Node, status)
+			report += l.T("   Evictable Pods: %d (DaemonSet pods skipped: %d)\n", node.EvictablePods, node.DaemonSetPods)
+			for _, blocker := range node.Blockers {
+				report += l.T("     - %s/%s [%s] %s\n", blocker.Namespace, blocker.Pod, blocker.Reason, blocker.Detail)
+			}
+			report += "\n"
+		}
+	}
+
+	if assessment.Headroom != nil {
+		headroom := assessment.Headroom
+		report += l.T("📈 CAPACITY HEADROOM\n")
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		report += l.T("   Nodes out of service: %d (%s)\n", headroom.SurgeNodes, strings.Join(headroom.CordonedNodes, ", "))
+		report += l.T("   CPU: %s requested / %s available\n", FormatCPU(headroom.RequiredCPU), FormatCPU(headroom.AvailableCPU))
+		report += l.T("   Memory: %s requested / %s available\n", FormatMemory(headroom.RequiredMemory), FormatMemory(headroom.AvailableMemory))
+		report += l.T("   Result: %s\n", headroom.Message)
+		if !headroom.Sufficient {
+			report += l.T("   Suggestion: temporarily add %d node(s) before upgrading\n", headroom.SuggestedAddNodes)
+		}
+		report += "\n"
+	}
+
+	if len(assessment.SchedulingWarnings) > 0 {
+		report += l.T("🧭 SCHEDULING CONSTRAINTS (%d)\n", len(assessment.SchedulingWarnings))
+		report += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for i, warning := range assessment.SchedulingWarnings {
+			report += l.T("%d. [%s] %s\n", i+1, warning.Type, warning.Resource)
+			report += l.T("   Severity: %s\n", warning.Severity)
+			report += l.T("   %s\n", warning.Description)
+			report += l.T("   Recommendation: %s\n\n", warning.Recommendation)
 		}
 	}
 
 	if assessment.TotalIssues == 0 {
-		report += "✅ No deprecated APIs or incompatible charts found. Safe to upgrade!\n"
+		report += l.T("✅ No deprecated APIs or incompatible charts found. Safe to upgrade!\n")
+	}
+
+	return report
+}
+
+// replacementServedLine tells whether a removed API's replacement can be adopted before the upgrade
+func replacementServedLine(l *i18n.Localizer, api DeprecatedAPIImpact) string {
+	if api.ReplacementServed == nil {
+		return ""
 	}
+	if *api.ReplacementServed {
+		return l.T("   Pre-migration: replacement is served today, migrate before the upgrade\n")
+	}
+	return l.T("   Pre-migration: replacement is not served by the current version, migrate during the upgrade\n")
+}
 
+// chartReleaseReport lists the status, revision, last deploy and chart source of a chart finding's
+// release, when known
+func chartReleaseReport(l *i18n.Localizer, chart ChartImpact) string {
+	var report string
+	if chart.ReleaseStatus != "" {
+		report += l.T("   Release Status: %s (revision %d)\n", chart.ReleaseStatus, chart.Revision)
+	}
+	if chart.LastDeployed != nil {
+		report += l.T("   Last Deployed: %s\n", chart.LastDeployed.UTC().Format(time.RFC3339))
+	}
+	if chart.ChartURL != "" {
+		report += l.T("   Chart Source: %s\n", chart.ChartURL)
+	}
+	return report
+}
+
+// upstreamDocsReport renders the upstream guide links of a finding as report lines
+func upstreamDocsReport(l *i18n.Localizer, meta FindingMeta) string {
+	var report string
+	for _, url := range meta.UpstreamDocs {
+		report += l.T("   Docs: %s\n", url)
+	}
 	return report
 }
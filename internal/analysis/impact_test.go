@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+func TestManifestImpactProvenance(t *testing.T) {
+	analyzer := testAnalyzer(t)
+	cronJob := func(source string) inventory.ResourceEntry {
+		return inventory.ResourceEntry{APIVersion: "batch/v1beta1", Kind: "CronJob", Namespace: "shop", Name: "cleanup", Source: source}
+	}
+
+	tests := []struct {
+		name      string
+		resources []inventory.ResourceEntry
+		want      []string
+	}{
+		{"one source", []inventory.ResourceEntry{cronJob("deploy/cronjob.yaml")}, []string{"deploy/cronjob.yaml"}},
+		{"several sources", []inventory.ResourceEntry{cronJob("deploy/cronjob.yaml"), cronJob("cluster-dump")}, []string{"deploy/cronjob.yaml", "cluster-dump"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assessment := analyzer.ComputeManifestImpact(tt.resources, "manifests", "1.25")
+			if len(assessment.DeprecatedManifestAPIs) != 1 {
+				t.Fatalf("%d deprecated manifest APIs, want the CronJob API", len(assessment.DeprecatedManifestAPIs))
+			}
+			api := assessment.DeprecatedManifestAPIs[0]
+			want := []ResourceProvenance{{Resource: "shop/cleanup", Sources: tt.want}}
+			if !reflect.DeepEqual(api.Provenance, want) {
+				t.Errorf("provenance %+v, want %+v", api.Provenance, want)
+			}
+			line := "shop/cleanup found in: " + strings.Join(tt.want, ", ")
+			if report := analyzer.GenerateReport(assessment); !strings.Contains(report, line) {
+				t.Errorf("report has no line %q:\n%s", line, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// IngressAnnotationFinding represents an Ingress annotation affected by a required controller upgrade
+type IngressAnnotationFinding struct {
+	FindingMeta
+	Ingress     string      `json:"ingress"`
+	Annotation  string      `json:"annotation"`
+	Value       string      `json:"value"`
+	Controller  string      `json:"controller"`
+	FromVersion string      `json:"fromVersion"`
+	ToVersion   string      `json:"toVersion"`
+	Change      string      `json:"change"`
+	ChangedIn   string      `json:"changedIn"`
+	Replacement string      `json:"replacement"`
+	Notes       string      `json:"notes"`
+	Severity    ImpactLevel `json:"severity"`
+}
+
+// LoadIngressKnowledge loads the ingress controller annotation knowledge base
+func (a *Analyzer) LoadIngressKnowledge(path string) error {
+	ingressKB := knowledge.NewIngressKnowledgeBase()
+	if err := ingressKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load ingress knowledge base: %w", err)
+	}
+	a.ingressKB = ingressKB
+	return nil
+}
+
+// CheckIngressAnnotations reports Ingress annotations that are removed or change behavior between the
+// running controller version and the controller version shipped by the recommended chart upgrade
+func (a *Analyzer) CheckIngressAnnotations(state *inventory.LiveClusterState, assessment *ImpactAssessment) []IngressAnnotationFinding {
+	findings := make([]IngressAnnotationFinding, 0)
+	if a.ingressKB == nil {
+		return findings
+	}
+
+	// Detect running controllers from workload images, keeping the oldest version per controller
+	type detected struct {
+		controller *knowledge.IngressController
+		version    string
+	}
+	controllers := make(map[string]*detected)
+	for _, workload := range state.Workloads {
+		if workload.Kind != "Deployment" && workload.Kind != "DaemonSet" {
+			continue
+		}
+		for _, image := range workload.Images {
+			controller, version, found := a.ingressKB.DetectController(image)
+			if !found || version == "" {
+				continue
+			}
+			existing, ok := controllers[controller.Controller]
+			if !ok || knowledge.CompareVersions(version, existing.version) < 0 {
+				controllers[controller.Controller] = &detected{controller: controller, version: version}
+			}
+		}
+	}
+
+	for _, d := range controllers {
+		// The required controller upgrade comes from the chart recommendation
+		targetVersion := ""
+		for _, chart := range assessment.IncompatibleCharts {
+			if chart.RecommendedVersion == "" {
+				continue
+			}
+			if v := d.controller.ControllerVersionForChart(chart.ChartName, chart.RecommendedVersion); v != "" {
+				targetVersion = v
+				break
+			}
+		}
+		if targetVersion == "" {
+			continue
+		}
+
+		changes := d.controller.ChangesBetween(d.version, targetVersion)
+		if len(changes) == 0 {
+			continue
+		}
+
+		for _, ing := range state.Ingresses {
+			for _, change := range changes {
+				value, ok := ing.Annotations[change.Annotation]
+				if !ok {
+					continue
+				}
+
+				severity := ImpactMedium
+				if change.Change == "removed" {
+					severity = ImpactHigh
+				}
+
+				findings = append(findings, IngressAnnotationFinding{
+					Ingress:     ing.Namespace + "/" + ing.Name,
+					Annotation:  change.Annotation,
+					Value:       value,
+					Controller:  d.controller.Controller,
+					FromVersion: d.version,
+					ToVersion:   targetVersion,
+					Change:      change.Change,
+					ChangedIn:   change.ChangedIn,
+					Replacement: change.Replacement,
+					Notes:       change.Notes,
+					Severity:    severity,
+				})
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Ingress != findings[j].Ingress {
+			return findings[i].Ingress < findings[j].Ingress
+		}
+		return findings[i].Annotation < findings[j].Annotation
+	})
+
+	return findings
+}
@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the test cases of one rule
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a single rule/resource check
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+// JUnitFailure marks a test case as failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitSkipped marks a test case as skipped
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// RenderJUnit renders the assessment as JUnit XML with one suite per rule and one test case per finding.
+// Rules without findings get a single passing case so CI history shows them as green; findings accepted
+// by a baseline are reported as skipped rather than failed.
+func RenderJUnit(assessment *ImpactAssessment) ([]byte, error) {
+	accepted := make(map[string]bool)
+	if assessment.Baseline != nil {
+		for _, finding := range assessment.Baseline.ExistingFindings {
+			accepted[finding.Key()] = true
+		}
+	}
+
+	byRule := make(map[string][]FindingRef)
+	for _, finding := range assessment.Findings() {
+		byRule[finding.RuleID] = append(byRule[finding.RuleID], finding)
+	}
+
+	report := JUnitTestSuites{
+		Name: fmt.Sprintf("kube-upgrade-advisor %s -> %s", assessment.CurrentVersion, assessment.TargetVersion),
+	}
+
+	for _, rule := range Rules {
+		suite := JUnitTestSuite{Name: fmt.Sprintf("%s %s", rule.ID, rule.Title)}
+
+		findings := byRule[rule.ID]
+		if len(findings) == 0 {
+			suite.Cases = append(suite.Cases, JUnitTestCase{Name: rule.ID, ClassName: rule.Category})
+		}
+
+		for _, finding := range findings {
+			testCase := JUnitTestCase{Name: finding.Resource, ClassName: rule.Category + "." + rule.ID}
+			if accepted[finding.Key()] {
+				testCase.Skipped = &JUnitSkipped{Message: "accepted in baseline"}
+				suite.Skipped++
+			} else {
+				testCase.Failure = &JUnitFailure{
+					Message: finding.Summary,
+					Type:    string(finding.Severity),
+					Text:    fmt.Sprintf("%s\nSeverity: %s\nDocs: %s", finding.Summary, finding.Severity, rule.DocsURL()),
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
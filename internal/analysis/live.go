@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// LiveCheckOptions configures checks that run against live cluster state
+type LiveCheckOptions struct {
+	// SurgeNodes is the number of nodes taken out of service at once during the rolling upgrade
+	SurgeNodes int
+}
+
+// DefaultLiveCheckOptions returns the default live check options
+func DefaultLiveCheckOptions() LiveCheckOptions {
+	return LiveCheckOptions{
+		SurgeNodes: 1,
+	}
+}
+
+// ApplyLiveState enriches an assessment with checks that need live cluster state
+func (a *Analyzer) ApplyLiveState(assessment *ImpactAssessment, state *inventory.LiveClusterState, opts LiveCheckOptions) {
+	assessment.NodeDrainResults = SimulateNodeDrains(state)
+
+	for _, result := range assessment.NodeDrainResults {
+		if result.Drainable {
+			continue
+		}
+		assessment.RiskSignals = append(assessment.RiskSignals, RiskSignal{
+			Type:        "drain_blocked",
+			Severity:    ImpactHigh,
+			Description: fmt.Sprintf("Node cannot be drained: %d blocking pod(s) would stall a rolling upgrade", len(result.Blockers)),
+			Resource:    result.Node,
+		})
+	}
+
+	assessment.Headroom = CheckSurgeHeadroom(state, opts.SurgeNodes)
+	if assessment.Headroom != nil && !assessment.Headroom.Sufficient {
+		assessment.RiskSignals = append(assessment.RiskSignals, RiskSignal{
+			Type:        "insufficient_headroom",
+			Severity:    ImpactHigh,
+			Description: assessment.Headroom.Message,
+			Resource:    "cluster",
+		})
+	}
+
+	assessment.InterferingComponents = DetectInterferingComponents(state)
+	for _, component := range assessment.InterferingComponents {
+		assessment.RiskSignals = append(assessment.RiskSignals, RiskSignal{
+			Type:        "interfering_component",
+			Severity:    ImpactMedium,
+			Description: fmt.Sprintf("%s is active and may add or remove nodes during the upgrade - pause it first", component.Type),
+			Resource:    fmt.Sprintf("%s/%s", component.Namespace, component.Name),
+		})
+	}
+
+	assessment.SchedulingWarnings = CheckSchedulingConstraints(state, opts.SurgeNodes)
+
+	assessment.StorageFindings = a.CheckStorage(state, assessment.TargetVersion)
+	for _, finding := range assessment.StorageFindings {
+		if impactRank(finding.Severity) < impactRank(ImpactMedium) {
+			continue
+		}
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+
+	assessment.IngressAnnotations = a.CheckIngressAnnotations(state, assessment)
+	for _, finding := range assessment.IngressAnnotations {
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+
+	assessment.Addons = a.CheckAddons(state, assessment.TargetVersion)
+	for _, finding := range assessment.Addons {
+		if len(finding.MissingPlatforms) > 0 {
+			assessment.RiskSignals = append(assessment.RiskSignals, missingPlatformSignal(
+				finding.Namespace+"/"+finding.Workload, finding.TargetVersion, finding.MissingPlatforms))
+		}
+		if impactRank(finding.Severity) < impactRank(ImpactMedium) {
+			continue
+		}
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+
+	meshes := a.CheckMeshes(state, assessment)
+	for _, finding := range meshes {
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+	assessment.Meshes = append(assessment.Meshes, meshes...)
+
+	assessment.DevicePlugins = a.CheckDevicePlugins(state, assessment)
+	for _, finding := range assessment.DevicePlugins {
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, finding.Severity)
+	}
+
+	assessment.NodeConfigs = a.CheckNodeConfigs(state, assessment.TargetVersion)
+	for _, finding := range assessment.NodeConfigs {
+		if finding.Removed {
+			assessment.TotalIssues++
+			raiseOverallRisk(assessment, finding.Severity)
+		}
+	}
+
+	subjects := liveSubjects(state)
+	assessment.BehaviorChanges = mergeBehaviorChanges(assessment.BehaviorChanges,
+		a.checkBehaviorChanges(subjects, assessment.CurrentVersion, assessment.TargetVersion))
+
+	previous := len(assessment.PodFields)
+	assessment.PodFields = mergePodFields(assessment.PodFields, a.checkPodFields(subjects, assessment.TargetVersion))
+	for _, finding := range assessment.PodFields[previous:] {
+		if finding.Removed {
+			assessment.TotalIssues++
+			raiseOverallRisk(assessment, finding.Severity)
+		}
+	}
+
+	AssignRuleIDs(assessment)
+}
+
+// raiseOverallRisk raises the assessment's overall risk to at least the given level
+func raiseOverallRisk(assessment *ImpactAssessment, level ImpactLevel) {
+	if impactRank(level) > impactRank(assessment.OverallRisk) {
+		assessment.OverallRisk = level
+	}
+}
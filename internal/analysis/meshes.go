@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// MeshFinding is a service mesh control plane whose version does not support the target
+// Kubernetes version. BeforeUpgrade is set when the recommended mesh version also supports the
+// current Kubernetes version, so the mesh can be upgraded first; otherwise it is upgraded right
+// after the control plane.
+type MeshFinding struct {
+	FindingMeta
+	Mesh               string      `json:"mesh"`
+	Version            string      `json:"version"`
+	Source             string      `json:"source"`   // "helm" or "image"
+	Resource           string      `json:"resource"` // namespace/release or namespace/workload
+	MinKubeVersion     string      `json:"minKubeVersion"`
+	MaxKubeVersion     string      `json:"maxKubeVersion"`
+	RecommendedVersion string      `json:"recommendedVersion,omitempty"`
+	BeforeUpgrade      bool        `json:"beforeUpgrade"`
+	Canary             bool        `json:"canary"`
+	Commands           []string    `json:"commands,omitempty"`
+	Hops               []MeshHop   `json:"hops,omitempty"` // versions to upgrade through first
+	Notes              string      `json:"notes"`
+	Severity           ImpactLevel `json:"severity"`
+}
+
+// MeshHop is an intermediate mesh version to upgrade through when the recommended version is more
+// minor versions away than the mesh supports skipping
+type MeshHop struct {
+	Version  string   `json:"version"`
+	Commands []string `json:"commands,omitempty"`
+}
+
+// LoadMeshKnowledge loads the service mesh knowledge base
+func (a *Analyzer) LoadMeshKnowledge(path string) error {
+	meshKB := knowledge.NewMeshKnowledgeBase()
+	if err := meshKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load mesh knowledge base: %w", err)
+	}
+	a.meshKB = meshKB
+	return nil
+}
+
+// checkMeshReleases checks the service mesh control planes installed with Helm
+func (a *Analyzer) checkMeshReleases(releases []*ent.HelmRelease, currentVersion, targetVersion string) []MeshFinding {
+	if a.meshKB == nil {
+		return nil
+	}
+
+	var findings []MeshFinding
+	for _, release := range releases {
+		mesh, found := a.meshKB.MeshForChart(release.Chart)
+		if !found {
+			continue
+		}
+		version := knowledge.MeshVersionOf(release.AppVersion)
+		if finding, ok := newMeshFinding(mesh, version, currentVersion, targetVersion); ok {
+			finding.Source = "helm"
+			finding.Resource = release.Namespace + "/" + release.Name
+			findings = append(findings, finding)
+		}
+	}
+	return sortMeshFindings(findings)
+}
+
+// CheckMeshes checks the service mesh control planes running in the live cluster by their images,
+// for meshes not installed with Helm
+func (a *Analyzer) CheckMeshes(state *inventory.LiveClusterState, assessment *ImpactAssessment) []MeshFinding {
+	if a.meshKB == nil {
+		return nil
+	}
+
+	var findings []MeshFinding
+	seen := make(map[string]bool)
+	for _, finding := range assessment.Meshes {
+		seen[finding.Mesh] = true
+	}
+	for _, workload := range state.Workloads {
+		if workload.Kind != "Deployment" {
+			continue
+		}
+		for _, image := range workload.Images {
+			mesh, version, found := a.meshKB.DetectMesh(image)
+			if !found || seen[mesh.Name] {
+				continue
+			}
+			seen[mesh.Name] = true
+			if finding, ok := newMeshFinding(mesh, version, assessment.CurrentVersion, assessment.TargetVersion); ok {
+				finding.Source = "image"
+				finding.Resource = workload.Namespace + "/" + workload.Name
+				findings = append(findings, finding)
+			}
+			break
+		}
+	}
+	return sortMeshFindings(findings)
+}
+
+// newMeshFinding checks a mesh version against the target version; ok is false when it supports
+// the target or the version is unknown
+func newMeshFinding(mesh *knowledge.Mesh, version, currentVersion, targetVersion string) (MeshFinding, bool) {
+	supported, known := mesh.Versions.Supports(version, targetVersion)
+	if !known || supported {
+		return MeshFinding{}, false
+	}
+
+	finding := MeshFinding{
+		FindingMeta: withUpstreamDocs(mesh.DocsURL),
+		Mesh:        mesh.Name,
+		Version:     version,
+		Canary:      mesh.Canary,
+		Notes:       mesh.Notes,
+		Severity:    ImpactHigh,
+	}
+	finding.MinKubeVersion, finding.MaxKubeVersion = mesh.Versions.Range(version)
+	finding.RecommendedVersion, finding.BeforeUpgrade = mesh.Versions.Recommend(version, currentVersion, targetVersion)
+	if finding.RecommendedVersion != "" {
+		finding.Commands = meshCommands(mesh, finding.RecommendedVersion)
+		for _, hop := range mesh.Versions.Hops(version, finding.RecommendedVersion, mesh.MaxMinorSkip) {
+			finding.Hops = append(finding.Hops, MeshHop{Version: hop, Commands: meshCommands(mesh, hop)})
+		}
+	}
+	return finding, true
+}
+
+// meshCommands fills in the mesh's upgrade command templates for a version
+func meshCommands(mesh *knowledge.Mesh, version string) []string {
+	replacer := strings.NewReplacer(
+		"{version}", version,
+		"{revision}", strings.ReplaceAll(version, ".", "-"),
+	)
+	commands := make([]string, 0, len(mesh.Upgrade))
+	for _, command := range mesh.Upgrade {
+		commands = append(commands, replacer.Replace(command))
+	}
+	return commands
+}
+
+func sortMeshFindings(findings []MeshFinding) []MeshFinding {
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Mesh < findings[j].Mesh
+	})
+	return findings
+}
@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// NodeConfigFinding is a deprecated or removed kubelet or kube-proxy setting found in the running
+// configuration of a component. Sources are where it was read, e.g. a node or a kube-system
+// ConfigMap, and Values the matched settings, e.g. feature gate names.
+type NodeConfigFinding struct {
+	FindingMeta
+	Setting      string      `json:"setting"`
+	Component    string      `json:"component"`
+	Flag         string      `json:"flag"`
+	Values       []string    `json:"values,omitempty"`
+	Sources      []string    `json:"sources"`
+	DeprecatedIn string      `json:"deprecatedIn,omitempty"`
+	RemovedIn    string      `json:"removedIn,omitempty"`
+	Removed      bool        `json:"removed"`
+	Replacement  string      `json:"replacement"`
+	Severity     ImpactLevel `json:"severity"`
+	Description  string      `json:"description"`
+}
+
+// LoadNodeConfigKnowledge loads the kubelet and kube-proxy configuration deprecation knowledge base
+func (a *Analyzer) LoadNodeConfigKnowledge(path string) error {
+	nodeConfigKB := knowledge.NewNodeConfigKnowledgeBase()
+	if err := nodeConfigKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load node config knowledge base: %w", err)
+	}
+	a.nodeConfigKB = nodeConfigKB
+	return nil
+}
+
+// CheckNodeConfigs reports the kubelet and kube-proxy settings deprecated at the target version,
+// one finding per setting and component, so node bootstrap templates can be updated before the
+// upgrade. Settings removed by the target version are high severity, as the component refuses to
+// start with them; the others are low.
+func (a *Analyzer) CheckNodeConfigs(state *inventory.LiveClusterState, targetVersion string) []NodeConfigFinding {
+	if a.nodeConfigKB == nil || len(state.NodeConfigs) == 0 {
+		return nil
+	}
+
+	subjects := make([]resourceSubject, 0, len(state.NodeConfigs))
+	for _, config := range state.NodeConfigs {
+		subjects = append(subjects, resourceSubject{kind: config.Component, name: config.Source, spec: config.Config})
+	}
+
+	var findings []NodeConfigFinding
+	for _, setting := range a.nodeConfigKB.Deprecations(targetVersion) {
+		removed := setting.IsRemoved(targetVersion)
+		severity := ImpactLow
+		if removed {
+			severity = ImpactHigh
+		}
+		for _, match := range matchFields(&setting.FieldMatcher, subjects) {
+			findings = append(findings, NodeConfigFinding{
+				FindingMeta:  withUpstreamDocs(setting.DocsURL),
+				Setting:      setting.ID,
+				Component:    match.kind,
+				Flag:         setting.Flag,
+				Values:       match.values,
+				Sources:      match.resources,
+				DeprecatedIn: setting.DeprecatedIn,
+				RemovedIn:    setting.RemovedIn,
+				Removed:      removed,
+				Replacement:  setting.Replacement,
+				Severity:     severity,
+				Description:  setting.Description,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,153 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// OperatorAPIImpact represents usage of a custom resource API deprecated by an operator release
+type OperatorAPIImpact struct {
+	FindingMeta
+	Operator        string      `json:"operator"`
+	Group           string      `json:"group"`
+	Version         string      `json:"version"`
+	Kind            string      `json:"kind"`
+	Resource        string      `json:"resource"`
+	Source          string      `json:"source"` // "manifest" or "crd"
+	AffectedCount   int         `json:"affectedCount"`
+	StoredVersion   bool        `json:"storedVersion"`
+	OperatorVersion string      `json:"operatorVersion"`
+	DeprecatedIn    string      `json:"deprecatedIn"`
+	RemovedIn       string      `json:"removedIn"`
+	ReplacementAPI  string      `json:"replacementAPI"`
+	MigrationNotes  string      `json:"migrationNotes"`
+	ImpactLevel     ImpactLevel `json:"impactLevel"`
+}
+
+// LoadOperatorKnowledge loads the operator custom resource API knowledge base
+func (a *Analyzer) LoadOperatorKnowledge(path string) error {
+	operatorKB := knowledge.NewOperatorKnowledgeBase()
+	if err := operatorKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load operator knowledge base: %w", err)
+	}
+	a.operatorKB = operatorKB
+	return nil
+}
+
+// checkOperatorAPIs finds manifests and CRDs using custom resource API versions deprecated by their operator.
+// Operator releases ship independently of Kubernetes, so severity depends on the installed operator version
+// and whether the chart upgrade required for the target Kubernetes version crosses the removal.
+func (a *Analyzer) checkOperatorAPIs(manifestAPIs []*ent.ManifestAPI, crds []*ent.CRD, releases []*ent.HelmRelease, charts []ChartImpact) []OperatorAPIImpact {
+	impacts := make([]OperatorAPIImpact, 0)
+	if a.operatorKB == nil {
+		return impacts
+	}
+
+	for _, api := range manifestAPIs {
+		dep, found := a.operatorKB.CheckAPI(api.Group, api.Version, api.Kind)
+		if !found {
+			continue
+		}
+
+		impact := newOperatorAPIImpact(dep, releases, charts)
+		impact.Resource = fmt.Sprintf("%s/%s %s", api.Group, api.Version, api.Kind)
+		impact.Source = "manifest"
+		impact.AffectedCount = 1
+		impacts = append(impacts, impact)
+	}
+
+	for _, crd := range crds {
+		for _, version := range crd.Versions {
+			dep, found := a.operatorKB.CheckAPI(crd.Group, version, crd.Kind)
+			if !found {
+				continue
+			}
+
+			impact := newOperatorAPIImpact(dep, releases, charts)
+			impact.Resource = crd.Name
+			impact.Source = "crd"
+			impact.AffectedCount = crd.InstanceCount
+			impact.StoredVersion = containsString(crd.StoredVersions, version)
+
+			// Objects persisted at the old version must be migrated before the operator drops it
+			if impact.StoredVersion && dep.RemovedIn != "" && impactRank(impact.ImpactLevel) < impactRank(ImpactHigh) {
+				impact.ImpactLevel = ImpactHigh
+			}
+			impacts = append(impacts, impact)
+		}
+	}
+
+	sort.Slice(impacts, func(i, j int) bool {
+		if impacts[i].Operator != impacts[j].Operator {
+			return impacts[i].Operator < impacts[j].Operator
+		}
+		if impacts[i].Source != impacts[j].Source {
+			return impacts[i].Source < impacts[j].Source
+		}
+		return impacts[i].Resource < impacts[j].Resource
+	})
+
+	return impacts
+}
+
+// newOperatorAPIImpact builds an impact for a deprecated operator API, grading it against the installed release
+func newOperatorAPIImpact(dep *knowledge.OperatorAPI, releases []*ent.HelmRelease, charts []ChartImpact) OperatorAPIImpact {
+	impact := OperatorAPIImpact{
+		FindingMeta:    withUpstreamDocs(dep.DocsURL),
+		Operator:       dep.Operator,
+		Group:          dep.Group,
+		Version:        dep.Version,
+		Kind:           dep.Kind,
+		DeprecatedIn:   dep.DeprecatedIn,
+		RemovedIn:      dep.RemovedIn,
+		ReplacementAPI: dep.ReplacementAPI,
+		MigrationNotes: dep.MigrationNotes,
+		ImpactLevel:    ImpactMedium,
+	}
+
+	for _, release := range releases {
+		if !dep.HasChart(release.Chart) {
+			continue
+		}
+		impact.OperatorVersion = strings.TrimPrefix(release.AppVersion, "v")
+		if impact.OperatorVersion == "" {
+			impact.OperatorVersion = strings.TrimPrefix(release.ChartVersion, "v")
+		}
+		break
+	}
+
+	switch {
+	case dep.IsRemoved(impact.OperatorVersion):
+		// The installed operator no longer serves this version
+		impact.ImpactLevel = ImpactCritical
+	case dep.RemovedIn != "" && operatorUpgradeRequired(dep, charts):
+		// The chart upgrade needed for the target Kubernetes version may cross the removal
+		impact.ImpactLevel = ImpactHigh
+	}
+
+	return impact
+}
+
+// operatorUpgradeRequired checks whether the operator's chart must be upgraded for the target version
+func operatorUpgradeRequired(dep *knowledge.OperatorAPI, charts []ChartImpact) bool {
+	for _, chart := range charts {
+		if dep.HasChart(chart.ChartName) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString checks whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
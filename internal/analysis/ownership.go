@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultOwner owns what the ownership map doesn't attribute, e.g. the cluster itself
+const DefaultOwner = "platform"
+
+// OwnershipMap attributes namespaces and Helm releases to the teams owning them
+type OwnershipMap struct {
+	// Default owns cluster-wide work and unattributed namespaces; DefaultOwner when empty
+	Default string `yaml:"default"`
+	// Namespaces maps namespace names or glob patterns, e.g. "payments-*", to teams
+	Namespaces map[string]string `yaml:"namespaces"`
+	// Releases maps releases, as namespace/name, to teams
+	Releases map[string]string `yaml:"releases"`
+}
+
+// LoadOwnershipMap reads an ownership map from a YAML file
+func LoadOwnershipMap(path string) (*OwnershipMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership map: %w", err)
+	}
+
+	ownership := &OwnershipMap{}
+	if err := yaml.Unmarshal(data, ownership); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ownership map: %w", err)
+	}
+
+	for _, entries := range []map[string]string{ownership.Namespaces, ownership.Releases} {
+		for key, team := range entries {
+			if strings.TrimSpace(team) == "" {
+				return nil, fmt.Errorf("no team for %s", key)
+			}
+		}
+	}
+	return ownership, nil
+}
+
+// Owner returns the team owning a workload, given as namespace/name or a namespace: by release,
+// then by namespace, else the default team. A nil map attributes everything to DefaultOwner.
+func (m *OwnershipMap) Owner(workload string) string {
+	if m == nil {
+		return DefaultOwner
+	}
+	if team, ok := m.Releases[workload]; ok {
+		return team
+	}
+	namespace, _, _ := strings.Cut(workload, "/")
+	if namespace != "" {
+		if team, ok := matchNamespace(m.Namespaces, namespace); ok {
+			return team
+		}
+	}
+	if m.Default != "" {
+		return m.Default
+	}
+	return DefaultOwner
+}
+
+// FindingOwners returns the teams owning the namespaces and releases a finding affects, sorted, or
+// the default team when it affects none
+func (m *OwnershipMap) FindingOwners(finding FindingRef) []string {
+	var owners []string
+	seen := make(map[string]bool)
+	for _, target := range findingTargets(finding) {
+		if owner := m.Owner(target); !seen[owner] {
+			seen[owner] = true
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == 0 {
+		return []string{m.Owner("")}
+	}
+	sort.Strings(owners)
+	return owners
+}
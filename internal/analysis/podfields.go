@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// PodFieldFinding is a deprecated or removed field or value used by the pod templates of
+// resources of one kind, e.g. a beta.kubernetes.io/os node selector. Rewrites holds the node
+// labels found that the fix command can rewrite, with their replacements.
+type PodFieldFinding struct {
+	FindingMeta
+	Field        string            `json:"field"`
+	Kind         string            `json:"kind"`
+	Resources    []string          `json:"resources"`
+	DeprecatedIn string            `json:"deprecatedIn,omitempty"`
+	RemovedIn    string            `json:"removedIn,omitempty"`
+	Removed      bool              `json:"removed"`
+	Replacement  string            `json:"replacement"`
+	Rewrites     map[string]string `json:"rewrites,omitempty"`
+	Severity     ImpactLevel       `json:"severity"`
+	Description  string            `json:"description"`
+}
+
+// LoadPodFieldKnowledge loads the pod template field deprecation knowledge base
+func (a *Analyzer) LoadPodFieldKnowledge(path string) error {
+	podFieldKB := knowledge.NewPodFieldKnowledgeBase()
+	if err := podFieldKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load pod field knowledge base: %w", err)
+	}
+	a.podFieldKB = podFieldKB
+	return nil
+}
+
+// checkPodFields reports the pod templates using fields or values deprecated at the target
+// version, one finding per deprecation and kind. Those removed by the target version are high
+// severity, the others low.
+func (a *Analyzer) checkPodFields(subjects []resourceSubject, targetVersion string) []PodFieldFinding {
+	if a.podFieldKB == nil {
+		return nil
+	}
+
+	var findings []PodFieldFinding
+	for _, deprecation := range a.podFieldKB.Deprecations(targetVersion) {
+		removed := deprecation.IsRemoved(targetVersion)
+		severity := ImpactLow
+		if removed {
+			severity = ImpactHigh
+		}
+		for _, match := range matchFields(&deprecation.FieldMatcher, subjects) {
+			var rewrites map[string]string
+			for _, value := range match.values {
+				if replacement, ok := deprecation.Rewrites[value]; ok {
+					if rewrites == nil {
+						rewrites = make(map[string]string)
+					}
+					rewrites[value] = replacement
+				}
+			}
+			findings = append(findings, PodFieldFinding{
+				FindingMeta:  withUpstreamDocs(deprecation.DocsURL),
+				Field:        deprecation.ID,
+				Kind:         match.kind,
+				Resources:    match.resources,
+				DeprecatedIn: deprecation.DeprecatedIn,
+				RemovedIn:    deprecation.RemovedIn,
+				Removed:      removed,
+				Replacement:  deprecation.Replacement,
+				Rewrites:     rewrites,
+				Severity:     severity,
+				Description:  deprecation.Description,
+			})
+		}
+	}
+	return findings
+}
+
+// RewriteLabels returns the node labels the fix command can rewrite in sorted order
+func (f PodFieldFinding) RewriteLabels() []string {
+	labels := make([]string, 0, len(f.Rewrites))
+	for label := range f.Rewrites {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// mergePodFields adds findings to those already in an assessment, so a field and kind is
+// reported once
+func mergePodFields(existing, added []PodFieldFinding) []PodFieldFinding {
+	for _, finding := range added {
+		merged := false
+		for i := range existing {
+			if existing[i].Field == finding.Field && existing[i].Kind == finding.Kind {
+				existing[i].Resources = mergeResources(existing[i].Resources, finding.Resources)
+				for label, replacement := range finding.Rewrites {
+					if existing[i].Rewrites == nil {
+						existing[i].Rewrites = make(map[string]string)
+					}
+					existing[i].Rewrites[label] = replacement
+				}
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			existing = append(existing, finding)
+		}
+	}
+	return existing
+}
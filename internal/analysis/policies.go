@@ -0,0 +1,409 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"gopkg.in/yaml.v3"
+)
+
+// policyEngineGroups maps the API groups of policy engines to the engine
+var policyEngineGroups = map[string]string{
+	"templates.gatekeeper.sh":   "gatekeeper",
+	"constraints.gatekeeper.sh": "gatekeeper",
+	"kyverno.io":                "kyverno",
+}
+
+// policyEngineCharts maps the Helm charts of policy engines to the engine
+var policyEngineCharts = map[string]string{
+	"gatekeeper": "gatekeeper",
+	"kyverno":    "kyverno",
+}
+
+// restrictedVolumes are the volume types the restricted Pod Security Standard allows
+var restrictedVolumes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+// PolicyMigrationFinding reports PodSecurityPolicies deprecated or removed at the target version
+// with no policy engine to take over their enforcement. Policies holds the ValidatingAdmissionPolicies generated
+// from the PodSecurityPolicy specs found in manifests.
+type PolicyMigrationFinding struct {
+	FindingMeta
+	PodSecurityPolicies []string          `json:"podSecurityPolicies,omitempty"`
+	Policies            []GeneratedPolicy `json:"policies,omitempty"`
+	Severity            ImpactLevel       `json:"severity"`
+	Description         string            `json:"description"`
+}
+
+// GeneratedPolicy is a replacement for a PodSecurityPolicy: a ValidatingAdmissionPolicy and its
+// binding, and the Pod Security Admission level closest to it. Manifest is empty when the target
+// version has no ValidatingAdmissionPolicy API. Unsupported lists the PodSecurityPolicy fields the
+// policy does not enforce, e.g. defaulting, which admission policies cannot do.
+type GeneratedPolicy struct {
+	Name              string   `json:"name"`
+	PodSecurityPolicy string   `json:"podSecurityPolicy"`
+	Level             string   `json:"level"`
+	Manifest          string   `json:"manifest,omitempty"`
+	Unsupported       []string `json:"unsupported,omitempty"`
+}
+
+// policyEngines returns the policy engines found in the inventory: Gatekeeper or Kyverno CRDs,
+// custom resources or Helm releases, and ValidatingAdmissionPolicy manifests
+func policyEngines(manifestAPIs []*ent.ManifestAPI, crds []*ent.CRD, releases []*ent.HelmRelease) []string {
+	var engines []string
+	add := func(engine string) {
+		if engine != "" && !containsString(engines, engine) {
+			engines = append(engines, engine)
+		}
+	}
+	for _, api := range manifestAPIs {
+		add(policyEngineGroups[api.Group])
+		if api.Group == "admissionregistration.k8s.io" && api.Kind == "ValidatingAdmissionPolicy" {
+			add("validating-admission-policy")
+		}
+	}
+	for _, crd := range crds {
+		add(policyEngineGroups[crd.Group])
+	}
+	for _, release := range releases {
+		add(policyEngineCharts[release.Chart])
+	}
+	sort.Strings(engines)
+	return engines
+}
+
+// checkPolicyMigration reports the PodSecurityPolicies the target version deprecates or removes
+// when no policy engine replaces them, as pods would silently lose their restrictions. Policies are
+// generated for the PodSecurityPolicies given with their spec, i.e. parsed from manifests; those
+// stored by a scan only have their names.
+func (a *Analyzer) checkPolicyMigration(assessment *ImpactAssessment, psps []inventory.ResourceEntry, engines []string) *PolicyMigrationFinding {
+	if len(engines) > 0 {
+		return nil
+	}
+	var pspAPI *DeprecatedAPIImpact
+	for i, api := range assessment.DeprecatedManifestAPIs {
+		if api.Kind == "PodSecurityPolicy" {
+			pspAPI = &assessment.DeprecatedManifestAPIs[i]
+			break
+		}
+	}
+	if pspAPI == nil {
+		return nil
+	}
+
+	finding := &PolicyMigrationFinding{
+		Severity:    ImpactMedium,
+		Description: "PodSecurityPolicy is removed and no policy engine (Pod Security Admission policies, Kyverno or Gatekeeper) replaces it; pods lose the restrictions it enforced",
+	}
+	if pspAPI.RemovedIn != "" && knowledge.CompareVersions(assessment.TargetVersion, pspAPI.RemovedIn) < 0 {
+		finding.Severity = ImpactLow
+		finding.Description = fmt.Sprintf("PodSecurityPolicy is deprecated and removed in %s, and no policy engine (Pod Security Admission policies, Kyverno or Gatekeeper) is in place to replace it", pspAPI.RemovedIn)
+	}
+	// A policy may be both live and in a manifest; only the manifest has its spec
+	generated := make(map[string]bool)
+	for _, psp := range psps {
+		if !containsString(finding.PodSecurityPolicies, psp.Name) {
+			finding.PodSecurityPolicies = append(finding.PodSecurityPolicies, psp.Name)
+		}
+		if psp.Spec == nil || generated[psp.Name] {
+			continue
+		}
+		generated[psp.Name] = true
+		policy, err := generatePolicy(psp.Name, psp.Spec, assessment.TargetVersion)
+		if err != nil {
+			continue
+		}
+		finding.Policies = append(finding.Policies, policy)
+	}
+	sort.Strings(finding.PodSecurityPolicies)
+	return finding
+}
+
+// podSecurityPolicies returns the PodSecurityPolicy manifests among resources
+func podSecurityPolicies(resources []inventory.ResourceEntry) []inventory.ResourceEntry {
+	var psps []inventory.ResourceEntry
+	for _, resource := range resources {
+		if resource.Kind == "PodSecurityPolicy" && !resource.Templated {
+			psps = append(psps, resource)
+		}
+	}
+	return psps
+}
+
+// generatePolicy translates a PodSecurityPolicy spec into a ValidatingAdmissionPolicy and binding.
+// The binding warns and audits rather than denies, so violations can be fixed before enforcing it.
+func generatePolicy(name string, spec map[string]interface{}, targetVersion string) (GeneratedPolicy, error) {
+	policy := GeneratedPolicy{
+		Name:              "psp-" + name,
+		PodSecurityPolicy: name,
+		Level:             podSecurityLevel(spec),
+	}
+
+	validations, unsupported := pspValidations(spec)
+	policy.Unsupported = unsupported
+
+	apiVersion := ""
+	switch {
+	case knowledge.CompareVersions(targetVersion, "1.30") >= 0:
+		apiVersion = "admissionregistration.k8s.io/v1"
+	case knowledge.CompareVersions(targetVersion, "1.28") >= 0:
+		apiVersion = "admissionregistration.k8s.io/v1beta1"
+	default:
+		return policy, nil
+	}
+	if len(validations) == 0 {
+		return policy, nil
+	}
+
+	admissionPolicy := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "ValidatingAdmissionPolicy",
+		"metadata":   map[string]interface{}{"name": policy.Name},
+		"spec": map[string]interface{}{
+			"failurePolicy": "Fail",
+			"matchConstraints": map[string]interface{}{
+				"resourceRules": []interface{}{map[string]interface{}{
+					"apiGroups":   []interface{}{""},
+					"apiVersions": []interface{}{"v1"},
+					"operations":  []interface{}{"CREATE", "UPDATE"},
+					"resources":   []interface{}{"pods"},
+				}},
+			},
+			"variables": []interface{}{map[string]interface{}{
+				"name":       "containers",
+				"expression": "object.spec.containers + (has(object.spec.initContainers) ? object.spec.initContainers : [])",
+			}},
+			"validations": validations,
+		},
+	}
+	binding := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "ValidatingAdmissionPolicyBinding",
+		"metadata":   map[string]interface{}{"name": policy.Name},
+		"spec": map[string]interface{}{
+			"policyName":        policy.Name,
+			"validationActions": []interface{}{"Warn", "Audit"},
+			"matchResources": map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{
+					"matchExpressions": []interface{}{map[string]interface{}{
+						"key":      "kubernetes.io/metadata.name",
+						"operator": "NotIn",
+						"values":   []interface{}{"kube-system"},
+					}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated from PodSecurityPolicy %s. Set validationActions to [Deny] once no warnings are reported.\n", name)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, obj := range []map[string]interface{}{admissionPolicy, binding} {
+		if err := encoder.Encode(obj); err != nil {
+			return policy, fmt.Errorf("failed to encode policy %s: %w", policy.Name, err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return policy, fmt.Errorf("failed to encode policy %s: %w", policy.Name, err)
+	}
+	policy.Manifest = buf.String()
+	return policy, nil
+}
+
+// pspValidations returns the CEL validations enforcing a PodSecurityPolicy spec, and the fields
+// of the spec they leave out
+func pspValidations(spec map[string]interface{}) ([]interface{}, []string) {
+	var validations []interface{}
+	add := func(expression, message string) {
+		validations = append(validations, map[string]interface{}{"expression": expression, "message": message})
+	}
+	containerCheck := func(condition string) string {
+		return "variables.containers.all(c, " + condition + ")"
+	}
+
+	if !boolField(spec, "privileged") {
+		add(containerCheck("!has(c.securityContext) || !has(c.securityContext.privileged) || !c.securityContext.privileged"),
+			"privileged containers are not allowed")
+	}
+	for _, field := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		if !boolField(spec, field) {
+			add(fmt.Sprintf("!has(object.spec.%[1]s) || !object.spec.%[1]s", field), field+" is not allowed")
+		}
+	}
+	if escalation, ok := spec["allowPrivilegeEscalation"].(bool); ok && !escalation {
+		add(containerCheck("has(c.securityContext) && has(c.securityContext.allowPrivilegeEscalation) && !c.securityContext.allowPrivilegeEscalation"),
+			"containers must set securityContext.allowPrivilegeEscalation to false")
+	}
+	if boolField(spec, "readOnlyRootFilesystem") {
+		add(containerCheck("has(c.securityContext) && has(c.securityContext.readOnlyRootFilesystem) && c.securityContext.readOnlyRootFilesystem"),
+			"containers must set securityContext.readOnlyRootFilesystem to true")
+	}
+
+	var unsupported []string
+	if rule := ruleField(spec, "runAsUser"); rule == "MustRunAsNonRoot" {
+		add(containerCheck("has(c.securityContext) && has(c.securityContext.runAsNonRoot) ? c.securityContext.runAsNonRoot : "+
+			"(has(object.spec.securityContext) && has(object.spec.securityContext.runAsNonRoot) && object.spec.securityContext.runAsNonRoot)"),
+			"containers must run as non-root")
+	} else if rule != "" && rule != "RunAsAny" {
+		unsupported = append(unsupported, "runAsUser")
+	}
+	for _, field := range []string{"runAsGroup", "seLinux", "fsGroup", "supplementalGroups"} {
+		if rule := ruleField(spec, field); rule != "" && rule != "RunAsAny" {
+			unsupported = append(unsupported, field)
+		}
+	}
+
+	if volumes := stringList(spec["volumes"]); !containsString(volumes, "*") {
+		if len(volumes) == 0 {
+			add("!has(object.spec.volumes) || size(object.spec.volumes) == 0", "volumes are not allowed")
+		} else {
+			allowed := make([]string, 0, len(volumes))
+			for _, volume := range volumes {
+				allowed = append(allowed, "has(v."+volume+")")
+			}
+			add("!has(object.spec.volumes) || object.spec.volumes.all(v, "+strings.Join(allowed, " || ")+")",
+				"only "+strings.Join(volumes, ", ")+" volumes are allowed")
+		}
+	}
+	if hostPaths, ok := spec["allowedHostPaths"].([]interface{}); ok && len(hostPaths) > 0 {
+		var prefixes []string
+		for _, hostPath := range hostPaths {
+			entry, _ := hostPath.(map[string]interface{})
+			if prefix, ok := entry["pathPrefix"].(string); ok {
+				prefixes = append(prefixes, celString(prefix))
+			}
+			if boolField(entry, "readOnly") && !containsString(unsupported, "allowedHostPaths.readOnly") {
+				unsupported = append(unsupported, "allowedHostPaths.readOnly")
+			}
+		}
+		add("!has(object.spec.volumes) || object.spec.volumes.all(v, !has(v.hostPath) || ["+strings.Join(prefixes, ", ")+
+			"].exists(p, v.hostPath.path.startsWith(p)))", "hostPath volumes must be below an allowed path")
+	}
+
+	if drop := stringList(spec["requiredDropCapabilities"]); len(drop) > 0 {
+		add(containerCheck("has(c.securityContext) && has(c.securityContext.capabilities) && has(c.securityContext.capabilities.drop) && "+
+			"(c.securityContext.capabilities.drop.exists(d, d == 'ALL') || "+celList(drop)+".all(r, r in c.securityContext.capabilities.drop))"),
+			"containers must drop "+strings.Join(drop, ", "))
+	}
+	allowedCapabilities := append(stringList(spec["allowedCapabilities"]), stringList(spec["defaultAddCapabilities"])...)
+	if !containsString(allowedCapabilities, "*") {
+		message := "containers may not add capabilities"
+		if len(allowedCapabilities) > 0 {
+			message += " other than " + strings.Join(allowedCapabilities, ", ")
+		}
+		add(containerCheck("!has(c.securityContext) || !has(c.securityContext.capabilities) || !has(c.securityContext.capabilities.add) || "+
+			"c.securityContext.capabilities.add.all(a, a in "+celList(allowedCapabilities)+")"), message)
+	}
+	if !boolField(spec, "hostNetwork") {
+		var ranges []string
+		hostPorts, _ := spec["hostPorts"].([]interface{})
+		for _, hostPort := range hostPorts {
+			entry, _ := hostPort.(map[string]interface{})
+			ranges = append(ranges, fmt.Sprintf("(p.hostPort >= %v && p.hostPort <= %v)", entry["min"], entry["max"]))
+		}
+		condition := "p.hostPort == 0"
+		if len(ranges) > 0 {
+			condition += " || " + strings.Join(ranges, " || ")
+		}
+		add(containerCheck("!has(c.ports) || c.ports.all(p, !has(p.hostPort) || "+condition+")"), "host ports outside the allowed ranges are not allowed")
+	}
+
+	for _, field := range []string{"defaultAddCapabilities", "defaultAllowPrivilegeEscalation", "allowedProcMountTypes",
+		"allowedFlexVolumes", "allowedCSIDrivers", "allowedUnsafeSysctls", "forbiddenSysctls", "runtimeClass"} {
+		if _, ok := spec[field]; ok {
+			unsupported = append(unsupported, field)
+		}
+	}
+	return validations, unsupported
+}
+
+// podSecurityLevel returns the Pod Security Standard closest to a PodSecurityPolicy spec:
+// privileged when it allows host namespaces, privileged containers or host paths, restricted when
+// it also requires non-root users without privilege escalation and dropped capabilities, and
+// baseline otherwise
+func podSecurityLevel(spec map[string]interface{}) string {
+	volumes := stringList(spec["volumes"])
+	if boolField(spec, "privileged") || boolField(spec, "hostNetwork") || boolField(spec, "hostPID") || boolField(spec, "hostIPC") ||
+		containsString(volumes, "*") || containsString(volumes, "hostPath") || containsString(stringList(spec["allowedCapabilities"]), "*") {
+		return "privileged"
+	}
+	for _, volume := range volumes {
+		if !containsString(restrictedVolumes, volume) {
+			return "baseline"
+		}
+	}
+	escalation, ok := spec["allowPrivilegeEscalation"].(bool)
+	if ok && !escalation && ruleField(spec, "runAsUser") == "MustRunAsNonRoot" &&
+		containsString(stringList(spec["requiredDropCapabilities"]), "ALL") {
+		return "restricted"
+	}
+	return "baseline"
+}
+
+// Manifests returns the generated policies with a ValidatingAdmissionPolicy manifest
+func (f *PolicyMigrationFinding) Manifests() []GeneratedPolicy {
+	var policies []GeneratedPolicy
+	for _, policy := range f.Policies {
+		if policy.Manifest != "" {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// PolicyLevel returns the least permissive Pod Security Admission level covering every generated
+// policy; empty when none was generated
+func (f *PolicyMigrationFinding) PolicyLevel() string {
+	level := ""
+	for _, policy := range f.Policies {
+		switch {
+		case policy.Level == "privileged":
+			return policy.Level
+		case policy.Level == "baseline" || level == "":
+			level = policy.Level
+		}
+	}
+	return level
+}
+
+func boolField(object map[string]interface{}, field string) bool {
+	value, _ := object[field].(bool)
+	return value
+}
+
+// ruleField returns the rule of a PodSecurityPolicy strategy field, e.g. runAsUser.rule
+func ruleField(spec map[string]interface{}, field string) string {
+	strategy, _ := spec[field].(map[string]interface{})
+	rule, _ := strategy["rule"].(string)
+	return rule
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// celString quotes a string as a CEL literal
+func celString(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", `\'`) + "'"
+}
+
+// celList formats strings as a CEL list literal
+func celList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, celString(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
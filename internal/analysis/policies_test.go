@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"gopkg.in/yaml.v3"
+)
+
+// restrictedPSP is a PodSecurityPolicy spec close to the restricted Pod Security Standard
+var restrictedPSP = map[string]interface{}{
+	"privileged":               false,
+	"allowPrivilegeEscalation": false,
+	"runAsUser":                map[string]interface{}{"rule": "MustRunAsNonRoot"},
+	"seLinux":                  map[string]interface{}{"rule": "RunAsAny"},
+	"fsGroup":                  map[string]interface{}{"rule": "RunAsAny"},
+	"supplementalGroups":       map[string]interface{}{"rule": "RunAsAny"},
+	"requiredDropCapabilities": []interface{}{"ALL"},
+	"volumes":                  []interface{}{"configMap", "secret", "emptyDir"},
+	"allowedHostPaths":         []interface{}{map[string]interface{}{"pathPrefix": "/var/log"}},
+	"hostPorts":                []interface{}{map[string]interface{}{"min": 8000, "max": 8080}},
+}
+
+// policyPod returns a pod meeting restrictedPSP, with its container's securityContext amended
+func policyPod(securityContext map[string]interface{}) map[string]interface{} {
+	context := map[string]interface{}{
+		"allowPrivilegeEscalation": false,
+		"runAsNonRoot":             true,
+		"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+	}
+	for key, value := range securityContext {
+		context[key] = value
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{
+				"name":            "app",
+				"image":           "registry.example.com/app:1.0",
+				"ports":           []interface{}{map[string]interface{}{"containerPort": 8080}},
+				"securityContext": context,
+			}},
+			"volumes": []interface{}{map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "app"}}},
+		},
+	}
+}
+
+func TestGeneratedPolicyCEL(t *testing.T) {
+	policy, err := generatePolicy("restricted", restrictedPSP, "1.30")
+	if err != nil {
+		t.Fatalf("generatePolicy: %v", err)
+	}
+	if policy.Level != "restricted" {
+		t.Errorf("level = %s, want restricted", policy.Level)
+	}
+	var admissionPolicy struct {
+		Spec struct {
+			Variables   []struct{ Name, Expression string }
+			Validations []struct{ Expression, Message string }
+		}
+	}
+	if err := yaml.NewDecoder(strings.NewReader(policy.Manifest)).Decode(&admissionPolicy); err != nil {
+		t.Fatalf("failed to decode the policy: %v", err)
+	}
+	spec := admissionPolicy.Spec
+	if len(spec.Variables) != 1 || len(spec.Validations) == 0 {
+		t.Fatalf("policy has %d variables and %d validations", len(spec.Variables), len(spec.Validations))
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compile := func(expression string) cel.Program {
+		t.Helper()
+		ast, issues := env.Compile(expression)
+		if issues.Err() != nil {
+			t.Fatalf("expression %q does not compile: %v", expression, issues.Err())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			t.Fatalf("expression %q: %v", expression, err)
+		}
+		return program
+	}
+	containers := compile(spec.Variables[0].Expression)
+	validations := make([]cel.Program, len(spec.Validations))
+	for i, validation := range spec.Validations {
+		validations[i] = compile(validation.Expression)
+	}
+
+	// failed returns the messages of the validations a pod fails
+	failed := func(pod map[string]interface{}) []string {
+		t.Helper()
+		value, _, err := containers.Eval(map[string]interface{}{"object": pod, "variables": map[string]interface{}{}})
+		if err != nil {
+			t.Fatalf("failed to evaluate the containers variable: %v", err)
+		}
+		activation := map[string]interface{}{"object": pod, "variables": map[string]interface{}{"containers": value}}
+		var messages []string
+		for i, program := range validations {
+			out, _, err := program.Eval(activation)
+			if err != nil {
+				t.Fatalf("failed to evaluate %q: %v", spec.Validations[i].Expression, err)
+			}
+			if allowed, _ := out.Value().(bool); !allowed {
+				messages = append(messages, spec.Validations[i].Message)
+			}
+		}
+		return messages
+	}
+
+	if messages := failed(policyPod(nil)); len(messages) > 0 {
+		t.Errorf("compliant pod fails %v", messages)
+	}
+	messages := failed(policyPod(map[string]interface{}{"privileged": true}))
+	if len(messages) != 1 || messages[0] != "privileged containers are not allowed" {
+		t.Errorf("privileged pod fails %v, want only the privileged check", messages)
+	}
+}
+
+func TestCheckPolicyMigrationWording(t *testing.T) {
+	psps := []inventory.ResourceEntry{
+		{APIVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", Name: "restricted"},
+		// The same policy, live and in a manifest
+		{APIVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", Name: "restricted", Spec: restrictedPSP},
+	}
+	tests := []struct {
+		target   string
+		severity ImpactLevel
+		wording  string
+	}{
+		{"1.23", ImpactLow, "deprecated"},
+		{"1.25", ImpactMedium, "is removed"},
+	}
+	for _, tt := range tests {
+		assessment := &ImpactAssessment{
+			TargetVersion: tt.target,
+			DeprecatedManifestAPIs: []DeprecatedAPIImpact{{
+				Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy", RemovedIn: "1.25",
+			}},
+		}
+		finding := (&Analyzer{}).checkPolicyMigration(assessment, psps, nil)
+		if finding == nil {
+			t.Fatalf("target %s: no finding", tt.target)
+		}
+		if finding.Severity != tt.severity || !strings.Contains(finding.Description, tt.wording) {
+			t.Errorf("target %s: %s %q, want %s and %q", tt.target, finding.Severity, finding.Description, tt.severity, tt.wording)
+		}
+		if len(finding.PodSecurityPolicies) != 1 || len(finding.Policies) != 1 {
+			t.Errorf("target %s: PodSecurityPolicies = %v with %d policies, want restricted once", tt.target, finding.PodSecurityPolicies, len(finding.Policies))
+		}
+	}
+
+	if finding := (&Analyzer{}).checkPolicyMigration(&ImpactAssessment{TargetVersion: "1.25"}, psps, []string{"kyverno"}); finding != nil {
+		t.Errorf("finding with Kyverno in place: %+v", finding)
+	}
+}
@@ -0,0 +1,501 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/convert"
+)
+
+// rulesDocsURL is the base URL of the rule catalog; rule anchors are lowercased IDs
+const rulesDocsURL = "https://github.com/retr0-kernel/kube-upgrade-advisor/blob/main/docs/rules.md#"
+
+// Rule describes a stable finding type that automation can key suppressions and fixes off
+type Rule struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+}
+
+// Rule IDs are stable: never renumber or reuse an ID, only add new ones
+var (
+	RuleRemovedManifestAPI    = Rule{"KUA-API-001", "api", "Manifest uses an API removed in the target version"}
+	RuleRemovedCRDAPI         = Rule{"KUA-API-002", "api", "CRD serves an API version removed in the target version"}
+	RuleUnservedAPI           = Rule{"KUA-API-003", "api", "Manifest uses an API the cluster does not serve today"}
+	RuleOperatorAPI           = Rule{"KUA-OPR-001", "operator", "Custom resource API deprecated or removed by its operator"}
+	RuleCRDScope              = Rule{"KUA-CRD-001", "crd", "Manifest sets a namespace on a cluster-scoped custom resource"}
+	RuleCRDTerminatingNS      = Rule{"KUA-CRD-002", "crd", "Custom resource is applied to a namespace being deleted"}
+	RuleConversionWebhook     = Rule{"KUA-CRD-003", "crd", "CRD conversion webhook has no service to serve it"}
+	RuleCRDDeprecatedVersion  = Rule{"KUA-CRD-004", "crd", "CRD version is deprecated by its definition"}
+	RuleCRDStoredVersion      = Rule{"KUA-CRD-005", "crd", "Objects are stored at a version other than the CRD's storage version"}
+	RulePolicyMigration       = Rule{"KUA-POL-001", "policy", "PodSecurityPolicy is removed with no policy engine to replace it"}
+	RuleBehaviorChange        = Rule{"KUA-BHV-001", "behavior", "Resource sets a field whose behavior changes in the target version"}
+	RuleRemovedPodField       = Rule{"KUA-FLD-001", "field", "Pod template uses a field or value removed in the target version"}
+	RuleDeprecatedPodField    = Rule{"KUA-FLD-002", "field", "Pod template uses a deprecated field or value"}
+	RuleHPAConversion         = Rule{"KUA-HPA-001", "hpa", "HorizontalPodAutoscaler uses a beta autoscaling API"}
+	RuleHPAInvalidMetrics     = Rule{"KUA-HPA-002", "hpa", "HorizontalPodAutoscaler metrics are invalid in autoscaling/v2"}
+	RuleMeshUnsupported       = Rule{"KUA-MSH-001", "mesh", "Service mesh version does not support the target version"}
+	RuleIncompatibleChart     = Rule{"KUA-CHT-001", "chart", "Helm chart is incompatible with the target version"}
+	RuleUnknownChart          = Rule{"KUA-CHT-002", "chart", "Helm chart is not in the compatibility matrix"}
+	RuleChartCaveats          = Rule{"KUA-CHT-003", "chart", "Helm chart is compatible with known issues"}
+	RuleUnhealthyRelease      = Rule{"KUA-CHT-004", "chart", "Helm release is failed or pending"}
+	RuleDrainBlocked          = Rule{"KUA-DRN-001", "drain", "Node cannot be drained"}
+	RuleDrainPDB              = Rule{"KUA-DRN-002", "drain", "PodDisruptionBudget allows no disruptions"}
+	RuleDrainLocalStorage     = Rule{"KUA-DRN-003", "drain", "Pod uses local storage"}
+	RuleDrainUnmanaged        = Rule{"KUA-DRN-004", "drain", "Pod is not managed by a controller"}
+	RuleInsufficientHeadroom  = Rule{"KUA-CAP-001", "capacity", "Insufficient capacity to take nodes out of service"}
+	RuleInterferingComponent  = Rule{"KUA-SCH-001", "scheduling", "Autoscaler or descheduler active during the upgrade"}
+	RuleTopologySpread        = Rule{"KUA-SCH-002", "scheduling", "Hard topology spread constraint can stall evicted pods"}
+	RulePodAntiAffinity       = Rule{"KUA-SCH-003", "scheduling", "Required pod anti-affinity can stall evicted pods"}
+	RuleInTreeStorageClass    = Rule{"KUA-STO-001", "storage", "StorageClass uses an in-tree volume plugin"}
+	RuleInTreeVolume          = Rule{"KUA-STO-002", "storage", "PersistentVolume uses an in-tree volume plugin"}
+	RuleStatefulSetStorage    = Rule{"KUA-STO-003", "storage", "StatefulSet provisions from an in-tree volume plugin"}
+	RuleDeprecatedStorageAnno = Rule{"KUA-STO-004", "storage", "Deprecated storage annotation"}
+	RuleIngressAnnoRemoved    = Rule{"KUA-ING-001", "ingress", "Ingress annotation removed by the controller upgrade"}
+	RuleIngressAnnoBehavior   = Rule{"KUA-ING-002", "ingress", "Ingress annotation changes behavior in the controller upgrade"}
+	RuleAddonOutdated         = Rule{"KUA-ADD-001", "addon", "kube-system addon is older than the target version's default"}
+	RuleRemovedNodeConfig     = Rule{"KUA-NOD-001", "node", "kubelet or kube-proxy uses a setting removed in the target version"}
+	RuleDeprecatedNodeConfig  = Rule{"KUA-NOD-002", "node", "kubelet or kube-proxy uses a deprecated setting"}
+	RuleDevicePlugin          = Rule{"KUA-GPU-001", "device", "Device plugin or GPU operator version does not support the target version"}
+	RuleDryRunRejected        = Rule{"KUA-VAL-001", "validation", "Manifest rejected by a server-side dry run"}
+	RuleMissingPlatformImage  = Rule{"KUA-PLT-001", "platform", "Recommended version publishes no images for a node platform"}
+	RuleDestructiveDrift      = Rule{"KUA-DRF-001", "drift", "Reconciling a drifted Helm release would delete or recreate resources"}
+	RuleReleaseDrift          = Rule{"KUA-DRF-002", "drift", "Helm release differs from its Git source"}
+)
+
+// Rules lists every rule in ID order
+var Rules = []Rule{
+	RuleRemovedManifestAPI,
+	RuleRemovedCRDAPI,
+	RuleUnservedAPI,
+	RuleOperatorAPI,
+	RuleCRDScope,
+	RuleCRDTerminatingNS,
+	RuleConversionWebhook,
+	RuleCRDDeprecatedVersion,
+	RuleCRDStoredVersion,
+	RulePolicyMigration,
+	RuleBehaviorChange,
+	RuleRemovedPodField,
+	RuleDeprecatedPodField,
+	RuleHPAConversion,
+	RuleHPAInvalidMetrics,
+	RuleMeshUnsupported,
+	RuleIncompatibleChart,
+	RuleUnknownChart,
+	RuleChartCaveats,
+	RuleUnhealthyRelease,
+	RuleDrainBlocked,
+	RuleDrainPDB,
+	RuleDrainLocalStorage,
+	RuleDrainUnmanaged,
+	RuleInsufficientHeadroom,
+	RuleInterferingComponent,
+	RuleTopologySpread,
+	RulePodAntiAffinity,
+	RuleInTreeStorageClass,
+	RuleInTreeVolume,
+	RuleStatefulSetStorage,
+	RuleDeprecatedStorageAnno,
+	RuleIngressAnnoRemoved,
+	RuleIngressAnnoBehavior,
+	RuleAddonOutdated,
+	RuleRemovedNodeConfig,
+	RuleDeprecatedNodeConfig,
+	RuleDevicePlugin,
+	RuleDryRunRejected,
+	RuleMissingPlatformImage,
+	RuleDestructiveDrift,
+	RuleReleaseDrift,
+}
+
+// FindingMeta carries the stable identity of a finding; it is embedded in every finding type
+type FindingMeta struct {
+	RuleID      string       `json:"ruleId,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	DocsURL     string       `json:"docsUrl,omitempty"`
+	Remediation *Remediation `json:"remediation,omitempty"`
+	// UpstreamDocs links the authoritative migration guides for the finding, e.g. the section of
+	// the Kubernetes deprecation guide or a chart's upgrade notes
+	UpstreamDocs []string `json:"upstreamDocs,omitempty"`
+}
+
+// Remediation is machine-readable fix metadata; commands are templates with <placeholders>
+type Remediation struct {
+	ReplacementGroup   string   `json:"replacementGroup,omitempty"`
+	ReplacementVersion string   `json:"replacementVersion,omitempty"`
+	ReplacementKind    string   `json:"replacementKind,omitempty"`
+	Commands           []string `json:"commands,omitempty"`
+	Manual             bool     `json:"manual"`
+}
+
+// RuleByID looks up a rule by its ID
+func RuleByID(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// DocsURL returns the rule's entry in the rule catalog
+func (r Rule) DocsURL() string {
+	return rulesDocsURL + strings.ToLower(r.ID)
+}
+
+// assign sets the rule metadata of a finding, keeping the upstream documentation links the check
+// that produced it found in the knowledge base
+func (m *FindingMeta) assign(rule Rule, remediation *Remediation) {
+	m.RuleID = rule.ID
+	m.Category = rule.Category
+	m.DocsURL = rule.DocsURL()
+	m.Remediation = remediation
+}
+
+// meta returns the metadata of the finding embedding it
+func (m FindingMeta) meta() FindingMeta {
+	return m
+}
+
+// findingSource is implemented by every finding type through its embedded FindingMeta
+type findingSource interface {
+	meta() FindingMeta
+}
+
+// withUpstreamDocs returns finding metadata linking the known upstream guides, skipping empty links
+func withUpstreamDocs(urls ...string) FindingMeta {
+	var meta FindingMeta
+	for _, url := range urls {
+		if url != "" {
+			meta.UpstreamDocs = append(meta.UpstreamDocs, url)
+		}
+	}
+	return meta
+}
+
+// replacementRemediation builds a remediation pointing at a replacement API, which is only
+// machine-readable when it is an apiVersion (e.g. "networking.k8s.io/v1" or "v1")
+func replacementRemediation(replacementAPI, kind string, commands ...string) *Remediation {
+	remediation := &Remediation{Commands: commands}
+
+	if group, version, ok := ParseAPIVersion(replacementAPI); ok {
+		remediation.ReplacementGroup = group
+		remediation.ReplacementVersion = version
+		remediation.ReplacementKind = kind
+	} else {
+		// Replaced by a different mechanism, e.g. PodSecurityPolicy -> Pod Security Admission
+		remediation.Manual = true
+	}
+
+	return remediation
+}
+
+// conversionCommands returns the ways to rewrite resources to a replacement API: the built-in converter
+// when there is one, reading them back from the API server at the new version (once it is served), or
+// the kubectl-convert plugin, which replaced the kubectl convert command removed in kubectl 1.22
+func conversionCommands(api DeprecatedAPIImpact) []string {
+	group, version, ok := ParseAPIVersion(api.ReplacementAPI)
+	if !ok {
+		return nil
+	}
+
+	var commands []string
+	if _, found := convert.Lookup(GroupVersion(api.Group, api.Version), api.Kind); found {
+		commands = append(commands, "kube-upgrade-advisor fix --write <manifest>")
+	}
+
+	resource := strings.ToLower(api.Kind)
+	if group != "" {
+		resource = fmt.Sprintf("%s.%s.%s", resource, version, group)
+	}
+	return append(commands,
+		fmt.Sprintf("kubectl get %s --all-namespaces -o yaml > <converted-file>", resource),
+		fmt.Sprintf("kubectl-convert -f <manifest> --output-version %s", api.ReplacementAPI),
+	)
+}
+
+// AssignRuleIDs attaches rule IDs, docs links and remediation metadata to every finding in an assessment.
+// It is idempotent, so it can run again after live checks add findings.
+func AssignRuleIDs(assessment *ImpactAssessment) {
+	for i := range assessment.DeprecatedManifestAPIs {
+		api := &assessment.DeprecatedManifestAPIs[i]
+		api.assign(RuleRemovedManifestAPI, replacementRemediation(api.ReplacementAPI, api.Kind,
+			conversionCommands(*api)...))
+	}
+
+	for i := range assessment.DeprecatedCRDAPIs {
+		api := &assessment.DeprecatedCRDAPIs[i]
+		api.assign(RuleRemovedCRDAPI, replacementRemediation(api.ReplacementAPI, api.Kind,
+			fmt.Sprintf("kubectl get %s.%s --all-namespaces -o yaml > <backup-file>", strings.ToLower(api.Kind), api.Group)))
+	}
+
+	for i := range assessment.DeprecatedOperatorAPIs {
+		api := &assessment.DeprecatedOperatorAPIs[i]
+		api.assign(RuleOperatorAPI, replacementRemediation(api.ReplacementAPI, api.Kind,
+			fmt.Sprintf("kubectl get %s.%s --all-namespaces -o yaml > <backup-file>", strings.ToLower(api.Kind), api.Group)))
+	}
+
+	for i := range assessment.CRDFindings {
+		finding := &assessment.CRDFindings[i]
+		switch finding.Problem {
+		case CRDProblemScope:
+			finding.assign(RuleCRDScope, &Remediation{Manual: true})
+		case CRDProblemTerminatingNamespace:
+			finding.assign(RuleCRDTerminatingNS, &Remediation{
+				Commands: []string{"kubectl get namespaces --field-selector status.phase=Terminating"},
+				Manual:   true,
+			})
+		case CRDProblemConversionWebhook:
+			remediation := &Remediation{Manual: true}
+			if namespace, name, ok := strings.Cut(finding.Resource, "/"); ok && !strings.Contains(finding.Resource, "://") {
+				remediation.Commands = []string{
+					fmt.Sprintf("kubectl get service,endpoints %s -n %s", name, namespace),
+					fmt.Sprintf("kubectl get crd %s -o jsonpath='{.spec.conversion}'", finding.CRD),
+				}
+			}
+			finding.assign(RuleConversionWebhook, remediation)
+		case CRDProblemDeprecatedVersion:
+			finding.assign(RuleCRDDeprecatedVersion, &Remediation{
+				Commands: []string{fmt.Sprintf("kubectl get crd %s -o jsonpath='{.spec.versions[*].name}'", finding.CRD)},
+				Manual:   true,
+			})
+		case CRDProblemStoredVersion:
+			finding.assign(RuleCRDStoredVersion, &Remediation{
+				Commands: []string{fmt.Sprintf("kubectl get crd %s -o jsonpath='{.status.storedVersions}'", finding.CRD)},
+				Manual:   true,
+			})
+		}
+	}
+
+	if finding := assessment.PolicyMigration; finding != nil {
+		remediation := &Remediation{Manual: true}
+		if len(finding.Policies) > 0 {
+			if len(finding.Manifests()) > 0 {
+				remediation.Commands = []string{"kubectl apply -f <policy-dir>"}
+			}
+			remediation.Commands = append(remediation.Commands,
+				fmt.Sprintf("kubectl label namespace <namespace> pod-security.kubernetes.io/enforce=%s", finding.PolicyLevel()))
+		} else {
+			remediation.Commands = []string{"kube-upgrade-advisor impact --target <version> --manifests <psp-manifests> --policy-dir <policy-dir>"}
+		}
+		finding.assign(RulePolicyMigration, remediation)
+	}
+
+	for i := range assessment.BehaviorChanges {
+		finding := &assessment.BehaviorChanges[i]
+		finding.assign(RuleBehaviorChange, &Remediation{Manual: true})
+	}
+
+	for i := range assessment.PodFields {
+		finding := &assessment.PodFields[i]
+		rule := RuleDeprecatedPodField
+		if finding.Removed {
+			rule = RuleRemovedPodField
+		}
+		remediation := &Remediation{Manual: len(finding.Rewrites) == 0}
+		if len(finding.Rewrites) > 0 {
+			remediation.Commands = []string{"kube-upgrade-advisor fix --write <manifest>"}
+		}
+		finding.assign(rule, remediation)
+	}
+
+	for i := range assessment.HPAs {
+		finding := &assessment.HPAs[i]
+		if finding.ReplacementAPI == "" {
+			finding.assign(RuleHPAInvalidMetrics, &Remediation{Manual: true})
+			continue
+		}
+		source := finding.Source
+		if source == "" {
+			source = "<manifest>"
+		}
+		finding.assign(RuleHPAConversion, &Remediation{
+			Commands: []string{fmt.Sprintf("kube-upgrade-advisor fix --write %s", source)},
+			Manual:   len(finding.Notes) > 0,
+		})
+	}
+
+	for i := range assessment.Meshes {
+		finding := &assessment.Meshes[i]
+		var commands []string
+		for _, hop := range finding.Hops {
+			commands = append(commands, hop.Commands...)
+		}
+		commands = append(commands, finding.Commands...)
+		finding.assign(RuleMeshUnsupported, &Remediation{
+			Commands: commands,
+			Manual:   len(commands) == 0,
+		})
+	}
+
+	for i := range assessment.IncompatibleCharts {
+		chart := &assessment.IncompatibleCharts[i]
+		remediation := &Remediation{Manual: chart.RecommendedVersion == ""}
+		if chart.RecommendedVersion != "" {
+			remediation.Commands = []string{
+				fmt.Sprintf("helm upgrade <release> <repo>/%s --version %s -n %s --reuse-values%s", chart.ChartName, chart.RecommendedVersion, chart.Namespace, chart.SetFlags()),
+			}
+		}
+		chart.assign(RuleIncompatibleChart, remediation)
+	}
+
+	for i := range assessment.ChartCaveats {
+		chart := &assessment.ChartCaveats[i]
+		remediation := &Remediation{Manual: true}
+		if chart.RecommendedVersion != "" {
+			remediation.Commands = []string{
+				fmt.Sprintf("helm upgrade <release> <repo>/%s --version %s -n %s --reuse-values", chart.ChartName, chart.RecommendedVersion, chart.Namespace),
+			}
+		}
+		chart.assign(RuleChartCaveats, remediation)
+	}
+
+	for i := range assessment.RiskSignals {
+		signal := &assessment.RiskSignals[i]
+		switch signal.Type {
+		case "unknown_chart":
+			signal.assign(RuleUnknownChart, &Remediation{Manual: true})
+		case "unhealthy_release":
+			namespace, name, _ := strings.Cut(signal.Resource, "/")
+			signal.assign(RuleUnhealthyRelease, &Remediation{
+				Commands: []string{fmt.Sprintf("helm history %s -n %s", name, namespace)},
+				Manual:   true,
+			})
+		case "unserved_api":
+			signal.assign(RuleUnservedAPI, &Remediation{
+				Commands: []string{"kubectl api-versions", "kubectl api-resources"},
+				Manual:   true,
+			})
+		case "drain_blocked":
+			signal.assign(RuleDrainBlocked, &Remediation{
+				Commands: []string{fmt.Sprintf("kubectl drain %s --ignore-daemonsets --dry-run=server", signal.Resource)},
+			})
+		case "insufficient_headroom":
+			signal.assign(RuleInsufficientHeadroom, &Remediation{Manual: true})
+		case "missing_platform_image":
+			signal.assign(RuleMissingPlatformImage, &Remediation{
+				Commands: []string{"docker manifest inspect <image>"},
+				Manual:   true,
+			})
+		case "interfering_component":
+			signal.assign(RuleInterferingComponent, &Remediation{
+				Commands: []string{"kubectl scale deployment <name> -n <namespace> --replicas=0"},
+			})
+		}
+	}
+
+	for i := range assessment.NodeDrainResults {
+		for j := range assessment.NodeDrainResults[i].Blockers {
+			blocker := &assessment.NodeDrainResults[i].Blockers[j]
+			switch blocker.Reason {
+			case DrainBlockPDB:
+				blocker.assign(RuleDrainPDB, &Remediation{
+					Commands: []string{fmt.Sprintf("kubectl get pdb -n %s", blocker.Namespace)},
+					Manual:   true,
+				})
+			case DrainBlockLocalStorage:
+				blocker.assign(RuleDrainLocalStorage, &Remediation{
+					Commands: []string{"kubectl drain <node> --ignore-daemonsets --delete-emptydir-data"},
+				})
+			case DrainBlockUnmanaged:
+				blocker.assign(RuleDrainUnmanaged, &Remediation{Manual: true})
+			}
+		}
+	}
+
+	for i := range assessment.SchedulingWarnings {
+		warning := &assessment.SchedulingWarnings[i]
+		rule := RuleTopologySpread
+		if warning.Type == "pod_anti_affinity" {
+			rule = RulePodAntiAffinity
+		}
+		warning.assign(rule, &Remediation{Manual: true})
+	}
+
+	for i := range assessment.StorageFindings {
+		finding := &assessment.StorageFindings[i]
+		var rule Rule
+		switch finding.Type {
+		case "in_tree_storage_class":
+			rule = RuleInTreeStorageClass
+		case "in_tree_volume":
+			rule = RuleInTreeVolume
+		case "statefulset_storage":
+			rule = RuleStatefulSetStorage
+		default:
+			rule = RuleDeprecatedStorageAnno
+		}
+		finding.assign(rule, &Remediation{Manual: true})
+	}
+
+	for i := range assessment.IngressAnnotations {
+		finding := &assessment.IngressAnnotations[i]
+		rule := RuleIngressAnnoBehavior
+		if finding.Change == "removed" {
+			rule = RuleIngressAnnoRemoved
+		}
+		remediation := &Remediation{Manual: true}
+		if finding.Replacement != "" {
+			remediation.Commands = []string{
+				fmt.Sprintf("kubectl annotate ingress <name> -n <namespace> %s-", finding.Annotation),
+			}
+		}
+		finding.assign(rule, remediation)
+	}
+
+	for i := range assessment.Addons {
+		finding := &assessment.Addons[i]
+		finding.assign(RuleAddonOutdated, &Remediation{
+			Commands: finding.Commands,
+			Manual:   len(finding.Commands) == 0 && !finding.Managed,
+		})
+	}
+
+	for i := range assessment.NodeConfigs {
+		finding := &assessment.NodeConfigs[i]
+		rule := RuleDeprecatedNodeConfig
+		if finding.Removed {
+			rule = RuleRemovedNodeConfig
+		}
+		finding.assign(rule, &Remediation{Manual: true})
+	}
+
+	for i := range assessment.DevicePlugins {
+		finding := &assessment.DevicePlugins[i]
+		finding.assign(RuleDevicePlugin, &Remediation{
+			Commands: finding.Commands,
+			Manual:   len(finding.Commands) == 0,
+		})
+	}
+
+	for i := range assessment.ValidationResults {
+		result := &assessment.ValidationResults[i]
+		if result.Passed {
+			continue
+		}
+		file := result.SourceFile
+		if file == "" {
+			file = "<manifest>"
+		}
+		result.assign(RuleDryRunRejected, &Remediation{
+			Commands: []string{fmt.Sprintf("kubectl apply --dry-run=server -f %s", file)},
+			Manual:   true,
+		})
+	}
+
+	for i := range assessment.ReleaseDrift {
+		drift := &assessment.ReleaseDrift[i]
+		if drift.Severity == ImpactNone || drift.Error != "" {
+			continue
+		}
+		rule := RuleReleaseDrift
+		if len(drift.Destructive) > 0 {
+			rule = RuleDestructiveDrift
+		}
+		drift.assign(rule, &Remediation{
+			Commands: []string{fmt.Sprintf("helm diff upgrade %s <chart> --version %s -n %s", drift.Release, drift.PinnedVersion, drift.Namespace)},
+			Manual:   true,
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+const hostnameTopologyKey = "kubernetes.io/hostname"
+
+// InterferingComponent represents a controller that can fight node replacement during an upgrade
+type InterferingComponent struct {
+	Type      string `json:"type"` // cluster-autoscaler, karpenter or descheduler
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// SchedulingWarning represents a scheduling rule that can stall pods evicted during an upgrade
+type SchedulingWarning struct {
+	FindingMeta
+	Type           string      `json:"type"` // topology_spread or pod_anti_affinity
+	Resource       string      `json:"resource"`
+	Severity       ImpactLevel `json:"severity"`
+	Description    string      `json:"description"`
+	Recommendation string      `json:"recommendation"`
+}
+
+// interferingComponentPatterns maps name/image fragments to the component they identify
+var interferingComponentPatterns = []struct {
+	pattern       string
+	componentType string
+}{
+	{"cluster-autoscaler", "cluster-autoscaler"},
+	{"karpenter", "karpenter"},
+	{"descheduler", "descheduler"},
+}
+
+// DetectInterferingComponents finds autoscalers and deschedulers that are currently active
+func DetectInterferingComponents(state *inventory.LiveClusterState) []InterferingComponent {
+	components := make([]InterferingComponent, 0)
+
+	for _, workload := range state.Workloads {
+		if workload.Kind != "Deployment" && workload.Kind != "CronJob" {
+			continue
+		}
+		// Already paused
+		if workload.Suspended || (workload.Kind == "Deployment" && workload.Replicas == 0) {
+			continue
+		}
+
+		componentType := matchInterferingComponent(workload)
+		if componentType == "" {
+			continue
+		}
+
+		components = append(components, InterferingComponent{
+			Type:      componentType,
+			Kind:      workload.Kind,
+			Name:      workload.Name,
+			Namespace: workload.Namespace,
+			Replicas:  workload.Replicas,
+		})
+	}
+
+	return components
+}
+
+// matchInterferingComponent returns the component type a workload is identified as, if any
+func matchInterferingComponent(workload inventory.WorkloadEntry) string {
+	for _, p := range interferingComponentPatterns {
+		if strings.Contains(workload.Name, p.pattern) {
+			return p.componentType
+		}
+		for _, image := range workload.Images {
+			if strings.Contains(image, p.pattern) {
+				return p.componentType
+			}
+		}
+	}
+	return ""
+}
+
+// CheckSchedulingConstraints flags workloads whose hard spread or anti-affinity rules leave
+// evicted pods nowhere to go while surgeNodes nodes are out of service
+func CheckSchedulingConstraints(state *inventory.LiveClusterState, surgeNodes int) []SchedulingWarning {
+	schedulableNodes := 0
+	for _, node := range state.Nodes {
+		if node.Ready && !node.Unschedulable {
+			schedulableNodes++
+		}
+	}
+
+	type ownerConstraints struct {
+		pods         int
+		spreadKeys   map[string]bool
+		antiAffinity map[string]bool
+	}
+
+	owners := make(map[string]*ownerConstraints)
+	for _, pod := range state.Pods {
+		if pod.OwnerKind == "" || pod.OwnerKind == "DaemonSet" {
+			continue
+		}
+		if len(pod.HardSpreadKeys) == 0 && len(pod.RequiredAntiAffinity) == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.OwnerKind, pod.OwnerName)
+		owner, ok := owners[key]
+		if !ok {
+			owner = &ownerConstraints{
+				spreadKeys:   make(map[string]bool),
+				antiAffinity: make(map[string]bool),
+			}
+			owners[key] = owner
+		}
+		owner.pods++
+		for _, k := range pod.HardSpreadKeys {
+			owner.spreadKeys[k] = true
+		}
+		for _, k := range pod.RequiredAntiAffinity {
+			owner.antiAffinity[k] = true
+		}
+	}
+
+	warnings := make([]SchedulingWarning, 0)
+	remainingNodes := schedulableNodes - surgeNodes
+
+	for resource, owner := range owners {
+		// One pod per node: evicted pods need a free node to land on
+		noSpareNode := owner.pods > remainingNodes
+
+		if owner.antiAffinity[hostnameTopologyKey] {
+			severity := ImpactMedium
+			if noSpareNode {
+				severity = ImpactHigh
+			}
+			warnings = append(warnings, SchedulingWarning{
+				Type:           "pod_anti_affinity",
+				Resource:       resource,
+				Severity:       severity,
+				Description:    fmt.Sprintf("%d pod(s) with required per-node anti-affinity across %d schedulable node(s)", owner.pods, schedulableNodes),
+				Recommendation: "Switch to preferredDuringScheduling anti-affinity or add a node before draining",
+			})
+		}
+
+		for key := range owner.spreadKeys {
+			severity := ImpactMedium
+			if key == hostnameTopologyKey && noSpareNode {
+				severity = ImpactHigh
+			}
+			warnings = append(warnings, SchedulingWarning{
+				Type:           "topology_spread",
+				Resource:       resource,
+				Severity:       severity,
+				Description:    fmt.Sprintf("DoNotSchedule topology spread on %s can leave evicted pods Pending", key),
+				Recommendation: "Temporarily use whenUnsatisfiable: ScheduleAnyway or replace nodes one topology domain at a time",
+			})
+		}
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Resource != warnings[j].Resource {
+			return warnings[i].Resource < warnings[j].Resource
+		}
+		return warnings[i].Description < warnings[j].Description
+	})
+
+	return warnings
+}
@@ -0,0 +1,179 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// UnservedAPI is an API the cluster serves that the target version no longer serves
+type UnservedAPI struct {
+	Group          string `json:"group"`
+	Version        string `json:"version"`
+	Kind           string `json:"kind"`
+	RemovedIn      string `json:"removedIn"`
+	ReplacementAPI string `json:"replacementAPI,omitempty"`
+	// InUse is set when manifests or CRDs of the inventory still use the API
+	InUse bool `json:"inUse"`
+}
+
+// Simulation predicts the state of a cluster after an upgrade that follows the plan: incompatible
+// releases upgraded to their recommended chart versions and deprecated APIs migrated to their
+// replacements
+type Simulation struct {
+	ClusterID      string `json:"clusterId"`
+	CurrentVersion string `json:"currentVersion"`
+	TargetVersion  string `json:"targetVersion"`
+	// UnservedAPIs stop being served after the upgrade; from API discovery at scan time, else
+	// only the APIs the inventory uses
+	UnservedAPIs []UnservedAPI `json:"unservedAPIs"`
+	// ChartUpgrades compare each incompatible release before and after its recommended upgrade
+	ChartUpgrades []ReleaseWhatIf `json:"chartUpgrades"`
+	// RemainingIncompatible are releases still incompatible after the recommended upgrades,
+	// including those without a recommended version
+	RemainingIncompatible []ChartImpact `json:"remainingIncompatible"`
+	// ResidualFindings remain after the plan; API findings with a replacement are left out
+	// since the plan migrates them
+	ResidualFindings []FindingRef `json:"residualFindings"`
+	RiskBefore       ImpactLevel  `json:"riskBefore"`
+	ResidualRisk     ImpactLevel  `json:"residualRisk"`
+}
+
+// FullyDerisked reports whether the plan leaves no finding
+func (s *Simulation) FullyDerisked() bool {
+	return len(s.ResidualFindings) == 0
+}
+
+// Simulate predicts the post-upgrade state of a cluster by analyzing it as if the incompatible
+// releases ran their recommended chart versions
+func (a *Analyzer) Simulate(ctx context.Context, clusterID, targetVersion string) (*Simulation, error) {
+	cluster, err := a.store.GetCluster(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	saved := a.chartOverrides
+	defer func() { a.chartOverrides = saved }()
+
+	a.chartOverrides = nil
+	actual, err := a.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []ChartOverride
+	for _, chart := range actual.IncompatibleCharts {
+		if chart.Release != "" && chart.RecommendedVersion != "" {
+			overrides = append(overrides, ChartOverride{Namespace: chart.Namespace, Release: chart.Release, ChartVersion: chart.RecommendedVersion})
+		}
+	}
+	releases, err := cluster.QueryHelmReleases().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query helm releases: %w", err)
+	}
+	current := make([]*ent.HelmRelease, len(overrides))
+	for i, override := range overrides {
+		if current[i], err = overriddenRelease(releases, override); err != nil {
+			return nil, err
+		}
+	}
+
+	a.chartOverrides = overrides
+	upgraded, err := a.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	comparison := a.compareWhatIf(actual, upgraded, overrides, current)
+
+	simulation := &Simulation{
+		ClusterID:             clusterID,
+		CurrentVersion:        actual.CurrentVersion,
+		TargetVersion:         targetVersion,
+		UnservedAPIs:          a.unservedAPIs(cluster.ServedApis, actual, targetVersion),
+		ChartUpgrades:         comparison.Releases,
+		RemainingIncompatible: upgraded.IncompatibleCharts,
+		RiskBefore:            actual.OverallRisk,
+	}
+	if simulation.ChartUpgrades == nil {
+		simulation.ChartUpgrades = make([]ReleaseWhatIf, 0)
+	}
+	simulation.ResidualFindings, simulation.ResidualRisk = residualFindings(upgraded)
+	return simulation, nil
+}
+
+// residualFindings returns the findings of an assessment left after the plan, i.e. all but those
+// of APIs it migrates to their replacements, and the highest severity among them
+func residualFindings(upgraded *ImpactAssessment) ([]FindingRef, ImpactLevel) {
+	migrated := make(map[string]bool)
+	for _, apis := range [][]DeprecatedAPIImpact{upgraded.DeprecatedManifestAPIs, upgraded.DeprecatedCRDAPIs} {
+		for _, api := range apis {
+			if api.ReplacementAPI != "" {
+				migrated[api.Group+"/"+api.Version+"/"+api.Kind] = true
+			}
+		}
+	}
+	for _, api := range upgraded.DeprecatedOperatorAPIs {
+		if api.ReplacementAPI != "" {
+			migrated[api.Group+"/"+api.Version+"/"+api.Kind] = true
+		}
+	}
+	residual, risk := make([]FindingRef, 0), ImpactNone
+	for _, finding := range upgraded.Findings() {
+		if finding.Kind != "" && migrated[finding.Group+"/"+finding.Version+"/"+finding.Kind] {
+			continue
+		}
+		residual = append(residual, finding)
+		if impactRank(finding.Severity) > impactRank(risk) {
+			risk = finding.Severity
+		}
+	}
+	return residual, risk
+}
+
+// unservedAPIs lists the served APIs the target version removes, or the removed APIs the
+// assessment found in use when the cluster's served APIs weren't discovered
+func (a *Analyzer) unservedAPIs(served map[string][]string, assessment *ImpactAssessment, targetVersion string) []UnservedAPI {
+	inUse := make(map[string]bool)
+	unserved := make(map[string]UnservedAPI)
+	for _, apis := range [][]DeprecatedAPIImpact{assessment.DeprecatedManifestAPIs, assessment.DeprecatedCRDAPIs} {
+		for _, api := range apis {
+			key := api.Group + "/" + api.Version + "/" + api.Kind
+			inUse[key] = true
+			if len(served) == 0 {
+				unserved[key] = UnservedAPI{Group: api.Group, Version: api.Version, Kind: api.Kind,
+					RemovedIn: api.RemovedIn, ReplacementAPI: api.ReplacementAPI, InUse: true}
+			}
+		}
+	}
+
+	for groupVersion, kinds := range served {
+		group, version, found := strings.Cut(groupVersion, "/")
+		if !found {
+			group, version = "", groupVersion
+		}
+		for _, kind := range kinds {
+			if !a.apiKB.IsAPIRemoved(group, version, kind, targetVersion) {
+				continue
+			}
+			dep, _ := a.apiKB.CheckDeprecation(group, version, kind)
+			key := group + "/" + version + "/" + kind
+			unserved[key] = UnservedAPI{Group: group, Version: version, Kind: kind,
+				RemovedIn: dep.RemovedIn, ReplacementAPI: dep.ReplacementAPI, InUse: inUse[key]}
+		}
+	}
+
+	apis := make([]UnservedAPI, 0, len(unserved))
+	for _, api := range unserved {
+		apis = append(apis, api)
+	}
+	sort.Slice(apis, func(i, j int) bool {
+		if apis[i].InUse != apis[j].InUse {
+			return apis[i].InUse
+		}
+		return apis[i].Group+"/"+apis[i].Version+"/"+apis[i].Kind < apis[j].Group+"/"+apis[j].Version+"/"+apis[j].Kind
+	})
+	return apis
+}
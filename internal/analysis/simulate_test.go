@@ -0,0 +1,56 @@
+package analysis
+
+import "testing"
+
+func TestResidualFindings(t *testing.T) {
+	upgraded := &ImpactAssessment{
+		TargetVersion: "1.25",
+		DeprecatedManifestAPIs: []DeprecatedAPIImpact{{
+			Group: "batch", Version: "v1beta1", Kind: "CronJob", AffectedCount: 1, ImpactLevel: ImpactHigh,
+			RemovedIn: "1.25", ReplacementAPI: "batch/v1",
+			Provenance: []ResourceProvenance{{Resource: "shop/web", Sources: []string{"cluster"}}},
+		}},
+		IncompatibleCharts: []ChartImpact{{ChartName: "legacy", Namespace: "shop", Release: "legacy", ImpactLevel: ImpactMedium}},
+	}
+	AssignRuleIDs(upgraded)
+
+	residual, risk := residualFindings(upgraded)
+	if len(residual) != 1 || residual[0].Resource != "shop/legacy" {
+		t.Errorf("residual findings = %+v, want only the chart of shop/legacy", residual)
+	}
+	if risk != ImpactMedium {
+		t.Errorf("residual risk = %s, want %s", risk, ImpactMedium)
+	}
+
+	if residual, risk := residualFindings(&ImpactAssessment{TargetVersion: "1.25"}); residual == nil || len(residual) != 0 || risk != ImpactNone {
+		t.Errorf("got %v, %s for no findings, want an empty list and %s", residual, risk, ImpactNone)
+	}
+}
+
+func TestUnservedAPIs(t *testing.T) {
+	assessment := &ImpactAssessment{DeprecatedManifestAPIs: []DeprecatedAPIImpact{
+		{Group: "batch", Version: "v1beta1", Kind: "CronJob", RemovedIn: "1.25", ReplacementAPI: "batch/v1"},
+	}}
+	served := map[string][]string{
+		"batch/v1beta1":  {"CronJob"},
+		"policy/v1beta1": {"PodSecurityPolicy"},
+		"apps/v1":        {"Deployment"},
+	}
+
+	apis := testAnalyzer(t).unservedAPIs(served, assessment, "1.25")
+	if len(apis) != 2 {
+		t.Fatalf("got %d unserved APIs, want 2: %+v", len(apis), apis)
+	}
+	if apis[0].Kind != "CronJob" || !apis[0].InUse {
+		t.Errorf("first unserved API = %+v, want the CronJob in use", apis[0])
+	}
+	if apis[1].Kind != "PodSecurityPolicy" || apis[1].InUse {
+		t.Errorf("second unserved API = %+v, want the unused PodSecurityPolicy", apis[1])
+	}
+
+	// Without discovery, only the APIs in use are known
+	apis = testAnalyzer(t).unservedAPIs(nil, assessment, "1.25")
+	if len(apis) != 1 || apis[0].Kind != "CronJob" || !apis[0].InUse {
+		t.Errorf("unserved APIs without discovery = %+v, want the CronJob in use", apis)
+	}
+}
@@ -0,0 +1,212 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// StorageFinding represents a storage-related upgrade risk
+type StorageFinding struct {
+	FindingMeta
+	Type           string      `json:"type"` // in_tree_storage_class, in_tree_volume, statefulset_storage, deprecated_annotation
+	Resource       string      `json:"resource"`
+	Severity       ImpactLevel `json:"severity"`
+	Provisioner    string      `json:"provisioner"`
+	Replacement    string      `json:"replacement"`
+	Description    string      `json:"description"`
+	MigrationNotes string      `json:"migrationNotes"`
+}
+
+// LoadStorageKnowledge loads the in-tree volume plugin knowledge base used by storage checks
+func (a *Analyzer) LoadStorageKnowledge(path string) error {
+	storageKB := knowledge.NewStorageKnowledgeBase()
+	if err := storageKB.LoadFromFile(path); err != nil {
+		return fmt.Errorf("failed to load storage knowledge base: %w", err)
+	}
+	a.storageKB = storageKB
+	return nil
+}
+
+// CheckStorage flags in-tree volume plugins, StatefulSets bound to them and deprecated storage annotations
+func (a *Analyzer) CheckStorage(state *inventory.LiveClusterState, targetVersion string) []StorageFinding {
+	findings := make([]StorageFinding, 0)
+	if a.storageKB == nil {
+		return findings
+	}
+
+	drivers := make(map[string]bool, len(state.CSIDrivers))
+	for _, driver := range state.CSIDrivers {
+		drivers[driver] = true
+	}
+
+	// Storage classes backed by in-tree provisioners that change at the target version
+	riskyClasses := make(map[string]*knowledge.InTreePlugin)
+	for _, sc := range state.StorageClasses {
+		plugin, found := a.storageKB.CheckProvisioner(sc.Provisioner)
+		if found {
+			if severity, description, risky := a.inTreeSeverity(plugin, drivers, targetVersion); risky {
+				riskyClasses[sc.Name] = plugin
+				findings = append(findings, StorageFinding{
+					Type:           "in_tree_storage_class",
+					Resource:       "StorageClass/" + sc.Name,
+					Severity:       severity,
+					Provisioner:    sc.Provisioner,
+					Replacement:    plugin.CSIDriver,
+					Description:    description,
+					MigrationNotes: plugin.MigrationNotes,
+				})
+			}
+		}
+
+		findings = append(findings, a.annotationFindings("StorageClass/"+sc.Name, sc.Annotations, targetVersion)...)
+	}
+
+	for _, pv := range state.PersistentVolumes {
+		if pv.InTreePlugin == "" {
+			continue
+		}
+		plugin, found := a.storageKB.CheckProvisioner(pv.InTreePlugin)
+		if !found {
+			continue
+		}
+		severity, description, risky := a.inTreeSeverity(plugin, drivers, targetVersion)
+		if !risky {
+			continue
+		}
+
+		resource := "PersistentVolume/" + pv.Name
+		if pv.ClaimRef != "" {
+			resource += fmt.Sprintf(" (claim %s)", pv.ClaimRef)
+		}
+		findings = append(findings, StorageFinding{
+			Type:           "in_tree_volume",
+			Resource:       resource,
+			Severity:       severity,
+			Provisioner:    pv.InTreePlugin,
+			Replacement:    plugin.CSIDriver,
+			Description:    description,
+			MigrationNotes: plugin.MigrationNotes,
+		})
+	}
+
+	for _, pvc := range state.PersistentVolumeClaims {
+		findings = append(findings, a.annotationFindings(fmt.Sprintf("PersistentVolumeClaim/%s/%s", pvc.Namespace, pvc.Name), pvc.Annotations, targetVersion)...)
+	}
+
+	for _, workload := range state.Workloads {
+		if workload.Kind != "StatefulSet" {
+			continue
+		}
+		for _, template := range workload.VolumeClaimTemplates {
+			resource := fmt.Sprintf("StatefulSet/%s/%s (volumeClaimTemplate %s)", workload.Namespace, workload.Name, template.Name)
+
+			if plugin, risky := riskyClasses[template.StorageClass]; risky {
+				findings = append(findings, StorageFinding{
+					Type:           "statefulset_storage",
+					Resource:       resource,
+					Severity:       ImpactHigh,
+					Provisioner:    plugin.Provisioner,
+					Replacement:    plugin.CSIDriver,
+					Description:    fmt.Sprintf("New replicas provision volumes from StorageClass %s backed by in-tree plugin %s", template.StorageClass, plugin.Provisioner),
+					MigrationNotes: "volumeClaimTemplates are immutable: point the StatefulSet at a CSI StorageClass by recreating it with --cascade=orphan. " + plugin.MigrationNotes,
+				})
+			}
+
+			// Templates pinning an in-tree provisioner via annotation
+			for _, key := range []string{"volume.beta.kubernetes.io/storage-provisioner", "volume.kubernetes.io/storage-provisioner"} {
+				provisioner, ok := template.Annotations[key]
+				if !ok {
+					continue
+				}
+				plugin, found := a.storageKB.CheckProvisioner(provisioner)
+				if !found {
+					continue
+				}
+				if severity, description, risky := a.inTreeSeverity(plugin, drivers, targetVersion); risky {
+					findings = append(findings, StorageFinding{
+						Type:           "statefulset_storage",
+						Resource:       resource,
+						Severity:       severity,
+						Provisioner:    provisioner,
+						Replacement:    plugin.CSIDriver,
+						Description:    fmt.Sprintf("Annotation %s pins provisioner %s: %s", key, provisioner, description),
+						MigrationNotes: plugin.MigrationNotes,
+					})
+				}
+			}
+
+			findings = append(findings, a.annotationFindings(resource, template.Annotations, targetVersion)...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return impactRank(findings[i].Severity) > impactRank(findings[j].Severity)
+	})
+
+	return findings
+}
+
+// inTreeSeverity rates an in-tree plugin at the target version, taking installed CSI drivers into account
+func (a *Analyzer) inTreeSeverity(plugin *knowledge.InTreePlugin, drivers map[string]bool, targetVersion string) (ImpactLevel, string, bool) {
+	driverInstalled := plugin.CSIDriver != "" && drivers[plugin.CSIDriver]
+
+	if plugin.IsRemoved(targetVersion) {
+		if driverInstalled && plugin.MigratedIn != "" {
+			return ImpactMedium, fmt.Sprintf("In-tree plugin removed in v%s; served through CSI migration by installed driver %s", plugin.RemovedIn, plugin.CSIDriver), true
+		}
+		return ImpactCritical, fmt.Sprintf("In-tree plugin removed in v%s and no CSI replacement is installed", plugin.RemovedIn), true
+	}
+
+	if plugin.IsMigrated(targetVersion) {
+		if driverInstalled {
+			return ImpactLow, fmt.Sprintf("CSI migration enabled by default since v%s; driver %s is installed", plugin.MigratedIn, plugin.CSIDriver), true
+		}
+		return ImpactHigh, fmt.Sprintf("CSI migration enabled by default since v%s but CSI driver %s is not installed", plugin.MigratedIn, plugin.CSIDriver), true
+	}
+
+	return "", "", false
+}
+
+// annotationFindings reports deprecated storage annotations on a resource, ordered by annotation
+func (a *Analyzer) annotationFindings(resource string, annotations map[string]string, targetVersion string) []StorageFinding {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var findings []StorageFinding
+	for _, key := range keys {
+		dep, found := a.storageKB.CheckAnnotation(key)
+		if !found || !dep.IsDeprecated(targetVersion) {
+			continue
+		}
+		findings = append(findings, StorageFinding{
+			Type:           "deprecated_annotation",
+			Resource:       resource,
+			Severity:       ImpactLow,
+			Replacement:    dep.Replacement,
+			Description:    fmt.Sprintf("Uses deprecated annotation %s (deprecated since v%s)", key, dep.DeprecatedIn),
+			MigrationNotes: dep.MigrationNotes,
+		})
+	}
+	return findings
+}
+
+// impactRank orders impact levels from none (0) to critical (4)
+func impactRank(level ImpactLevel) int {
+	switch level {
+	case ImpactCritical:
+		return 4
+	case ImpactHigh:
+		return 3
+	case ImpactMedium:
+		return 2
+	case ImpactLow:
+		return 1
+	}
+	return 0
+}
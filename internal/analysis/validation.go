@@ -0,0 +1,52 @@
+package analysis
+
+import "fmt"
+
+// ValidationResult is the outcome of applying a manifest resource to the live cluster with a
+// server-side dry run
+type ValidationResult struct {
+	FindingMeta
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	SourceFile string `json:"sourceFile,omitempty"`
+	Converted  bool   `json:"converted,omitempty"` // validated as converted by the built-in converter
+	Passed     bool   `json:"passed"`
+	Reason     string `json:"reason,omitempty"` // why the API server rejected it, e.g. Invalid, Forbidden or NotServed
+	Message    string `json:"message,omitempty"`
+}
+
+// Resource formats the validated resource, e.g. "Ingress default/web"
+func (r ValidationResult) Resource() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// ApplyValidationResults adds server-side dry-run results to an assessment. Every rejected resource
+// is a high-severity issue: applying it would fail the same way.
+func ApplyValidationResults(assessment *ImpactAssessment, results []ValidationResult) {
+	assessment.ValidationResults = append(assessment.ValidationResults, results...)
+	for _, result := range results {
+		if result.Passed {
+			continue
+		}
+		assessment.TotalIssues++
+		raiseOverallRisk(assessment, ImpactHigh)
+	}
+
+	AssignRuleIDs(assessment)
+}
+
+// RejectedValidations counts the resources rejected by the dry run
+func (assessment *ImpactAssessment) RejectedValidations() int {
+	rejected := 0
+	for _, result := range assessment.ValidationResults {
+		if !result.Passed {
+			rejected++
+		}
+	}
+	return rejected
+}
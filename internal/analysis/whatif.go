@@ -0,0 +1,166 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// ChartOverride is a hypothetical chart version of a Helm release
+type ChartOverride struct {
+	Namespace    string `json:"namespace"`
+	Release      string `json:"release"`
+	ChartVersion string `json:"chartVersion"`
+}
+
+// SetChartOverrides makes analyses assume releases run the given chart versions, as if they had been
+// upgraded. Nothing is written to the inventory.
+func (a *Analyzer) SetChartOverrides(overrides []ChartOverride) {
+	a.chartOverrides = overrides
+}
+
+// applyChartOverrides substitutes the hypothetical chart versions in the releases loaded for an
+// analysis; every override must name a release of the cluster
+func (a *Analyzer) applyChartOverrides(releases []*ent.HelmRelease) error {
+	for _, override := range a.chartOverrides {
+		release, err := overriddenRelease(releases, override)
+		if err != nil {
+			return err
+		}
+		release.ChartVersion = override.ChartVersion
+	}
+	return nil
+}
+
+// overriddenRelease finds the release an override names
+func overriddenRelease(releases []*ent.HelmRelease, override ChartOverride) (*ent.HelmRelease, error) {
+	for _, release := range releases {
+		if release.Namespace == override.Namespace && release.Name == override.Release {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("helm release %s/%s not found in the inventory", override.Namespace, override.Release)
+}
+
+// ReleaseWhatIf compares a release's chart findings at its current and a hypothetical chart version
+type ReleaseWhatIf struct {
+	ChartOverride
+	Chart          string `json:"chart"`
+	CurrentVersion string `json:"currentVersion"`
+	// Known is false when the chart matrix doesn't list the hypothetical version, so its
+	// compatibility can't be judged
+	Known bool `json:"known"`
+	// Incompatibility or known issues of the chart at each version; nil when there are none
+	Before             *ChartImpact `json:"before,omitempty"`
+	After              *ChartImpact `json:"after,omitempty"`
+	IncompatibleBefore bool         `json:"incompatibleBefore"`
+	IncompatibleAfter  bool         `json:"incompatibleAfter"`
+}
+
+// Resolved reports whether the hypothetical version leaves no incompatibility or known issue
+func (r ReleaseWhatIf) Resolved() bool {
+	return r.Known && r.After == nil
+}
+
+// WhatIfResult compares the assessment with hypothetical chart versions against the actual one
+type WhatIfResult struct {
+	TargetVersion      string          `json:"targetVersion"`
+	Releases           []ReleaseWhatIf `json:"releases"`
+	OverallRiskBefore  ImpactLevel     `json:"overallRiskBefore"`
+	OverallRiskAfter   ImpactLevel     `json:"overallRiskAfter"`
+	TotalIssuesBefore  int             `json:"totalIssuesBefore"`
+	TotalIssuesAfter   int             `json:"totalIssuesAfter"`
+	ResolvedFindings   []FindingRef    `json:"resolvedFindings"`
+	IntroducedFindings []FindingRef    `json:"introducedFindings"`
+}
+
+// WhatIf analyzes the upgrade of a cluster twice, with its actual chart versions and with the
+// overrides, and compares the two assessments
+func (a *Analyzer) WhatIf(ctx context.Context, clusterID, targetVersion string, overrides []ChartOverride) (*WhatIfResult, error) {
+	cluster, err := a.store.GetCluster(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	releases, err := cluster.QueryHelmReleases().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query helm releases: %w", err)
+	}
+	current := make([]*ent.HelmRelease, len(overrides))
+	for i, override := range overrides {
+		if current[i], err = overriddenRelease(releases, override); err != nil {
+			return nil, err
+		}
+	}
+
+	saved := a.chartOverrides
+	defer func() { a.chartOverrides = saved }()
+
+	a.chartOverrides = nil
+	actual, err := a.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	a.chartOverrides = overrides
+	hypothetical, err := a.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	return a.compareWhatIf(actual, hypothetical, overrides, current), nil
+}
+
+// compareWhatIf compares the actual assessment with the one computed with the overrides of the
+// current releases
+func (a *Analyzer) compareWhatIf(actual, hypothetical *ImpactAssessment, overrides []ChartOverride, current []*ent.HelmRelease) *WhatIfResult {
+	result := &WhatIfResult{
+		TargetVersion:      actual.TargetVersion,
+		OverallRiskBefore:  actual.OverallRisk,
+		OverallRiskAfter:   hypothetical.OverallRisk,
+		TotalIssuesBefore:  actual.TotalIssues,
+		TotalIssuesAfter:   hypothetical.TotalIssues,
+		ResolvedFindings:   make([]FindingRef, 0),
+		IntroducedFindings: make([]FindingRef, 0),
+	}
+
+	for i, override := range overrides {
+		comparison := ReleaseWhatIf{ChartOverride: override, Chart: current[i].Chart, CurrentVersion: current[i].ChartVersion}
+		comparison.Known = a.chartKB.HasChartVersion(comparison.Chart, override.ChartVersion)
+		comparison.Before, comparison.IncompatibleBefore = releaseChartImpact(actual, override.Namespace, override.Release)
+		comparison.After, comparison.IncompatibleAfter = releaseChartImpact(hypothetical, override.Namespace, override.Release)
+		result.Releases = append(result.Releases, comparison)
+	}
+
+	before := make(map[string]bool)
+	for _, finding := range actual.Findings() {
+		before[finding.Key()] = true
+	}
+	after := make(map[string]bool)
+	for _, finding := range hypothetical.Findings() {
+		after[finding.Key()] = true
+		if !before[finding.Key()] {
+			result.IntroducedFindings = append(result.IntroducedFindings, finding)
+		}
+	}
+	for _, finding := range actual.Findings() {
+		if !after[finding.Key()] {
+			result.ResolvedFindings = append(result.ResolvedFindings, finding)
+		}
+	}
+	return result
+}
+
+// releaseChartImpact returns the incompatibility of a release, else its chart caveat, or nil, and
+// whether it is an incompatibility
+func releaseChartImpact(assessment *ImpactAssessment, namespace, release string) (*ChartImpact, bool) {
+	for i, chart := range assessment.IncompatibleCharts {
+		if chart.Namespace == namespace && chart.Release == release {
+			return &assessment.IncompatibleCharts[i], true
+		}
+	}
+	for i, chart := range assessment.ChartCaveats {
+		if chart.Namespace == namespace && chart.Release == release {
+			return &assessment.ChartCaveats[i], false
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// testAnalyzer loads the repository's API and chart knowledge bases, without a store
+func testAnalyzer(t *testing.T) *Analyzer {
+	t.Helper()
+	kb := filepath.Join("..", "..", "knowledge-base")
+	analyzer, err := NewAnalyzer(filepath.Join(kb, "apis.json"), filepath.Join(kb, "chart-matrix.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return analyzer
+}
+
+func TestCompareWhatIfKeysChartsByRelease(t *testing.T) {
+	public := ChartImpact{ChartName: "nginx-ingress", Namespace: "ingress", Release: "public", CurrentVersion: "4.0.0", ImpactLevel: ImpactHigh}
+	internal := ChartImpact{ChartName: "nginx-ingress", Namespace: "ingress", Release: "internal", CurrentVersion: "4.0.0", ImpactLevel: ImpactHigh}
+	actual := &ImpactAssessment{TargetVersion: "1.28", OverallRisk: ImpactHigh, TotalIssues: 2, IncompatibleCharts: []ChartImpact{public, internal}}
+	hypothetical := &ImpactAssessment{TargetVersion: "1.28", OverallRisk: ImpactHigh, TotalIssues: 1, IncompatibleCharts: []ChartImpact{internal}}
+	AssignRuleIDs(actual)
+	AssignRuleIDs(hypothetical)
+
+	overrides := []ChartOverride{{Namespace: "ingress", Release: "public", ChartVersion: "4.8.0"}}
+	current := []*ent.HelmRelease{{Namespace: "ingress", Name: "public", Chart: "nginx-ingress", ChartVersion: "4.0.0"}}
+	result := testAnalyzer(t).compareWhatIf(actual, hypothetical, overrides, current)
+
+	if len(result.ResolvedFindings) != 1 || result.ResolvedFindings[0].Resource != "ingress/public" {
+		t.Errorf("resolved findings = %+v, want the one of ingress/public", result.ResolvedFindings)
+	}
+	if len(result.IntroducedFindings) != 0 {
+		t.Errorf("introduced findings = %+v, want none", result.IntroducedFindings)
+	}
+	if len(result.Releases) != 1 {
+		t.Fatalf("got %d releases, want 1", len(result.Releases))
+	}
+	release := result.Releases[0]
+	if !release.Known || !release.IncompatibleBefore || release.IncompatibleAfter || !release.Resolved() {
+		t.Errorf("release comparison = %+v, want a known version resolving the incompatibility", release)
+	}
+	if result.TotalIssuesBefore != 2 || result.TotalIssuesAfter != 1 {
+		t.Errorf("issues %d -> %d, want 2 -> 1", result.TotalIssuesBefore, result.TotalIssuesAfter)
+	}
+}
+
+func TestCompareWhatIfIntroducedFinding(t *testing.T) {
+	actual := &ImpactAssessment{TargetVersion: "1.28"}
+	hypothetical := &ImpactAssessment{TargetVersion: "1.28", IncompatibleCharts: []ChartImpact{
+		{ChartName: "nginx-ingress", Namespace: "ingress", Release: "public", CurrentVersion: "9.9.9", ImpactLevel: ImpactHigh},
+	}}
+	AssignRuleIDs(actual)
+	AssignRuleIDs(hypothetical)
+
+	overrides := []ChartOverride{{Namespace: "ingress", Release: "public", ChartVersion: "9.9.9"}}
+	current := []*ent.HelmRelease{{Namespace: "ingress", Name: "public", Chart: "nginx-ingress", ChartVersion: "4.8.0"}}
+	result := testAnalyzer(t).compareWhatIf(actual, hypothetical, overrides, current)
+
+	if len(result.IntroducedFindings) != 1 || result.IntroducedFindings[0].Resource != "ingress/public" {
+		t.Errorf("introduced findings = %+v, want the one of ingress/public", result.IntroducedFindings)
+	}
+	if release := result.Releases[0]; release.Known || release.Resolved() {
+		t.Errorf("release comparison = %+v, want an unknown version", release)
+	}
+}
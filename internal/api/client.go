@@ -0,0 +1,318 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+)
+
+// Client queries a kube-upgrade-server, read-only besides starting scans and approving plans
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	// streamClient has no timeout, for event streams lasting as long as a scan
+	streamClient *http.Client
+	token        string
+}
+
+// StatusError is a non-OK response of the server
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned %s: %s", e.Status, e.Message)
+}
+
+// NewClient creates a client for the server at baseURL, e.g. https://advisor.internal
+func NewClient(baseURL string) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q: scheme must be http or https", baseURL)
+	}
+
+	return &Client{
+		baseURL:      parsed,
+		httpClient:   network.Client(2 * time.Minute),
+		streamClient: network.Client(0),
+	}, nil
+}
+
+// SetToken sets the bearer token sent to servers that require API tokens
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Impact computes the impact assessment and upgrade plan of a cluster on the server
+func (c *Client) Impact(ctx context.Context, clusterID, targetVersion, lang string) (*planner.UpgradeAssessmentWithPlan, error) {
+	query := url.Values{"cluster": {clusterID}, "target": {targetVersion}}
+	if lang != "" {
+		query.Set("lang", lang)
+	}
+
+	var response planner.UpgradeAssessmentWithPlan
+	if err := c.get(ctx, "/impact", query, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Clusters lists the clusters matching a label selector
+func (c *Client) Clusters(ctx context.Context, selector string) ([]ClusterInfo, error) {
+	query := url.Values{}
+	if selector != "" {
+		query.Set("selector", selector)
+	}
+
+	var clusters []ClusterInfo
+	if err := c.get(ctx, "/clusters", query, &clusters); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// Inventory retrieves the inventory of a cluster
+func (c *Client) Inventory(ctx context.Context, clusterID string) (*inventory.Export, error) {
+	var export inventory.Export
+	if err := c.get(ctx, "/inventory", url.Values{"cluster": {clusterID}}, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Search finds the inventory items of the server's clusters matching filter
+func (c *Client) Search(ctx context.Context, filter inventory.SearchFilter) (*inventory.SearchResult, error) {
+	query := url.Values{"q": {filter.Pattern}}
+	if filter.ClusterID != "" {
+		query.Set("cluster", filter.ClusterID)
+	}
+	if len(filter.Types) > 0 {
+		query.Set("type", strings.Join(filter.Types, ","))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var result inventory.SearchResult
+	if err := c.get(ctx, "/search", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Assessments lists stored assessments newest first, optionally filtered by cluster and target version
+func (c *Client) Assessments(ctx context.Context, clusterID, targetVersion string) ([]AssessmentInfo, error) {
+	query := url.Values{}
+	if clusterID != "" {
+		query.Set("cluster", clusterID)
+	}
+	if targetVersion != "" {
+		query.Set("target", targetVersion)
+	}
+
+	var assessments []AssessmentInfo
+	if err := c.get(ctx, "/assessments", query, &assessments); err != nil {
+		return nil, err
+	}
+	return assessments, nil
+}
+
+// AuditLogs lists the audit log entries of the server newest first
+func (c *Client) AuditLogs(ctx context.Context, filter inventory.AuditFilter) ([]AuditLogInfo, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"cluster": filter.ClusterID, "action": filter.Action, "actor": filter.Actor} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var entries []AuditLogInfo
+	if err := c.get(ctx, "/audit", query, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Plans lists stored plans newest first, optionally only those of one cluster
+func (c *Client) Plans(ctx context.Context, clusterID string) ([]PlanInfo, error) {
+	query := url.Values{}
+	if clusterID != "" {
+		query.Set("cluster", clusterID)
+	}
+
+	var plans []PlanInfo
+	if err := c.get(ctx, "/plans", query, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// ApprovePlan approves a draft plan as the holder of the client's token
+func (c *Client) ApprovePlan(ctx context.Context, id int) (*PlanInfo, error) {
+	var plan PlanInfo
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/plans/%d/approve", id), nil, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ExecutePlan marks a plan as executed; the server refuses unapproved plans when approval is
+// required by it or by requireApproval
+func (c *Client) ExecutePlan(ctx context.Context, id int, requireApproval bool) (*PlanInfo, error) {
+	query := url.Values{}
+	if requireApproval {
+		query.Set("requireApproval", "true")
+	}
+
+	var plan PlanInfo
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/plans/%d/execute", id), query, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Assessment retrieves a stored assessment and its plan
+func (c *Client) Assessment(ctx context.Context, id int) (*planner.UpgradeAssessmentWithPlan, error) {
+	var response planner.UpgradeAssessmentWithPlan
+	if err := c.get(ctx, "/assessments", url.Values{"id": {strconv.Itoa(id)}}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// StartScan starts a scan of the cluster the server runs in, stored under clusterID
+func (c *Client) StartScan(ctx context.Context, clusterID string, incremental bool) (*ScanStatus, error) {
+	query := url.Values{"cluster": {clusterID}}
+	if incremental {
+		query.Set("incremental", "true")
+	}
+
+	var status ScanStatus
+	if err := c.do(ctx, http.MethodPost, "/scans", query, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Scan retrieves the status of a scan started through the server
+func (c *Client) Scan(ctx context.Context, id int) (*ScanStatus, error) {
+	var status ScanStatus
+	if err := c.get(ctx, "/scans", url.Values{"id": {strconv.Itoa(id)}}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// FollowScan streams the progress events of a scan to onEvent, from its start, and returns its
+// final status once it has finished
+func (c *Client) FollowScan(ctx context.Context, id int, onEvent func(scan.Event)) (*ScanStatus, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/scans/events", url.Values{"id": {strconv.Itoa(id)}}, "text/event-stream", c.streamClient)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var name string
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "" && data.Len() > 0:
+			switch name {
+			case "progress":
+				var event scan.Event
+				if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+					return nil, fmt.Errorf("failed to decode scan event: %w", err)
+				}
+				onEvent(event)
+			case "status":
+				var status ScanStatus
+				if err := json.Unmarshal([]byte(data.String()), &status); err != nil {
+					return nil, fmt.Errorf("failed to decode scan status: %w", err)
+				}
+				return &status, nil
+			}
+			name = ""
+			data.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scan events: %w", err)
+	}
+	return nil, fmt.Errorf("scan event stream ended before the scan finished")
+}
+
+// get sends a GET request and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, v)
+}
+
+// do sends a request and decodes the JSON response into v
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, v interface{}) error {
+	resp, err := c.send(ctx, method, path, query, "application/json", c.httpClient)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode server response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request, returning the response when it is successful
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, accept string, httpClient *http.Client) (*http.Response, error) {
+	endpoint := *c.baseURL
+	endpoint.Path += path
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Message: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// ClusterInfo is a cluster as returned by /clusters
+type ClusterInfo struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Incomplete string            `json:"incomplete,omitempty"`
+	ScanErrors map[string]string `json:"scanErrors,omitempty"`
+}
+
+// AssessmentInfo summarizes a stored assessment as returned by /assessments
+type AssessmentInfo struct {
+	ID             int       `json:"id"`
+	ClusterID      string    `json:"clusterId"`
+	CurrentVersion string    `json:"currentVersion"`
+	TargetVersion  string    `json:"targetVersion"`
+	OverallRisk    string    `json:"overallRisk"`
+	TotalIssues    int       `json:"totalIssues"`
+	Live           bool      `json:"live"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// PlanInfo summarizes a stored plan and its approval as returned by /plans
+type PlanInfo struct {
+	ID            int        `json:"id"`
+	ClusterID     string     `json:"clusterId"`
+	FromVersion   string     `json:"fromVersion"`
+	TargetVersion string     `json:"targetVersion"`
+	TotalSteps    int        `json:"totalSteps"`
+	State         string     `json:"state"`
+	ApprovedBy    string     `json:"approvedBy,omitempty"`
+	ApprovedAt    *time.Time `json:"approvedAt,omitempty"`
+	ExecutedBy    string     `json:"executedBy,omitempty"`
+	ExecutedAt    *time.Time `json:"executedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// AuditLogInfo is an audit log entry as returned by /audit
+type AuditLogInfo struct {
+	ID         int               `json:"id"`
+	Action     string            `json:"action"`
+	Actor      string            `json:"actor"`
+	Origin     string            `json:"origin"`
+	ClusterID  string            `json:"clusterId,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Succeeded  bool              `json:"succeeded"`
+	Summary    string            `json:"summary,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+}
+
+// States of a scan triggered through the server
+const (
+	ScanRunning   = "running"
+	ScanCompleted = "completed"
+	ScanPartial   = "partial"
+	ScanFailed    = "failed"
+)
+
+// ScanStatus is a scan triggered through the server, as returned by /scans
+type ScanStatus struct {
+	ID         int               `json:"id"`
+	ClusterID  string            `json:"clusterId"`
+	State      string            `json:"state"`
+	Phase      string            `json:"phase,omitempty"`
+	Version    string            `json:"version,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	ScanErrors map[string]string `json:"scanErrors,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+}
+
+// NewClusterInfo converts a cluster entity
+func NewClusterInfo(cluster *ent.Cluster) ClusterInfo {
+	return ClusterInfo{
+		ID:         cluster.ID,
+		Name:       cluster.Name,
+		Version:    cluster.KubeVersion,
+		Labels:     cluster.Labels,
+		Incomplete: cluster.IncompleteReason,
+		ScanErrors: cluster.ScanErrors,
+	}
+}
+
+// NewAssessmentInfo converts an assessment entity, loaded with its cluster
+func NewAssessmentInfo(assessment *ent.Assessment) AssessmentInfo {
+	info := AssessmentInfo{
+		ID:             assessment.ID,
+		CurrentVersion: assessment.CurrentVersion,
+		TargetVersion:  assessment.TargetVersion,
+		OverallRisk:    assessment.OverallRisk,
+		TotalIssues:    assessment.TotalIssues,
+		Live:           assessment.Live,
+		CreatedAt:      assessment.CreatedAt,
+	}
+	if assessment.Edges.Cluster != nil {
+		info.ClusterID = assessment.Edges.Cluster.ID
+	}
+	return info
+}
+
+// NewPlanInfo converts a plan entity, loaded with its cluster
+func NewPlanInfo(plan *ent.Plan) PlanInfo {
+	info := PlanInfo{
+		ID:            plan.ID,
+		FromVersion:   plan.FromVersion,
+		TargetVersion: plan.TargetVersion,
+		TotalSteps:    plan.TotalSteps,
+		State:         plan.State.String(),
+		ApprovedBy:    plan.ApprovedBy,
+		ApprovedAt:    plan.ApprovedAt,
+		ExecutedBy:    plan.ExecutedBy,
+		ExecutedAt:    plan.ExecutedAt,
+		CreatedAt:     plan.CreatedAt,
+	}
+	if plan.Edges.Cluster != nil {
+		info.ClusterID = plan.Edges.Cluster.ID
+	}
+	return info
+}
+
+// NewAuditLogInfo converts an audit log entity
+func NewAuditLogInfo(entry *ent.AuditLog) AuditLogInfo {
+	return AuditLogInfo{
+		ID:         entry.ID,
+		Action:     entry.Action,
+		Actor:      entry.Actor,
+		Origin:     entry.Origin,
+		ClusterID:  entry.ClusterID,
+		Parameters: entry.Parameters,
+		Succeeded:  entry.Succeeded,
+		Summary:    entry.Summary,
+		Error:      entry.Error,
+		StartedAt:  entry.StartedAt,
+		FinishedAt: entry.CreatedAt,
+	}
+}
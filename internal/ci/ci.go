@@ -0,0 +1,181 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+)
+
+// Provider is a CI system the advisor integrates with
+type Provider string
+
+const (
+	ProviderGitHub  Provider = "github"
+	ProviderGitLab  Provider = "gitlab"
+	ProviderGeneric Provider = "generic"
+)
+
+// Environment describes the detected CI run
+type Environment struct {
+	Provider  Provider
+	Workspace string // checked-out repository root
+}
+
+// Detect identifies the CI system from its standard environment variables
+func Detect() *Environment {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return &Environment{Provider: ProviderGitHub, Workspace: envOr("GITHUB_WORKSPACE", ".")}
+	case os.Getenv("GITLAB_CI") == "true":
+		return &Environment{Provider: ProviderGitLab, Workspace: envOr("CI_PROJECT_DIR", ".")}
+	default:
+		return &Environment{Provider: ProviderGeneric, Workspace: "."}
+	}
+}
+
+// ParseProvider validates a provider name, e.g. from a --provider flag overriding detection
+func ParseProvider(value string) (Provider, error) {
+	provider := Provider(strings.ToLower(value))
+	switch provider {
+	case ProviderGitHub, ProviderGitLab, ProviderGeneric:
+		return provider, nil
+	}
+	return "", fmt.Errorf("unknown CI provider %q (supported: github, gitlab, generic)", value)
+}
+
+// Annotation is a finding attached to a file of the scanned repository
+type Annotation struct {
+	analysis.FindingRef
+	File    string // relative to the workspace, empty when the finding has no source file
+	DocsURL string
+	New     bool // false when accepted in a baseline
+}
+
+// Result summarizes a CI run for outputs and the pass/fail decision
+type Result struct {
+	Annotations []Annotation
+	RiskLevel   analysis.ImpactLevel
+	Findings    int
+	NewFindings int
+	BySeverity  map[analysis.ImpactLevel]int
+	Failed      bool
+}
+
+// Evaluate builds file annotations for the assessment's findings and decides whether the run fails.
+// Only new findings count toward the threshold when a baseline was applied; ImpactNone never fails.
+func Evaluate(assessment *analysis.ImpactAssessment, resources []manifests.Resource, workspace string, failOn analysis.ImpactLevel) *Result {
+	files := sourceFilesByAPI(resources, workspace)
+
+	accepted := make(map[string]bool)
+	if assessment.Baseline != nil {
+		for _, finding := range assessment.Baseline.ExistingFindings {
+			accepted[finding.Key()] = true
+		}
+	}
+
+	result := &Result{
+		RiskLevel:  assessment.OverallRisk,
+		BySeverity: make(map[analysis.ImpactLevel]int),
+	}
+
+	for _, finding := range assessment.Findings() {
+		isNew := !accepted[finding.Key()]
+		result.Findings++
+		result.BySeverity[finding.Severity]++
+		if isNew {
+			result.NewFindings++
+			if failOn != analysis.ImpactNone && finding.Severity.AtLeast(failOn) {
+				result.Failed = true
+			}
+		}
+
+		docsURL := ""
+		if rule, found := analysis.RuleByID(finding.RuleID); found {
+			docsURL = rule.DocsURL()
+		}
+
+		paths := files[apiKey(finding.Group, finding.Version, finding.Kind)]
+		if len(paths) == 0 {
+			paths = []string{""}
+		}
+		for _, path := range paths {
+			result.Annotations = append(result.Annotations, Annotation{
+				FindingRef: finding,
+				File:       path,
+				DocsURL:    docsURL,
+				New:        isNew,
+			})
+		}
+	}
+
+	return result
+}
+
+// Outputs returns the step outputs exposed to later pipeline steps
+func (r *Result) Outputs() map[string]string {
+	return map[string]string{
+		"risk-level":     string(r.RiskLevel),
+		"findings":       fmt.Sprintf("%d", r.Findings),
+		"new-findings":   fmt.Sprintf("%d", r.NewFindings),
+		"critical-count": fmt.Sprintf("%d", r.BySeverity[analysis.ImpactCritical]),
+		"high-count":     fmt.Sprintf("%d", r.BySeverity[analysis.ImpactHigh]),
+		"medium-count":   fmt.Sprintf("%d", r.BySeverity[analysis.ImpactMedium]),
+		"low-count":      fmt.Sprintf("%d", r.BySeverity[analysis.ImpactLow]),
+		"failed":         fmt.Sprintf("%t", r.Failed),
+	}
+}
+
+// sourceFilesByAPI maps group/version/kind to the sorted workspace-relative files using it
+func sourceFilesByAPI(resources []manifests.Resource, workspace string) map[string][]string {
+	seen := make(map[string]map[string]bool)
+	for _, resource := range resources {
+		if resource.SourceFile == "" {
+			continue
+		}
+		group, version := "", resource.APIVersion
+		if i := strings.Index(resource.APIVersion, "/"); i >= 0 {
+			group, version = resource.APIVersion[:i], resource.APIVersion[i+1:]
+		}
+		key := apiKey(group, version, resource.Kind)
+		if seen[key] == nil {
+			seen[key] = make(map[string]bool)
+		}
+		seen[key][relativePath(resource.SourceFile, workspace)] = true
+	}
+
+	files := make(map[string][]string, len(seen))
+	for key, paths := range seen {
+		for path := range paths {
+			files[key] = append(files[key], path)
+		}
+		sort.Strings(files[key])
+	}
+	return files
+}
+
+// apiKey builds the lookup key for an API
+func apiKey(group, version, kind string) string {
+	return fmt.Sprintf("%s/%s/%s", group, version, kind)
+}
+
+// relativePath makes a path relative to the workspace, as annotation APIs expect
+func relativePath(path, workspace string) string {
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// envOr returns an environment variable or a fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,102 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// WriteGitHubAnnotations emits workflow commands, which GitHub attaches to the check run as annotations.
+// New findings at or above the fail threshold are errors, other new findings warnings and
+// baselined findings notices.
+func WriteGitHubAnnotations(w io.Writer, annotations []Annotation, failOn analysis.ImpactLevel) {
+	for _, annotation := range annotations {
+		command := "notice"
+		if annotation.New {
+			command = "warning"
+			if failOn != analysis.ImpactNone && annotation.Severity.AtLeast(failOn) {
+				command = "error"
+			}
+		}
+
+		properties := fmt.Sprintf("title=%s", escapeProperty(fmt.Sprintf("[%s] %s", annotation.RuleID, annotation.Resource)))
+		if annotation.File != "" {
+			properties = fmt.Sprintf("file=%s,%s", escapeProperty(annotation.File), properties)
+		}
+
+		message := fmt.Sprintf("%s (severity: %s)", annotation.Summary, annotation.Severity)
+		if annotation.DocsURL != "" {
+			message += "\n" + annotation.DocsURL
+		}
+
+		fmt.Fprintf(w, "::%s %s::%s\n", command, properties, escapeData(message))
+	}
+}
+
+// WriteGitHubOutputs appends step outputs to the file named by $GITHUB_OUTPUT
+func WriteGitHubOutputs(outputs map[string]string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+	}
+	defer file.Close()
+
+	for _, key := range sortedKeys(outputs) {
+		if _, err := fmt.Fprintf(file, "%s=%s\n", key, outputs[key]); err != nil {
+			return fmt.Errorf("failed to write GITHUB_OUTPUT: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteGitHubSummary appends the text report to the job summary named by $GITHUB_STEP_SUMMARY
+func WriteGitHubSummary(report string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_STEP_SUMMARY: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "```\n%s\n```\n", report); err != nil {
+		return fmt.Errorf("failed to write GITHUB_STEP_SUMMARY: %w", err)
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command message
+func escapeData(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(value string) string {
+	value = escapeData(value)
+	value = strings.ReplaceAll(value, ":", "%3A")
+	return strings.ReplaceAll(value, ",", "%2C")
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
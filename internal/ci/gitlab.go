@@ -0,0 +1,96 @@
+package ci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// codeQualityIssue is an entry of a GitLab code quality report (a subset of the Code Climate format)
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// WriteGitLabCodeQuality writes annotations as a code quality report for artifacts:reports:codequality.
+// Baselined findings are included as info so merge requests only highlight new ones.
+func WriteGitLabCodeQuality(path string, annotations []Annotation) error {
+	issues := make([]codeQualityIssue, 0, len(annotations))
+	for _, annotation := range annotations {
+		file := annotation.File
+		if file == "" {
+			// Code quality requires a path; cluster-level findings are attached to the repository root
+			file = "."
+		}
+
+		severity := "info"
+		if annotation.New {
+			severity = codeQualitySeverity(annotation.Severity)
+		}
+
+		description := fmt.Sprintf("[%s] %s: %s", annotation.RuleID, annotation.Resource, annotation.Summary)
+		sum := sha256.Sum256([]byte(annotation.Key() + "|" + file))
+
+		issues = append(issues, codeQualityIssue{
+			Description: description,
+			CheckName:   annotation.RuleID,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    severity,
+			Location:    codeQualityLocation{Path: file, Lines: codeQualityLines{Begin: 1}},
+		})
+	}
+
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal code quality report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write code quality report: %w", err)
+	}
+	return nil
+}
+
+// WriteDotenv writes outputs as a dotenv report for artifacts:reports:dotenv, prefixing
+// and upper-casing keys (risk-level becomes KUBE_ADVISOR_RISK_LEVEL)
+func WriteDotenv(path string, outputs map[string]string) error {
+	var b strings.Builder
+	for _, key := range sortedKeys(outputs) {
+		name := "KUBE_ADVISOR_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		fmt.Fprintf(&b, "%s=%s\n", name, outputs[key])
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write dotenv report: %w", err)
+	}
+	return nil
+}
+
+// codeQualitySeverity maps impact levels to code quality severities
+func codeQualitySeverity(level analysis.ImpactLevel) string {
+	switch level {
+	case analysis.ImpactCritical:
+		return "critical"
+	case analysis.ImpactHigh:
+		return "major"
+	case analysis.ImpactMedium:
+		return "minor"
+	default:
+		return "info"
+	}
+}
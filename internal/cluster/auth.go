@@ -0,0 +1,34 @@
+package cluster
+
+import (
+	"strings"
+
+	// Register the oidc auth provider, refreshing expired ID tokens in the kubeconfig, and the
+	// removed gcp and azure providers, which fail with how to migrate to their exec plugins.
+	// Exec credential plugins, e.g. aws-iam-authenticator, gke-gcloud-auth-plugin or kubelogin,
+	// need no registration.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// IsCredentialError reports whether an error comes from a kubeconfig's credential plugin rather
+// than the cluster: an exec plugin that isn't installed or failed, e.g. because its login expired,
+// an OIDC token that couldn't be refreshed, or a removed auth provider
+func IsCredentialError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	for _, marker := range []string{
+		"getting credentials: ",
+		"exec plugin",
+		"failed to refresh token",
+		"oidc: ",
+		"auth plugin has been removed",
+		"no Auth Provider found",
+	} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,35 +3,65 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 )
 
 // CustomResourceDefinition represents a CRD in the cluster
 type CustomResourceDefinition struct {
-	Name        string
-	Group       string
-	Versions    []CRDVersion
-	Kind        string
-	Scope       string
-	Labels      map[string]string
-	Annotations map[string]string
+	Name           string
+	Group          string
+	Versions       []CRDVersion
+	StoredVersions []string
+	Kind           string
+	Plural         string
+	Scope          string
+	Labels         map[string]string
+	Annotations    map[string]string
+	// ConversionService is the service of the conversion webhook, as namespace/name, and
+	// ConversionURL the URL of one not running in the cluster; both empty without a webhook
+	ConversionService string
+	ConversionURL     string
 }
 
 // CRDVersion represents a version of a CRD
 type CRDVersion struct {
-	Name    string
-	Served  bool
-	Storage bool
+	Name               string
+	Served             bool
+	Storage            bool
+	Deprecated         bool
+	DeprecationWarning string
 }
 
 // CRDClient handles Custom Resource Definition operations
 type CRDClient struct {
-	clientset *apiextclientset.Clientset
+	clientset      *apiextclientset.Clientset
+	dynamicClient  dynamic.Interface
+	metadataClient metadata.Interface
+	// Progress, when set, is called with the number of CRDs stored so far
+	Progress func(processed, total int)
+	// Log receives a line per CRD stored and warnings; stdout when nil
+	Log io.Writer
+}
+
+// logWriter returns w, or stdout when w is nil
+func logWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
 }
 
 // NewCRDClient creates a new CRD client from REST config
@@ -41,8 +71,20 @@ func NewCRDClient(config *rest.Config) (*CRDClient, error) {
 		return nil, fmt.Errorf("failed to create apiextensions clientset: %w", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata client: %w", err)
+	}
+
 	return &CRDClient{
-		clientset: clientset,
+		clientset:      clientset,
+		dynamicClient:  dynamicClient,
+		metadataClient: metadataClient,
 	}, nil
 }
 
@@ -81,22 +123,73 @@ func (c *CRDClient) GetCRD(ctx context.Context, name string) (*CustomResourceDef
 func (c *CRDClient) convertCRD(crd *apiextv1.CustomResourceDefinition) CustomResourceDefinition {
 	versions := make([]CRDVersion, 0, len(crd.Spec.Versions))
 	for _, v := range crd.Spec.Versions {
-		versions = append(versions, CRDVersion{
-			Name:    v.Name,
-			Served:  v.Served,
-			Storage: v.Storage,
-		})
+		version := CRDVersion{
+			Name:       v.Name,
+			Served:     v.Served,
+			Storage:    v.Storage,
+			Deprecated: v.Deprecated,
+		}
+		if v.DeprecationWarning != nil {
+			version.DeprecationWarning = *v.DeprecationWarning
+		}
+		versions = append(versions, version)
 	}
 
-	return CustomResourceDefinition{
-		Name:        crd.Name,
-		Group:       crd.Spec.Group,
-		Versions:    versions,
-		Kind:        crd.Spec.Names.Kind,
-		Scope:       string(crd.Spec.Scope),
-		Labels:      crd.Labels,
-		Annotations: crd.Annotations,
+	result := CustomResourceDefinition{
+		Name:           crd.Name,
+		Group:          crd.Spec.Group,
+		Versions:       versions,
+		StoredVersions: crd.Status.StoredVersions,
+		Kind:           crd.Spec.Names.Kind,
+		Plural:         crd.Spec.Names.Plural,
+		Scope:          string(crd.Spec.Scope),
+		Labels:         crd.Labels,
+		Annotations:    crd.Annotations,
+	}
+	if conversion := crd.Spec.Conversion; conversion != nil && conversion.Strategy == apiextv1.WebhookConverter &&
+		conversion.Webhook != nil && conversion.Webhook.ClientConfig != nil {
+		if service := conversion.Webhook.ClientConfig.Service; service != nil {
+			result.ConversionService = service.Namespace + "/" + service.Name
+		} else if conversion.Webhook.ClientConfig.URL != nil {
+			result.ConversionURL = *conversion.Webhook.ClientConfig.URL
+		}
 	}
+	return result
+}
+
+// conversionServiceError tells why the conversion webhook service of a CRD can't serve: it
+// doesn't exist or has no ready endpoints, e.g. because its deployment was removed. Empty when it
+// can serve or is not in the cluster.
+func (c *CRDClient) conversionServiceError(ctx context.Context, crd CustomResourceDefinition) (string, error) {
+	if crd.ConversionService == "" {
+		return "", nil
+	}
+	namespace, name, _ := strings.Cut(crd.ConversionService, "/")
+
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	if _, err := c.dynamicClient.Resource(services).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("service %s not found", crd.ConversionService), nil
+		}
+		return "", fmt.Errorf("failed to get conversion webhook service of %s: %w", crd.Name, err)
+	}
+
+	endpoints := schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}
+	object, err := c.dynamicClient.Resource(endpoints).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get conversion webhook endpoints of %s: %w", crd.Name, err)
+	}
+	if err == nil {
+		subsets, _, _ := unstructured.NestedSlice(object.Object, "subsets")
+		for _, subset := range subsets {
+			if subset, ok := subset.(map[string]interface{}); ok {
+				if addresses, _, _ := unstructured.NestedSlice(subset, "addresses"); len(addresses) > 0 {
+					return "", nil
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("service %s has no ready endpoints", crd.ConversionService), nil
 }
 
 // GetHelmOwnerInfo extracts Helm owner information from CRD labels/annotations
@@ -118,50 +211,159 @@ func (c *CRDClient) GetHelmOwnerInfo(crd CustomResourceDefinition) (string, stri
 	return "", "", false
 }
 
-// GetCRDInstances retrieves instances of a specific CRD (placeholder)
-func (c *CRDClient) GetCRDInstances(ctx context.Context, crd CustomResourceDefinition) ([]interface{}, error) {
-	// This would require dynamic client to fetch actual CR instances
-	// Placeholder for future implementation
-	return nil, nil
+// GetCRDInstances lists the metadata of all instances of a CRD across namespaces, page by page,
+// with the version they were listed at; only what the inventory stores is transferred
+func (c *CRDClient) GetCRDInstances(ctx context.Context, crd CustomResourceDefinition) ([]metav1.PartialObjectMetadata, string, error) {
+	version := servedVersion(crd)
+	if version == "" {
+		return nil, "", nil
+	}
+
+	gvr := schema.GroupVersionResource{Group: crd.Group, Version: version, Resource: crd.Plural}
+	instances, err := listMetadata(ctx, c.metadataClient, gvr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list %s instances: %w", crd.Name, err)
+	}
+	return instances, gvr.GroupVersion().String(), nil
 }
 
-// StoreCRDsToInventory stores CRDs to the inventory database
-func (c *CRDClient) StoreCRDsToInventory(ctx context.Context, clusterID string, store *inventory.Store) error {
+// servedVersion returns the storage version if it is served, otherwise the first served version
+func servedVersion(crd CustomResourceDefinition) string {
+	version := ""
+	for _, v := range crd.Versions {
+		if !v.Served {
+			continue
+		}
+		if v.Storage {
+			return v.Name
+		}
+		if version == "" {
+			version = v.Name
+		}
+	}
+	return version
+}
+
+// InventoryCRD converts a CRD into its inventory record with its served versions, Helm owner,
+// conversion webhook and number of custom resources. When counting or checking the webhook fails,
+// the record is returned without that part and the error.
+func (c *CRDClient) InventoryCRD(ctx context.Context, crd CustomResourceDefinition) (inventory.ExportedCRD, error) {
+	entry, _, err := c.InventoryCRDWithResources(ctx, crd)
+	return entry, err
+}
+
+// InventoryCRDWithResources is InventoryCRD also returning the custom resources counted, nil when
+// listing them failed
+func (c *CRDClient) InventoryCRDWithResources(ctx context.Context, crd CustomResourceDefinition) (inventory.ExportedCRD, []inventory.ResourceEntry, error) {
+	servedVersions := make([]string, 0)
+	details := make([]inventory.CRDVersion, 0, len(crd.Versions))
+	for _, v := range crd.Versions {
+		if v.Served {
+			servedVersions = append(servedVersions, v.Name)
+		}
+		details = append(details, inventory.CRDVersion{
+			Name:               v.Name,
+			Served:             v.Served,
+			Storage:            v.Storage,
+			Deprecated:         v.Deprecated,
+			DeprecationWarning: v.DeprecationWarning,
+		})
+	}
+	helmOwnerName, helmOwnerNamespace, _ := c.GetHelmOwnerInfo(crd)
+
+	entry := inventory.ExportedCRD{
+		Name:               crd.Name,
+		Group:              crd.Group,
+		Kind:               crd.Kind,
+		Versions:           servedVersions,
+		StoredVersions:     crd.StoredVersions,
+		VersionDetails:     details,
+		Scope:              crd.Scope,
+		ConversionWebhook:  crd.ConversionService,
+		HelmOwnerName:      helmOwnerName,
+		HelmOwnerNamespace: helmOwnerNamespace,
+	}
+	if crd.ConversionURL != "" {
+		entry.ConversionWebhook = crd.ConversionURL
+	}
+
+	// A conversion webhook that can't serve fails reads and writes of the other versions
+	webhookError, webhookErr := c.conversionServiceError(ctx, crd)
+	entry.ConversionWebhookError = webhookError
+
+	// Count custom resources so deprecated operator APIs can be weighed by usage
+	instances, apiVersion, err := c.GetCRDInstances(ctx, crd)
+	if err != nil {
+		return entry, nil, fmt.Errorf("failed to count instances of %s: %w", crd.Name, err)
+	}
+	entry.InstanceCount = len(instances)
+	return entry, resourceEntries(instances, apiVersion, crd.Kind), webhookErr
+}
+
+// resourceEntries converts the metadata of objects listed at an apiVersion to inventory resources
+// with their owner references
+func resourceEntries(objects []metav1.PartialObjectMetadata, apiVersion, kind string) []inventory.ResourceEntry {
+	entries := make([]inventory.ResourceEntry, 0, len(objects))
+	for _, obj := range objects {
+		entries = append(entries, inventory.ResourceEntry{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  obj.Namespace,
+			Name:       obj.Name,
+			Labels:     obj.Labels,
+			Owners:     ownerReferences(obj.OwnerReferences),
+		})
+	}
+	return entries
+}
+
+// StoreCRDsToInventory stores CRDs to the inventory database, updating CRDs stored by an earlier
+// scan and deleting those no longer in the cluster. When ctx is done, the CRDs stored so far are
+// kept and the error says how far the scan got.
+func (c *CRDClient) StoreCRDsToInventory(ctx context.Context, clusterID string, store inventory.ScanStore) error {
 	crds, err := c.ListCRDs(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list CRDs: %w", err)
 	}
+	storeCtx := context.WithoutCancel(ctx)
 
-	for _, crd := range crds {
-		// Extract served versions
-		servedVersions := make([]string, 0)
-		for _, v := range crd.Versions {
-			if v.Served {
-				servedVersions = append(servedVersions, v.Name)
-			}
+	names := make([]string, 0, len(crds))
+	for i, crd := range crds {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d of %d CRDs: %w", i, len(crds), err)
 		}
-
-		// Get Helm owner info
-		helmOwnerName, helmOwnerNamespace, _ := c.GetHelmOwnerInfo(crd)
-
-		// Save to database
-		entCRD, err := store.GetClient().CRD.
-			Create().
-			SetName(crd.Name).
-			SetGroup(crd.Group).
-			SetKind(crd.Kind).
-			SetVersions(servedVersions).
-			SetClusterID(clusterID).
-			SetNillableHelmOwnerName(&helmOwnerName).
-			SetNillableHelmOwnerNamespace(&helmOwnerNamespace).
-			Save(ctx)
-
-		if err != nil {
-			return fmt.Errorf("failed to save CRD %s: %w", crd.Name, err)
+		if err := c.storeCRD(ctx, clusterID, store, crd); err != nil {
+			return err
 		}
+		names = append(names, crd.Name)
+		if c.Progress != nil {
+			c.Progress(i+1, len(crds))
+		}
+	}
 
-		fmt.Printf("Stored CRD: %s (Kind: %s, ID: %d)\n", crd.Name, crd.Kind, entCRD.ID)
+	return store.PruneCRDs(storeCtx, clusterID, names)
+}
+
+// storeCRD saves a CRD with its instance count and its custom resources; a failure to list them is
+// only a warning, and keeps the custom resources stored by an earlier scan
+func (c *CRDClient) storeCRD(ctx context.Context, clusterID string, store inventory.ScanStore, crd CustomResourceDefinition) error {
+	entry, resources, err := c.InventoryCRDWithResources(ctx, crd)
+	if err != nil {
+		fmt.Fprintf(logWriter(c.Log), "Warning: %v\n", err)
+	}
+
+	storeCtx := context.WithoutCancel(ctx)
+	entCRD, err := store.SaveScannedCRD(storeCtx, clusterID, entry)
+	if err != nil {
+		return fmt.Errorf("failed to save CRD %s: %w", crd.Name, err)
+	}
+	if resources != nil {
+		filter := inventory.ResourceFilter{Source: inventory.ResourceSourceCluster, Group: crd.Group, Kind: crd.Kind}
+		if err := store.ReplaceResources(storeCtx, clusterID, filter, resources); err != nil {
+			return fmt.Errorf("failed to save %s resources: %w", crd.Name, err)
+		}
 	}
 
+	fmt.Fprintf(logWriter(c.Log), "Stored CRD: %s (Kind: %s, ID: %d)\n", crd.Name, crd.Kind, entCRD.ID)
 	return nil
 }
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// dryRunFieldManager is the field manager of dry-run applies; nothing is persisted under it
+const dryRunFieldManager = "kube-upgrade-advisor"
+
+// DryRunClient submits manifests to the cluster with server-side dry runs
+type DryRunClient struct {
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+}
+
+// NewDryRunClient creates a new dry-run client from KubeClient
+func NewDryRunClient(kubeClient *KubeClient) (*DryRunClient, error) {
+	dynamicClient, err := dynamic.NewForConfig(kubeClient.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	discoveryClient := memory.NewMemCacheClient(kubeClient.GetClientset().Discovery())
+	return &DryRunClient{
+		dynamicClient: dynamicClient,
+		mapper:        restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient),
+	}, nil
+}
+
+// Apply submits a resource as a server-side apply with dryRun=All, so schema validation and
+// admission (including webhooks) run without persisting anything. Namespaced resources without a
+// namespace go to "default".
+func (c *DryRunClient) Apply(ctx context.Context, obj map[string]interface{}) error {
+	resource := &unstructured.Unstructured{Object: obj}
+	gvk := resource.GroupVersionKind()
+	if resource.GetName() == "" {
+		return fmt.Errorf("%s has no metadata.name", gvk.Kind)
+	}
+
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("failed to map %s: %w", gvk, err)
+	}
+
+	var client dynamic.ResourceInterface = c.dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace := resource.GetNamespace()
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		client = c.dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+	}
+
+	_, err = client.Apply(ctx, resource.GetName(), resource, metav1.ApplyOptions{
+		FieldManager: dryRunFieldManager,
+		Force:        true,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	return err
+}
+
+// DryRunReason classifies an Apply error, e.g. Invalid, Forbidden or NotServed for an API the
+// cluster doesn't serve
+func DryRunReason(err error) string {
+	if meta.IsNoMatchError(err) {
+		return "NotServed"
+	}
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return "Error"
+}
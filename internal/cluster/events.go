@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EventAnchor is the name of the ConfigMap advisor events are recorded on
+const EventAnchor = "kube-upgrade-advisor"
+
+// eventComponent is the source and reporting controller of advisor events
+const eventComponent = "kube-upgrade-advisor"
+
+// Event types, the values of corev1.EventTypeNormal and corev1.EventTypeWarning
+const (
+	EventNormal  = "Normal"
+	EventWarning = "Warning"
+)
+
+// Event reasons
+const (
+	EventReasonScanCompleted    = "ScanCompleted"
+	EventReasonScanIncomplete   = "ScanIncomplete"
+	EventReasonRiskLevelChanged = "RiskLevelChanged"
+)
+
+// EventRecorder emits Kubernetes Events on an anchor ConfigMap, so that event pipelines such as
+// Datadog or event routers pick up advisor activity. The anchor is created on first use.
+type EventRecorder struct {
+	kube      *KubeClient
+	namespace string
+	anchor    *corev1.ObjectReference
+}
+
+// NewEventRecorder creates a recorder for the anchor ConfigMap in namespace
+func (k *KubeClient) NewEventRecorder(namespace string) *EventRecorder {
+	return &EventRecorder{kube: k, namespace: namespace}
+}
+
+// Emit records an event of type EventNormal or EventWarning
+func (r *EventRecorder) Emit(ctx context.Context, eventType, reason, message string) error {
+	anchor, err := r.ensureAnchor(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	instance, _ := os.Hostname()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// Unique like the names client-go's recorder generates
+			Name:      fmt.Sprintf("%s.%x", anchor.Name, now.UnixNano()),
+			Namespace: r.namespace,
+		},
+		InvolvedObject:      *anchor,
+		Reason:              reason,
+		Message:             message,
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: eventComponent, Host: instance},
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+		ReportingController: eventComponent,
+		ReportingInstance:   instance,
+	}
+	if _, err := r.kube.clientset.CoreV1().Events(r.namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	return nil
+}
+
+// ensureAnchor gets or creates the anchor ConfigMap
+func (r *EventRecorder) ensureAnchor(ctx context.Context) (*corev1.ObjectReference, error) {
+	if r.anchor != nil {
+		return r.anchor, nil
+	}
+
+	configMaps := r.kube.clientset.CoreV1().ConfigMaps(r.namespace)
+	cm, err := configMaps.Get(ctx, EventAnchor, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      EventAnchor,
+				Namespace: r.namespace,
+				Labels:    map[string]string{"app.kubernetes.io/name": "kube-upgrade-advisor"},
+			},
+			Data: map[string]string{"purpose": "Anchor of kube-upgrade-advisor events"},
+		}, metav1.CreateOptions{})
+		// Another advisor may have created it meanwhile
+		if apierrors.IsAlreadyExists(err) {
+			cm, err = configMaps.Get(ctx, EventAnchor, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event anchor %s/%s: %w", r.namespace, EventAnchor, err)
+	}
+
+	r.anchor = &corev1.ObjectReference{
+		APIVersion:      "v1",
+		Kind:            "ConfigMap",
+		Namespace:       cm.Namespace,
+		Name:            cm.Name,
+		UID:             cm.UID,
+		ResourceVersion: cm.ResourceVersion,
+	}
+	return r.anchor, nil
+}
+
+// ScanEvent describes a finished scan: a warning naming the skipped subsystems or why the scan
+// stopped early, or a normal event when everything was scanned
+func ScanEvent(clusterID, version string, scanErrors map[string]string, incompleteReason string) (eventType, reason, message string) {
+	switch {
+	case incompleteReason != "":
+		return EventWarning, EventReasonScanIncomplete,
+			fmt.Sprintf("Scan of cluster %s (Kubernetes %s) stopped early: %s", clusterID, version, incompleteReason)
+	case len(scanErrors) > 0:
+		return EventWarning, EventReasonScanIncomplete,
+			fmt.Sprintf("Scanned cluster %s (Kubernetes %s), skipping %s", clusterID, version, strings.Join(inventory.ScanErrorSubsystems(scanErrors), ", "))
+	}
+	return EventNormal, EventReasonScanCompleted, fmt.Sprintf("Scanned cluster %s (Kubernetes %s)", clusterID, version)
+}
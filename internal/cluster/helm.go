@@ -3,52 +3,208 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
 )
 
 // HelmRelease represents a Helm release in the cluster
 type HelmRelease struct {
-	Name         string
-	Namespace    string
-	Chart        string
-	ChartVersion string
-	AppVersion   string
-	Status       string
-	Revision     int
-	Updated      string
-	Description  string
+	Name          string
+	Namespace     string
+	Chart         string
+	ChartVersion  string
+	AppVersion    string
+	Status        string
+	Revision      int
+	Updated       string
+	FirstDeployed time.Time
+	LastDeployed  time.Time
+	Description   string
+	// ChartURL is the first source URL of the chart, else its home page
+	ChartURL string
+	// APIs are those of the objects in the release manifest, as "apiVersion/Kind"
+	APIs []string
+}
+
+// Entry converts a release to its inventory entry
+func (r HelmRelease) Entry() inventory.HelmReleaseEntry {
+	return inventory.HelmReleaseEntry{
+		Name:          r.Name,
+		Namespace:     r.Namespace,
+		Chart:         r.Chart,
+		ChartVersion:  r.ChartVersion,
+		AppVersion:    r.AppVersion,
+		Status:        r.Status,
+		Revision:      r.Revision,
+		FirstDeployed: r.FirstDeployed,
+		LastDeployed:  r.LastDeployed,
+		ChartURL:      r.ChartURL,
+		APIs:          r.APIs,
+	}
+}
+
+// ReleaseFilter selects the releases a client lists, so failed or superseded releases and ones
+// still rolling out don't pollute the analysis. The zero filter selects all releases.
+type ReleaseFilter struct {
+	// Statuses are the release statuses to keep, e.g. deployed or failed; empty keeps all
+	Statuses []string
+	// DeployedOnly keeps only deployed releases
+	DeployedOnly bool
+	// MinAge keeps only releases last deployed at least this long ago
+	MinAge time.Duration
+}
+
+// ReleaseStatuses are the statuses of Helm releases
+var ReleaseStatuses = []string{
+	string(release.StatusUnknown), string(release.StatusDeployed), string(release.StatusUninstalled),
+	string(release.StatusSuperseded), string(release.StatusFailed), string(release.StatusUninstalling),
+	string(release.StatusPendingInstall), string(release.StatusPendingUpgrade), string(release.StatusPendingRollback),
+}
+
+// Validate checks the statuses of the filter
+func (f ReleaseFilter) Validate() error {
+	for _, status := range f.Statuses {
+		if !slices.Contains(ReleaseStatuses, status) {
+			return fmt.Errorf("unknown release status %q (supported: %s)", status, strings.Join(ReleaseStatuses, ", "))
+		}
+	}
+	if f.MinAge < 0 {
+		return fmt.Errorf("minimum release age must not be negative")
+	}
+	return nil
+}
+
+// Matches reports whether the filter keeps a release at a time
+func (f ReleaseFilter) Matches(rel HelmRelease, now time.Time) bool {
+	if f.DeployedOnly && rel.Status != string(release.StatusDeployed) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, rel.Status) {
+		return false
+	}
+	return f.MinAge == 0 || now.Sub(rel.LastDeployed) >= f.MinAge
+}
+
+// HelmOptions select the cluster, namespace and storage drivers a Helm client reads releases from
+type HelmOptions struct {
+	// Kubeconfig and Context select the cluster; empty uses $KUBECONFIG and its current context
+	Kubeconfig string
+	Context    string
+	// Namespace scopes the client to a namespace; empty means all namespaces
+	Namespace string
+	// Drivers are the storage drivers releases are listed from: secret, configmap or sql. Clusters
+	// migrated between drivers keep releases in both. Empty uses DefaultHelmDrivers.
+	Drivers []string
+	// Filter selects the releases listed
+	Filter ReleaseFilter
+}
+
+// HelmDrivers are the supported Helm storage drivers
+var HelmDrivers = []string{"secret", "configmap", "sql"}
+
+// DefaultHelmDrivers are the drivers of $HELM_DRIVER, comma-separated, or the secret driver as Helm
+// defaults to
+func DefaultHelmDrivers() []string {
+	if value := os.Getenv("HELM_DRIVER"); value != "" {
+		return strings.Split(value, ",")
+	}
+	return []string{"secret"}
 }
 
 // HelmClient handles Helm operations
 type HelmClient struct {
-	settings *cli.EnvSettings
+	settings  *cli.EnvSettings
+	namespace string
+	drivers   []string
+	filter    ReleaseFilter
+	// Progress, when set, is called with the number of releases stored so far
+	Progress func(processed, total int)
+	// Log receives a line per release stored; stdout when nil
+	Log io.Writer
+
+	// configs are the action configurations initialized so far, by driver and namespace
+	mu      sync.Mutex
+	configs map[string]*action.Configuration
 }
 
 // NewHelmClient creates a new Helm client
 func NewHelmClient() (*HelmClient, error) {
-	settings := cli.New()
-	return &HelmClient{
-		settings: settings,
-	}, nil
+	return NewHelmClientWithOptions(HelmOptions{})
 }
 
 // NewHelmClientWithKubeconfig creates a new Helm client with specific kubeconfig
 func NewHelmClientWithKubeconfig(kubeconfig string) (*HelmClient, error) {
+	return NewHelmClientWithOptions(HelmOptions{Kubeconfig: kubeconfig})
+}
+
+// NewHelmClientWithContext creates a new Helm client for a kubeconfig and context, scoped to a
+// namespace when namespace is set (empty means all namespaces)
+func NewHelmClientWithContext(kubeconfig, kubeContext, namespace string) (*HelmClient, error) {
+	return NewHelmClientWithOptions(HelmOptions{Kubeconfig: kubeconfig, Context: kubeContext, Namespace: namespace})
+}
+
+// NewHelmClientWithOptions creates a new Helm client for a cluster, namespace and storage drivers
+func NewHelmClientWithOptions(opts HelmOptions) (*HelmClient, error) {
+	if err := opts.Filter.Validate(); err != nil {
+		return nil, err
+	}
+	drivers := opts.Drivers
+	if len(drivers) == 0 {
+		drivers = DefaultHelmDrivers()
+	}
+	normalized := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		driver, err := normalizeHelmDriver(driver)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(normalized, driver) {
+			normalized = append(normalized, driver)
+		}
+	}
+
 	settings := cli.New()
-	if kubeconfig != "" {
-		settings.KubeConfig = kubeconfig
+	if opts.Kubeconfig != "" {
+		settings.KubeConfig = opts.Kubeconfig
+	}
+	if opts.Context != "" {
+		settings.KubeContext = opts.Context
 	}
 	return &HelmClient{
-		settings: settings,
+		settings:  settings,
+		namespace: opts.Namespace,
+		drivers:   normalized,
+		filter:    opts.Filter,
+		configs:   make(map[string]*action.Configuration),
 	}, nil
 }
 
+// normalizeHelmDriver validates a driver name, accepting the plural forms Helm also accepts
+func normalizeHelmDriver(name string) (string, error) {
+	driver := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), "s")
+	if !slices.Contains(HelmDrivers, driver) {
+		return "", fmt.Errorf("unsupported Helm driver %q (supported: %s)", name, strings.Join(HelmDrivers, ", "))
+	}
+	return driver, nil
+}
+
+// Drivers returns the storage drivers the client lists releases from
+func (h *HelmClient) Drivers() []string {
+	return slices.Clone(h.drivers)
+}
+
 // ListReleases lists all Helm releases across all namespaces
 func (h *HelmClient) ListReleases(ctx context.Context) ([]HelmRelease, error) {
 	return h.ListReleasesInNamespace(ctx, "")
@@ -56,49 +212,154 @@ func (h *HelmClient) ListReleases(ctx context.Context) ([]HelmRelease, error) {
 
 // ListReleasesInNamespace lists Helm releases in a specific namespace
 // Pass empty string for all namespaces
+// With several drivers, they are listed concurrently; a release found by more than one is returned
+// once, as stored by the driver with its highest revision.
+// Only the releases selected by the client's filter are returned; a release whose latest revision
+// is failed, pending or too recent is returned at its last revision the filter keeps.
 func (h *HelmClient) ListReleasesInNamespace(ctx context.Context, namespace string) ([]HelmRelease, error) {
-	var releases []HelmRelease
-
-	// If namespace is empty, we need to list across all namespaces
-	if namespace == "" {
-		// Get all namespaces by iterating through them
-		// Helm doesn't have a built-in "all namespaces" feature in action config
-		// So we use the --all-namespaces equivalent approach
+	results := make([][]*release.Release, len(h.drivers))
+	errs := make([]error, len(h.drivers))
+	var wg sync.WaitGroup
+	for i, driver := range h.drivers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = h.listReleases(ctx, namespace, driver)
+		}()
+	}
+	wg.Wait()
+
+	var releases []*release.Release
+	var drivers []string // of each release
+	seen := make(map[string]int)
+	for i, driver := range h.drivers {
+		if errs[i] != nil {
+			if len(h.drivers) > 1 {
+				return nil, fmt.Errorf("%s driver: %w", driver, errs[i])
+			}
+			return nil, errs[i]
+		}
+		for _, rel := range results[i] {
+			key := rel.Namespace + "/" + rel.Name
+			if j, found := seen[key]; !found {
+				seen[key] = len(releases)
+				releases = append(releases, rel)
+				drivers = append(drivers, driver)
+			} else if rel.Version > releases[j].Version {
+				releases[j], drivers[j] = rel, driver
+			}
+		}
+	}
 
-		actionConfig, err := h.getActionConfig("")
-		if err != nil {
-			return nil, fmt.Errorf("failed to get action config: %w", err)
+	converted := h.convertReleases(releases)
+	filtered := converted[:0]
+	now := time.Now()
+	for i, rel := range converted {
+		if h.filter.Matches(rel, now) {
+			filtered = append(filtered, rel)
+		} else if kept, ok := h.fallbackRevision(rel, drivers[i], now); ok {
+			filtered = append(filtered, kept)
 		}
+	}
+	return filtered, nil
+}
 
-		listClient := action.NewList(actionConfig)
-		listClient.AllNamespaces = true
-		listClient.All = true // Include all releases (deployed, failed, etc.)
+// inFlightStatuses are the statuses of a release revision still being rolled out, or whose rollout
+// failed while the previous revision kept running
+var inFlightStatuses = []string{
+	string(release.StatusFailed), string(release.StatusPendingInstall),
+	string(release.StatusPendingUpgrade), string(release.StatusPendingRollback),
+}
 
-		results, err := listClient.Run()
-		if err != nil {
-			return nil, fmt.Errorf("failed to list releases: %w", err)
-		}
+// fallbackRevision returns the revision to keep of a release whose latest revision the filter drops
+// because it is failed, pending or too recent: the newest earlier revision the filter keeps, e.g. the
+// one still deployed while an upgrade is pending. A release dropped only for its age whose earlier
+// revisions don't match either is kept at its latest revision with a warning.
+func (h *HelmClient) fallbackRevision(latest HelmRelease, driver string, now time.Time) (HelmRelease, bool) {
+	recent := h.filter.MinAge > 0 && now.Sub(latest.LastDeployed) < h.filter.MinAge
+	if !recent && !slices.Contains(inFlightStatuses, latest.Status) {
+		return HelmRelease{}, false
+	}
 
-		releases = h.convertReleases(results)
-	} else {
-		// List releases in specific namespace
-		actionConfig, err := h.getActionConfig(namespace)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get action config for namespace %s: %w", namespace, err)
+	history, err := h.releaseHistory(latest.Name, latest.Namespace, driver)
+	if err != nil {
+		fmt.Fprintf(logWriter(h.Log), "Warning: keeping Helm release %s/%s at revision %d: %v\n", latest.Namespace, latest.Name, latest.Revision, err)
+		return latest, true
+	}
+	slices.SortFunc(history, func(a, b *release.Release) int { return b.Version - a.Version })
+	for _, revision := range h.convertReleases(history) {
+		if revision.Revision < latest.Revision && h.filter.Matches(revision, now) {
+			return revision, true
 		}
+	}
+
+	statusOnly := ReleaseFilter{Statuses: h.filter.Statuses, DeployedOnly: h.filter.DeployedOnly}
+	if recent && statusOnly.Matches(latest, now) {
+		fmt.Fprintf(logWriter(h.Log), "Warning: keeping Helm release %s/%s at revision %d, deployed less than %s ago\n", latest.Namespace, latest.Name, latest.Revision, h.filter.MinAge)
+		return latest, true
+	}
+	return HelmRelease{}, false
+}
 
-		listClient := action.NewList(actionConfig)
-		listClient.All = true
+// releaseHistory returns the revisions of a release stored by a driver
+func (h *HelmClient) releaseHistory(name, namespace, driver string) ([]*release.Release, error) {
+	actionConfig, err := h.actionConfig(namespace, driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action config: %w", err)
+	}
 
-		results, err := listClient.Run()
-		if err != nil {
-			return nil, fmt.Errorf("failed to list releases in namespace %s: %w", namespace, err)
+	historyClient := action.NewHistory(actionConfig)
+	historyClient.Max = 256
+	history, err := historyClient.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history for release %s: %w", name, err)
+	}
+	return history, nil
+}
+
+// listReleases lists the releases of all statuses (deployed, failed, etc.) stored by one driver
+func (h *HelmClient) listReleases(ctx context.Context, namespace, driver string) ([]*release.Release, error) {
+	actionConfig, err := h.actionConfig(namespace, driver)
+	if err != nil {
+		if namespace == "" {
+			return nil, fmt.Errorf("failed to get action config: %w", err)
 		}
+		return nil, fmt.Errorf("failed to get action config for namespace %s: %w", namespace, err)
+	}
 
-		releases = h.convertReleases(results)
+	listClient := action.NewList(actionConfig)
+	listClient.All = true
+	// Helm has no "all namespaces" action config, so an empty namespace lists as --all-namespaces
+	listClient.AllNamespaces = namespace == ""
+
+	results, err := runList(ctx, listClient)
+	if err != nil {
+		if namespace == "" {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		return nil, fmt.Errorf("failed to list releases in namespace %s: %w", namespace, err)
 	}
+	return results, nil
+}
 
-	return releases, nil
+// runList runs a Helm list, which takes no context, returning early when ctx is done
+func runList(ctx context.Context, listClient *action.List) ([]*release.Release, error) {
+	type result struct {
+		releases []*release.Release
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		releases, err := listClient.Run()
+		done <- result{releases, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.releases, r.err
+	}
 }
 
 // GetRelease retrieves a specific Helm release
@@ -154,8 +415,32 @@ func (h *HelmClient) GetReleaseValues(ctx context.Context, name, namespace strin
 	return values, nil
 }
 
-// getActionConfig creates an action configuration for Helm operations
+// getActionConfig returns the action configuration of the first driver for Helm operations on a
+// single release
 func (h *HelmClient) getActionConfig(namespace string) (*action.Configuration, error) {
+	return h.actionConfig(namespace, h.drivers[0])
+}
+
+// actionConfig returns the action configuration of a namespace and driver, initialized once per
+// client and reused, so listing or reading many releases doesn't set up Helm again each time
+func (h *HelmClient) actionConfig(namespace, driver string) (*action.Configuration, error) {
+	key := driver + "/" + namespace
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if actionConfig, ok := h.configs[key]; ok {
+		return actionConfig, nil
+	}
+
+	actionConfig, err := h.newActionConfig(namespace, driver)
+	if err != nil {
+		return nil, err
+	}
+	h.configs[key] = actionConfig
+	return actionConfig, nil
+}
+
+// newActionConfig creates an action configuration for Helm operations
+func (h *HelmClient) newActionConfig(namespace, driver string) (*action.Configuration, error) {
 	actionConfig := new(action.Configuration)
 
 	// Set namespace
@@ -167,10 +452,17 @@ func (h *HelmClient) getActionConfig(namespace string) (*action.Configuration, e
 	configFlags := &genericclioptions.ConfigFlags{
 		Namespace:  &namespace,
 		KubeConfig: &h.settings.KubeConfig,
+		Context:    &h.settings.KubeContext,
+		WrapConfigFn: func(config *rest.Config) *rest.Config {
+			if err := configureTransport(config); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			return config
+		},
 	}
 
 	// Initialize action configuration
-	if err := actionConfig.Init(configFlags, namespace, "secret", log.Printf); err != nil {
+	if err := actionConfig.Init(configFlags, namespace, driver, log.Printf); err != nil {
 		return nil, fmt.Errorf("failed to initialize action config: %w", err)
 	}
 
@@ -185,58 +477,69 @@ func (h *HelmClient) convertReleases(releases []*release.Release) []HelmRelease
 		chartVersion := ""
 		chartName := ""
 		appVersion := ""
+		chartURL := ""
 
 		if rel.Chart != nil {
 			if rel.Chart.Metadata != nil {
 				chartName = rel.Chart.Metadata.Name
 				chartVersion = rel.Chart.Metadata.Version
 				appVersion = rel.Chart.Metadata.AppVersion
+				chartURL = rel.Chart.Metadata.Home
+				if len(rel.Chart.Metadata.Sources) > 0 && rel.Chart.Metadata.Sources[0] != "" {
+					chartURL = rel.Chart.Metadata.Sources[0]
+				}
 			}
 		}
 
 		results = append(results, HelmRelease{
-			Name:         rel.Name,
-			Namespace:    rel.Namespace,
-			Chart:        chartName,
-			ChartVersion: chartVersion,
-			AppVersion:   appVersion,
-			Status:       string(rel.Info.Status),
-			Revision:     rel.Version,
-			Updated:      rel.Info.LastDeployed.String(),
-			Description:  rel.Info.Description,
+			Name:          rel.Name,
+			Namespace:     rel.Namespace,
+			Chart:         chartName,
+			ChartVersion:  chartVersion,
+			AppVersion:    appVersion,
+			Status:        string(rel.Info.Status),
+			Revision:      rel.Version,
+			Updated:       rel.Info.LastDeployed.String(),
+			FirstDeployed: rel.Info.FirstDeployed.Time,
+			LastDeployed:  rel.Info.LastDeployed.Time,
+			ChartURL:      chartURL,
+			Description:   rel.Info.Description,
+			APIs:          inventory.ManifestAPIs(rel.Manifest),
 		})
 	}
 
 	return results
 }
 
-// StoreReleasesToInventory stores Helm releases to the inventory database
-func (h *HelmClient) StoreReleasesToInventory(ctx context.Context, clusterID string, store *inventory.Store) error {
-	releases, err := h.ListReleases(ctx)
+// StoreReleasesToInventory stores Helm releases to the inventory database. When ctx is done, the
+// releases stored so far are kept and the error says how far the scan got.
+func (h *HelmClient) StoreReleasesToInventory(ctx context.Context, clusterID string, store inventory.ScanStore) error {
+	releases, err := h.ListReleasesInNamespace(ctx, h.namespace)
 	if err != nil {
 		return fmt.Errorf("failed to list releases: %w", err)
 	}
+	storeCtx := context.WithoutCancel(ctx)
 
-	fmt.Printf("Found %d Helm releases\n", len(releases))
+	fmt.Fprintf(logWriter(h.Log), "Found %d Helm releases\n", len(releases))
 
-	for _, rel := range releases {
-		// Create HelmReleaseEntry
-		entry := inventory.HelmReleaseEntry{
-			Name:         rel.Name,
-			Namespace:    rel.Namespace,
-			Chart:        rel.Chart,
-			ChartVersion: rel.ChartVersion,
-			AppVersion:   rel.AppVersion,
-			Status:       rel.Status,
+	for i, rel := range releases {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d of %d Helm releases: %w", i, len(releases), err)
 		}
 
+		// Create HelmReleaseEntry
+		entry := rel.Entry()
+
 		// Save to database
-		entRelease, err := store.SaveHelmRelease(ctx, clusterID, entry)
+		entRelease, err := store.SaveHelmRelease(storeCtx, clusterID, entry)
 		if err != nil {
 			return fmt.Errorf("failed to save helm release %s/%s: %w", rel.Namespace, rel.Name, err)
 		}
 
-		fmt.Printf("Stored Helm release: %s/%s (ID: %d)\n", rel.Namespace, rel.Name, entRelease.ID)
+		fmt.Fprintf(logWriter(h.Log), "Stored Helm release: %s/%s (ID: %d)\n", rel.Namespace, rel.Name, entRelease.ID)
+		if h.Progress != nil {
+			h.Progress(i+1, len(releases))
+		}
 	}
 
 	return nil
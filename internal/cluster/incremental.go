@@ -0,0 +1,256 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// ErrWatermarkExpired means the API server no longer has the changes since a watermark, e.g. after
+// etcd compaction; the resource type has to be listed in full
+var ErrWatermarkExpired = errors.New("resourceVersion watermark expired")
+
+// watchIdleTimeout ends a watch that delivered no event for this long: the watch cache replays the
+// changes since a watermark at once, so a quiet watch has caught up
+const watchIdleTimeout = 2 * time.Second
+
+// ResourceType is a kind whose changes an incremental scan follows from a resourceVersion watermark
+type ResourceType struct {
+	// Name keys the watermark in the inventory, e.g. "crds"
+	Name          string
+	GVR           schema.GroupVersionResource
+	Namespace     string
+	LabelSelector string
+}
+
+// CRDResources are the CustomResourceDefinitions of the cluster
+var CRDResources = ResourceType{
+	Name: inventory.ScanCRDs,
+	GVR:  schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+}
+
+// NodeResources are the nodes, whose os/arch make up the node platforms
+var NodeResources = ResourceType{
+	Name: inventory.ScanNodePlatforms,
+	GVR:  schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+}
+
+// HelmReleaseResources are the Secrets of Helm's default storage driver holding the releases of a
+// namespace, or of all namespaces when empty
+func HelmReleaseResources(namespace string) ResourceType {
+	name := inventory.ScanHelm
+	if namespace != "" {
+		name += "/" + namespace
+	}
+	return ResourceType{
+		Name:          name,
+		GVR:           schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+		Namespace:     namespace,
+		LabelSelector: "owner=helm",
+	}
+}
+
+// Change is an object added, modified or deleted since a watermark
+type Change struct {
+	Type   watch.EventType
+	Object *unstructured.Unstructured
+}
+
+// changeSet keeps the latest change per object, in the order the objects first changed
+type changeSet struct {
+	changes []Change
+	index   map[string]int
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{changes: make([]Change, 0), index: make(map[string]int)}
+}
+
+func (s *changeSet) add(change Change) {
+	key := change.Object.GetNamespace() + "/" + change.Object.GetName()
+	if i, seen := s.index[key]; seen {
+		s.changes[i] = change
+		return
+	}
+	s.index[key] = len(s.changes)
+	s.changes = append(s.changes, change)
+}
+
+func (k *KubeClient) resource(rt ResourceType) (dynamic.ResourceInterface, error) {
+	dynamicClient, err := dynamic.NewForConfig(k.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	return dynamicClient.Resource(rt.GVR).Namespace(rt.Namespace), nil
+}
+
+// Watermark returns the current resourceVersion of a resource type, to take before listing it so
+// that no change made during the scan is missed by the next incremental scan
+func (k *KubeClient) Watermark(ctx context.Context, rt ResourceType) (string, error) {
+	resource, err := k.resource(rt)
+	if err != nil {
+		return "", err
+	}
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: rt.LabelSelector, Limit: 1})
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", rt.GVR.Resource, err)
+	}
+	return list.GetResourceVersion(), nil
+}
+
+// ChangesSince returns the objects of a resource type changed after watermark since up to watermark
+// until, with the latest change per object in the order the objects first changed. It watches from
+// since and returns ErrWatermarkExpired when the API server has compacted the changes away.
+func (k *KubeClient) ChangesSince(ctx context.Context, rt ResourceType, since, until string) ([]Change, error) {
+	if since == until {
+		return nil, nil
+	}
+	resource, err := k.resource(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := resource.Watch(ctx, metav1.ListOptions{
+		LabelSelector:       rt.LabelSelector,
+		ResourceVersion:     since,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		if isExpired(err) {
+			return nil, ErrWatermarkExpired
+		}
+		return nil, fmt.Errorf("failed to watch %s: %w", rt.GVR.Resource, err)
+	}
+	defer watcher.Stop()
+
+	changes := newChangeSet()
+	idle := time.NewTimer(watchIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-idle.C:
+			return changes.changes, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watch of %s closed before catching up", rt.GVR.Resource)
+			}
+			if event.Type == watch.Error {
+				err := apierrors.FromObject(event.Object)
+				if isExpired(err) {
+					return nil, ErrWatermarkExpired
+				}
+				return nil, fmt.Errorf("failed to watch %s: %w", rt.GVR.Resource, err)
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+
+			if event.Type != watch.Bookmark {
+				changes.add(Change{Type: event.Type, Object: obj})
+			}
+			if reached(obj.GetResourceVersion(), until) {
+				return changes.changes, nil
+			}
+			idle.Reset(watchIdleTimeout)
+		}
+	}
+}
+
+// isExpired reports whether the API server rejected a resourceVersion as too old
+func isExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+// reached reports whether resourceVersion rv is at or past until. resourceVersions are opaque, but
+// etcd-backed API servers issue increasing integers; others rely on the idle timeout.
+func reached(rv, until string) bool {
+	current, err := strconv.ParseUint(rv, 10, 64)
+	if err != nil {
+		return false
+	}
+	target, err := strconv.ParseUint(until, 10, 64)
+	if err != nil {
+		return false
+	}
+	return current >= target
+}
+
+// ApplyCRDChanges updates the inventory in place for the CRDs changed since the last scan: changed
+// CRDs are stored again with a fresh instance count, deleted ones are removed. Instance counts of
+// unchanged CRDs are kept from the last scan.
+func (c *CRDClient) ApplyCRDChanges(ctx context.Context, clusterID string, store inventory.ScanStore, changes []Change) error {
+	deleted := make([]string, 0)
+	for i, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d of %d changed CRDs: %w", i, len(changes), err)
+		}
+		if change.Type == watch.Deleted {
+			deleted = append(deleted, change.Object.GetName())
+			fmt.Fprintf(logWriter(c.Log), "Deleted CRD: %s\n", change.Object.GetName())
+		} else {
+			var crd apiextv1.CustomResourceDefinition
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(change.Object.Object, &crd); err != nil {
+				return fmt.Errorf("failed to decode CRD %s: %w", change.Object.GetName(), err)
+			}
+			if err := c.storeCRD(ctx, clusterID, store, c.convertCRD(&crd)); err != nil {
+				return err
+			}
+		}
+		if c.Progress != nil {
+			c.Progress(i+1, len(changes))
+		}
+	}
+
+	return store.DeleteCRDs(context.WithoutCancel(ctx), clusterID, deleted)
+}
+
+// ApplyReleaseChanges updates the inventory in place for the Helm releases changed since the last
+// scan, by listing the releases again in each namespace whose release Secrets changed
+func (h *HelmClient) ApplyReleaseChanges(ctx context.Context, clusterID string, store inventory.ScanStore, changes []Change) error {
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		seen[change.Object.GetNamespace()] = true
+	}
+	namespaces := make([]string, 0, len(seen))
+	for ns := range seen {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for i, ns := range namespaces {
+		releases, err := h.ListReleasesInNamespace(ctx, ns)
+		if err != nil {
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		entries := make([]inventory.HelmReleaseEntry, 0, len(releases))
+		for _, rel := range releases {
+			entries = append(entries, rel.Entry())
+		}
+		if err := store.ReplaceHelmReleases(context.WithoutCancel(ctx), clusterID, ns, entries); err != nil {
+			return err
+		}
+		fmt.Fprintf(logWriter(h.Log), "Updated %d Helm releases in namespace %s\n", len(entries), ns)
+		if h.Progress != nil {
+			h.Progress(i+1, len(namespaces))
+		}
+	}
+
+	return nil
+}
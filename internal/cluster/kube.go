@@ -3,7 +3,13 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,6 +39,36 @@ func NewKubeClient(kubeconfig string) (*KubeClient, error) {
 			return nil, fmt.Errorf("failed to build config from kubeconfig: %w", err)
 		}
 	}
+	if err := configureTransport(config); err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	return &KubeClient{
+		clientset: clientset,
+		config:    config,
+	}, nil
+}
+
+// NewKubeClientFromFlags creates a client the way kubectl does: an explicit kubeconfig wins, otherwise
+// the files listed in $KUBECONFIG (or ~/.kube/config) are merged, falling back to in-cluster config.
+// kubeContext overrides the current context when set.
+func NewKubeClientFromFlags(kubeconfig, kubeContext string) (*KubeClient, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if err := configureTransport(config); err != nil {
+		return nil, err
+	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -45,6 +81,32 @@ func NewKubeClient(kubeconfig string) (*KubeClient, error) {
 	}, nil
 }
 
+// configureTransport applies the outbound connection settings of the network package, tracing and
+// the client options to a client configuration. A configured CA bundle is trusted besides the cluster CA of the
+// kubeconfig, e.g. for a proxy intercepting TLS.
+func configureTransport(config *rest.Config) error {
+	opts := network.Current()
+	if opts.Proxy != "" {
+		config.Proxy = network.Proxy
+	}
+	switch caData := network.CAData(); {
+	case opts.InsecureSkipTLSVerify:
+		config.Insecure = true
+		config.CAFile, config.CAData = "", nil
+	case caData != nil && config.CAFile != "":
+		clusterCA, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read cluster CA: %w", err)
+		}
+		config.CAFile, config.CAData = "", append(append(clusterCA, '\n'), caData...)
+	case caData != nil && len(config.CAData) > 0:
+		config.CAData = append(append(config.CAData[:len(config.CAData):len(config.CAData)], '\n'), caData...)
+	}
+	config.Wrap(telemetry.WrapTransport)
+	configureRateLimits(config)
+	return nil
+}
+
 // NewKubeClientInCluster creates a new Kubernetes client using in-cluster config
 func NewKubeClientInCluster() (*KubeClient, error) {
 	return NewKubeClient("")
@@ -83,7 +145,8 @@ func (k *KubeClient) GetServerVersionInfo(ctx context.Context) (*ServerVersionIn
 // ListAPIResources lists all API resources in the cluster
 func (k *KubeClient) ListAPIResources(ctx context.Context) ([]APIResource, error) {
 	_, apiResourceLists, err := k.clientset.Discovery().ServerGroupsAndResources()
-	if err != nil {
+	// an unavailable aggregated API (e.g. metrics-server) fails only its own group
+	if err != nil && !(discovery.IsGroupDiscoveryFailedError(err) && len(apiResourceLists) > 0) {
 		return nil, fmt.Errorf("failed to list API resources: %w", err)
 	}
 
@@ -103,6 +166,24 @@ func (k *KubeClient) ListAPIResources(ctx context.Context) ([]APIResource, error
 	return resources, nil
 }
 
+// ServedAPIs returns the kinds served per group/version, e.g. "apps/v1" -> [Deployment, ...].
+// Subresources such as deployments/scale are left out.
+func (k *KubeClient) ServedAPIs(ctx context.Context) (map[string][]string, error) {
+	resources, err := k.ListAPIResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	served := make(map[string][]string)
+	for _, resource := range resources {
+		if strings.Contains(resource.Name, "/") {
+			continue
+		}
+		served[resource.Group] = append(served[resource.Group], resource.Kind)
+	}
+	return served, nil
+}
+
 // GetClientset returns the underlying Kubernetes clientset
 func (k *KubeClient) GetClientset() *kubernetes.Clientset {
 	return k.clientset
@@ -130,7 +211,13 @@ type ServerVersionInfo struct {
 type APIResource struct {
 	Name       string
 	Kind       string
-	Group      string
+	Group      string // group/version, e.g. "apps/v1" or "v1"
 	Namespaced bool
 	Verbs      []string
 }
+
+// IsPermissionError reports whether the API server rejected a request for lack of authentication or
+// RBAC permissions
+func IsPermissionError(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err)
+}
@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceInfo is what a scan records about the cluster's namespaces
+type NamespaceInfo struct {
+	// Criticality maps the namespaces declaring one, with one of inventory.CriticalityKeys as a
+	// label or annotation, to it in lower case
+	Criticality map[string]string
+	// Terminating lists the namespaces being deleted
+	Terminating []string
+}
+
+// Namespaces lists the cluster's namespaces for their criticality and deletion state
+func (k *KubeClient) Namespaces(ctx context.Context) (*NamespaceInfo, error) {
+	namespaces, err := k.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	info := &NamespaceInfo{Criticality: make(map[string]string)}
+	for _, namespace := range namespaces.Items {
+		if namespace.Status.Phase == corev1.NamespaceTerminating || namespace.DeletionTimestamp != nil {
+			info.Terminating = append(info.Terminating, namespace.Name)
+		}
+		for _, key := range inventory.CriticalityKeys {
+			value := namespace.Labels[key]
+			if value == "" {
+				value = namespace.Annotations[key]
+			}
+			if value != "" {
+				info.Criticality[namespace.Name] = strings.ToLower(value)
+				break
+			}
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"gopkg.in/yaml.v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodePoolLabels are the labels naming the node pool of a node on managed platforms and Karpenter
+var nodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+}
+
+// configKinds maps the kinds of component configuration files to their component
+var configKinds = map[string]string{
+	"KubeletConfiguration":   "kubelet",
+	"KubeProxyConfiguration": "kube-proxy",
+}
+
+// CollectNodeConfigs reads the kubelet configuration of one node per kubelet version and node
+// pool from its configz endpoint, and the kubelet and kube-proxy configuration files kept in
+// kube-system ConfigMaps, e.g. by kubeadm. Failures are warnings: the checks then run on what
+// could be read.
+func (k *KubeClient) CollectNodeConfigs(ctx context.Context, state *inventory.LiveClusterState) {
+	sampled := make(map[string][]string)
+	var groups []string
+	for _, node := range state.Nodes {
+		group := node.KubeletVersion
+		for _, label := range nodePoolLabels {
+			if pool := node.Labels[label]; pool != "" {
+				group += "/" + pool
+				break
+			}
+		}
+		if _, ok := sampled[group]; !ok {
+			groups = append(groups, group)
+		}
+		sampled[group] = append(sampled[group], node.Name)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		nodes := sampled[group]
+		config, err := k.kubeletConfigz(ctx, nodes[0])
+		if err != nil {
+			fmt.Printf("Warning: failed to read kubelet configuration of node %s: %v\n", nodes[0], err)
+			if apierrors.IsForbidden(err) {
+				break
+			}
+			continue
+		}
+		source := "node " + nodes[0]
+		if len(nodes) > 1 {
+			source = fmt.Sprintf("node %s and %d more like it", nodes[0], len(nodes)-1)
+		}
+		state.NodeConfigs = append(state.NodeConfigs, inventory.NodeConfigEntry{Component: "kubelet", Source: source, Config: config})
+	}
+
+	configMaps, err := k.clientset.CoreV1().ConfigMaps("kube-system").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list kube-system configmaps: %v\n", err)
+		return
+	}
+	for _, configMap := range configMaps.Items {
+		keys := make([]string, 0, len(configMap.Data))
+		for key := range configMap.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			data := configMap.Data[key]
+			if !strings.Contains(data, "Configuration") {
+				continue
+			}
+			var config map[string]interface{}
+			if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+				continue
+			}
+			kind, _ := config["kind"].(string)
+			component, ok := configKinds[kind]
+			if !ok {
+				continue
+			}
+			state.NodeConfigs = append(state.NodeConfigs, inventory.NodeConfigEntry{
+				Component: component,
+				Source:    fmt.Sprintf("configmap kube-system/%s[%s]", configMap.Name, key),
+				Config:    config,
+			})
+		}
+	}
+}
+
+// kubeletConfigz reads the running configuration of a node's kubelet through the API server's
+// node proxy, which needs get on nodes/proxy
+func (k *KubeClient) kubeletConfigz(ctx context.Context, node string) (map[string]interface{}, error) {
+	data, err := k.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node, "proxy", "configz").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var configz struct {
+		KubeletConfig map[string]interface{} `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(data, &configz); err != nil {
+		return nil, fmt.Errorf("failed to parse configz: %w", err)
+	}
+	return configz.KubeletConfig, nil
+}
@@ -0,0 +1,236 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// mirrorPodAnnotation marks static pods managed directly by the kubelet
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// CollectLiveState gathers nodes, pods and PodDisruptionBudgets used by live cluster checks
+func (k *KubeClient) CollectLiveState(ctx context.Context) (*inventory.LiveClusterState, error) {
+	nodes, err := k.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	podList, err := k.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pdbList, err := k.clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+
+	// Resolve PDB selectors once so pods can be matched against them
+	type pdbSelector struct {
+		key       string
+		namespace string
+		selector  labels.Selector
+	}
+
+	workloads, err := k.ListWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &inventory.LiveClusterState{
+		Workloads:            workloads,
+		Nodes:                nodes,
+		Pods:                 make([]inventory.PodEntry, 0, len(podList.Items)),
+		PodDisruptionBudgets: make([]inventory.PodDisruptionBudgetEntry, 0, len(pdbList.Items)),
+	}
+
+	selectors := make([]pdbSelector, 0, len(pdbList.Items))
+	for _, pdb := range pdbList.Items {
+		state.PodDisruptionBudgets = append(state.PodDisruptionBudgets, inventory.PodDisruptionBudgetEntry{
+			Name:               pdb.Name,
+			Namespace:          pdb.Namespace,
+			DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+		})
+
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			fmt.Printf("Warning: invalid selector on PDB %s/%s: %v\n", pdb.Namespace, pdb.Name, err)
+			continue
+		}
+		selectors = append(selectors, pdbSelector{
+			key:       pdb.Namespace + "/" + pdb.Name,
+			namespace: pdb.Namespace,
+			selector:  selector,
+		})
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		entry := convertPod(pod)
+
+		for _, s := range selectors {
+			if s.namespace == pod.Namespace && s.selector.Matches(labels.Set(pod.Labels)) {
+				entry.DisruptionBudgets = append(entry.DisruptionBudgets, s.key)
+			}
+		}
+
+		state.Pods = append(state.Pods, entry)
+	}
+
+	if err := k.CollectStorageState(ctx, state); err != nil {
+		return nil, err
+	}
+
+	ingresses, err := k.ListIngresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state.Ingresses = ingresses
+
+	k.CollectNodeConfigs(ctx, state)
+
+	runtimeClasses, err := k.ListRuntimeClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state.RuntimeClasses = runtimeClasses
+
+	return state, nil
+}
+
+// ListNodes lists all nodes in the cluster
+func (k *KubeClient) ListNodes(ctx context.Context) ([]inventory.NodeEntry, error) {
+	nodeList, err := k.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	nodes := make([]inventory.NodeEntry, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, convertNode(&nodeList.Items[i]))
+	}
+
+	return nodes, nil
+}
+
+// NodePlatforms lists the distinct os/arch platforms of the nodes, e.g. "linux/amd64"
+func (k *KubeClient) NodePlatforms(ctx context.Context) ([]string, error) {
+	nodes, err := k.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.NodePlatforms(nodes), nil
+}
+
+// convertNode converts a k8s node to its inventory representation
+func convertNode(node *corev1.Node) inventory.NodeEntry {
+	ready := false
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			ready = cond.Status == corev1.ConditionTrue
+			break
+		}
+	}
+
+	return inventory.NodeEntry{
+		Name:              node.Name,
+		Labels:            node.Labels,
+		Ready:             ready,
+		Unschedulable:     node.Spec.Unschedulable,
+		AllocatableCPU:    node.Status.Allocatable.Cpu().MilliValue(),
+		AllocatableMemory: node.Status.Allocatable.Memory().Value(),
+		OS:                node.Status.NodeInfo.OperatingSystem,
+		Arch:              node.Status.NodeInfo.Architecture,
+		KubeletVersion:    node.Status.NodeInfo.KubeletVersion,
+		ExtendedResources: extendedResources(node.Status.Allocatable),
+	}
+}
+
+// extendedResources returns the allocatable resources advertised by device plugins, i.e. those
+// with a domain other than kubernetes.io
+func extendedResources(allocatable corev1.ResourceList) map[string]int64 {
+	var resources map[string]int64
+	for name, quantity := range allocatable {
+		domain, _, found := strings.Cut(string(name), "/")
+		if !found || domain == "kubernetes.io" || strings.HasSuffix(domain, ".kubernetes.io") {
+			continue
+		}
+		if resources == nil {
+			resources = make(map[string]int64)
+		}
+		resources[string(name)] = quantity.Value()
+	}
+	return resources
+}
+
+// convertPod converts a k8s pod to its inventory representation
+func convertPod(pod *corev1.Pod) inventory.PodEntry {
+	entry := inventory.PodEntry{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		NodeName:  pod.Spec.NodeName,
+		Phase:     string(pod.Status.Phase),
+	}
+
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		entry.OwnerKind = owner.Kind
+		entry.OwnerName = owner.Name
+	}
+
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		entry.IsMirror = true
+	}
+
+	for _, vol := range pod.Spec.Volumes {
+		if vol.EmptyDir != nil {
+			entry.HasLocalStorage = true
+			break
+		}
+	}
+
+	entry.RequestedCPU, entry.RequestedMemory = podRequests(pod)
+
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable == corev1.DoNotSchedule {
+			entry.HardSpreadKeys = append(entry.HardSpreadKeys, constraint.TopologyKey)
+		}
+	}
+
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+		for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			entry.RequiredAntiAffinity = append(entry.RequiredAntiAffinity, term.TopologyKey)
+		}
+	}
+
+	return entry
+}
+
+// podRequests returns the effective CPU (millicores) and memory (bytes) requests of a pod,
+// i.e. the larger of the summed app containers and any single init container
+func podRequests(pod *corev1.Pod) (int64, int64) {
+	var cpu, memory int64
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().MilliValue()
+		memory += c.Resources.Requests.Memory().Value()
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		if initCPU := c.Resources.Requests.Cpu().MilliValue(); initCPU > cpu {
+			cpu = initCPU
+		}
+		if initMemory := c.Resources.Requests.Memory().Value(); initMemory > memory {
+			memory = initMemory
+		}
+	}
+
+	return cpu, memory
+}
@@ -0,0 +1,172 @@
+package cluster
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// ClientOptions limit the load scans put on the API server and make them ride out its throttling.
+// Large clusters with strict API priority and fairness answer bursts of list calls with 429s.
+type ClientOptions struct {
+	// QPS and Burst are the client-side rate limit of API requests; a zero QPS keeps client-go's
+	// default of 5
+	QPS   float32
+	Burst int
+	// RequestTimeout bounds each request, besides watches, that has no earlier deadline
+	RequestTimeout time.Duration
+	// MaxRetries is how often a read is retried after a 429 or 5xx response, with exponential
+	// backoff; 0 disables retries
+	MaxRetries int
+}
+
+// DefaultClientOptions are used unless SetClientOptions is called
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{QPS: 20, Burst: 40, RequestTimeout: time.Minute, MaxRetries: 5}
+}
+
+// Backoff between retries, doubling from retryBaseDelay up to retryMaxDelay, with jitter
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+var (
+	clientOptionsMu sync.RWMutex
+	clientOptions   = DefaultClientOptions()
+)
+
+// SetClientOptions sets the options of the clients created from then on
+func SetClientOptions(opts ClientOptions) {
+	clientOptionsMu.Lock()
+	defer clientOptionsMu.Unlock()
+	clientOptions = opts
+}
+
+func currentClientOptions() ClientOptions {
+	clientOptionsMu.RLock()
+	defer clientOptionsMu.RUnlock()
+	return clientOptions
+}
+
+// configureRateLimits applies the client options to a client configuration. Retries wrap the
+// transport outermost, so each attempt is traced and counted.
+func configureRateLimits(config *rest.Config) {
+	opts := currentClientOptions()
+	if opts.QPS > 0 {
+		config.QPS = opts.QPS
+		config.Burst = opts.Burst
+		config.RateLimiter = &measuredRateLimiter{RateLimiter: flowcontrol.NewTokenBucketRateLimiter(opts.QPS, opts.Burst)}
+	}
+	config.Wrap(func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{next: next, maxRetries: opts.MaxRetries, requestTimeout: opts.RequestTimeout}
+	})
+}
+
+// measuredRateLimiter records the time requests wait for the client-side rate limit
+type measuredRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+func (l *measuredRateLimiter) Wait(ctx context.Context) error {
+	started := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	if waited := time.Since(started); waited > time.Millisecond {
+		telemetry.RecordThrottle(ctx, telemetry.ThrottleClient, waited)
+	}
+	return err
+}
+
+// retryTransport bounds requests with a deadline and retries reads answered with a 429 or 5xx.
+// client-go retries responses with a Retry-After itself, so the header is dropped from the response
+// a read is given up with, leaving MaxRetries the only bound on its attempts.
+type retryTransport struct {
+	next           http.RoundTripper
+	maxRetries     int
+	requestTimeout time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	watch := req.URL.Query().Get("watch") == "true"
+	cancel := context.CancelFunc(func() {})
+	if _, ok := req.Context().Deadline(); !ok && t.requestTimeout > 0 && !watch {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.requestTimeout)
+		req = req.WithContext(ctx)
+	}
+
+	retryable := !watch && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !retryable || attempt >= t.maxRetries || !retryStatus(resp.StatusCode) {
+			if err != nil {
+				cancel()
+				return resp, err
+			}
+			if retryable && retryStatus(resp.StatusCode) {
+				resp.Header.Del("Retry-After")
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		delay := retryDelay(resp, attempt)
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		telemetry.RecordRetry(req.Context(), req.Method, resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			telemetry.RecordThrottle(req.Context(), telemetry.ThrottleServer, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			cancel()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryStatus reports whether a response is worth retrying: throttled, or a transient server error
+func retryStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay is the Retry-After of a response, else exponential backoff with jitter
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+	}
+	backoff := retryMaxDelay
+	if attempt < 8 {
+		backoff = min(retryBaseDelay<<attempt, retryMaxDelay)
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// cancelBody releases the deadline of a request once its response has been read
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedTransport answers requests with the given statuses in turn, recording the requests
+type scriptedTransport struct {
+	responses []*http.Response
+	requests  []*http.Request
+}
+
+func (t *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	resp := t.responses[0]
+	if len(t.responses) > 1 {
+		t.responses = t.responses[1:]
+	}
+	return resp, nil
+}
+
+// response is a response with a status and, if not empty, a Retry-After header
+func response(status int, retryAfter string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("{}"))}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		maxRetries     int
+		responses      []*http.Response
+		wantAttempts   int
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{
+			name:         "read retried until it succeeds",
+			method:       http.MethodGet,
+			url:          "https://api/api/v1/pods",
+			maxRetries:   1,
+			responses:    []*http.Response{response(http.StatusServiceUnavailable, ""), response(http.StatusOK, "")},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			// client-go would retry the final 429 again for its Retry-After
+			name:         "read given up without Retry-After",
+			method:       http.MethodGet,
+			url:          "https://api/api/v1/pods",
+			maxRetries:   1,
+			responses:    []*http.Response{response(http.StatusServiceUnavailable, ""), response(http.StatusTooManyRequests, "1")},
+			wantAttempts: 2,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "retries disabled",
+			method:       http.MethodGet,
+			url:          "https://api/api/v1/pods",
+			responses:    []*http.Response{response(http.StatusTooManyRequests, "1")},
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:           "writes are left to client-go",
+			method:         http.MethodPost,
+			url:            "https://api/api/v1/namespaces",
+			maxRetries:     5,
+			responses:      []*http.Response{response(http.StatusServiceUnavailable, "1")},
+			wantAttempts:   1,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: "1",
+		},
+		{
+			name:         "watches are not retried",
+			method:       http.MethodGet,
+			url:          "https://api/api/v1/pods?watch=true",
+			maxRetries:   5,
+			responses:    []*http.Response{response(http.StatusServiceUnavailable, "")},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedTransport{responses: tt.responses}
+			transport := &retryTransport{next: next, maxRetries: tt.maxRetries}
+			resp, err := transport.RoundTrip(httptest.NewRequest(tt.method, tt.url, nil))
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			defer resp.Body.Close()
+			if len(next.requests) != tt.wantAttempts || resp.StatusCode != tt.wantStatus {
+				t.Errorf("%d attempts with status %d, want %d with status %d", len(next.requests), resp.StatusCode, tt.wantAttempts, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRequestTimeout(t *testing.T) {
+	for url, wantDeadline := range map[string]bool{
+		"https://api/api/v1/pods":            true,
+		"https://api/api/v1/pods?watch=true": false,
+	} {
+		next := &scriptedTransport{responses: []*http.Response{response(http.StatusOK, "")}}
+		transport := &retryTransport{next: next, requestTimeout: time.Minute}
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, url, nil))
+		if err != nil {
+			t.Fatalf("RoundTrip: %v", err)
+		}
+		resp.Body.Close()
+		if _, ok := next.requests[0].Context().Deadline(); ok != wantDeadline {
+			t.Errorf("request to %s has a deadline: %v, want %v", url, ok, wantDeadline)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		min, max   time.Duration
+	}{
+		{"Retry-After", "3", 0, 3 * time.Second, 3 * time.Second},
+		{"Retry-After beyond the maximum", "120", 0, retryMaxDelay, retryMaxDelay},
+		{"invalid Retry-After", "soon", 0, retryBaseDelay / 2, retryBaseDelay},
+		{"first retry", "", 0, retryBaseDelay / 2, retryBaseDelay},
+		{"third retry", "", 2, 2 * retryBaseDelay, 4 * retryBaseDelay},
+		{"backoff beyond the maximum", "", 20, retryMaxDelay / 2, retryMaxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if delay := retryDelay(response(http.StatusTooManyRequests, tt.retryAfter), tt.attempt); delay < tt.min || delay > tt.max {
+					t.Fatalf("retryDelay() = %v, want between %v and %v", delay, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
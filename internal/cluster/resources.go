@@ -0,0 +1,203 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+)
+
+// metadataPageSize is the number of objects listed per request when only their metadata is needed
+const metadataPageSize = 500
+
+// liveType is a built-in kind whose objects a scan stores as resources
+type liveType struct {
+	kind string
+	// resources are listed in order until one is served, newest version first
+	resources []schema.GroupVersionResource
+	// groups the kind's objects may have been written with, e.g. extensions for old Ingresses
+	groups []string
+	// spec is set for kinds stored with the spec of their last-applied configuration
+	spec bool
+}
+
+// liveTypes are the workloads and the kinds around them whose APIs were deprecated or removed
+var liveTypes = []liveType{
+	{kind: "Deployment", resources: gvrs("apps", "deployments", "v1"), groups: []string{"apps", "extensions"}},
+	{kind: "StatefulSet", resources: gvrs("apps", "statefulsets", "v1"), groups: []string{"apps"}},
+	{kind: "DaemonSet", resources: gvrs("apps", "daemonsets", "v1"), groups: []string{"apps", "extensions"}},
+	{kind: "Job", resources: gvrs("batch", "jobs", "v1"), groups: []string{"batch"}},
+	{kind: "CronJob", resources: gvrs("batch", "cronjobs", "v1", "v1beta1"), groups: []string{"batch"}},
+	{kind: "Service", resources: gvrs("", "services", "v1"), groups: []string{""}},
+	{kind: "Ingress", resources: gvrs("networking.k8s.io", "ingresses", "v1", "v1beta1"), groups: []string{"networking.k8s.io", "extensions"}},
+	{kind: "NetworkPolicy", resources: gvrs("networking.k8s.io", "networkpolicies", "v1"), groups: []string{"networking.k8s.io", "extensions"}},
+	{kind: "PodDisruptionBudget", resources: gvrs("policy", "poddisruptionbudgets", "v1", "v1beta1"), groups: []string{"policy"}},
+	{kind: "HorizontalPodAutoscaler", resources: gvrs("autoscaling", "horizontalpodautoscalers", "v2", "v1"), groups: []string{"autoscaling"}, spec: true},
+	{kind: "PodSecurityPolicy", resources: gvrs("policy", "podsecuritypolicies", "v1beta1"), groups: []string{"policy", "extensions"}},
+}
+
+// gvrs returns the resource of a group at each version
+func gvrs(group, resource string, versions ...string) []schema.GroupVersionResource {
+	resources := make([]schema.GroupVersionResource, len(versions))
+	for i, version := range versions {
+		resources[i] = schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+	}
+	return resources
+}
+
+// StoreLiveResources stores the cluster's workloads, Services, Ingresses, NetworkPolicies,
+// PodDisruptionBudgets, HorizontalPodAutoscalers and PodSecurityPolicies as resources, replacing
+// those of the last scan.
+// Only their metadata is listed. Each object is stored under the apiVersion it was last written
+// with, from kubectl's last-applied configuration or its managed fields, so objects still
+// maintained with a deprecated API are found although the API server serves them at any version.
+// HorizontalPodAutoscalers are stored with the spec of their last-applied configuration, which
+// is written at that apiVersion, to check their metrics convert.
+func (k *KubeClient) StoreLiveResources(ctx context.Context, clusterID string, store inventory.ScanStore, progress func(processed, total int)) error {
+	client, err := metadata.NewForConfig(k.config)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata client: %w", err)
+	}
+	storeCtx := context.WithoutCancel(ctx)
+
+	for i, lt := range liveTypes {
+		objects, listed, err := listServed(ctx, client, lt.resources)
+		if err != nil {
+			return err
+		}
+		// A kind no longer served has no objects, replacing those of the last scan
+		if err := storeLive(storeCtx, clusterID, store, lt, listed, objects); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(i+1, len(liveTypes))
+		}
+	}
+	return nil
+}
+
+// storeLive replaces the stored objects of a kind with those listed at a resource
+func storeLive(ctx context.Context, clusterID string, store inventory.ScanStore, lt liveType, listed schema.GroupVersionResource, objects []metav1.PartialObjectMetadata) error {
+	entries := make([]inventory.ResourceEntry, 0, len(objects))
+	for _, object := range objects {
+		entry := inventory.ResourceEntry{
+			APIVersion: writtenAPIVersion(object.ObjectMeta, listed.GroupVersion().String(), lt.groups),
+			Kind:       lt.kind,
+			Namespace:  object.Namespace,
+			Name:       object.Name,
+			Labels:     object.Labels,
+			Owners:     ownerReferences(object.OwnerReferences),
+		}
+		if lt.spec {
+			entry.Spec = appliedSpec(object.ObjectMeta, entry.APIVersion)
+		}
+		entries = append(entries, entry)
+	}
+	filter := inventory.ResourceFilter{Source: inventory.ResourceSourceCluster, Groups: lt.groups, Kind: lt.kind}
+	if err := store.ReplaceResources(ctx, clusterID, filter, entries); err != nil {
+		return fmt.Errorf("failed to save %s resources: %w", lt.kind, err)
+	}
+	return nil
+}
+
+// listServed lists the metadata of the objects of the first of resources the cluster serves; the
+// resource listed is empty when it serves none
+func listServed(ctx context.Context, client metadata.Interface, resources []schema.GroupVersionResource) ([]metav1.PartialObjectMetadata, schema.GroupVersionResource, error) {
+	for _, gvr := range resources {
+		objects, err := listMetadata(ctx, client, gvr)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, gvr, err
+		}
+		return objects, gvr, nil
+	}
+	return nil, schema.GroupVersionResource{}, nil
+}
+
+// listMetadata lists the metadata of all objects of a resource across namespaces, page by page
+func listMetadata(ctx context.Context, client metadata.Interface, gvr schema.GroupVersionResource) ([]metav1.PartialObjectMetadata, error) {
+	var objects []metav1.PartialObjectMetadata
+	opts := metav1.ListOptions{Limit: metadataPageSize}
+	for {
+		list, err := client.Resource(gvr).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+		objects = append(objects, list.Items...)
+
+		if list.GetContinue() == "" {
+			return objects, nil
+		}
+		opts.Continue = list.GetContinue()
+	}
+}
+
+// writtenAPIVersion returns the apiVersion an object was last written with: that of kubectl's
+// last-applied configuration, else that of the newest managed fields entry for the object itself,
+// else the listed one. Versions of groups the kind never had are ignored.
+func writtenAPIVersion(meta metav1.ObjectMeta, listed string, groups []string) string {
+	known := func(apiVersion string) bool {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		return err == nil && apiVersion != "" && slices.Contains(groups, gv.Group)
+	}
+
+	var applied struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if config := meta.Annotations["kubectl.kubernetes.io/last-applied-configuration"]; config != "" {
+		if json.Unmarshal([]byte(config), &applied) == nil && known(applied.APIVersion) {
+			return applied.APIVersion
+		}
+	}
+
+	var newest *metav1.ManagedFieldsEntry
+	for i, entry := range meta.ManagedFields {
+		if entry.Subresource != "" || !known(entry.APIVersion) {
+			continue
+		}
+		if newest == nil || newest.Time == nil || (entry.Time != nil && entry.Time.After(newest.Time.Time)) {
+			newest = &meta.ManagedFields[i]
+		}
+	}
+	if newest != nil {
+		return newest.APIVersion
+	}
+	return listed
+}
+
+// appliedSpec returns the spec of kubectl's last-applied configuration when it was applied with
+// apiVersion, nil otherwise, e.g. for objects created by Helm
+func appliedSpec(meta metav1.ObjectMeta, apiVersion string) map[string]interface{} {
+	var applied struct {
+		APIVersion string                 `json:"apiVersion"`
+		Spec       map[string]interface{} `json:"spec"`
+	}
+	config := meta.Annotations["kubectl.kubernetes.io/last-applied-configuration"]
+	if config == "" || json.Unmarshal([]byte(config), &applied) != nil || applied.APIVersion != apiVersion {
+		return nil
+	}
+	return applied.Spec
+}
+
+// ownerReferences converts owner references to inventory ones
+func ownerReferences(refs []metav1.OwnerReference) []inventory.OwnerReference {
+	var owners []inventory.OwnerReference
+	for _, ref := range refs {
+		owners = append(owners, inventory.OwnerReference{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			UID:        string(ref.UID),
+			Controller: ref.Controller != nil && *ref.Controller,
+		})
+	}
+	return owners
+}
@@ -0,0 +1,27 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListRuntimeClasses lists the RuntimeClasses and their container runtime handlers
+func (k *KubeClient) ListRuntimeClasses(ctx context.Context) ([]inventory.RuntimeClassEntry, error) {
+	classList, err := k.clientset.NodeV1().RuntimeClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runtime classes: %w", err)
+	}
+
+	classes := make([]inventory.RuntimeClassEntry, 0, len(classList.Items))
+	for _, class := range classList.Items {
+		classes = append(classes, inventory.RuntimeClassEntry{
+			Name:    class.Name,
+			Handler: class.Handler,
+		})
+	}
+
+	return classes, nil
+}
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultStorageClassAnnotations mark the default StorageClass (GA and beta forms)
+var defaultStorageClassAnnotations = []string{
+	"storageclass.kubernetes.io/is-default-class",
+	"storageclass.beta.kubernetes.io/is-default-class",
+}
+
+// CollectStorageState adds StorageClasses, PVs, PVCs and CSI drivers to the live state
+func (k *KubeClient) CollectStorageState(ctx context.Context, state *inventory.LiveClusterState) error {
+	classes, err := k.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list storage classes: %w", err)
+	}
+	for _, sc := range classes.Items {
+		isDefault := false
+		for _, annotation := range defaultStorageClassAnnotations {
+			if sc.Annotations[annotation] == "true" {
+				isDefault = true
+			}
+		}
+		state.StorageClasses = append(state.StorageClasses, inventory.StorageClassEntry{
+			Name:        sc.Name,
+			Provisioner: sc.Provisioner,
+			IsDefault:   isDefault,
+			Annotations: sc.Annotations,
+		})
+	}
+
+	volumes, err := k.clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	for i := range volumes.Items {
+		pv := &volumes.Items[i]
+		entry := inventory.PersistentVolumeEntry{
+			Name:         pv.Name,
+			StorageClass: pv.Spec.StorageClassName,
+			InTreePlugin: inTreeVolumePlugin(pv.Spec.PersistentVolumeSource),
+		}
+		if pv.Spec.ClaimRef != nil {
+			entry.ClaimRef = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+		}
+		if pv.Spec.CSI != nil {
+			entry.CSIDriver = pv.Spec.CSI.Driver
+		}
+		state.PersistentVolumes = append(state.PersistentVolumes, entry)
+	}
+
+	claims, err := k.clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	for _, pvc := range claims.Items {
+		storageClass := ""
+		if pvc.Spec.StorageClassName != nil {
+			storageClass = *pvc.Spec.StorageClassName
+		}
+		state.PersistentVolumeClaims = append(state.PersistentVolumeClaims, inventory.PersistentVolumeClaimEntry{
+			Name:         pvc.Name,
+			Namespace:    pvc.Namespace,
+			StorageClass: storageClass,
+			VolumeName:   pvc.Spec.VolumeName,
+			Annotations:  pvc.Annotations,
+		})
+	}
+
+	drivers, err := k.clientset.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list CSI drivers: %w", err)
+	}
+	for _, driver := range drivers.Items {
+		state.CSIDrivers = append(state.CSIDrivers, driver.Name)
+	}
+
+	return nil
+}
+
+// inTreeVolumePlugin returns the in-tree provisioner name backing a volume source, if any
+func inTreeVolumePlugin(source corev1.PersistentVolumeSource) string {
+	switch {
+	case source.AWSElasticBlockStore != nil:
+		return "kubernetes.io/aws-ebs"
+	case source.GCEPersistentDisk != nil:
+		return "kubernetes.io/gce-pd"
+	case source.AzureDisk != nil:
+		return "kubernetes.io/azure-disk"
+	case source.AzureFile != nil:
+		return "kubernetes.io/azure-file"
+	case source.Cinder != nil:
+		return "kubernetes.io/cinder"
+	case source.VsphereVolume != nil:
+		return "kubernetes.io/vsphere-volume"
+	case source.PortworxVolume != nil:
+		return "kubernetes.io/portworx-volume"
+	case source.RBD != nil:
+		return "kubernetes.io/rbd"
+	case source.CephFS != nil:
+		return "kubernetes.io/cephfs"
+	case source.Glusterfs != nil:
+		return "kubernetes.io/glusterfs"
+	case source.StorageOS != nil:
+		return "kubernetes.io/storageos"
+	case source.ScaleIO != nil:
+		return "kubernetes.io/scaleio"
+	case source.Quobyte != nil:
+		return "kubernetes.io/quobyte"
+	case source.Flocker != nil:
+		return "kubernetes.io/flocker"
+	}
+	return ""
+}
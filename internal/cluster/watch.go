@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WorkloadResources are the workloads and PodDisruptionBudgets read by live checks
+var WorkloadResources = []ResourceType{
+	{Name: "deployments", GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+	{Name: "statefulsets", GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}},
+	{Name: "daemonsets", GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}},
+	{Name: "poddisruptionbudgets", GVR: schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}},
+}
+
+// WatchChanges keeps an informer open on each resource type and sends the changes to their objects
+// in batches keyed by ResourceType.Name, collected for window after the first change of a batch.
+// Objects listed when the informers start are not sent. It returns once the informers have synced;
+// the channel is closed when ctx is done.
+func (k *KubeClient) WatchChanges(ctx context.Context, types []ResourceType, window time.Duration) (<-chan map[string][]Change, error) {
+	dynamicClient, err := dynamic.NewForConfig(k.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	var mu sync.Mutex
+	pending := make(map[string]*changeSet)
+	notify := make(chan struct{}, 1)
+	record := func(rt ResourceType, eventType watch.EventType, obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		object, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+
+		mu.Lock()
+		if pending[rt.Name] == nil {
+			pending[rt.Name] = newChangeSet()
+		}
+		pending[rt.Name].add(Change{Type: eventType, Object: object})
+		mu.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+
+	synced := make([]cache.InformerSynced, 0, len(types))
+	for _, rt := range types {
+		rt := rt
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, rt.Namespace, func(opts *metav1.ListOptions) {
+			opts.LabelSelector = rt.LabelSelector
+		})
+		informer := factory.ForResource(rt.GVR).Informer()
+		registration, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj interface{}, isInInitialList bool) {
+				if !isInInitialList {
+					record(rt, watch.Added, obj)
+				}
+			},
+			UpdateFunc: func(oldObj, obj interface{}) {
+				// A relist replays unchanged objects
+				if oldObj.(metav1.Object).GetResourceVersion() != obj.(metav1.Object).GetResourceVersion() {
+					record(rt, watch.Modified, obj)
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				record(rt, watch.Deleted, obj)
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch %s: %w", rt.GVR.Resource, err)
+		}
+		factory.Start(ctx.Done())
+		synced = append(synced, registration.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to sync informers")
+	}
+
+	batches := make(chan map[string][]Change)
+	go func() {
+		defer close(batches)
+		timer := time.NewTimer(window)
+		timer.Stop()
+		collecting := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notify:
+				// The window is not extended by later changes, so constant churn still yields batches
+				if !collecting {
+					timer.Reset(window)
+					collecting = true
+				}
+			case <-timer.C:
+				collecting = false
+				mu.Lock()
+				batch := make(map[string][]Change, len(pending))
+				for name, set := range pending {
+					batch[name] = set.changes
+				}
+				pending = make(map[string]*changeSet)
+				mu.Unlock()
+
+				select {
+				case batches <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return batches, nil
+}
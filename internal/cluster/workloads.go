@@ -0,0 +1,153 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ListWorkloads lists Deployments, StatefulSets, DaemonSets and CronJobs across all namespaces
+func (k *KubeClient) ListWorkloads(ctx context.Context) ([]inventory.WorkloadEntry, error) {
+	var workloads []inventory.WorkloadEntry
+
+	deployments, err := k.clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, inventory.WorkloadEntry{
+			Kind:      "Deployment",
+			Name:      d.Name,
+			Namespace: d.Namespace,
+			Replicas:  replicasOrDefault(d.Spec.Replicas),
+			Images:    containerImages(d.Spec.Template.Spec),
+			Labels:    d.Labels,
+			Spec:      specObject(&d.Spec),
+		})
+	}
+
+	statefulSets, err := k.clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		templates := make([]inventory.VolumeClaimTemplateEntry, 0, len(s.Spec.VolumeClaimTemplates))
+		for _, t := range s.Spec.VolumeClaimTemplates {
+			storageClass := ""
+			if t.Spec.StorageClassName != nil {
+				storageClass = *t.Spec.StorageClassName
+			}
+			templates = append(templates, inventory.VolumeClaimTemplateEntry{
+				Name:         t.Name,
+				StorageClass: storageClass,
+				Annotations:  t.Annotations,
+			})
+		}
+
+		workloads = append(workloads, inventory.WorkloadEntry{
+			Kind:                 "StatefulSet",
+			Name:                 s.Name,
+			Namespace:            s.Namespace,
+			Replicas:             replicasOrDefault(s.Spec.Replicas),
+			Images:               containerImages(s.Spec.Template.Spec),
+			Labels:               s.Labels,
+			VolumeClaimTemplates: templates,
+			Spec:                 specObject(&s.Spec),
+		})
+	}
+
+	daemonSets, err := k.clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, d := range daemonSets.Items {
+		workloads = append(workloads, inventory.WorkloadEntry{
+			Kind:      "DaemonSet",
+			Name:      d.Name,
+			Namespace: d.Namespace,
+			Replicas:  d.Status.DesiredNumberScheduled,
+			Images:    containerImages(d.Spec.Template.Spec),
+			Labels:    d.Labels,
+			Spec:      specObject(&d.Spec),
+		})
+	}
+
+	// batch/v1 CronJobs are only served from 1.21, so older clusters are skipped
+	cronJobs, err := k.clientset.BatchV1().CronJobs("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list cronjobs: %v\n", err)
+		return workloads, nil
+	}
+	for _, c := range cronJobs.Items {
+		suspended := c.Spec.Suspend != nil && *c.Spec.Suspend
+		workloads = append(workloads, inventory.WorkloadEntry{
+			Kind:      "CronJob",
+			Name:      c.Name,
+			Namespace: c.Namespace,
+			Suspended: suspended,
+			Images:    containerImages(c.Spec.JobTemplate.Spec.Template.Spec),
+			Labels:    c.Labels,
+			Spec:      specObject(&c.Spec),
+		})
+	}
+
+	return workloads, nil
+}
+
+// ListIngresses lists networking.k8s.io/v1 Ingresses across all namespaces
+func (k *KubeClient) ListIngresses(ctx context.Context) ([]inventory.IngressEntry, error) {
+	ingressList, err := k.clientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	ingresses := make([]inventory.IngressEntry, 0, len(ingressList.Items))
+	for _, ing := range ingressList.Items {
+		class := ""
+		if ing.Spec.IngressClassName != nil {
+			class = *ing.Spec.IngressClassName
+		}
+		ingresses = append(ingresses, inventory.IngressEntry{
+			Name:        ing.Name,
+			Namespace:   ing.Namespace,
+			Class:       class,
+			Annotations: ing.Annotations,
+		})
+	}
+
+	return ingresses, nil
+}
+
+// replicasOrDefault returns the replica count, defaulting to 1 when unset
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// specObject converts a typed spec to its unstructured form, for checks on fields by path; nil
+// when it can't be converted
+func specObject(spec interface{}) map[string]interface{} {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec)
+	if err != nil {
+		return nil
+	}
+	return object
+}
+
+// containerImages returns the images of all containers in a pod spec
+func containerImages(spec corev1.PodSpec) []string {
+	images := make([]string, 0, len(spec.InitContainers)+len(spec.Containers))
+	for _, c := range spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
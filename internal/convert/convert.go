@@ -0,0 +1,128 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Object is a decoded Kubernetes resource, e.g. from yaml.Unmarshal into a map
+type Object = map[string]interface{}
+
+// Converter rewrites resources of a removed API version to its replacement
+type Converter struct {
+	FromAPIVersion string
+	ToAPIVersion   string
+	Kind           string
+	// convert rewrites the object in place, except apiVersion, and returns notes on anything
+	// left for manual review
+	convert func(obj Object) []string
+	// schema lists the keys the target version allows by field path, see unsupportedFields
+	schema map[string][]string
+}
+
+// Result describes a converted resource
+type Result struct {
+	FromAPIVersion string
+	ToAPIVersion   string
+	Kind           string
+	Name           string
+	Namespace      string
+	Notes          []string      // behavior changes and fields left for manual review
+	Changes        []FieldChange // fields renamed, added or removed, and fields the target version lacks
+}
+
+// converters holds the built-in converters keyed by apiVersion and kind
+var converters = map[string]*Converter{}
+
+func init() {
+	register(&Converter{FromAPIVersion: "extensions/v1beta1", ToAPIVersion: "networking.k8s.io/v1", Kind: "Ingress", convert: convertIngress, schema: ingressSchema})
+	register(&Converter{FromAPIVersion: "networking.k8s.io/v1beta1", ToAPIVersion: "networking.k8s.io/v1", Kind: "Ingress", convert: convertIngress, schema: ingressSchema})
+	register(&Converter{FromAPIVersion: "policy/v1beta1", ToAPIVersion: "policy/v1", Kind: "PodDisruptionBudget", convert: convertPodDisruptionBudget, schema: podDisruptionBudgetSchema})
+	register(&Converter{FromAPIVersion: "batch/v1beta1", ToAPIVersion: "batch/v1", Kind: "CronJob", convert: convertCronJob, schema: cronJobSchema})
+	register(&Converter{FromAPIVersion: "autoscaling/v2beta1", ToAPIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler", convert: convertHorizontalPodAutoscalerV2beta1, schema: horizontalPodAutoscalerSchema})
+	register(&Converter{FromAPIVersion: "autoscaling/v2beta2", ToAPIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler", convert: convertHorizontalPodAutoscaler, schema: horizontalPodAutoscalerSchema})
+}
+
+// register adds a built-in converter
+func register(converter *Converter) {
+	converters[converterKey(converter.FromAPIVersion, converter.Kind)] = converter
+}
+
+// converterKey builds the lookup key of a converter
+func converterKey(apiVersion, kind string) string {
+	return apiVersion + "/" + kind
+}
+
+// Lookup returns the converter for resources of an apiVersion and kind
+func Lookup(apiVersion, kind string) (*Converter, bool) {
+	converter, found := converters[converterKey(apiVersion, kind)]
+	return converter, found
+}
+
+// Converters lists the built-in converters ordered by kind and source version
+func Converters() []*Converter {
+	list := make([]*Converter, 0, len(converters))
+	for _, converter := range converters {
+		list = append(list, converter)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].FromAPIVersion < list[j].FromAPIVersion
+	})
+	return list
+}
+
+// Convert rewrites a resource to its replacement API in place. It returns nil when no converter
+// handles the resource's apiVersion and kind.
+func Convert(obj Object) (*Result, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	converter, found := Lookup(apiVersion, kind)
+	if !found {
+		return nil, nil
+	}
+	return converter.Convert(obj)
+}
+
+// Convert rewrites a resource handled by the converter in place
+func (c *Converter) Convert(obj Object) (*Result, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	if apiVersion != c.FromAPIVersion || kind != c.Kind {
+		return nil, fmt.Errorf("converter for %s %s cannot convert %s %s", c.FromAPIVersion, c.Kind, apiVersion, kind)
+	}
+
+	result := &Result{
+		FromAPIVersion: c.FromAPIVersion,
+		ToAPIVersion:   c.ToAPIVersion,
+		Kind:           c.Kind,
+	}
+	if metadata, ok := obj["metadata"].(Object); ok {
+		result.Name, _ = metadata["name"].(string)
+		result.Namespace, _ = metadata["namespace"].(string)
+	}
+
+	before := make(map[string]string)
+	flattenFields("", obj, before)
+
+	result.Notes = c.convert(obj)
+	obj["apiVersion"] = c.ToAPIVersion
+
+	after := make(map[string]string)
+	flattenFields("", obj, after)
+	delete(before, "apiVersion")
+	delete(after, "apiVersion")
+	result.Changes = append(diffFields(before, after), unsupportedFields("", "", obj, c.schema, nil)...)
+	sortChanges(result.Changes)
+	return result, nil
+}
+
+// Resource formats the converted resource for messages, e.g. "Ingress default/web"
+func (r *Result) Resource() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
@@ -0,0 +1,203 @@
+package convert
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenCases are the inputs in testdata, one per built-in converter
+var goldenCases = []struct {
+	name       string
+	apiVersion string
+	kind       string
+}{
+	{"ingress-extensions-v1beta1", "extensions/v1beta1", "Ingress"},
+	{"ingress-networking-v1beta1", "networking.k8s.io/v1beta1", "Ingress"},
+	{"pdb-policy-v1beta1", "policy/v1beta1", "PodDisruptionBudget"},
+	{"cronjob-batch-v1beta1", "batch/v1beta1", "CronJob"},
+	{"hpa-autoscaling-v2beta1", "autoscaling/v2beta1", "HorizontalPodAutoscaler"},
+	{"hpa-autoscaling-v2beta2", "autoscaling/v2beta2", "HorizontalPodAutoscaler"},
+}
+
+func TestConvertYAMLGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", tc.name+".yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			converted, results, err := ConvertYAML(input, nil)
+			if err != nil {
+				t.Fatalf("ConvertYAML: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			if results[0].FromAPIVersion != tc.apiVersion || results[0].Kind != tc.kind {
+				t.Errorf("converted %s %s, want %s %s", results[0].FromAPIVersion, results[0].Kind, tc.apiVersion, tc.kind)
+			}
+
+			golden := filepath.Join("testdata", tc.name+".golden.yaml")
+			if *update {
+				if err := os.WriteFile(golden, converted, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(converted) != string(want) {
+				t.Errorf("converted YAML differs from %s:\n%s", golden, converted)
+			}
+
+			for _, comment := range comments(string(input)) {
+				if !strings.Contains(string(converted), comment) {
+					t.Errorf("comment %q was dropped", comment)
+				}
+			}
+			if before, after := commonKeyOrder(t, input, converted); !reflect.DeepEqual(before, after) {
+				t.Errorf("key order changed:\nbefore: %v\nafter:  %v", before, after)
+			}
+		})
+	}
+}
+
+func TestGoldenCasesCoverConverters(t *testing.T) {
+	covered := make(map[string]bool, len(goldenCases))
+	for _, tc := range goldenCases {
+		covered[converterKey(tc.apiVersion, tc.kind)] = true
+	}
+	for _, converter := range Converters() {
+		if !covered[converterKey(converter.FromAPIVersion, converter.Kind)] {
+			t.Errorf("no golden file for the %s %s converter", converter.FromAPIVersion, converter.Kind)
+		}
+	}
+}
+
+func TestConvertYAMLKeepsOtherDocuments(t *testing.T) {
+	input := "# config\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: settings}\n---\n" +
+		"apiVersion: batch/v1beta1\nkind: CronJob\nmetadata:\n  name: report\nspec:\n  schedule: '@daily'\n"
+	converted, results, err := ConvertYAML([]byte(input), nil)
+	if err != nil {
+		t.Fatalf("ConvertYAML: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	want := "# config\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: settings}\n---\n" +
+		"apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: report\nspec:\n  schedule: '@daily'\n"
+	if string(converted) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", converted, want)
+	}
+}
+
+func TestConvertHorizontalPodAutoscalerV2beta1(t *testing.T) {
+	obj := Object{
+		"apiVersion": "autoscaling/v2beta1",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   Object{"name": "api"},
+		"spec": Object{
+			"behavior": Object{},
+			"metrics": []interface{}{
+				Object{"type": "ContainerResource", "containerResource": Object{
+					"name": "cpu", "container": "app",
+					"targetAverageUtilization": 70, "targetAverageValue": "500m",
+				}},
+			},
+		},
+	}
+	result, err := Convert(obj)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	metric := obj["spec"].(Object)["metrics"].([]interface{})[0].(Object)
+	want := Object{
+		"name": "cpu", "container": "app",
+		"target": Object{"type": "Utilization", "averageUtilization": 70},
+	}
+	if got := metric["containerResource"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("containerResource = %v, want %v", got, want)
+	}
+	note := "spec.metrics[0].containerResource.targetAverageValue is dropped: autoscaling/v2 allows one target per metric, and Utilization is kept"
+	if !reflect.DeepEqual(result.Notes, []string{note}) {
+		t.Errorf("notes = %q, want %q", result.Notes, []string{note})
+	}
+}
+
+// comments returns the comments of a YAML document, e.g. "# nightly"
+func comments(text string) []string {
+	var found []string
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "# "); i >= 0 {
+			found = append(found, strings.TrimSpace(line[i:]))
+		}
+	}
+	return found
+}
+
+// commonKeyOrder returns the paths of the keys both documents have, in the order of each
+func commonKeyOrder(t *testing.T, before, after []byte) ([]string, []string) {
+	t.Helper()
+	beforePaths, afterPaths := keyPaths(t, before), keyPaths(t, after)
+	inBefore, inAfter := make(map[string]bool), make(map[string]bool)
+	for _, path := range beforePaths {
+		inBefore[path] = true
+	}
+	for _, path := range afterPaths {
+		inAfter[path] = true
+	}
+	var commonBefore, commonAfter []string
+	for _, path := range beforePaths {
+		if inAfter[path] {
+			commonBefore = append(commonBefore, path)
+		}
+	}
+	for _, path := range afterPaths {
+		if inBefore[path] {
+			commonAfter = append(commonAfter, path)
+		}
+	}
+	return commonBefore, commonAfter
+}
+
+// keyPaths lists the paths of all mapping keys of a document in document order
+func keyPaths(t *testing.T, data []byte) []string {
+	t.Helper()
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, prefix)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				path := prefix + "." + node.Content[i].Value
+				paths = append(paths, path)
+				walk(node.Content[i+1], path)
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, fmt.Sprintf("%s[%d]", prefix, i))
+			}
+		}
+	}
+	walk(&document, "")
+	return paths
+}
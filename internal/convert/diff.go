@@ -0,0 +1,159 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangeType classifies a field change in a converted resource
+type ChangeType string
+
+const (
+	ChangeRenamed     ChangeType = "renamed"     // moved to a new path with the same value
+	ChangeAdded       ChangeType = "added"       // set by the conversion, e.g. a newly required field
+	ChangeRemoved     ChangeType = "removed"     // dropped by the conversion
+	ChangeModified    ChangeType = "modified"    // same path, new value
+	ChangeUnsupported ChangeType = "unsupported" // not in the target version's schema; needs manual attention
+)
+
+// FieldChange is a field of a converted resource that changed or needs manual attention
+type FieldChange struct {
+	Type     ChangeType `json:"type"`
+	Path     string     `json:"path"`
+	NewPath  string     `json:"newPath,omitempty"`
+	OldValue string     `json:"oldValue,omitempty"`
+	NewValue string     `json:"newValue,omitempty"`
+}
+
+// String formats the change as a diff line; lines starting with ! need manual attention
+func (c FieldChange) String() string {
+	switch c.Type {
+	case ChangeRenamed:
+		return fmt.Sprintf("~ %s -> %s", c.Path, c.NewPath)
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.NewValue)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, c.OldValue)
+	case ChangeModified:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.OldValue, c.NewValue)
+	default:
+		return fmt.Sprintf("! %s: not in the target schema, review manually", c.Path)
+	}
+}
+
+// flattenFields collects the leaf fields of a resource by path, e.g. spec.rules[0].host
+func flattenFields(path string, value interface{}, fields map[string]string) {
+	switch value := value.(type) {
+	case Object:
+		if len(value) == 0 {
+			fields[path] = "{}"
+		}
+		for key, child := range value {
+			flattenFields(joinPath(path, key), child, fields)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			fields[path] = "[]"
+		}
+		for i, child := range value {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		fields[path] = fmt.Sprint(value)
+	}
+}
+
+// diffFields compares the leaf fields of a resource before and after conversion. A removed field
+// whose value reappears at an added path is reported as renamed.
+func diffFields(before, after map[string]string) []FieldChange {
+	var changes []FieldChange
+	var removed, added []string
+	for path, value := range before {
+		newValue, found := after[path]
+		switch {
+		case !found:
+			removed = append(removed, path)
+		case newValue != value:
+			changes = append(changes, FieldChange{Type: ChangeModified, Path: path, OldValue: value, NewValue: newValue})
+		}
+	}
+	for path := range after {
+		if _, found := before[path]; !found {
+			added = append(added, path)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	paired := make(map[string]bool)
+	for _, path := range removed {
+		renamed := false
+		for _, newPath := range added {
+			if !paired[newPath] && after[newPath] == before[path] {
+				paired[newPath] = true
+				renamed = true
+				changes = append(changes, FieldChange{Type: ChangeRenamed, Path: path, NewPath: newPath, OldValue: before[path], NewValue: after[newPath]})
+				break
+			}
+		}
+		if !renamed {
+			changes = append(changes, FieldChange{Type: ChangeRemoved, Path: path, OldValue: before[path]})
+		}
+	}
+	for _, path := range added {
+		if !paired[path] {
+			changes = append(changes, FieldChange{Type: ChangeAdded, Path: path, NewValue: after[path]})
+		}
+	}
+	return changes
+}
+
+// unsupportedFields reports fields the target schema doesn't have. The schema lists the allowed
+// keys by path, with [] for list items; paths it doesn't list aren't checked.
+func unsupportedFields(path, schemaPath string, value interface{}, schema map[string][]string, changes []FieldChange) []FieldChange {
+	switch value := value.(type) {
+	case Object:
+		allowed, checked := schema[schemaPath]
+		for key, child := range value {
+			if checked && !contains(allowed, key) {
+				change := FieldChange{Type: ChangeUnsupported, Path: joinPath(path, key)}
+				if _, nested := child.(Object); !nested {
+					change.OldValue = fmt.Sprint(child)
+				}
+				changes = append(changes, change)
+				continue
+			}
+			changes = unsupportedFields(joinPath(path, key), joinPath(schemaPath, key), child, schema, changes)
+		}
+	case []interface{}:
+		for i, child := range value {
+			changes = unsupportedFields(fmt.Sprintf("%s[%d]", path, i), schemaPath+"[]", child, schema, changes)
+		}
+	}
+	return changes
+}
+
+// joinPath appends a key to a field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// contains reports whether a list holds a value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortChanges orders changes by path
+func sortChanges(changes []FieldChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
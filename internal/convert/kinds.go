@@ -0,0 +1,388 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ingressBackendSchema = []string{"service", "resource"}
+	ingressServiceSchema = []string{"name", "port"}
+	ingressPortSchema    = []string{"number", "name"}
+
+	// ingressSchema is the networking.k8s.io/v1 Ingress spec
+	ingressSchema = map[string][]string{
+		"spec":                                           {"ingressClassName", "defaultBackend", "tls", "rules"},
+		"spec.defaultBackend":                            ingressBackendSchema,
+		"spec.defaultBackend.service":                    ingressServiceSchema,
+		"spec.defaultBackend.service.port":               ingressPortSchema,
+		"spec.tls[]":                                     {"hosts", "secretName"},
+		"spec.rules[]":                                   {"host", "http"},
+		"spec.rules[].http":                              {"paths"},
+		"spec.rules[].http.paths[]":                      {"path", "pathType", "backend"},
+		"spec.rules[].http.paths[].backend":              ingressBackendSchema,
+		"spec.rules[].http.paths[].backend.service":      ingressServiceSchema,
+		"spec.rules[].http.paths[].backend.service.port": ingressPortSchema,
+	}
+
+	// podDisruptionBudgetSchema is the policy/v1 PodDisruptionBudget spec
+	podDisruptionBudgetSchema = map[string][]string{
+		"spec": {"minAvailable", "maxUnavailable", "selector", "unhealthyPodEvictionPolicy"},
+	}
+
+	// cronJobSchema is the batch/v1 CronJob spec
+	cronJobSchema = map[string][]string{
+		"spec": {"schedule", "timeZone", "startingDeadlineSeconds", "concurrencyPolicy", "suspend",
+			"jobTemplate", "successfulJobsHistoryLimit", "failedJobsHistoryLimit"},
+	}
+
+	hpaTargetSchema        = []string{"type", "value", "averageValue", "averageUtilization"}
+	hpaMetricSchema        = []string{"name", "selector"}
+	hpaScalingRulesSchema  = []string{"stabilizationWindowSeconds", "selectPolicy", "policies"}
+	hpaScalingPolicySchema = []string{"type", "value", "periodSeconds"}
+
+	// horizontalPodAutoscalerSchema is the autoscaling/v2 HorizontalPodAutoscaler spec
+	horizontalPodAutoscalerSchema = map[string][]string{
+		"spec":                                    {"scaleTargetRef", "minReplicas", "maxReplicas", "metrics", "behavior"},
+		"spec.scaleTargetRef":                     {"apiVersion", "kind", "name"},
+		"spec.behavior":                           {"scaleUp", "scaleDown"},
+		"spec.behavior.scaleUp":                   hpaScalingRulesSchema,
+		"spec.behavior.scaleUp.policies[]":        hpaScalingPolicySchema,
+		"spec.behavior.scaleDown":                 hpaScalingRulesSchema,
+		"spec.behavior.scaleDown.policies[]":      hpaScalingPolicySchema,
+		"spec.metrics[]":                          {"type", "object", "pods", "resource", "containerResource", "external"},
+		"spec.metrics[].resource":                 {"name", "target"},
+		"spec.metrics[].resource.target":          hpaTargetSchema,
+		"spec.metrics[].containerResource":        {"name", "container", "target"},
+		"spec.metrics[].containerResource.target": hpaTargetSchema,
+		"spec.metrics[].pods":                     {"metric", "target"},
+		"spec.metrics[].pods.metric":              hpaMetricSchema,
+		"spec.metrics[].pods.target":              hpaTargetSchema,
+		"spec.metrics[].object":                   {"describedObject", "metric", "target"},
+		"spec.metrics[].object.describedObject":   {"apiVersion", "kind", "name"},
+		"spec.metrics[].object.metric":            hpaMetricSchema,
+		"spec.metrics[].object.target":            hpaTargetSchema,
+		"spec.metrics[].external":                 {"metric", "target"},
+		"spec.metrics[].external.metric":          hpaMetricSchema,
+		"spec.metrics[].external.target":          hpaTargetSchema,
+	}
+
+	// hpaMetricSources maps an autoscaling/v2 metric type to the field of its source
+	hpaMetricSources = map[string]string{
+		"Resource":          "resource",
+		"ContainerResource": "containerResource",
+		"Pods":              "pods",
+		"Object":            "object",
+		"External":          "external",
+	}
+
+	// hpaTargetTypes lists the target types each metric type allows
+	hpaTargetTypes = map[string][]string{
+		"Resource":          {"Utilization", "AverageValue"},
+		"ContainerResource": {"Utilization", "AverageValue"},
+		"Pods":              {"AverageValue"},
+		"Object":            {"Value", "AverageValue"},
+		"External":          {"Value", "AverageValue"},
+	}
+
+	// hpaTargetFields maps a target type to the target field holding its value
+	hpaTargetFields = map[string]string{
+		"Utilization":  "averageUtilization",
+		"AverageValue": "averageValue",
+		"Value":        "value",
+	}
+)
+
+// convertIngress converts an extensions/v1beta1 or networking.k8s.io/v1beta1 Ingress to
+// networking.k8s.io/v1: spec.backend becomes spec.defaultBackend, serviceName/servicePort move
+// into backend.service, and paths get the now required pathType
+func convertIngress(obj Object) []string {
+	var notes []string
+	spec, ok := obj["spec"].(Object)
+	if !ok {
+		return notes
+	}
+
+	if backend, ok := spec["backend"].(Object); ok {
+		spec["defaultBackend"] = convertIngressBackend(backend)
+		delete(spec, "backend")
+	}
+
+	rules, _ := spec["rules"].([]interface{})
+	for _, rule := range rules {
+		rule, ok := rule.(Object)
+		if !ok {
+			continue
+		}
+		http, ok := rule["http"].(Object)
+		if !ok {
+			continue
+		}
+		paths, _ := http["paths"].([]interface{})
+		for _, path := range paths {
+			path, ok := path.(Object)
+			if !ok {
+				continue
+			}
+			if backend, ok := path["backend"].(Object); ok {
+				path["backend"] = convertIngressBackend(backend)
+			}
+			if _, found := path["pathType"]; !found {
+				path["pathType"] = "ImplementationSpecific"
+				notes = appendOnce(notes, "pathType was required in networking.k8s.io/v1 and set to ImplementationSpecific; use Prefix or Exact for portable matching")
+			}
+		}
+	}
+
+	if metadata, ok := obj["metadata"].(Object); ok {
+		annotations, _ := metadata["annotations"].(Object)
+		if class, found := annotations["kubernetes.io/ingress.class"]; found {
+			if _, set := spec["ingressClassName"]; !set {
+				notes = append(notes, fmt.Sprintf("the kubernetes.io/ingress.class annotation is deprecated; consider spec.ingressClassName: %v", class))
+			}
+		}
+	}
+
+	return notes
+}
+
+// convertIngressBackend converts a v1beta1 backend ({serviceName, servicePort}) to a v1 backend
+// ({service: {name, port: {number|name}}}); resource backends are unchanged
+func convertIngressBackend(backend Object) Object {
+	serviceName, hasName := backend["serviceName"]
+	servicePort, hasPort := backend["servicePort"]
+	if !hasName && !hasPort {
+		return backend
+	}
+
+	converted := Object{}
+	for key, value := range backend {
+		if key != "serviceName" && key != "servicePort" {
+			converted[key] = value
+		}
+	}
+
+	service := Object{"name": serviceName}
+	if hasPort {
+		service["port"] = ingressServicePort(servicePort)
+	}
+	converted["service"] = service
+	return converted
+}
+
+// ingressServicePort converts an int-or-string servicePort to a port number or name
+func ingressServicePort(port interface{}) Object {
+	switch value := port.(type) {
+	case int:
+		return Object{"number": value}
+	case int64:
+		return Object{"number": value}
+	case float64:
+		return Object{"number": int(value)}
+	case string:
+		if number, err := strconv.Atoi(value); err == nil {
+			return Object{"number": number}
+		}
+		return Object{"name": value}
+	default:
+		return Object{"name": fmt.Sprint(value)}
+	}
+}
+
+// convertPodDisruptionBudget converts a policy/v1beta1 PodDisruptionBudget to policy/v1. The
+// fields are unchanged, but an empty selector now selects every pod in the namespace.
+func convertPodDisruptionBudget(obj Object) []string {
+	var notes []string
+	spec, _ := obj["spec"].(Object)
+	selector, _ := spec["selector"].(Object)
+	if len(selector) == 0 {
+		notes = append(notes, "an empty selector matched no pods in policy/v1beta1 but matches every pod in the namespace in policy/v1")
+	}
+	return notes
+}
+
+// convertCronJob converts a batch/v1beta1 CronJob to batch/v1, which has the same fields
+func convertCronJob(obj Object) []string {
+	return nil
+}
+
+// convertHorizontalPodAutoscaler converts an autoscaling/v2beta2 HorizontalPodAutoscaler to
+// autoscaling/v2, which has the same fields, and notes metric targets autoscaling/v2 rejects
+func convertHorizontalPodAutoscaler(obj Object) []string {
+	return hpaMetricProblems(obj)
+}
+
+// convertHorizontalPodAutoscalerV2beta1 converts an autoscaling/v2beta1 HorizontalPodAutoscaler
+// to autoscaling/v2: metric names and selectors move into metric, the target fields
+// (targetAverageUtilization, targetAverageValue, targetValue) into a typed target, and the
+// object an Object metric describes from target to describedObject. autoscaling/v2 allows one
+// target per metric, so further target fields are dropped with a note.
+func convertHorizontalPodAutoscalerV2beta1(obj Object) []string {
+	var notes []string
+	spec, ok := obj["spec"].(Object)
+	if !ok {
+		return notes
+	}
+
+	metrics, _ := spec["metrics"].([]interface{})
+	for i, metric := range metrics {
+		metric, ok := metric.(Object)
+		if !ok {
+			continue
+		}
+		metricType, _ := metric["type"].(string)
+		source, ok := metric[hpaMetricSources[metricType]].(Object)
+		if !ok {
+			continue
+		}
+
+		var target Object
+		var dropped []string
+		switch metricType {
+		case "Resource", "ContainerResource":
+			target, dropped = hpaTarget(source, "targetAverageUtilization", "targetAverageValue", "")
+		case "Pods":
+			hpaMetricIdentifier(source, "metricName", "selector")
+			target, dropped = hpaTarget(source, "", "targetAverageValue", "")
+		case "Object":
+			if describedObject, ok := source["target"].(Object); ok {
+				source["describedObject"] = describedObject
+				delete(source, "target")
+			}
+			hpaMetricIdentifier(source, "metricName", "selector")
+			target, dropped = hpaTarget(source, "", "averageValue", "targetValue")
+		case "External":
+			hpaMetricIdentifier(source, "metricName", "metricSelector")
+			target, dropped = hpaTarget(source, "", "targetAverageValue", "targetValue")
+		}
+		if target != nil {
+			source["target"] = target
+		}
+		for _, field := range dropped {
+			notes = append(notes, fmt.Sprintf("spec.metrics[%d].%s.%s is dropped: autoscaling/v2 allows one target per metric, and %s is kept",
+				i, hpaMetricSources[metricType], field, target["type"]))
+		}
+	}
+
+	if _, found := spec["behavior"]; !found {
+		notes = append(notes, "autoscaling/v2 adds spec.behavior; without it the controller defaults still apply, e.g. a 5 minute scale-down stabilization window")
+	}
+	return append(notes, hpaMetricProblems(obj)...)
+}
+
+// hpaTarget moves the first target field a v2beta1 metric source sets into an autoscaling/v2
+// target; empty keys are skipped. It returns nil when the source sets none, and the keys of
+// further target fields, which are removed from the source.
+func hpaTarget(source Object, utilization, averageValue, value string) (Object, []string) {
+	var target Object
+	var dropped []string
+	for _, field := range []struct{ key, targetType string }{
+		{utilization, "Utilization"},
+		{averageValue, "AverageValue"},
+		{value, "Value"},
+	} {
+		if field.key == "" {
+			continue
+		}
+		v, found := source[field.key]
+		if !found {
+			continue
+		}
+		delete(source, field.key)
+		if target == nil {
+			target = Object{"type": field.targetType, hpaTargetFields[field.targetType]: v}
+		} else {
+			dropped = append(dropped, field.key)
+		}
+	}
+	return target, dropped
+}
+
+// hpaMetricIdentifier moves the metric name and selector of a v2beta1 metric source into metric
+func hpaMetricIdentifier(source Object, nameKey, selectorKey string) {
+	metric := Object{}
+	if name, found := source[nameKey]; found {
+		metric["name"] = name
+		delete(source, nameKey)
+	}
+	if selector, found := source[selectorKey]; found {
+		metric["selector"] = selector
+		delete(source, selectorKey)
+	}
+	if len(metric) > 0 {
+		source["metric"] = metric
+	}
+}
+
+// CheckHorizontalPodAutoscaler reports the problems of an autoscaling/v2 HorizontalPodAutoscaler
+// converted by hand: metric targets whose type the metric doesn't allow or whose value is missing,
+// and fields left over from the beta versions, e.g. targetAverageUtilization
+func CheckHorizontalPodAutoscaler(obj Object) []string {
+	notes := hpaMetricProblems(obj)
+	for _, change := range unsupportedFields("", "", obj, horizontalPodAutoscalerSchema, nil) {
+		notes = append(notes, fmt.Sprintf("%s is not an autoscaling/v2 field", change.Path))
+	}
+	sort.Strings(notes)
+	return notes
+}
+
+// hpaMetricProblems checks the metrics of an autoscaling/v2 HorizontalPodAutoscaler against the
+// target types and fields each metric type requires
+func hpaMetricProblems(obj Object) []string {
+	var notes []string
+	spec, _ := obj["spec"].(Object)
+	metrics, _ := spec["metrics"].([]interface{})
+	for i, metric := range metrics {
+		metric, ok := metric.(Object)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("spec.metrics[%d]", i)
+		metricType, _ := metric["type"].(string)
+		key, known := hpaMetricSources[metricType]
+		if !known {
+			notes = append(notes, fmt.Sprintf("%s: unknown metric type %q", path, metricType))
+			continue
+		}
+		source, ok := metric[key].(Object)
+		if !ok {
+			notes = append(notes, fmt.Sprintf("%s: %s metrics need %s", path, metricType, key))
+			continue
+		}
+		if metricType == "Pods" || metricType == "Object" || metricType == "External" {
+			if identifier, _ := source["metric"].(Object); identifier["name"] == nil {
+				notes = append(notes, fmt.Sprintf("%s: %s metrics need %s.metric.name", path, metricType, key))
+			}
+		}
+		if _, found := source["describedObject"]; metricType == "Object" && !found {
+			notes = append(notes, fmt.Sprintf("%s: Object metrics need object.describedObject", path))
+		}
+
+		target, ok := source["target"].(Object)
+		if !ok {
+			notes = append(notes, fmt.Sprintf("%s: %s metrics need %s.target", path, metricType, key))
+			continue
+		}
+		targetType, _ := target["type"].(string)
+		if !contains(hpaTargetTypes[metricType], targetType) {
+			notes = append(notes, fmt.Sprintf("%s: %s metrics support target type %s, not %q", path, metricType, strings.Join(hpaTargetTypes[metricType], " or "), targetType))
+			continue
+		}
+		if _, found := target[hpaTargetFields[targetType]]; !found {
+			notes = append(notes, fmt.Sprintf("%s: target type %s needs %s.target.%s", path, targetType, key, hpaTargetFields[targetType]))
+		}
+	}
+	return notes
+}
+
+// appendOnce appends a note unless it is already present
+func appendOnce(notes []string, note string) []string {
+	for _, existing := range notes {
+		if existing == note {
+			return notes
+		}
+	}
+	return append(notes, note)
+}
@@ -0,0 +1,91 @@
+package convert
+
+import "strings"
+
+// podSpecPaths locates the pod spec of each kind with a pod template
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// RewriteNodeLabels replaces deprecated node label keys, e.g. beta.kubernetes.io/os, with their
+// replacements in the node selector, node affinity and topology keys of a resource's pod template,
+// in place. The label selectors of pod affinities and topology spread constraints match other
+// pods' labels and are left alone. It returns nil when the resource has no pod template or nothing
+// to rewrite.
+func RewriteNodeLabels(obj Object, rewrites map[string]string) *Result {
+	if len(rewrites) == 0 {
+		return nil
+	}
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	path, ok := podSpecPaths[kind]
+	if !ok {
+		return nil
+	}
+	spec := obj
+	for _, key := range path {
+		if spec, ok = spec[key].(Object); !ok {
+			return nil
+		}
+	}
+
+	before := make(map[string]string)
+	flattenFields("", obj, before)
+
+	if selector, ok := spec["nodeSelector"].(Object); ok {
+		for key, value := range selector {
+			if replacement, ok := rewrites[key]; ok {
+				delete(selector, key)
+				selector[replacement] = value
+			}
+		}
+	}
+	if affinity, ok := spec["affinity"].(Object); ok {
+		rewriteLabelKeys(affinity["nodeAffinity"], rewrites, "key")
+		rewriteLabelKeys(affinity["podAffinity"], rewrites, "topologyKey")
+		rewriteLabelKeys(affinity["podAntiAffinity"], rewrites, "topologyKey")
+	}
+	rewriteLabelKeys(spec["topologySpreadConstraints"], rewrites, "topologyKey")
+
+	after := make(map[string]string)
+	flattenFields("", obj, after)
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	sortChanges(changes)
+
+	result := &Result{FromAPIVersion: apiVersion, ToAPIVersion: apiVersion, Kind: kind, Changes: changes}
+	if metadata, ok := obj["metadata"].(Object); ok {
+		result.Name, _ = metadata["name"].(string)
+		result.Namespace, _ = metadata["namespace"].(string)
+	}
+	return result
+}
+
+// rewriteLabelKeys replaces the label keys held by the given fields below a value, e.g. the keys of
+// match expressions
+func rewriteLabelKeys(value interface{}, rewrites map[string]string, fields ...string) {
+	switch value := value.(type) {
+	case Object:
+		for key, child := range value {
+			if s, ok := child.(string); ok && contains(fields, key) {
+				if replacement, ok := rewrites[strings.TrimSpace(s)]; ok {
+					value[key] = replacement
+				}
+				continue
+			}
+			rewriteLabelKeys(child, rewrites, fields...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			rewriteLabelKeys(child, rewrites, fields...)
+		}
+	}
+}
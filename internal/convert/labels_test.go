@@ -0,0 +1,108 @@
+package convert
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const zoneDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    failure-domain.beta.kubernetes.io/zone: eu-1a
+spec:
+  selector:
+    matchLabels:
+      failure-domain.beta.kubernetes.io/zone: eu-1a
+  template:
+    metadata:
+      labels:
+        failure-domain.beta.kubernetes.io/zone: eu-1a
+    spec:
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+              - matchExpressions:
+                  - {key: failure-domain.beta.kubernetes.io/zone, operator: In, values: [eu-1a]}
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            - topologyKey: failure-domain.beta.kubernetes.io/zone
+              labelSelector:
+                matchExpressions:
+                  - {key: failure-domain.beta.kubernetes.io/zone, operator: Exists}
+      topologySpreadConstraints:
+        - topologyKey: failure-domain.beta.kubernetes.io/zone
+          maxSkew: 1
+          whenUnsatisfiable: DoNotSchedule
+          labelSelector:
+            matchLabels:
+              failure-domain.beta.kubernetes.io/zone: eu-1a
+`
+
+var nodeLabelRewrites = map[string]string{
+	"beta.kubernetes.io/os":                  "kubernetes.io/os",
+	"failure-domain.beta.kubernetes.io/zone": "topology.kubernetes.io/zone",
+}
+
+func TestRewriteNodeLabels(t *testing.T) {
+	var obj Object
+	if err := yaml.Unmarshal([]byte(zoneDeployment), &obj); err != nil {
+		t.Fatal(err)
+	}
+	result := RewriteNodeLabels(obj, nodeLabelRewrites)
+	if result == nil {
+		t.Fatal("RewriteNodeLabels rewrote nothing")
+	}
+
+	changed := make(map[string]bool)
+	for _, change := range result.Changes {
+		changed[change.Path] = true
+		changed[change.NewPath] = true
+	}
+	fields := make(map[string]string)
+	flattenFields("", obj, fields)
+
+	spec := "spec.template.spec."
+	rewritten := []string{
+		spec + "nodeSelector.kubernetes.io/os",
+		spec + "affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0].matchExpressions[0].key",
+		spec + "affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution[0].topologyKey",
+		spec + "topologySpreadConstraints[0].topologyKey",
+	}
+	for _, path := range rewritten {
+		if !changed[path] {
+			t.Errorf("%s not rewritten; changes: %+v", path, result.Changes)
+		}
+	}
+
+	// Pod labels and the selectors matching them are kept
+	kept := map[string]string{
+		"metadata.labels.failure-domain.beta.kubernetes.io/zone":                                                                  "eu-1a",
+		"spec.selector.matchLabels.failure-domain.beta.kubernetes.io/zone":                                                        "eu-1a",
+		"spec.template.metadata.labels.failure-domain.beta.kubernetes.io/zone":                                                    "eu-1a",
+		spec + "affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution[0].labelSelector.matchExpressions[0].key": "failure-domain.beta.kubernetes.io/zone",
+		spec + "topologySpreadConstraints[0].labelSelector.matchLabels.failure-domain.beta.kubernetes.io/zone":                    "eu-1a",
+	}
+	for path, want := range kept {
+		if got := fields[path]; got != want {
+			t.Errorf("%s = %q, want %q kept", path, got, want)
+		}
+	}
+}
+
+func TestRewriteNodeLabelsNothingToRewrite(t *testing.T) {
+	obj := Object{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   Object{"name": "web"},
+	}
+	if result := RewriteNodeLabels(obj, nodeLabelRewrites); result != nil {
+		t.Errorf("RewriteNodeLabels(Service) = %+v, want nil", result)
+	}
+}
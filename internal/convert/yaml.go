@@ -0,0 +1,194 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertYAML converts every resource with a built-in converter in a multi-document YAML stream,
+// and rewrites deprecated node labels in pod templates with RewriteNodeLabels. Other documents are
+// kept byte for byte; converted documents are re-encoded with their comments and key order, new
+// fields following the existing ones of their object.
+func ConvertYAML(data []byte, labelRewrites map[string]string) ([]byte, []*Result, error) {
+	var results []*Result
+	documents := splitDocuments(string(data))
+	for i, document := range documents {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(document.body), &node); err != nil || len(node.Content) == 0 {
+			continue
+		}
+		var obj Object
+		if err := node.Decode(&obj); err != nil || obj == nil {
+			continue
+		}
+		result, err := Convert(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		var converted []*Result
+		if result != nil {
+			converted = append(converted, result)
+		}
+		if result := RewriteNodeLabels(obj, labelRewrites); result != nil {
+			converted = append(converted, result)
+		}
+		if len(converted) == 0 {
+			continue
+		}
+
+		node.Content[0], err = mergeNode(node.Content[0], obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode converted %s: %w", converted[0].Resource(), err)
+		}
+		encoded, err := encodeYAML(&node)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode converted %s: %w", converted[0].Resource(), err)
+		}
+		if !strings.HasSuffix(document.body, "\n") {
+			encoded = strings.TrimSuffix(encoded, "\n")
+		}
+		documents[i].body = encoded
+		results = append(results, converted...)
+	}
+
+	var converted strings.Builder
+	for _, document := range documents {
+		converted.WriteString(document.header)
+		converted.WriteString(document.body)
+	}
+	return []byte(converted.String()), results, nil
+}
+
+// document is a document of a YAML stream: its separator line, e.g. "--- # comment", and the rest
+type document struct {
+	header string
+	body   string
+}
+
+// splitDocuments splits a YAML stream at its document separators, keeping every byte. Only a line
+// starting with "---" followed by whitespace or its end separates documents, as in the YAML spec,
+// so "---" inside a scalar or a "----" line does not.
+func splitDocuments(text string) []document {
+	documents := []document{{}}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if isDocumentSeparator(line) {
+			documents = append(documents, document{header: line})
+			continue
+		}
+		documents[len(documents)-1].body += line
+	}
+	return documents
+}
+
+func isDocumentSeparator(line string) bool {
+	if !strings.HasPrefix(line, "---") {
+		return false
+	}
+	rest := line[len("---"):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n'
+}
+
+// mergeNode updates a decoded node to a value, keeping the comments, styles and key order of what
+// is left of it. Keys the value doesn't have anymore are removed and new ones appended in sorted
+// order; a node that changes kind is replaced but keeps its comments.
+func mergeNode(node *yaml.Node, value interface{}) (*yaml.Node, error) {
+	var current interface{}
+	if err := node.Decode(&current); err == nil && reflect.DeepEqual(current, value) {
+		return node, nil
+	}
+
+	switch value := value.(type) {
+	case Object:
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+		content := make([]*yaml.Node, 0, len(node.Content))
+		kept := make(map[string]bool, len(value))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			child, ok := value[key]
+			if !ok {
+				continue
+			}
+			merged, err := mergeNode(node.Content[i+1], child)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, node.Content[i], merged)
+			kept[key] = true
+		}
+		added := make([]string, 0, len(value))
+		for key := range value {
+			if !kept[key] {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		for _, key := range added {
+			child, err := encodeNode(value[key])
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		node.Content = content
+		return node, nil
+	case []interface{}:
+		if node.Kind != yaml.SequenceNode {
+			break
+		}
+		content := make([]*yaml.Node, 0, len(value))
+		for i, item := range value {
+			if i < len(node.Content) {
+				merged, err := mergeNode(node.Content[i], item)
+				if err != nil {
+					return nil, err
+				}
+				content = append(content, merged)
+				continue
+			}
+			child, err := encodeNode(item)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, child)
+		}
+		node.Content = content
+		return node, nil
+	}
+
+	replaced, err := encodeNode(value)
+	if err != nil {
+		return nil, err
+	}
+	replaced.HeadComment, replaced.LineComment, replaced.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	return replaced, nil
+}
+
+// encodeNode encodes a value as a node
+func encodeNode(value interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// encodeYAML encodes a document with the two-space indentation common in manifests
+func encodeYAML(document *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
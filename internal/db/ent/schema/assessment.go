@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+// Assessment holds the schema definition for the Assessment entity.
+type Assessment struct {
+	ent.Schema
+}
+
+// Fields of the Assessment.
+func (Assessment) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("target_version").
+			NotEmpty(),
+		field.String("current_version").
+			Default(""),
+		field.String("overall_risk").
+			Default(""),
+		field.Int("total_issues").
+			Default(0),
+		field.Bool("live").
+			Default(false),
+		// JSON-encoded analysis.ImpactAssessment
+		field.Text("snapshot"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+	}
+}
+
+// Edges of the Assessment.
+func (Assessment) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("cluster", Cluster.Type).
+			Ref("assessments").
+			Required().
+			Unique(),
+		edge.To("plans", Plan.Type),
+	}
+}
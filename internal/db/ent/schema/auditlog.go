@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+)
+
+// AuditLog holds the schema definition for the AuditLog entity: an action of the advisor, kept as
+// change-management evidence. Entries name their cluster by ID instead of an edge, so they outlive
+// deleted clusters and record scans of clusters that were never stored.
+type AuditLog struct {
+	ent.Schema
+}
+
+// Fields of the AuditLog.
+func (AuditLog) Fields() []ent.Field {
+	return []ent.Field{
+		// scan, impact, plan or fix
+		field.String("action").
+			NotEmpty().
+			Immutable(),
+		// OS user of the CLI, or name of the API token on the server
+		field.String("actor").
+			NotEmpty().
+			Immutable(),
+		// cli or server
+		field.String("origin").
+			Default("cli").
+			Immutable(),
+		field.String("cluster_id").
+			Default("").
+			Immutable(),
+		// flags and arguments the action was run with
+		field.JSON("parameters", map[string]string{}).
+			Optional().
+			Immutable(),
+		field.Bool("succeeded").
+			Immutable(),
+		// result, e.g. "overall risk high, 12 issues"
+		field.Text("summary").
+			Default("").
+			Immutable(),
+		field.Text("error").
+			Default("").
+			Immutable(),
+		field.Time("started_at").
+			Immutable(),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+	}
+}
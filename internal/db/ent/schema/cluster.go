@@ -21,6 +21,31 @@ func (Cluster) Fields() []ent.Field {
 			Immutable(),
 		field.String("name"),
 		field.String("kube_version"),
+		// user-defined grouping labels, e.g. env=prod, region=eu, team=platform
+		field.JSON("labels", map[string]string{}).
+			Optional(),
+		// kinds served per group/version (e.g. "apps/v1") at scan time, from API discovery
+		field.JSON("served_apis", map[string][]string{}).
+			Optional(),
+		// distinct os/arch of the nodes at scan time, e.g. "linux/amd64", "windows/amd64"
+		field.JSON("node_platforms", []string{}).
+			Optional(),
+		// criticality of namespaces labeled or annotated with one at scan time, e.g. ingress: production
+		field.JSON("namespace_criticality", map[string]string{}).
+			Optional(),
+		// namespaces being deleted at scan time; resources applied to them fail
+		field.JSON("terminating_namespaces", []string{}).
+			Optional(),
+		// why the last scan stopped early (interrupted or timed out); empty when it completed
+		field.String("incomplete_reason").
+			Optional(),
+		// errors of scan subsystems (e.g. "helm", "crds") that failed while the rest of the scan went on
+		field.JSON("scan_errors", map[string]string{}).
+			Optional(),
+		// resourceVersion per watched resource type (e.g. "crds", "helm") at the last scan, from
+		// which scan --incremental follows changes
+		field.JSON("resource_versions", map[string]string{}).
+			Optional(),
 		field.Time("created_at").
 			Default(time.Now).
 			Immutable(),
@@ -36,5 +61,8 @@ func (Cluster) Edges() []ent.Edge {
 		edge.To("helm_releases", HelmRelease.Type),
 		edge.To("crds", CRD.Type),
 		edge.To("manifest_apis", ManifestAPI.Type),
+		edge.To("resources", Resource.Type),
+		edge.To("plans", Plan.Type),
+		edge.To("assessments", Assessment.Type),
 	}
 }
@@ -13,6 +13,15 @@ type CRD struct {
 	ent.Schema
 }
 
+// CRDVersion is a version of a CRD as its definition declares it
+type CRDVersion struct {
+	Name               string `json:"name"`
+	Served             bool   `json:"served"`
+	Storage            bool   `json:"storage"`
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationWarning string `json:"deprecationWarning,omitempty"`
+}
+
 // Fields of the CRD.
 func (CRD) Fields() []ent.Field {
 	return []ent.Field{
@@ -24,6 +33,21 @@ func (CRD) Fields() []ent.Field {
 			Default(""),
 		field.JSON("versions", []string{}).
 			Optional(),
+		field.JSON("stored_versions", []string{}).
+			Optional(),
+		// every version of the definition, served or not; versions only lists the served names
+		field.JSON("version_details", []CRDVersion{}).
+			Optional(),
+		field.String("scope").
+			Optional(), // "Namespaced" or "Cluster"
+		// service (namespace/name) or URL of the conversion webhook; empty without one
+		field.String("conversion_webhook").
+			Optional(),
+		// why the conversion webhook's service can't serve at scan time, e.g. it has no endpoints
+		field.String("conversion_webhook_error").
+			Optional(),
+		field.Int("instance_count").
+			Default(0),
 		field.String("helm_owner_name").
 			Optional(),
 		field.String("helm_owner_namespace").
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// Digest holds the schema definition for the Digest entity: a digest email the server sent. The
+// findings it reported are kept so the next digest only lists new ones.
+type Digest struct {
+	ent.Schema
+}
+
+// Fields of the Digest.
+func (Digest) Fields() []ent.Field {
+	return []ent.Field{
+		// name of the digest in the server's digest config
+		field.String("name").
+			NotEmpty().
+			Immutable(),
+		// keys of the reported findings by cluster ID
+		field.JSON("finding_keys", map[string][]string{}).
+			Optional().
+			Immutable(),
+		field.Int("recipients").
+			Default(0).
+			Immutable(),
+		field.Time("sent_at").
+			Default(time.Now).
+			Immutable(),
+	}
+}
+
+// Indexes of the Digest.
+func (Digest) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("name", "sent_at"),
+	}
+}
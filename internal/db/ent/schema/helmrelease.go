@@ -26,6 +26,25 @@ func (HelmRelease) Fields() []ent.Field {
 			NotEmpty(),
 		field.String("app_version").
 			Optional(),
+		field.Enum("source").
+			Values("cluster", "terraform").
+			Default("cluster"),
+		field.String("status").
+			Optional(),
+		field.Int("revision").
+			Default(0),
+		field.Time("first_deployed").
+			Optional().
+			Nillable(),
+		field.Time("last_deployed").
+			Optional().
+			Nillable(),
+		// Repository or source URL of the chart, when resolvable
+		field.String("chart_url").
+			Optional(),
+		// APIs of the objects in the release manifest, e.g. "networking.k8s.io/v1beta1/Ingress"
+		field.JSON("apis", []string{}).
+			Optional(),
 		field.Time("created_at").
 			Default(time.Now).
 			Immutable(),
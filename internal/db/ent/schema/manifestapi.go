@@ -23,8 +23,12 @@ func (ManifestAPI) Fields() []ent.Field {
 		field.String("kind").
 			NotEmpty(),
 		field.Enum("source").
-			Values("git", "local").
+			Values("git", "local", "terraform", "dump").
 			Default("local"),
+		field.Bool("templated").
+			Default(false), // only found in un-rendered templates, where a condition may pick another API
+		field.JSON("namespaces", []string{}).
+			Optional(), // namespaces the manifests set on resources of the API
 		field.Time("created_at").
 			Default(time.Now).
 			Immutable(),
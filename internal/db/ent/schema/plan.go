@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+// Plan holds the schema definition for the Plan entity.
+type Plan struct {
+	ent.Schema
+}
+
+// Fields of the Plan.
+func (Plan) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("target_version").
+			NotEmpty(),
+		field.String("from_version").
+			Default(""),
+		field.Bool("skip_backup").
+			Default(false),
+		field.Bool("include_rollback").
+			Default(false),
+		field.Int("total_steps").
+			Default(0),
+		field.String("timeline").
+			Default(""),
+		// JSON-encoded planner.UpgradePlan
+		field.Text("document"),
+		// Change control: plans are drafts until a reviewer approves them, then executed
+		field.Enum("state").
+			Values("draft", "approved", "executed").
+			Default("draft"),
+		field.String("approved_by").
+			Default(""),
+		field.Time("approved_at").
+			Optional().
+			Nillable(),
+		field.String("executed_by").
+			Default(""),
+		field.Time("executed_at").
+			Optional().
+			Nillable(),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+	}
+}
+
+// Edges of the Plan.
+func (Plan) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("cluster", Cluster.Type).
+			Ref("plans").
+			Required().
+			Unique(),
+		edge.From("assessment", Assessment.Type).
+			Ref("plans").
+			Unique(),
+	}
+}
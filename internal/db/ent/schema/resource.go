@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// OwnerReference is an owner of a resource, as set in its metadata.ownerReferences
+type OwnerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid,omitempty"`
+	Controller bool   `json:"controller,omitempty"`
+}
+
+// Resource holds the schema definition for the Resource entity: a single object, either live in the
+// cluster or found in manifests.
+type Resource struct {
+	ent.Schema
+}
+
+// Fields of the Resource.
+func (Resource) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("group").
+			Default(""), // Allow empty string for core APIs
+		field.String("version").
+			NotEmpty(),
+		field.String("kind").
+			NotEmpty(),
+		field.String("namespace").
+			Default(""), // empty for cluster-scoped resources
+		field.String("name").
+			Default(""), // templates may leave the name to be rendered
+		field.JSON("labels", map[string]string{}).
+			Optional(),
+		field.Enum("source").
+			Values("cluster", "git", "local", "terraform", "dump").
+			Default("cluster"),
+		field.Bool("templated").
+			Default(false), // parsed from an un-rendered template
+		field.JSON("owner_references", []OwnerReference{}).
+			Optional(),
+		// spec of the kinds whose checks read it from the inventory, e.g. HorizontalPodAutoscalers
+		field.JSON("spec", map[string]interface{}{}).
+			Optional(),
+		// file the manifest was parsed from and the line of its document; empty for live objects
+		field.String("file").
+			Optional(),
+		field.Int("line").
+			Default(0),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Edges of the Resource.
+func (Resource) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("cluster", Cluster.Type).
+			Ref("resources").
+			Required().
+			Unique(),
+	}
+}
+
+// Indexes of the Resource.
+func (Resource) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("group", "version", "kind").
+			Edges("cluster"),
+		index.Fields("namespace", "name").
+			Edges("cluster"),
+		index.Fields("source", "file").
+			Edges("cluster"),
+	}
+}
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// Ticket holds the schema definition for the Ticket entity: an issue created in a ticketing system
+// from findings. Its idempotency key identifies the findings it tracks, so re-runs update the issue
+// instead of creating a duplicate. Like audit log entries, tickets name their cluster by ID.
+type Ticket struct {
+	ent.Schema
+}
+
+// Fields of the Ticket.
+func (Ticket) Fields() []ent.Field {
+	return []ent.Field{
+		// ticketing system, e.g. jira
+		field.String("sink").
+			NotEmpty().
+			Immutable(),
+		field.String("project").
+			NotEmpty().
+			Immutable(),
+		// cluster, target version and finding group, e.g. "prod-eu-1|1.29|owner:payments"
+		field.String("idempotency_key").
+			NotEmpty().
+			Immutable(),
+		// key of the issue in the sink, e.g. K8SUP-123
+		field.String("external_key").
+			NotEmpty(),
+		field.String("cluster_id").
+			Default("").
+			Immutable(),
+		field.String("title").
+			Default(""),
+		// findings the issue listed when last updated
+		field.Int("finding_count").
+			Default(0),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the Ticket.
+func (Ticket) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("sink", "project", "idempotency_key").
+			Unique(),
+	}
+}
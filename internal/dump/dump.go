@@ -0,0 +1,672 @@
+// Package dump builds a cluster inventory from a recorded dump, such as the output of 'kubectl
+// cluster-info dump' or a custom collector, so customer clusters can be analyzed from artifacts
+// without access to their API server.
+package dump
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// notInDump is the scan error of a subsystem the dump holds no data for
+const notInDump = "not in dump"
+
+// Load reads a dump into an inventory export. The dump is a .tar.gz archive or a directory as
+// written by 'kubectl cluster-info dump --output-directory', or a file of the concatenated JSON
+// documents it prints to stdout. Besides the objects of cluster-info dump, it understands the
+// output of 'kubectl version -o json', CRD lists, Helm release secrets and APIResourceLists
+// ('kubectl get --raw /apis/<group>/<version>') a collector adds. Subsystems the dump holds no data
+// for are recorded as scan errors.
+func Load(path string) (*inventory.Export, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %w", err)
+	}
+
+	c := newCollector()
+	if info.IsDir() {
+		err = c.readDir(path)
+	} else {
+		err = c.readFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.export(clusterName(path))
+}
+
+// clusterName derives a cluster name from the dump's file name, e.g. "acme" from acme.tar.gz
+func clusterName(path string) string {
+	name := filepath.Base(filepath.Clean(path))
+	for _, ext := range []string{".gz", ".tgz", ".tar", ".json", ".txt"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// readDir reads the JSON files of a dump directory
+func (c *collector) readDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		if err := c.readStream(f); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// readFile reads a gzipped tar archive, or a file of concatenated JSON documents
+func (c *collector) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open dump: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(2)
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if err := c.readStream(r); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return nil
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to decompress dump: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".json") {
+			continue
+		}
+		if err := c.readStream(tr); err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+	}
+}
+
+// readStream decodes concatenated JSON documents, skipping the container log sections
+// cluster-info dump interleaves with them on stdout
+func (c *collector) readStream(r io.Reader) error {
+	var docs bytes.Buffer
+	inLogs := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "==== START logs"):
+			inLogs = true
+		case strings.HasPrefix(line, "==== END logs"):
+			inLogs = false
+		case !inLogs:
+			docs.WriteString(line)
+			docs.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(&docs)
+	for {
+		var obj object
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		if err := c.add(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// object is the subset of a Kubernetes object, list or version document read from a dump
+type object struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   metadata          `json:"metadata"`
+	Items      []json.RawMessage `json:"items"`
+	Spec       json.RawMessage   `json:"spec"`
+	Status     json.RawMessage   `json:"status"`
+	// Type and Data are set on secrets and config maps
+	Type string            `json:"type"`
+	Data map[string]string `json:"data"`
+	// GroupVersion and Resources are set on APIResourceLists
+	GroupVersion string `json:"groupVersion"`
+	Resources    []struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+	} `json:"resources"`
+	// ServerVersion is set on 'kubectl version -o json' output
+	ServerVersion *struct {
+		GitVersion string `json:"gitVersion"`
+	} `json:"serverVersion"`
+}
+
+type metadata struct {
+	Name            string                     `json:"name"`
+	Namespace       string                     `json:"namespace"`
+	Labels          map[string]string          `json:"labels"`
+	Annotations     map[string]string          `json:"annotations"`
+	OwnerReferences []inventory.OwnerReference `json:"ownerReferences"`
+}
+
+// collector accumulates the inventory of the documents of a dump
+type collector struct {
+	serverVersion    string
+	apiserverVersion string
+	kubeletVersions  []string
+	nodes            []inventory.NodeEntry
+	nodesSeen        bool
+	servedAPIs       map[string][]string
+	crds             map[string]inventory.ExportedCRD
+	crdsSeen         bool
+	releases         map[string]helmRelease
+	helmSeen         bool
+	manifestAPIs     map[string]inventory.ExportedManifestAPI
+	resources        []inventory.ExportedResource
+	criticality      map[string]string
+	terminating      []string
+}
+
+func newCollector() *collector {
+	return &collector{
+		servedAPIs:   make(map[string][]string),
+		crds:         make(map[string]inventory.ExportedCRD),
+		releases:     make(map[string]helmRelease),
+		manifestAPIs: make(map[string]inventory.ExportedManifestAPI),
+		criticality:  make(map[string]string),
+	}
+}
+
+// add records a document; items of lists are added with the kind of the list when they have none
+func (c *collector) add(obj object) error {
+	if obj.ServerVersion != nil && obj.ServerVersion.GitVersion != "" {
+		c.serverVersion = obj.ServerVersion.GitVersion
+		return nil
+	}
+
+	if strings.HasSuffix(obj.Kind, "List") && obj.Kind != "APIResourceList" {
+		itemKind := strings.TrimSuffix(obj.Kind, "List")
+		c.seen(itemKind)
+		for _, raw := range obj.Items {
+			var item object
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return fmt.Errorf("failed to decode %s item: %w", obj.Kind, err)
+			}
+			if item.Kind == "" {
+				item.Kind = itemKind
+				item.APIVersion = obj.APIVersion
+			}
+			if err := c.add(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c.seen(obj.Kind)
+	c.addLastApplied(obj)
+	c.addResource(obj)
+	switch obj.Kind {
+	case "APIResourceList":
+		c.addServedAPIs(obj)
+	case "Node":
+		return c.addNode(obj)
+	case "Namespace":
+		c.addNamespace(obj)
+	case "Pod":
+		return c.addPod(obj)
+	case "CustomResourceDefinition":
+		return c.addCRD(obj)
+	case "Secret":
+		if obj.Type == "helm.sh/release.v1" {
+			return c.addHelmSecret(obj)
+		}
+	case "ConfigMap":
+		if obj.Metadata.Labels["owner"] == "helm" && obj.Data["release"] != "" {
+			return c.addRelease(obj.Data["release"])
+		}
+	}
+	return nil
+}
+
+// seen notes the kinds of objects a dump lists, to tell an empty subsystem from a missing one
+func (c *collector) seen(kind string) {
+	switch kind {
+	case "Node":
+		c.nodesSeen = true
+	case "CustomResourceDefinition":
+		c.crdsSeen = true
+	case "Secret":
+		c.helmSeen = true
+	}
+}
+
+// addResource records an object of the dump as a live resource with its owners
+func (c *collector) addResource(obj object) {
+	if obj.APIVersion == "" || obj.Kind == "" || obj.Kind == "APIResourceList" || obj.Metadata.Name == "" {
+		return
+	}
+	c.resources = append(c.resources, inventory.ExportedResource{
+		APIVersion:      obj.APIVersion,
+		Kind:            obj.Kind,
+		Namespace:       obj.Metadata.Namespace,
+		Name:            obj.Metadata.Name,
+		Labels:          obj.Metadata.Labels,
+		Source:          "dump",
+		OwnerReferences: obj.Metadata.OwnerReferences,
+	})
+}
+
+// addLastApplied records the API an object was applied with by 'kubectl apply', which the
+// cluster's manifests likely still use
+func (c *collector) addLastApplied(obj object) {
+	applied := obj.Metadata.Annotations["kubectl.kubernetes.io/last-applied-configuration"]
+	if applied == "" {
+		return
+	}
+	var config struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal([]byte(applied), &config); err != nil || config.APIVersion == "" || config.Kind == "" {
+		return
+	}
+
+	group, version := "", config.APIVersion
+	if i := strings.LastIndex(config.APIVersion, "/"); i >= 0 {
+		group, version = config.APIVersion[:i], config.APIVersion[i+1:]
+	}
+	c.manifestAPIs[config.APIVersion+" "+config.Kind] = inventory.ExportedManifestAPI{
+		Group:   group,
+		Version: version,
+		Kind:    config.Kind,
+		Source:  "dump",
+	}
+}
+
+// addNamespace records the criticality a namespace declares, if any, and whether it is terminating
+func (c *collector) addNamespace(obj object) {
+	var status struct {
+		Phase string `json:"phase"`
+	}
+	if len(obj.Status) > 0 && json.Unmarshal(obj.Status, &status) == nil && status.Phase == "Terminating" {
+		c.terminating = append(c.terminating, obj.Metadata.Name)
+	}
+
+	for _, key := range inventory.CriticalityKeys {
+		value := obj.Metadata.Labels[key]
+		if value == "" {
+			value = obj.Metadata.Annotations[key]
+		}
+		if value != "" {
+			c.criticality[obj.Metadata.Name] = strings.ToLower(value)
+			return
+		}
+	}
+}
+
+// addServedAPIs records the kinds of an APIResourceList, skipping subresources
+func (c *collector) addServedAPIs(obj object) {
+	seen := make(map[string]bool)
+	for _, kind := range c.servedAPIs[obj.GroupVersion] {
+		seen[kind] = true
+	}
+	for _, resource := range obj.Resources {
+		if strings.Contains(resource.Name, "/") || resource.Kind == "" || seen[resource.Kind] {
+			continue
+		}
+		seen[resource.Kind] = true
+		c.servedAPIs[obj.GroupVersion] = append(c.servedAPIs[obj.GroupVersion], resource.Kind)
+	}
+	sort.Strings(c.servedAPIs[obj.GroupVersion])
+}
+
+// addNode records a node's platform and kubelet version
+func (c *collector) addNode(obj object) error {
+	var status struct {
+		NodeInfo struct {
+			KubeletVersion  string `json:"kubeletVersion"`
+			OperatingSystem string `json:"operatingSystem"`
+			Architecture    string `json:"architecture"`
+		} `json:"nodeInfo"`
+	}
+	if len(obj.Status) > 0 {
+		if err := json.Unmarshal(obj.Status, &status); err != nil {
+			return fmt.Errorf("failed to decode node %s: %w", obj.Metadata.Name, err)
+		}
+	}
+
+	c.nodes = append(c.nodes, inventory.NodeEntry{
+		Name:   obj.Metadata.Name,
+		Labels: obj.Metadata.Labels,
+		OS:     status.NodeInfo.OperatingSystem,
+		Arch:   status.NodeInfo.Architecture,
+	})
+	if status.NodeInfo.KubeletVersion != "" {
+		c.kubeletVersions = append(c.kubeletVersions, status.NodeInfo.KubeletVersion)
+	}
+	return nil
+}
+
+// addPod records the version of a kube-apiserver static pod from its image tag
+func (c *collector) addPod(obj object) error {
+	if obj.Metadata.Namespace != "kube-system" || obj.Metadata.Labels["component"] != "kube-apiserver" {
+		return nil
+	}
+	var spec struct {
+		Containers []struct {
+			Image string `json:"image"`
+		} `json:"containers"`
+	}
+	if err := json.Unmarshal(obj.Spec, &spec); err != nil {
+		return fmt.Errorf("failed to decode pod %s: %w", obj.Metadata.Name, err)
+	}
+
+	for _, container := range spec.Containers {
+		image, _, _ := strings.Cut(container.Image, "@")
+		i := strings.LastIndex(image, ":")
+		if i < 0 || !strings.Contains(image[:i], "kube-apiserver") {
+			continue
+		}
+		if tag := image[i+1:]; strings.HasPrefix(tag, "v") && newerVersion(tag, c.apiserverVersion) {
+			c.apiserverVersion = tag
+		}
+	}
+	return nil
+}
+
+// addCRD records a CRD; the dump holds no custom resources, so their number is unknown
+func (c *collector) addCRD(obj object) error {
+	var spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		// Version is the single version of apiextensions.k8s.io/v1beta1 CRDs
+		Version    string                 `json:"version"`
+		Versions   []inventory.CRDVersion `json:"versions"`
+		Scope      string                 `json:"scope"`
+		Conversion struct {
+			Strategy string `json:"strategy"`
+			Webhook  struct {
+				ClientConfig webhookClientConfig `json:"clientConfig"`
+			} `json:"webhook"`
+			// WebhookClientConfig is the webhook of apiextensions.k8s.io/v1beta1 CRDs
+			WebhookClientConfig webhookClientConfig `json:"webhookClientConfig"`
+		} `json:"conversion"`
+	}
+	var status struct {
+		StoredVersions []string `json:"storedVersions"`
+	}
+	if err := json.Unmarshal(obj.Spec, &spec); err != nil {
+		return fmt.Errorf("failed to decode CRD %s: %w", obj.Metadata.Name, err)
+	}
+	if len(obj.Status) > 0 {
+		if err := json.Unmarshal(obj.Status, &status); err != nil {
+			return fmt.Errorf("failed to decode CRD %s: %w", obj.Metadata.Name, err)
+		}
+	}
+
+	var versions []string
+	for _, version := range spec.Versions {
+		if version.Served {
+			versions = append(versions, version.Name)
+		}
+	}
+	if len(versions) == 0 && spec.Version != "" {
+		versions = []string{spec.Version}
+		spec.Versions = []inventory.CRDVersion{{Name: spec.Version, Served: true, Storage: true}}
+	}
+
+	var webhook string
+	if spec.Conversion.Strategy == "Webhook" {
+		webhook = spec.Conversion.Webhook.ClientConfig.String()
+		if webhook == "" {
+			webhook = spec.Conversion.WebhookClientConfig.String()
+		}
+	}
+
+	c.crds[obj.Metadata.Name] = inventory.ExportedCRD{
+		Name:               obj.Metadata.Name,
+		Group:              spec.Group,
+		Kind:               spec.Names.Kind,
+		Versions:           versions,
+		StoredVersions:     status.StoredVersions,
+		VersionDetails:     spec.Versions,
+		Scope:              spec.Scope,
+		ConversionWebhook:  webhook,
+		HelmOwnerName:      obj.Metadata.Annotations["meta.helm.sh/release-name"],
+		HelmOwnerNamespace: obj.Metadata.Annotations["meta.helm.sh/release-namespace"],
+	}
+	return nil
+}
+
+// webhookClientConfig is where a CRD's conversion webhook runs
+type webhookClientConfig struct {
+	URL     string `json:"url"`
+	Service *struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	} `json:"service"`
+}
+
+// String formats the webhook as its service, namespace/name, or its URL
+func (w webhookClientConfig) String() string {
+	if w.Service != nil {
+		return w.Service.Namespace + "/" + w.Service.Name
+	}
+	return w.URL
+}
+
+// helmRelease is the subset of a Helm release record read from its storage secret
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status        string     `json:"status"`
+		FirstDeployed *time.Time `json:"first_deployed"`
+		LastDeployed  *time.Time `json:"last_deployed"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string   `json:"name"`
+			Version    string   `json:"version"`
+			AppVersion string   `json:"appVersion"`
+			Home       string   `json:"home"`
+			Sources    []string `json:"sources"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Manifest string `json:"manifest"`
+}
+
+// chartURL is the first source URL of the chart, else its home page
+func (r helmRelease) chartURL() string {
+	if len(r.Chart.Metadata.Sources) > 0 && r.Chart.Metadata.Sources[0] != "" {
+		return r.Chart.Metadata.Sources[0]
+	}
+	return r.Chart.Metadata.Home
+}
+
+// addHelmSecret records the release stored in a Helm secret, whose data is base64-encoded once
+// more by the API server
+func (c *collector) addHelmSecret(obj object) error {
+	data, err := base64.StdEncoding.DecodeString(obj.Data["release"])
+	if err != nil {
+		return fmt.Errorf("failed to decode Helm secret %s/%s: %w", obj.Metadata.Namespace, obj.Metadata.Name, err)
+	}
+	if err := c.addRelease(string(data)); err != nil {
+		return fmt.Errorf("failed to decode Helm secret %s/%s: %w", obj.Metadata.Namespace, obj.Metadata.Name, err)
+	}
+	return nil
+}
+
+// addRelease records a Helm release record (base64-encoded, optionally gzipped JSON), keeping the
+// latest revision of each release
+func (c *collector) addRelease(data string) error {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		if b, err = io.ReadAll(gz); err != nil {
+			return err
+		}
+	}
+
+	var rel helmRelease
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return err
+	}
+	key := rel.Namespace + "/" + rel.Name
+	if latest, ok := c.releases[key]; !ok || rel.Version > latest.Version {
+		c.releases[key] = rel
+	}
+	return nil
+}
+
+// version returns the cluster version: the server version of 'kubectl version', else the version
+// of the kube-apiserver image, else the newest kubelet version
+func (c *collector) version() string {
+	if c.serverVersion != "" {
+		return c.serverVersion
+	}
+	if c.apiserverVersion != "" {
+		return c.apiserverVersion
+	}
+	version := ""
+	for _, kubelet := range c.kubeletVersions {
+		if newerVersion(kubelet, version) {
+			version = kubelet
+		}
+	}
+	return version
+}
+
+// newerVersion reports whether version is newer than current, which may be empty
+func newerVersion(version, current string) bool {
+	return current == "" || knowledge.CompareVersions(version, current) > 0
+}
+
+// export converts the collected documents into an inventory export
+func (c *collector) export(name string) (*inventory.Export, error) {
+	version := c.version()
+	if version == "" {
+		return nil, errors.New("dump has no cluster version: add 'kubectl version -o json' output or the nodes")
+	}
+
+	export := &inventory.Export{
+		ExportedAt: time.Now().UTC(),
+		Cluster: inventory.ExportedCluster{
+			Name:          name,
+			KubeVersion:   version,
+			NodePlatforms: inventory.NodePlatforms(c.nodes),
+			ScanErrors:    make(map[string]string),
+
+			NamespaceCriticality:  c.criticality,
+			TerminatingNamespaces: c.terminating,
+		},
+	}
+	if len(c.servedAPIs) > 0 {
+		export.Cluster.ServedAPIs = c.servedAPIs
+	} else {
+		export.Cluster.ScanErrors[inventory.ScanServedAPIs] = notInDump
+	}
+	if !c.nodesSeen {
+		export.Cluster.ScanErrors[inventory.ScanNodePlatforms] = notInDump
+	}
+	if !c.crdsSeen {
+		export.Cluster.ScanErrors[inventory.ScanCRDs] = notInDump
+	}
+	if !c.helmSeen && len(c.releases) == 0 {
+		export.Cluster.ScanErrors[inventory.ScanHelm] = notInDump
+	}
+
+	for _, key := range sortedKeys(c.releases) {
+		rel := c.releases[key]
+		// Uninstalled releases kept with --keep-history are gone from the cluster
+		if rel.Info.Status == "uninstalled" {
+			continue
+		}
+		export.HelmReleases = append(export.HelmReleases, inventory.ExportedHelmRelease{
+			Name:          rel.Name,
+			Namespace:     rel.Namespace,
+			Chart:         rel.Chart.Metadata.Name,
+			ChartVersion:  rel.Chart.Metadata.Version,
+			AppVersion:    rel.Chart.Metadata.AppVersion,
+			Status:        rel.Info.Status,
+			Revision:      rel.Version,
+			FirstDeployed: rel.Info.FirstDeployed,
+			LastDeployed:  rel.Info.LastDeployed,
+			ChartURL:      rel.chartURL(),
+			Source:        "cluster",
+			APIs:          inventory.ManifestAPIs(rel.Manifest),
+		})
+	}
+	for _, key := range sortedKeys(c.crds) {
+		export.CRDs = append(export.CRDs, c.crds[key])
+	}
+	for _, key := range sortedKeys(c.manifestAPIs) {
+		export.ManifestAPIs = append(export.ManifestAPIs, c.manifestAPIs[key])
+	}
+	export.Resources = c.resources
+	return export, nil
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
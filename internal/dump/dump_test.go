@@ -0,0 +1,130 @@
+package dump
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// load loads a dump, without the time of the export
+func load(t *testing.T, path string) *inventory.Export {
+	t.Helper()
+	export, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	export.ExportedAt = time.Time{}
+	return export
+}
+
+func TestLoad(t *testing.T) {
+	// cluster-info.txt is the stdout of 'kubectl cluster-info dump', collector a directory with the
+	// documents a collector adds
+	for _, name := range []string{"cluster-info.txt", "collector"} {
+		t.Run(name, func(t *testing.T) {
+			export := load(t, filepath.Join("testdata", name))
+			data, err := json.MarshalIndent(export, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			golden := filepath.Join("testdata", clusterName(name)+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("export differs from %s:\n%s", golden, data)
+			}
+		})
+	}
+}
+
+func TestLoadArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "acme.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	entries, err := os.ReadDir(filepath.Join("testdata", "collector"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata", "collector", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := &tar.Header{Name: "acme/" + entry.Name(), Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []interface{ Close() error }{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := load(t, archive)
+	if got.Cluster.Name != "acme" {
+		t.Errorf("cluster name %q, want acme", got.Cluster.Name)
+	}
+	want := load(t, filepath.Join("testdata", "collector"))
+	want.Cluster.Name = got.Cluster.Name
+	if !reflect.DeepEqual(got, want) {
+		t.Error("the archive of the collector directory loads differently from the directory")
+	}
+}
+
+func TestLoadWithoutVersion(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("testdata", "collector", "namespaces.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "namespaces.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load succeeded for a dump without a cluster version")
+	}
+}
+
+func TestClusterName(t *testing.T) {
+	tests := map[string]string{
+		"acme.tar.gz":           "acme",
+		"acme.tgz":              "acme",
+		"dumps/acme.json":       "acme",
+		"dumps/cluster-info/":   "cluster-info",
+		"cluster-info.txt":      "cluster-info",
+		"prod-eu.2024-01-10.gz": "prod-eu.2024-01-10",
+	}
+	for path, want := range tests {
+		if got := clusterName(path); got != want {
+			t.Errorf("clusterName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
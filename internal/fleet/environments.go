@@ -0,0 +1,116 @@
+package fleet
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment is a promotion stage of the fleet, e.g. dev, staging or prod
+type Environment struct {
+	Name     string   `yaml:"name"`
+	Clusters []string `yaml:"clusters"`
+	Soak     string   `yaml:"soak"` // time to run on the new version before promoting to the next environment, e.g. 48h
+	// UpgradeDate is the scheduled start of the environment's upgrade, YYYY-MM-DD; unscheduled when empty
+	UpgradeDate string `yaml:"upgradeDate"`
+	// Receiver is the Alertmanager receiver of the environment's alerts
+	Receiver string `yaml:"receiver"`
+}
+
+// Config maps clusters to environments in promotion order
+type Config struct {
+	Environments []Environment `yaml:"environments"`
+	Alerts       AlertConfig   `yaml:"alerts"`
+}
+
+// AlertConfig parameterizes the alerting rules generated for the fleet
+type AlertConfig struct {
+	// CriticalFindingsDays alerts on critical findings this many days before an environment's
+	// upgrade date (default 14)
+	CriticalFindingsDays int `yaml:"criticalFindingsDays"`
+	// EOLDays alerts this many days before a running version's end of life (default 30)
+	EOLDays int `yaml:"eolDays"`
+	// EOL overrides end of life dates per minor version, e.g. {"1.29": "2025-03-23"} for the
+	// support window of a managed offering
+	EOL map[string]string `yaml:"eol"`
+}
+
+// LoadConfig reads an environments file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environments: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal environments: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate checks that every environment is named, has clusters and a valid soak time, and that
+// no cluster is promoted twice
+func (c *Config) Validate() error {
+	if len(c.Environments) == 0 {
+		return fmt.Errorf("no environments defined")
+	}
+
+	seen := make(map[string]string)
+	for i, env := range c.Environments {
+		if env.Name == "" {
+			return fmt.Errorf("environment %d has no name", i+1)
+		}
+		if len(env.Clusters) == 0 {
+			return fmt.Errorf("environment %q has no clusters", env.Name)
+		}
+		if _, err := env.SoakDuration(); err != nil {
+			return fmt.Errorf("environment %q: %w", env.Name, err)
+		}
+		if _, _, err := env.ScheduledUpgrade(); err != nil {
+			return fmt.Errorf("environment %q: %w", env.Name, err)
+		}
+		for _, cluster := range env.Clusters {
+			if other, exists := seen[cluster]; exists {
+				return fmt.Errorf("cluster %q is in both %q and %q", cluster, other, env.Name)
+			}
+			seen[cluster] = env.Name
+		}
+	}
+
+	if c.Alerts.CriticalFindingsDays < 0 || c.Alerts.EOLDays < 0 {
+		return fmt.Errorf("alert lead times must not be negative")
+	}
+
+	return nil
+}
+
+// ScheduledUpgrade parses the upgrade date; ok is false when the environment is unscheduled
+func (e Environment) ScheduledUpgrade() (date time.Time, ok bool, err error) {
+	if e.UpgradeDate == "" {
+		return time.Time{}, false, nil
+	}
+	date, err = time.Parse("2006-01-02", e.UpgradeDate)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid upgrade date %q (want YYYY-MM-DD): %w", e.UpgradeDate, err)
+	}
+	return date, true, nil
+}
+
+// SoakDuration parses the soak time, zero when not set
+func (e Environment) SoakDuration() (time.Duration, error) {
+	if e.Soak == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(e.Soak)
+	if err != nil {
+		return 0, fmt.Errorf("invalid soak time %q: %w", e.Soak, err)
+	}
+	return duration, nil
+}
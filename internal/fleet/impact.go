@@ -0,0 +1,200 @@
+package fleet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// readinessPenalty is subtracted from a cluster's readiness score for each finding of a severity
+var readinessPenalty = map[analysis.ImpactLevel]int{
+	analysis.ImpactCritical: 25,
+	analysis.ImpactHigh:     10,
+	analysis.ImpactMedium:   3,
+	analysis.ImpactLow:      1,
+}
+
+// SortKeys are the supported orderings of the readiness ranking
+var SortKeys = []string{"readiness", "risk", "findings", "cluster"}
+
+// ClusterSummary is one cluster's row in the fleet report
+type ClusterSummary struct {
+	ClusterID      string               `json:"clusterId"`
+	CurrentVersion string               `json:"currentVersion,omitempty"`
+	OverallRisk    analysis.ImpactLevel `json:"overallRisk,omitempty"`
+	TotalFindings  int                  `json:"totalFindings"`
+	Critical       int                  `json:"critical"`
+	High           int                  `json:"high"`
+	Medium         int                  `json:"medium"`
+	Low            int                  `json:"low"`
+	Readiness      int                  `json:"readiness"` // 0-100, higher is closer to upgradable
+	Error          string               `json:"error,omitempty"`
+}
+
+// CommonBlocker is a finding shared by several clusters, e.g. the same outdated chart
+type CommonBlocker struct {
+	RuleID   string               `json:"ruleId"`
+	Subject  string               `json:"subject"`
+	Severity analysis.ImpactLevel `json:"severity"`
+	Clusters []string             `json:"clusters"`
+}
+
+// Report aggregates the assessments of a fleet
+type Report struct {
+	TargetVersion  string               `json:"targetVersion"`
+	Clusters       []ClusterSummary     `json:"clusters"`
+	CommonBlockers []CommonBlocker      `json:"commonBlockers"`
+	TotalFindings  int                  `json:"totalFindings"`
+	OverallRisk    analysis.ImpactLevel `json:"overallRisk"`
+}
+
+// BuildReport summarizes each cluster and collects findings shared by at least minClusters clusters.
+// Clusters are ranked by readiness.
+func BuildReport(targetVersion string, results map[string]ClusterResult, minClusters int) *Report {
+	report := &Report{
+		TargetVersion: targetVersion,
+		OverallRisk:   analysis.ImpactNone,
+	}
+
+	blockers := make(map[string]*CommonBlocker)
+	for clusterID, result := range results {
+		summary := ClusterSummary{ClusterID: clusterID, Error: result.Error}
+		if result.Assessment == nil {
+			report.Clusters = append(report.Clusters, summary)
+			continue
+		}
+
+		summary.CurrentVersion = result.Assessment.CurrentVersion
+		summary.OverallRisk = result.Assessment.OverallRisk
+		summary.Readiness = 100
+
+		seen := make(map[string]bool)
+		addBlocker := func(ruleID, subject string, severity analysis.ImpactLevel) {
+			key := ruleID + "|" + subject
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+
+			blocker, exists := blockers[key]
+			if !exists {
+				blocker = &CommonBlocker{RuleID: ruleID, Subject: subject, Severity: severity}
+				blockers[key] = blocker
+			}
+			if severity.AtLeast(blocker.Severity) {
+				blocker.Severity = severity
+			}
+			blocker.Clusters = append(blocker.Clusters, clusterID)
+		}
+
+		for _, finding := range result.Assessment.Findings() {
+			summary.TotalFindings++
+			switch finding.Severity {
+			case analysis.ImpactCritical:
+				summary.Critical++
+			case analysis.ImpactHigh:
+				summary.High++
+			case analysis.ImpactMedium:
+				summary.Medium++
+			case analysis.ImpactLow:
+				summary.Low++
+			}
+			summary.Readiness -= readinessPenalty[finding.Severity]
+
+			if finding.RuleID != analysis.RuleIncompatibleChart.ID {
+				addBlocker(finding.RuleID, blockerSubject(finding), finding.Severity)
+			}
+		}
+
+		// chart findings carry versions in their message, so group them by chart and minimum version
+		for _, chart := range result.Assessment.IncompatibleCharts {
+			subject := fmt.Sprintf("%s (no compatible version)", chart.ChartName)
+			if chart.RecommendedVersion != "" {
+				subject = fmt.Sprintf("%s < %s", chart.ChartName, chart.RecommendedVersion)
+			}
+			addBlocker(chart.RuleID, subject, chart.ImpactLevel)
+		}
+
+		if summary.Readiness < 0 {
+			summary.Readiness = 0
+		}
+		if summary.OverallRisk.AtLeast(report.OverallRisk) {
+			report.OverallRisk = summary.OverallRisk
+		}
+		report.TotalFindings += summary.TotalFindings
+		report.Clusters = append(report.Clusters, summary)
+	}
+
+	for _, blocker := range blockers {
+		if len(blocker.Clusters) < minClusters {
+			continue
+		}
+		sort.Strings(blocker.Clusters)
+		report.CommonBlockers = append(report.CommonBlockers, *blocker)
+	}
+	sort.Slice(report.CommonBlockers, func(i, j int) bool {
+		a, b := report.CommonBlockers[i], report.CommonBlockers[j]
+		if len(a.Clusters) != len(b.Clusters) {
+			return len(a.Clusters) > len(b.Clusters)
+		}
+		if a.Severity != b.Severity {
+			return a.Severity.AtLeast(b.Severity)
+		}
+		return a.Subject < b.Subject
+	})
+
+	report.Sort("readiness")
+	return report
+}
+
+// blockerSubject describes what a finding is about, so the same problem can be matched across
+// clusters together with its rule: API findings by their API independently of the affected object,
+// others by their resource, as their summaries may be worded per cluster. Incompatible charts are
+// grouped by chart and version instead.
+func blockerSubject(finding analysis.FindingRef) string {
+	if finding.Kind != "" {
+		gv := finding.Version
+		if finding.Group != "" {
+			gv = finding.Group + "/" + finding.Version
+		}
+		return fmt.Sprintf("%s %s", gv, finding.Kind)
+	}
+	if finding.Resource != "" {
+		return finding.Resource
+	}
+	return finding.Summary
+}
+
+// Sort orders the cluster ranking by readiness (most ready first), risk (riskiest first),
+// findings (most first) or cluster ID. Clusters that could not be assessed come last.
+func (r *Report) Sort(key string) error {
+	var less func(a, b ClusterSummary) bool
+	switch key {
+	case "readiness":
+		less = func(a, b ClusterSummary) bool { return a.Readiness > b.Readiness }
+	case "risk":
+		less = func(a, b ClusterSummary) bool { return !b.OverallRisk.AtLeast(a.OverallRisk) }
+	case "findings":
+		less = func(a, b ClusterSummary) bool { return a.TotalFindings > b.TotalFindings }
+	case "cluster":
+		less = func(a, b ClusterSummary) bool { return false }
+	default:
+		return fmt.Errorf("unsupported sort key %q (supported: readiness, risk, findings, cluster)", key)
+	}
+
+	sort.SliceStable(r.Clusters, func(i, j int) bool {
+		a, b := r.Clusters[i], r.Clusters[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.ClusterID < b.ClusterID
+	})
+	return nil
+}
@@ -0,0 +1,156 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// ClusterResult is the assessment and plan of one cluster
+type ClusterResult struct {
+	ClusterID  string
+	Assessment *analysis.ImpactAssessment
+	Plan       *planner.UpgradePlan
+	Error      string // set when the cluster could not be assessed
+}
+
+// ClusterPlan summarizes the upgrade of one cluster within a wave
+type ClusterPlan struct {
+	ClusterID      string               `json:"clusterId"`
+	CurrentVersion string               `json:"currentVersion,omitempty"`
+	OverallRisk    analysis.ImpactLevel `json:"overallRisk,omitempty"`
+	TotalIssues    int                  `json:"totalIssues"`
+	Duration       Duration             `json:"duration"`
+	Blocked        bool                 `json:"blocked"` // critical findings must be resolved first
+	Error          string               `json:"error,omitempty"`
+	Plan           *planner.UpgradePlan `json:"plan,omitempty"`
+}
+
+// Wave upgrades the clusters of one environment in parallel
+type Wave struct {
+	Number      int                  `json:"number"`
+	Environment string               `json:"environment"`
+	Clusters    []ClusterPlan        `json:"clusters"`
+	OverallRisk analysis.ImpactLevel `json:"overallRisk"`
+	Duration    Duration             `json:"duration"` // longest cluster upgrade in the wave
+	Soak        Duration             `json:"soak"`
+	StartOffset Duration             `json:"startOffset"` // earliest start relative to the first wave
+	Gate        string               `json:"gate,omitempty"`
+}
+
+// Plan sequences cluster upgrades across environments
+type Plan struct {
+	TargetVersion string               `json:"targetVersion"`
+	Waves         []Wave               `json:"waves"`
+	OverallRisk   analysis.ImpactLevel `json:"overallRisk"`
+	TotalClusters int                  `json:"totalClusters"`
+	Blocked       []string             `json:"blocked,omitempty"`
+	Duration      Duration             `json:"duration"` // including soak times between waves
+}
+
+// BuildPlan aggregates per-cluster results into a fleet plan with one wave per environment, in
+// promotion order. Each wave starts once the previous wave has been upgraded and soaked.
+func BuildPlan(config *Config, targetVersion string, results map[string]ClusterResult) *Plan {
+	plan := &Plan{
+		TargetVersion: targetVersion,
+		OverallRisk:   analysis.ImpactNone,
+	}
+
+	var offset time.Duration
+	for i, env := range config.Environments {
+		soak, _ := env.SoakDuration()
+		wave := Wave{
+			Number:      i + 1,
+			Environment: env.Name,
+			OverallRisk: analysis.ImpactNone,
+			Soak:        Duration(soak),
+			StartOffset: Duration(offset),
+		}
+
+		for _, clusterID := range env.Clusters {
+			clusterPlan := newClusterPlan(clusterID, results[clusterID])
+			if clusterPlan.OverallRisk.AtLeast(wave.OverallRisk) {
+				wave.OverallRisk = clusterPlan.OverallRisk
+			}
+			if clusterPlan.Duration > wave.Duration {
+				wave.Duration = clusterPlan.Duration
+			}
+			if clusterPlan.Blocked {
+				plan.Blocked = append(plan.Blocked, clusterID)
+			}
+			wave.Clusters = append(wave.Clusters, clusterPlan)
+		}
+
+		if i < len(config.Environments)-1 {
+			wave.Gate = fmt.Sprintf("Promote to %s once all %s clusters pass post-upgrade validation", config.Environments[i+1].Name, env.Name)
+			if soak > 0 {
+				wave.Gate += fmt.Sprintf(" and have run on %s for %s", targetVersion, Duration(soak))
+			}
+		}
+
+		if wave.OverallRisk.AtLeast(plan.OverallRisk) {
+			plan.OverallRisk = wave.OverallRisk
+		}
+		plan.TotalClusters += len(wave.Clusters)
+		plan.Waves = append(plan.Waves, wave)
+
+		offset += time.Duration(wave.Duration)
+		if i < len(config.Environments)-1 {
+			offset += soak
+		}
+	}
+	plan.Duration = Duration(offset)
+
+	return plan
+}
+
+// newClusterPlan summarizes a cluster result
+func newClusterPlan(clusterID string, result ClusterResult) ClusterPlan {
+	clusterPlan := ClusterPlan{ClusterID: clusterID, Error: result.Error}
+	if result.Assessment == nil {
+		if clusterPlan.Error == "" {
+			clusterPlan.Error = "no assessment"
+		}
+		clusterPlan.Blocked = true
+		return clusterPlan
+	}
+
+	clusterPlan.CurrentVersion = result.Assessment.CurrentVersion
+	clusterPlan.OverallRisk = result.Assessment.OverallRisk
+	clusterPlan.TotalIssues = result.Assessment.TotalIssues
+	clusterPlan.Blocked = result.Assessment.OverallRisk == analysis.ImpactCritical
+	if result.Plan != nil {
+		clusterPlan.Plan = result.Plan
+		clusterPlan.Duration = Duration(time.Duration(result.Plan.TotalSteps) * planner.StepDuration)
+	}
+	return clusterPlan
+}
+
+// Duration is a time span reported in days and hours, e.g. "2d 4h"
+type Duration time.Duration
+
+// String formats the duration in days and hours
+func (d Duration) String() string {
+	if d == 0 {
+		return "0h"
+	}
+	hours := int(time.Duration(d).Round(time.Hour) / time.Hour)
+	if hours < 1 {
+		return "<1h"
+	}
+	if hours < 24 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	if hours%24 == 0 {
+		return fmt.Sprintf("%dd", hours/24)
+	}
+	return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+}
+
+// MarshalJSON encodes the duration in its readable form
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
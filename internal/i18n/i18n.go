@@ -0,0 +1,95 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is used when no language is requested; its messages are the catalog keys
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Localizer translates report and plan messages. Catalogs map the English format string to its
+// translation; positional verbs such as %[2]s let translations reorder arguments.
+// A nil Localizer formats messages in English.
+type Localizer struct {
+	lang     string
+	messages map[string]string
+}
+
+// New creates a localizer for a language such as "de", "ja" or "de_DE.UTF-8"
+func New(lang string) (*Localizer, error) {
+	lang = normalize(lang)
+	if lang == DefaultLanguage {
+		return &Localizer{lang: lang, messages: map[string]string{}}, nil
+	}
+
+	data, err := locales.ReadFile(path.Join("locales", lang+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages(), ", "))
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s message catalog: %w", lang, err)
+	}
+
+	return &Localizer{lang: lang, messages: messages}, nil
+}
+
+// Languages lists the supported language codes
+func Languages() []string {
+	languages := []string{DefaultLanguage}
+	entries, _ := locales.ReadDir("locales")
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Lang returns the localizer's language code
+func (l *Localizer) Lang() string {
+	if l == nil {
+		return DefaultLanguage
+	}
+	return l.lang
+}
+
+// T translates and formats a message. Leading indentation and trailing newlines are not part of the
+// catalog key, so report layout stays in code; messages without a translation are used as-is.
+func (l *Localizer) T(format string, args ...interface{}) string {
+	if l != nil && len(l.messages) > 0 {
+		body := strings.TrimLeft(format, " \n")
+		indent := format[:len(format)-len(body)]
+		key := strings.TrimRight(body, "\n")
+		newlines := body[len(key):]
+
+		if translated, ok := l.messages[key]; ok && translated != "" {
+			format = indent + translated + newlines
+		}
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// normalize reduces a locale such as "de_DE.UTF-8" or "ja-JP" to its language code
+func normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "" || lang == "c" || lang == "posix" {
+		return DefaultLanguage
+	}
+	return lang
+}
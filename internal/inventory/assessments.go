@@ -0,0 +1,74 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	entassessment "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/assessment"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entplan "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/plan"
+)
+
+// SaveAssessment stores an impact assessment for a cluster and returns it with its ID
+func (s *Store) SaveAssessment(ctx context.Context, clusterID string, assessment AssessmentEntry) (*ent.Assessment, error) {
+	saved, err := s.client.Assessment.
+		Create().
+		SetTargetVersion(assessment.TargetVersion).
+		SetCurrentVersion(assessment.CurrentVersion).
+		SetOverallRisk(assessment.OverallRisk).
+		SetTotalIssues(assessment.TotalIssues).
+		SetLive(assessment.Live).
+		SetSnapshot(assessment.Snapshot).
+		SetClusterID(clusterID).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save assessment: %w", err)
+	}
+	return saved, nil
+}
+
+// GetAssessment retrieves an assessment by ID, including its cluster and plans (newest first)
+func (s *Store) GetAssessment(ctx context.Context, id int) (*ent.Assessment, error) {
+	return s.client.Assessment.
+		Query().
+		Where(entassessment.ID(id)).
+		WithCluster().
+		WithPlans(newestPlansFirst).
+		Only(ctx)
+}
+
+// LatestAssessment retrieves the most recent assessment of a cluster for a target version,
+// including its plans (newest first)
+func (s *Store) LatestAssessment(ctx context.Context, clusterID, targetVersion string) (*ent.Assessment, error) {
+	return s.client.Assessment.
+		Query().
+		Where(
+			entassessment.HasClusterWith(cluster.ID(clusterID)),
+			entassessment.TargetVersion(targetVersion),
+		).
+		WithCluster().
+		WithPlans(newestPlansFirst).
+		Order(ent.Desc(entassessment.FieldCreatedAt)).
+		First(ctx)
+}
+
+// ListAssessments lists assessments newest first, optionally filtered by cluster and target version
+func (s *Store) ListAssessments(ctx context.Context, clusterID, targetVersion string) ([]*ent.Assessment, error) {
+	query := s.client.Assessment.
+		Query().
+		WithCluster().
+		Order(ent.Desc(entassessment.FieldCreatedAt))
+	if clusterID != "" {
+		query = query.Where(entassessment.HasClusterWith(cluster.ID(clusterID)))
+	}
+	if targetVersion != "" {
+		query = query.Where(entassessment.TargetVersion(targetVersion))
+	}
+	return query.All(ctx)
+}
+
+// newestPlansFirst orders eager-loaded plans by creation time, newest first
+func newestPlansFirst(query *ent.PlanQuery) {
+	query.Order(ent.Desc(entplan.FieldCreatedAt))
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	entauditlog "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/auditlog"
+)
+
+// RecordAudit appends an action to the audit log
+func (s *Store) RecordAudit(ctx context.Context, entry AuditEntry) (*ent.AuditLog, error) {
+	origin := entry.Origin
+	if origin == "" {
+		origin = AuditOriginCLI
+	}
+	saved, err := s.client.AuditLog.
+		Create().
+		SetAction(entry.Action).
+		SetActor(entry.Actor).
+		SetOrigin(origin).
+		SetClusterID(entry.ClusterID).
+		SetParameters(entry.Parameters).
+		SetSucceeded(entry.Succeeded).
+		SetSummary(entry.Summary).
+		SetError(entry.Error).
+		SetStartedAt(entry.StartedAt).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record audit log entry: %w", err)
+	}
+	return saved, nil
+}
+
+// ListAuditLogs lists audit log entries newest first
+func (s *Store) ListAuditLogs(ctx context.Context, filter AuditFilter) ([]*ent.AuditLog, error) {
+	query := s.client.AuditLog.
+		Query().
+		Order(ent.Desc(entauditlog.FieldCreatedAt))
+	if filter.ClusterID != "" {
+		query = query.Where(entauditlog.ClusterID(filter.ClusterID))
+	}
+	if filter.Action != "" {
+		query = query.Where(entauditlog.Action(filter.Action))
+	}
+	if filter.Actor != "" {
+		query = query.Where(entauditlog.Actor(filter.Actor))
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where(entauditlog.CreatedAtGTE(filter.Since))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	return query.All(ctx)
+}
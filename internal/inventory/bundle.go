@@ -0,0 +1,60 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Bundle is an inventory export with an embedded signature over its exact bytes, as written by
+// kua-collector. Unlike an export and its .sig file, a bundle travels as a single file, e.g. the log
+// of a Job. Reformatting a bundle breaks its signature.
+type Bundle struct {
+	Inventory json.RawMessage `json:"inventory"`
+	// Signature is the base64-encoded signature of Inventory; empty when unsigned
+	Signature string `json:"signature,omitempty"`
+}
+
+// NewBundle encodes an export into an unsigned bundle
+func NewBundle(export *Export) (*Bundle, error) {
+	if export.Version == 0 {
+		export.Version = exportFormatVersion
+	}
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	return &Bundle{Inventory: data}, nil
+}
+
+// ReadBundle parses data as a bundle; ok is false when data is a plain export. Text after the
+// bundle, such as warnings in the same Job log, is ignored.
+func ReadBundle(data []byte) (*Bundle, bool) {
+	var bundle Bundle
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&bundle); err != nil || len(bundle.Inventory) == 0 {
+		return nil, false
+	}
+	return &bundle, true
+}
+
+// Export decodes the bundled inventory
+func (b *Bundle) Export() (*Export, error) {
+	var export Export
+	if err := json.Unmarshal(b.Inventory, &export); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inventory: %w", err)
+	}
+	return &export, nil
+}
+
+// Write writes the bundle as a single line of JSON
+func (b *Bundle) Write(w io.Writer) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	entdigest "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/digest"
+)
+
+// LastDigest returns the digest of the given name sent last; the error satisfies ent.IsNotFound
+// when none was sent yet
+func (s *Store) LastDigest(ctx context.Context, name string) (*ent.Digest, error) {
+	return s.client.Digest.
+		Query().
+		Where(entdigest.Name(name)).
+		Order(ent.Desc(entdigest.FieldSentAt)).
+		First(ctx)
+}
+
+// RecordDigest records a digest email that was sent
+func (s *Store) RecordDigest(ctx context.Context, entry DigestEntry) (*ent.Digest, error) {
+	saved, err := s.client.Digest.
+		Create().
+		SetName(entry.Name).
+		SetFindingKeys(entry.FindingKeys).
+		SetRecipients(entry.Recipients).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record digest: %w", err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,444 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entcrd "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/crd"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/helmrelease"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/manifestapi"
+	entresource "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/resource"
+)
+
+// exportFormatVersion is bumped when the export layout changes incompatibly
+const exportFormatVersion = 1
+
+// Export is a portable, database-independent copy of a cluster's inventory. It can be produced by
+// lightweight collectors and imported into a central database for analysis.
+type Export struct {
+	Version      int                   `json:"version"`
+	ExportedAt   time.Time             `json:"exportedAt"`
+	Cluster      ExportedCluster       `json:"cluster"`
+	HelmReleases []ExportedHelmRelease `json:"helmReleases"`
+	CRDs         []ExportedCRD         `json:"crds"`
+	ManifestAPIs []ExportedManifestAPI `json:"manifestApis"`
+	Resources    []ExportedResource    `json:"resources,omitempty"`
+}
+
+// ExportedCluster is the cluster record of an export
+type ExportedCluster struct {
+	ID                    string              `json:"id"`
+	Name                  string              `json:"name"`
+	KubeVersion           string              `json:"kubeVersion"`
+	Labels                map[string]string   `json:"labels,omitempty"`
+	ServedAPIs            map[string][]string `json:"servedApis,omitempty"`
+	NodePlatforms         []string            `json:"nodePlatforms,omitempty"`
+	IncompleteReason      string              `json:"incompleteReason,omitempty"`
+	ScanErrors            map[string]string   `json:"scanErrors,omitempty"`
+	NamespaceCriticality  map[string]string   `json:"namespaceCriticality,omitempty"`
+	TerminatingNamespaces []string            `json:"terminatingNamespaces,omitempty"`
+}
+
+// ExportedHelmRelease is a Helm release of an export
+type ExportedHelmRelease struct {
+	Name          string     `json:"name"`
+	Namespace     string     `json:"namespace"`
+	Chart         string     `json:"chart"`
+	ChartVersion  string     `json:"chartVersion"`
+	AppVersion    string     `json:"appVersion,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	Revision      int        `json:"revision,omitempty"`
+	FirstDeployed *time.Time `json:"firstDeployed,omitempty"`
+	LastDeployed  *time.Time `json:"lastDeployed,omitempty"`
+	ChartURL      string     `json:"chartURL,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	APIs          []string   `json:"apis,omitempty"` // of the objects in the release manifest
+}
+
+// NewExportedHelmRelease converts an inventory entry to a Helm release of an export
+func NewExportedHelmRelease(entry HelmReleaseEntry) ExportedHelmRelease {
+	source := entry.Source
+	if source == "" {
+		source = string(helmrelease.SourceCluster)
+	}
+	return ExportedHelmRelease{
+		Name:          entry.Name,
+		Namespace:     entry.Namespace,
+		Chart:         entry.Chart,
+		ChartVersion:  entry.ChartVersion,
+		AppVersion:    entry.AppVersion,
+		Status:        entry.Status,
+		Revision:      entry.Revision,
+		FirstDeployed: timePtr(entry.FirstDeployed),
+		LastDeployed:  timePtr(entry.LastDeployed),
+		ChartURL:      entry.ChartURL,
+		Source:        source,
+		APIs:          entry.APIs,
+	}
+}
+
+// ExportedCRD is a CRD of an export
+type ExportedCRD struct {
+	Name                   string       `json:"name"`
+	Group                  string       `json:"group"`
+	Kind                   string       `json:"kind"`
+	Versions               []string     `json:"versions,omitempty"`
+	StoredVersions         []string     `json:"storedVersions,omitempty"`
+	VersionDetails         []CRDVersion `json:"versionDetails,omitempty"`
+	Scope                  string       `json:"scope,omitempty"`
+	ConversionWebhook      string       `json:"conversionWebhook,omitempty"`
+	ConversionWebhookError string       `json:"conversionWebhookError,omitempty"`
+	InstanceCount          int          `json:"instanceCount"`
+	HelmOwnerName          string       `json:"helmOwnerName,omitempty"`
+	HelmOwnerNamespace     string       `json:"helmOwnerNamespace,omitempty"`
+}
+
+// ExportedManifestAPI is a manifest API of an export
+type ExportedManifestAPI struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Source    string `json:"source,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+	// Namespaces the manifests set on resources of the API
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ExportedResource is a live or manifest resource of an export
+type ExportedResource struct {
+	APIVersion      string                 `json:"apiVersion"`
+	Kind            string                 `json:"kind"`
+	Namespace       string                 `json:"namespace,omitempty"`
+	Name            string                 `json:"name,omitempty"`
+	Labels          map[string]string      `json:"labels,omitempty"`
+	Source          string                 `json:"source,omitempty"`
+	Templated       bool                   `json:"templated,omitempty"`
+	OwnerReferences []OwnerReference       `json:"ownerReferences,omitempty"`
+	File            string                 `json:"file,omitempty"`
+	Line            int                    `json:"line,omitempty"`
+	Spec            map[string]interface{} `json:"spec,omitempty"`
+}
+
+// NewExportedResource converts an inventory entry stored with source, e.g. "cluster", to a resource of
+// an export
+func NewExportedResource(entry ResourceEntry, source string) ExportedResource {
+	return ExportedResource{
+		APIVersion:      entry.APIVersion,
+		Kind:            entry.Kind,
+		Namespace:       entry.Namespace,
+		Name:            entry.Name,
+		Labels:          entry.Labels,
+		Source:          source,
+		Templated:       entry.Templated,
+		OwnerReferences: entry.Owners,
+		File:            entry.Source,
+		Line:            entry.Line,
+		Spec:            storedSpec(entry.Kind, entry.Spec),
+	}
+}
+
+// ExportInventory copies a cluster's inventory into a portable export
+func (s *Store) ExportInventory(ctx context.Context, clusterID string) (*Export, error) {
+	clusterEntity, err := s.GetCluster(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	helmReleases, err := clusterEntity.QueryHelmReleases().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query helm releases: %w", err)
+	}
+	crds, err := clusterEntity.QueryCrds().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CRDs: %w", err)
+	}
+	manifestAPIs, err := clusterEntity.QueryManifestApis().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query manifest APIs: %w", err)
+	}
+	resources, err := clusterEntity.QueryResources().All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resources: %w", err)
+	}
+
+	export := &Export{
+		Version:    exportFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Cluster: ExportedCluster{
+			ID:                    clusterEntity.ID,
+			Name:                  clusterEntity.Name,
+			KubeVersion:           clusterEntity.KubeVersion,
+			Labels:                clusterEntity.Labels,
+			ServedAPIs:            clusterEntity.ServedApis,
+			NodePlatforms:         clusterEntity.NodePlatforms,
+			IncompleteReason:      clusterEntity.IncompleteReason,
+			ScanErrors:            clusterEntity.ScanErrors,
+			NamespaceCriticality:  clusterEntity.NamespaceCriticality,
+			TerminatingNamespaces: clusterEntity.TerminatingNamespaces,
+		},
+		HelmReleases: make([]ExportedHelmRelease, len(helmReleases)),
+		CRDs:         make([]ExportedCRD, len(crds)),
+		ManifestAPIs: make([]ExportedManifestAPI, len(manifestAPIs)),
+	}
+
+	for i, hr := range helmReleases {
+		export.HelmReleases[i] = ExportedHelmRelease{
+			Name:          hr.Name,
+			Namespace:     hr.Namespace,
+			Chart:         hr.Chart,
+			ChartVersion:  hr.ChartVersion,
+			AppVersion:    hr.AppVersion,
+			Status:        hr.Status,
+			Revision:      hr.Revision,
+			FirstDeployed: hr.FirstDeployed,
+			LastDeployed:  hr.LastDeployed,
+			ChartURL:      hr.ChartURL,
+			Source:        string(hr.Source),
+			APIs:          hr.Apis,
+		}
+	}
+	for i, crd := range crds {
+		export.CRDs[i] = ExportedCRD{
+			Name:                   crd.Name,
+			Group:                  crd.Group,
+			Kind:                   crd.Kind,
+			Versions:               crd.Versions,
+			StoredVersions:         crd.StoredVersions,
+			VersionDetails:         crd.VersionDetails,
+			Scope:                  crd.Scope,
+			ConversionWebhook:      crd.ConversionWebhook,
+			ConversionWebhookError: crd.ConversionWebhookError,
+			InstanceCount:          crd.InstanceCount,
+			HelmOwnerName:          crd.HelmOwnerName,
+			HelmOwnerNamespace:     crd.HelmOwnerNamespace,
+		}
+	}
+	for i, api := range manifestAPIs {
+		export.ManifestAPIs[i] = ExportedManifestAPI{
+			Group:      api.Group,
+			Version:    api.Version,
+			Kind:       api.Kind,
+			Source:     string(api.Source),
+			Templated:  api.Templated,
+			Namespaces: api.Namespaces,
+		}
+	}
+	for _, resource := range resources {
+		export.Resources = append(export.Resources, NewExportedResource(resourceEntry(resource), string(resource.Source)))
+	}
+
+	return export, nil
+}
+
+// ImportInventory replaces a cluster's inventory with an export in a single transaction. The
+// cluster is created if it doesn't exist; clusterID overrides the exported ID when not empty.
+func (s *Store) ImportInventory(ctx context.Context, export *Export, clusterID string) (*ent.Cluster, error) {
+	if export.Version > exportFormatVersion {
+		return nil, fmt.Errorf("export version %d is newer than supported version %d", export.Version, exportFormatVersion)
+	}
+	if clusterID == "" {
+		clusterID = export.Cluster.ID
+	}
+	if clusterID == "" {
+		return nil, fmt.Errorf("export has no cluster ID")
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	clusterEntity, err := importInventory(ctx, tx, export, clusterID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return clusterEntity, nil
+}
+
+// importInventory writes an export within a transaction
+func importInventory(ctx context.Context, tx *ent.Tx, export *Export, clusterID string) (*ent.Cluster, error) {
+	clusterEntity, err := tx.Cluster.Get(ctx, clusterID)
+	if err == nil {
+		clusterEntity, err = clusterEntity.Update().
+			SetName(export.Cluster.Name).
+			SetKubeVersion(export.Cluster.KubeVersion).
+			SetLabels(export.Cluster.Labels).
+			SetServedApis(export.Cluster.ServedAPIs).
+			SetNodePlatforms(export.Cluster.NodePlatforms).
+			SetIncompleteReason(export.Cluster.IncompleteReason).
+			SetScanErrors(export.Cluster.ScanErrors).
+			SetNamespaceCriticality(export.Cluster.NamespaceCriticality).
+			SetTerminatingNamespaces(export.Cluster.TerminatingNamespaces).
+			Save(ctx)
+	} else {
+		clusterEntity, err = tx.Cluster.
+			Create().
+			SetID(clusterID).
+			SetName(export.Cluster.Name).
+			SetKubeVersion(export.Cluster.KubeVersion).
+			SetLabels(export.Cluster.Labels).
+			SetServedApis(export.Cluster.ServedAPIs).
+			SetNodePlatforms(export.Cluster.NodePlatforms).
+			SetIncompleteReason(export.Cluster.IncompleteReason).
+			SetScanErrors(export.Cluster.ScanErrors).
+			SetNamespaceCriticality(export.Cluster.NamespaceCriticality).
+			SetTerminatingNamespaces(export.Cluster.TerminatingNamespaces).
+			Save(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save cluster: %w", err)
+	}
+
+	// Replace existing inventory data
+	if _, err := tx.HelmRelease.Delete().Where(helmrelease.HasClusterWith(cluster.ID(clusterID))).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete helm releases: %w", err)
+	}
+	if _, err := tx.CRD.Delete().Where(entcrd.HasClusterWith(cluster.ID(clusterID))).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete CRDs: %w", err)
+	}
+	if _, err := tx.ManifestAPI.Delete().Where(manifestapi.HasClusterWith(cluster.ID(clusterID))).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete manifest APIs: %w", err)
+	}
+	if _, err := tx.Resource.Delete().Where(entresource.HasClusterWith(cluster.ID(clusterID))).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete resources: %w", err)
+	}
+
+	for _, hr := range export.HelmReleases {
+		source := helmrelease.SourceCluster
+		if hr.Source != "" {
+			source = helmrelease.Source(hr.Source)
+		}
+		if err := helmrelease.SourceValidator(source); err != nil {
+			return nil, fmt.Errorf("helm release %s/%s: %w", hr.Namespace, hr.Name, err)
+		}
+
+		_, err := tx.HelmRelease.
+			Create().
+			SetName(hr.Name).
+			SetNamespace(hr.Namespace).
+			SetChart(hr.Chart).
+			SetChartVersion(hr.ChartVersion).
+			SetAppVersion(hr.AppVersion).
+			SetStatus(hr.Status).
+			SetRevision(hr.Revision).
+			SetNillableFirstDeployed(hr.FirstDeployed).
+			SetNillableLastDeployed(hr.LastDeployed).
+			SetChartURL(hr.ChartURL).
+			SetApis(hr.APIs).
+			SetSource(source).
+			SetClusterID(clusterID).
+			Save(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import helm release %s/%s: %w", hr.Namespace, hr.Name, err)
+		}
+	}
+
+	for _, crd := range export.CRDs {
+		_, err := tx.CRD.
+			Create().
+			SetName(crd.Name).
+			SetGroup(crd.Group).
+			SetKind(crd.Kind).
+			SetVersions(crd.Versions).
+			SetStoredVersions(crd.StoredVersions).
+			SetVersionDetails(crd.VersionDetails).
+			SetScope(crd.Scope).
+			SetConversionWebhook(crd.ConversionWebhook).
+			SetConversionWebhookError(crd.ConversionWebhookError).
+			SetInstanceCount(crd.InstanceCount).
+			SetHelmOwnerName(crd.HelmOwnerName).
+			SetHelmOwnerNamespace(crd.HelmOwnerNamespace).
+			SetClusterID(clusterID).
+			Save(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import CRD %s: %w", crd.Name, err)
+		}
+	}
+
+	for _, api := range export.ManifestAPIs {
+		source := manifestapi.SourceLocal
+		if api.Source != "" {
+			source = manifestapi.Source(api.Source)
+		}
+		if err := manifestapi.SourceValidator(source); err != nil {
+			return nil, fmt.Errorf("manifest API %s/%s %s: %w", api.Group, api.Version, api.Kind, err)
+		}
+
+		_, err := tx.ManifestAPI.
+			Create().
+			SetGroup(api.Group).
+			SetVersion(api.Version).
+			SetKind(api.Kind).
+			SetSource(source).
+			SetTemplated(api.Templated).
+			SetNamespaces(api.Namespaces).
+			SetClusterID(clusterID).
+			Save(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import manifest API %s/%s %s: %w", api.Group, api.Version, api.Kind, err)
+		}
+	}
+
+	for _, resource := range export.Resources {
+		source := entresource.SourceCluster
+		if resource.Source != "" {
+			source = entresource.Source(resource.Source)
+		}
+		if err := entresource.SourceValidator(source); err != nil {
+			return nil, fmt.Errorf("resource %s %s/%s: %w", resource.Kind, resource.Namespace, resource.Name, err)
+		}
+		group, version := splitAPIVersion(resource.APIVersion)
+
+		_, err := tx.Resource.
+			Create().
+			SetGroup(group).
+			SetVersion(version).
+			SetKind(resource.Kind).
+			SetNamespace(resource.Namespace).
+			SetName(resource.Name).
+			SetLabels(resource.Labels).
+			SetSource(source).
+			SetTemplated(resource.Templated).
+			SetOwnerReferences(resource.OwnerReferences).
+			SetSpec(storedSpec(resource.Kind, resource.Spec)).
+			SetFile(resource.File).
+			SetLine(resource.Line).
+			SetClusterID(clusterID).
+			Save(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import resource %s %s/%s: %w", resource.Kind, resource.Namespace, resource.Name, err)
+		}
+	}
+
+	return clusterEntity, nil
+}
+
+// Write writes the export as indented JSON
+func (e *Export) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+	return nil
+}
+
+// ReadExport reads an inventory export
+func ReadExport(r io.Reader) (*Export, error) {
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inventory: %w", err)
+	}
+	return &export, nil
+}
@@ -0,0 +1,138 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// Selector filters clusters by label, in the kubectl equality-based syntax: "env=prod,region!=us,team"
+type Selector []labelRequirement
+
+// labelRequirement is a single term of a selector
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool // != for a value, or the key must be absent (!key)
+	exists bool // only the key must be present
+}
+
+// ParseSelector parses a comma-separated label selector; an empty string matches every cluster
+func ParseSelector(value string) (Selector, error) {
+	var selector Selector
+	for _, term := range strings.Split(value, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			requirement = labelRequirement{key: parts[0], value: parts[1], negate: true}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(strings.Replace(term, "==", "=", 1), "=", 2)
+			requirement = labelRequirement{key: parts[0], value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			requirement = labelRequirement{key: term[1:], negate: true, exists: true}
+		default:
+			requirement = labelRequirement{key: term, exists: true}
+		}
+
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if requirement.key == "" {
+			return nil, fmt.Errorf("invalid selector term %q", term)
+		}
+		selector = append(selector, requirement)
+	}
+	return selector, nil
+}
+
+// Matches reports whether labels satisfy every term of the selector
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, found := labels[requirement.key]
+		switch {
+		case requirement.exists && found == requirement.negate:
+			return false
+		case !requirement.exists && requirement.negate && found && value == requirement.value:
+			return false
+		case !requirement.exists && !requirement.negate && (!found || value != requirement.value):
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabels parses key=value pairs, e.g. from --label flags
+func ParseLabels(pairs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// FormatLabels formats labels as sorted key=value pairs
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// SetClusterLabels merges labels into a cluster's labels; an empty value removes the label
+func (s *Store) SetClusterLabels(ctx context.Context, clusterID string, labels map[string]string) (*ent.Cluster, error) {
+	existing, err := s.client.Cluster.Get(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	merged := make(map[string]string, len(existing.Labels)+len(labels))
+	for key, value := range existing.Labels {
+		merged[key] = value
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+
+	updated, err := existing.Update().
+		SetLabels(merged).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update cluster labels: %w", err)
+	}
+	return updated, nil
+}
+
+// ListClustersMatching lists the clusters whose labels match a selector
+func (s *Store) ListClustersMatching(ctx context.Context, selector Selector) ([]*ent.Cluster, error) {
+	clusters, err := s.ListClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*ent.Cluster
+	for _, cluster := range clusters {
+		if selector.Matches(cluster.Labels) {
+			matching = append(matching, cluster)
+		}
+	}
+	return matching, nil
+}
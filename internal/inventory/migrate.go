@@ -0,0 +1,429 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+)
+
+// Errors opening a database whose schema version doesn't match the binary's
+var (
+	ErrSchemaTooNew      = errors.New("database schema is newer than this binary supports")
+	ErrMigrationRequired = errors.New("database schema must be migrated")
+)
+
+// ErrReadOnly is returned for writes to a store opened read-only
+var ErrReadOnly = errors.New("database is opened read-only")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFS holds the SQL of the migrations, in a migrations directory
+var migrationFS fs.FS = migrationFiles
+
+// Migration is a version of the database schema
+type Migration struct {
+	Version     int
+	Description string
+	file        string // SQL script in migrations/
+}
+
+// Migrations are the schema versions in order. Whenever the ent schema changes, append one with a
+// script of the DDL from the previous version to the new one, plus any data it has to move; a
+// released script is never edited, so every database at a version has the same schema.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "clusters, Helm releases, CRDs, manifest APIs, resources, plans, assessments and audit log",
+		file:        "0001_inventory.sql",
+	},
+	{
+		Version:     2,
+		Description: "tickets created from findings",
+		file:        "0002_tickets.sql",
+	},
+	{
+		Version:     3,
+		Description: "digest emails sent by the server",
+		file:        "0003_digests.sql",
+	},
+	{
+		Version:     4,
+		Description: "APIs of the objects in Helm release manifests",
+		file:        "0004_release_apis.sql",
+	},
+	{
+		Version:     5,
+		Description: "specs of stored HorizontalPodAutoscalers",
+		file:        "0005_resource_specs.sql",
+	},
+}
+
+// script returns the SQL of a migration
+func (m Migration) script() (string, error) {
+	data, err := fs.ReadFile(migrationFS, path.Join("migrations", m.file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration %d: %w", m.Version, err)
+	}
+	return string(data), nil
+}
+
+// SchemaVersion returns the newest schema version the binary understands
+func SchemaVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// AppliedMigration is a migration recorded in the database
+type AppliedMigration struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+// MigrationStatus is the schema version of a database against the binary's
+type MigrationStatus struct {
+	Current int  // newest version applied, 0 for databases created before versioning
+	Latest  int  // newest version the binary understands
+	Empty   bool // no tables yet, so migrating alters nothing
+	Applied []AppliedMigration
+	Pending []Migration
+}
+
+// Migrator applies versioned schema migrations to a database
+type Migrator struct {
+	client *ent.Client
+	db     *sql.DB
+}
+
+// NewMigrator opens a database for migrating and maintenance, whatever its schema version
+func NewMigrator(dbPath string) (*Migrator, error) {
+	return openMigrator(dbPath, false)
+}
+
+// memoryDatabases numbers the in-memory databases opened for the path ":memory:"
+var memoryDatabases atomic.Int64
+
+// openMigrator opens a database, with SQLite refusing writes when readOnly. The path ":memory:"
+// opens a new in-memory database, shared by the connections of the migrator but no one else.
+func openMigrator(dbPath string, readOnly bool) (*Migrator, error) {
+	name, params := dbPath, "cache=shared&_fk=1"
+	if dbPath == ":memory:" {
+		// a named database, as a shared cache of file::memory: would be one for the whole process
+		name = fmt.Sprintf("memory-%d", memoryDatabases.Add(1))
+		params = "mode=memory&" + params
+	} else if readOnly {
+		params = "mode=ro&" + params
+	}
+	dsn := fmt.Sprintf("file:%s?%s", name, params)
+	client, err := ent.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening connection to sqlite: %w", err)
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed opening connection to sqlite: %w", err)
+	}
+	return &Migrator{client: client, db: db}, nil
+}
+
+// ensureTable creates the table recording applied migrations
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// Status returns the applied and pending migrations of the database. It doesn't write, so it also
+// works on databases opened read-only. A database with tables but no schema_migrations was
+// created before versioning, by the ent auto-migration of an older binary, and is at version 0.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	var tables, versioned int
+	err := m.db.QueryRowContext(ctx, `SELECT
+		COUNT(*) FILTER (WHERE name != 'schema_migrations'),
+		COUNT(*) FILTER (WHERE name = 'schema_migrations')
+		FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`).Scan(&tables, &versioned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	status := &MigrationStatus{Latest: SchemaVersion(), Empty: tables == 0}
+	if versioned == 0 {
+		status.Pending = Migrations
+		return status, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, description, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var applied AppliedMigration
+		if err := rows.Scan(&applied.Version, &applied.Description, &applied.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		status.Applied = append(status.Applied, applied)
+		status.Current = max(status.Current, applied.Version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	for _, migration := range Migrations {
+		if migration.Version > status.Current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Migrate applies the pending migrations in order and returns those applied. Each migration runs
+// in a transaction with its schema_migrations record, so one that fails leaves the database at the
+// previous version. A database created before versioning is first brought to version 1 by
+// adoptUnversioned. A database migrated by a newer binary is refused with ErrSchemaTooNew.
+func (m *Migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Current > status.Latest {
+		return nil, fmt.Errorf("%w: version %d, this binary supports up to %d", ErrSchemaTooNew, status.Current, status.Latest)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0, len(status.Pending))
+	for _, migration := range status.Pending {
+		apply := m.apply
+		if migration.Version == 1 && status.Current == 0 && !status.Empty {
+			apply = m.adoptUnversioned
+		}
+		if err := m.inTx(ctx, migration, apply); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// inTx applies a migration and records it in one transaction
+func (m *Migrator) inTx(ctx context.Context, migration Migration, apply func(ctx context.Context, tx *sql.Tx, migration Migration) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if err := apply(ctx, tx, migration); err != nil {
+		return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		migration.Version, migration.Description, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// apply runs the script of a migration
+func (m *Migrator) apply(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	script, err := migration.script()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, script)
+	return err
+}
+
+// adoptUnversioned brings a database created before versioning to version 1. Depending on the
+// binary that created it, it lacks some of the tables, columns and indexes of version 1; they are
+// found by comparing it with version 1 applied to an empty in-memory database, and added. Nothing
+// is dropped or altered.
+func (m *Migrator) adoptUnversioned(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	script, err := migration.script()
+	if err != nil {
+		return err
+	}
+	reference, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return fmt.Errorf("failed to open reference database: %w", err)
+	}
+	defer reference.Close()
+	reference.SetMaxOpenConns(1) // every connection to :memory: is a database of its own
+	if _, err := reference.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	want, err := schemaObjects(ctx, reference)
+	if err != nil {
+		return err
+	}
+	existing, err := schemaObjects(ctx, tx)
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for _, object := range existing {
+		have[object.name] = true
+	}
+
+	for _, object := range want {
+		if object.kind != "table" {
+			continue
+		}
+		if !have[object.name] {
+			if _, err := tx.ExecContext(ctx, object.sql); err != nil {
+				return fmt.Errorf("failed to create table %s: %w", object.name, err)
+			}
+			continue
+		}
+		if err := addMissingColumns(ctx, reference, tx, object.name); err != nil {
+			return err
+		}
+	}
+	for _, object := range want {
+		if object.kind == "index" && !have[object.name] {
+			if _, err := tx.ExecContext(ctx, object.sql); err != nil {
+				return fmt.Errorf("failed to create index %s: %w", object.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// querier is a database or a transaction
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// schemaObject is a table or index of a database
+type schemaObject struct {
+	kind, name, sql string
+}
+
+// schemaObjects returns the tables and indexes of a database in the order they were created
+func schemaObjects(ctx context.Context, db querier) ([]schemaObject, error) {
+	rows, err := db.QueryContext(ctx, `SELECT type, name, sql FROM sqlite_master
+		WHERE type IN ('table', 'index') AND sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []schemaObject
+	for rows.Next() {
+		var object schemaObject
+		if err := rows.Scan(&object.kind, &object.name, &object.sql); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+// column is a column of a table, as PRAGMA table_info describes it
+type column struct {
+	name, typ  string
+	notNull    bool
+	defaultSQL sql.NullString
+}
+
+// tableColumns returns the columns of a table in order
+func tableColumns(ctx context.Context, db querier, table string) ([]column, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%q)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []column
+	for rows.Next() {
+		var c column
+		var cid, pk int
+		if err := rows.Scan(&cid, &c.name, &c.typ, &c.notNull, &c.defaultSQL, &pk); err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %w", table, err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// addMissingColumns adds the columns of a reference table the database's table lacks. SQLite only
+// adds NOT NULL columns with a default, so those without one default to the zero value of their
+// type, which is what ent reads for a column it never wrote.
+func addMissingColumns(ctx context.Context, reference querier, tx *sql.Tx, table string) error {
+	want, err := tableColumns(ctx, reference, table)
+	if err != nil {
+		return err
+	}
+	have, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, c := range have {
+		existing[c.name] = true
+	}
+
+	for _, c := range want {
+		if existing[c.name] {
+			continue
+		}
+		definition := fmt.Sprintf("%q %s", c.name, c.typ)
+		switch {
+		case c.defaultSQL.Valid:
+			definition += " DEFAULT " + c.defaultSQL.String
+			if c.notNull {
+				definition += " NOT NULL"
+			}
+		case c.notNull:
+			definition += " NOT NULL DEFAULT " + zeroValue(c.typ)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %q ADD COLUMN %s", table, definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", table, c.name, err)
+		}
+	}
+	return nil
+}
+
+// zeroValue returns the SQL literal of the zero value of a column type
+func zeroValue(typ string) string {
+	switch strings.ToLower(typ) {
+	case "integer", "bool", "real":
+		return "0"
+	case "datetime":
+		return "'0001-01-01 00:00:00+00:00'"
+	default:
+		return "''"
+	}
+}
+
+// Close closes the database
+func (m *Migrator) Close() error {
+	dbErr := m.db.Close()
+	if err := m.client.Close(); err != nil {
+		return err
+	}
+	return dbErr
+}
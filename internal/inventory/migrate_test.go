@@ -0,0 +1,202 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openDB opens a new database file for a test
+func openDB(t *testing.T) (string, *sql.DB) {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?_fk=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return dbPath, db
+}
+
+// migrate migrates a database and returns its status afterwards
+func migrate(t *testing.T, dbPath string) ([]Migration, *MigrationStatus, error) {
+	t.Helper()
+	migrator, err := NewMigrator(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+
+	applied, migrateErr := migrator.Migrate(context.Background())
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return applied, status, migrateErr
+}
+
+// hasObject reports whether a database has a table or index
+func hasObject(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, name).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count > 0
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	dbPath, _ := openDB(t)
+
+	applied, status, err := migrate(t, dbPath)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != len(Migrations) || status.Current != SchemaVersion() || len(status.Pending) != 0 {
+		t.Fatalf("applied %d migrations, now at version %d with %d pending", len(applied), status.Current, len(status.Pending))
+	}
+
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if _, err := store.SaveCluster(ctx, "prod", "prod", "v1.28.3"); err != nil {
+		t.Fatalf("SaveCluster: %v", err)
+	}
+	if _, err := store.SaveHelmRelease(ctx, "prod", HelmReleaseEntry{Name: "ingress", Namespace: "ingress-nginx", Chart: "ingress-nginx", ChartVersion: "4.7.1"}); err != nil {
+		t.Fatalf("SaveHelmRelease: %v", err)
+	}
+}
+
+func TestNewStoreInMemory(t *testing.T) {
+	ctx := context.Background()
+	var stores []*Store
+	for i := 0; i < 2; i++ {
+		store, err := NewStore(":memory:")
+		if err != nil {
+			t.Fatalf("NewStore: %v", err)
+		}
+		defer store.Close()
+		stores = append(stores, store)
+	}
+	if _, err := stores[0].SaveCluster(ctx, "prod", "prod", "v1.28.3"); err != nil {
+		t.Fatalf("SaveCluster: %v", err)
+	}
+
+	clusters, err := stores[1].ListClusters(ctx)
+	if err != nil {
+		t.Fatalf("ListClusters: %v", err)
+	}
+	if len(clusters) != 0 {
+		t.Errorf("an in-memory store has the %d clusters of another", len(clusters))
+	}
+}
+
+func TestMigrateUnversionedDatabase(t *testing.T) {
+	dbPath, db := openDB(t)
+	// An early database: clusters without the columns added since, and no other table
+	_, err := db.Exec(`CREATE TABLE clusters (
+		id text NOT NULL, name text NOT NULL, kube_version text NOT NULL, labels json NULL,
+		created_at datetime NOT NULL, updated_at datetime NOT NULL, PRIMARY KEY (id)
+	);
+	INSERT INTO clusters (id, name, kube_version, created_at, updated_at)
+		VALUES ('prod', 'prod', 'v1.27.4', '2024-03-01 12:00:00+00:00', '2024-03-01 12:00:00+00:00')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, status, err := migrate(t, dbPath)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != len(Migrations) || status.Current != SchemaVersion() {
+		t.Fatalf("applied %d migrations, now at version %d", len(applied), status.Current)
+	}
+	for _, name := range []string{"helm_releases", "resources", "resource_source_file_cluster_resources", "tickets", "digests"} {
+		if !hasObject(t, db, name) {
+			t.Errorf("%s was not created", name)
+		}
+	}
+
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	cluster, err := store.GetCluster(ctx, "prod")
+	if err != nil {
+		t.Fatalf("GetCluster: %v", err)
+	}
+	if cluster.KubeVersion != "v1.27.4" {
+		t.Errorf("kube version = %s, want the one stored before migrating", cluster.KubeVersion)
+	}
+	if err := store.SetResourceVersions(ctx, "prod", map[string]string{"apps/v1/deployments": "42"}); err != nil {
+		t.Errorf("SetResourceVersions on an added column: %v", err)
+	}
+}
+
+func TestMigrateNewerDatabase(t *testing.T) {
+	dbPath, db := openDB(t)
+	if _, _, err := migrate(t, dbPath); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	newer := SchemaVersion() + 1
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, 'from a newer binary', CURRENT_TIMESTAMP)`, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, status, err := migrate(t, dbPath)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("Migrate error = %v, want ErrSchemaTooNew", err)
+	}
+	if len(applied) != 0 || status.Current != newer {
+		t.Errorf("applied %d migrations, now at version %d", len(applied), status.Current)
+	}
+	if _, err := NewStore(dbPath); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("NewStore error = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestMigratePartialFailure(t *testing.T) {
+	scripts := fstest.MapFS{}
+	for _, migration := range Migrations {
+		data, err := fs.ReadFile(migrationFiles, "migrations/"+migration.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scripts["migrations/"+migration.file] = &fstest.MapFile{Data: data}
+	}
+	// The first statement succeeds and the second fails, as clusters exists
+	scripts["migrations/9999_broken.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE partial (id integer);
+CREATE TABLE clusters (id text);`)}
+	broken := Migration{Version: SchemaVersion() + 1, Description: "broken", file: "9999_broken.sql"}
+
+	defaultMigrations, defaultFS := Migrations, migrationFS
+	Migrations = append(append([]Migration{}, Migrations...), broken)
+	migrationFS = scripts
+	defer func() { Migrations, migrationFS = defaultMigrations, defaultFS }()
+
+	dbPath, db := openDB(t)
+	applied, status, err := migrate(t, dbPath)
+	if err == nil {
+		t.Fatal("Migrate succeeded with a broken migration")
+	}
+	if len(applied) != len(defaultMigrations) || status.Current != broken.Version-1 {
+		t.Errorf("applied %d migrations, now at version %d, want the ones before the broken one", len(applied), status.Current)
+	}
+	if len(status.Pending) != 1 || status.Pending[0].Version != broken.Version {
+		t.Errorf("pending %v, want the broken migration", status.Pending)
+	}
+	if hasObject(t, db, "partial") {
+		t.Error("the broken migration was partly applied")
+	}
+}
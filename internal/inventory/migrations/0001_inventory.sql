@@ -0,0 +1,144 @@
+-- Clusters and what was collected from them, upgrade plans and assessments, and the audit log.
+-- Column names, types and foreign keys follow the ent schema in internal/db/ent/schema, which
+-- databases created before schema versioning were migrated to automatically.
+
+CREATE TABLE IF NOT EXISTS clusters (
+	id text NOT NULL,
+	name text NOT NULL,
+	kube_version text NOT NULL,
+	labels json NULL,
+	served_apis json NULL,
+	node_platforms json NULL,
+	namespace_criticality json NULL,
+	terminating_namespaces json NULL,
+	incomplete_reason text NULL,
+	scan_errors json NULL,
+	resource_versions json NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS helm_releases (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	namespace text NOT NULL,
+	chart text NOT NULL,
+	chart_version text NOT NULL,
+	app_version text NULL,
+	source text NOT NULL DEFAULT 'cluster',
+	status text NULL,
+	revision integer NOT NULL DEFAULT 0,
+	first_deployed datetime NULL,
+	last_deployed datetime NULL,
+	chart_url text NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	cluster_helm_releases text NOT NULL,
+	CONSTRAINT helm_releases_clusters_helm_releases FOREIGN KEY (cluster_helm_releases) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS cr_ds (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	"group" text NOT NULL DEFAULT '',
+	kind text NOT NULL DEFAULT '',
+	versions json NULL,
+	stored_versions json NULL,
+	version_details json NULL,
+	scope text NULL,
+	conversion_webhook text NULL,
+	conversion_webhook_error text NULL,
+	instance_count integer NOT NULL DEFAULT 0,
+	helm_owner_name text NULL,
+	helm_owner_namespace text NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	cluster_crds text NOT NULL,
+	CONSTRAINT cr_ds_clusters_crds FOREIGN KEY (cluster_crds) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS manifest_ap_is (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"group" text NOT NULL DEFAULT '',
+	version text NOT NULL,
+	kind text NOT NULL,
+	source text NOT NULL DEFAULT 'local',
+	templated bool NOT NULL DEFAULT false,
+	namespaces json NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	cluster_manifest_apis text NOT NULL,
+	CONSTRAINT manifest_ap_is_clusters_manifest_apis FOREIGN KEY (cluster_manifest_apis) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS resources (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"group" text NOT NULL DEFAULT '',
+	version text NOT NULL,
+	kind text NOT NULL,
+	namespace text NOT NULL DEFAULT '',
+	name text NOT NULL DEFAULT '',
+	labels json NULL,
+	source text NOT NULL DEFAULT 'cluster',
+	templated bool NOT NULL DEFAULT false,
+	owner_references json NULL,
+	file text NULL,
+	line integer NOT NULL DEFAULT 0,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	cluster_resources text NOT NULL,
+	CONSTRAINT resources_clusters_resources FOREIGN KEY (cluster_resources) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS resource_group_version_kind_cluster_resources ON resources ("group", version, kind, cluster_resources);
+CREATE INDEX IF NOT EXISTS resource_namespace_name_cluster_resources ON resources (namespace, name, cluster_resources);
+CREATE INDEX IF NOT EXISTS resource_source_file_cluster_resources ON resources (source, file, cluster_resources);
+
+CREATE TABLE IF NOT EXISTS assessments (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	target_version text NOT NULL,
+	current_version text NOT NULL DEFAULT '',
+	overall_risk text NOT NULL DEFAULT '',
+	total_issues integer NOT NULL DEFAULT 0,
+	live bool NOT NULL DEFAULT false,
+	snapshot text NOT NULL,
+	created_at datetime NOT NULL,
+	cluster_assessments text NOT NULL,
+	CONSTRAINT assessments_clusters_assessments FOREIGN KEY (cluster_assessments) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS plans (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	target_version text NOT NULL,
+	from_version text NOT NULL DEFAULT '',
+	skip_backup bool NOT NULL DEFAULT false,
+	include_rollback bool NOT NULL DEFAULT false,
+	total_steps integer NOT NULL DEFAULT 0,
+	timeline text NOT NULL DEFAULT '',
+	document text NOT NULL,
+	state text NOT NULL DEFAULT 'draft',
+	approved_by text NOT NULL DEFAULT '',
+	approved_at datetime NULL,
+	executed_by text NOT NULL DEFAULT '',
+	executed_at datetime NULL,
+	created_at datetime NOT NULL,
+	assessment_plans integer NULL,
+	cluster_plans text NOT NULL,
+	CONSTRAINT plans_assessments_plans FOREIGN KEY (assessment_plans) REFERENCES assessments (id) ON DELETE SET NULL,
+	CONSTRAINT plans_clusters_plans FOREIGN KEY (cluster_plans) REFERENCES clusters (id) ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	action text NOT NULL,
+	actor text NOT NULL,
+	origin text NOT NULL DEFAULT 'cli',
+	cluster_id text NOT NULL DEFAULT '',
+	parameters json NULL,
+	succeeded bool NOT NULL,
+	summary text NOT NULL DEFAULT '',
+	error text NOT NULL DEFAULT '',
+	started_at datetime NOT NULL,
+	created_at datetime NOT NULL
+);
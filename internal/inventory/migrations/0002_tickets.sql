@@ -0,0 +1,17 @@
+-- Issues filed for findings by tickets create, so running it again updates them instead of filing
+-- duplicates.
+
+CREATE TABLE IF NOT EXISTS tickets (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	sink text NOT NULL,
+	project text NOT NULL,
+	idempotency_key text NOT NULL,
+	external_key text NOT NULL,
+	cluster_id text NOT NULL DEFAULT '',
+	title text NOT NULL DEFAULT '',
+	finding_count integer NOT NULL DEFAULT 0,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_sink_project_idempotency_key ON tickets (sink, project, idempotency_key);
@@ -0,0 +1,12 @@
+-- Digest emails the server sent, with the findings each covered, so the next digest only reports
+-- what is new.
+
+CREATE TABLE IF NOT EXISTS digests (
+	id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	finding_keys json NULL,
+	recipients integer NOT NULL DEFAULT 0,
+	sent_at datetime NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS digest_name_sent_at ON digests (name, sent_at);
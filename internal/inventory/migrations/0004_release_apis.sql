@@ -0,0 +1,4 @@
+-- The APIs of the objects in each Helm release's manifest, to find the releases using an API.
+-- Releases stored before get theirs on the next scan.
+
+ALTER TABLE helm_releases ADD COLUMN apis json NULL;
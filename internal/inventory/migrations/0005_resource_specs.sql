@@ -0,0 +1,4 @@
+-- The spec of resources whose checks read it from the inventory, e.g. HorizontalPodAutoscalers.
+-- Resources stored before get theirs on the next scan.
+
+ALTER TABLE resources ADD COLUMN spec json NULL;
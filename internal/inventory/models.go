@@ -1,9 +1,36 @@
 package inventory
 
 import (
+	"sort"
 	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/schema"
+)
+
+// Scan subsystems whose failures are recorded with the inventory instead of aborting the scan
+const (
+	ScanServedAPIs    = "served-apis"
+	ScanNodePlatforms = "node-platforms"
+	ScanNamespaces    = "namespaces"
+	ScanCRDs          = "crds"
+	ScanHelm          = "helm"
+	ScanResources     = "resources"
 )
 
+// CriticalityKeys are the namespace labels and annotations declaring its criticality, e.g.
+// criticality=production; the first one set wins
+var CriticalityKeys = []string{"kube-upgrade-advisor.io/criticality", "criticality"}
+
+// ScanErrorSubsystems returns the failed subsystems of a scan in a stable order
+func ScanErrorSubsystems(scanErrors map[string]string) []string {
+	subsystems := make([]string, 0, len(scanErrors))
+	for subsystem := range scanErrors {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	return subsystems
+}
+
 // ClusterInventory represents the complete inventory of a cluster
 type ClusterInventory struct {
 	ClusterVersion string
@@ -21,16 +48,27 @@ type ResourceEntry struct {
 	Name        string
 	Labels      map[string]string
 	Annotations map[string]string
+	Templated   bool   // parsed from an un-rendered template
+	Source      string // file or location the resource was parsed from, if known
+	Line        int    // line of the resource's document in Source, 0 if unknown
+	Spec        map[string]interface{}
+	Owners      []OwnerReference
 }
 
 // HelmReleaseEntry represents a Helm release in inventory
 type HelmReleaseEntry struct {
-	Name         string
-	Namespace    string
-	Chart        string
-	ChartVersion string
-	AppVersion   string
-	Status       string
+	Name          string
+	Namespace     string
+	Chart         string
+	ChartVersion  string
+	AppVersion    string
+	Status        string
+	Revision      int
+	FirstDeployed time.Time // zero when unknown, e.g. for Terraform releases
+	LastDeployed  time.Time
+	ChartURL      string   // repository or source URL of the chart, when resolvable
+	Source        string   // "cluster" (default) or "terraform"
+	APIs          []string // APIs of the objects in the release manifest, e.g. "apps/v1/Deployment"
 }
 
 // CRDEntry represents a CRD in inventory
@@ -42,9 +80,252 @@ type CRDEntry struct {
 	InstanceCount int
 }
 
+// CRDVersion is a version of a CRD with whether it is served, the storage version or deprecated
+type CRDVersion = schema.CRDVersion
+
+// OwnerReference is an owner of a resource, e.g. the ReplicaSet of a Pod
+type OwnerReference = schema.OwnerReference
+
+// ResourceSourceCluster is the source of live objects; resources parsed from manifests take the
+// source of their manifest APIs, e.g. "git"
+const ResourceSourceCluster = "cluster"
+
+// ResourceFilter selects stored resources; empty fields match everything. Groups match any of
+// several groups, e.g. those a kind moved between. Files match the Source of manifest resources,
+// e.g. to replace the resources of the files parsed again.
+type ResourceFilter struct {
+	Source    string
+	Group     string
+	Groups    []string
+	Kind      string
+	Namespace string
+	Files     []string
+}
+
 // InventorySnapshot represents a point-in-time snapshot
 type InventorySnapshot struct {
 	ID        string
 	Timestamp time.Time
 	Inventory ClusterInventory
 }
+
+// PlanEntry represents a generated upgrade plan to persist
+type PlanEntry struct {
+	TargetVersion   string
+	FromVersion     string
+	SkipBackup      bool
+	IncludeRollback bool
+	TotalSteps      int
+	Timeline        string
+	Document        string // JSON-encoded plan
+	AssessmentID    int    // assessment the plan was generated from, 0 if none
+}
+
+// TicketEntry represents an issue created in a ticketing system to persist
+type TicketEntry struct {
+	Sink           string
+	Project        string
+	IdempotencyKey string
+	ExternalKey    string // key of the issue in the sink
+	ClusterID      string
+	Title          string
+	FindingCount   int
+}
+
+// DigestEntry represents a digest email sent to persist
+type DigestEntry struct {
+	Name        string
+	FindingKeys map[string][]string // keys of the reported findings by cluster ID
+	Recipients  int
+}
+
+// Actions recorded in the audit log
+const (
+	AuditScan    = "scan"
+	AuditImpact  = "impact"
+	AuditPlan    = "plan"
+	AuditFix     = "fix"
+	AuditApprove = "approve"
+	AuditExecute = "execute"
+)
+
+// Origins of audit log entries
+const (
+	AuditOriginCLI    = "cli"
+	AuditOriginServer = "server"
+)
+
+// AuditEntry represents an action of the advisor to record in the audit log
+type AuditEntry struct {
+	Action     string
+	Actor      string
+	Origin     string
+	ClusterID  string
+	Parameters map[string]string
+	Succeeded  bool
+	Summary    string
+	Error      string
+	StartedAt  time.Time
+}
+
+// AuditFilter selects audit log entries; zero fields match everything
+type AuditFilter struct {
+	ClusterID string
+	Action    string
+	Actor     string
+	Since     time.Time
+	Limit     int
+}
+
+// AssessmentEntry represents an impact assessment to persist
+type AssessmentEntry struct {
+	TargetVersion  string
+	CurrentVersion string
+	OverallRisk    string
+	TotalIssues    int
+	Live           bool   // live cluster checks were included
+	Snapshot       string // JSON-encoded assessment
+}
+
+// LiveClusterState holds workload-level state collected directly from a live cluster
+type LiveClusterState struct {
+	Nodes                  []NodeEntry
+	Pods                   []PodEntry
+	PodDisruptionBudgets   []PodDisruptionBudgetEntry
+	Workloads              []WorkloadEntry
+	StorageClasses         []StorageClassEntry
+	PersistentVolumes      []PersistentVolumeEntry
+	PersistentVolumeClaims []PersistentVolumeClaimEntry
+	CSIDrivers             []string
+	Ingresses              []IngressEntry
+	NodeConfigs            []NodeConfigEntry
+	RuntimeClasses         []RuntimeClassEntry
+}
+
+// NodeEntry represents a cluster node
+type NodeEntry struct {
+	Name              string
+	Labels            map[string]string
+	Ready             bool
+	Unschedulable     bool
+	AllocatableCPU    int64 // millicores
+	AllocatableMemory int64 // bytes
+	OS                string
+	Arch              string
+	KubeletVersion    string
+	// ExtendedResources holds allocatable device plugin resources, e.g. nvidia.com/gpu
+	ExtendedResources map[string]int64
+}
+
+// Platform returns the node's os/arch, e.g. "linux/arm64"
+func (n NodeEntry) Platform() string {
+	return n.OS + "/" + n.Arch
+}
+
+// NodePlatforms lists the distinct platforms of a set of nodes in sorted order
+func NodePlatforms(nodes []NodeEntry) []string {
+	seen := make(map[string]bool)
+	var platforms []string
+	for _, node := range nodes {
+		if node.OS == "" || node.Arch == "" || seen[node.Platform()] {
+			continue
+		}
+		seen[node.Platform()] = true
+		platforms = append(platforms, node.Platform())
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// PodEntry represents a pod scheduled on a node
+type PodEntry struct {
+	Name              string
+	Namespace         string
+	NodeName          string
+	Phase             string
+	OwnerKind         string
+	OwnerName         string
+	IsMirror          bool
+	HasLocalStorage   bool
+	DisruptionBudgets []string // namespace/name of PDBs selecting this pod
+	RequestedCPU      int64    // millicores
+	RequestedMemory   int64    // bytes
+	// Topology keys of DoNotSchedule spread constraints and required pod anti-affinity terms
+	HardSpreadKeys       []string
+	RequiredAntiAffinity []string
+}
+
+// PodDisruptionBudgetEntry represents a PodDisruptionBudget
+type PodDisruptionBudgetEntry struct {
+	Name               string
+	Namespace          string
+	DisruptionsAllowed int32
+}
+
+// WorkloadEntry represents a workload controller (Deployment, StatefulSet, DaemonSet, CronJob)
+type WorkloadEntry struct {
+	Kind                 string
+	Name                 string
+	Namespace            string
+	Replicas             int32
+	Suspended            bool
+	Images               []string
+	Labels               map[string]string
+	VolumeClaimTemplates []VolumeClaimTemplateEntry // StatefulSets only
+	Spec                 map[string]interface{}     // the spec, for checks of fields whose behavior changes
+}
+
+// VolumeClaimTemplateEntry represents a StatefulSet volumeClaimTemplate
+type VolumeClaimTemplateEntry struct {
+	Name         string
+	StorageClass string
+	Annotations  map[string]string
+}
+
+// StorageClassEntry represents a StorageClass
+type StorageClassEntry struct {
+	Name        string
+	Provisioner string
+	IsDefault   bool
+	Annotations map[string]string
+}
+
+// PersistentVolumeEntry represents a PersistentVolume
+type PersistentVolumeEntry struct {
+	Name         string
+	StorageClass string
+	ClaimRef     string // namespace/name of the bound claim
+	InTreePlugin string // provisioner name of the in-tree volume source, if any
+	CSIDriver    string
+}
+
+// PersistentVolumeClaimEntry represents a PersistentVolumeClaim
+type PersistentVolumeClaimEntry struct {
+	Name         string
+	Namespace    string
+	StorageClass string
+	VolumeName   string
+	Annotations  map[string]string
+}
+
+// NodeConfigEntry represents the configuration of a node component, e.g. a kubelet's
+// KubeletConfiguration read from its configz endpoint
+type NodeConfigEntry struct {
+	Component string // "kubelet" or "kube-proxy"
+	Source    string // where the configuration was read, e.g. "node worker-1"
+	Config    map[string]interface{}
+}
+
+// RuntimeClassEntry represents a RuntimeClass and the container runtime handler it selects
+type RuntimeClassEntry struct {
+	Name    string
+	Handler string
+}
+
+// IngressEntry represents an Ingress and its annotations
+type IngressEntry struct {
+	Name        string
+	Namespace   string
+	Class       string
+	Annotations map[string]string
+}
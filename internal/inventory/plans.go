@@ -0,0 +1,115 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entplan "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/plan"
+)
+
+// ErrPlanState is returned when a plan can't make a state transition, e.g. when approving a plan
+// that was already approved or executing an unapproved one while approval is required
+var ErrPlanState = errors.New("invalid plan state")
+
+// SavePlan stores a generated upgrade plan for a cluster and returns it with its ID
+func (s *Store) SavePlan(ctx context.Context, clusterID string, plan PlanEntry) (*ent.Plan, error) {
+	create := s.client.Plan.
+		Create().
+		SetTargetVersion(plan.TargetVersion).
+		SetFromVersion(plan.FromVersion).
+		SetSkipBackup(plan.SkipBackup).
+		SetIncludeRollback(plan.IncludeRollback).
+		SetTotalSteps(plan.TotalSteps).
+		SetTimeline(plan.Timeline).
+		SetDocument(plan.Document).
+		SetClusterID(clusterID)
+	if plan.AssessmentID != 0 {
+		create = create.SetAssessmentID(plan.AssessmentID)
+	}
+
+	saved, err := create.Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save plan: %w", err)
+	}
+	return saved, nil
+}
+
+// GetPlan retrieves a plan by ID, including its cluster
+func (s *Store) GetPlan(ctx context.Context, id int) (*ent.Plan, error) {
+	return s.client.Plan.
+		Query().
+		Where(entplan.ID(id)).
+		WithCluster().
+		Only(ctx)
+}
+
+// ListPlans lists plans newest first, optionally only those of one cluster
+func (s *Store) ListPlans(ctx context.Context, clusterID string) ([]*ent.Plan, error) {
+	query := s.client.Plan.
+		Query().
+		WithCluster().
+		Order(ent.Desc(entplan.FieldCreatedAt))
+	if clusterID != "" {
+		query = query.Where(entplan.HasClusterWith(cluster.ID(clusterID)))
+	}
+	return query.All(ctx)
+}
+
+// DeletePlan deletes a plan
+func (s *Store) DeletePlan(ctx context.Context, id int) error {
+	return s.client.Plan.DeleteOneID(id).Exec(ctx)
+}
+
+// ApprovePlan records a reviewer's approval of a draft plan
+func (s *Store) ApprovePlan(ctx context.Context, id int, reviewer string) (*ent.Plan, error) {
+	updated, err := s.client.Plan.
+		Update().
+		Where(entplan.ID(id), entplan.StateEQ(entplan.StateDraft)).
+		SetState(entplan.StateApproved).
+		SetApprovedBy(reviewer).
+		SetApprovedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve plan: %w", err)
+	}
+	if updated == 0 {
+		return nil, s.planStateError(ctx, id, "approved")
+	}
+	return s.GetPlan(ctx, id)
+}
+
+// ExecutePlan records that a plan was executed. Draft plans may only be executed when approval
+// isn't required, and a plan is executed at most once.
+func (s *Store) ExecutePlan(ctx context.Context, id int, executor string, requireApproval bool) (*ent.Plan, error) {
+	from := []entplan.State{entplan.StateApproved}
+	if !requireApproval {
+		from = append(from, entplan.StateDraft)
+	}
+	updated, err := s.client.Plan.
+		Update().
+		Where(entplan.ID(id), entplan.StateIn(from...)).
+		SetState(entplan.StateExecuted).
+		SetExecutedBy(executor).
+		SetExecutedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute plan: %w", err)
+	}
+	if updated == 0 {
+		return nil, s.planStateError(ctx, id, "executed")
+	}
+	return s.GetPlan(ctx, id)
+}
+
+// planStateError explains why a plan wasn't updated: it doesn't exist, or its state forbids it
+func (s *Store) planStateError(ctx context.Context, id int, transition string) error {
+	stored, err := s.GetPlan(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: plan %d is %s and can't be %s", ErrPlanState, id, stored.State, transition)
+}
@@ -0,0 +1,204 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/predicate"
+	entresource "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/resource"
+)
+
+// resourceBatchSize is the number of resources created per statement, well below SQLite's limit
+// of bound variables
+const resourceBatchSize = 500
+
+// specKinds are the kinds stored with their spec, for the checks reading it from the inventory;
+// other specs are dropped to keep the inventory small
+var specKinds = []string{"HorizontalPodAutoscaler"}
+
+// storedSpec returns the spec of a resource of a kind to store, nil for kinds not in specKinds
+func storedSpec(kind string, spec map[string]interface{}) map[string]interface{} {
+	if !slices.Contains(specKinds, kind) {
+		return nil
+	}
+	return spec
+}
+
+// resourcePredicates selects the cluster's resources matching filter
+func resourcePredicates(clusterID string, filter ResourceFilter) []predicate.Resource {
+	predicates := []predicate.Resource{entresource.HasClusterWith(cluster.ID(clusterID))}
+	if filter.Source != "" {
+		predicates = append(predicates, entresource.SourceEQ(entresource.Source(filter.Source)))
+	}
+	if filter.Group != "" {
+		predicates = append(predicates, entresource.Group(filter.Group))
+	}
+	if len(filter.Groups) > 0 {
+		predicates = append(predicates, entresource.GroupIn(filter.Groups...))
+	}
+	if filter.Kind != "" {
+		predicates = append(predicates, entresource.Kind(filter.Kind))
+	}
+	if filter.Namespace != "" {
+		predicates = append(predicates, entresource.Namespace(filter.Namespace))
+	}
+	if len(filter.Files) > 0 {
+		predicates = append(predicates, entresource.FileIn(filter.Files...))
+	}
+	return predicates
+}
+
+// splitAPIVersion splits an apiVersion into its group, empty for the core group, and version
+func splitAPIVersion(apiVersion string) (group, version string) {
+	if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+		return group, version
+	}
+	return "", apiVersion
+}
+
+// ReplaceResources deletes the cluster's resources matching filter and stores resources in a single
+// transaction, with filter.Source as their source. Resources without an apiVersion or kind are skipped.
+func (s *Store) ReplaceResources(ctx context.Context, clusterID string, filter ResourceFilter, resources []ResourceEntry) error {
+	source := entresource.Source(filter.Source)
+	if err := entresource.SourceValidator(source); err != nil {
+		return fmt.Errorf("invalid resource source %q: %w", filter.Source, err)
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	if _, err := tx.Resource.Delete().Where(resourcePredicates(clusterID, filter)...).Exec(ctx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete resources: %w", err)
+	}
+
+	builders := make([]*ent.ResourceCreate, 0, resourceBatchSize)
+	flush := func() error {
+		if len(builders) == 0 {
+			return nil
+		}
+		if _, err := tx.Resource.CreateBulk(builders...).Save(ctx); err != nil {
+			return fmt.Errorf("failed to save resources: %w", err)
+		}
+		builders = builders[:0]
+		return nil
+	}
+	for _, resource := range resources {
+		group, version := splitAPIVersion(resource.APIVersion)
+		if version == "" || resource.Kind == "" {
+			continue
+		}
+		builders = append(builders, tx.Resource.
+			Create().
+			SetGroup(group).
+			SetVersion(version).
+			SetKind(resource.Kind).
+			SetNamespace(resource.Namespace).
+			SetName(resource.Name).
+			SetLabels(resource.Labels).
+			SetSource(source).
+			SetTemplated(resource.Templated).
+			SetOwnerReferences(resource.Owners).
+			SetSpec(storedSpec(resource.Kind, resource.Spec)).
+			SetFile(resource.Source).
+			SetLine(resource.Line).
+			SetClusterID(clusterID))
+		if len(builders) == resourceBatchSize {
+			if err := flush(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit resources: %w", err)
+	}
+	return nil
+}
+
+// DeleteResources deletes the cluster's resources matching filter
+func (s *Store) DeleteResources(ctx context.Context, clusterID string, filter ResourceFilter) error {
+	_, err := s.client.Resource.
+		Delete().
+		Where(resourcePredicates(clusterID, filter)...).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete resources: %w", err)
+	}
+	return nil
+}
+
+// ListResources lists the cluster's resources matching filter, ordered by kind, namespace and name
+func (s *Store) ListResources(ctx context.Context, clusterID string, filter ResourceFilter) ([]ResourceEntry, error) {
+	resources, err := s.client.Resource.
+		Query().
+		Where(resourcePredicates(clusterID, filter)...).
+		Order(ent.Asc(entresource.FieldKind, entresource.FieldNamespace, entresource.FieldName)).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resources: %w", err)
+	}
+
+	entries := make([]ResourceEntry, len(resources))
+	for i, resource := range resources {
+		entries[i] = resourceEntry(resource)
+	}
+	return entries, nil
+}
+
+// CountResources counts the cluster's resources matching filter
+func (s *Store) CountResources(ctx context.Context, clusterID string, filter ResourceFilter) (int, error) {
+	count, err := s.client.Resource.
+		Query().
+		Where(resourcePredicates(clusterID, filter)...).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count resources: %w", err)
+	}
+	return count, nil
+}
+
+// ResourceFiles lists the distinct files of the cluster's resources matching filter, e.g. to find
+// the files of a source parsed before
+func (s *Store) ResourceFiles(ctx context.Context, clusterID string, filter ResourceFilter) ([]string, error) {
+	files, err := s.client.Resource.
+		Query().
+		Where(resourcePredicates(clusterID, filter)...).
+		Unique(true).
+		Select(entresource.FieldFile).
+		Strings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resource files: %w", err)
+	}
+	return files, nil
+}
+
+// resourceEntry converts a stored resource to an inventory entry
+func resourceEntry(resource *ent.Resource) ResourceEntry {
+	apiVersion := resource.Version
+	if resource.Group != "" {
+		apiVersion = resource.Group + "/" + resource.Version
+	}
+	return ResourceEntry{
+		APIVersion: apiVersion,
+		Kind:       resource.Kind,
+		Namespace:  resource.Namespace,
+		Name:       resource.Name,
+		Labels:     resource.Labels,
+		Templated:  resource.Templated,
+		Source:     resource.File,
+		Line:       resource.Line,
+		Spec:       resource.Spec,
+		Owners:     resource.OwnerReferences,
+	}
+}
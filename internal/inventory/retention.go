@@ -0,0 +1,141 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	entassessment "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/assessment"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entplan "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/plan"
+)
+
+// RetentionPolicy bounds the assessments and draft plans kept; zero fields keep everything.
+// Assessments that approved or executed plans were generated from are always kept as evidence,
+// as is the audit log.
+type RetentionPolicy struct {
+	MaxAssessments int           // per cluster, newest first
+	MaxAge         time.Duration // of assessments and draft plans
+}
+
+// Enabled reports whether the policy deletes anything
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAssessments > 0 || p.MaxAge > 0
+}
+
+// PruneResult counts the records a prune deleted
+type PruneResult struct {
+	Assessments int
+	Plans       int
+}
+
+// Prune deletes the assessments and draft plans beyond the retention policy in a single
+// transaction. Draft plans generated from a deleted assessment are deleted with it.
+func (s *Store) Prune(ctx context.Context, policy RetentionPolicy) (*PruneResult, error) {
+	result := &PruneResult{}
+	if !policy.Enabled() {
+		return result, nil
+	}
+
+	// Assessments approved or executed plans were generated from
+	evidence := entassessment.HasPlansWith(entplan.StateIn(entplan.StateApproved, entplan.StateExecuted))
+
+	var expired []int
+	if policy.MaxAssessments > 0 {
+		clusters, err := s.client.Cluster.Query().IDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		for _, clusterID := range clusters {
+			ids, err := s.client.Assessment.
+				Query().
+				Where(entassessment.HasClusterWith(cluster.ID(clusterID))).
+				Order(ent.Desc(entassessment.FieldCreatedAt)).
+				Offset(policy.MaxAssessments).
+				IDs(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query assessments of %s: %w", clusterID, err)
+			}
+			expired = append(expired, ids...)
+		}
+	}
+	if policy.MaxAge > 0 {
+		ids, err := s.client.Assessment.
+			Query().
+			Where(entassessment.CreatedAtLT(time.Now().Add(-policy.MaxAge))).
+			IDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query assessments: %w", err)
+		}
+		expired = append(expired, ids...)
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	if len(expired) > 0 {
+		ids, err := tx.Assessment.
+			Query().
+			Where(entassessment.IDIn(expired...), entassessment.Not(evidence)).
+			IDs(ctx)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to query assessments: %w", err)
+		}
+		if len(ids) > 0 {
+			plans, err := tx.Plan.
+				Delete().
+				Where(entplan.StateEQ(entplan.StateDraft), entplan.HasAssessmentWith(entassessment.IDIn(ids...))).
+				Exec(ctx)
+			if err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to delete plans: %w", err)
+			}
+			result.Plans += plans
+
+			if result.Assessments, err = tx.Assessment.Delete().Where(entassessment.IDIn(ids...)).Exec(ctx); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to delete assessments: %w", err)
+			}
+		}
+	}
+	if policy.MaxAge > 0 {
+		plans, err := tx.Plan.
+			Delete().
+			Where(entplan.StateEQ(entplan.StateDraft), entplan.CreatedAtLT(time.Now().Add(-policy.MaxAge))).
+			Exec(ctx)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to delete plans: %w", err)
+		}
+		result.Plans += plans
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prune: %w", err)
+	}
+	return result, nil
+}
+
+// Backup writes a consistent copy of the database to path while it stays in use, whatever its
+// schema version, e.g. before migrating it. The copy is compacted like by Vacuum; path must not
+// exist yet.
+func (m *Migrator) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup file %s already exists", path)
+	}
+	if _, err := m.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file, returning the space of deleted records to the file system
+func (m *Migrator) Vacuum(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
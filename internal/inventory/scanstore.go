@@ -0,0 +1,173 @@
+package inventory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/helmrelease"
+)
+
+// ScanStore receives the inventory of a live cluster as it is scanned: Store persists it, and
+// ExportBuilder keeps it in memory for binaries without a database
+type ScanStore interface {
+	SaveCluster(ctx context.Context, id, name, kubeVersion string) (*ent.Cluster, error)
+	SetServedAPIs(ctx context.Context, clusterID string, served map[string][]string) error
+	SetNodePlatforms(ctx context.Context, clusterID string, platforms []string) error
+	SetNamespaces(ctx context.Context, clusterID string, criticality map[string]string, terminating []string) error
+	SetResourceVersions(ctx context.Context, clusterID string, versions map[string]string) error
+	SaveScannedCRD(ctx context.Context, clusterID string, crd ExportedCRD) (*ent.CRD, error)
+	DeleteCRDs(ctx context.Context, clusterID string, names []string) error
+	PruneCRDs(ctx context.Context, clusterID string, keep []string) error
+	ReplaceResources(ctx context.Context, clusterID string, filter ResourceFilter, resources []ResourceEntry) error
+	SaveHelmRelease(ctx context.Context, clusterID string, release HelmReleaseEntry) (*ent.HelmRelease, error)
+	ReplaceHelmReleases(ctx context.Context, clusterID, namespace string, releases []HelmReleaseEntry) error
+}
+
+var (
+	_ ScanStore = (*Store)(nil)
+	_ ScanStore = (*ExportBuilder)(nil)
+)
+
+// ExportBuilder collects the scan of a single cluster into an Export. It has no watermarks, so
+// scans into it are always full.
+type ExportBuilder struct {
+	export Export
+}
+
+// NewExportBuilder creates a builder for the export of a cluster; its ID, name and labels are
+// kept as given, the rest is filled in by the scan
+func NewExportBuilder(cluster ExportedCluster) *ExportBuilder {
+	return &ExportBuilder{export: Export{
+		Version:    exportFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Cluster:    cluster,
+	}}
+}
+
+// Export returns the inventory collected so far
+func (b *ExportBuilder) Export() *Export {
+	export := b.export
+	return &export
+}
+
+// SaveCluster records the cluster version
+func (b *ExportBuilder) SaveCluster(ctx context.Context, id, name, kubeVersion string) (*ent.Cluster, error) {
+	b.export.Cluster.KubeVersion = kubeVersion
+	return &ent.Cluster{ID: b.export.Cluster.ID, Name: b.export.Cluster.Name, KubeVersion: kubeVersion}, nil
+}
+
+// SetServedAPIs records the kinds the cluster's API server serves per group/version
+func (b *ExportBuilder) SetServedAPIs(ctx context.Context, clusterID string, served map[string][]string) error {
+	b.export.Cluster.ServedAPIs = served
+	return nil
+}
+
+// SetNodePlatforms records the distinct os/arch platforms of the cluster's nodes
+func (b *ExportBuilder) SetNodePlatforms(ctx context.Context, clusterID string, platforms []string) error {
+	b.export.Cluster.NodePlatforms = platforms
+	return nil
+}
+
+// SetNamespaces records the namespaces' criticality and the namespaces being deleted
+func (b *ExportBuilder) SetNamespaces(ctx context.Context, clusterID string, criticality map[string]string, terminating []string) error {
+	b.export.Cluster.NamespaceCriticality = criticality
+	b.export.Cluster.TerminatingNamespaces = terminating
+	return nil
+}
+
+// SetResourceVersions does nothing, since an export has no watermarks
+func (b *ExportBuilder) SetResourceVersions(ctx context.Context, clusterID string, versions map[string]string) error {
+	return nil
+}
+
+// SaveScannedCRD adds a CRD, or replaces the one of the same name
+func (b *ExportBuilder) SaveScannedCRD(ctx context.Context, clusterID string, crd ExportedCRD) (*ent.CRD, error) {
+	i := slices.IndexFunc(b.export.CRDs, func(c ExportedCRD) bool { return c.Name == crd.Name })
+	if i < 0 {
+		i = len(b.export.CRDs)
+		b.export.CRDs = append(b.export.CRDs, crd)
+	} else {
+		b.export.CRDs[i] = crd
+	}
+	return &ent.CRD{ID: i + 1, Name: crd.Name, Group: crd.Group, Kind: crd.Kind}, nil
+}
+
+// DeleteCRDs deletes the CRDs with the given names and their custom resources
+func (b *ExportBuilder) DeleteCRDs(ctx context.Context, clusterID string, names []string) error {
+	b.deleteCRDs(func(crd ExportedCRD) bool { return slices.Contains(names, crd.Name) })
+	return nil
+}
+
+// PruneCRDs deletes the CRDs not among the given names and their custom resources
+func (b *ExportBuilder) PruneCRDs(ctx context.Context, clusterID string, keep []string) error {
+	b.deleteCRDs(func(crd ExportedCRD) bool { return !slices.Contains(keep, crd.Name) })
+	return nil
+}
+
+func (b *ExportBuilder) deleteCRDs(del func(ExportedCRD) bool) {
+	b.export.CRDs = slices.DeleteFunc(b.export.CRDs, func(crd ExportedCRD) bool {
+		if del(crd) {
+			b.deleteResources(ResourceFilter{Source: ResourceSourceCluster, Group: crd.Group, Kind: crd.Kind})
+			return true
+		}
+		return false
+	})
+}
+
+// ReplaceResources deletes the resources matching filter and adds resources with filter.Source as
+// their source. Resources without an apiVersion or kind are skipped.
+func (b *ExportBuilder) ReplaceResources(ctx context.Context, clusterID string, filter ResourceFilter, resources []ResourceEntry) error {
+	b.deleteResources(filter)
+	for _, resource := range resources {
+		if _, version := splitAPIVersion(resource.APIVersion); version == "" || resource.Kind == "" {
+			continue
+		}
+		b.export.Resources = append(b.export.Resources, NewExportedResource(resource, filter.Source))
+	}
+	return nil
+}
+
+func (b *ExportBuilder) deleteResources(filter ResourceFilter) {
+	b.export.Resources = slices.DeleteFunc(b.export.Resources, func(resource ExportedResource) bool {
+		group, _ := splitAPIVersion(resource.APIVersion)
+		return (filter.Source == "" || resource.Source == filter.Source) &&
+			(filter.Group == "" || group == filter.Group) &&
+			(len(filter.Groups) == 0 || slices.Contains(filter.Groups, group)) &&
+			(filter.Kind == "" || resource.Kind == filter.Kind) &&
+			(filter.Namespace == "" || resource.Namespace == filter.Namespace) &&
+			(len(filter.Files) == 0 || slices.Contains(filter.Files, resource.File))
+	})
+}
+
+// SaveHelmRelease adds a Helm release, or replaces the one of the same namespace and name
+func (b *ExportBuilder) SaveHelmRelease(ctx context.Context, clusterID string, release HelmReleaseEntry) (*ent.HelmRelease, error) {
+	exported := NewExportedHelmRelease(release)
+	i := slices.IndexFunc(b.export.HelmReleases, func(r ExportedHelmRelease) bool {
+		return r.Namespace == release.Namespace && r.Name == release.Name
+	})
+	if i < 0 {
+		i = len(b.export.HelmReleases)
+		b.export.HelmReleases = append(b.export.HelmReleases, exported)
+	} else {
+		b.export.HelmReleases[i] = exported
+	}
+	return &ent.HelmRelease{ID: i + 1, Name: release.Name, Namespace: release.Namespace}, nil
+}
+
+// ReplaceHelmReleases saves the Helm releases of a namespace as scanned from the cluster and deletes
+// the namespace's cluster releases no longer among them
+func (b *ExportBuilder) ReplaceHelmReleases(ctx context.Context, clusterID, namespace string, releases []HelmReleaseEntry) error {
+	names := make([]string, 0, len(releases))
+	for _, release := range releases {
+		if _, err := b.SaveHelmRelease(ctx, clusterID, release); err != nil {
+			return err
+		}
+		names = append(names, release.Name)
+	}
+	b.export.HelmReleases = slices.DeleteFunc(b.export.HelmReleases, func(r ExportedHelmRelease) bool {
+		return r.Namespace == namespace && r.Source == string(helmrelease.SourceCluster) && !slices.Contains(names, r.Name)
+	})
+	return nil
+}
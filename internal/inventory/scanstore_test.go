@@ -0,0 +1,83 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExportBuilder(t *testing.T) {
+	ctx := context.Background()
+	builder := NewExportBuilder(ExportedCluster{ID: "prod", Name: "Production"})
+
+	if _, err := builder.SaveCluster(ctx, "prod", "my-cluster", "v1.27.4"); err != nil {
+		t.Fatalf("SaveCluster: %v", err)
+	}
+	for _, crd := range []ExportedCRD{
+		{Name: "certificates.cert-manager.io", Group: "cert-manager.io", Kind: "Certificate"},
+		{Name: "widgets.example.com", Group: "example.com", Kind: "Widget"},
+	} {
+		if _, err := builder.SaveScannedCRD(ctx, "prod", crd); err != nil {
+			t.Fatalf("SaveScannedCRD: %v", err)
+		}
+		filter := ResourceFilter{Source: ResourceSourceCluster, Group: crd.Group, Kind: crd.Kind}
+		resources := []ResourceEntry{{APIVersion: crd.Group + "/v1", Kind: crd.Kind, Namespace: "shop", Name: "a"}}
+		if err := builder.ReplaceResources(ctx, "prod", filter, resources); err != nil {
+			t.Fatalf("ReplaceResources: %v", err)
+		}
+	}
+	deployments := ResourceFilter{Source: ResourceSourceCluster, Groups: []string{"apps", "extensions"}, Kind: "Deployment"}
+	for _, names := range [][]string{{"web", "api"}, {"web"}} {
+		resources := make([]ResourceEntry, 0, len(names))
+		for _, name := range names {
+			resources = append(resources, ResourceEntry{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: name})
+		}
+		// Without a kind, skipped
+		resources = append(resources, ResourceEntry{APIVersion: "apps/v1", Name: "broken"})
+		if err := builder.ReplaceResources(ctx, "prod", deployments, resources); err != nil {
+			t.Fatalf("ReplaceResources: %v", err)
+		}
+	}
+	if err := builder.PruneCRDs(ctx, "prod", []string{"certificates.cert-manager.io"}); err != nil {
+		t.Fatalf("PruneCRDs: %v", err)
+	}
+
+	releases := []HelmReleaseEntry{
+		{Name: "web", Namespace: "shop", Chart: "web", ChartVersion: "1.0.0"},
+		{Name: "old", Namespace: "shop", Chart: "old", ChartVersion: "1.0.0"},
+		{Name: "infra", Namespace: "shop", Chart: "infra", ChartVersion: "1.0.0", Source: "terraform"},
+	}
+	for _, release := range releases {
+		if _, err := builder.SaveHelmRelease(ctx, "prod", release); err != nil {
+			t.Fatalf("SaveHelmRelease: %v", err)
+		}
+	}
+	upgraded := []HelmReleaseEntry{{Name: "web", Namespace: "shop", Chart: "web", ChartVersion: "2.0.0"}}
+	if err := builder.ReplaceHelmReleases(ctx, "prod", "shop", upgraded); err != nil {
+		t.Fatalf("ReplaceHelmReleases: %v", err)
+	}
+
+	export := builder.Export()
+	if export.Cluster.ID != "prod" || export.Cluster.Name != "Production" || export.Cluster.KubeVersion != "v1.27.4" {
+		t.Errorf("cluster = %+v, want prod named Production at v1.27.4", export.Cluster)
+	}
+	if len(export.CRDs) != 1 || export.CRDs[0].Kind != "Certificate" {
+		t.Errorf("CRDs = %+v, want only the Certificate CRD", export.CRDs)
+	}
+	kinds := make(map[string]int)
+	for _, resource := range export.Resources {
+		kinds[resource.Kind]++
+		if resource.Source != ResourceSourceCluster {
+			t.Errorf("resource %s/%s has source %q", resource.Kind, resource.Name, resource.Source)
+		}
+	}
+	if kinds["Certificate"] != 1 || kinds["Deployment"] != 1 || len(kinds) != 2 {
+		t.Errorf("resources by kind = %v, want a Certificate and the web Deployment", kinds)
+	}
+	got := make(map[string]string)
+	for _, release := range export.HelmReleases {
+		got[release.Name] = release.ChartVersion
+	}
+	if len(got) != 2 || got["web"] != "2.0.0" || got["infra"] != "1.0.0" {
+		t.Errorf("releases = %v, want web 2.0.0 and the Terraform release", got)
+	}
+}
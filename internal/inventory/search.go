@@ -0,0 +1,306 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entcrd "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/crd"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/helmrelease"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/manifestapi"
+	entresource "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/resource"
+)
+
+// Types of search matches
+const (
+	SearchTypeHelmRelease = "helm-release"
+	SearchTypeCRD         = "crd"
+	SearchTypeManifestAPI = "manifest-api"
+	SearchTypeResource    = "resource"
+)
+
+// SearchTypes are the types of search matches in the order they are listed
+var SearchTypes = []string{SearchTypeHelmRelease, SearchTypeCRD, SearchTypeManifestAPI, SearchTypeResource}
+
+// SearchFilter selects what Search looks through. The pattern matches anywhere in a name, GVK or
+// file path, ignoring the case of ASCII letters, with * matching any characters.
+type SearchFilter struct {
+	Pattern   string
+	ClusterID string   // empty searches every cluster
+	Types     []string // empty searches every type
+	Limit     int      // 0 returns all matches
+}
+
+// SearchMatch is an inventory item matching a search
+type SearchMatch struct {
+	ClusterID string `json:"clusterId"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Detail    string `json:"detail,omitempty"`
+	Source    string `json:"source,omitempty"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+}
+
+// SearchResult holds the matches of a search, at most the filter's limit, and how many there were
+type SearchResult struct {
+	Total   int           `json:"total"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// Validate checks the filter's pattern and types
+func (f SearchFilter) Validate() error {
+	if strings.Trim(f.Pattern, "*") == "" {
+		return fmt.Errorf("search pattern %q matches everything", f.Pattern)
+	}
+	for _, t := range f.Types {
+		if !containsType(SearchTypes, t) {
+			return fmt.Errorf("unknown search type %q (types: %s)", t, strings.Join(SearchTypes, ", "))
+		}
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("search limit %d is negative", f.Limit)
+	}
+	return nil
+}
+
+// formatGVK formats a group, version and kind like "batch/v1beta1 CronJob", without a group for core APIs
+func formatGVK(group, version, kind string) string {
+	if group == "" {
+		return version + " " + kind
+	}
+	return group + "/" + version + " " + kind
+}
+
+// Search finds the Helm releases, CRDs, manifest APIs and resources whose names, charts, GVKs or
+// file paths match the filter's pattern, ordered by cluster, type and name. The pattern is matched
+// by the database, with LIKE, and each type is read up to the limit.
+func (s *Store) Search(ctx context.Context, filter SearchFilter) (*SearchResult, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	like := likePattern(filter.Pattern)
+	searched := func(t string) bool {
+		return len(filter.Types) == 0 || containsType(filter.Types, t)
+	}
+
+	result := &SearchResult{Matches: []SearchMatch{}}
+	if searched(SearchTypeHelmRelease) {
+		releaseName := func(s *sql.Selector) string {
+			return s.C(helmrelease.FieldNamespace) + " || '/' || " + s.C(helmrelease.FieldName)
+		}
+		query := s.client.HelmRelease.Query().Where(func(s *sql.Selector) {
+			likeAny(s, like, releaseName(s), s.C(helmrelease.FieldChart), s.C(helmrelease.FieldChartURL))
+		})
+		if filter.ClusterID != "" {
+			query = query.Where(helmrelease.HasClusterWith(cluster.ID(filter.ClusterID)))
+		}
+		total, err := query.Clone().Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count helm releases: %w", err)
+		}
+		result.Total += total
+		query = query.WithCluster().Order(func(s *sql.Selector) {
+			s.OrderBy(s.C(helmrelease.ClusterColumn))
+			s.OrderExpr(sql.Expr(releaseName(s)))
+		})
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		releases, err := query.All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query helm releases: %w", err)
+		}
+		for _, release := range releases {
+			result.Matches = append(result.Matches, SearchMatch{
+				ClusterID: edgeClusterID(release.Edges.Cluster),
+				Type:      SearchTypeHelmRelease,
+				Name:      release.Namespace + "/" + release.Name,
+				Detail:    release.Chart + "-" + release.ChartVersion,
+				Source:    string(release.Source),
+			})
+		}
+	}
+
+	if searched(SearchTypeCRD) {
+		query := s.client.CRD.Query().Where(func(s *sql.Selector) {
+			// a CRD matches by its name or the GVK of any of its versions
+			versionGVK := gvkExpr(s.C(entcrd.FieldGroup), "version.value", s.C(entcrd.FieldKind))
+			s.Where(sql.ExprP("("+s.C(entcrd.FieldName)+likeClause+" OR EXISTS (SELECT 1 FROM json_each("+
+				s.C(entcrd.FieldVersions)+") AS version WHERE "+versionGVK+likeClause+"))", like, like))
+		})
+		if filter.ClusterID != "" {
+			query = query.Where(entcrd.HasClusterWith(cluster.ID(filter.ClusterID)))
+		}
+		total, err := query.Clone().Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count CRDs: %w", err)
+		}
+		result.Total += total
+		query = query.WithCluster().Order(func(s *sql.Selector) {
+			s.OrderBy(s.C(entcrd.ClusterColumn), s.C(entcrd.FieldName))
+		})
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		crds, err := query.All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query CRDs: %w", err)
+		}
+		for _, crd := range crds {
+			result.Matches = append(result.Matches, SearchMatch{
+				ClusterID: edgeClusterID(crd.Edges.Cluster),
+				Type:      SearchTypeCRD,
+				Name:      crd.Name,
+				Detail:    crd.Kind + " " + strings.Join(crd.Versions, ","),
+			})
+		}
+	}
+
+	if searched(SearchTypeManifestAPI) {
+		apiGVK := func(s *sql.Selector) string {
+			return gvkExpr(s.C(manifestapi.FieldGroup), s.C(manifestapi.FieldVersion), s.C(manifestapi.FieldKind))
+		}
+		query := s.client.ManifestAPI.Query().Where(func(s *sql.Selector) {
+			likeAny(s, like, apiGVK(s))
+		})
+		if filter.ClusterID != "" {
+			query = query.Where(manifestapi.HasClusterWith(cluster.ID(filter.ClusterID)))
+		}
+		total, err := query.Clone().Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count manifest APIs: %w", err)
+		}
+		result.Total += total
+		query = query.WithCluster().Order(func(s *sql.Selector) {
+			s.OrderBy(s.C(manifestapi.ClusterColumn))
+			s.OrderExpr(sql.Expr(apiGVK(s)))
+		})
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		apis, err := query.All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query manifest APIs: %w", err)
+		}
+		for _, api := range apis {
+			result.Matches = append(result.Matches, SearchMatch{
+				ClusterID: edgeClusterID(api.Edges.Cluster),
+				Type:      SearchTypeManifestAPI,
+				Name:      formatGVK(api.Group, api.Version, api.Kind),
+				Detail:    strings.Join(api.Namespaces, ","),
+				Source:    string(api.Source),
+			})
+		}
+	}
+
+	if searched(SearchTypeResource) {
+		resourceName := func(s *sql.Selector) string {
+			namespace, name := s.C(entresource.FieldNamespace), s.C(entresource.FieldName)
+			return "(CASE WHEN " + namespace + " = '' THEN " + name + " ELSE " + namespace + " || '/' || " + name + " END)"
+		}
+		query := s.client.Resource.Query().Where(func(s *sql.Selector) {
+			gvk := gvkExpr(s.C(entresource.FieldGroup), s.C(entresource.FieldVersion), s.C(entresource.FieldKind))
+			likeAny(s, like, gvk, resourceName(s), s.C(entresource.FieldFile))
+		})
+		if filter.ClusterID != "" {
+			query = query.Where(entresource.HasClusterWith(cluster.ID(filter.ClusterID)))
+		}
+		total, err := query.Clone().Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count resources: %w", err)
+		}
+		result.Total += total
+		query = query.WithCluster().Order(func(s *sql.Selector) {
+			s.OrderBy(s.C(entresource.ClusterColumn))
+			s.OrderExpr(sql.Expr(resourceName(s)))
+			s.OrderBy(s.C(entresource.FieldFile))
+		})
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		resources, err := query.All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query resources: %w", err)
+		}
+		for _, resource := range resources {
+			name := resource.Name
+			if resource.Namespace != "" {
+				name = resource.Namespace + "/" + resource.Name
+			}
+			result.Matches = append(result.Matches, SearchMatch{
+				ClusterID: edgeClusterID(resource.Edges.Cluster),
+				Type:      SearchTypeResource,
+				Name:      name,
+				Detail:    formatGVK(resource.Group, resource.Version, resource.Kind),
+				Source:    string(resource.Source),
+				File:      resource.File,
+				Line:      resource.Line,
+			})
+		}
+	}
+
+	// Each type is in order already; the first matches of each are merged up to the limit
+	rank := make(map[string]int, len(SearchTypes))
+	for i, t := range SearchTypes {
+		rank[t] = i
+	}
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		a, b := result.Matches[i], result.Matches[j]
+		if a.ClusterID != b.ClusterID {
+			return a.ClusterID < b.ClusterID
+		}
+		return rank[a.Type] < rank[b.Type]
+	})
+	if filter.Limit > 0 && len(result.Matches) > filter.Limit {
+		result.Matches = result.Matches[:filter.Limit]
+	}
+	return result, nil
+}
+
+// likeClause compares an SQL expression with a LIKE pattern made by likePattern
+const likeClause = ` LIKE ? ESCAPE '\'`
+
+// likePattern converts a search pattern to a LIKE pattern matching it anywhere in a value: * becomes
+// %, and the LIKE wildcards % and _ are escaped. SQLite's LIKE ignores the case of ASCII letters.
+func likePattern(pattern string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`).Replace(pattern) + "%"
+}
+
+// likeAny selects the rows where any of the SQL expressions matches a LIKE pattern
+func likeAny(s *sql.Selector, like string, exprs ...string) {
+	clauses := make([]string, len(exprs))
+	args := make([]any, len(exprs))
+	for i, expr := range exprs {
+		clauses[i] = expr + likeClause
+		args[i] = like
+	}
+	s.Where(sql.ExprP("("+strings.Join(clauses, " OR ")+")", args...))
+}
+
+// gvkExpr is the SQL of formatGVK over the columns or expressions of a group, version and kind
+func gvkExpr(group, version, kind string) string {
+	return "(CASE WHEN " + group + " = '' THEN " + version + " ELSE " + group + " || '/' || " + version + " END || ' ' || " + kind + ")"
+}
+
+// containsType reports whether types contains t
+func containsType(types []string, t string) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeClusterID returns the ID of a loaded cluster edge, empty when it wasn't loaded
+func edgeClusterID(c *ent.Cluster) string {
+	if c == nil {
+		return ""
+	}
+	return c.ID
+}
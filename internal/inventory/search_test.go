@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	for _, id := range []string{"prod", "staging"} {
+		if _, err := store.SaveCluster(ctx, id, id, "v1.21.5"); err != nil {
+			t.Fatalf("SaveCluster: %v", err)
+		}
+	}
+	releases := map[string][]HelmReleaseEntry{
+		"prod": {
+			{Name: "web", Namespace: "shop", Chart: "web", ChartVersion: "1.2.0", ChartURL: "https://charts.example.com/web"},
+			{Name: "api", Namespace: "shop", Chart: "api", ChartVersion: "0.3.1"},
+		},
+		"staging": {{Name: "web", Namespace: "shop", Chart: "web", ChartVersion: "1.3.0"}},
+	}
+	for id, entries := range releases {
+		for _, release := range entries {
+			if _, err := store.SaveHelmRelease(ctx, id, release); err != nil {
+				t.Fatalf("SaveHelmRelease: %v", err)
+			}
+		}
+	}
+	certificate := CRDEntry{Name: "certificates.cert-manager.io", Group: "cert-manager.io", Version: "v1alpha2", Kind: "Certificate"}
+	if _, err := store.SaveCRD(ctx, "prod", certificate); err != nil {
+		t.Fatalf("SaveCRD: %v", err)
+	}
+	if _, err := store.SaveManifestAPI(ctx, "prod", "networking.k8s.io", "v1beta1", "Ingress", "git", false, []string{"shop"}); err != nil {
+		t.Fatalf("SaveManifestAPI: %v", err)
+	}
+	resources := []ResourceEntry{
+		{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", Namespace: "shop", Name: "web_1", Source: "apps/shop/ingress.yaml"},
+		{APIVersion: "v1", Kind: "Service", Namespace: "shop", Name: "web-1", Source: "apps/shop/service.yaml"},
+	}
+	if err := store.ReplaceResources(ctx, "prod", ResourceFilter{Source: "git"}, resources); err != nil {
+		t.Fatalf("ReplaceResources: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		filter    SearchFilter
+		wantTotal int
+		want      []string // type and name of each match
+	}{
+		{
+			name:      "case-insensitive GVK",
+			filter:    SearchFilter{Pattern: "V1BETA1 INGRESS"},
+			wantTotal: 2,
+			want:      []string{"manifest-api networking.k8s.io/v1beta1 Ingress", "resource shop/web_1"},
+		},
+		{
+			name:      "LIKE wildcards are literal",
+			filter:    SearchFilter{Pattern: "web_1"},
+			wantTotal: 1,
+			want:      []string{"resource shop/web_1"},
+		},
+		{
+			name:      "CRD version GVK",
+			filter:    SearchFilter{Pattern: "cert-manager.io/v1alpha2 certificate"},
+			wantTotal: 1,
+			want:      []string{"crd certificates.cert-manager.io"},
+		},
+		{
+			name:      "chart URL",
+			filter:    SearchFilter{Pattern: "charts.example.com"},
+			wantTotal: 1,
+			want:      []string{"helm-release shop/web"},
+		},
+		{
+			name:      "limit counts every match",
+			filter:    SearchFilter{Pattern: "shop/*", Limit: 2},
+			wantTotal: 5,
+			want:      []string{"helm-release shop/api", "helm-release shop/web"},
+		},
+		{
+			name:      "cluster",
+			filter:    SearchFilter{Pattern: "web", ClusterID: "staging"},
+			wantTotal: 1,
+			want:      []string{"helm-release shop/web"},
+		},
+		{
+			name:      "types",
+			filter:    SearchFilter{Pattern: "web", Types: []string{SearchTypeResource}},
+			wantTotal: 2,
+			want:      []string{"resource shop/web-1", "resource shop/web_1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.Search(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got := []string{}
+			for _, match := range result.Matches {
+				got = append(got, match.Type+" "+match.Name)
+			}
+			if result.Total != tt.wantTotal || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %d %v, want %d %v", result.Total, got, tt.wantTotal, tt.want)
+			}
+		})
+	}
+
+	if _, err := store.Search(ctx, SearchFilter{Pattern: "**"}); err == nil {
+		t.Error("Search() of a pattern matching everything succeeded, want an error")
+	}
+}
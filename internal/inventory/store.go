@@ -3,38 +3,119 @@ package inventory
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
 	entcrd "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/crd"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/helmrelease"
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/manifestapi"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/predicate"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
 )
 
 // Store handles persistent storage of inventory data using Ent
 type Store struct {
-	client *ent.Client
+	client   *ent.Client
+	migrator *Migrator
+	readOnly bool
 }
 
-// NewStore creates a new inventory store with SQLite backend
+// NewStore creates a new inventory store with SQLite backend. Binaries register the sqlite3 driver
+// by importing github.com/mattn/go-sqlite3, so the inventory types can be used without cgo.
+// A new database gets the current schema; an existing one must be at the binary's schema
+// version, else ErrMigrationRequired or ErrSchemaTooNew is returned and nothing is altered.
 func NewStore(dbPath string) (*Store, error) {
-	client, err := ent.Open("sqlite3", fmt.Sprintf("file:%s?cache=shared&_fk=1", dbPath))
+	migrator, err := NewMigrator(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed opening connection to sqlite: %w", err)
+		return nil, err
 	}
 
-	// Run auto migration
-	if err := client.Schema.Create(context.Background()); err != nil {
-		client.Close()
-		return nil, fmt.Errorf("failed creating schema: %w", err)
+	ctx := context.Background()
+	status, err := migrator.Status(ctx)
+	if err == nil {
+		switch {
+		case status.Current > status.Latest:
+			err = fmt.Errorf("%w: version %d, this binary supports up to %d", ErrSchemaTooNew, status.Current, status.Latest)
+		case status.Empty:
+			_, err = migrator.Migrate(ctx)
+		case status.Current < status.Latest:
+			err = fmt.Errorf("%w: version %d, this binary needs %d", ErrMigrationRequired, status.Current, status.Latest)
+		}
+	}
+	if err != nil {
+		migrator.Close()
+		return nil, err
 	}
+	migrator.client.Use(timeWrites)
 
 	return &Store{
-		client: client,
+		client:   migrator.client,
+		migrator: migrator,
 	}, nil
 }
 
+// NewReadOnlyStore opens an existing database read-only: SQLite opens the file with mode=ro and
+// every write is refused with ErrReadOnly, so the file can be handed to people who must not
+// alter the inventory. The database must be at the binary's schema version.
+func NewReadOnlyStore(dbPath string) (*Store, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database read-only: %w", err)
+	}
+	migrator, err := openMigrator(dbPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := migrator.Status(context.Background())
+	if err == nil {
+		switch {
+		case status.Current > status.Latest:
+			err = fmt.Errorf("%w: version %d, this binary supports up to %d", ErrSchemaTooNew, status.Current, status.Latest)
+		case status.Empty:
+			err = fmt.Errorf("database %s has no inventory to read", dbPath)
+		case status.Current < status.Latest:
+			err = fmt.Errorf("%w: version %d, this binary needs %d", ErrMigrationRequired, status.Current, status.Latest)
+		}
+	}
+	if err != nil {
+		migrator.Close()
+		return nil, err
+	}
+	migrator.client.Use(rejectWrites)
+
+	return &Store{
+		client:   migrator.client,
+		migrator: migrator,
+		readOnly: true,
+	}, nil
+}
+
+// rejectWrites refuses every mutation of a read-only store before it reaches SQLite
+func rejectWrites(next ent.Mutator) ent.Mutator {
+	return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+		return nil, fmt.Errorf("%w: can't write %s", ErrReadOnly, m.Type())
+	})
+}
+
+// ReadOnly reports whether the store was opened with NewReadOnlyStore
+func (s *Store) ReadOnly() bool {
+	return s.readOnly
+}
+
+// timeWrites records the duration of every mutation for telemetry
+func timeWrites(next ent.Mutator) ent.Mutator {
+	return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+		start := time.Now()
+		value, err := next.Mutate(ctx, m)
+		op := strings.ToLower(strings.TrimPrefix(m.Op().String(), "Op"))
+		telemetry.RecordDBWrite(ctx, m.Type(), op, time.Since(start), err)
+		return value, err
+	})
+}
+
 // GetClient returns the underlying Ent client
 func (s *Store) GetClient() *ent.Client {
 	return s.client
@@ -62,6 +143,87 @@ func (s *Store) SaveCluster(ctx context.Context, id, name, kubeVersion string) (
 		Save(ctx)
 }
 
+// SetServedAPIs records the kinds the cluster's API server serves per group/version
+func (s *Store) SetServedAPIs(ctx context.Context, clusterID string, served map[string][]string) error {
+	err := s.client.Cluster.
+		UpdateOneID(clusterID).
+		SetServedApis(served).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update served APIs: %w", err)
+	}
+	return nil
+}
+
+// SetNodePlatforms records the distinct os/arch platforms of the cluster's nodes
+func (s *Store) SetNodePlatforms(ctx context.Context, clusterID string, platforms []string) error {
+	err := s.client.Cluster.
+		UpdateOneID(clusterID).
+		SetNodePlatforms(platforms).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update node platforms: %w", err)
+	}
+	return nil
+}
+
+// SetNamespaces records the criticality of the cluster's namespaces that declare one, and the
+// namespaces being deleted
+func (s *Store) SetNamespaces(ctx context.Context, clusterID string, criticality map[string]string, terminating []string) error {
+	err := s.client.Cluster.
+		UpdateOneID(clusterID).
+		SetNamespaceCriticality(criticality).
+		SetTerminatingNamespaces(terminating).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update namespaces: %w", err)
+	}
+	return nil
+}
+
+// SetIncompleteReason marks the cluster's inventory as partial, or as complete with an empty reason
+func (s *Store) SetIncompleteReason(ctx context.Context, clusterID, reason string) error {
+	update := s.client.Cluster.UpdateOneID(clusterID)
+	if reason == "" {
+		update.ClearIncompleteReason()
+	} else {
+		update.SetIncompleteReason(reason)
+	}
+	if err := update.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update incomplete reason: %w", err)
+	}
+	return nil
+}
+
+// SetScanErrors records the scan subsystems that failed, or clears them with an empty map
+func (s *Store) SetScanErrors(ctx context.Context, clusterID string, scanErrors map[string]string) error {
+	update := s.client.Cluster.UpdateOneID(clusterID)
+	if len(scanErrors) == 0 {
+		update.ClearScanErrors()
+	} else {
+		update.SetScanErrors(scanErrors)
+	}
+	if err := update.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update scan errors: %w", err)
+	}
+	return nil
+}
+
+// SetResourceVersions records the resourceVersion watermarks of the scanned resource types, or
+// clears them with an empty map
+func (s *Store) SetResourceVersions(ctx context.Context, clusterID string, versions map[string]string) error {
+	update := s.client.Cluster.UpdateOneID(clusterID)
+	if len(versions) == 0 {
+		update.ClearResourceVersions()
+	} else {
+		update.SetResourceVersions(versions)
+	}
+	if err := update.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update resource versions: %w", err)
+	}
+	return nil
+}
+
 // GetCluster retrieves a cluster by ID
 func (s *Store) GetCluster(ctx context.Context, id string) (*ent.Cluster, error) {
 	return s.client.Cluster.
@@ -75,7 +237,7 @@ func (s *Store) ListClusters(ctx context.Context) ([]*ent.Cluster, error) {
 		All(ctx)
 }
 
-// ClearClusterData deletes all data for a cluster (Helm releases, CRDs, ManifestAPIs)
+// ClearClusterData deletes all data for a cluster (Helm releases, CRDs, ManifestAPIs, resources)
 func (s *Store) ClearClusterData(ctx context.Context, clusterID string) error {
 	// Delete Helm releases
 	_, err := s.client.HelmRelease.
@@ -104,11 +266,17 @@ func (s *Store) ClearClusterData(ctx context.Context, clusterID string) error {
 		return fmt.Errorf("failed to delete manifest APIs: %w", err)
 	}
 
-	return nil
+	// Delete resources
+	return s.DeleteResources(ctx, clusterID, ResourceFilter{})
 }
 
 // SaveHelmRelease saves a Helm release (creates or updates)
 func (s *Store) SaveHelmRelease(ctx context.Context, clusterID string, release HelmReleaseEntry) (*ent.HelmRelease, error) {
+	source := helmrelease.SourceCluster
+	if release.Source != "" {
+		source = helmrelease.Source(release.Source)
+	}
+
 	// Check if release already exists
 	existing, err := s.client.HelmRelease.
 		Query().
@@ -119,13 +287,30 @@ func (s *Store) SaveHelmRelease(ctx context.Context, clusterID string, release H
 		).
 		Only(ctx)
 
+	firstDeployed, lastDeployed := timePtr(release.FirstDeployed), timePtr(release.LastDeployed)
+
 	if err == nil {
 		// Release exists, update it
-		return existing.Update().
+		update := existing.Update().
 			SetChart(release.Chart).
 			SetChartVersion(release.ChartVersion).
 			SetAppVersion(release.AppVersion).
-			Save(ctx)
+			SetStatus(release.Status).
+			SetRevision(release.Revision).
+			SetChartURL(release.ChartURL).
+			SetApis(release.APIs).
+			SetSource(source)
+		if firstDeployed != nil {
+			update.SetFirstDeployed(*firstDeployed)
+		} else {
+			update.ClearFirstDeployed()
+		}
+		if lastDeployed != nil {
+			update.SetLastDeployed(*lastDeployed)
+		} else {
+			update.ClearLastDeployed()
+		}
+		return update.Save(ctx)
 	}
 
 	// Release doesn't exist, create new one
@@ -136,10 +321,33 @@ func (s *Store) SaveHelmRelease(ctx context.Context, clusterID string, release H
 		SetChart(release.Chart).
 		SetChartVersion(release.ChartVersion).
 		SetAppVersion(release.AppVersion).
+		SetStatus(release.Status).
+		SetRevision(release.Revision).
+		SetNillableFirstDeployed(firstDeployed).
+		SetNillableLastDeployed(lastDeployed).
+		SetChartURL(release.ChartURL).
+		SetApis(release.APIs).
+		SetSource(source).
 		SetClusterID(clusterID).
 		Save(ctx)
 }
 
+// timePtr returns a pointer to t, or nil for the zero time
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// timeValue returns *t, or the zero time for nil
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
 // SaveCRD saves a CRD entry (creates or updates)
 func (s *Store) SaveCRD(ctx context.Context, clusterID string, crd CRDEntry) (*ent.CRD, error) {
 	versions := []string{crd.Version}
@@ -159,6 +367,7 @@ func (s *Store) SaveCRD(ctx context.Context, clusterID string, crd CRDEntry) (*e
 			SetGroup(crd.Group).
 			SetKind(crd.Kind).
 			SetVersions(versions).
+			SetInstanceCount(crd.InstanceCount).
 			Save(ctx)
 	}
 
@@ -169,12 +378,140 @@ func (s *Store) SaveCRD(ctx context.Context, clusterID string, crd CRDEntry) (*e
 		SetGroup(crd.Group).
 		SetKind(crd.Kind).
 		SetVersions(versions).
+		SetInstanceCount(crd.InstanceCount).
 		SetClusterID(clusterID).
 		Save(ctx)
 }
 
-// SaveManifestAPI saves a manifest API entry (creates or updates)
-func (s *Store) SaveManifestAPI(ctx context.Context, clusterID, group, version, kind, source string) (*ent.ManifestAPI, error) {
+// SaveScannedCRD saves a CRD as scanned from the cluster with its served and stored versions, Helm
+// owner and instance count (creates or updates)
+func (s *Store) SaveScannedCRD(ctx context.Context, clusterID string, crd ExportedCRD) (*ent.CRD, error) {
+	existing, err := s.client.CRD.
+		Query().
+		Where(
+			entcrd.Name(crd.Name),
+			entcrd.HasClusterWith(cluster.ID(clusterID)),
+		).
+		First(ctx)
+
+	if err == nil {
+		return existing.Update().
+			SetGroup(crd.Group).
+			SetKind(crd.Kind).
+			SetVersions(crd.Versions).
+			SetStoredVersions(crd.StoredVersions).
+			SetVersionDetails(crd.VersionDetails).
+			SetScope(crd.Scope).
+			SetConversionWebhook(crd.ConversionWebhook).
+			SetConversionWebhookError(crd.ConversionWebhookError).
+			SetInstanceCount(crd.InstanceCount).
+			SetHelmOwnerName(crd.HelmOwnerName).
+			SetHelmOwnerNamespace(crd.HelmOwnerNamespace).
+			Save(ctx)
+	}
+	if !ent.IsNotFound(err) {
+		return nil, err
+	}
+
+	return s.client.CRD.
+		Create().
+		SetName(crd.Name).
+		SetGroup(crd.Group).
+		SetKind(crd.Kind).
+		SetVersions(crd.Versions).
+		SetStoredVersions(crd.StoredVersions).
+		SetVersionDetails(crd.VersionDetails).
+		SetScope(crd.Scope).
+		SetConversionWebhook(crd.ConversionWebhook).
+		SetConversionWebhookError(crd.ConversionWebhookError).
+		SetInstanceCount(crd.InstanceCount).
+		SetHelmOwnerName(crd.HelmOwnerName).
+		SetHelmOwnerNamespace(crd.HelmOwnerNamespace).
+		SetClusterID(clusterID).
+		Save(ctx)
+}
+
+// DeleteCRDs deletes the cluster's CRDs with the given names and their live custom resources
+func (s *Store) DeleteCRDs(ctx context.Context, clusterID string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if err := s.deleteCustomResources(ctx, clusterID, entcrd.NameIn(names...)); err != nil {
+		return err
+	}
+	_, err := s.client.CRD.
+		Delete().
+		Where(entcrd.NameIn(names...), entcrd.HasClusterWith(cluster.ID(clusterID))).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete CRDs: %w", err)
+	}
+	return nil
+}
+
+// PruneCRDs deletes the cluster's CRDs not among the given names, e.g. CRDs removed since the last
+// scan, and their live custom resources
+func (s *Store) PruneCRDs(ctx context.Context, clusterID string, keep []string) error {
+	if err := s.deleteCustomResources(ctx, clusterID, entcrd.NameNotIn(keep...)); err != nil {
+		return err
+	}
+	_, err := s.client.CRD.
+		Delete().
+		Where(entcrd.NameNotIn(keep...), entcrd.HasClusterWith(cluster.ID(clusterID))).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to prune CRDs: %w", err)
+	}
+	return nil
+}
+
+// deleteCustomResources deletes the live custom resources of the cluster's CRDs matching where
+func (s *Store) deleteCustomResources(ctx context.Context, clusterID string, where predicate.CRD) error {
+	crds, err := s.client.CRD.
+		Query().
+		Where(where, entcrd.HasClusterWith(cluster.ID(clusterID))).
+		All(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query CRDs: %w", err)
+	}
+	for _, crd := range crds {
+		filter := ResourceFilter{Source: ResourceSourceCluster, Group: crd.Group, Kind: crd.Kind}
+		if err := s.DeleteResources(ctx, clusterID, filter); err != nil {
+			return fmt.Errorf("failed to delete %s resources: %w", crd.Name, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceHelmReleases saves the Helm releases of a namespace as scanned from the cluster and deletes
+// the namespace's cluster releases no longer among them. Releases from Terraform are kept.
+func (s *Store) ReplaceHelmReleases(ctx context.Context, clusterID, namespace string, releases []HelmReleaseEntry) error {
+	names := make([]string, 0, len(releases))
+	for _, release := range releases {
+		if _, err := s.SaveHelmRelease(ctx, clusterID, release); err != nil {
+			return fmt.Errorf("failed to save helm release %s/%s: %w", release.Namespace, release.Name, err)
+		}
+		names = append(names, release.Name)
+	}
+
+	_, err := s.client.HelmRelease.
+		Delete().
+		Where(
+			helmrelease.Namespace(namespace),
+			helmrelease.NameNotIn(names...),
+			helmrelease.SourceEQ(helmrelease.SourceCluster),
+			helmrelease.HasClusterWith(cluster.ID(clusterID)),
+		).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete removed helm releases: %w", err)
+	}
+	return nil
+}
+
+// SaveManifestAPI saves a manifest API entry (creates or updates); templated marks an API only
+// found in un-rendered templates, and namespaces are the ones the manifests set on its resources
+func (s *Store) SaveManifestAPI(ctx context.Context, clusterID, group, version, kind, source string, templated bool, namespaces []string) (*ent.ManifestAPI, error) {
 	// Check if ManifestAPI already exists
 	existing, err := s.client.ManifestAPI.
 		Query().
@@ -190,6 +527,8 @@ func (s *Store) SaveManifestAPI(ctx context.Context, clusterID, group, version,
 		// ManifestAPI exists, update source if needed
 		return existing.Update().
 			SetSource(manifestapi.Source(source)).
+			SetTemplated(templated).
+			SetNamespaces(namespaces).
 			Save(ctx)
 	}
 
@@ -200,6 +539,8 @@ func (s *Store) SaveManifestAPI(ctx context.Context, clusterID, group, version,
 		SetVersion(version).
 		SetKind(kind).
 		SetSource(manifestapi.Source(source)).
+		SetTemplated(templated).
+		SetNamespaces(namespaces).
 		SetClusterID(clusterID).
 		Save(ctx)
 }
@@ -256,12 +597,19 @@ func (s *Store) GetSnapshot(ctx context.Context, id string) (*InventorySnapshot,
 		return nil, err
 	}
 
+	// Load live and manifest resources
+	resources, err := s.ListResources(ctx, id, ResourceFilter{})
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert to InventorySnapshot
 	snapshot := &InventorySnapshot{
 		ID:        clusterEntity.ID,
 		Timestamp: clusterEntity.CreatedAt,
 		Inventory: ClusterInventory{
 			ClusterVersion: clusterEntity.KubeVersion,
+			Resources:      resources,
 			HelmReleases:   make([]HelmReleaseEntry, len(helmReleases)),
 			CRDs:           make([]CRDEntry, len(crds)),
 		},
@@ -269,11 +617,18 @@ func (s *Store) GetSnapshot(ctx context.Context, id string) (*InventorySnapshot,
 
 	for i, hr := range helmReleases {
 		snapshot.Inventory.HelmReleases[i] = HelmReleaseEntry{
-			Name:         hr.Name,
-			Namespace:    hr.Namespace,
-			Chart:        hr.Chart,
-			ChartVersion: hr.ChartVersion,
-			AppVersion:   hr.AppVersion,
+			Name:          hr.Name,
+			Namespace:     hr.Namespace,
+			Chart:         hr.Chart,
+			ChartVersion:  hr.ChartVersion,
+			AppVersion:    hr.AppVersion,
+			Status:        hr.Status,
+			Revision:      hr.Revision,
+			FirstDeployed: timeValue(hr.FirstDeployed),
+			LastDeployed:  timeValue(hr.LastDeployed),
+			ChartURL:      hr.ChartURL,
+			Source:        string(hr.Source),
+			APIs:          hr.Apis,
 		}
 	}
 
@@ -283,9 +638,11 @@ func (s *Store) GetSnapshot(ctx context.Context, id string) (*InventorySnapshot,
 			version = crd.Versions[0]
 		}
 		snapshot.Inventory.CRDs[i] = CRDEntry{
-			Name:    crd.Name,
-			Group:   crd.Group,
-			Version: version,
+			Name:          crd.Name,
+			Group:         crd.Group,
+			Version:       version,
+			Kind:          crd.Kind,
+			InstanceCount: crd.InstanceCount,
 		}
 	}
 
@@ -320,5 +677,5 @@ func (s *Store) DeleteSnapshot(ctx context.Context, id string) error {
 
 // Close closes the store connection
 func (s *Store) Close() error {
-	return s.client.Close()
+	return s.migrator.Close()
 }
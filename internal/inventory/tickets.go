@@ -0,0 +1,70 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	entticket "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/ticket"
+)
+
+// GetTicket returns the ticket of a sink and project with the idempotency key; the error satisfies
+// ent.IsNotFound when none was created yet
+func (s *Store) GetTicket(ctx context.Context, sink, project, key string) (*ent.Ticket, error) {
+	return s.client.Ticket.
+		Query().
+		Where(entticket.Sink(sink), entticket.Project(project), entticket.IdempotencyKey(key)).
+		Only(ctx)
+}
+
+// ListTickets lists the tickets of a sink and project whose idempotency keys start with prefix
+func (s *Store) ListTickets(ctx context.Context, sink, project, prefix string) ([]*ent.Ticket, error) {
+	tickets, err := s.client.Ticket.
+		Query().
+		Where(entticket.Sink(sink), entticket.Project(project), entticket.IdempotencyKeyHasPrefix(prefix)).
+		Order(ent.Asc(entticket.FieldIdempotencyKey)).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	return tickets, nil
+}
+
+// SaveTicket records a ticket created in a sink, or updates the one with the same idempotency key
+func (s *Store) SaveTicket(ctx context.Context, entry TicketEntry) (*ent.Ticket, error) {
+	if strings.TrimSpace(entry.IdempotencyKey) == "" {
+		return nil, fmt.Errorf("ticket needs an idempotency key")
+	}
+
+	existing, err := s.GetTicket(ctx, entry.Sink, entry.Project, entry.IdempotencyKey)
+	switch {
+	case ent.IsNotFound(err):
+		saved, err := s.client.Ticket.
+			Create().
+			SetSink(entry.Sink).
+			SetProject(entry.Project).
+			SetIdempotencyKey(entry.IdempotencyKey).
+			SetExternalKey(entry.ExternalKey).
+			SetClusterID(entry.ClusterID).
+			SetTitle(entry.Title).
+			SetFindingCount(entry.FindingCount).
+			Save(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save ticket: %w", err)
+		}
+		return saved, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	saved, err := existing.Update().
+		SetExternalKey(entry.ExternalKey).
+		SetTitle(entry.Title).
+		SetFindingCount(entry.FindingCount).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket: %w", err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,209 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/cluster"
+	entcrd "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/crd"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/helmrelease"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/manifestapi"
+	entresource "github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent/resource"
+)
+
+// APIQuery selects an API by group, empty for the core group, version and optionally kind
+type APIQuery struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind,omitempty"`
+}
+
+// GroupVersion returns the apiVersion of the API, e.g. "networking.k8s.io/v1beta1"
+func (q APIQuery) GroupVersion() string {
+	if q.Group == "" {
+		return q.Version
+	}
+	return q.Group + "/" + q.Version
+}
+
+// String formats the query like "networking.k8s.io/v1beta1 Ingress"
+func (q APIQuery) String() string {
+	if q.Kind == "" {
+		return q.GroupVersion()
+	}
+	return q.GroupVersion() + " " + q.Kind
+}
+
+// matches reports whether an API of the given group, version and kind is selected
+func (q APIQuery) matches(group, version, kind string) bool {
+	return group == q.Group && version == q.Version && (q.Kind == "" || kind == q.Kind)
+}
+
+// ManifestAPIs returns the distinct APIs of the objects in a rendered manifest, such as a Helm
+// release's, as sorted "apiVersion/Kind" strings, e.g. "networking.k8s.io/v1beta1/Ingress"
+func ManifestAPIs(manifest string) []string {
+	seen := make(map[string]bool)
+	var apis []string
+	for _, document := range strings.Split(manifest, "\n---") {
+		var apiVersion, kind string
+		for _, line := range strings.Split(document, "\n") {
+			if value, ok := strings.CutPrefix(line, "apiVersion:"); ok {
+				apiVersion = strings.Trim(strings.TrimSpace(value), `"'`)
+			} else if value, ok := strings.CutPrefix(line, "kind:"); ok {
+				kind = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+		if apiVersion == "" || kind == "" {
+			continue
+		}
+		if api := apiVersion + "/" + kind; !seen[api] {
+			seen[api] = true
+			apis = append(apis, api)
+		}
+	}
+	sort.Strings(apis)
+	return apis
+}
+
+// matchesAPI reports whether an "apiVersion/Kind" string of ManifestAPIs is selected
+func (q APIQuery) matchesAPI(api string) bool {
+	apiVersion, kind, ok := cutLast(api, "/")
+	if !ok {
+		return false
+	}
+	group, version, ok := cutLast(apiVersion, "/")
+	if !ok {
+		group, version = "", apiVersion
+	}
+	return q.matches(group, version, kind)
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// ManifestAPIUsage is a manifest API of a cluster matching an API query
+type ManifestAPIUsage struct {
+	Kind       string   `json:"kind"`
+	Source     string   `json:"source"`
+	Templated  bool     `json:"templated,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ClusterAPIUsage is everything of a cluster using an API
+type ClusterAPIUsage struct {
+	ClusterID   string             `json:"clusterId"`
+	KubeVersion string             `json:"kubeVersion"`
+	Served      bool               `json:"served"`         // the API server serves the API
+	CRDs        []string           `json:"crds,omitempty"` // CRDs defining the API
+	Manifests   []ManifestAPIUsage `json:"manifests,omitempty"`
+	Resources   []ExportedResource `json:"resources,omitempty"` // live and manifest objects
+	Releases    []string           `json:"releases,omitempty"`  // namespace/name of Helm releases whose manifests use the API
+}
+
+// APIUsage lists the clusters using an API
+type APIUsage struct {
+	API      APIQuery          `json:"api"`
+	Clusters []ClusterAPIUsage `json:"clusters"`
+}
+
+// FindAPIUsage finds the CRDs, manifests, resources and Helm releases of every cluster that use the
+// queried API, whatever the clusters' versions, and the clusters serving it. Helm releases are
+// matched by the APIs of the objects in their stored manifests.
+func (s *Store) FindAPIUsage(ctx context.Context, query APIQuery) (*APIUsage, error) {
+	if query.Version == "" {
+		return nil, fmt.Errorf("API query needs a version")
+	}
+
+	clusters, err := s.client.Cluster.Query().Order(ent.Asc(cluster.FieldID)).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	usage := &APIUsage{API: query, Clusters: []ClusterAPIUsage{}}
+	for _, c := range clusters {
+		clusterUsage := ClusterAPIUsage{ClusterID: c.ID, KubeVersion: c.KubeVersion}
+		for _, kind := range c.ServedApis[query.GroupVersion()] {
+			if query.Kind == "" || kind == query.Kind {
+				clusterUsage.Served = true
+			}
+		}
+
+		crds, err := s.client.CRD.
+			Query().
+			Where(entcrd.HasClusterWith(cluster.ID(c.ID)), entcrd.Group(query.Group)).
+			All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query CRDs of %s: %w", c.ID, err)
+		}
+		for _, crd := range crds {
+			for _, version := range crd.Versions {
+				if query.matches(crd.Group, version, crd.Kind) {
+					clusterUsage.CRDs = append(clusterUsage.CRDs, crd.Name)
+					break
+				}
+			}
+		}
+
+		apis, err := s.client.ManifestAPI.
+			Query().
+			Where(manifestapi.HasClusterWith(cluster.ID(c.ID)), manifestapi.Group(query.Group), manifestapi.Version(query.Version)).
+			All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query manifest APIs of %s: %w", c.ID, err)
+		}
+		for _, api := range apis {
+			if query.matches(api.Group, api.Version, api.Kind) {
+				clusterUsage.Manifests = append(clusterUsage.Manifests, ManifestAPIUsage{
+					Kind:       api.Kind,
+					Source:     string(api.Source),
+					Templated:  api.Templated,
+					Namespaces: api.Namespaces,
+				})
+			}
+		}
+
+		resources, err := s.client.Resource.
+			Query().
+			Where(entresource.HasClusterWith(cluster.ID(c.ID)), entresource.Group(query.Group), entresource.Version(query.Version)).
+			Order(ent.Asc(entresource.FieldKind, entresource.FieldNamespace, entresource.FieldName)).
+			All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query resources of %s: %w", c.ID, err)
+		}
+		for _, resource := range resources {
+			if query.matches(resource.Group, resource.Version, resource.Kind) {
+				clusterUsage.Resources = append(clusterUsage.Resources, NewExportedResource(resourceEntry(resource), string(resource.Source)))
+			}
+		}
+
+		releases, err := s.client.HelmRelease.
+			Query().
+			Where(helmrelease.HasClusterWith(cluster.ID(c.ID))).
+			All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query helm releases of %s: %w", c.ID, err)
+		}
+		for _, release := range releases {
+			for _, api := range release.Apis {
+				if query.matchesAPI(api) {
+					clusterUsage.Releases = append(clusterUsage.Releases, release.Namespace+"/"+release.Name)
+					break
+				}
+			}
+		}
+		sort.Strings(clusterUsage.Releases)
+
+		if clusterUsage.Served || len(clusterUsage.CRDs) > 0 || len(clusterUsage.Manifests) > 0 || len(clusterUsage.Resources) > 0 || len(clusterUsage.Releases) > 0 {
+			usage.Clusters = append(usage.Clusters, clusterUsage)
+		}
+	}
+	return usage, nil
+}
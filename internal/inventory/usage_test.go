@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestAPIs(t *testing.T) {
+	manifest := `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/ingress.yaml
+apiVersion: "networking.k8s.io/v1beta1"
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+    - http:
+        paths:
+          - backend:
+              kind: Service
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-headless
+`
+	want := []string{"networking.k8s.io/v1beta1/Ingress", "v1/Service"}
+	if got := ManifestAPIs(manifest); !reflect.DeepEqual(got, want) {
+		t.Errorf("ManifestAPIs() = %v, want %v", got, want)
+	}
+}
+
+func TestFindAPIUsage(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	ingress := APIQuery{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}
+	for _, id := range []string{"prod", "staging", "dev"} {
+		if _, err := store.SaveCluster(ctx, id, id, "v1.21.5"); err != nil {
+			t.Fatalf("SaveCluster: %v", err)
+		}
+	}
+	// prod serves the API and still uses it, staging only serves it and dev neither
+	served := map[string][]string{"networking.k8s.io/v1beta1": {"Ingress", "IngressClass"}, "v1": {"Service"}}
+	for _, id := range []string{"prod", "staging"} {
+		if err := store.SetServedAPIs(ctx, id, served); err != nil {
+			t.Fatalf("SetServedAPIs: %v", err)
+		}
+	}
+	live := []ResourceEntry{{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", Namespace: "shop", Name: "web"}}
+	filter := ResourceFilter{Source: ResourceSourceCluster, Kind: "Ingress"}
+	if err := store.ReplaceResources(ctx, "prod", filter, live); err != nil {
+		t.Fatalf("ReplaceResources: %v", err)
+	}
+	releases := []HelmReleaseEntry{
+		// Not labelled with app.kubernetes.io/instance; only its manifest shows it uses the API
+		{Name: "web", Namespace: "shop", Chart: "web", ChartVersion: "1.0.0", APIs: []string{"networking.k8s.io/v1beta1/Ingress", "v1/Service"}},
+		{Name: "api", Namespace: "shop", Chart: "api", ChartVersion: "2.0.0", APIs: []string{"networking.k8s.io/v1/Ingress", "v1/Service"}},
+	}
+	for _, release := range releases {
+		if _, err := store.SaveHelmRelease(ctx, "prod", release); err != nil {
+			t.Fatalf("SaveHelmRelease: %v", err)
+		}
+	}
+
+	usage, err := store.FindAPIUsage(ctx, ingress)
+	if err != nil {
+		t.Fatalf("FindAPIUsage: %v", err)
+	}
+	if len(usage.Clusters) != 2 {
+		t.Fatalf("found %d clusters, want prod and staging: %+v", len(usage.Clusters), usage.Clusters)
+	}
+	prod, staging := usage.Clusters[0], usage.Clusters[1]
+	if prod.ClusterID != "prod" || !prod.Served || len(prod.Resources) != 1 || !reflect.DeepEqual(prod.Releases, []string{"shop/web"}) {
+		t.Errorf("prod usage = %+v, want the served API, the live Ingress and the web release", prod)
+	}
+	if staging.ClusterID != "staging" || !staging.Served || len(staging.Resources) != 0 || len(staging.Releases) != 0 {
+		t.Errorf("staging usage = %+v, want only the served API", staging)
+	}
+}
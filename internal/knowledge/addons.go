@@ -0,0 +1,125 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AddonVersion is the addon version shipped from a Kubernetes minor release on
+type AddonVersion struct {
+	Kubernetes string `json:"kubernetes"`
+	Version    string `json:"version"`
+}
+
+// Addon describes a kube-system component that has to be upgraded alongside Kubernetes
+type Addon struct {
+	Name          string   `json:"name"`
+	ImagePatterns []string `json:"imagePatterns"`
+	// FollowsKubernetes is set for addons released with Kubernetes itself (e.g. kube-proxy), whose
+	// version is the Kubernetes version
+	FollowsKubernetes bool           `json:"followsKubernetes"`
+	Versions          []AddonVersion `json:"versions"`
+	// ManagedBy lists the platforms that upgrade the addon with the control plane
+	ManagedBy []string `json:"managedBy"`
+	// Upgrade holds upgrade command templates per platform, with {version}, {kubernetes},
+	// {namespace}, {kind} and {workload} placeholders
+	Upgrade map[string][]string `json:"upgrade"`
+	// Image is the upstream image template, with a {version} placeholder
+	Image string `json:"image,omitempty"`
+	// Platforms the addon images are published for, e.g. "linux/arm64"; unknown when empty
+	Platforms []string `json:"platforms,omitempty"`
+	Notes     string   `json:"notes"`
+}
+
+// AddonKnowledgeBase manages the addon versions shipped with each Kubernetes release
+type AddonKnowledgeBase struct {
+	addons []Addon
+}
+
+// AddonKnowledgeData represents the structure of addons.json
+type AddonKnowledgeData struct {
+	Addons []Addon `json:"addons"`
+}
+
+// NewAddonKnowledgeBase creates a new addon knowledge base
+func NewAddonKnowledgeBase() *AddonKnowledgeBase {
+	return &AddonKnowledgeBase{
+		addons: make([]Addon, 0),
+	}
+}
+
+// LoadFromFile loads addon data from a JSON file
+func (kb *AddonKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var addonData AddonKnowledgeData
+	if err := json.Unmarshal(data, &addonData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	kb.addons = append(kb.addons, addonData.Addons...)
+
+	return nil
+}
+
+// DetectAddon identifies an addon and its version from a container image
+func (kb *AddonKnowledgeBase) DetectAddon(image string) (*Addon, string, bool) {
+	for i := range kb.addons {
+		addon := &kb.addons[i]
+		for _, pattern := range addon.ImagePatterns {
+			if strings.Contains(image, pattern) {
+				// drop build suffixes such as v1.11.1-eksbuild.4
+				version, _, _ := strings.Cut(strings.TrimPrefix(imageTag(image), "v"), "-")
+				return addon, version, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// VersionFor returns the addon version shipped with a Kubernetes version, or "" when unknown
+func (a *Addon) VersionFor(kubernetesVersion string) string {
+	if a.FollowsKubernetes {
+		return strings.TrimPrefix(kubernetesVersion, "v")
+	}
+
+	version := ""
+	for _, v := range a.Versions {
+		if compareVersions(v.Kubernetes, minorVersion(kubernetesVersion)) <= 0 {
+			version = v.Version
+		}
+	}
+	return version
+}
+
+// IsManagedBy checks whether a platform upgrades the addon with the control plane
+func (a *Addon) IsManagedBy(platform string) bool {
+	for _, p := range a.ManagedBy {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingPlatforms returns the node platforms the addon publishes no images for
+func (a *Addon) MissingPlatforms(nodePlatforms []string) []string {
+	if len(a.Platforms) == 0 {
+		return nil
+	}
+	return MissingPlatforms(a.Platforms, RequiredPlatforms(nodePlatforms, a.Platforms))
+}
+
+// minorVersion trims a version to major.minor, e.g. "1.29.3" -> "1.29"
+func minorVersion(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
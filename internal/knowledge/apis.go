@@ -17,6 +17,8 @@ type APIDeprecation struct {
 	RemovedIn      string `json:"removedIn"`
 	ReplacementAPI string `json:"replacementAPI"`
 	MigrationNotes string `json:"migrationNotes"`
+	// DocsURL links to the section of the upstream deprecation guide covering the migration
+	DocsURL string `json:"docsUrl,omitempty"`
 }
 
 // APIKnowledgeBase manages API deprecation knowledge
@@ -0,0 +1,73 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// BehaviorChange represents a field of a resource whose behavior changes in a Kubernetes version,
+// while the API serving it stays
+type BehaviorChange struct {
+	FieldMatcher
+	ID             string `json:"id"`
+	ChangedIn      string `json:"changedIn"`
+	Description    string `json:"description"`
+	Recommendation string `json:"recommendation"`
+	DocsURL        string `json:"docsUrl,omitempty"`
+}
+
+// BehaviorKnowledgeBase manages Kubernetes behavior change knowledge
+type BehaviorKnowledgeBase struct {
+	changes []BehaviorChange
+}
+
+// BehaviorKnowledgeData represents the structure of behavior-changes.json
+type BehaviorKnowledgeData struct {
+	Changes []BehaviorChange `json:"changes"`
+}
+
+// NewBehaviorKnowledgeBase creates a new behavior change knowledge base
+func NewBehaviorKnowledgeBase() *BehaviorKnowledgeBase {
+	return &BehaviorKnowledgeBase{
+		changes: make([]BehaviorChange, 0),
+	}
+}
+
+// LoadFromFile loads behavior change data from a JSON file
+func (kb *BehaviorKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var behaviorData BehaviorKnowledgeData
+	if err := json.Unmarshal(data, &behaviorData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for _, change := range behaviorData.Changes {
+		if err := change.compile(); err != nil {
+			return fmt.Errorf("invalid behavior change %s: %w", change.ID, err)
+		}
+		kb.changes = append(kb.changes, change)
+	}
+
+	return nil
+}
+
+// Changes returns the behavior changes an upgrade from currentVersion to targetVersion crosses.
+// An empty or unknown current version crosses every change up to the target.
+func (kb *BehaviorKnowledgeBase) Changes(currentVersion, targetVersion string) []BehaviorChange {
+	var changes []BehaviorChange
+	for _, change := range kb.changes {
+		if !isVersionGreaterOrEqual(targetVersion, change.ChangedIn) {
+			continue
+		}
+		if currentVersion != "" && currentVersion != "unknown" && isVersionGreaterOrEqual(currentVersion, change.ChangedIn) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
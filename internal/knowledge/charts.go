@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -15,13 +16,69 @@ type ChartCompatibility struct {
 	MaxKubeVersion string   `json:"maxKubeVersion"`
 	CompatibleWith []string `json:"compatibleWith"`
 	KnownIssues    []string `json:"knownIssues"`
+	// Platforms the chart version's images are published for, e.g. "linux/arm64"; unknown when empty
+	Platforms []string `json:"platforms,omitempty"`
+	// Images the chart version deploys by default, for mirroring into air-gapped registries
+	Images []string `json:"images,omitempty"`
+	// APIs the chart version may render, including behind conditions, as group/version/kind,
+	// e.g. "policy/v1beta1/PodSecurityPolicy" or "v1/Service"
+	APIs []string `json:"apis,omitempty"`
+	// Values to set when upgrading to the chart version, e.g. because it changes a default that
+	// matters from some Kubernetes version on
+	Values []ValueOverride `json:"values,omitempty"`
 }
 
+// ValueOverride is a chart value suggested for an upgrade, e.g. podSecurityPolicy.enabled=false
+type ValueOverride struct {
+	Path   string      `json:"path"` // dotted path in the chart values, as for helm --set
+	Value  interface{} `json:"value"`
+	Reason string      `json:"reason"`
+	// MinKubeVersion limits the override to target versions from this one on; empty for all
+	MinKubeVersion string `json:"minKubeVersion,omitempty"`
+	// Advisory overrides are only reported, never added to generated commands, e.g. because
+	// setting them weakens security and only some installations need them
+	Advisory bool `json:"advisory,omitempty"`
+}
+
+// SetFlag formats the override as a shell-quoted helm upgrade flag
+func (v ValueOverride) SetFlag() string {
+	switch value := v.Value.(type) {
+	case string:
+		return "--set-string " + shellQuote(v.Path+"="+value)
+	case bool, float64, int:
+		return "--set " + shellQuote(fmt.Sprintf("%s=%v", v.Path, value))
+	default:
+		data, _ := json.Marshal(value)
+		return "--set-json " + shellQuote(v.Path+"="+string(data))
+	}
+}
+
+// shellQuote quotes a string as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// valuesFor returns the value overrides of the chart version that apply to a Kubernetes version
+func (c ChartCompatibility) valuesFor(kubeVersion string) []ValueOverride {
+	var values []ValueOverride
+	for _, value := range c.Values {
+		if value.MinKubeVersion == "" || compareVersions(value.MinKubeVersion, kubeVersion) <= 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// RemovedAPIsFunc returns the APIs a chart version emits that the target Kubernetes version removed
+type RemovedAPIsFunc func(chart ChartInfo, version ChartCompatibility) []string
+
 // ChartInfo represents a Helm chart with all its versions
 type ChartInfo struct {
-	ChartName  string               `json:"chartName"`
-	Repository string               `json:"repository"`
-	Versions   []ChartCompatibility `json:"versions"`
+	ChartName  string `json:"chartName"`
+	Repository string `json:"repository"`
+	// UpgradeNotes links to the chart's upgrade notes, e.g. its UPGRADING.md or changelog
+	UpgradeNotes string               `json:"upgradeNotes,omitempty"`
+	Versions     []ChartCompatibility `json:"versions"`
 }
 
 // ChartKnowledgeBase manages Helm chart compatibility knowledge
@@ -88,8 +145,21 @@ func (kb *ChartKnowledgeBase) CheckCompatibility(chartName, chartVersion, kubeVe
 	return true, nil
 }
 
-// FindCompatibleChartVersion finds a compatible chart version for target Kubernetes version
-func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersion, targetK8sVersion string) *ChartRecommendation {
+// HasChartVersion reports whether the knowledge base lists a version of a chart
+func (kb *ChartKnowledgeBase) HasChartVersion(chartName, chartVersion string) bool {
+	for _, compat := range kb.charts[chartName].Versions {
+		if compat.ChartVersion == chartVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCompatibleChartVersion finds a compatible chart version for target Kubernetes version, preferring
+// versions that emit no API removed in the target version, then versions whose images are published
+// for every node platform (e.g. "linux/arm64"). removedAPIs may be nil to skip the API check. A
+// compatible current version with known issues stays compatible, with the issues as caveats.
+func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersion, targetK8sVersion string, platforms []string, removedAPIs RemovedAPIsFunc) *ChartRecommendation {
 	chart, exists := kb.charts[chartName]
 	if !exists {
 		// Chart not in knowledge base
@@ -109,10 +179,10 @@ func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersi
 
 	for _, compat := range chart.Versions {
 		if compat.ChartVersion == currentVersion {
+			currentIssues = compat.KnownIssues
 			for _, compatVersion := range compat.CompatibleWith {
 				if normalizeVersion(compatVersion) == normalizedTarget {
 					currentCompatible = true
-					currentIssues = compat.KnownIssues
 					break
 				}
 			}
@@ -126,11 +196,13 @@ func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersi
 			CurrentVersion: currentVersion,
 			IsCompatible:   true,
 			Message:        "Current version is compatible",
+			UpgradeNotes:   chart.UpgradeNotes,
 		}
 	}
 
-	// Find the best compatible version
-	var bestVersion *ChartCompatibility
+	// Find the compatible versions without known issues, best first
+	required := chart.requiredPlatforms(platforms)
+	var candidates []*ChartCompatibility
 
 	for i := range chart.Versions {
 		compat := &chart.Versions[i]
@@ -153,12 +225,50 @@ func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersi
 			continue
 		}
 
-		// If this is the first compatible version or it's newer than the current best
-		if bestVersion == nil || compareVersions(compat.ChartVersion, bestVersion.ChartVersion) > 0 {
-			bestVersion = compat
+		candidates = append(candidates, compat)
+	}
+	// Versions with images for every node platform first, then the newest
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iMissing, jMissing := len(candidates[i].missingPlatforms(required)) > 0, len(candidates[j].missingPlatforms(required)) > 0
+		if iMissing != jMissing {
+			return jMissing
+		}
+		return compareVersions(candidates[i].ChartVersion, candidates[j].ChartVersion) > 0
+	})
+
+	// The first candidate emitting no removed API; checking may render the chart, so it stops there
+	var bestVersion *ChartCompatibility
+	var bestRemoved []string
+	for i, compat := range candidates {
+		var removed []string
+		if removedAPIs != nil {
+			removed = removedAPIs(chart, *compat)
+		}
+		if i == 0 {
+			bestVersion, bestRemoved = compat, removed
+		}
+		if len(removed) == 0 {
+			bestVersion, bestRemoved = compat, nil
+			break
 		}
 	}
 
+	if currentCompatible {
+		recommendation := &ChartRecommendation{
+			ChartName:      chartName,
+			CurrentVersion: currentVersion,
+			IsCompatible:   true,
+			Message:        "Current version is compatible with known issues",
+			KnownIssues:    currentIssues,
+			UpgradeNotes:   chart.UpgradeNotes,
+		}
+		// an upgrade is optional, so only a version without issues of its own is suggested
+		if bestVersion != nil && len(bestRemoved) == 0 && compareVersions(bestVersion.ChartVersion, currentVersion) > 0 {
+			recommendation.RecommendedVersion = bestVersion.ChartVersion
+		}
+		return recommendation
+	}
+
 	if bestVersion != nil {
 		return &ChartRecommendation{
 			ChartName:          chartName,
@@ -167,6 +277,11 @@ func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersi
 			RecommendedVersion: bestVersion.ChartVersion,
 			Message:            fmt.Sprintf("Upgrade required for Kubernetes %s", targetK8sVersion),
 			KnownIssues:        currentIssues,
+			MissingPlatforms:   bestVersion.missingPlatforms(required),
+			RecommendedImages:  bestVersion.Images,
+			RemovedAPIs:        bestRemoved,
+			SuggestedValues:    bestVersion.valuesFor(normalizedTarget),
+			UpgradeNotes:       chart.UpgradeNotes,
 		}
 	}
 
@@ -177,6 +292,7 @@ func (kb *ChartKnowledgeBase) FindCompatibleChartVersion(chartName, currentVersi
 		IsCompatible:   false,
 		Message:        fmt.Sprintf("No compatible version found for Kubernetes %s", targetK8sVersion),
 		KnownIssues:    currentIssues,
+		UpgradeNotes:   chart.UpgradeNotes,
 	}
 }
 
@@ -209,6 +325,12 @@ func (kb *ChartKnowledgeBase) GetRecommendedVersion(chartName, kubeVersion strin
 	return latestVersion
 }
 
+// CompareVersions compares two dotted version strings (a leading 'v' is ignored)
+// Returns: 1 if v1 > v2, -1 if v1 < v2, 0 if equal
+func CompareVersions(v1, v2 string) int {
+	return compareVersions(v1, v2)
+}
+
 // ChartRecommendation represents a recommendation for chart upgrade
 type ChartRecommendation struct {
 	ChartName          string
@@ -216,7 +338,68 @@ type ChartRecommendation struct {
 	RecommendedVersion string
 	IsCompatible       bool
 	Message            string
-	KnownIssues        []string
+	KnownIssues        []string        // of the current version; caveats when it is compatible
+	MissingPlatforms   []string        // node platforms the recommended version publishes no images for
+	RecommendedImages  []string        // images the recommended version deploys by default; unknown when empty
+	RemovedAPIs        []string        // APIs the recommended version still emits that the target version removed
+	SuggestedValues    []ValueOverride // values to set when upgrading to the recommended version
+	UpgradeNotes       string          // link to the chart's upgrade notes; empty when unknown
+}
+
+// requiredPlatforms narrows node platforms to the ones any version of the chart has to support
+func (c ChartInfo) requiredPlatforms(platforms []string) []string {
+	var published []string
+	for _, version := range c.Versions {
+		published = append(published, version.Platforms...)
+	}
+	return RequiredPlatforms(platforms, published)
+}
+
+// missingPlatforms returns the required platforms the chart version publishes no images for; none
+// when its platforms are unknown
+func (c *ChartCompatibility) missingPlatforms(required []string) []string {
+	if len(c.Platforms) == 0 {
+		return nil
+	}
+	return MissingPlatforms(c.Platforms, required)
+}
+
+// RequiredPlatforms narrows node platforms to the ones a component has to publish images for.
+// Windows nodes only count when the component publishes Windows images at all; Linux-only
+// components pin their pods to Linux.
+func RequiredPlatforms(nodePlatforms, published []string) []string {
+	windows := false
+	for _, platform := range published {
+		if strings.HasPrefix(platform, "windows/") {
+			windows = true
+		}
+	}
+
+	var required []string
+	for _, platform := range nodePlatforms {
+		if windows || !strings.HasPrefix(platform, "windows/") {
+			required = append(required, platform)
+		}
+	}
+	return required
+}
+
+// MissingPlatforms returns the required platforms not in a list of published ones
+func MissingPlatforms(published, required []string) []string {
+	var missing []string
+	for _, platform := range required {
+		found := false
+		for _, p := range published {
+			if p == platform {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, platform)
+		}
+	}
+	return missing
 }
 
 // compareVersions compares two version strings
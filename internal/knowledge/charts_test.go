@@ -0,0 +1,20 @@
+package knowledge
+
+import "testing"
+
+func TestValueOverrideSetFlag(t *testing.T) {
+	tests := []struct {
+		value ValueOverride
+		want  string
+	}{
+		{ValueOverride{Path: "podSecurityPolicy.enabled", Value: false}, "--set 'podSecurityPolicy.enabled=false'"},
+		{ValueOverride{Path: "controller.replicaCount", Value: float64(2)}, "--set 'controller.replicaCount=2'"},
+		{ValueOverride{Path: "controller.config.server-snippet", Value: "echo 'x'; rm -rf /"}, `--set-string 'controller.config.server-snippet=echo '\''x'\''; rm -rf /'`},
+		{ValueOverride{Path: "tolerations", Value: []interface{}{"a b"}}, `--set-json 'tolerations=["a b"]'`},
+	}
+	for _, tt := range tests {
+		if got := tt.value.SetFlag(); got != tt.want {
+			t.Errorf("SetFlag() = %s, want %s", got, tt.want)
+		}
+	}
+}
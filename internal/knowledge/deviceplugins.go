@@ -0,0 +1,87 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DevicePlugin describes a device plugin or GPU operator whose releases support a limited range of
+// Kubernetes versions
+type DevicePlugin struct {
+	Name          string   `json:"name"`
+	ImagePatterns []string `json:"imagePatterns"`
+	// ManagedBy is the device plugin that deploys and upgrades this one, e.g. the GPU operator
+	ManagedBy string `json:"managedBy,omitempty"`
+	// Resources are the extended resources the plugin advertises on nodes, e.g. nvidia.com/gpu
+	Resources []string `json:"resources"`
+	// RuntimeHandlers are the container runtime handlers RuntimeClasses select to use the devices
+	RuntimeHandlers []string          `json:"runtimeHandlers"`
+	Versions        KubeSupportMatrix `json:"versions"`
+	// Upgrade holds upgrade command templates, with {version}, {namespace}, {kind} and {workload}
+	// placeholders
+	Upgrade []string `json:"upgrade"`
+	Notes   string   `json:"notes"`
+	// DocsURL links to the plugin's upgrade guide
+	DocsURL string `json:"docsUrl,omitempty"`
+}
+
+// DevicePluginKnowledgeBase manages device plugin Kubernetes support ranges
+type DevicePluginKnowledgeBase struct {
+	plugins []DevicePlugin
+}
+
+// DevicePluginKnowledgeData represents the structure of device-plugins.json
+type DevicePluginKnowledgeData struct {
+	DevicePlugins []DevicePlugin `json:"devicePlugins"`
+}
+
+// NewDevicePluginKnowledgeBase creates a new device plugin knowledge base
+func NewDevicePluginKnowledgeBase() *DevicePluginKnowledgeBase {
+	return &DevicePluginKnowledgeBase{
+		plugins: make([]DevicePlugin, 0),
+	}
+}
+
+// LoadFromFile loads device plugin data from a JSON file
+func (kb *DevicePluginKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var pluginData DevicePluginKnowledgeData
+	if err := json.Unmarshal(data, &pluginData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	kb.plugins = append(kb.plugins, pluginData.DevicePlugins...)
+
+	return nil
+}
+
+// DetectDevicePlugin identifies a device plugin and its version from a container image, e.g.
+// "nvcr.io/nvidia/k8s-device-plugin:v0.14.1-ubi8" -> 0.14.1
+func (kb *DevicePluginKnowledgeBase) DetectDevicePlugin(image string) (*DevicePlugin, string, bool) {
+	for i := range kb.plugins {
+		plugin := &kb.plugins[i]
+		for _, pattern := range plugin.ImagePatterns {
+			if strings.Contains(image, pattern) {
+				version, _, _ := strings.Cut(strings.TrimPrefix(imageTag(image), "v"), "-")
+				return plugin, version, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// UsesRuntimeHandler checks whether RuntimeClasses with a handler depend on the plugin's devices
+func (p *DevicePlugin) UsesRuntimeHandler(handler string) bool {
+	for _, h := range p.RuntimeHandlers {
+		if h == handler {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package knowledge
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Field matches
+const (
+	FieldMatchPresent = "present" // any of the fields is set
+	FieldMatchAbsent  = "absent"  // none of the fields is set
+	FieldMatchPattern = "pattern" // a string or number field matches the pattern
+)
+
+// FieldMatcher selects resources of some kinds by fields of their spec. Fields are dotted paths
+// relative to the spec; "podSpec." stands for the spec of the kind's pod template and
+// "podMetadata." for its metadata, a "[]" suffix for every element of a list and a "{}" suffix for
+// the keys of a map, e.g. "podSpec.containers[].securityContext.seccompProfile" or
+// "podSpec.nodeSelector{}". A resource setting any of the Unless fields is never selected, e.g.
+// one choosing its seccomp profile explicitly.
+type FieldMatcher struct {
+	Kinds   []string `json:"kinds"`
+	Fields  []string `json:"fields"`
+	Match   string   `json:"match"`
+	Pattern string   `json:"pattern,omitempty"`
+	Unless  []string `json:"unless,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// compile checks the match and compiles its pattern
+func (m *FieldMatcher) compile() error {
+	switch m.Match {
+	case FieldMatchPresent, FieldMatchAbsent:
+	case FieldMatchPattern:
+		pattern, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		m.pattern = pattern
+	default:
+		return fmt.Errorf("unknown match %q", m.Match)
+	}
+	return nil
+}
+
+// MatchesValue checks a field value against the pattern
+func (m *FieldMatcher) MatchesValue(value string) bool {
+	return m.pattern != nil && m.pattern.MatchString(value)
+}
+
+// HasKind checks whether the matcher applies to a kind
+func (m *FieldMatcher) HasKind(kind string) bool {
+	for _, k := range m.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
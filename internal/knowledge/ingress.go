@@ -0,0 +1,127 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AnnotationChange represents an Ingress annotation that is removed or changes behavior in a controller version
+type AnnotationChange struct {
+	Annotation  string `json:"annotation"`
+	ChangedIn   string `json:"changedIn"`
+	Change      string `json:"change"` // "removed" or "behavior"
+	Replacement string `json:"replacement"`
+	Notes       string `json:"notes"`
+}
+
+// ControllerChartVersion maps a chart version to the controller version it ships
+type ControllerChartVersion struct {
+	ChartVersion      string `json:"chartVersion"`
+	ControllerVersion string `json:"controllerVersion"`
+}
+
+// IngressController represents annotation knowledge for a single ingress controller
+type IngressController struct {
+	Controller    string                   `json:"controller"`
+	Charts        []string                 `json:"charts"`
+	ImagePatterns []string                 `json:"imagePatterns"`
+	ChartVersions []ControllerChartVersion `json:"chartVersions"`
+	Annotations   []AnnotationChange       `json:"annotations"`
+}
+
+// IngressKnowledgeBase manages ingress controller annotation knowledge
+type IngressKnowledgeBase struct {
+	controllers []IngressController
+}
+
+// IngressKnowledgeData represents the structure of ingress-annotations.json
+type IngressKnowledgeData struct {
+	Controllers []IngressController `json:"controllers"`
+}
+
+// NewIngressKnowledgeBase creates a new ingress knowledge base
+func NewIngressKnowledgeBase() *IngressKnowledgeBase {
+	return &IngressKnowledgeBase{
+		controllers: make([]IngressController, 0),
+	}
+}
+
+// LoadFromFile loads ingress annotation data from a JSON file
+func (kb *IngressKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var ingressData IngressKnowledgeData
+	if err := json.Unmarshal(data, &ingressData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	kb.controllers = append(kb.controllers, ingressData.Controllers...)
+
+	return nil
+}
+
+// DetectController identifies an ingress controller and its version from a container image
+func (kb *IngressKnowledgeBase) DetectController(image string) (*IngressController, string, bool) {
+	for i := range kb.controllers {
+		controller := &kb.controllers[i]
+		for _, pattern := range controller.ImagePatterns {
+			if strings.Contains(image, pattern) {
+				return controller, imageTag(image), true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// ControllerVersionForChart returns the controller version shipped by a chart version
+func (c *IngressController) ControllerVersionForChart(chartName, chartVersion string) string {
+	if !c.HasChart(chartName) {
+		return ""
+	}
+	for _, v := range c.ChartVersions {
+		if compareVersions(v.ChartVersion, chartVersion) == 0 {
+			return v.ControllerVersion
+		}
+	}
+	return ""
+}
+
+// HasChart checks whether a chart installs this controller
+func (c *IngressController) HasChart(chartName string) bool {
+	for _, chart := range c.Charts {
+		if chart == chartName {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangesBetween returns annotation changes introduced after fromVersion up to and including toVersion
+func (c *IngressController) ChangesBetween(fromVersion, toVersion string) []AnnotationChange {
+	var changes []AnnotationChange
+	for _, change := range c.Annotations {
+		if compareVersions(change.ChangedIn, fromVersion) > 0 && compareVersions(change.ChangedIn, toVersion) <= 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// imageTag extracts the tag from an image reference, ignoring any digest
+func imageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// The tag follows the last colon after the last slash (registry ports contain colons too)
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon <= slash {
+		return ""
+	}
+	return image[colon+1:]
+}
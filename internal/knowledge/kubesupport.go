@@ -0,0 +1,105 @@
+package knowledge
+
+import (
+	"strconv"
+	"strings"
+)
+
+// KubeSupport is the range of Kubernetes versions a minor version of a component, e.g. a service
+// mesh or a device plugin, supports
+type KubeSupport struct {
+	Version        string `json:"version"`
+	MinKubeVersion string `json:"minKubeVersion"`
+	MaxKubeVersion string `json:"maxKubeVersion"`
+}
+
+// KubeSupportMatrix lists the Kubernetes versions supported by each minor version of a component,
+// oldest first
+type KubeSupportMatrix []KubeSupport
+
+// Supports returns whether a component version supports a Kubernetes version, and whether the
+// component version is known at all
+func (m KubeSupportMatrix) Supports(version, kubeVersion string) (supported, known bool) {
+	entry := m.entry(version)
+	if entry == nil {
+		return false, false
+	}
+	kube := minorVersion(kubeVersion)
+	return compareVersions(kube, entry.MinKubeVersion) >= 0 && compareVersions(kube, entry.MaxKubeVersion) <= 0, true
+}
+
+// Range returns the Kubernetes versions a component version supports
+func (m KubeSupportMatrix) Range(version string) (string, string) {
+	if entry := m.entry(version); entry != nil {
+		return entry.MinKubeVersion, entry.MaxKubeVersion
+	}
+	return "", ""
+}
+
+// Recommend returns the oldest component version newer than the installed one that supports the
+// target Kubernetes version, preferring one that also supports the current version, so the
+// component can be upgraded before the cluster. bothSupported reports whether it does.
+func (m KubeSupportMatrix) Recommend(version, currentKube, targetKube string) (recommended string, bothSupported bool) {
+	installed := minorVersion(version)
+	for _, entry := range m {
+		if compareVersions(entry.Version, installed) <= 0 {
+			continue
+		}
+		if supported, _ := m.Supports(entry.Version, targetKube); !supported {
+			continue
+		}
+		if current, _ := m.Supports(entry.Version, currentKube); current {
+			return entry.Version, true
+		}
+		if recommended == "" {
+			recommended = entry.Version
+		}
+	}
+	return recommended, false
+}
+
+// Hops returns the versions to upgrade through from the installed version to target when the
+// component supports skipping at most maxSkip minor versions at a time; target itself is not
+// included. It is empty when no limit applies or the jump is within it.
+func (m KubeSupportMatrix) Hops(version, target string, maxSkip int) []string {
+	if maxSkip <= 0 {
+		return nil
+	}
+	var hops []string
+	last, previous := minorVersion(version), ""
+	for _, entry := range m {
+		if compareVersions(entry.Version, last) <= 0 || compareVersions(entry.Version, target) > 0 {
+			continue
+		}
+		if minorDistance(last, entry.Version) > maxSkip && previous != "" {
+			hops = append(hops, previous)
+			last = previous
+		}
+		previous = entry.Version
+	}
+	return hops
+}
+
+// minorDistance returns the number of minor versions from one version to a later one of the same
+// major version
+func minorDistance(from, to string) int {
+	minor := func(version string) int {
+		parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+		if len(parts) < 2 {
+			return 0
+		}
+		n, _ := strconv.Atoi(parts[1])
+		return n
+	}
+	return minor(to) - minor(from)
+}
+
+func (m KubeSupportMatrix) entry(version string) *KubeSupport {
+	minor := minorVersion(version)
+	for i := range m {
+		if m[i].Version == minor {
+			return &m[i]
+		}
+	}
+	return nil
+}
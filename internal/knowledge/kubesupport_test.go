@@ -0,0 +1,29 @@
+package knowledge
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKubeSupportMatrixHops(t *testing.T) {
+	var matrix KubeSupportMatrix
+	for _, version := range []string{"1.18", "1.19", "1.20", "1.21", "1.22", "1.23", "1.24", "1.25"} {
+		matrix = append(matrix, KubeSupport{Version: version})
+	}
+	tests := []struct {
+		version, target string
+		maxSkip         int
+		want            []string
+	}{
+		{"1.18.3", "1.20", 2, nil},
+		{"1.18.3", "1.22", 2, []string{"1.20"}},
+		{"1.18.3", "1.25", 2, []string{"1.20", "1.22", "1.24"}},
+		{"1.19.0", "1.24", 2, []string{"1.21", "1.23"}},
+		{"1.18.3", "1.25", 0, nil},
+	}
+	for _, tt := range tests {
+		if got := matrix.Hops(tt.version, tt.target, tt.maxSkip); !slices.Equal(got, tt.want) {
+			t.Errorf("Hops(%s, %s, %d) = %v, want %v", tt.version, tt.target, tt.maxSkip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Mesh describes a service mesh whose control plane has to support the Kubernetes version
+type Mesh struct {
+	Name          string            `json:"name"`
+	Charts        []string          `json:"charts"`
+	ImagePatterns []string          `json:"imagePatterns"`
+	Versions      KubeSupportMatrix `json:"versions"`
+	// Canary is set for meshes upgraded by installing the new control plane as a revision next to
+	// the old one
+	Canary bool `json:"canary"`
+	// MaxMinorSkip is the most minor versions an upgrade may skip at a time; 0 means no limit
+	MaxMinorSkip int `json:"maxMinorSkip,omitempty"`
+	// Upgrade holds upgrade command templates, with {version} and {revision} placeholders
+	Upgrade []string `json:"upgrade"`
+	Notes   string   `json:"notes"`
+	// DocsURL links to the mesh's upgrade guide
+	DocsURL string `json:"docsUrl,omitempty"`
+}
+
+// MeshKnowledgeBase manages service mesh Kubernetes support ranges
+type MeshKnowledgeBase struct {
+	meshes []Mesh
+}
+
+// MeshKnowledgeData represents the structure of meshes.json
+type MeshKnowledgeData struct {
+	Meshes []Mesh `json:"meshes"`
+}
+
+// NewMeshKnowledgeBase creates a new mesh knowledge base
+func NewMeshKnowledgeBase() *MeshKnowledgeBase {
+	return &MeshKnowledgeBase{
+		meshes: make([]Mesh, 0),
+	}
+}
+
+// LoadFromFile loads mesh data from a JSON file
+func (kb *MeshKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var meshData MeshKnowledgeData
+	if err := json.Unmarshal(data, &meshData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	kb.meshes = append(kb.meshes, meshData.Meshes...)
+
+	return nil
+}
+
+// MeshForChart returns the mesh a Helm chart installs the control plane of
+func (kb *MeshKnowledgeBase) MeshForChart(chart string) (*Mesh, bool) {
+	for i := range kb.meshes {
+		for _, c := range kb.meshes[i].Charts {
+			if c == chart {
+				return &kb.meshes[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// DetectMesh identifies a mesh and its version from a control plane image
+func (kb *MeshKnowledgeBase) DetectMesh(image string) (*Mesh, string, bool) {
+	for i := range kb.meshes {
+		mesh := &kb.meshes[i]
+		for _, pattern := range mesh.ImagePatterns {
+			if strings.Contains(image, pattern) {
+				return mesh, MeshVersionOf(imageTag(image)), true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// MeshVersionOf normalizes a mesh release to its version, e.g. "stable-2.14.10" -> "2.14.10" and
+// "1.20.3-distroless" -> "1.20.3". Edge releases, e.g. "edge-24.5.1", have no mesh version.
+func MeshVersionOf(release string) string {
+	if strings.HasPrefix(release, "edge-") {
+		return ""
+	}
+	version := strings.TrimPrefix(strings.TrimPrefix(release, "stable-"), "v")
+	version, _, _ = strings.Cut(version, "-")
+	return version
+}
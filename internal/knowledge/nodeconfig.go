@@ -0,0 +1,78 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// NodeConfigDeprecation represents a deprecated kubelet or kube-proxy setting, e.g. a feature gate
+// removed after its feature went GA. Kinds are the components it applies to and Fields are paths in
+// their configuration file (KubeletConfiguration or KubeProxyConfiguration). Flag is the
+// command-line form node bootstrap templates use.
+type NodeConfigDeprecation struct {
+	FieldMatcher
+	ID           string `json:"id"`
+	Flag         string `json:"flag"`
+	DeprecatedIn string `json:"deprecatedIn,omitempty"`
+	RemovedIn    string `json:"removedIn,omitempty"`
+	Replacement  string `json:"replacement"`
+	Description  string `json:"description"`
+	DocsURL      string `json:"docsUrl,omitempty"`
+}
+
+// NodeConfigKnowledgeBase manages node component configuration deprecation knowledge
+type NodeConfigKnowledgeBase struct {
+	settings []NodeConfigDeprecation
+}
+
+// NodeConfigKnowledgeData represents the structure of node-config.json
+type NodeConfigKnowledgeData struct {
+	Settings []NodeConfigDeprecation `json:"settings"`
+}
+
+// NewNodeConfigKnowledgeBase creates a new node config knowledge base
+func NewNodeConfigKnowledgeBase() *NodeConfigKnowledgeBase {
+	return &NodeConfigKnowledgeBase{
+		settings: make([]NodeConfigDeprecation, 0),
+	}
+}
+
+// LoadFromFile loads node config deprecation data from a JSON file
+func (kb *NodeConfigKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var nodeConfigData NodeConfigKnowledgeData
+	if err := json.Unmarshal(data, &nodeConfigData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for _, setting := range nodeConfigData.Settings {
+		if err := setting.compile(); err != nil {
+			return fmt.Errorf("invalid node config setting %s: %w", setting.ID, err)
+		}
+		kb.settings = append(kb.settings, setting)
+	}
+
+	return nil
+}
+
+// Deprecations returns the node config deprecations in effect at a Kubernetes version
+func (kb *NodeConfigKnowledgeBase) Deprecations(kubeVersion string) []NodeConfigDeprecation {
+	var settings []NodeConfigDeprecation
+	for _, setting := range kb.settings {
+		if (setting.DeprecatedIn != "" && isVersionGreaterOrEqual(kubeVersion, setting.DeprecatedIn)) ||
+			setting.IsRemoved(kubeVersion) {
+			settings = append(settings, setting)
+		}
+	}
+	return settings
+}
+
+// IsRemoved checks if the setting is rejected or without effect at a Kubernetes version
+func (d *NodeConfigDeprecation) IsRemoved(kubeVersion string) bool {
+	return d.RemovedIn != "" && isVersionGreaterOrEqual(kubeVersion, d.RemovedIn)
+}
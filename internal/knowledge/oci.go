@@ -0,0 +1,223 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/signature"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// OCI artifact layout of a knowledge base: one layer per JSON file, titled with the file name
+const (
+	OCIScheme             = "oci://"
+	KnowledgeArtifactType = "application/vnd.kube-upgrade-advisor.knowledge.v1"
+	knowledgeLayerType    = "application/vnd.kube-upgrade-advisor.knowledge.layer.v1+json"
+)
+
+// Signatures created by 'cosign sign', stored under the sha256-<digest>.sig tag
+const (
+	cosignSignatureType       = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// cosignPayload is the simple signing payload cosign signs for a manifest digest
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// PushOCI publishes the JSON knowledge base files of a directory as an OCI artifact, e.g. to
+// oci://registry.internal/platform/kua-knowledge:2024-06, and returns the artifact digest
+func PushOCI(ctx context.Context, ref, dir string) (string, error) {
+	parsed, err := parseOCIReference(ref)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Reference == "" {
+		return "", fmt.Errorf("reference %q has no tag", ref)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list knowledge base files: %w", err)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no knowledge base files in %s", dir)
+	}
+
+	store, err := file.New(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file store: %w", err)
+	}
+	defer store.Close()
+
+	layers := make([]ocispec.Descriptor, 0, len(files))
+	for _, path := range files {
+		layer, err := store.Add(ctx, filepath.Base(path), knowledgeLayerType, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s: %w", path, err)
+		}
+		layers = append(layers, layer)
+	}
+
+	manifest, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, KnowledgeArtifactType, oras.PackManifestOptions{
+		Layers: layers,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to pack manifest: %w", err)
+	}
+	if err := store.Tag(ctx, manifest, parsed.Reference); err != nil {
+		return "", fmt.Errorf("failed to tag manifest: %w", err)
+	}
+
+	repo, err := newRepository(parsed)
+	if err != nil {
+		return "", err
+	}
+	if _, err := oras.Copy(ctx, store, parsed.Reference, repo, parsed.Reference, oras.DefaultCopyOptions); err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+
+	return manifest.Digest.String(), nil
+}
+
+// PullOCI fetches a knowledge base artifact into a directory and returns its digest. A non-empty
+// digest pins the artifact: it is pulled by digest instead of by tag, so a moved tag can't change
+// the knowledge base. With a verifier, the artifact must carry a cosign signature by its key.
+func PullOCI(ctx context.Context, ref, digest, dir string, verifier *signature.Verifier) (string, error) {
+	parsed, err := parseOCIReference(ref)
+	if err != nil {
+		return "", err
+	}
+	target := parsed.Reference
+	if digest != "" {
+		target = digest
+	}
+	if target == "" {
+		return "", fmt.Errorf("reference %q has no tag or digest", ref)
+	}
+
+	repo, err := newRepository(parsed)
+	if err != nil {
+		return "", err
+	}
+
+	// Resolve the artifact first, to verify it before writing any file
+	manifest, err := repo.Resolve(ctx, target)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if digest != "" && manifest.Digest.String() != digest {
+		return "", fmt.Errorf("digest mismatch for %s: pinned %s, got %s", ref, digest, manifest.Digest)
+	}
+	if verifier != nil {
+		if err := verifyCosignSignature(ctx, repo, manifest, verifier); err != nil {
+			return "", fmt.Errorf("failed to verify signature of %s: %w", ref, err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	store, err := file.New(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file store: %w", err)
+	}
+	defer store.Close()
+
+	if _, err := oras.Copy(ctx, repo, manifest.Digest.String(), store, "", oras.DefaultCopyOptions); err != nil {
+		return "", fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+
+	return manifest.Digest.String(), nil
+}
+
+// verifyCosignSignature checks that a signature stored by 'cosign sign --key' for the manifest
+// verifies with the key and names the manifest digest
+func verifyCosignSignature(ctx context.Context, repo *remote.Repository, manifest ocispec.Descriptor, verifier *signature.Verifier) error {
+	tag := fmt.Sprintf("%s-%s.sig", manifest.Digest.Algorithm(), manifest.Digest.Encoded())
+	_, data, err := oras.FetchBytes(ctx, repo, tag, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature %s: %w", tag, err)
+	}
+
+	var signatures ocispec.Manifest
+	if err := json.Unmarshal(data, &signatures); err != nil {
+		return fmt.Errorf("failed to unmarshal signature manifest: %w", err)
+	}
+
+	for _, layer := range signatures.Layers {
+		sig, found := layer.Annotations[cosignSignatureAnnotation]
+		if layer.MediaType != cosignSignatureType || !found {
+			continue
+		}
+		payload, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch signature payload: %w", err)
+		}
+		if err := verifier.Verify(payload, []byte(sig)); err != nil {
+			continue
+		}
+
+		var signed cosignPayload
+		if err := json.Unmarshal(payload, &signed); err != nil {
+			continue
+		}
+		if signed.Critical.Image.DockerManifestDigest == manifest.Digest.String() {
+			return nil
+		}
+	}
+
+	return errors.New("no signature verifies with the key")
+}
+
+// parseOCIReference parses an oci:// reference into registry, repository and tag or digest
+func parseOCIReference(ref string) (registry.Reference, error) {
+	if !strings.HasPrefix(ref, OCIScheme) {
+		return registry.Reference{}, fmt.Errorf("reference %q must start with %s", ref, OCIScheme)
+	}
+	parsed, err := registry.ParseReference(strings.TrimPrefix(ref, OCIScheme))
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("failed to parse reference %q: %w", ref, err)
+	}
+	return parsed, nil
+}
+
+// newRepository connects to a registry repository with the docker credentials of the user
+func newRepository(ref registry.Reference) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository client: %w", err)
+	}
+
+	credentialStore, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+	repo.Client = &auth.Client{
+		Client:     &http.Client{Transport: retry.NewTransport(network.Transport())},
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(credentialStore),
+	}
+
+	return repo, nil
+}
@@ -0,0 +1,83 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OperatorAPI represents a custom resource API version deprecated or removed by an operator release
+type OperatorAPI struct {
+	Operator       string   `json:"operator"`
+	Charts         []string `json:"charts"`
+	Group          string   `json:"group"`
+	Version        string   `json:"version"`
+	Kind           string   `json:"kind"`
+	DeprecatedIn   string   `json:"deprecatedIn"`
+	RemovedIn      string   `json:"removedIn"`
+	ReplacementAPI string   `json:"replacementAPI"`
+	MigrationNotes string   `json:"migrationNotes"`
+	// DocsURL links to the operator's migration guide for the API
+	DocsURL string `json:"docsUrl,omitempty"`
+}
+
+// OperatorKnowledgeBase manages operator custom resource API knowledge
+type OperatorKnowledgeBase struct {
+	apis map[string]OperatorAPI
+}
+
+// OperatorKnowledgeData represents the structure of operator-apis.json
+type OperatorKnowledgeData struct {
+	OperatorAPIs []OperatorAPI `json:"operatorAPIs"`
+}
+
+// NewOperatorKnowledgeBase creates a new operator knowledge base
+func NewOperatorKnowledgeBase() *OperatorKnowledgeBase {
+	return &OperatorKnowledgeBase{
+		apis: make(map[string]OperatorAPI),
+	}
+}
+
+// LoadFromFile loads operator API data from a JSON file
+func (kb *OperatorKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var operatorData OperatorKnowledgeData
+	if err := json.Unmarshal(data, &operatorData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for _, api := range operatorData.OperatorAPIs {
+		key := makeKey(api.Group, api.Version, api.Kind)
+		kb.apis[key] = api
+	}
+
+	return nil
+}
+
+// CheckAPI returns operator deprecation info if the custom resource API is deprecated
+func (kb *OperatorKnowledgeBase) CheckAPI(group, version, kind string) (*OperatorAPI, bool) {
+	api, found := kb.apis[makeKey(group, version, kind)]
+	if found {
+		return &api, true
+	}
+	return nil, false
+}
+
+// HasChart checks whether a chart installs this operator
+func (o *OperatorAPI) HasChart(chartName string) bool {
+	for _, chart := range o.Charts {
+		if chart == chartName {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRemoved checks if the API is no longer served by the given operator version
+func (o *OperatorAPI) IsRemoved(operatorVersion string) bool {
+	return o.RemovedIn != "" && operatorVersion != "" && compareVersions(operatorVersion, o.RemovedIn) >= 0
+}
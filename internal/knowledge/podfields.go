@@ -0,0 +1,89 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// PodFieldDeprecation represents a deprecated field or value of pod templates, e.g. the
+// beta.kubernetes.io/os node selector. RemovedIn is the version from which it is rejected or has
+// no effect; empty while it still works. Rewrites maps deprecated node label keys to their
+// replacements, which the fix command applies.
+type PodFieldDeprecation struct {
+	FieldMatcher
+	ID           string            `json:"id"`
+	DeprecatedIn string            `json:"deprecatedIn,omitempty"`
+	RemovedIn    string            `json:"removedIn,omitempty"`
+	Replacement  string            `json:"replacement"`
+	Rewrites     map[string]string `json:"rewrites,omitempty"`
+	Description  string            `json:"description"`
+	DocsURL      string            `json:"docsUrl,omitempty"`
+}
+
+// PodFieldKnowledgeBase manages pod template field deprecation knowledge
+type PodFieldKnowledgeBase struct {
+	deprecations []PodFieldDeprecation
+}
+
+// PodFieldKnowledgeData represents the structure of pod-fields.json
+type PodFieldKnowledgeData struct {
+	Deprecations []PodFieldDeprecation `json:"deprecations"`
+}
+
+// NewPodFieldKnowledgeBase creates a new pod field knowledge base
+func NewPodFieldKnowledgeBase() *PodFieldKnowledgeBase {
+	return &PodFieldKnowledgeBase{
+		deprecations: make([]PodFieldDeprecation, 0),
+	}
+}
+
+// LoadFromFile loads pod field deprecation data from a JSON file
+func (kb *PodFieldKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var podFieldData PodFieldKnowledgeData
+	if err := json.Unmarshal(data, &podFieldData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for _, deprecation := range podFieldData.Deprecations {
+		if err := deprecation.compile(); err != nil {
+			return fmt.Errorf("invalid pod field deprecation %s: %w", deprecation.ID, err)
+		}
+		kb.deprecations = append(kb.deprecations, deprecation)
+	}
+
+	return nil
+}
+
+// Deprecations returns the pod field deprecations in effect at a Kubernetes version
+func (kb *PodFieldKnowledgeBase) Deprecations(kubeVersion string) []PodFieldDeprecation {
+	var deprecations []PodFieldDeprecation
+	for _, deprecation := range kb.deprecations {
+		if deprecation.DeprecatedIn == "" || isVersionGreaterOrEqual(kubeVersion, deprecation.DeprecatedIn) ||
+			deprecation.IsRemoved(kubeVersion) {
+			deprecations = append(deprecations, deprecation)
+		}
+	}
+	return deprecations
+}
+
+// Rewrites returns the replacements of deprecated node label keys of every deprecation
+func (kb *PodFieldKnowledgeBase) Rewrites() map[string]string {
+	rewrites := make(map[string]string)
+	for _, deprecation := range kb.deprecations {
+		for label, replacement := range deprecation.Rewrites {
+			rewrites[label] = replacement
+		}
+	}
+	return rewrites
+}
+
+// IsRemoved checks if the field or value is rejected or without effect at a Kubernetes version
+func (d *PodFieldDeprecation) IsRemoved(kubeVersion string) bool {
+	return d.RemovedIn != "" && isVersionGreaterOrEqual(kubeVersion, d.RemovedIn)
+}
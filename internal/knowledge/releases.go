@@ -0,0 +1,88 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Release is a Kubernetes minor release and the end of its upstream patch support
+type Release struct {
+	Version   string `json:"version"`
+	EndOfLife string `json:"endOfLife"` // YYYY-MM-DD
+}
+
+// EOL parses the end of life date
+func (r Release) EOL() (time.Time, error) {
+	eol, err := time.Parse("2006-01-02", r.EndOfLife)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid end of life %q of %s: %w", r.EndOfLife, r.Version, err)
+	}
+	return eol, nil
+}
+
+// ReleaseKnowledgeBase manages the end of life dates of Kubernetes releases
+type ReleaseKnowledgeBase struct {
+	releases map[string]Release
+}
+
+// ReleaseKnowledgeData represents the structure of releases.json
+type ReleaseKnowledgeData struct {
+	Releases []Release `json:"releases"`
+}
+
+// NewReleaseKnowledgeBase creates a new release knowledge base
+func NewReleaseKnowledgeBase() *ReleaseKnowledgeBase {
+	return &ReleaseKnowledgeBase{
+		releases: make(map[string]Release),
+	}
+}
+
+// LoadFromFile loads release data from a JSON file
+func (kb *ReleaseKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var releaseData ReleaseKnowledgeData
+	if err := json.Unmarshal(data, &releaseData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	for _, release := range releaseData.Releases {
+		if err := kb.Set(release); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set adds a release or overrides its end of life, e.g. with the date of a managed offering
+func (kb *ReleaseKnowledgeBase) Set(release Release) error {
+	if _, err := release.EOL(); err != nil {
+		return err
+	}
+	release.Version = minorVersion(release.Version)
+	kb.releases[release.Version] = release
+	return nil
+}
+
+// Releases returns the releases oldest first
+func (kb *ReleaseKnowledgeBase) Releases() []Release {
+	releases := make([]Release, 0, len(kb.releases))
+	for _, release := range kb.releases {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return compareVersions(releases[i].Version, releases[j].Version) < 0
+	})
+	return releases
+}
+
+// Release looks up the release of a Kubernetes version, e.g. "v1.28.3"
+func (kb *ReleaseKnowledgeBase) Release(version string) (Release, bool) {
+	release, ok := kb.releases[minorVersion(version)]
+	return release, ok
+}
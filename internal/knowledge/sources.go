@@ -0,0 +1,76 @@
+package knowledge
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KnowledgeSource pins a knowledge base artifact to the digest it was pulled at
+type KnowledgeSource struct {
+	Ref      string    `yaml:"ref"`
+	Digest   string    `yaml:"digest"`
+	PulledAt time.Time `yaml:"pulledAt,omitempty"`
+}
+
+// KnowledgeSources is the config of pinned knowledge base artifacts
+type KnowledgeSources struct {
+	// VerifyKey is a public key artifacts must be signed with, e.g. cosign.pub
+	VerifyKey string            `yaml:"verifyKey,omitempty"`
+	Sources   []KnowledgeSource `yaml:"sources"`
+}
+
+// LoadKnowledgeSources loads pinned knowledge sources; a missing file has none
+func LoadKnowledgeSources(path string) (*KnowledgeSources, error) {
+	sources := &KnowledgeSources{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sources, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, sources); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+
+	return sources, nil
+}
+
+// Save writes the pinned knowledge sources to a file
+func (s *KnowledgeSources) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// Find returns the source pinned for a reference, or nil
+func (s *KnowledgeSources) Find(ref string) *KnowledgeSource {
+	for i := range s.Sources {
+		if s.Sources[i].Ref == ref {
+			return &s.Sources[i]
+		}
+	}
+	return nil
+}
+
+// Pin records the digest a reference was pulled at
+func (s *KnowledgeSources) Pin(ref, digest string, pulledAt time.Time) {
+	if source := s.Find(ref); source != nil {
+		source.Digest = digest
+		source.PulledAt = pulledAt
+		return
+	}
+	s.Sources = append(s.Sources, KnowledgeSource{Ref: ref, Digest: digest, PulledAt: pulledAt})
+}
@@ -0,0 +1,100 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// InTreePlugin represents an in-tree volume plugin that is migrated to CSI or removed
+type InTreePlugin struct {
+	Provisioner    string `json:"provisioner"`
+	VolumeSource   string `json:"volumeSource"`
+	CSIDriver      string `json:"csiDriver"`
+	MigratedIn     string `json:"migratedIn"`
+	RemovedIn      string `json:"removedIn"`
+	MigrationNotes string `json:"migrationNotes"`
+}
+
+// DeprecatedStorageAnnotation represents a deprecated storage-related annotation
+type DeprecatedStorageAnnotation struct {
+	Annotation     string `json:"annotation"`
+	DeprecatedIn   string `json:"deprecatedIn"`
+	Replacement    string `json:"replacement"`
+	MigrationNotes string `json:"migrationNotes"`
+}
+
+// StorageKnowledgeBase manages in-tree volume plugin and storage annotation knowledge
+type StorageKnowledgeBase struct {
+	plugins     map[string]InTreePlugin
+	annotations map[string]DeprecatedStorageAnnotation
+}
+
+// StorageKnowledgeData represents the structure of storage.json
+type StorageKnowledgeData struct {
+	InTreePlugins         []InTreePlugin                `json:"inTreePlugins"`
+	DeprecatedAnnotations []DeprecatedStorageAnnotation `json:"deprecatedAnnotations"`
+}
+
+// NewStorageKnowledgeBase creates a new storage knowledge base
+func NewStorageKnowledgeBase() *StorageKnowledgeBase {
+	return &StorageKnowledgeBase{
+		plugins:     make(map[string]InTreePlugin),
+		annotations: make(map[string]DeprecatedStorageAnnotation),
+	}
+}
+
+// LoadFromFile loads storage knowledge from a JSON file
+func (kb *StorageKnowledgeBase) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var storageData StorageKnowledgeData
+	if err := json.Unmarshal(data, &storageData); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for _, plugin := range storageData.InTreePlugins {
+		kb.plugins[plugin.Provisioner] = plugin
+	}
+	for _, annotation := range storageData.DeprecatedAnnotations {
+		kb.annotations[annotation.Annotation] = annotation
+	}
+
+	return nil
+}
+
+// CheckProvisioner returns in-tree plugin info if the provisioner is an in-tree plugin
+func (kb *StorageKnowledgeBase) CheckProvisioner(provisioner string) (*InTreePlugin, bool) {
+	plugin, found := kb.plugins[provisioner]
+	if found {
+		return &plugin, true
+	}
+	return nil, false
+}
+
+// CheckAnnotation returns deprecation info if the annotation is deprecated
+func (kb *StorageKnowledgeBase) CheckAnnotation(annotation string) (*DeprecatedStorageAnnotation, bool) {
+	dep, found := kb.annotations[annotation]
+	if found {
+		return &dep, true
+	}
+	return nil, false
+}
+
+// IsRemoved checks if the plugin is removed in the target Kubernetes version
+func (p *InTreePlugin) IsRemoved(targetVersion string) bool {
+	return p.RemovedIn != "" && isVersionGreaterOrEqual(targetVersion, p.RemovedIn)
+}
+
+// IsMigrated checks if CSI migration is enabled by default in the target Kubernetes version
+func (p *InTreePlugin) IsMigrated(targetVersion string) bool {
+	return p.MigratedIn != "" && isVersionGreaterOrEqual(targetVersion, p.MigratedIn)
+}
+
+// IsDeprecated checks if the annotation is deprecated in the target Kubernetes version
+func (a *DeprecatedStorageAnnotation) IsDeprecated(targetVersion string) bool {
+	return isVersionGreaterOrEqual(targetVersion, a.DeprecatedIn)
+}
@@ -0,0 +1,211 @@
+package manifests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultChartCacheTTL is how long a cached repository index is used before it is fetched again
+const DefaultChartCacheTTL = 24 * time.Hour
+
+// ChartCache keeps the index.yaml files of https chart repositories and the chart archives
+// fetched from them on disk, so analyses rendering the same charts again don't fetch them again.
+// Indexes are fetched again once older than TTL; an archive of a chart version doesn't change
+// and is kept. OCI charts are not cached.
+type ChartCache struct {
+	Dir     string
+	TTL     time.Duration
+	Refresh bool // fetch indexes and archives again, once per run, regardless of their age
+
+	mu        sync.Mutex // guards refreshed, as charts are rendered concurrently
+	refreshed map[string]bool
+}
+
+// NewChartCache creates a chart cache in a directory
+func NewChartCache(dir string, ttl time.Duration) *ChartCache {
+	return &ChartCache{Dir: dir, TTL: ttl, refreshed: make(map[string]bool)}
+}
+
+// DefaultChartCacheDir is the chart cache in the user's cache directory, e.g.
+// ~/.cache/kube-upgrade-advisor/charts
+func DefaultChartCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "kube-upgrade-advisor", "charts")
+}
+
+// chartIndex is the subset of a repository index.yaml needed to locate chart archives
+type chartIndex struct {
+	Entries map[string][]chartIndexEntry `yaml:"entries"`
+}
+
+// chartIndexEntry is a chart version of a repository index
+type chartIndexEntry struct {
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+}
+
+// findChartVersion returns the newest entry matching a version or a semver constraint, e.g.
+// "1.2.3" or "^1.2", as helm resolves --version. An empty version is the newest stable version;
+// prereleases only match a constraint that names one.
+func findChartVersion(entries []chartIndexEntry, version string) (chartIndexEntry, bool) {
+	for _, entry := range entries {
+		if version != "" && entry.Version == version && len(entry.URLs) > 0 {
+			return entry, true
+		}
+	}
+
+	if version == "" {
+		version = "*"
+	}
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return chartIndexEntry{}, false
+	}
+	var newest *semver.Version
+	var found chartIndexEntry
+	for _, entry := range entries {
+		v, err := semver.NewVersion(entry.Version)
+		if err != nil || len(entry.URLs) == 0 || !constraint.Check(v) {
+			continue
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest, found = v, entry
+		}
+	}
+	return found, newest != nil
+}
+
+// Archive returns the path of the cached archive of a chart version, fetching the repository index
+// and the archive as needed. The version may be a semver constraint and, when empty, is the newest
+// stable version in the index.
+func (c *ChartCache) Archive(ctx context.Context, repoURL, chart, version string) (string, error) {
+	repoURL = strings.TrimSuffix(repoURL, "/")
+	data, err := c.fetch(ctx, repoURL+"/index.yaml", filepath.Join(c.Dir, "index", cacheKey(repoURL)+".yaml"), c.TTL)
+	if err != nil {
+		return "", err
+	}
+	var index chartIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return "", fmt.Errorf("failed to parse index of %s: %w", repoURL, err)
+	}
+
+	entry, ok := findChartVersion(index.Entries[chart], version)
+	if !ok {
+		return "", fmt.Errorf("chart %s not found in %s", strings.TrimSpace(chart+" "+version), repoURL)
+	}
+	resolved, err := resolveChartURL(repoURL, entry.URLs[0])
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(c.Dir, "archives", cacheKey(resolved)+".tgz")
+	if _, err := c.fetch(ctx, resolved, path, 0); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fetch returns a cached file, fetching it again when missing, older than ttl (zero never
+// expires) or to be refreshed
+func (c *ChartCache) fetch(ctx context.Context, rawURL, path string, ttl time.Duration) ([]byte, error) {
+	refresh := c.Refresh && !c.isRefreshed(path)
+	if info, err := os.Stat(path); err == nil && !refresh && (ttl == 0 || time.Since(info.ModTime()) < ttl) {
+		return os.ReadFile(path)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, urlTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := network.Client(0).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	data, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+
+	if err := writeCacheFile(path, data); err != nil {
+		return nil, fmt.Errorf("failed to cache %s: %w", rawURL, err)
+	}
+	c.markRefreshed(path)
+	return data, nil
+}
+
+// isRefreshed reports whether a cache file was fetched in this run
+func (c *ChartCache) isRefreshed(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshed[path]
+}
+
+// markRefreshed records that a cache file was fetched in this run
+func (c *ChartCache) markRefreshed(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshed == nil {
+		c.refreshed = make(map[string]bool)
+	}
+	c.refreshed[path] = true
+}
+
+// writeCacheFile replaces a cache file atomically, so a concurrent run never reads a partial file
+func writeCacheFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fetch-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// resolveChartURL resolves an archive URL of an index, which may be relative to the repository
+func resolveChartURL(repoURL, archiveURL string) (string, error) {
+	base, err := url.Parse(repoURL + "/")
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL %s: %w", repoURL, err)
+	}
+	ref, err := url.Parse(archiveURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart URL %s: %w", archiveURL, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func cacheKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
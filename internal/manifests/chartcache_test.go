@@ -0,0 +1,96 @@
+package manifests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// chartRepoIndex lists ingress-nginx versions out of order, with a prerelease newer than all
+const chartRepoIndex = `apiVersion: v1
+entries:
+  ingress-nginx:
+    - version: 4.7.1
+      urls: [charts/ingress-nginx-4.7.1.tgz]
+    - version: 4.8.0-beta.0
+      urls: [charts/ingress-nginx-4.8.0-beta.0.tgz]
+    - version: 4.7.3
+      urls: [charts/ingress-nginx-4.7.3.tgz]
+    - version: 3.40.0
+      urls: [charts/ingress-nginx-3.40.0.tgz]
+`
+
+// serveChartRepo serves chartRepoIndex and archives holding their file name
+func serveChartRepo(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			fmt.Fprint(w, chartRepoIndex)
+			return
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChartCacheArchiveVersions(t *testing.T) {
+	server := serveChartRepo(t)
+	cache := NewChartCache(t.TempDir(), DefaultChartCacheTTL)
+
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"", "/charts/ingress-nginx-4.7.3.tgz", false},
+		{"4.7.1", "/charts/ingress-nginx-4.7.1.tgz", false},
+		{"v4.7.1", "/charts/ingress-nginx-4.7.1.tgz", false},
+		{"^4.7", "/charts/ingress-nginx-4.7.3.tgz", false},
+		{"~4.7.0", "/charts/ingress-nginx-4.7.3.tgz", false},
+		{"< 4.0.0", "/charts/ingress-nginx-3.40.0.tgz", false},
+		{"4.8.0-beta.0", "/charts/ingress-nginx-4.8.0-beta.0.tgz", false},
+		{">= 4.8.0-0", "/charts/ingress-nginx-4.8.0-beta.0.tgz", false},
+		{"4.9.0", "", true},
+		{"not a version", "", true},
+	}
+	for _, tt := range tests {
+		path, err := cache.Archive(context.Background(), server.URL, "ingress-nginx", tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Archive(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Archive(%q) fetched %s, want %s", tt.version, data, tt.want)
+		}
+	}
+}
+
+func TestChartCacheRefreshConcurrently(t *testing.T) {
+	server := serveChartRepo(t)
+	cache := NewChartCache(t.TempDir(), DefaultChartCacheTTL)
+	cache.Refresh = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Archive(context.Background(), server.URL, "ingress-nginx", "^4.7"); err != nil {
+				t.Errorf("Archive: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
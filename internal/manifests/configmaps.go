@@ -0,0 +1,48 @@
+package manifests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configMapSkippedKeys are ConfigMap data keys that never hold manifests, although their content
+// may mention apiVersion or kind, e.g. the CoreDNS Corefile with its kubernetes plugin
+var configMapSkippedKeys = map[string]bool{
+	"Corefile": true,
+}
+
+// embeddedResources parses the manifests embedded in the data of ConfigMaps. Values that mention
+// neither apiVersion nor kind are skipped, and embedded ConfigMaps are not searched further.
+func (p *Parser) embeddedResources(resources []Resource) []Resource {
+	var embedded []Resource
+	for _, resource := range resources {
+		if resource.APIVersion != "v1" || resource.Kind != "ConfigMap" {
+			continue
+		}
+		data, _ := resource.Object["data"].(map[string]interface{})
+
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, _ := data[key].(string)
+			if configMapSkippedKeys[key] || !strings.Contains(value, "apiVersion") || !strings.Contains(value, "kind") {
+				continue
+			}
+			location := fmt.Sprintf("ConfigMap %s/%s[%s]", resource.Namespace, resource.Name, key)
+			if resource.Namespace == "" {
+				location = fmt.Sprintf("ConfigMap %s[%s]", resource.Name, key)
+			}
+			for _, nested := range p.parseData([]byte(value)) {
+				nested.EmbeddedIn = location
+				nested.SourceFile = resource.SourceFile
+				embedded = append(embedded, nested)
+			}
+		}
+	}
+	return embedded
+}
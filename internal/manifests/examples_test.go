@@ -0,0 +1,44 @@
+package manifests
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestDocsExamplesParse checks that the example files in docs/examples are valid YAML, so a
+// broken example fails the build instead of the user's kubectl apply
+func TestDocsExamplesParse(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "docs", "examples", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no examples in docs/examples")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			f, err := os.Open(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			decoder := yaml.NewDecoder(f)
+			for i := 0; ; i++ {
+				var document yaml.Node
+				err := decoder.Decode(&document)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("document %d: %v", i+1, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package manifests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"gopkg.in/yaml.v3"
+)
+
+// ReleaseSource is a Helm release as declared in Git by a Flux HelmRelease or an Argo CD
+// Application with a chart source
+type ReleaseSource struct {
+	Name             string // release name
+	Namespace        string // namespace the release is installed into
+	StorageNamespace string // namespace Helm stores the release in; Flux lets it differ from Namespace
+	Chart            string
+	Version          string // version pinned in Git; empty for the latest
+	RepoURL          string // chart repository, https:// or oci://
+	Values           map[string]interface{}
+	Origin           string // "flux" or "argocd"
+	SourceFile       string
+}
+
+// String formats the release as namespace/name
+func (s ReleaseSource) String() string {
+	return fmt.Sprintf("%s/%s", s.Namespace, s.Name)
+}
+
+// ReleaseSources finds the Helm releases declared by Flux HelmReleases and Argo CD Applications.
+// Flux chart repositories are resolved from the HelmRepository resources among resources. Values
+// from valuesFrom and Argo CD parameters are not resolved, and Applications sourcing a chart from
+// a Git path are skipped, since rendering them needs a checkout.
+func ReleaseSources(resources []Resource) []ReleaseSource {
+	repositories := make(map[string]string)
+	for _, resource := range resources {
+		if resource.Kind == "HelmRepository" && strings.HasPrefix(resource.APIVersion, "source.toolkit.fluxcd.io/") {
+			url := nestedString(resource.Object, "spec", "url")
+			if nestedString(resource.Object, "spec", "type") == "oci" && !strings.HasPrefix(url, "oci://") {
+				url = "oci://" + url
+			}
+			repositories[resource.Namespace+"/"+resource.Name] = url
+		}
+	}
+
+	var sources []ReleaseSource
+	for _, resource := range resources {
+		switch {
+		case resource.Kind == "HelmRelease" && strings.HasPrefix(resource.APIVersion, "helm.toolkit.fluxcd.io/"):
+			if source, ok := fluxReleaseSource(resource, repositories); ok {
+				sources = append(sources, source)
+			}
+		case resource.Kind == "Application" && strings.HasPrefix(resource.APIVersion, "argoproj.io/"):
+			if source, ok := argoReleaseSource(resource); ok {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].String() < sources[j].String()
+	})
+	return sources
+}
+
+// fluxReleaseSource reads a Flux HelmRelease; the release name defaults as Flux does, to the
+// target namespace and name
+func fluxReleaseSource(resource Resource, repositories map[string]string) (ReleaseSource, bool) {
+	spec, _ := resource.Object["spec"].(map[string]interface{})
+	chart := nestedString(spec, "chart", "spec", "chart")
+	if chart == "" {
+		return ReleaseSource{}, false
+	}
+
+	source := ReleaseSource{
+		Name:       nestedString(spec, "releaseName"),
+		Namespace:  nestedString(spec, "targetNamespace"),
+		Chart:      chart,
+		Version:    nestedString(spec, "chart", "spec", "version"),
+		Origin:     "flux",
+		SourceFile: resource.SourceFile,
+	}
+	source.Values, _ = spec["values"].(map[string]interface{})
+	if source.Name == "" {
+		source.Name = resource.Name
+		if source.Namespace != "" {
+			source.Name = source.Namespace + "-" + resource.Name
+		}
+	}
+	if source.Namespace == "" {
+		source.Namespace = resource.Namespace
+	}
+	// Flux stores releases in the HelmRelease's namespace by default
+	source.StorageNamespace = nestedString(spec, "storageNamespace")
+	if source.StorageNamespace == "" {
+		source.StorageNamespace = resource.Namespace
+	}
+
+	repoNamespace := nestedString(spec, "chart", "spec", "sourceRef", "namespace")
+	if repoNamespace == "" {
+		repoNamespace = resource.Namespace
+	}
+	source.RepoURL = repositories[repoNamespace+"/"+nestedString(spec, "chart", "spec", "sourceRef", "name")]
+	return source, source.RepoURL != ""
+}
+
+// argoReleaseSource reads an Argo CD Application with a chart source; of several sources, the
+// first with a chart is used
+func argoReleaseSource(resource Resource) (ReleaseSource, bool) {
+	spec, _ := resource.Object["spec"].(map[string]interface{})
+	appSource, _ := spec["source"].(map[string]interface{})
+	if sources, ok := spec["sources"].([]interface{}); ok {
+		for _, candidate := range sources {
+			if candidate, ok := candidate.(map[string]interface{}); ok && nestedString(candidate, "chart") != "" {
+				appSource = candidate
+				break
+			}
+		}
+	}
+	chart := nestedString(appSource, "chart")
+	if chart == "" {
+		return ReleaseSource{}, false
+	}
+
+	source := ReleaseSource{
+		Name:       nestedString(appSource, "helm", "releaseName"),
+		Namespace:  nestedString(spec, "destination", "namespace"),
+		Chart:      chart,
+		Version:    nestedString(appSource, "targetRevision"),
+		RepoURL:    nestedString(appSource, "repoURL"),
+		Origin:     "argocd",
+		SourceFile: resource.SourceFile,
+	}
+	if source.Name == "" {
+		source.Name = resource.Name
+	}
+	source.StorageNamespace = source.Namespace
+	// Argo CD takes OCI registries without a scheme
+	if !strings.Contains(source.RepoURL, "://") {
+		source.RepoURL = "oci://" + source.RepoURL
+	}
+
+	// valuesObject takes precedence over the values string, as in Argo CD
+	source.Values = make(map[string]interface{})
+	if values := nestedString(appSource, "helm", "values"); values != "" {
+		if err := yaml.Unmarshal([]byte(values), &source.Values); err != nil {
+			source.Values = make(map[string]interface{})
+		}
+	}
+	if values, ok := nestedValue(appSource, "helm", "valuesObject").(map[string]interface{}); ok {
+		for key, value := range values {
+			source.Values[key] = value
+		}
+	}
+	return source, true
+}
+
+// RenderRelease renders the chart of a release source at its pinned version with `helm template`,
+// as the GitOps controller would install it on a Kubernetes version; an empty one renders for
+// helm's default
+func (p *Parser) RenderRelease(source ReleaseSource, kubeVersion string) ([]Resource, error) {
+	values, err := os.CreateTemp("", "kua-values-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create values file: %w", err)
+	}
+	defer os.Remove(values.Name())
+	data, err := yaml.Marshal(source.Values)
+	if err == nil {
+		_, err = values.Write(data)
+	}
+	if closeErr := values.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write values file: %w", err)
+	}
+
+	args := []string{"--namespace", source.Namespace, "--values", values.Name()}
+	if kubeVersion != "" {
+		args = append(args, "--kube-version", kubeVersion)
+	}
+	return p.helmTemplate(source.Name, source.RepoURL, source.Chart, source.Version, args...)
+}
+
+// RenderChart renders a chart version with its default values as it would be installed on a
+// Kubernetes version, so templates checking .Capabilities.KubeVersion pick the same APIs
+func (p *Parser) RenderChart(repoURL, chart, version, kubeVersion string) ([]Resource, error) {
+	return p.helmTemplate(chart, repoURL, chart, version, "--kube-version", kubeVersion)
+}
+
+// helmTemplate runs `helm template` for a chart in a repository, https:// or oci://
+func (p *Parser) helmTemplate(name, repoURL, chart, version string, extra ...string) ([]Resource, error) {
+	if p.Offline {
+		return nil, fmt.Errorf("rendering chart %s fetches it from %s and can't run offline", chart, repoURL)
+	}
+
+	args := []string{"template", name}
+	switch {
+	case strings.HasPrefix(repoURL, "oci://"):
+		args = append(args, strings.TrimSuffix(repoURL, "/")+"/"+chart)
+	case p.ChartCache != nil:
+		archive, err := p.ChartCache.Archive(context.Background(), repoURL, chart, version)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, archive)
+		version = ""
+	default:
+		args = append(args, chart, "--repo", repoURL)
+	}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if network.Current().InsecureSkipTLSVerify {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+	args = append(args, extra...)
+
+	output, err := runRenderCommand("", nil, "helm", args...)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseStream(bytes.NewReader(output))
+}
+
+// nestedValue looks up a value by its keys in nested maps
+func nestedValue(object map[string]interface{}, keys ...string) interface{} {
+	var value interface{} = object
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// nestedString looks up a string by its keys in nested maps; empty when missing
+func nestedString(object map[string]interface{}, keys ...string) string {
+	value, _ := nestedValue(object, keys...).(string)
+	return value
+}
@@ -0,0 +1,161 @@
+package manifests
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+const gitopsManifest = `apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: HelmRepository
+metadata:
+  name: ingress-nginx
+  namespace: flux-system
+spec:
+  url: https://kubernetes.github.io/ingress-nginx
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: ingress
+  namespace: flux-system
+spec:
+  targetNamespace: ingress-nginx
+  chart:
+    spec:
+      chart: ingress-nginx
+      version: 4.7.1
+      sourceRef: {kind: HelmRepository, name: ingress-nginx}
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: metrics
+  namespace: flux-system
+spec:
+  releaseName: metrics
+  targetNamespace: monitoring
+  storageNamespace: monitoring
+  chart:
+    spec:
+      chart: ingress-nginx
+      sourceRef: {kind: HelmRepository, name: ingress-nginx}
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cert-manager
+  namespace: argocd
+spec:
+  destination: {namespace: cert-manager}
+  source:
+    repoURL: https://charts.jetstack.io
+    chart: cert-manager
+    targetRevision: v1.13.2
+`
+
+func TestReleaseSourcesNamespaces(t *testing.T) {
+	parser := NewParser()
+	parser.Progress = io.Discard
+	resources, err := parser.ParseYAML([]byte(gitopsManifest))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	sources := ReleaseSources(resources)
+	want := map[string]struct{ namespace, storage string }{
+		"ingress-nginx-ingress": {"ingress-nginx", "flux-system"},
+		"metrics":               {"monitoring", "monitoring"},
+		"cert-manager":          {"cert-manager", "cert-manager"},
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("found %d release sources, want %d: %+v", len(sources), len(want), sources)
+	}
+	for _, source := range sources {
+		w, ok := want[source.Name]
+		if !ok {
+			t.Errorf("unexpected release %s", source.Name)
+			continue
+		}
+		if source.Namespace != w.namespace || source.StorageNamespace != w.storage {
+			t.Errorf("release %s in %s stored in %s, want in %s stored in %s", source.Name, source.Namespace, source.StorageNamespace, w.namespace, w.storage)
+		}
+	}
+}
+
+// fakeHelm puts a helm on PATH that emits a ConfigMap holding its arguments and proxy
+func fakeHelm(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake helm is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\nprintf 'apiVersion: v1\\nkind: ConfigMap\\nmetadata:\\n  name: args\\ndata:\\n  args: \"%s\"\\n  proxy: \"%s\"\\n' \"$*\" \"$HTTPS_PROXY\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRenderReleaseKubeVersion(t *testing.T) {
+	fakeHelm(t)
+	parser := NewParser()
+	parser.Progress = io.Discard
+	source := ReleaseSource{Name: "ingress", Namespace: "ingress-nginx", Chart: "ingress-nginx", Version: "4.7.1", RepoURL: "https://kubernetes.github.io/ingress-nginx"}
+
+	tests := []struct {
+		kubeVersion string
+		want        string
+	}{
+		{"v1.27.4", "--kube-version v1.27.4"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		resources, err := parser.RenderRelease(source, tt.kubeVersion)
+		if err != nil {
+			t.Fatalf("RenderRelease: %v", err)
+		}
+		if len(resources) != 1 {
+			t.Fatalf("rendered %d resources, want 1", len(resources))
+		}
+		args := nestedString(resources[0].Object, "data", "args")
+		if !strings.Contains(args, "--namespace ingress-nginx") || !strings.Contains(args, "--version 4.7.1") {
+			t.Errorf("helm args %q lack the namespace or version", args)
+		}
+		if got := strings.Contains(args, "--kube-version"); got != (tt.want != "") || !strings.Contains(args, tt.want) {
+			t.Errorf("helm args %q, want %q", args, tt.want)
+		}
+	}
+}
+
+func TestRenderReleaseNetwork(t *testing.T) {
+	fakeHelm(t)
+	t.Setenv("HTTPS_PROXY", "")
+	opts := network.Options{Proxy: "http://proxy.example.com:3128", InsecureSkipTLSVerify: true}
+	if err := network.Configure(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { network.Configure(network.Options{}) })
+
+	parser := NewParser()
+	parser.Progress = io.Discard
+	source := ReleaseSource{Name: "ingress", Namespace: "ingress-nginx", Chart: "ingress-nginx", Version: "4.7.1", RepoURL: "https://kubernetes.github.io/ingress-nginx"}
+	resources, err := parser.RenderRelease(source, "")
+	if err != nil {
+		t.Fatalf("RenderRelease: %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("rendered %d resources, want 1", len(resources))
+	}
+	if proxy := nestedString(resources[0].Object, "data", "proxy"); proxy != opts.Proxy {
+		t.Errorf("helm ran with HTTPS_PROXY=%q, want %q", proxy, opts.Proxy)
+	}
+	if args := nestedString(resources[0].Object, "data", "args"); !strings.Contains(args, "--insecure-skip-tls-verify") {
+		t.Errorf("helm args %q lack --insecure-skip-tls-verify", args)
+	}
+}
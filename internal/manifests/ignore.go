@@ -0,0 +1,219 @@
+package manifests
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the file of gitignore-style patterns excluding files from manifest scanning. It
+// applies to the folder it is in and its subfolders, as a .gitignore does.
+const IgnoreFileName = ".kubeadvisorignore"
+
+// ignoreRule is a compiled gitignore-style pattern
+type ignoreRule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes what an earlier pattern excluded
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// compileIgnoreRule compiles a gitignore-style pattern. A pattern without a slash, other than a
+// trailing one, matches a name at any depth; otherwise it is relative to the folder of the pattern.
+// "*" and "?" do not match "/", "**" matches any number of folders.
+func compileIgnoreRule(pattern string) (ignoreRule, error) {
+	rule := ignoreRule{pattern: pattern}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	prefix := "^"
+	if !strings.Contains(pattern, "/") {
+		prefix = "^(.*/)?"
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return ignoreRule{}, fmt.Errorf("empty pattern %q", rule.pattern)
+	}
+
+	re, err := regexp.Compile(prefix + globToRegexp(pattern) + "$")
+	if err != nil {
+		return ignoreRule{}, fmt.Errorf("invalid pattern %q: %w", rule.pattern, err)
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// globToRegexp translates a glob to a regular expression matching slash-separated paths
+func globToRegexp(glob string) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			re.WriteString(regexp.QuoteMeta(glob[i+1 : i+2]))
+			i++
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String()
+}
+
+// readIgnoreFile reads the rules of an ignore file, skipping blank lines and # comments
+func readIgnoreFile(path string) ([]ignoreRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimRight(scanner.Text(), " \t\r")
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		rule, err := compileIgnoreRule(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// SetFilters sets gitignore-style patterns, relative to the scanned folder, excluding files and
+// folders from scanning and, when includes are given, limiting it to the files matching one
+func (p *Parser) SetFilters(excludes, includes []string) error {
+	p.excludes, p.includes = nil, nil
+	for _, pattern := range excludes {
+		rule, err := compileIgnoreRule(pattern)
+		if err != nil {
+			return err
+		}
+		p.excludes = append(p.excludes, rule)
+	}
+	for _, pattern := range includes {
+		rule, err := compileIgnoreRule(pattern)
+		if err != nil {
+			return err
+		}
+		p.includes = append(p.includes, rule)
+	}
+	return nil
+}
+
+// walkFilter decides which paths of a folder walk are skipped, by the parser's ignored folder
+// names, its filters and the ignore files found along the way
+type walkFilter struct {
+	parser *Parser
+	root   string
+	// rules holds the rules of the ignore file of each folder walked that has one
+	rules map[string][]ignoreRule
+}
+
+func (p *Parser) newWalkFilter(root string) *walkFilter {
+	return &walkFilter{parser: p, root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// skip reports whether a path of the walk is skipped. Call it for every folder before its
+// contents, so its ignore file is loaded.
+func (f *walkFilter) skip(path string, info os.FileInfo) bool {
+	if path == f.root {
+		f.load(path)
+		return false
+	}
+	if info.IsDir() && f.parser.shouldIgnore(info.Name()) {
+		return true
+	}
+
+	ignored := matchRules(f.parser.excludes, f.relative(f.root, path), info.IsDir(), false)
+	// Ignore files of the folders above the path, nearest last so its rules take precedence
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == f.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if rules := f.rules[dirs[i]]; len(rules) > 0 {
+			ignored = matchRules(rules, f.relative(dirs[i], path), info.IsDir(), ignored)
+		}
+	}
+	if ignored {
+		return true
+	}
+
+	if info.IsDir() {
+		f.load(path)
+		return false
+	}
+	return len(f.parser.includes) > 0 && !matchRules(f.parser.includes, f.relative(f.root, path), false, false)
+}
+
+// load reads the ignore file of a folder, if any
+func (f *walkFilter) load(dir string) {
+	path := filepath.Join(dir, IgnoreFileName)
+	rules, err := readIgnoreFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(f.parser.Progress, "Warning: failed to read %s: %v\n", path, err)
+		}
+		return
+	}
+	f.rules[dir] = rules
+}
+
+func (f *walkFilter) relative(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchRules applies rules in order to a relative path, the last matching rule deciding whether it
+// is matched; matched is the outcome of earlier rules
+func matchRules(rules []ignoreRule, rel string, isDir, matched bool) bool {
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			matched = !rule.negate
+		}
+	}
+	return matched
+}
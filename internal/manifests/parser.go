@@ -1,12 +1,17 @@
 package manifests
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
 	"gopkg.in/yaml.v3"
@@ -14,16 +19,52 @@ import (
 
 // Resource represents a Kubernetes resource
 type Resource struct {
-	APIVersion string                 `yaml:"apiVersion"`
-	Kind       string                 `yaml:"kind"`
-	Metadata   map[string]interface{} `yaml:"metadata"`
-	Spec       map[string]interface{} `yaml:"spec"`
+	APIVersion  string                 `yaml:"apiVersion"`
+	Kind        string                 `yaml:"kind"`
+	Metadata    map[string]interface{} `yaml:"metadata"`
+	Spec        map[string]interface{} `yaml:"spec"`
+	Status      map[string]interface{} `yaml:"status"`
+	Namespace   string                 `yaml:"-"`
+	Name        string                 `yaml:"-"`
+	Labels      map[string]string      `yaml:"-"`
+	Annotations map[string]string      `yaml:"-"`
+	Object      map[string]interface{} `yaml:"-"` // the full document, including fields without a typed counterpart
+	Node        *yaml.Node             `yaml:"-"` // the document as parsed, with comments and key order, for later conversion; nil for non-YAML sources
+	Templated   bool                   `yaml:"-"` // recovered from an un-rendered Go template, so a template condition may pick another API
+	EmbeddedIn  string                 `yaml:"-"` // the ConfigMap data key the resource was read from, e.g. "ConfigMap ns/name[app.yaml]"
+	SourceFile  string                 `yaml:"-"` // file the resource was parsed or rendered from, empty for stdin
 }
 
 // Parser handles parsing of Kubernetes manifests
 type Parser struct {
 	// Configuration options
 	IgnorePatterns []string
+	Renderers      []Renderer
+	Offline        bool      // renderers must not fetch remote charts or modules
+	Progress       io.Writer // receives progress and warnings; os.Stdout by default
+
+	// TolerateTemplates parses raw Go templates, e.g. the templates of a chart that is not rendered,
+	// by stubbing their actions; the resources recovered are marked Templated
+	TolerateTemplates bool
+	// ScanConfigMaps also parses manifests embedded in the data of ConfigMaps, e.g. the addon
+	// manifests a controller applies
+	ScanConfigMaps bool
+
+	// FollowSymlinks follows symlinked files and folders when walking a folder, skipping folders
+	// already visited so link cycles end
+	FollowSymlinks bool
+	// ReadArchives also parses the .tar.gz, .tgz and .zip archives found in a folder; an archive
+	// given as the path to parse is always read
+	ReadArchives bool
+	// Workers is the number of files of a folder parsed at once; GOMAXPROCS when zero
+	Workers int
+	// ChartCache caches the repository indexes and archives of rendered charts; nil lets helm
+	// fetch them on every render
+	ChartCache *ChartCache
+
+	// excludes and includes are set by SetFilters
+	excludes []ignoreRule
+	includes []ignoreRule
 }
 
 // NewParser creates a new manifest parser
@@ -35,52 +76,233 @@ func NewParser() *Parser {
 			"vendor",
 			".terraform",
 		},
+		Progress: os.Stdout,
 	}
 }
 
-// ParseFolder recursively parses all YAML files in a folder
+// EnableRenderers enables renderers by name so templated sources are rendered before parsing. Set
+// Offline first to reject renderers that need network access.
+func (p *Parser) EnableRenderers(names []string) error {
+	for _, name := range names {
+		renderer, err := NewRenderer(name, p)
+		if err != nil {
+			return err
+		}
+		p.Renderers = append(p.Renderers, renderer)
+	}
+	return nil
+}
+
+// ParseInput parses a manifest folder, a single file or archive, an https:// URL, or a YAML stream
+// from stdin when path is "-"
+func (p *Parser) ParseInput(path string) ([]Resource, error) {
+	return p.ParseInputContext(context.Background(), path)
+}
+
+// ParseInputContext is ParseInput stopping between files when ctx is done; the resources parsed
+// so far are returned with the error
+func (p *Parser) ParseInputContext(ctx context.Context, path string) ([]Resource, error) {
+	if path == "-" {
+		return p.ParseStream(os.Stdin)
+	}
+	if IsURL(path) {
+		return p.parseURL(ctx, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return p.parseFolder(ctx, path)
+	}
+	if renderer := p.rendererFor(path); renderer != nil {
+		resources, err := renderer.Render(path)
+		return withSourceFile(resources, path), err
+	}
+	if isArchive(path) {
+		return p.ParseArchive(path)
+	}
+	return p.ParseFile(path)
+}
+
+// ParseFolder recursively parses all YAML and JSON files in a folder
 func (p *Parser) ParseFolder(folderPath string) ([]Resource, error) {
-	var allResources []Resource
+	return p.parseFolder(context.Background(), folderPath)
+}
 
-	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+// parseFolder parses a folder, stopping between files when ctx is done. Ignored folders, the
+// patterns of ignore files and the parser's filters are honored. Files and archives are parsed by
+// a pool of workers while the folder is walked; resources and warnings are still reported in walk
+// order, so the result does not depend on scheduling.
+func (p *Parser) parseFolder(ctx context.Context, folderPath string) ([]Resource, error) {
+	filter := p.newWalkFilter(folderPath)
+
+	// results holds a slot per file in walk order, filled in by the workers
+	var results []*parseResult
+	jobs := make(chan parseJob)
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				p.runParseJob(ctx, job)
+			}
+		}()
+	}
+
+	err := p.walk(folderPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		// Skip directories
-		if info.IsDir() {
-			// Check if this directory should be ignored
-			if p.shouldIgnore(info.Name()) {
+		// Skip ignored directories and files
+		if filter.skip(path, info) {
+			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
+		if info.IsDir() {
+			return nil
+		}
 
-		// Only process YAML files
-		if !isYAMLFile(path) {
+		// Render templated sources first; renderers run one at a time, as they may run CLIs
+		if renderer := p.rendererFor(path); renderer != nil {
+			result := &parseResult{}
+			results = append(results, result)
+			resources, err := renderer.Render(path)
+			if err != nil {
+				fmt.Fprintf(&result.output, "Warning: failed to render %s with %s: %v\n", path, renderer.Name(), err)
+				return nil
+			}
+			result.resources = withSourceFile(resources, path)
 			return nil
 		}
 
-		// Parse the file
-		resources, err := p.ParseFile(path)
-		if err != nil {
-			// Log error but continue processing other files
-			fmt.Printf("Warning: failed to parse %s: %v\n", path, err)
+		archive := isArchive(path)
+		if archive && !p.ReadArchives {
+			return nil
+		}
+		// Only process YAML and JSON files
+		if !archive && !isManifestFile(path) {
 			return nil
 		}
 
-		allResources = append(allResources, resources...)
-		return nil
+		result := &parseResult{}
+		results = append(results, result)
+		select {
+		case jobs <- parseJob{path: path, archive: archive, result: result}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	})
+	close(jobs)
+	wg.Wait()
+
+	var allResources []Resource
+	for _, result := range results {
+		p.Progress.Write(result.output.Bytes())
+		allResources = append(allResources, result.resources...)
+	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return allResources, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		// files queued when ctx was done were not parsed
+		return allResources, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
 	return allResources, nil
 }
 
-// ParseFile parses a single YAML file which may contain multiple documents
+// parseJob is a file or archive of a folder to parse, and the slot for its result
+type parseJob struct {
+	path    string
+	archive bool
+	result  *parseResult
+}
+
+// parseResult is what parsing a file yielded, including the warnings to print in walk order
+type parseResult struct {
+	resources []Resource
+	output    bytes.Buffer
+}
+
+// runParseJob parses a file or archive, unless ctx is done
+func (p *Parser) runParseJob(ctx context.Context, job parseJob) {
+	if ctx.Err() != nil {
+		return
+	}
+	// A copy of the parser writes its warnings to the job's output
+	worker := *p
+	worker.Progress = &job.result.output
+
+	if job.archive {
+		resources, err := worker.ParseArchive(job.path)
+		if err != nil {
+			fmt.Fprintf(&job.result.output, "Warning: failed to read archive %s: %v\n", job.path, err)
+		}
+		job.result.resources = resources
+		return
+	}
+
+	resources, err := worker.ParseFile(job.path)
+	if err != nil {
+		// Log error but continue processing other files
+		fmt.Fprintf(&job.result.output, "Warning: failed to parse %s: %v\n", job.path, err)
+		return
+	}
+	job.result.resources = resources
+}
+
+// workers returns the number of files parsed at once
+func (p *Parser) workers() int {
+	if p.Workers > 0 {
+		return p.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// YAMLFiles lists the YAML files in a folder, skipping ignored directories and files, or the path
+// itself when it is a file
+func (p *Parser) YAMLFiles(path string) ([]string, error) {
+	var files []string
+	filter := p.newWalkFilter(path)
+
+	err := p.walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filter.skip(filePath, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if filePath == path || isYAMLFile(filePath) {
+			files = append(files, filePath)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", path, err)
+	}
+
+	return files, nil
+}
+
+// ParseFile parses a single YAML or JSON file which may contain multiple documents
 func (p *Parser) ParseFile(filePath string) ([]Resource, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -88,66 +310,298 @@ func (p *Parser) ParseFile(filePath string) ([]Resource, error) {
 	}
 	defer file.Close()
 
-	return p.ParseStream(file)
+	resources, err := p.ParseStream(file)
+	return withSourceFile(resources, filePath), err
+}
+
+// withSourceFile records the file resources came from, keeping sources set by nested renderers
+func withSourceFile(resources []Resource, path string) []Resource {
+	for i := range resources {
+		if resources[i].SourceFile == "" {
+			resources[i].SourceFile = path
+		}
+	}
+	return resources
 }
 
 // ParseYAML parses YAML manifest data
 func (p *Parser) ParseYAML(data []byte) ([]Resource, error) {
-	var resources []Resource
+	return p.ParseStream(bytes.NewReader(data))
+}
 
-	// Split by YAML document separator
-	documents := strings.Split(string(data), "\n---")
+// ParseStream parses a stream of YAML documents or JSON values, expanding the items of List
+// documents and of top-level arrays. A YAML document that fails to parse is skipped with a warning
+// and the documents after it are parsed; a JSON syntax error ends the stream, keeping the resources
+// parsed before. With TolerateTemplates, a stream holding Go
+// template actions is stubbed first; with ScanConfigMaps, manifests embedded in ConfigMaps are
+// parsed too.
+func (p *Parser) ParseStream(reader io.Reader) ([]Resource, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %w", err)
+	}
 
-	for i, doc := range documents {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
-			continue
-		}
+	resources := p.parseData(data)
+	if p.ScanConfigMaps {
+		resources = append(resources, p.embeddedResources(resources)...)
+	}
+	return resources, nil
+}
 
-		var resource Resource
-		err := yaml.Unmarshal([]byte(doc), &resource)
-		if err != nil {
-			// Skip invalid YAML
-			fmt.Printf("Warning: failed to parse document %d: %v\n", i, err)
-			continue
+// parseData parses JSON, or YAML that may be templated
+func (p *Parser) parseData(data []byte) []Resource {
+	if isJSON(data) {
+		if resources, ok := p.parseJSON(data); ok {
+			return resources
 		}
+		// a YAML flow mapping, e.g. "{apiVersion: v1, ...}"
+	}
+	if p.TolerateTemplates && isTemplated(data) {
+		return markTemplated(p.parseDocuments(stubTemplates(data)))
+	}
+	return p.parseDocuments(data)
+}
 
-		// Validate it's a Kubernetes resource
-		if !p.isKubernetesResource(resource) {
-			continue
+// parseDocuments decodes the Kubernetes resources of a YAML stream. Documents are decoded one by
+// one, as the decoder cannot resume after a syntax error, so a broken document only loses itself.
+func (p *Parser) parseDocuments(data []byte) []Resource {
+	var resources []Resource
+	for _, chunk := range splitDocuments(data) {
+		decoder := yaml.NewDecoder(bytes.NewReader(chunk.data))
+		for {
+			var document yaml.Node
+			err := decoder.Decode(&document)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fmt.Fprintf(p.Progress, "Warning: failed to parse the document at line %d: %v\n", chunk.line, err)
+				break
+			}
+			if len(document.Content) == 0 {
+				continue
+			}
+			shiftLines(&document, chunk.line-1)
+
+			// A document may also be an array of objects, as in JSON
+			root := document.Content[0]
+			objects := []*yaml.Node{root}
+			if root.Kind == yaml.SequenceNode {
+				objects = root.Content
+			}
+			for _, object := range objects {
+				resources = p.appendResources(resources, object)
+			}
 		}
+	}
 
-		resources = append(resources, resource)
+	return resources
+}
+
+// yamlDocument is a document of a YAML stream and the line it starts at
+type yamlDocument struct {
+	data []byte
+	line int
+}
+
+// splitDocuments splits a YAML stream at its "---" separator lines
+func splitDocuments(data []byte) []yamlDocument {
+	var documents []yamlDocument
+	start, startLine := 0, 1
+	for offset, line := 0, 1; offset < len(data); line++ {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data) - offset
+		}
+		text := bytes.TrimRight(data[offset:offset+end], "\r")
+		if bytes.Equal(text, []byte("---")) || bytes.HasPrefix(text, []byte("--- ")) || bytes.HasPrefix(text, []byte("---\t")) {
+			documents = append(documents, yamlDocument{data: data[start:offset], line: startLine})
+			// The separator stays with the document it opens, as it may carry content or a tag
+			start, startLine = offset, line
+		}
+		offset += end + 1
 	}
+	return append(documents, yamlDocument{data: data[start:], line: startLine})
+}
 
-	return resources, nil
+// shiftLines moves the nodes of a document decoded on its own to their lines in the stream
+func shiftLines(node *yaml.Node, offset int) {
+	if offset == 0 {
+		return
+	}
+	node.Line += offset
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
 }
 
-// ParseStream parses a stream of YAML documents
-func (p *Parser) ParseStream(reader io.Reader) ([]Resource, error) {
+// parseJSON decodes the Kubernetes resources of a stream of JSON objects or arrays of objects. It
+// returns false when the data does not start with a JSON value.
+func (p *Parser) parseJSON(data []byte) ([]Resource, bool) {
 	var resources []Resource
-	decoder := yaml.NewDecoder(reader)
+	decoder := json.NewDecoder(bytes.NewReader(data))
 
-	for {
-		var resource Resource
-		err := decoder.Decode(&resource)
+	for i := 0; ; i++ {
+		var value interface{}
+		err := decoder.Decode(&value)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			// Skip invalid documents
-			continue
+			if i == 0 {
+				return nil, false
+			}
+			fmt.Fprintf(p.Progress, "Warning: failed to parse JSON value %d: %v\n", i, err)
+			break
 		}
 
+		objects := []interface{}{value}
+		if array, ok := value.([]interface{}); ok {
+			objects = array
+		}
+		for _, object := range objects {
+			if _, ok := object.(map[string]interface{}); !ok {
+				continue
+			}
+			// Converted to a node, so List expansion and later conversion work as for YAML
+			var node yaml.Node
+			if err := node.Encode(object); err != nil {
+				continue
+			}
+			resources = p.appendResources(resources, &node)
+		}
+	}
+
+	return resources, true
+}
+
+// appendResources appends the Kubernetes resources of a mapping node
+func (p *Parser) appendResources(resources []Resource, node *yaml.Node) []Resource {
+	if node.Kind != yaml.MappingNode {
+		return resources
+	}
+	for _, resource := range resourcesFromNode(node) {
 		// Validate it's a Kubernetes resource
-		if !p.isKubernetesResource(resource) {
-			continue
+		if p.isKubernetesResource(resource) {
+			resources = append(resources, resource)
 		}
+	}
+	return resources
+}
+
+// isJSON reports whether data starts like a JSON object or array
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// resourcesFromNode converts a mapping node to a resource, or to the resources of its items when it
+// is a List (kind List, or a typed list such as DeploymentList whose items leave out their kind)
+func resourcesFromNode(node *yaml.Node) []Resource {
+	var obj map[string]interface{}
+	if err := node.Decode(&obj); err != nil {
+		return nil
+	}
 
+	kind, _ := obj["kind"].(string)
+	itemsNode := mappingValue(node, "items")
+	if !strings.HasSuffix(kind, "List") || itemsNode == nil || itemsNode.Kind != yaml.SequenceNode {
+		resource, ok := resourceFromObject(obj)
+		if !ok {
+			return nil
+		}
+		resource.Node = node
+		return []Resource{resource}
+	}
+
+	// Items of a typed list share its group version, e.g. apps/v1 for a DeploymentList
+	apiVersion, _ := obj["apiVersion"].(string)
+	itemKind := strings.TrimSuffix(kind, "List")
+
+	var resources []Resource
+	for _, item := range itemsNode.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		var itemObj map[string]interface{}
+		if err := item.Decode(&itemObj); err != nil {
+			continue
+		}
+		if kind != "List" {
+			if itemObj["apiVersion"] == nil {
+				itemObj["apiVersion"] = apiVersion
+			}
+			if itemObj["kind"] == nil {
+				itemObj["kind"] = itemKind
+			}
+		}
+		resource, ok := resourceFromObject(itemObj)
+		if !ok {
+			continue
+		}
+		resource.Node = item
 		resources = append(resources, resource)
 	}
+	return resources
+}
 
-	return resources, nil
+// mappingValue returns the value node of a key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// resourceFromObject converts a decoded manifest object into a Resource
+func resourceFromObject(obj map[string]interface{}) (Resource, bool) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		return Resource{}, false
+	}
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	spec, _ := obj["spec"].(map[string]interface{})
+	status, _ := obj["status"].(map[string]interface{})
+	resource := Resource{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Spec:       spec,
+		Status:     status,
+		Object:     obj,
+	}
+	resource.setMetadata(metadata)
+	return resource, true
+}
+
+// setMetadata sets the metadata of a resource and the name, namespace, labels and annotations in it
+func (r *Resource) setMetadata(metadata map[string]interface{}) {
+	r.Metadata = metadata
+	r.Name, _ = metadata["name"].(string)
+	r.Namespace, _ = metadata["namespace"].(string)
+	r.Labels = stringMap(metadata["labels"])
+	r.Annotations = stringMap(metadata["annotations"])
+}
+
+// stringMap converts a decoded map of labels or annotations, formatting values that YAML decoded as
+// numbers or booleans
+func stringMap(v interface{}) map[string]string {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		if s, ok := value.(string); ok {
+			result[key] = s
+		} else if value != nil {
+			result[key] = fmt.Sprint(value)
+		}
+	}
+	return result
 }
 
 // ExtractAPIVersions extracts all unique API versions from resources
@@ -172,11 +626,16 @@ func (p *Parser) ExtractAPIInfo(resources []Resource) []APIInfo {
 	for _, resource := range resources {
 		group, version := p.splitAPIVersion(resource.APIVersion)
 
-		apiInfos = append(apiInfos, APIInfo{
-			Group:   group,
-			Version: version,
-			Kind:    resource.Kind,
-		})
+		api := APIInfo{
+			Group:     group,
+			Version:   version,
+			Kind:      resource.Kind,
+			Templated: resource.Templated,
+		}
+		if resource.Namespace != "" {
+			api.Namespaces = []string{resource.Namespace}
+		}
+		apiInfos = append(apiInfos, api)
 	}
 
 	return apiInfos
@@ -229,28 +688,63 @@ func (p *Parser) shouldIgnore(name string) bool {
 	return false
 }
 
+// rendererFor returns the enabled renderer that handles a file, if any
+func (p *Parser) rendererFor(path string) Renderer {
+	for _, renderer := range p.Renderers {
+		if renderer.Matches(path) {
+			return renderer
+		}
+	}
+	return nil
+}
+
 // isYAMLFile checks if a file is a YAML file
 func isYAMLFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	return ext == ".yaml" || ext == ".yml"
 }
 
+// isManifestFile checks if a file may hold manifests: YAML, or JSON as written by kubectl -o json
+func isManifestFile(path string) bool {
+	return isYAMLFile(path) || strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
 // APIInfo represents API group/version/kind information
 type APIInfo struct {
-	Group   string
-	Version string
-	Kind    string
+	Group     string
+	Version   string
+	Kind      string
+	Templated bool // only found in un-rendered templates
+	// Namespaces the resources of the API set, sorted; resources without one are not counted
+	Namespaces []string
 }
 
-// StoreManifestsToInventory parses manifests from a folder and stores them to inventory
+// StoreManifestsToInventory parses manifests from a folder, file or stdin ("-") and stores their APIs
+// and resources to inventory; the resources replace those stored from the same files before, and
+// those of files below folderPath that are gone are deleted. When ctx is done mid-parse, the
+// manifests parsed so far are stored before returning the error, and nothing is deleted.
 func (p *Parser) StoreManifestsToInventory(ctx context.Context, folderPath, clusterID string, store *inventory.Store, source string) error {
 	// Parse all manifests in the folder
-	resources, err := p.ParseFolder(folderPath)
-	if err != nil {
-		return fmt.Errorf("failed to parse folder: %w", err)
+	resources, parseErr := p.ParseInputContext(ctx, folderPath)
+	if parseErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to parse manifests: %w", parseErr)
 	}
+	storeCtx := context.WithoutCancel(ctx)
 
-	fmt.Printf("Found %d Kubernetes resources in %s\n", len(resources), folderPath)
+	if folderPath == "-" {
+		fmt.Fprintf(p.Progress, "Found %d Kubernetes resources in stdin\n", len(resources))
+	} else {
+		fmt.Fprintf(p.Progress, "Found %d Kubernetes resources in %s\n", len(resources), folderPath)
+	}
+	embedded := 0
+	for _, resource := range resources {
+		if resource.EmbeddedIn != "" {
+			embedded++
+		}
+	}
+	if embedded > 0 {
+		fmt.Fprintf(p.Progress, "  %d of them embedded in ConfigMaps\n", embedded)
+	}
 
 	// Extract API info
 	apiInfos := p.ExtractAPIInfo(resources)
@@ -258,11 +752,11 @@ func (p *Parser) StoreManifestsToInventory(ctx context.Context, folderPath, clus
 	// Remove duplicates
 	uniqueAPIs := p.deduplicateAPIInfo(apiInfos)
 
-	fmt.Printf("Found %d unique API types\n", len(uniqueAPIs))
+	fmt.Fprintf(p.Progress, "Found %d unique API types\n", len(uniqueAPIs))
 
 	// Store each unique API to database
 	for _, api := range uniqueAPIs {
-		_, err := store.SaveManifestAPI(ctx, clusterID, api.Group, api.Version, api.Kind, source)
+		_, err := store.SaveManifestAPI(storeCtx, clusterID, api.Group, api.Version, api.Kind, source, api.Templated, api.Namespaces)
 		if err != nil {
 			return fmt.Errorf("failed to save manifest API %s/%s %s: %w", api.Group, api.Version, api.Kind, err)
 		}
@@ -271,28 +765,160 @@ func (p *Parser) StoreManifestsToInventory(ctx context.Context, folderPath, clus
 		if api.Group == "" {
 			gvk = api.Version
 		}
-		fmt.Printf("Stored API: %s %s\n", gvk, api.Kind)
+		if api.Templated {
+			fmt.Fprintf(p.Progress, "Stored API: %s %s (from templates)\n", gvk, api.Kind)
+		} else {
+			fmt.Fprintf(p.Progress, "Stored API: %s %s\n", gvk, api.Kind)
+		}
 	}
 
+	entries := p.ToResourceEntries(resources)
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, entry := range entries {
+		if !seen[entry.Source] {
+			seen[entry.Source] = true
+			files = append(files, entry.Source)
+		}
+	}
+	// Once the whole root is parsed, files stored from it before but gone now are pruned too
+	if parseErr == nil && folderPath != "-" {
+		stored, err := store.ResourceFiles(storeCtx, clusterID, inventory.ResourceFilter{Source: source})
+		if err != nil {
+			return err
+		}
+		for _, file := range stored {
+			if !seen[file] && underRoot(file, folderPath) {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) > 0 {
+		filter := inventory.ResourceFilter{Source: source, Files: files}
+		if err := store.ReplaceResources(storeCtx, clusterID, filter, entries); err != nil {
+			return fmt.Errorf("failed to save manifest resources: %w", err)
+		}
+	}
+
+	if parseErr != nil {
+		return fmt.Errorf("failed to parse all manifests: %w", parseErr)
+	}
 	return nil
 }
 
-// deduplicateAPIInfo removes duplicate API info entries
+// underRoot reports whether a resource source lies in a parsed root: the file, folder or URL
+// itself, a file below the folder, or an archive member or ConfigMap of one of those
+func underRoot(source, root string) bool {
+	file, _, _ := strings.Cut(source, " (")
+	file, _, _ = strings.Cut(file, "!/")
+	if IsURL(root) {
+		return file == root
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(file))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// UniqueAPIs returns the distinct group, version and kind of the resources
+func (p *Parser) UniqueAPIs(resources []Resource) []APIInfo {
+	return p.deduplicateAPIInfo(p.ExtractAPIInfo(resources))
+}
+
+// deduplicateAPIInfo removes duplicate API info entries; an API is templated when every entry is
 func (p *Parser) deduplicateAPIInfo(apiInfos []APIInfo) []APIInfo {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	var unique []APIInfo
 
 	for _, api := range apiInfos {
 		key := fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)
-		if !seen[key] {
-			seen[key] = true
-			unique = append(unique, api)
+		if i, ok := seen[key]; ok {
+			unique[i].Templated = unique[i].Templated && api.Templated
+			unique[i].Namespaces = mergeNamespaces(unique[i].Namespaces, api.Namespaces)
+			continue
 		}
+		seen[key] = len(unique)
+		unique = append(unique, api)
 	}
 
 	return unique
 }
 
+// mergeNamespaces adds namespaces to a sorted list of distinct namespaces
+func mergeNamespaces(namespaces, add []string) []string {
+	for _, namespace := range add {
+		i := sort.SearchStrings(namespaces, namespace)
+		if i < len(namespaces) && namespaces[i] == namespace {
+			continue
+		}
+		namespaces = append(namespaces, "")
+		copy(namespaces[i+1:], namespaces[i:])
+		namespaces[i] = namespace
+	}
+	return namespaces
+}
+
+// ToResourceEntries converts parsed resources to inventory resource entries
+func (p *Parser) ToResourceEntries(resources []Resource) []inventory.ResourceEntry {
+	entries := make([]inventory.ResourceEntry, 0, len(resources))
+	for _, resource := range resources {
+		entries = append(entries, inventory.ResourceEntry{
+			APIVersion:  resource.APIVersion,
+			Kind:        resource.Kind,
+			Namespace:   resource.Namespace,
+			Name:        resource.Name,
+			Labels:      resource.Labels,
+			Annotations: resource.Annotations,
+			Templated:   resource.Templated,
+			Source:      resource.source(),
+			Line:        resource.line(),
+			Spec:        resource.Spec,
+			Owners:      resource.owners(),
+		})
+	}
+	return entries
+}
+
+// line returns the line of the resource's document, 0 for resources not parsed from YAML
+func (r *Resource) line() int {
+	if r.Node == nil {
+		return 0
+	}
+	return r.Node.Line
+}
+
+// owners returns the metadata.ownerReferences of the resource, e.g. of objects exported with kubectl
+func (r *Resource) owners() []inventory.OwnerReference {
+	refs, _ := r.Metadata["ownerReferences"].([]interface{})
+	owners := make([]inventory.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		fields, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		owner := inventory.OwnerReference{}
+		owner.APIVersion, _ = fields["apiVersion"].(string)
+		owner.Kind, _ = fields["kind"].(string)
+		owner.Name, _ = fields["name"].(string)
+		owner.UID, _ = fields["uid"].(string)
+		owner.Controller, _ = fields["controller"].(bool)
+		if owner.Kind != "" && owner.Name != "" {
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	return owners
+}
+
+// source describes where a resource was found, for attributing findings
+func (r *Resource) source() string {
+	if r.EmbeddedIn != "" {
+		return fmt.Sprintf("%s (%s)", r.SourceFile, r.EmbeddedIn)
+	}
+	return r.SourceFile
+}
+
 // GetResourcesByKind filters resources by kind
 func (p *Parser) GetResourcesByKind(resources []Resource, kind string) []Resource {
 	var filtered []Resource
@@ -0,0 +1,174 @@
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// benchDocument is a Deployment and an Ingress, formatted with file index, document index and team
+const benchDocument = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-%[1]d-%[2]d
+  namespace: team-%[3]d
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: app
+          image: registry.example.com/app:%[2]d
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: app-%[1]d-%[2]d
+  namespace: team-%[3]d
+spec:
+  rules:
+    - host: app-%[1]d.example.com
+      http:
+        paths:
+          - path: /
+            backend: {serviceName: app-%[1]d, servicePort: 8080}
+---
+`
+
+// generateMonorepo writes files of docs Deployment and Ingress pairs into nested team and app
+// folders, as in a monorepo
+func generateMonorepo(tb testing.TB, files, docs int) string {
+	tb.Helper()
+	dir := tb.TempDir()
+	for i := 0; i < files; i++ {
+		folder := filepath.Join(dir, fmt.Sprintf("team-%d", i%10), fmt.Sprintf("app-%d", i%50))
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		f, err := os.Create(filepath.Join(folder, fmt.Sprintf("manifest-%d.yaml", i)))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		for j := 0; j < docs; j++ {
+			fmt.Fprintf(f, benchDocument, i, j, i%10)
+		}
+		if err := f.Close(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// parseKeys parses a folder with a number of workers and returns the resources as source/kind/name
+func parseKeys(tb testing.TB, dir string, workers int) []string {
+	tb.Helper()
+	parser := NewParser()
+	parser.Progress = io.Discard
+	parser.Workers = workers
+	resources, err := parser.ParseFolder(dir)
+	if err != nil {
+		tb.Fatalf("ParseFolder: %v", err)
+	}
+	keys := make([]string, 0, len(resources))
+	for _, r := range resources {
+		keys = append(keys, r.SourceFile+" "+r.Kind+" "+r.Name)
+	}
+	return keys
+}
+
+func TestParseFolderDeterministic(t *testing.T) {
+	const files, docs = 200, 3
+	dir := generateMonorepo(t, files, docs)
+
+	want := parseKeys(t, dir, 1)
+	if len(want) != files*docs*2 {
+		t.Fatalf("got %d resources, want %d", len(want), files*docs*2)
+	}
+	for _, workers := range []int{2, 8, runtime.GOMAXPROCS(0)} {
+		for run := 0; run < 3; run++ {
+			if got := parseKeys(t, dir, workers); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%d workers yielded other resources or another order than 1 worker", workers)
+			}
+		}
+	}
+}
+
+func BenchmarkParseFolder(b *testing.B) {
+	dir := generateMonorepo(b, 1000, 4)
+	for _, workers := range []int{1, runtime.GOMAXPROCS(0)} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				parseKeys(b, dir, workers)
+			}
+		})
+	}
+}
+
+func TestUnderRoot(t *testing.T) {
+	tests := []struct {
+		source, root string
+		want         bool
+	}{
+		{"manifests/app.yaml", "manifests", true},
+		{"manifests/team/app.yaml", "./manifests/", true},
+		{"manifests/bundle.tgz!/app.yaml", "manifests", true},
+		{"manifests/addons.yaml (ConfigMap kube-system/addons)", "manifests", true},
+		{"app.yaml", ".", true},
+		{"manifests-old/app.yaml", "manifests", false},
+		{"../app.yaml", ".", false},
+		{"/srv/manifests/app.yaml", "manifests", false},
+		{"https://example.com/app.yaml", "https://example.com/app.yaml", true},
+		{"https://example.com/app.yaml", "https://example.com/other.yaml", false},
+	}
+	for _, tt := range tests {
+		if got := underRoot(tt.source, tt.root); got != tt.want {
+			t.Errorf("underRoot(%q, %q) = %v, want %v", tt.source, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestParseSkipsBrokenDocument(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Service
+metadata:
+  name: first
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: broken
+  labels: {app: [unclosed
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: last
+`
+	var progress bytes.Buffer
+	parser := NewParser()
+	parser.Progress = &progress
+
+	resources, err := parser.ParseYAML([]byte(manifest))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	var names []string
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"first", "last"}) {
+		t.Fatalf("parsed %v, want the documents around the broken one", names)
+	}
+	if line := resources[1].line(); line != 12 {
+		t.Errorf("last document at line %d, want 12", line)
+	}
+	if !strings.Contains(progress.String(), "Warning: failed to parse the document at line 5") {
+		t.Errorf("no warning for the broken document in %q", progress.String())
+	}
+}
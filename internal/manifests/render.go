@@ -0,0 +1,196 @@
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+// Renderer turns templated sources into plain Kubernetes resources before parsing
+type Renderer interface {
+	Name() string
+	Matches(path string) bool
+	Render(path string) ([]Resource, error)
+}
+
+// NewRenderer creates a renderer by name: jsonnet, cue or helmfile
+func NewRenderer(name string, parser *Parser) (Renderer, error) {
+	switch name {
+	case "jsonnet":
+		return &JsonnetRenderer{parser: parser}, nil
+	case "cue":
+		return &CueRenderer{parser: parser, rendered: make(map[string]bool)}, nil
+	case "helmfile":
+		if parser.Offline {
+			return nil, fmt.Errorf("renderer helmfile fetches charts from their repositories and can't run offline")
+		}
+		return &HelmfileRenderer{parser: parser}, nil
+	default:
+		return nil, fmt.Errorf("unknown renderer %q (supported: jsonnet, cue, helmfile)", name)
+	}
+}
+
+// JsonnetRenderer evaluates .jsonnet files with go-jsonnet
+type JsonnetRenderer struct {
+	parser *Parser
+}
+
+// Name returns the renderer name
+func (r *JsonnetRenderer) Name() string {
+	return "jsonnet"
+}
+
+// Matches checks if the file is a jsonnet entrypoint (libraries are only imported)
+func (r *JsonnetRenderer) Matches(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".jsonnet"
+}
+
+// Render evaluates the file, resolving imports relative to it and its vendor folder
+func (r *JsonnetRenderer) Render(path string) ([]Resource, error) {
+	dir := filepath.Dir(path)
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.FileImporter{JPaths: []string{dir, filepath.Join(dir, "vendor"), filepath.Join(dir, "lib")}})
+
+	output, err := vm.EvaluateFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate jsonnet: %w", err)
+	}
+
+	return r.parser.ParseJSONObjects([]byte(output))
+}
+
+// CueRenderer exports CUE packages with the cue CLI
+type CueRenderer struct {
+	parser   *Parser
+	rendered map[string]bool
+}
+
+// Name returns the renderer name
+func (r *CueRenderer) Name() string {
+	return "cue"
+}
+
+// Matches checks if the file is a CUE source file
+func (r *CueRenderer) Matches(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".cue"
+}
+
+// Render exports the package in the file's directory, once per directory
+func (r *CueRenderer) Render(path string) ([]Resource, error) {
+	dir := filepath.Dir(path)
+	if r.rendered[dir] {
+		return nil, nil
+	}
+	r.rendered[dir] = true
+
+	// Offline, modules must come from the local cache
+	var env []string
+	if r.parser.Offline {
+		env = append(env, "CUE_REGISTRY=none")
+	}
+	output, err := runRenderCommand(dir, env, "cue", "export", "--out", "json", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	return r.parser.ParseJSONObjects(output)
+}
+
+// HelmfileRenderer templates helmfile specs with the helmfile CLI
+type HelmfileRenderer struct {
+	parser *Parser
+}
+
+// Name returns the renderer name
+func (r *HelmfileRenderer) Name() string {
+	return "helmfile"
+}
+
+// Matches checks if the file is a helmfile spec
+func (r *HelmfileRenderer) Matches(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return name == "helmfile.yaml" || name == "helmfile.yml" || name == "helmfile.yaml.gotmpl"
+}
+
+// Render runs `helmfile template` and parses the resulting YAML stream
+func (r *HelmfileRenderer) Render(path string) ([]Resource, error) {
+	args := []string{"--file", filepath.Base(path), "template", "--skip-deps"}
+	if network.Current().InsecureSkipTLSVerify {
+		args = append(args, "--args", "--insecure-skip-tls-verify")
+	}
+	output, err := runRenderCommand(filepath.Dir(path), nil, "helmfile", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.parser.ParseStream(bytes.NewReader(output))
+}
+
+// runRenderCommand runs an external renderer in dir, with extra environment variables and the
+// configured proxy and CA bundle, and returns its stdout
+func runRenderCommand(dir string, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), network.Environ()...), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// ParseJSONObjects extracts resources from rendered JSON, which may be a single object,
+// an array, a List, or an object whose values are resources (as jsonnet and CUE commonly emit)
+func (p *Parser) ParseJSONObjects(data []byte) ([]Resource, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rendered JSON: %w", err)
+	}
+
+	var resources []Resource
+	p.collectObjects(value, &resources)
+	return resources, nil
+}
+
+// collectObjects walks rendered output and appends every Kubernetes resource found
+func (p *Parser) collectObjects(value interface{}, resources *[]Resource) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			p.collectObjects(item, resources)
+		}
+	case map[string]interface{}:
+		resource, ok := resourceFromObject(v)
+		if !ok {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				p.collectObjects(v[key], resources)
+			}
+			return
+		}
+		if items, isList := v["items"].([]interface{}); isList && strings.HasSuffix(resource.Kind, "List") {
+			p.collectObjects(items, resources)
+			return
+		}
+		if p.isKubernetesResource(resource) {
+			*resources = append(*resources, resource)
+		}
+	}
+}
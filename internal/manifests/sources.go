@@ -0,0 +1,241 @@
+package manifests
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+// maxManifestSize caps a file read from an archive or URL, so a bad source can't exhaust memory
+const maxManifestSize = 64 << 20
+
+// urlTimeout bounds fetching manifests from a URL
+const urlTimeout = time.Minute
+
+// IsURL reports whether a manifest path is a URL rather than a local path
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// isArchive reports whether a file is an archive of manifests
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".zip")
+}
+
+// parseURL fetches and parses raw manifests, or an archive of them, from an https:// URL
+func (p *Parser) parseURL(ctx context.Context, url string) ([]Resource, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("only https:// manifest URLs are supported: %s", url)
+	}
+	if p.Offline {
+		return nil, fmt.Errorf("fetching manifests from %s can't run offline", url)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, urlTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := network.Client(0).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	// Query strings such as ?raw=true do not change the file type
+	name := strings.SplitN(url, "?", 2)[0]
+	if isArchive(name) {
+		return p.parseArchive(bytes.NewReader(data), int64(len(data)), name, url)
+	}
+
+	resources, err := p.ParseStream(bytes.NewReader(data))
+	return withSourceFile(resources, url), err
+}
+
+// ParseArchive parses the manifest files in a .tar.gz, .tgz or .zip archive. Resources record
+// their source as "<archive>!/<path in archive>".
+func (p *Parser) ParseArchive(archivePath string) ([]Resource, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	return p.parseArchive(file, info.Size(), archivePath, archivePath)
+}
+
+// parseArchive parses the manifest files of an archive named name, recording source as its location
+func (p *Parser) parseArchive(r io.ReaderAt, size int64, name, source string) ([]Resource, error) {
+	var resources []Resource
+	add := func(entry string, rc io.Reader) error {
+		if !isManifestFile(entry) || p.ignoredInArchive(entry) {
+			return nil
+		}
+		data, err := readLimited(rc)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entry, err)
+		}
+		parsed, err := p.ParseStream(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", entry, err)
+		}
+		resources = append(resources, withSourceFile(parsed, source+"!/"+entry)...)
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip archive: %w", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return resources, fmt.Errorf("failed to open %s: %w", f.Name, err)
+			}
+			err = add(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return resources, err
+			}
+		}
+		return resources, nil
+	}
+
+	gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return resources, nil
+		}
+		if err != nil {
+			return resources, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := add(header.Name, tr); err != nil {
+			return resources, err
+		}
+	}
+}
+
+// ignoredInArchive reports whether an archive entry is in an ignored folder, e.g. vendor/
+func (p *Parser) ignoredInArchive(entry string) bool {
+	for _, dir := range strings.Split(path.Dir(path.Clean(entry)), "/") {
+		if p.shouldIgnore(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// readLimited reads all of r, failing beyond maxManifestSize
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("larger than %d MiB", maxManifestSize>>20)
+	}
+	return data, nil
+}
+
+// walk is filepath.Walk, following symlinks when the parser is configured to. Symlinked files and
+// folders are visited under their link path; a folder reached again through a link, which would
+// loop forever, is skipped with a warning.
+func (p *Parser) walk(root string, fn filepath.WalkFunc) error {
+	if !p.FollowSymlinks {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	visited := make(map[string]bool)
+	err = p.walkFollowing(root, info, visited, fn)
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (p *Parser) walkFollowing(path string, info os.FileInfo, visited map[string]bool, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	if visited[target] {
+		fmt.Fprintf(p.Progress, "Warning: skipping %s: links to %s, which is already scanned\n", path, target)
+		return nil
+	}
+	visited[target] = true
+
+	if err := fn(path, info, nil); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := os.Stat(child)
+		if err != nil {
+			// e.g. a dangling symlink
+			fmt.Fprintf(p.Progress, "Warning: skipping %s: %v\n", child, err)
+			continue
+		}
+		err = p.walkFollowing(child, childInfo, visited, fn)
+		if err == filepath.SkipDir {
+			if !childInfo.IsDir() {
+				// as for filepath.Walk, SkipDir on a file skips the rest of its folder
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
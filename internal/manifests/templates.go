@@ -0,0 +1,106 @@
+package manifests
+
+import (
+	"regexp"
+	"strings"
+)
+
+// templatePlaceholder replaces template expressions inside values, e.g. "name: {{ .Release.Name }}"
+const templatePlaceholder = "TEMPLATED"
+
+var (
+	// templateAction matches a Go template action, including trim markers and multi-line actions
+	templateAction = regexp.MustCompile(`(?s)\{\{-?.*?-?\}\}`)
+	// templateComment matches a template comment, which may contain "}}"
+	templateComment = regexp.MustCompile(`(?s)\{\{-?\s*/\*.*?\*/\s*-?\}\}`)
+)
+
+// isTemplated reports whether data contains Go template actions, as raw Helm chart templates do
+func isTemplated(data []byte) bool {
+	return templateAction.Match(data)
+}
+
+// stubTemplates turns a Go template into YAML that parses, well enough to recover the apiVersion
+// and kind of its resources. Lines holding nothing but actions ({{ if }}, {{ end }}, {{ include }}
+// and the like) are dropped, and actions inside a line are replaced by a placeholder. Only the
+// first branch of an if/with/range block is kept, so that alternative apiVersions picked by a
+// capability check do not clash as duplicate keys; named templates are dropped entirely.
+func stubTemplates(data []byte) []byte {
+	text := templateComment.ReplaceAllString(string(data), "")
+	// Keep actions on one line, so the lines can be classified
+	text = templateAction.ReplaceAllStringFunc(text, func(action string) string {
+		return strings.ReplaceAll(action, "\n", " ")
+	})
+
+	// skipping holds, per open block, whether its lines are dropped
+	var skipping []bool
+	dropped := func() bool {
+		for _, skip := range skipping {
+			if skip {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		actions := templateAction.FindAllString(line, -1)
+		inline := len(actions) == 0 || strings.TrimSpace(templateAction.ReplaceAllString(line, "")) != ""
+		switch {
+		case inline && !dropped():
+			out.WriteString(templateAction.ReplaceAllString(line, templatePlaceholder))
+			continue
+		case strings.HasSuffix(line, "\n"):
+			// Dropped lines are kept empty, so parse errors point near the right line
+			out.WriteString("\n")
+		}
+		if inline {
+			continue
+		}
+
+		// A line of actions only: follow the blocks it opens and closes
+		for _, action := range actions {
+			switch actionKeyword(action) {
+			case "if", "with", "range":
+				skipping = append(skipping, false)
+			case "define", "block":
+				skipping = append(skipping, true)
+			case "else":
+				if len(skipping) > 0 {
+					skipping[len(skipping)-1] = true
+				}
+			case "end":
+				if len(skipping) > 0 {
+					skipping = skipping[:len(skipping)-1]
+				}
+			}
+		}
+	}
+	return []byte(out.String())
+}
+
+// actionKeyword returns the first word of a template action, e.g. "if" for "{{- if .Values.x }}"
+func actionKeyword(action string) string {
+	action = strings.TrimPrefix(strings.TrimPrefix(action, "{{"), "-")
+	action = strings.TrimSuffix(strings.TrimSuffix(action, "}}"), "-")
+	fields := strings.Fields(action)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// markTemplated flags resources recovered from a stubbed template, dropping those whose apiVersion
+// or kind is itself templated
+func markTemplated(resources []Resource) []Resource {
+	kept := resources[:0]
+	for _, resource := range resources {
+		if strings.Contains(resource.APIVersion, templatePlaceholder) || strings.Contains(resource.Kind, templatePlaceholder) {
+			continue
+		}
+		resource.Templated = true
+		kept = append(kept, resource)
+	}
+	return kept
+}
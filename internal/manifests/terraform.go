@@ -0,0 +1,304 @@
+package manifests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// typedResourceAPIs maps typed Terraform kubernetes provider resources to the API they manage
+var typedResourceAPIs = map[string]struct {
+	apiVersion string
+	kind       string
+}{
+	"kubernetes_config_map":                        {"v1", "ConfigMap"},
+	"kubernetes_config_map_v1":                     {"v1", "ConfigMap"},
+	"kubernetes_secret":                            {"v1", "Secret"},
+	"kubernetes_secret_v1":                         {"v1", "Secret"},
+	"kubernetes_service":                           {"v1", "Service"},
+	"kubernetes_service_v1":                        {"v1", "Service"},
+	"kubernetes_namespace":                         {"v1", "Namespace"},
+	"kubernetes_namespace_v1":                      {"v1", "Namespace"},
+	"kubernetes_deployment":                        {"apps/v1", "Deployment"},
+	"kubernetes_deployment_v1":                     {"apps/v1", "Deployment"},
+	"kubernetes_stateful_set":                      {"apps/v1", "StatefulSet"},
+	"kubernetes_stateful_set_v1":                   {"apps/v1", "StatefulSet"},
+	"kubernetes_daemonset":                         {"apps/v1", "DaemonSet"},
+	"kubernetes_daemon_set_v1":                     {"apps/v1", "DaemonSet"},
+	"kubernetes_job":                               {"batch/v1", "Job"},
+	"kubernetes_job_v1":                            {"batch/v1", "Job"},
+	"kubernetes_cron_job":                          {"batch/v1beta1", "CronJob"},
+	"kubernetes_cron_job_v1":                       {"batch/v1", "CronJob"},
+	"kubernetes_ingress":                           {"networking.k8s.io/v1beta1", "Ingress"},
+	"kubernetes_ingress_v1":                        {"networking.k8s.io/v1", "Ingress"},
+	"kubernetes_pod_security_policy":               {"policy/v1beta1", "PodSecurityPolicy"},
+	"kubernetes_pod_disruption_budget":             {"policy/v1beta1", "PodDisruptionBudget"},
+	"kubernetes_pod_disruption_budget_v1":          {"policy/v1", "PodDisruptionBudget"},
+	"kubernetes_horizontal_pod_autoscaler":         {"autoscaling/v1", "HorizontalPodAutoscaler"},
+	"kubernetes_horizontal_pod_autoscaler_v2beta2": {"autoscaling/v2beta2", "HorizontalPodAutoscaler"},
+	"kubernetes_horizontal_pod_autoscaler_v2":      {"autoscaling/v2", "HorizontalPodAutoscaler"},
+	"kubernetes_csi_driver":                        {"storage.k8s.io/v1beta1", "CSIDriver"},
+	"kubernetes_csi_driver_v1":                     {"storage.k8s.io/v1", "CSIDriver"},
+	"kubernetes_storage_class":                     {"storage.k8s.io/v1", "StorageClass"},
+	"kubernetes_storage_class_v1":                  {"storage.k8s.io/v1", "StorageClass"},
+	"kubernetes_priority_class":                    {"scheduling.k8s.io/v1", "PriorityClass"},
+	"kubernetes_priority_class_v1":                 {"scheduling.k8s.io/v1", "PriorityClass"},
+}
+
+// TerraformResult holds the Kubernetes resources and Helm releases found in Terraform data
+type TerraformResult struct {
+	Resources    []Resource
+	HelmReleases []inventory.HelmReleaseEntry
+}
+
+// terraformResource is a managed resource from either a raw state file or `terraform show -json` output
+type terraformResource struct {
+	Type   string
+	Name   string
+	Values map[string]interface{}
+}
+
+// terraformModule mirrors the module layout of `terraform show -json` for states and plans
+type terraformModule struct {
+	Resources []struct {
+		Mode   string                 `json:"mode"`
+		Type   string                 `json:"type"`
+		Name   string                 `json:"name"`
+		Values map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []terraformModule `json:"child_modules"`
+}
+
+// terraformFile covers the raw state format (version 4) and the JSON state/plan representations
+type terraformFile struct {
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+	Values *struct {
+		RootModule terraformModule `json:"root_module"`
+	} `json:"values"`
+	PlannedValues *struct {
+		RootModule terraformModule `json:"root_module"`
+	} `json:"planned_values"`
+}
+
+// TerraformScanner extracts Kubernetes resources and Helm releases from Terraform state or plan JSON
+type TerraformScanner struct {
+	parser   *Parser
+	Progress io.Writer // receives progress and warnings; os.Stdout by default
+}
+
+// NewTerraformScanner creates a new Terraform scanner
+func NewTerraformScanner() *TerraformScanner {
+	return &TerraformScanner{
+		parser:   NewParser(),
+		Progress: os.Stdout,
+	}
+}
+
+// ScanFile reads a terraform.tfstate file or the output of `terraform show -json`
+func (s *TerraformScanner) ScanFile(filePath string) (*TerraformResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return s.Scan(data)
+}
+
+// Scan extracts Kubernetes resources and Helm releases from Terraform JSON data
+func (s *TerraformScanner) Scan(data []byte) (*TerraformResult, error) {
+	var tf terraformFile
+	if err := json.Unmarshal(data, &tf); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal terraform JSON: %w", err)
+	}
+
+	var tfResources []terraformResource
+	switch {
+	case tf.PlannedValues != nil:
+		tfResources = collectModuleResources(tf.PlannedValues.RootModule)
+	case tf.Values != nil:
+		tfResources = collectModuleResources(tf.Values.RootModule)
+	default:
+		for _, r := range tf.Resources {
+			if r.Mode != "managed" {
+				continue
+			}
+			for _, instance := range r.Instances {
+				tfResources = append(tfResources, terraformResource{Type: r.Type, Name: r.Name, Values: instance.Attributes})
+			}
+		}
+	}
+
+	result := &TerraformResult{}
+	for _, r := range tfResources {
+		switch {
+		case r.Type == "helm_release":
+			if release, ok := helmReleaseFromTerraform(r.Values); ok {
+				result.HelmReleases = append(result.HelmReleases, release)
+			}
+		case r.Type == "kubernetes_manifest":
+			if manifest, ok := unwrapDynamic(r.Values["manifest"]).(map[string]interface{}); ok {
+				if resource, ok := resourceFromObject(manifest); ok && s.parser.isKubernetesResource(resource) {
+					result.Resources = append(result.Resources, resource)
+				}
+			}
+		case r.Type == "kubectl_manifest":
+			body, _ := r.Values["yaml_body"].(string)
+			resources, err := s.parser.ParseYAML([]byte(body))
+			if err != nil {
+				fmt.Fprintf(s.Progress, "Warning: failed to parse yaml_body of kubectl_manifest.%s: %v\n", r.Name, err)
+				continue
+			}
+			result.Resources = append(result.Resources, resources...)
+		default:
+			api, ok := typedResourceAPIs[r.Type]
+			if !ok {
+				continue
+			}
+			resource := Resource{APIVersion: api.apiVersion, Kind: api.kind}
+			resource.setMetadata(firstBlock(r.Values["metadata"]))
+			result.Resources = append(result.Resources, resource)
+		}
+	}
+
+	return result, nil
+}
+
+// StoreTerraformToInventory scans a Terraform state or plan file and stores its APIs, resources and
+// Helm releases
+func (s *TerraformScanner) StoreTerraformToInventory(ctx context.Context, filePath, clusterID string, store *inventory.Store) error {
+	result, err := s.ScanFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to scan terraform file: %w", err)
+	}
+
+	fmt.Fprintf(s.Progress, "Found %d Kubernetes resources and %d Helm releases in %s\n", len(result.Resources), len(result.HelmReleases), filePath)
+
+	uniqueAPIs := s.parser.deduplicateAPIInfo(s.parser.ExtractAPIInfo(result.Resources))
+	for _, api := range uniqueAPIs {
+		if _, err := store.SaveManifestAPI(ctx, clusterID, api.Group, api.Version, api.Kind, "terraform", false, api.Namespaces); err != nil {
+			return fmt.Errorf("failed to save manifest API %s/%s %s: %w", api.Group, api.Version, api.Kind, err)
+		}
+
+		gvk := api.Group + "/" + api.Version
+		if api.Group == "" {
+			gvk = api.Version
+		}
+		fmt.Fprintf(s.Progress, "Stored API: %s %s (terraform)\n", gvk, api.Kind)
+	}
+
+	// The resources of the file replace those stored from it by an earlier scan
+	entries := s.parser.ToResourceEntries(result.Resources)
+	for i := range entries {
+		entries[i].Source = filePath
+	}
+	filter := inventory.ResourceFilter{Source: "terraform", Files: []string{filePath}}
+	if err := store.ReplaceResources(ctx, clusterID, filter, entries); err != nil {
+		return fmt.Errorf("failed to save terraform resources: %w", err)
+	}
+
+	for _, release := range result.HelmReleases {
+		if _, err := store.SaveHelmRelease(ctx, clusterID, release); err != nil {
+			return fmt.Errorf("failed to save helm release %s: %w", release.Name, err)
+		}
+		fmt.Fprintf(s.Progress, "Stored Helm release: %s/%s (chart: %s-%s, terraform)\n", release.Namespace, release.Name, release.Chart, release.ChartVersion)
+	}
+
+	return nil
+}
+
+// collectModuleResources flattens managed resources of a module and its children
+func collectModuleResources(module terraformModule) []terraformResource {
+	var resources []terraformResource
+	for _, r := range module.Resources {
+		if r.Mode != "" && r.Mode != "managed" {
+			continue
+		}
+		resources = append(resources, terraformResource{Type: r.Type, Name: r.Name, Values: r.Values})
+	}
+	for _, child := range module.ChildModules {
+		resources = append(resources, collectModuleResources(child)...)
+	}
+	return resources
+}
+
+// helmReleaseFromTerraform builds a Helm release entry from helm_release attributes
+func helmReleaseFromTerraform(values map[string]interface{}) (inventory.HelmReleaseEntry, bool) {
+	name, _ := values["name"].(string)
+	chart, _ := values["chart"].(string)
+	chartVersion, _ := values["version"].(string)
+	repository, _ := values["repository"].(string)
+	namespace, _ := values["namespace"].(string)
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	// The metadata block holds what was actually deployed
+	appVersion := ""
+	metadata := firstBlock(values["metadata"])
+	if v, ok := metadata["version"].(string); ok && v != "" {
+		chartVersion = v
+	}
+	if v, ok := metadata["app_version"].(string); ok {
+		appVersion = v
+	}
+
+	// Charts may be given as repo/chart, a URL or a local path
+	chart = strings.TrimSuffix(path.Base(chart), ".tgz")
+
+	if name == "" || chart == "" || chartVersion == "" {
+		return inventory.HelmReleaseEntry{}, false
+	}
+
+	return inventory.HelmReleaseEntry{
+		Name:         name,
+		Namespace:    namespace,
+		Chart:        chart,
+		ChartVersion: chartVersion,
+		AppVersion:   appVersion,
+		Status:       "deployed",
+		ChartURL:     repository,
+		Source:       "terraform",
+	}, true
+}
+
+// unwrapDynamic unwraps dynamically typed attributes, which raw state stores as {"value": ..., "type": ...}
+func unwrapDynamic(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	if value, hasValue := m["value"]; hasValue {
+		if _, hasType := m["type"]; hasType {
+			return value
+		}
+	}
+	return m
+}
+
+// firstBlock returns the first element of a nested block list such as metadata
+func firstBlock(v interface{}) map[string]interface{} {
+	switch block := v.(type) {
+	case []interface{}:
+		if len(block) > 0 {
+			if m, ok := block[0].(map[string]interface{}); ok {
+				return m
+			}
+		}
+	case map[string]interface{}:
+		return block
+	}
+	return map[string]interface{}{}
+}
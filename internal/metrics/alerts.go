@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/fleet"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// Alert names; all start with KubeUpgrade, so routes can match them together
+const (
+	AlertCriticalFindings = "KubeUpgradeCriticalFindings"
+	AlertVersionEndOfLife = "KubeUpgradeVersionEndOfLife"
+)
+
+// Default alert lead times in days
+const (
+	defaultCriticalFindingsDays = 14
+	defaultEOLDays              = 30
+)
+
+// RuleGroup is a Prometheus rule group
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a Prometheus alerting rule
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// alertScope is the clusters of an environment, or the clusters of no environment
+type alertScope struct {
+	environment string
+	selector    string
+}
+
+// AlertRules generates alerting rules for the upgrade of a fleet to a target version, parameterized
+// by its planning config: critical findings that remain within alerts.criticalFindingsDays of an
+// environment's upgrade date, and clusters running a release within alerts.eolDays of its end of
+// life (or past it). Alerts carry the environment label of their cluster. Without releases, no end
+// of life rules are generated.
+func AlertRules(config *fleet.Config, targetVersion string, releases *knowledge.ReleaseKnowledgeBase) ([]RuleGroup, error) {
+	criticalDays := config.Alerts.CriticalFindingsDays
+	if criticalDays == 0 {
+		criticalDays = defaultCriticalFindingsDays
+	}
+	eolDays := config.Alerts.EOLDays
+	if eolDays == 0 {
+		eolDays = defaultEOLDays
+	}
+
+	var scopes []alertScope
+	var assigned []string
+	schedule := RuleGroup{Name: "kube-upgrade-advisor.schedule"}
+	for _, env := range config.Environments {
+		selector := fmt.Sprintf("cluster=~`%s`", clusterRegex(env.Clusters))
+		scopes = append(scopes, alertScope{environment: env.Name, selector: selector})
+		assigned = append(assigned, env.Clusters...)
+
+		date, scheduled, err := env.ScheduledUpgrade()
+		if err != nil {
+			return nil, fmt.Errorf("environment %q: %w", env.Name, err)
+		}
+		if !scheduled {
+			continue
+		}
+		from := date.AddDate(0, 0, -criticalDays)
+		schedule.Rules = append(schedule.Rules, Rule{
+			Alert: AlertCriticalFindings,
+			Expr: fmt.Sprintf("(sum by (cluster) (%s{%s,target=%q,severity=\"critical\"}) > 0)\nand on() (vector(time()) > %d)",
+				Findings, selector, targetVersion, from.Unix()),
+			Labels: map[string]string{"severity": "warning", "environment": env.Name},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("{{ $labels.cluster }} still has critical findings for Kubernetes %s", targetVersion),
+				"description": fmt.Sprintf("{{ $labels.cluster }} has {{ $value }} critical findings blocking the upgrade to %s, scheduled for the %s environment on %s. Run 'kube-upgrade-advisor impact --cluster {{ $labels.cluster }} --target %s' for details.",
+					targetVersion, env.Name, env.UpgradeDate, targetVersion),
+			},
+		})
+	}
+	// Clusters of no environment still get end of life alerts
+	unassigned := ""
+	if len(assigned) > 0 {
+		unassigned = fmt.Sprintf("cluster!~`%s`", clusterRegex(assigned))
+	}
+	scopes = append(scopes, alertScope{selector: unassigned})
+
+	groups := []RuleGroup{}
+	if len(schedule.Rules) > 0 {
+		groups = append(groups, schedule)
+	}
+	if releases == nil {
+		return groups, nil
+	}
+
+	for version, date := range config.Alerts.EOL {
+		if err := releases.Set(knowledge.Release{Version: version, EndOfLife: date}); err != nil {
+			return nil, fmt.Errorf("alerts.eol: %w", err)
+		}
+	}
+	eol := RuleGroup{Name: "kube-upgrade-advisor.eol"}
+	for _, release := range releases.Releases() {
+		date, err := release.EOL()
+		if err != nil {
+			return nil, err
+		}
+		from := date.AddDate(0, 0, -eolDays)
+		versionSelector := fmt.Sprintf("version=~`v?%s(\\..*)?`", regexp.QuoteMeta(release.Version))
+
+		for _, scope := range scopes {
+			selector := versionSelector
+			labels := map[string]string{"severity": "warning"}
+			if scope.selector != "" {
+				selector += "," + scope.selector
+			}
+			if scope.environment != "" {
+				labels["environment"] = scope.environment
+			}
+			eol.Rules = append(eol.Rules, Rule{
+				Alert:  AlertVersionEndOfLife,
+				Expr:   fmt.Sprintf("%s{%s}\nand on() (vector(time()) > %d)", ClusterInfo, selector, from.Unix()),
+				Labels: labels,
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("{{ $labels.cluster }} runs Kubernetes %s, end of life on %s", release.Version, release.EndOfLife),
+					"description": fmt.Sprintf("{{ $labels.cluster }} runs {{ $labels.version }}; Kubernetes %s gets no more patches after %s. Plan the upgrade with 'kube-upgrade-advisor plan'.", release.Version, release.EndOfLife),
+				},
+			})
+		}
+	}
+	return append(groups, eol), nil
+}
+
+// clusterRegex matches any of the cluster IDs exactly
+func clusterRegex(clusters []string) string {
+	quoted := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		quoted[i] = regexp.QuoteMeta(cluster)
+	}
+	return strings.Join(quoted, "|")
+}
+
+// PrometheusRule wraps rule groups into a prometheus-operator PrometheusRule
+func PrometheusRule(name, namespace string, groups []RuleGroup) any {
+	type metadata struct {
+		Name      string            `yaml:"name"`
+		Namespace string            `yaml:"namespace,omitempty"`
+		Labels    map[string]string `yaml:"labels"`
+	}
+	type spec struct {
+		Groups []RuleGroup `yaml:"groups"`
+	}
+	return struct {
+		APIVersion string   `yaml:"apiVersion"`
+		Kind       string   `yaml:"kind"`
+		Metadata   metadata `yaml:"metadata"`
+		Spec       spec     `yaml:"spec"`
+	}{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata:   metadata{Name: name, Namespace: namespace, Labels: map[string]string{"app.kubernetes.io/name": "kube-upgrade-advisor"}},
+		Spec:       spec{Groups: groups},
+	}
+}
+
+// RuleFile wraps rule groups into a Prometheus rule file
+func RuleFile(groups []RuleGroup) any {
+	return struct {
+		Groups []RuleGroup `yaml:"groups"`
+	}{Groups: groups}
+}
+
+// AlertmanagerRoute routes the alerts of each environment with a receiver to that receiver. The
+// routes are meant to be merged into an existing Alertmanager configuration defining the receivers.
+func AlertmanagerRoute(config *fleet.Config) (any, error) {
+	type route struct {
+		Receiver string   `yaml:"receiver"`
+		Matchers []string `yaml:"matchers"`
+	}
+	var routes []route
+	for _, env := range config.Environments {
+		if env.Receiver == "" {
+			continue
+		}
+		routes = append(routes, route{
+			Receiver: env.Receiver,
+			Matchers: []string{`alertname=~"KubeUpgrade.+"`, fmt.Sprintf("environment=%q", env.Name)},
+		})
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no environment has a receiver")
+	}
+
+	type tree struct {
+		Routes []route `yaml:"routes"`
+	}
+	return struct {
+		Route tree `yaml:"route"`
+	}{Route: tree{Routes: routes}}, nil
+}
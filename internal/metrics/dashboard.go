@@ -0,0 +1,233 @@
+package metrics
+
+import "fmt"
+
+// DashboardOptions customizes the generated dashboard
+type DashboardOptions struct {
+	Title string
+	UID   string
+}
+
+// severityColors colors the findings of each severity alike in all panels
+var severityColors = map[string]string{
+	"critical": "dark-red",
+	"high":     "orange",
+	"medium":   "yellow",
+	"low":      "blue",
+}
+
+// Dashboard renders a Grafana dashboard of the metrics: fleet readiness and findings by severity,
+// readiness per cluster over time, and a row of panels per cluster. The Prometheus data source, the
+// target version and the clusters are dashboard variables.
+func Dashboard(opts DashboardOptions) map[string]any {
+	if opts.Title == "" {
+		opts.Title = "Kubernetes Upgrade Readiness"
+	}
+	if opts.UID == "" {
+		opts.UID = "kube-upgrade-advisor"
+	}
+
+	selector := `cluster=~"$cluster",target="$target"`
+	d := &dashboard{}
+
+	d.add(statPanel("Fleet readiness", fmt.Sprintf(`avg(%s{%s})`, ReadinessScore, selector), readinessField()), 6, 4)
+	d.add(statPanel("Critical findings", fmt.Sprintf(`sum(%s{%s,severity="critical"})`, Findings, selector), countField("dark-red")), 6, 4)
+	d.add(statPanel("High findings", fmt.Sprintf(`sum(%s{%s,severity="high"})`, Findings, selector), countField("orange")), 6, 4)
+	d.add(statPanel("Clusters assessed", fmt.Sprintf(`count(%s{%s})`, ReadinessScore, selector), countField("text")), 6, 4)
+	d.newline()
+
+	d.add(map[string]any{
+		"type":    "barchart",
+		"title":   "Findings by severity per cluster",
+		"targets": []any{target(fmt.Sprintf(`sum by (cluster, severity) (%s{%s})`, Findings, selector), "{{severity}}", "table")},
+		"transformations": []any{
+			map[string]any{"id": "groupingToMatrix", "options": map[string]any{"columnField": "severity", "rowField": "cluster", "valueField": "Value"}},
+		},
+		"fieldConfig": map[string]any{"defaults": map[string]any{}, "overrides": severityOverrides()},
+		"options":     map[string]any{"stacking": "normal", "legend": map[string]any{"displayMode": "list", "placement": "bottom"}},
+	}, 12, 9)
+	d.add(map[string]any{
+		"type":        "bargauge",
+		"title":       "Readiness per cluster",
+		"targets":     []any{target(fmt.Sprintf(`sort(%s{%s})`, ReadinessScore, selector), "{{cluster}}", "")},
+		"fieldConfig": map[string]any{"defaults": readinessField(), "overrides": []any{}},
+		"options":     map[string]any{"displayMode": "gradient", "orientation": "horizontal", "showUnfilled": true},
+	}, 12, 9)
+	d.newline()
+
+	d.add(map[string]any{
+		"type":        "timeseries",
+		"title":       "Readiness over time",
+		"targets":     []any{target(fmt.Sprintf(`%s{%s}`, ReadinessScore, selector), "{{cluster}}", "")},
+		"fieldConfig": map[string]any{"defaults": readinessField(), "overrides": []any{}},
+	}, 24, 8)
+	d.newline()
+
+	// One row per selected cluster
+	clusterSelector := `cluster="$cluster",target="$target"`
+	d.add(map[string]any{
+		"type":      "row",
+		"title":     "Cluster $cluster",
+		"repeat":    "cluster",
+		"collapsed": false,
+		"panels":    []any{},
+	}, 24, 1)
+	d.newline()
+	d.add(statPanel("Readiness", fmt.Sprintf(`%s{%s}`, ReadinessScore, clusterSelector), readinessField()), 4, 6)
+	d.add(map[string]any{
+		"type":    "stat",
+		"title":   "Current version",
+		"targets": []any{target(fmt.Sprintf(`%s{cluster="$cluster"}`, ClusterInfo), "{{version}}", "")},
+		"options": map[string]any{"textMode": "name", "colorMode": "none", "graphMode": "none"},
+	}, 4, 6)
+	d.add(map[string]any{
+		"type":        "timeseries",
+		"title":       "Findings by severity",
+		"targets":     []any{target(fmt.Sprintf(`%s{%s}`, Findings, clusterSelector), "{{severity}}", "")},
+		"fieldConfig": map[string]any{"defaults": map[string]any{"min": 0, "decimals": 0}, "overrides": severityOverrides()},
+	}, 12, 6)
+	lastAssessed := map[string]any{"unit": "dateTimeFromNow", "color": map[string]any{"mode": "fixed", "fixedColor": "text"}}
+	d.add(statPanel("Last assessed", fmt.Sprintf(`%s{%s} * 1000`, AssessmentTimestamp, clusterSelector), lastAssessed), 4, 6)
+
+	return map[string]any{
+		"title":         opts.Title,
+		"uid":           opts.UID,
+		"tags":          []string{"kubernetes", "upgrades", "kube-upgrade-advisor"},
+		"schemaVersion": 39,
+		"version":       1,
+		"editable":      true,
+		"refresh":       "5m",
+		"time":          map[string]any{"from": "now-30d", "to": "now"},
+		"annotations":   map[string]any{"list": []any{}},
+		"templating": map[string]any{"list": []any{
+			map[string]any{
+				"name":  "datasource",
+				"label": "Data source",
+				"type":  "datasource",
+				"query": "prometheus",
+			},
+			queryVariable("target", "Target version", fmt.Sprintf("label_values(%s, target)", ReadinessScore), false),
+			queryVariable("cluster", "Cluster", fmt.Sprintf(`label_values(%s{target="$target"}, cluster)`, ReadinessScore), true),
+		}},
+		"panels": d.panels,
+	}
+}
+
+// dashboard lays out panels left to right on a 24 column grid
+type dashboard struct {
+	panels  []any
+	x, y, h int
+}
+
+// add places a panel of width w and height h after the previous one
+func (d *dashboard) add(panel map[string]any, w, h int) {
+	if d.x+w > 24 {
+		d.newline()
+	}
+	panel["id"] = len(d.panels) + 1
+	panel["gridPos"] = map[string]any{"x": d.x, "y": d.y, "w": w, "h": h}
+	if panel["type"] != "row" {
+		panel["datasource"] = datasource()
+	}
+	d.panels = append(d.panels, panel)
+	d.x += w
+	if h > d.h {
+		d.h = h
+	}
+}
+
+// newline starts a new line of panels
+func (d *dashboard) newline() {
+	d.x, d.y, d.h = 0, d.y+d.h, 0
+}
+
+func datasource() map[string]any {
+	return map[string]any{"type": "prometheus", "uid": "${datasource}"}
+}
+
+// target is a Prometheus query; format is "table" for instant tabular queries, else a time series
+func target(expr, legend, format string) map[string]any {
+	t := map[string]any{"refId": "A", "expr": expr, "legendFormat": legend, "datasource": datasource()}
+	if format != "" {
+		t["format"] = format
+		t["instant"] = true
+	}
+	return t
+}
+
+// statPanel shows the last value of a query
+func statPanel(title, expr string, defaults map[string]any) map[string]any {
+	return map[string]any{
+		"type":        "stat",
+		"title":       title,
+		"targets":     []any{target(expr, "", "")},
+		"fieldConfig": map[string]any{"defaults": defaults, "overrides": []any{}},
+		"options": map[string]any{
+			"reduceOptions": map[string]any{"calcs": []string{"lastNotNull"}, "fields": "", "values": false},
+			"colorMode":     "value",
+			"graphMode":     "area",
+		},
+	}
+}
+
+// readinessField colors readiness scores red below 50, yellow below 80 and green above
+func readinessField() map[string]any {
+	return map[string]any{
+		"unit": "none",
+		"min":  0,
+		"max":  100,
+		"color": map[string]any{
+			"mode": "thresholds",
+		},
+		"thresholds": map[string]any{
+			"mode": "absolute",
+			"steps": []any{
+				map[string]any{"color": "red", "value": nil},
+				map[string]any{"color": "yellow", "value": 50},
+				map[string]any{"color": "green", "value": 80},
+			},
+		},
+	}
+}
+
+// countField shows a count in a fixed color
+func countField(color string) map[string]any {
+	return map[string]any{
+		"decimals": 0,
+		"color":    map[string]any{"mode": "fixed", "fixedColor": color},
+	}
+}
+
+// severityOverrides colors series named after a severity
+func severityOverrides() []any {
+	var overrides []any
+	for _, severity := range Severities {
+		overrides = append(overrides, map[string]any{
+			"matcher": map[string]any{"id": "byName", "options": string(severity)},
+			"properties": []any{
+				map[string]any{"id": "color", "value": map[string]any{"mode": "fixed", "fixedColor": severityColors[string(severity)]}},
+			},
+		})
+	}
+	return overrides
+}
+
+// queryVariable is a dashboard variable listing label values
+func queryVariable(name, label, query string, multi bool) map[string]any {
+	v := map[string]any{
+		"name":       name,
+		"label":      label,
+		"type":       "query",
+		"datasource": datasource(),
+		"definition": query,
+		"query":      map[string]any{"query": query, "refId": name},
+		"refresh":    2,
+		"sort":       1,
+		"multi":      multi,
+		"includeAll": multi,
+	}
+	if multi {
+		v["current"] = map[string]any{"selected": true, "text": []string{"All"}, "value": []string{"$__all"}}
+	}
+	return v
+}
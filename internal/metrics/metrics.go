@@ -0,0 +1,121 @@
+// Package metrics exposes the latest stored assessments as Prometheus metrics and renders a Grafana
+// dashboard for them.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/fleet"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// Metric names
+const (
+	ClusterInfo         = "kube_upgrade_advisor_cluster_info"
+	ReadinessScore      = "kube_upgrade_advisor_readiness_score"
+	Findings            = "kube_upgrade_advisor_findings"
+	AssessmentTimestamp = "kube_upgrade_advisor_assessment_timestamp_seconds"
+)
+
+// Severities are the values of the severity label of Findings
+var Severities = []analysis.ImpactLevel{analysis.ImpactCritical, analysis.ImpactHigh, analysis.ImpactMedium, analysis.ImpactLow}
+
+// Write writes the metrics of the stored clusters and of the latest assessment of each cluster and
+// target version in the Prometheus text exposition format
+func Write(ctx context.Context, w io.Writer, store *inventory.Store) error {
+	clusters, err := store.ListClusters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+	// Assessments are listed newest first
+	assessments, err := store.ListAssessments(ctx, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to list assessments: %w", err)
+	}
+
+	var b strings.Builder
+	header(&b, ClusterInfo, "Scanned clusters with their current Kubernetes version")
+	for _, cluster := range clusters {
+		sample(&b, ClusterInfo, 1, "cluster", cluster.ID, "version", cluster.KubeVersion)
+	}
+
+	type key struct{ cluster, target string }
+	latest := make(map[key]fleet.ClusterSummary)
+	timestamps := make(map[key]int64)
+	var keys []key
+	for _, stored := range assessments {
+		if stored.Edges.Cluster == nil {
+			continue
+		}
+		k := key{stored.Edges.Cluster.ID, stored.TargetVersion}
+		if _, ok := latest[k]; ok {
+			continue
+		}
+
+		var assessment analysis.ImpactAssessment
+		if err := json.Unmarshal([]byte(stored.Snapshot), &assessment); err != nil {
+			return fmt.Errorf("failed to decode assessment %d: %w", stored.ID, err)
+		}
+		// Score the assessment the way the fleet report does
+		report := fleet.BuildReport(k.target, map[string]fleet.ClusterResult{k.cluster: {Assessment: &assessment}}, 1)
+		latest[k] = report.Clusters[0]
+		timestamps[k] = stored.CreatedAt.Unix()
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].cluster != keys[j].cluster {
+			return keys[i].cluster < keys[j].cluster
+		}
+		return keys[i].target < keys[j].target
+	})
+
+	header(&b, ReadinessScore, "Upgrade readiness of the latest assessment, 0-100, higher is closer to upgradable")
+	for _, k := range keys {
+		sample(&b, ReadinessScore, int64(latest[k].Readiness), "cluster", k.cluster, "target", k.target)
+	}
+
+	header(&b, Findings, "Findings of the latest assessment by severity")
+	for _, k := range keys {
+		summary := latest[k]
+		counts := map[analysis.ImpactLevel]int{
+			analysis.ImpactCritical: summary.Critical,
+			analysis.ImpactHigh:     summary.High,
+			analysis.ImpactMedium:   summary.Medium,
+			analysis.ImpactLow:      summary.Low,
+		}
+		for _, severity := range Severities {
+			sample(&b, Findings, int64(counts[severity]), "cluster", k.cluster, "target", k.target, "severity", string(severity))
+		}
+	}
+
+	header(&b, AssessmentTimestamp, "Time of the latest assessment")
+	for _, k := range keys {
+		sample(&b, AssessmentTimestamp, timestamps[k], "cluster", k.cluster, "target", k.target)
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// header writes the HELP and TYPE lines of a gauge
+func header(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// labelEscaper escapes label values for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sample writes a sample with label name/value pairs
+func sample(b *strings.Builder, name string, value int64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+	fmt.Fprintf(b, "%s{%s} %d\n", name, strings.Join(pairs, ","), value)
+}
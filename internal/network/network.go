@@ -0,0 +1,172 @@
+// Package network configures outbound connections for use behind corporate proxies: an explicit
+// proxy, extra CA certificates and, as a last resort, skipping TLS verification. The settings apply
+// alike to the Kubernetes API, chart repositories, manifest URLs, knowledge base registries, OTLP
+// export and the kube-upgrade-server client.
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Options are the outbound connection settings
+type Options struct {
+	// Proxy is the URL of the proxy for HTTP and HTTPS connections; empty uses $HTTPS_PROXY,
+	// $HTTP_PROXY and $NO_PROXY
+	Proxy string
+	// NoProxy lists hosts, domains and CIDRs reached directly, as in $NO_PROXY; only used with Proxy
+	NoProxy string
+	// CAFile is a PEM bundle of CA certificates trusted besides the system ones, e.g. of a proxy
+	// intercepting TLS
+	CAFile string
+	// InsecureSkipTLSVerify disables certificate verification of every connection
+	InsecureSkipTLSVerify bool
+}
+
+// OptionsFromEnv reads options from $KUBE_ADVISOR_PROXY, $KUBE_ADVISOR_NO_PROXY,
+// $KUBE_ADVISOR_CA_FILE and $KUBE_ADVISOR_INSECURE_SKIP_TLS_VERIFY
+func OptionsFromEnv() Options {
+	insecure, _ := strconv.ParseBool(os.Getenv("KUBE_ADVISOR_INSECURE_SKIP_TLS_VERIFY"))
+	return Options{
+		Proxy:                 os.Getenv("KUBE_ADVISOR_PROXY"),
+		NoProxy:               os.Getenv("KUBE_ADVISOR_NO_PROXY"),
+		CAFile:                os.Getenv("KUBE_ADVISOR_CA_FILE"),
+		InsecureSkipTLSVerify: insecure,
+	}
+}
+
+// settings are the configured options with the proxy function and TLS configuration built from them
+type settings struct {
+	options Options
+	proxy   func(*http.Request) (*url.URL, error)
+	caData  []byte
+	tls     *tls.Config
+}
+
+var (
+	mu      sync.RWMutex
+	current = settings{proxy: http.ProxyFromEnvironment}
+)
+
+// Configure applies options to the connections created from then on; call it before creating clients
+func Configure(opts Options) error {
+	configured := settings{options: opts, proxy: http.ProxyFromEnvironment}
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		proxyFunc := (&httpproxy.Config{HTTPProxy: opts.Proxy, HTTPSProxy: opts.Proxy, NoProxy: opts.NoProxy}).ProxyFunc()
+		configured.proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if opts.CAFile != "" || opts.InsecureSkipTLSVerify {
+		configured.tls = &tls.Config{InsecureSkipVerify: opts.InsecureSkipTLSVerify}
+	}
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM certificates in CA bundle %s", opts.CAFile)
+		}
+		configured.caData = data
+		configured.tls.RootCAs = pool
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = configured
+	return nil
+}
+
+// Current returns the configured options
+func Current() Options {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.options
+}
+
+// Proxy returns the proxy URL of a request, as http.Transport.Proxy
+func Proxy(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	proxy := current.proxy
+	mu.RUnlock()
+	return proxy(req)
+}
+
+// CAData returns the configured CA bundle, or nil
+func CAData() []byte {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.caData
+}
+
+// TLSConfig returns a TLS configuration trusting the configured CAs, or nil when none are
+// configured and verification isn't skipped
+func TLSConfig() *tls.Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current.tls == nil {
+		return nil
+	}
+	return current.tls.Clone()
+}
+
+// Environ returns the environment variables that apply the configured proxy and CA bundle to
+// child processes such as helm, which read $HTTPS_PROXY, $NO_PROXY and $SSL_CERT_FILE. Go programs
+// still trust the system CAs of the certificate directories besides the bundle. Skipping TLS
+// verification has no variable; children need their own flag for it.
+func Environ() []string {
+	opts := Current()
+	var env []string
+	if opts.Proxy != "" {
+		for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "https_proxy", "http_proxy"} {
+			env = append(env, name+"="+opts.Proxy)
+		}
+		env = append(env, "NO_PROXY="+opts.NoProxy, "no_proxy="+opts.NoProxy)
+	}
+	if opts.CAFile != "" {
+		// Children may run in another directory
+		caFile, err := filepath.Abs(opts.CAFile)
+		if err != nil {
+			caFile = opts.CAFile
+		}
+		env = append(env, "SSL_CERT_FILE="+caFile)
+	}
+	return env
+}
+
+// Transport returns a transport with the defaults of http.DefaultTransport, the configured proxy
+// and TLS configuration
+func Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = Proxy
+	if config := TLSConfig(); config != nil {
+		transport.TLSClientConfig = config
+	}
+	return transport
+}
+
+// Client returns an HTTP client using Transport; a zero timeout leaves requests to their context
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport(), Timeout: timeout}
+}
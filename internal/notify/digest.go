@@ -0,0 +1,238 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEOLDays is how close an end of life must be to be reported when a digest doesn't say
+const defaultEOLDays = 30
+
+// Config is the digest file of the server: the mail server and the digests sent through it
+type Config struct {
+	SMTP    SMTPConfig     `yaml:"smtp"`
+	Digests []DigestConfig `yaml:"digests"`
+}
+
+// DigestConfig is a digest email sent on a schedule
+type DigestConfig struct {
+	// Name identifies the digest; the findings it reported are recorded under it
+	Name string   `yaml:"name"`
+	To   []string `yaml:"to"`
+	// Every is the interval between digests, e.g. 24h or 168h
+	Every string `yaml:"every"`
+	// Target is the Kubernetes version the clusters are assessed for
+	Target string `yaml:"target"`
+	// Clusters are the clusters summarized; with neither clusters nor a selector the digest
+	// covers the whole fleet
+	Clusters []string `yaml:"clusters"`
+	// Selector picks clusters by label, e.g. env=prod
+	Selector string `yaml:"selector"`
+	// EOLDays reports end of life dates this many days ahead; 30 when zero
+	EOLDays int `yaml:"eolDays"`
+
+	interval time.Duration
+	selector inventory.Selector
+}
+
+// Interval is the parsed interval between digests
+func (d DigestConfig) Interval() time.Duration {
+	return d.interval
+}
+
+// ClusterSelector is the parsed label selector
+func (d DigestConfig) ClusterSelector() inventory.Selector {
+	return d.selector
+}
+
+// LoadConfig reads and validates a digest file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest config: %w", err)
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal digest config: %w", err)
+	}
+	if len(config.Digests) == 0 {
+		return nil, fmt.Errorf("no digests in %s", path)
+	}
+
+	names := make(map[string]bool, len(config.Digests))
+	for i := range config.Digests {
+		digest := &config.Digests[i]
+		if digest.Name == "" {
+			return nil, fmt.Errorf("digest %d: name is required", i+1)
+		}
+		if names[digest.Name] {
+			return nil, fmt.Errorf("digest %s is listed twice", digest.Name)
+		}
+		names[digest.Name] = true
+
+		if len(digest.To) == 0 {
+			return nil, fmt.Errorf("digest %s: to is required", digest.Name)
+		}
+		if digest.Target == "" {
+			return nil, fmt.Errorf("digest %s: target is required", digest.Name)
+		}
+		if digest.interval, err = time.ParseDuration(digest.Every); err != nil || digest.interval < time.Hour {
+			return nil, fmt.Errorf("digest %s: invalid every %q: must be a duration of at least 1h, e.g. 24h", digest.Name, digest.Every)
+		}
+		if digest.selector, err = inventory.ParseSelector(digest.Selector); err != nil {
+			return nil, fmt.Errorf("digest %s: invalid selector: %w", digest.Name, err)
+		}
+		if digest.EOLDays < 0 {
+			return nil, fmt.Errorf("digest %s: eolDays must not be negative", digest.Name)
+		}
+		if digest.EOLDays == 0 {
+			digest.EOLDays = defaultEOLDays
+		}
+	}
+	return config, nil
+}
+
+// ClusterDigest summarizes one cluster in a digest
+type ClusterDigest struct {
+	ClusterID   string
+	KubeVersion string
+	OverallRisk analysis.ImpactLevel
+	TotalIssues int
+	// FindingKeys are the keys of all current findings, recorded to tell new findings next time
+	FindingKeys []string
+	// NewFindings are the findings not reported by the previous digest
+	NewFindings []analysis.FindingRef
+	// EndOfLife is set when the cluster's version reaches its end of life within the digest's
+	// eolDays, or already has
+	EndOfLife *time.Time
+	// Error is set when the cluster could not be assessed
+	Error string
+}
+
+// NewClusterDigest summarizes the assessment of a cluster; reported are the keys of the findings
+// the previous digest listed for it, nil when there was none
+func NewClusterDigest(assessment *analysis.ImpactAssessment, reported []string, releases *knowledge.ReleaseKnowledgeBase, eolDays int, now time.Time) ClusterDigest {
+	summary := ClusterDigest{
+		ClusterID:   assessment.ClusterID,
+		KubeVersion: assessment.CurrentVersion,
+		OverallRisk: assessment.OverallRisk,
+		TotalIssues: assessment.TotalIssues,
+	}
+
+	known := make(map[string]bool, len(reported))
+	for _, key := range reported {
+		known[key] = true
+	}
+	for _, finding := range assessment.Findings() {
+		summary.FindingKeys = append(summary.FindingKeys, finding.Key())
+		if !known[finding.Key()] {
+			summary.NewFindings = append(summary.NewFindings, finding)
+		}
+	}
+
+	if releases != nil {
+		if release, ok := releases.Release(assessment.CurrentVersion); ok {
+			if eol, err := release.EOL(); err == nil && eol.Before(now.AddDate(0, 0, eolDays)) {
+				summary.EndOfLife = &eol
+			}
+		}
+	}
+	return summary
+}
+
+// Digest is the summary of the clusters of a digest since it was sent last
+type Digest struct {
+	Name   string
+	Target string
+	// Since is when the previous digest was sent; zero for the first one
+	Since       time.Time
+	GeneratedAt time.Time
+	Clusters    []ClusterDigest
+}
+
+// Subject is the subject of the digest email
+func (d *Digest) Subject() string {
+	newFindings := 0
+	risk := analysis.ImpactNone
+	for _, cluster := range d.Clusters {
+		newFindings += len(cluster.NewFindings)
+		if !risk.AtLeast(cluster.OverallRisk) {
+			risk = cluster.OverallRisk
+		}
+	}
+	return fmt.Sprintf("[kube-upgrade-advisor] %s: %d clusters, %s risk, %d new findings for Kubernetes %s",
+		d.Name, len(d.Clusters), risk, newFindings, d.Target)
+}
+
+// Body is the plain text of the digest email
+func (d *Digest) Body() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Upgrade readiness of %d clusters for Kubernetes %s, as of %s.\n",
+		len(d.Clusters), d.Target, d.GeneratedAt.Format("2006-01-02 15:04 MST"))
+	if !d.Since.IsZero() {
+		fmt.Fprintf(&b, "New findings are those since the previous digest of %s.\n", d.Since.Format("2006-01-02 15:04 MST"))
+	}
+
+	var eol []ClusterDigest
+	for _, cluster := range d.Clusters {
+		if cluster.EndOfLife != nil {
+			eol = append(eol, cluster)
+		}
+	}
+	if len(eol) > 0 {
+		sort.Slice(eol, func(i, j int) bool {
+			return eol[i].EndOfLife.Before(*eol[j].EndOfLife)
+		})
+		b.WriteString("\nEnd of life\n")
+		for _, cluster := range eol {
+			days := int(cluster.EndOfLife.Sub(d.GeneratedAt).Hours() / 24)
+			when := fmt.Sprintf("in %d days", days)
+			if days < 0 {
+				when = "already reached"
+			}
+			fmt.Fprintf(&b, "  %s runs %s, end of life on %s (%s)\n",
+				cluster.ClusterID, cluster.KubeVersion, cluster.EndOfLife.Format("2006-01-02"), when)
+		}
+	}
+
+	for _, cluster := range d.Clusters {
+		fmt.Fprintf(&b, "\n%s\n", cluster.ClusterID)
+		if cluster.Error != "" {
+			fmt.Fprintf(&b, "  Not assessed: %s\n", cluster.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "  Kubernetes %s, overall risk %s, %d issues, %d new\n",
+			cluster.KubeVersion, cluster.OverallRisk, cluster.TotalIssues, len(cluster.NewFindings))
+		for _, finding := range cluster.NewFindings {
+			fmt.Fprintf(&b, "  - [%s] %s %s: %s\n", finding.Severity, finding.RuleID, finding.Resource, finding.Summary)
+		}
+	}
+
+	b.WriteString("\nRun 'kube-upgrade-advisor impact --cluster <cluster> --target " + d.Target + "' for details.\n")
+	return b.String()
+}
+
+// FindingKeys are the keys of the current findings by cluster, to record with the digest. Clusters
+// that could not be assessed keep the keys reported before, so their findings aren't new next time.
+func (d *Digest) FindingKeys(reported map[string][]string) map[string][]string {
+	keys := make(map[string][]string, len(d.Clusters))
+	for _, cluster := range d.Clusters {
+		if cluster.Error != "" {
+			if previous, ok := reported[cluster.ClusterID]; ok {
+				keys[cluster.ClusterID] = previous
+			}
+			continue
+		}
+		keys[cluster.ClusterID] = cluster.FindingKeys
+	}
+	return keys
+}
@@ -0,0 +1,170 @@
+// Package notify sends digest emails summarizing the upgrade readiness of clusters: their risk,
+// the findings new since the previous digest and approaching end of life dates.
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+// TLS modes of the SMTP connection
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls" // implicit TLS, usually on port 465
+	SMTPNoTLS    = "none"
+)
+
+// smtpSessionTimeout bounds an SMTP session whose context has no earlier deadline, so a server
+// that stops responding can't hold up later sends
+const smtpSessionTimeout = 2 * time.Minute
+
+// SMTPConfig is the mail server digests are sent through
+type SMTPConfig struct {
+	Host string `yaml:"host"`
+	// Port defaults to 465 with implicit TLS, else 587
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	// PasswordEnv names the environment variable holding the password, keeping it out of the file
+	PasswordEnv string `yaml:"passwordEnv"`
+	From        string `yaml:"from"`
+	// TLS is starttls (default), tls or none
+	TLS string `yaml:"tls"`
+}
+
+// SMTPSink sends emails through an SMTP server
+type SMTPSink struct {
+	config   SMTPConfig
+	password string
+}
+
+// NewSMTPSink validates the SMTP settings and reads the password from its environment variable
+func NewSMTPSink(config SMTPConfig) (*SMTPSink, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("smtp.host is required")
+	}
+	if config.From == "" {
+		return nil, fmt.Errorf("smtp.from is required")
+	}
+	switch config.TLS {
+	case "":
+		config.TLS = SMTPStartTLS
+	case SMTPStartTLS, SMTPTLS, SMTPNoTLS:
+	default:
+		return nil, fmt.Errorf("unknown smtp.tls %q (supported: %s, %s, %s)", config.TLS, SMTPStartTLS, SMTPTLS, SMTPNoTLS)
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.TLS == SMTPTLS {
+			config.Port = 465
+		}
+	}
+
+	sink := &SMTPSink{config: config}
+	if config.PasswordEnv != "" {
+		sink.password = os.Getenv(config.PasswordEnv)
+		if sink.password == "" {
+			return nil, fmt.Errorf("smtp.passwordEnv: $%s is not set", config.PasswordEnv)
+		}
+	}
+	return sink, nil
+}
+
+// Send sends a plain text email
+func (s *SMTPSink) Send(ctx context.Context, to []string, subject, body string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	tlsConfig := network.TLSConfig()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ServerName = s.config.Host
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if s.config.TLS == SMTPTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	deadline := time.Now().Add(smtpSessionTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	// net/smtp has no context support; closing the connection aborts a session in progress
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet %s: %w", address, err)
+	}
+	defer client.Close()
+
+	if s.config.TLS == SMTPStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.password, s.config.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := writer.Write(s.message(to, subject, body)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// message formats the headers and body of an email with CRLF line endings
+func (s *SMTPSink) message(to []string, subject, body string) []byte {
+	var b strings.Builder
+	headers := [][2]string{
+		{"From", s.config.From},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", header[0], header[1])
+	}
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,46 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// createAddonSteps creates a step per outdated kube-system addon, run after the cluster upgrade.
+// Addons the platform upgrades itself only get verified; the platform's upgrade commands stay
+// available as optional fallbacks.
+func (p *Planner) createAddonSteps(findings []analysis.AddonFinding) []*UpgradeStep {
+	var steps []*UpgradeStep
+
+	for _, finding := range findings {
+		step := &UpgradeStep{
+			ID:          fmt.Sprintf("addon-upgrade-%s", sanitizeID(finding.Addon)),
+			Description: p.localizer.T("Upgrade %s from %s to %s", finding.Addon, finding.CurrentVersion, finding.TargetVersion),
+			Type:        StepAddonUpgrade,
+			Impact:      finding.Severity,
+			Targets:     []string{finding.Namespace + "/" + finding.Workload},
+		}
+		if finding.Managed {
+			step.Description = p.localizer.T("Verify %s was upgraded to %s by %s", finding.Addon, finding.TargetVersion, finding.Platform)
+		}
+
+		for _, command := range finding.Commands {
+			step.Actions = append(step.Actions, Action{
+				Command:     command,
+				Description: p.localizer.T("Upgrade %s", finding.Addon),
+				Required:    !finding.Managed,
+			})
+		}
+		step.Actions = append(step.Actions, Action{
+			Command: fmt.Sprintf("kubectl -n %s get %s %s -o jsonpath='{.spec.template.spec.containers[*].image}'",
+				finding.Namespace, strings.ToLower(finding.Kind), finding.Workload),
+			Description: p.localizer.T("Verify the running %s version", finding.Addon),
+			Required:    true,
+		})
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
@@ -0,0 +1,78 @@
+package planner
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/convert"
+)
+
+// ConversionTool is how API migration steps rewrite resources to the replacement API.
+// kubectl convert was removed from kubectl in 1.22 and lives on as the kubectl-convert plugin.
+type ConversionTool string
+
+const (
+	// ConvertServerSide reads resources back from the API server at the replacement version.
+	// Migration steps run once the replacement is served, so this needs no extra tooling.
+	ConvertServerSide ConversionTool = "server-side"
+	// ConvertPlugin converts manifest files offline with the kubectl-convert plugin
+	ConvertPlugin ConversionTool = "kubectl-convert"
+)
+
+// DetectConversionTool prefers the kubectl-convert plugin when it is installed
+func DetectConversionTool() ConversionTool {
+	if _, err := exec.LookPath("kubectl-convert"); err == nil {
+		return ConvertPlugin
+	}
+	return ConvertServerSide
+}
+
+// conversionActions returns the actions rewriting resources of a removed API to its replacement,
+// preferring the built-in converter. Replacements that aren't an API (e.g. Pod Security Admission)
+// have no conversion.
+func (p *Planner) conversionActions(api analysis.DeprecatedAPIImpact) []Action {
+	group, version, ok := analysis.ParseAPIVersion(api.ReplacementAPI)
+	if !ok {
+		return nil
+	}
+	kind := strings.ToLower(api.Kind)
+
+	if _, found := convert.Lookup(analysis.GroupVersion(api.Group, api.Version), api.Kind); found {
+		return []Action{
+			{
+				Command:     "kube-upgrade-advisor fix --write <manifest-dir>",
+				Description: p.localizer.T("Convert manifests to %s with the built-in converter", api.ReplacementAPI),
+				Required:    true,
+			},
+		}
+	}
+
+	if p.options.ConversionTool == ConvertPlugin {
+		return []Action{
+			{
+				Command:     fmt.Sprintf("kubectl-convert -f <manifest> --output-version %s", api.ReplacementAPI),
+				Description: p.localizer.T("Convert manifests to %s with the kubectl-convert plugin", api.ReplacementAPI),
+				Required:    true,
+			},
+		}
+	}
+
+	resource := kind
+	if group != "" {
+		resource = fmt.Sprintf("%s.%s.%s", kind, version, group)
+	}
+	return []Action{
+		{
+			Command:     fmt.Sprintf("kubectl get %s --all-namespaces -o yaml > converted-%s.yaml", resource, kind),
+			Description: p.localizer.T("Read the resources back at %s from the API server", api.ReplacementAPI),
+			Required:    true,
+		},
+		{
+			Command:     p.localizer.T("Update manifests to apiVersion: %s", api.ReplacementAPI),
+			Description: p.localizer.T("Commit the converted resources to the manifests and charts that create them"),
+			Required:    true,
+		},
+	}
+}
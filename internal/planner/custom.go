@@ -0,0 +1,123 @@
+package planner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// CustomStep is an operational step injected into the plan from a custom steps file, e.g.
+// "notify on-call" or "pause ArgoCD sync"
+type CustomStep struct {
+	ID          string               `yaml:"id"`
+	Description string               `yaml:"description"`
+	Impact      analysis.ImpactLevel `yaml:"impact"`
+	DependsOn   []string             `yaml:"dependsOn"` // steps that must complete first
+	Before      []string             `yaml:"before"`    // steps that must wait for this one
+	Actions     []CustomAction       `yaml:"actions"`
+}
+
+// CustomAction is an action of a custom step
+type CustomAction struct {
+	Command     string `yaml:"command"`
+	Description string `yaml:"description"`
+	Optional    bool   `yaml:"optional"`
+}
+
+// customStepsFile is the layout of a custom steps file
+type customStepsFile struct {
+	Steps []CustomStep `yaml:"steps"`
+}
+
+// LoadCustomSteps reads custom steps from a YAML file
+func LoadCustomSteps(path string) ([]CustomStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom steps: %w", err)
+	}
+
+	var file customStepsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom steps: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, step := range file.Steps {
+		if step.ID == "" {
+			return nil, fmt.Errorf("custom step %d has no id", i+1)
+		}
+		if seen[step.ID] {
+			return nil, fmt.Errorf("duplicate custom step id %q", step.ID)
+		}
+		seen[step.ID] = true
+
+		if step.Description == "" {
+			return nil, fmt.Errorf("custom step %q has no description", step.ID)
+		}
+		if step.Impact != "" {
+			if _, err := analysis.ParseImpactLevel(string(step.Impact)); err != nil {
+				return nil, fmt.Errorf("custom step %q: %w", step.ID, err)
+			}
+		}
+	}
+
+	return file.Steps, nil
+}
+
+// SetCustomSteps sets steps to inject into generated plans
+func (p *Planner) SetCustomSteps(steps []CustomStep) {
+	p.customSteps = steps
+}
+
+// addCustomSteps adds the custom steps and their dependency edges to the graph. Dependencies may
+// reference built-in steps (e.g. "backup", "cluster-upgrade") or other custom steps; cycles are
+// reported by the topological sort.
+func (p *Planner) addCustomSteps() error {
+	for _, custom := range p.customSteps {
+		if _, exists := p.graph[custom.ID]; exists {
+			return fmt.Errorf("custom step %q conflicts with a built-in step", custom.ID)
+		}
+
+		impact := custom.Impact
+		if impact == "" {
+			impact = analysis.ImpactLow
+		}
+
+		step := &UpgradeStep{
+			ID:           custom.ID,
+			Description:  custom.Description,
+			Type:         StepCustom,
+			Impact:       impact,
+			Dependencies: append([]string(nil), custom.DependsOn...),
+		}
+		for _, action := range custom.Actions {
+			step.Actions = append(step.Actions, Action{
+				Command:     action.Command,
+				Description: action.Description,
+				Required:    !action.Optional,
+			})
+		}
+		p.addNode(step)
+	}
+
+	for _, custom := range p.customSteps {
+		for _, dependency := range custom.DependsOn {
+			if _, exists := p.graph[dependency]; !exists {
+				return fmt.Errorf("custom step %q depends on unknown step %q", custom.ID, dependency)
+			}
+			p.addEdge(dependency, custom.ID)
+		}
+		for _, dependent := range custom.Before {
+			step, exists := p.graph[dependent]
+			if !exists {
+				return fmt.Errorf("custom step %q must run before unknown step %q", custom.ID, dependent)
+			}
+			step.Dependencies = append(step.Dependencies, custom.ID)
+			p.addEdge(custom.ID, dependent)
+		}
+	}
+
+	return nil
+}
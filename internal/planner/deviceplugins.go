@@ -0,0 +1,39 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// createDevicePluginSteps creates a step per device plugin or GPU operator to upgrade to a version
+// supporting the target, split like mesh steps into those upgraded before the cluster and those
+// upgraded after the control plane
+func (p *Planner) createDevicePluginSteps(findings []analysis.DevicePluginFinding) (before, after []*UpgradeStep) {
+	for _, finding := range findings {
+		if finding.RecommendedVersion == "" {
+			continue
+		}
+		step := &UpgradeStep{
+			ID:          fmt.Sprintf("device-plugin-upgrade-%s", sanitizeID(finding.Plugin)),
+			Description: p.localizer.T("Upgrade %s from %s to %s", finding.Plugin, finding.Version, finding.RecommendedVersion),
+			Type:        StepDevicePluginUpgrade,
+			Impact:      finding.Severity,
+			Targets:     []string{finding.Namespace + "/" + finding.Workload},
+		}
+		for _, command := range finding.Commands {
+			step.Actions = append(step.Actions, Action{
+				Command:     command,
+				Description: p.localizer.T("Upgrade %s", finding.Plugin),
+				Required:    true,
+			})
+		}
+
+		if finding.BeforeUpgrade {
+			before = append(before, step)
+		} else {
+			after = append(after, step)
+		}
+	}
+	return before, after
+}
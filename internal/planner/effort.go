@@ -0,0 +1,170 @@
+package planner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// EffortWeights are the engineering hours a step of each type takes at medium impact
+type EffortWeights map[StepType]float64
+
+// DefaultEffortWeights are rough hours per step type; migrations and chart upgrades need changes
+// reviewed and rolled out by the owning team, the rest is mostly running the runbook
+var DefaultEffortWeights = EffortWeights{
+	StepPreCheck:            1,
+	StepBackup:              1,
+	StepAPIMigration:        4,
+	StepChartUpgrade:        3,
+	StepClusterUpgrade:      4,
+	StepValidation:          2,
+	StepCapacity:            1,
+	StepPause:               0.5,
+	StepResume:              0.5,
+	StepRollback:            2,
+	StepCustom:              1,
+	StepAddonUpgrade:        2,
+	StepMeshUpgrade:         6,
+	StepDevicePluginUpgrade: 3,
+}
+
+// effortImpactFactors scale a step's hours by its impact: riskier changes need more testing
+var effortImpactFactors = map[analysis.ImpactLevel]float64{
+	analysis.ImpactCritical: 2,
+	analysis.ImpactHigh:     1.5,
+	analysis.ImpactMedium:   1,
+	analysis.ImpactLow:      0.75,
+	analysis.ImpactNone:     0.5,
+}
+
+// StepEffort is a team's share of the hours of a plan step
+type StepEffort struct {
+	StepID      string               `json:"stepId"`
+	Description string               `json:"description"`
+	Type        StepType             `json:"type"`
+	Impact      analysis.ImpactLevel `json:"impact"`
+	Team        string               `json:"team"`
+	Hours       float64              `json:"hours"`
+}
+
+// TeamEffort totals the hours of a team
+type TeamEffort struct {
+	Team  string  `json:"team"`
+	Steps int     `json:"steps"`
+	Hours float64 `json:"hours"`
+}
+
+// EffortEstimate is the engineering effort of a plan per team and in total
+type EffortEstimate struct {
+	FromVersion string       `json:"fromVersion"`
+	ToVersion   string       `json:"toVersion"`
+	Teams       []TeamEffort `json:"teams"`
+	Steps       []StepEffort `json:"steps"`
+	TotalHours  float64      `json:"totalHours"`
+}
+
+// EstimateEffort estimates the hours of each plan step from its type's weight, falling back to
+// DefaultEffortWeights, scaled by its impact. A step is attributed to the teams owning its targets,
+// split evenly between them, and cluster-wide steps to the ownership map's default team.
+func EstimateEffort(plan *UpgradePlan, ownership *analysis.OwnershipMap, weights EffortWeights) *EffortEstimate {
+	estimate := &EffortEstimate{FromVersion: plan.FromVersion, ToVersion: plan.ToVersion, Teams: []TeamEffort{}, Steps: []StepEffort{}}
+	teams := make(map[string]*TeamEffort)
+
+	for _, step := range plan.Steps {
+		hours, ok := weights[step.Type]
+		if !ok {
+			hours = DefaultEffortWeights[step.Type]
+		}
+		if factor, ok := effortImpactFactors[step.Impact]; ok {
+			hours *= factor
+		}
+
+		var owners []string
+		seen := make(map[string]bool)
+		for _, target := range step.Targets {
+			if owner := ownership.Owner(target); !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+		if len(owners) == 0 {
+			owners = []string{ownership.Owner("")}
+		}
+		sort.Strings(owners)
+
+		share := hours / float64(len(owners))
+		for _, owner := range owners {
+			estimate.Steps = append(estimate.Steps, StepEffort{
+				StepID:      step.ID,
+				Description: step.Description,
+				Type:        step.Type,
+				Impact:      step.Impact,
+				Team:        owner,
+				Hours:       share,
+			})
+			team, ok := teams[owner]
+			if !ok {
+				team = &TeamEffort{Team: owner}
+				teams[owner] = team
+			}
+			team.Steps++
+			team.Hours += share
+		}
+		estimate.TotalHours += hours
+	}
+
+	for _, team := range teams {
+		estimate.Teams = append(estimate.Teams, *team)
+	}
+	sort.Slice(estimate.Teams, func(i, j int) bool {
+		if estimate.Teams[i].Hours != estimate.Teams[j].Hours {
+			return estimate.Teams[i].Hours > estimate.Teams[j].Hours
+		}
+		return estimate.Teams[i].Team < estimate.Teams[j].Team
+	})
+	return estimate
+}
+
+// ParseEffortWeights parses hours per step type, e.g. {"chart_upgrade": "6"}
+func ParseEffortWeights(values map[string]string) (EffortWeights, error) {
+	weights := make(EffortWeights, len(values))
+	for stepType, value := range values {
+		if _, ok := DefaultEffortWeights[StepType(stepType)]; !ok {
+			return nil, fmt.Errorf("unknown step type %q", stepType)
+		}
+		hours, err := strconv.ParseFloat(value, 64)
+		if err != nil || hours < 0 {
+			return nil, fmt.Errorf("invalid hours %q for %s", value, stepType)
+		}
+		weights[StepType(stepType)] = hours
+	}
+	return weights, nil
+}
+
+// WriteCSV writes a row per step and team, followed by a total row per team and the grand total
+func (e *EffortEstimate) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"team", "step", "type", "impact", "description", "hours"}}
+	for _, step := range e.Steps {
+		rows = append(rows, []string{step.Team, step.StepID, string(step.Type), string(step.Impact), step.Description, formatHours(step.Hours)})
+	}
+	for _, team := range e.Teams {
+		rows = append(rows, []string{team.Team, "total", "", "", fmt.Sprintf("%d steps", team.Steps), formatHours(team.Hours)})
+	}
+	rows = append(rows, []string{"all", "total", "", "", fmt.Sprintf("%s to %s", e.FromVersion, e.ToVersion), formatHours(e.TotalHours)})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write effort CSV: %w", err)
+	}
+	return nil
+}
+
+// formatHours formats hours with at most two decimals
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(math.Round(hours*100)/100, 'f', -1, 64)
+}
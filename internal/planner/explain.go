@@ -0,0 +1,70 @@
+package planner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// StepsFor returns the plan steps generated for a finding, in plan order; findings the plan has
+// no dedicated step for, e.g. those left to the report, return none
+func (plan *UpgradePlan) StepsFor(finding analysis.FindingRef) []UpgradeStep {
+	var ids []string
+	switch finding.RuleID {
+	case analysis.RuleRemovedManifestAPI.ID:
+		ids = append(ids, "migrate-api-"+sanitizeID(fmt.Sprintf("%s/%s/%s", finding.Group, finding.Version, finding.Kind)))
+	case analysis.RuleOperatorAPI.ID:
+		ids = append(ids, "migrate-operator-api-"+sanitizeID(fmt.Sprintf("%s/%s/%s", finding.Group, finding.Version, finding.Kind)))
+	case analysis.RuleIncompatibleChart.ID:
+		// the resource is namespace/chart
+		ids = append(ids, "upgrade-chart-"+sanitizeID(finding.Resource[strings.LastIndex(finding.Resource, "/")+1:]))
+	case analysis.RuleMeshUnsupported.ID:
+		// the resource is mesh:namespace/name; a mesh upgraded in hops has a step per hop
+		mesh, resource, _ := strings.Cut(finding.Resource, ":")
+		for _, step := range plan.Steps {
+			if step.Type == StepMeshUpgrade && strings.HasPrefix(step.ID, "mesh-upgrade-"+sanitizeID(mesh)+"-") && slices.Contains(step.Targets, resource) {
+				ids = append(ids, step.ID)
+			}
+		}
+	case analysis.RuleDevicePlugin.ID:
+		// the resource is plugin:namespace/workload
+		plugin, _, _ := strings.Cut(finding.Resource, ":")
+		ids = append(ids, "device-plugin-upgrade-"+sanitizeID(plugin))
+	case analysis.RuleInterferingComponent.ID:
+		ids = append(ids, "pause-"+sanitizeID(finding.Resource), "resume-"+sanitizeID(finding.Resource))
+	case analysis.RuleInsufficientHeadroom.ID:
+		ids = append(ids, "scale-up", "scale-down")
+	case analysis.RuleAddonOutdated.ID:
+		// addon steps are named after the addon, so they are matched by the workload they verify
+		namespace, workload, _ := strings.Cut(finding.Resource, "/")
+		for _, step := range plan.Steps {
+			if step.Type == StepAddonUpgrade && step.verifies(namespace, workload) {
+				ids = append(ids, step.ID)
+			}
+		}
+	}
+
+	var steps []UpgradeStep
+	for _, step := range plan.Steps {
+		for _, id := range ids {
+			if step.ID == id {
+				steps = append(steps, step)
+				break
+			}
+		}
+	}
+	return steps
+}
+
+// verifies reports whether a step checks the image of a workload
+func (s UpgradeStep) verifies(namespace, workload string) bool {
+	for _, action := range s.Actions {
+		if strings.HasPrefix(action.Command, fmt.Sprintf("kubectl -n %s get ", namespace)) &&
+			strings.Contains(action.Command, " "+workload+" -o jsonpath=") {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
 )
 
 // UpgradeStep represents a single step in the upgrade plan
@@ -17,19 +19,29 @@ type UpgradeStep struct {
 	Impact       analysis.ImpactLevel
 	Actions      []Action
 	Order        int // Topological order
+	// Targets are the workloads the step changes, as namespace/name, or namespaces; empty for
+	// cluster-wide steps
+	Targets []string
 }
 
 // StepType defines the type of upgrade step
 type StepType string
 
 const (
-	StepPreCheck       StepType = "precheck"
-	StepBackup         StepType = "backup"
-	StepAPIMigration   StepType = "api_migration"
-	StepChartUpgrade   StepType = "chart_upgrade"
-	StepClusterUpgrade StepType = "cluster_upgrade"
-	StepValidation     StepType = "validation"
-	StepRollback       StepType = "rollback"
+	StepPreCheck            StepType = "precheck"
+	StepBackup              StepType = "backup"
+	StepAPIMigration        StepType = "api_migration"
+	StepChartUpgrade        StepType = "chart_upgrade"
+	StepClusterUpgrade      StepType = "cluster_upgrade"
+	StepValidation          StepType = "validation"
+	StepCapacity            StepType = "capacity"
+	StepPause               StepType = "pause"
+	StepResume              StepType = "resume"
+	StepRollback            StepType = "rollback"
+	StepCustom              StepType = "custom"
+	StepAddonUpgrade        StepType = "addon_upgrade"
+	StepMeshUpgrade         StepType = "mesh_upgrade"
+	StepDevicePluginUpgrade StepType = "device_plugin_upgrade"
 )
 
 // Action represents an action to perform
@@ -47,12 +59,24 @@ type UpgradePlan struct {
 	OrderedUpgradeSteps []string
 	Timeline            string
 	TotalSteps          int
+	Images              []Image  // images to mirror before the upgrade window
+	ImageCommands       []string // commands listing images the plan couldn't resolve
 }
 
 // Planner generates upgrade plans
 type Planner struct {
-	graph map[string]*UpgradeStep
-	edges map[string][]string
+	graph       map[string]*UpgradeStep
+	edges       map[string][]string
+	localizer   *i18n.Localizer
+	options     Options
+	customSteps []CustomStep
+}
+
+// Options customizes plan generation
+type Options struct {
+	SkipBackup      bool           // the cluster is backed up out of band
+	IncludeRollback bool           // add a contingency step restoring the pre-upgrade state
+	ConversionTool  ConversionTool // how migrations rewrite resources; server-side when empty
 }
 
 // NewPlanner creates a new upgrade planner
@@ -63,6 +87,16 @@ func NewPlanner() *Planner {
 	}
 }
 
+// SetLocalizer sets the language of step and action descriptions
+func (p *Planner) SetLocalizer(localizer *i18n.Localizer) {
+	p.localizer = localizer
+}
+
+// SetOptions sets the plan generation options
+func (p *Planner) SetOptions(options Options) {
+	p.options = options
+}
+
 // GeneratePlan generates an upgrade plan based on impact assessment
 func (p *Planner) GeneratePlan(assessment *analysis.ImpactAssessment) (*UpgradePlan, error) {
 	p.graph = make(map[string]*UpgradeStep)
@@ -71,59 +105,68 @@ func (p *Planner) GeneratePlan(assessment *analysis.ImpactAssessment) (*UpgradeP
 	// Step 1: Pre-check
 	precheck := &UpgradeStep{
 		ID:          "precheck",
-		Description: "Pre-upgrade validation and checks",
+		Description: p.localizer.T("Pre-upgrade validation and checks"),
 		Type:        StepPreCheck,
 		Impact:      analysis.ImpactLow,
 		Actions: []Action{
 			{
 				Command:     "kubectl version",
-				Description: "Verify cluster connectivity",
+				Description: p.localizer.T("Verify cluster connectivity"),
 				Required:    true,
 			},
 			{
 				Command:     "kubectl get nodes",
-				Description: "Check node status",
+				Description: p.localizer.T("Check node status"),
 				Required:    true,
 			},
 		},
 	}
+	p.addDrainCheckActions(precheck, assessment.NodeDrainResults)
 	p.addNode(precheck)
 
-	// Step 2: Backup
+	// Step 2: Backup. Later steps hang off the backup, or off the precheck when it is skipped.
+	anchor := "backup"
+	if p.options.SkipBackup {
+		anchor = "precheck"
+	}
+
 	backup := &UpgradeStep{
 		ID:           "backup",
-		Description:  "Backup cluster state and critical resources",
+		Description:  p.localizer.T("Backup cluster state and critical resources"),
 		Type:         StepBackup,
 		Impact:       analysis.ImpactHigh,
 		Dependencies: []string{"precheck"},
 		Actions: []Action{
 			{
 				Command:     "velero backup create pre-upgrade-backup --wait",
-				Description: "Create full cluster backup",
+				Description: p.localizer.T("Create full cluster backup"),
 				Required:    true,
 			},
 			{
 				Command:     "etcdctl snapshot save /backup/etcd-snapshot.db",
-				Description: "Backup etcd",
+				Description: p.localizer.T("Backup etcd"),
 				Required:    true,
 			},
 		},
 	}
-	p.addNode(backup)
-	p.addEdge("precheck", "backup")
+	if !p.options.SkipBackup {
+		p.addNode(backup)
+		p.addEdge("precheck", "backup")
+	}
 
 	// Step 3: API Migrations
-	apiMigrationSteps := p.createAPIMigrationSteps(assessment)
+	apiMigrationSteps, deferredMigrations := p.createAPIMigrationSteps(assessment)
+	apiMigrationSteps = append(apiMigrationSteps, p.createOperatorMigrationSteps(assessment.DeprecatedOperatorAPIs)...)
 	for _, step := range apiMigrationSteps {
-		step.Dependencies = append(step.Dependencies, "backup")
+		step.Dependencies = append(step.Dependencies, anchor)
 		p.addNode(step)
-		p.addEdge("backup", step.ID)
+		p.addEdge(anchor, step.ID)
 	}
 
 	// Step 4: Chart Upgrades
 	chartUpgradeSteps := p.createChartUpgradeSteps(assessment)
 	for _, step := range chartUpgradeSteps {
-		step.Dependencies = append(step.Dependencies, "backup")
+		step.Dependencies = append(step.Dependencies, anchor)
 
 		// Chart upgrades depend on API migrations
 		for _, apiStep := range apiMigrationSteps {
@@ -134,32 +177,58 @@ func (p *Planner) GeneratePlan(assessment *analysis.ImpactAssessment) (*UpgradeP
 		p.addNode(step)
 	}
 
+	// Step 4a: Service meshes and device plugins upgraded to a version supporting both Kubernetes versions
+	meshSteps, postUpgradeMeshSteps := p.createMeshSteps(assessment.Meshes)
+	devicePluginSteps, postUpgradeDevicePluginSteps := p.createDevicePluginSteps(assessment.DevicePlugins)
+	meshSteps = append(meshSteps, devicePluginSteps...)
+	for _, step := range meshSteps {
+		step.Dependencies = append(step.Dependencies, anchor)
+		p.addNode(step)
+		p.addEdge(anchor, step.ID)
+	}
+
+	// Step 4b: Temporary capacity for the rolling upgrade
+	scaleUp, scaleDown := p.createCapacitySteps(assessment.Headroom)
+	if scaleUp != nil {
+		scaleUp.Dependencies = append(scaleUp.Dependencies, anchor)
+		p.addNode(scaleUp)
+		p.addEdge(anchor, scaleUp.ID)
+	}
+
+	// Step 4c: Pause autoscalers and deschedulers for the duration of the upgrade
+	pauseSteps, resumeSteps := p.createPauseSteps(assessment.InterferingComponents)
+	for _, step := range pauseSteps {
+		step.Dependencies = append(step.Dependencies, anchor)
+		p.addNode(step)
+		p.addEdge(anchor, step.ID)
+	}
+
 	// Step 5: Cluster Upgrade
 	clusterUpgrade := &UpgradeStep{
 		ID:           "cluster-upgrade",
-		Description:  fmt.Sprintf("Upgrade Kubernetes from %s to %s", assessment.CurrentVersion, assessment.TargetVersion),
+		Description:  p.localizer.T("Upgrade Kubernetes from %s to %s", assessment.CurrentVersion, assessment.TargetVersion),
 		Type:         StepClusterUpgrade,
 		Impact:       analysis.ImpactCritical,
-		Dependencies: []string{"backup"},
+		Dependencies: []string{anchor},
 		Actions: []Action{
 			{
 				Command:     "kubeadm upgrade plan",
-				Description: "Review upgrade plan",
+				Description: p.localizer.T("Review upgrade plan"),
 				Required:    true,
 			},
 			{
 				Command:     fmt.Sprintf("kubeadm upgrade apply %s", assessment.TargetVersion),
-				Description: "Apply Kubernetes upgrade",
+				Description: p.localizer.T("Apply Kubernetes upgrade"),
 				Required:    true,
 			},
 			{
 				Command:     "kubectl drain <node> --ignore-daemonsets",
-				Description: "Drain nodes before upgrade",
+				Description: p.localizer.T("Drain nodes before upgrade"),
 				Required:    true,
 			},
 			{
 				Command:     "kubectl uncordon <node>",
-				Description: "Uncordon nodes after upgrade",
+				Description: p.localizer.T("Uncordon nodes after upgrade"),
 				Required:    true,
 			},
 		},
@@ -174,37 +243,109 @@ func (p *Planner) GeneratePlan(assessment *analysis.ImpactAssessment) (*UpgradeP
 		clusterUpgrade.Dependencies = append(clusterUpgrade.Dependencies, step.ID)
 		p.addEdge(step.ID, "cluster-upgrade")
 	}
+	for _, step := range meshSteps {
+		clusterUpgrade.Dependencies = append(clusterUpgrade.Dependencies, step.ID)
+		p.addEdge(step.ID, "cluster-upgrade")
+	}
+
+	if scaleUp != nil {
+		clusterUpgrade.Dependencies = append(clusterUpgrade.Dependencies, scaleUp.ID)
+		p.addEdge(scaleUp.ID, "cluster-upgrade")
+	}
+	for _, step := range pauseSteps {
+		clusterUpgrade.Dependencies = append(clusterUpgrade.Dependencies, step.ID)
+		p.addEdge(step.ID, "cluster-upgrade")
+	}
 
 	p.addNode(clusterUpgrade)
+	p.addEdge(anchor, "cluster-upgrade")
+
+	// Step 5b: Migrations to replacement APIs the current version doesn't serve yet
+	preUpgrade := []string{anchor}
+	for _, step := range apiMigrationSteps {
+		preUpgrade = append(preUpgrade, step.ID)
+	}
+	if scaleUp != nil {
+		preUpgrade = append(preUpgrade, scaleUp.ID)
+	}
+	for _, step := range pauseSteps {
+		preUpgrade = append(preUpgrade, step.ID)
+	}
+	for _, step := range meshSteps {
+		preUpgrade = append(preUpgrade, step.ID)
+	}
+	postUpgradeMigrations := p.addDeferredMigrations(assessment, deferredMigrations, preUpgrade, clusterUpgrade)
+
+	// Step 5c: kube-system addons, meshes and device plugins, upgraded once the control plane runs
+	// the target version
+	addonSteps := append(p.createAddonSteps(assessment.Addons), postUpgradeMeshSteps...)
+	addonSteps = append(addonSteps, postUpgradeDevicePluginSteps...)
+	for _, step := range addonSteps {
+		step.Dependencies = append(step.Dependencies, "cluster-upgrade")
+		p.addNode(step)
+		p.addEdge("cluster-upgrade", step.ID)
+	}
 
 	// Step 6: Validation
 	validation := &UpgradeStep{
 		ID:           "validation",
-		Description:  "Post-upgrade validation",
+		Description:  p.localizer.T("Post-upgrade validation"),
 		Type:         StepValidation,
 		Impact:       analysis.ImpactMedium,
 		Dependencies: []string{"cluster-upgrade"},
 		Actions: []Action{
 			{
 				Command:     "kubectl get nodes",
-				Description: "Verify all nodes are ready",
+				Description: p.localizer.T("Verify all nodes are ready"),
 				Required:    true,
 			},
 			{
 				Command:     "kubectl get pods --all-namespaces",
-				Description: "Check all pods are running",
+				Description: p.localizer.T("Check all pods are running"),
 				Required:    true,
 			},
 			{
 				Command:     "kubectl api-resources",
-				Description: "Verify API resources",
+				Description: p.localizer.T("Verify API resources"),
 				Required:    true,
 			},
 		},
 	}
+	for _, id := range postUpgradeMigrations {
+		validation.Dependencies = append(validation.Dependencies, id)
+		p.addEdge(id, "validation")
+	}
+	for _, step := range addonSteps {
+		validation.Dependencies = append(validation.Dependencies, step.ID)
+		p.addEdge(step.ID, "validation")
+	}
 	p.addNode(validation)
 	p.addEdge("cluster-upgrade", "validation")
 
+	if scaleDown != nil {
+		scaleDown.Dependencies = append(scaleDown.Dependencies, "validation")
+		p.addNode(scaleDown)
+		p.addEdge("validation", scaleDown.ID)
+	}
+
+	for _, step := range resumeSteps {
+		step.Dependencies = append(step.Dependencies, "validation")
+		p.addNode(step)
+		p.addEdge("validation", step.ID)
+	}
+
+	// Step 7: Contingency rollback, only executed when validation fails
+	if p.options.IncludeRollback {
+		rollback := p.createRollbackStep(assessment)
+		p.addNode(rollback)
+		p.addEdge("validation", rollback.ID)
+	}
+
+	// Step 8: Operational steps from the custom steps file
+	if err := p.addCustomSteps(); err != nil {
+		return nil, fmt.Errorf("failed to add custom steps: %w", err)
+	}
+
 	// Perform topological sort
 	orderedSteps, err := p.topologicalSort()
 	if err != nil {
@@ -225,13 +366,16 @@ func (p *Planner) GeneratePlan(assessment *analysis.ImpactAssessment) (*UpgradeP
 	}
 
 	plan.Timeline = p.estimateTimeline(len(orderedSteps))
+	plan.Images, plan.ImageCommands = p.createImageList(assessment)
 
 	return plan, nil
 }
 
-// createAPIMigrationSteps creates steps for migrating deprecated APIs
-func (p *Planner) createAPIMigrationSteps(assessment *analysis.ImpactAssessment) []*UpgradeStep {
+// createAPIMigrationSteps creates steps for migrating deprecated APIs. Migrations to a replacement
+// the current cluster doesn't serve are returned separately, to be scheduled later.
+func (p *Planner) createAPIMigrationSteps(assessment *analysis.ImpactAssessment) ([]*UpgradeStep, []deferredMigration) {
 	var steps []*UpgradeStep
+	var deferred []deferredMigration
 
 	// Group by API
 	apiMap := make(map[string]analysis.DeprecatedAPIImpact)
@@ -241,35 +385,94 @@ func (p *Planner) createAPIMigrationSteps(assessment *analysis.ImpactAssessment)
 	}
 
 	for key, api := range apiMap {
-		gv := api.Group + "/" + api.Version
-		if api.Group == "" {
-			gv = api.Version
-		}
+		gv := analysis.GroupVersion(api.Group, api.Version)
 
 		step := &UpgradeStep{
 			ID:          fmt.Sprintf("migrate-api-%s", sanitizeID(key)),
-			Description: fmt.Sprintf("Migrate %s %s to %s", gv, api.Kind, api.ReplacementAPI),
+			Description: p.localizer.T("Migrate %s %s to %s", gv, api.Kind, api.ReplacementAPI),
 			Type:        StepAPIMigration,
 			Impact:      api.ImpactLevel,
+			Targets:     provenanceNamespaces(api.Provenance),
 			Actions: []Action{
 				{
 					Command:     fmt.Sprintf("kubectl get %s -o yaml > backup-%s.yaml", api.Kind, strings.ToLower(api.Kind)),
-					Description: fmt.Sprintf("Backup existing %s resources", api.Kind),
+					Description: p.localizer.T("Backup existing %s resources", api.Kind),
 					Required:    true,
 				},
+			},
+		}
+		step.Actions = append(step.Actions, p.conversionActions(api)...)
+		step.Actions = append(step.Actions, Action{
+			Command:     p.localizer.T("Manual review required"),
+			Description: api.MigrationNotes,
+			Required:    true,
+		})
+
+		if api.ReplacementServed != nil && !*api.ReplacementServed {
+			deferred = append(deferred, p.deferMigration(step, api, assessment))
+			continue
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, deferred
+}
+
+// provenanceNamespaces returns the namespaces of the affected resources, sorted
+func provenanceNamespaces(provenance []analysis.ResourceProvenance) []string {
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, resource := range provenance {
+		namespace, _, namespaced := strings.Cut(resource.Resource, "/")
+		if namespaced && !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// createOperatorMigrationSteps creates steps for moving custom resources off operator API versions
+// that are removed before or during the upgrade; deprecated-only versions are left to the report
+func (p *Planner) createOperatorMigrationSteps(impacts []analysis.OperatorAPIImpact) []*UpgradeStep {
+	var steps []*UpgradeStep
+	seen := make(map[string]bool)
+
+	for _, api := range impacts {
+		if api.RemovedIn == "" || api.ImpactLevel == analysis.ImpactMedium {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s", api.Group, api.Version, api.Kind)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		resource := strings.ToLower(api.Kind) + "." + api.Group
+		steps = append(steps, &UpgradeStep{
+			ID:          fmt.Sprintf("migrate-operator-api-%s", sanitizeID(key)),
+			Description: p.localizer.T("Migrate %s/%s %s to %s before %s %s", api.Group, api.Version, api.Kind, api.ReplacementAPI, api.Operator, api.RemovedIn),
+			Type:        StepAPIMigration,
+			Impact:      api.ImpactLevel,
+			Actions: []Action{
 				{
-					Command:     fmt.Sprintf("kubectl convert -f backup-%s.yaml --output-version=%s", strings.ToLower(api.Kind), api.ReplacementAPI),
-					Description: fmt.Sprintf("Convert to %s", api.ReplacementAPI),
+					Command:     fmt.Sprintf("kubectl get %s --all-namespaces -o yaml > backup-%s.yaml", resource, sanitizeID(resource)),
+					Description: p.localizer.T("Backup existing %s resources", api.Kind),
 					Required:    true,
 				},
 				{
-					Command:     "Manual review required",
+					Command:     p.localizer.T("Update manifests to apiVersion: %s", api.ReplacementAPI),
+					Description: p.localizer.T("Stop applying %s/%s in manifests and charts", api.Group, api.Version),
+					Required:    true,
+				},
+				{
+					Command:     p.localizer.T("Manual review required"),
 					Description: api.MigrationNotes,
 					Required:    true,
 				},
 			},
-		}
-		steps = append(steps, step)
+		})
 	}
 
 	return steps
@@ -282,21 +485,25 @@ func (p *Planner) createChartUpgradeSteps(assessment *analysis.ImpactAssessment)
 	for _, chart := range assessment.IncompatibleCharts {
 		step := &UpgradeStep{
 			ID:          fmt.Sprintf("upgrade-chart-%s", sanitizeID(chart.ChartName)),
-			Description: fmt.Sprintf("Upgrade %s from %s to %s", chart.ChartName, chart.CurrentVersion, chart.RecommendedVersion),
+			Description: p.localizer.T("Upgrade %s from %s to %s", chart.ChartName, chart.CurrentVersion, chart.RecommendedVersion),
 			Type:        StepChartUpgrade,
 			Impact:      chart.ImpactLevel,
 			Actions:     []Action{},
+			Targets:     []string{chart.Namespace},
+		}
+		if chart.Release != "" {
+			step.Targets = []string{chart.Namespace + "/" + chart.Release}
 		}
 
 		if chart.RecommendedVersion != "" {
 			step.Actions = append(step.Actions, Action{
-				Command:     fmt.Sprintf("helm upgrade %s %s --version %s -n %s", chart.ChartName, chart.ChartName, chart.RecommendedVersion, chart.Namespace),
-				Description: fmt.Sprintf("Upgrade to version %s", chart.RecommendedVersion),
+				Command:     fmt.Sprintf("helm upgrade %s %s --version %s -n %s%s", chart.ChartName, chart.ChartName, chart.RecommendedVersion, chart.Namespace, chart.SetFlags()),
+				Description: p.localizer.T("Upgrade to version %s", chart.RecommendedVersion),
 				Required:    true,
 			})
 		} else {
 			step.Actions = append(step.Actions, Action{
-				Command:     "Manual intervention required",
+				Command:     p.localizer.T("Manual intervention required"),
 				Description: chart.Message,
 				Required:    true,
 			})
@@ -304,7 +511,7 @@ func (p *Planner) createChartUpgradeSteps(assessment *analysis.ImpactAssessment)
 
 		if len(chart.Issues) > 0 {
 			step.Actions = append(step.Actions, Action{
-				Command:     "Review known issues",
+				Command:     p.localizer.T("Review known issues"),
 				Description: strings.Join(chart.Issues, "; "),
 				Required:    true,
 			})
@@ -316,6 +523,181 @@ func (p *Planner) createChartUpgradeSteps(assessment *analysis.ImpactAssessment)
 	return steps
 }
 
+// createCapacitySteps creates temporary scale-up/scale-down steps when headroom is insufficient
+func (p *Planner) createCapacitySteps(headroom *analysis.HeadroomResult) (*UpgradeStep, *UpgradeStep) {
+	if headroom == nil || headroom.Sufficient {
+		return nil, nil
+	}
+
+	scaleUp := &UpgradeStep{
+		ID:          "scale-up",
+		Description: p.localizer.T("Temporarily add %d node(s) to absorb %d node(s) out of service", headroom.SuggestedAddNodes, headroom.SurgeNodes),
+		Type:        StepCapacity,
+		Impact:      analysis.ImpactMedium,
+		Actions: []Action{
+			{
+				Command:     p.localizer.T("Scale up the node group / machine pool"),
+				Description: headroom.Message,
+				Required:    true,
+			},
+			{
+				Command:     "kubectl get nodes",
+				Description: p.localizer.T("Verify the new nodes are Ready before draining"),
+				Required:    true,
+			},
+		},
+	}
+
+	scaleDown := &UpgradeStep{
+		ID:          "scale-down",
+		Description: p.localizer.T("Remove temporary capacity added for the upgrade"),
+		Type:        StepCapacity,
+		Impact:      analysis.ImpactLow,
+		Actions: []Action{
+			{
+				Command:     p.localizer.T("Scale the node group / machine pool back to its original size"),
+				Description: p.localizer.T("Remove the %d temporary node(s)", headroom.SuggestedAddNodes),
+				Required:    false,
+			},
+		},
+	}
+
+	return scaleUp, scaleDown
+}
+
+// createPauseSteps creates pause/resume steps for components that interfere with node replacement
+func (p *Planner) createPauseSteps(components []analysis.InterferingComponent) ([]*UpgradeStep, []*UpgradeStep) {
+	var pauseSteps, resumeSteps []*UpgradeStep
+
+	for _, component := range components {
+		id := sanitizeID(fmt.Sprintf("%s-%s", component.Namespace, component.Name))
+
+		var pauseCmd, resumeCmd string
+		if component.Kind == "CronJob" {
+			pauseCmd = fmt.Sprintf(`kubectl -n %s patch cronjob %s -p '{"spec":{"suspend":true}}'`, component.Namespace, component.Name)
+			resumeCmd = fmt.Sprintf(`kubectl -n %s patch cronjob %s -p '{"spec":{"suspend":false}}'`, component.Namespace, component.Name)
+		} else {
+			pauseCmd = fmt.Sprintf("kubectl -n %s scale deployment %s --replicas=0", component.Namespace, component.Name)
+			resumeCmd = fmt.Sprintf("kubectl -n %s scale deployment %s --replicas=%d", component.Namespace, component.Name, component.Replicas)
+		}
+
+		pauseSteps = append(pauseSteps, &UpgradeStep{
+			ID:          fmt.Sprintf("pause-%s", id),
+			Description: p.localizer.T("Pause %s (%s/%s)", component.Type, component.Namespace, component.Name),
+			Type:        StepPause,
+			Impact:      analysis.ImpactMedium,
+			Actions: []Action{
+				{
+					Command:     pauseCmd,
+					Description: p.localizer.T("Stop %s from adding, removing or rebalancing nodes during the upgrade", component.Type),
+					Required:    true,
+				},
+			},
+		})
+
+		resumeSteps = append(resumeSteps, &UpgradeStep{
+			ID:          fmt.Sprintf("resume-%s", id),
+			Description: p.localizer.T("Resume %s (%s/%s)", component.Type, component.Namespace, component.Name),
+			Type:        StepResume,
+			Impact:      analysis.ImpactLow,
+			Actions: []Action{
+				{
+					Command:     resumeCmd,
+					Description: p.localizer.T("Re-enable %s", component.Type),
+					Required:    true,
+				},
+			},
+		})
+	}
+
+	return pauseSteps, resumeSteps
+}
+
+// createRollbackStep creates a contingency step that restores the pre-upgrade state if validation fails.
+// kubeadm cannot downgrade, so the control plane is restored from the etcd snapshot and the static pod
+// manifests kubeadm backs up during the upgrade.
+func (p *Planner) createRollbackStep(assessment *analysis.ImpactAssessment) *UpgradeStep {
+	step := &UpgradeStep{
+		ID:           "rollback",
+		Description:  p.localizer.T("Roll back to %s if post-upgrade validation fails", assessment.CurrentVersion),
+		Type:         StepRollback,
+		Impact:       analysis.ImpactCritical,
+		Dependencies: []string{"validation"},
+	}
+
+	for _, chart := range assessment.IncompatibleCharts {
+		if chart.RecommendedVersion == "" {
+			continue
+		}
+		step.Actions = append(step.Actions, Action{
+			Command:     fmt.Sprintf("helm rollback %s -n %s", chart.ChartName, chart.Namespace),
+			Description: p.localizer.T("Roll back %s to its previous revision", chart.ChartName),
+			Required:    false,
+		})
+	}
+
+	step.Actions = append(step.Actions, Action{
+		Command:     "cp /etc/kubernetes/tmp/kubeadm-backup-manifests-*/*.yaml /etc/kubernetes/manifests/",
+		Description: p.localizer.T("Restore the control plane static pod manifests backed up by kubeadm"),
+		Required:    true,
+	})
+
+	if p.options.SkipBackup {
+		step.Actions = append(step.Actions, Action{
+			Command:     p.localizer.T("Restore from the out-of-band backup"),
+			Description: p.localizer.T("Restore etcd and cluster resources from your own backup"),
+			Required:    true,
+		})
+	} else {
+		step.Actions = append(step.Actions,
+			Action{
+				Command:     "etcdctl snapshot restore /backup/etcd-snapshot.db --data-dir /var/lib/etcd-restore",
+				Description: p.localizer.T("Restore etcd from the pre-upgrade snapshot on each control plane node"),
+				Required:    true,
+			},
+			Action{
+				Command:     "velero restore create --from-backup pre-upgrade-backup --wait",
+				Description: p.localizer.T("Restore cluster resources from the pre-upgrade backup"),
+				Required:    true,
+			},
+		)
+	}
+
+	step.Actions = append(step.Actions, Action{
+		Command:     p.localizer.T("Reinstall kubelet and kubectl %s on upgraded nodes", assessment.CurrentVersion),
+		Description: p.localizer.T("Downgrade node packages and restart kubelet"),
+		Required:    true,
+	})
+
+	return step
+}
+
+// addDrainCheckActions adds per-node drain simulation results to the precheck step
+func (p *Planner) addDrainCheckActions(precheck *UpgradeStep, results []analysis.NodeDrainResult) {
+	for _, node := range results {
+		if node.Drainable {
+			precheck.Actions = append(precheck.Actions, Action{
+				Command:     fmt.Sprintf("kubectl drain %s --ignore-daemonsets --dry-run=server", node.Node),
+				Description: p.localizer.T("Node %s can be drained (%d pods to evict)", node.Node, node.EvictablePods),
+				Required:    false,
+			})
+			continue
+		}
+
+		culprits := make([]string, 0, len(node.Blockers))
+		for _, blocker := range node.Blockers {
+			culprits = append(culprits, fmt.Sprintf("%s/%s (%s)", blocker.Namespace, blocker.Pod, blocker.Reason))
+		}
+
+		precheck.Impact = analysis.ImpactHigh
+		precheck.Actions = append(precheck.Actions, Action{
+			Command:     fmt.Sprintf("kubectl drain %s --ignore-daemonsets --dry-run=server", node.Node),
+			Description: p.localizer.T("Resolve drain blockers on node %s: %s", node.Node, strings.Join(culprits, ", ")),
+			Required:    true,
+		})
+	}
+}
+
 // addNode adds a node to the graph
 func (p *Planner) addNode(step *UpgradeStep) {
 	p.graph[step.ID] = step
@@ -403,17 +785,19 @@ func (p *Planner) ValidatePlan(plan *UpgradePlan) error {
 	return nil
 }
 
+// StepDuration is the rough time estimate for a single plan step
+const StepDuration = 30 * time.Minute
+
 // estimateTimeline estimates the time needed for the upgrade
 func (p *Planner) estimateTimeline(stepCount int) string {
-	// Rough estimate: 30 min per step
-	hours := (stepCount * 30) / 60
+	hours := int(time.Duration(stepCount) * StepDuration / time.Hour)
 	if hours < 1 {
-		return "Less than 1 hour"
+		return p.localizer.T("Less than 1 hour")
 	}
 	if hours == 1 {
-		return "Approximately 1 hour"
+		return p.localizer.T("Approximately 1 hour")
 	}
-	return fmt.Sprintf("Approximately %d hours", hours)
+	return p.localizer.T("Approximately %d hours", hours)
 }
 
 // sanitizeID creates a valid ID from a string
@@ -0,0 +1,139 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/knowledge"
+)
+
+// deferredMigration is an API migration that can't run before the upgrade, because the current
+// cluster doesn't serve the replacement API yet
+type deferredMigration struct {
+	step *UpgradeStep
+	hop  string // intermediate version to migrate at; empty to migrate after the cluster upgrade
+}
+
+// migrationHop returns the intermediate version to migrate a removed API at: the last minor release
+// still serving it, which also serves the replacement during the deprecation period. It is empty when
+// that release is not between the current and the target version.
+func migrationHop(currentVersion, targetVersion, removedIn string) string {
+	parts := strings.Split(strings.TrimPrefix(removedIn, "v"), ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor == 0 {
+		return ""
+	}
+
+	hop := fmt.Sprintf("%s.%d", parts[0], minor-1)
+	if knowledge.CompareVersions(hop, currentVersion) <= 0 || knowledge.CompareVersions(hop, targetVersion) >= 0 {
+		return ""
+	}
+	return hop
+}
+
+// deferMigration turns a migration step into one that runs once the replacement API is served
+func (p *Planner) deferMigration(step *UpgradeStep, api analysis.DeprecatedAPIImpact, assessment *analysis.ImpactAssessment) deferredMigration {
+	hop := migrationHop(assessment.CurrentVersion, assessment.TargetVersion, api.RemovedIn)
+	version := hop
+	if hop == "" {
+		version = assessment.TargetVersion
+	}
+
+	step.Description = p.localizer.T("Migrate %s %s to %s after upgrading to %s", analysis.GroupVersion(api.Group, api.Version), api.Kind, api.ReplacementAPI, version)
+	step.Actions = append([]Action{
+		{
+			Command:     fmt.Sprintf("kubectl api-versions | grep -x %s", api.ReplacementAPI),
+			Description: p.localizer.T("Verify %s is served before migrating", api.ReplacementAPI),
+			Required:    true,
+		},
+	}, step.Actions...)
+
+	return deferredMigration{step: step, hop: hop}
+}
+
+// addDeferredMigrations schedules migrations whose replacement API the current version doesn't
+// serve. Migrations with a hop run after an intermediate upgrade to it; hops are chained in version
+// order after the pre-upgrade steps and before the cluster upgrade. The others run after the cluster
+// upgrade; their IDs are returned so validation can wait for them.
+func (p *Planner) addDeferredMigrations(assessment *analysis.ImpactAssessment, deferred []deferredMigration, preUpgrade []string, clusterUpgrade *UpgradeStep) []string {
+	var postUpgrade []string
+	byHop := make(map[string][]*UpgradeStep)
+	var hops []string
+
+	for _, migration := range deferred {
+		if migration.hop == "" {
+			migration.step.Dependencies = append(migration.step.Dependencies, clusterUpgrade.ID)
+			p.addNode(migration.step)
+			p.addEdge(clusterUpgrade.ID, migration.step.ID)
+			postUpgrade = append(postUpgrade, migration.step.ID)
+			continue
+		}
+		if _, found := byHop[migration.hop]; !found {
+			hops = append(hops, migration.hop)
+		}
+		byHop[migration.hop] = append(byHop[migration.hop], migration.step)
+	}
+	if len(hops) == 0 {
+		return postUpgrade
+	}
+
+	sort.Slice(hops, func(i, j int) bool {
+		return knowledge.CompareVersions(hops[i], hops[j]) < 0
+	})
+
+	previous := preUpgrade
+	fromVersion := assessment.CurrentVersion
+	for _, version := range hops {
+		hop := p.createHopStep(fromVersion, version)
+		for _, id := range previous {
+			hop.Dependencies = append(hop.Dependencies, id)
+			p.addEdge(id, hop.ID)
+		}
+		p.addNode(hop)
+
+		previous = nil
+		for _, step := range byHop[version] {
+			step.Dependencies = append(step.Dependencies, hop.ID)
+			p.addNode(step)
+			p.addEdge(hop.ID, step.ID)
+			previous = append(previous, step.ID)
+		}
+		fromVersion = version
+	}
+
+	for _, id := range previous {
+		clusterUpgrade.Dependencies = append(clusterUpgrade.Dependencies, id)
+		p.addEdge(id, clusterUpgrade.ID)
+	}
+	clusterUpgrade.Description = p.localizer.T("Upgrade Kubernetes from %s to %s", fromVersion, assessment.TargetVersion)
+
+	return postUpgrade
+}
+
+// createHopStep creates an intermediate cluster upgrade
+func (p *Planner) createHopStep(fromVersion, toVersion string) *UpgradeStep {
+	return &UpgradeStep{
+		ID:          fmt.Sprintf("cluster-upgrade-%s", sanitizeID(toVersion)),
+		Description: p.localizer.T("Upgrade Kubernetes from %s to intermediate version %s", fromVersion, toVersion),
+		Type:        StepClusterUpgrade,
+		Impact:      analysis.ImpactCritical,
+		Actions: []Action{
+			{
+				Command:     fmt.Sprintf("kubeadm upgrade apply %s", toVersion),
+				Description: p.localizer.T("Apply Kubernetes upgrade"),
+				Required:    true,
+			},
+			{
+				Command:     "kubectl get nodes",
+				Description: p.localizer.T("Verify all nodes are ready"),
+				Required:    true,
+			},
+		},
+	}
+}
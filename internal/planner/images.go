@@ -0,0 +1,111 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// kubernetesImages are the control plane images released with each Kubernetes version
+var kubernetesImages = []string{
+	"registry.k8s.io/kube-apiserver",
+	"registry.k8s.io/kube-controller-manager",
+	"registry.k8s.io/kube-scheduler",
+	"registry.k8s.io/kube-proxy",
+}
+
+// Image is a container image the upgrade needs
+type Image struct {
+	Reference string
+	Source    string // what deploys the image, e.g. "kubernetes" or "chart cert-manager v1.13.0"
+}
+
+// MirrorTools are the supported image copy tools
+var MirrorTools = []string{"skopeo", "crane"}
+
+// createImageList lists the images the upgrade pulls, so air-gapped environments can mirror them
+// before the upgrade window. Images that can't be resolved from the assessment get a command
+// listing them instead.
+func (p *Planner) createImageList(assessment *analysis.ImpactAssessment) ([]Image, []string) {
+	var images []Image
+	var commands []string
+	seen := make(map[string]bool)
+	add := func(reference, source string) {
+		if !seen[reference] {
+			seen[reference] = true
+			images = append(images, Image{Reference: reference, Source: source})
+		}
+	}
+
+	// Image tags need a patch release; kubeadm resolves the latest one otherwise
+	target := strings.TrimPrefix(assessment.TargetVersion, "v")
+	if strings.Count(target, ".") >= 2 {
+		for _, image := range kubernetesImages {
+			add(fmt.Sprintf("%s:v%s", image, target), "kubernetes")
+		}
+		commands = append(commands, fmt.Sprintf("kubeadm config images list --kubernetes-version v%s", target))
+	} else {
+		commands = append(commands, fmt.Sprintf("kubeadm config images list --kubernetes-version stable-%s", target))
+	}
+
+	for _, chart := range assessment.IncompatibleCharts {
+		if chart.RecommendedVersion == "" {
+			continue
+		}
+		source := fmt.Sprintf("chart %s %s", chart.ChartName, chart.RecommendedVersion)
+		if len(chart.RecommendedImages) == 0 {
+			commands = append(commands, fmt.Sprintf("helm template %s <repo>/%s --version %s -n %s | grep 'image:' | sort -u",
+				chart.ChartName, chart.ChartName, chart.RecommendedVersion, chart.Namespace))
+			continue
+		}
+		for _, image := range chart.RecommendedImages {
+			add(image, source)
+		}
+	}
+
+	for _, addon := range assessment.Addons {
+		if addon.Image != "" {
+			add(addon.Image, fmt.Sprintf("addon %s %s", addon.Addon, addon.TargetVersion))
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Reference < images[j].Reference
+	})
+
+	return images, commands
+}
+
+// MirrorCommands returns the commands copying images into a mirror registry with skopeo or crane,
+// keeping their repository paths, e.g. registry.k8s.io/kube-proxy -> <mirror>/kube-proxy
+func MirrorCommands(images []Image, tool, mirror string) ([]string, error) {
+	mirror = strings.TrimSuffix(mirror, "/")
+	commands := make([]string, 0, len(images))
+	for _, image := range images {
+		destination := mirror + "/" + repositoryPath(image.Reference)
+		switch tool {
+		case "skopeo":
+			commands = append(commands, fmt.Sprintf("skopeo copy --all docker://%s docker://%s", image.Reference, destination))
+		case "crane":
+			commands = append(commands, fmt.Sprintf("crane copy %s %s", image.Reference, destination))
+		default:
+			return nil, fmt.Errorf("unsupported mirror tool %q (supported: %s)", tool, strings.Join(MirrorTools, ", "))
+		}
+	}
+	return commands, nil
+}
+
+// repositoryPath strips the registry host from an image reference, e.g.
+// "quay.io/jetstack/cert-manager-controller:v1.13.0" -> "jetstack/cert-manager-controller:v1.13.0"
+func repositoryPath(reference string) string {
+	host, path, found := strings.Cut(reference, "/")
+	if !found {
+		return "library/" + reference
+	}
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return path
+	}
+	return reference
+}
@@ -0,0 +1,63 @@
+package planner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// createMeshSteps creates a step per service mesh control plane to upgrade to a version supporting
+// the target, split into the meshes upgraded before the cluster and those upgraded after the
+// control plane because no version supports both Kubernetes versions. Meshes that can't skip that
+// many minor versions get a step per hop, each depending on the one before.
+func (p *Planner) createMeshSteps(findings []analysis.MeshFinding) (before, after []*UpgradeStep) {
+	for _, finding := range findings {
+		if finding.RecommendedVersion == "" {
+			continue
+		}
+		hops := append(slices.Clip(finding.Hops), analysis.MeshHop{Version: finding.RecommendedVersion, Commands: finding.Commands})
+		id := fmt.Sprintf("mesh-upgrade-%s-%s", sanitizeID(finding.Mesh), sanitizeID(finding.Resource))
+		from := finding.Version
+		var previous *UpgradeStep
+		for _, hop := range hops {
+			step := p.createMeshStep(finding, from, hop)
+			step.ID = id
+			if len(hops) > 1 {
+				step.ID = fmt.Sprintf("%s-%s", id, sanitizeID(hop.Version))
+			}
+			if previous != nil {
+				step.Dependencies = append(step.Dependencies, previous.ID)
+				p.addEdge(previous.ID, step.ID)
+			}
+			if finding.BeforeUpgrade {
+				before = append(before, step)
+			} else {
+				after = append(after, step)
+			}
+			from, previous = hop.Version, step
+		}
+	}
+	return before, after
+}
+
+// createMeshStep creates the step upgrading a mesh from a version to a hop
+func (p *Planner) createMeshStep(finding analysis.MeshFinding, from string, hop analysis.MeshHop) *UpgradeStep {
+	step := &UpgradeStep{
+		Description: p.localizer.T("Upgrade %s from %s to %s", finding.Mesh, from, hop.Version),
+		Type:        StepMeshUpgrade,
+		Impact:      finding.Severity,
+		Targets:     []string{finding.Resource},
+	}
+	if finding.Canary {
+		step.Description = p.localizer.T("Canary upgrade of %s from %s to %s", finding.Mesh, from, hop.Version)
+	}
+	for _, command := range hop.Commands {
+		step.Actions = append(step.Actions, Action{
+			Command:     command,
+			Description: p.localizer.T("Upgrade %s", finding.Mesh),
+			Required:    true,
+		})
+	}
+	return step
+}
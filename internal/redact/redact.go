@@ -0,0 +1,425 @@
+// Package redact strips internal naming from assessments and plans, so they can be shared with
+// vendors or external consultants. GVKs, counts, versions and chart names are kept.
+//
+// Fields are allowlisted: names are pseudonymized, free text has those names replaced, fields
+// known to be safe are kept and every other string field is removed, so a field added to a
+// finding stays hidden until it is listed here.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces values that are removed outright rather than pseudonymized
+const Redacted = "[redacted]"
+
+// nameKinds maps the JSON fields holding names to the kind of name, which prefixes its pseudonym
+var nameKinds = map[string]string{
+	"clusterId":           "cluster",
+	"namespace":           "namespace",
+	"workload":            "workload",
+	"pod":                 "workload",
+	"ingress":             "workload",
+	"release":             "workload",
+	"name":                "workload",
+	"node":                "node",
+	"nodes":               "node",
+	"cordonedNodes":       "node",
+	"team":                "team",
+	"teams":               "team",
+	"podSecurityPolicy":   "policy",
+	"podSecurityPolicies": "policy",
+	"runtimeClasses":      "runtimeclass",
+}
+
+// referenceFields hold object references, e.g. namespace/name or mesh:namespace/name
+var referenceFields = map[string]bool{
+	"resource":  true,
+	"resources": true,
+	"affected":  true,
+	"adapter":   true,
+	"added":     true,
+	"removed":   true,
+	"changed":   true,
+	"Targets":   true,
+}
+
+// sourceFields hold file paths and other locations, pseudonymized as a whole
+var sourceFields = map[string]bool{
+	"sourceFile": true,
+	"manifest":   true,
+	"sources":    true,
+}
+
+// keptFields hold GVKs and chart names, which are never redacted even where they match a name
+var keptFields = map[string]bool{
+	"kind":             true,
+	"group":            true,
+	"replacementKind":  true,
+	"replacementGroup": true,
+	"chart":            true,
+	"chartName":        true,
+}
+
+// safeFields hold versions, rule metadata, enumerations and knowledge base content such as
+// public documentation links, which are shown as-is
+var safeFields = map[string]bool{
+	"apiVersion":         true,
+	"version":            true,
+	"replacementAPI":     true,
+	"replacementVersion": true,
+	"replacement":        true, // e.g. a CSI driver or flag from the knowledge base
+	"storedVersion":      true,
+	"deprecatedIn":       true,
+	"removedIn":          true,
+	"changedIn":          true,
+	"fromVersion":        true,
+	"toVersion":          true,
+	"FromVersion":        true,
+	"ToVersion":          true,
+	"currentVersion":     true,
+	"targetVersion":      true,
+	"recommendedVersion": true,
+	"deployedVersion":    true,
+	"pinnedVersion":      true,
+	"operatorVersion":    true,
+	"minKubeVersion":     true,
+	"maxKubeVersion":     true,
+	"crd":                true, // plural.group, as public as the group
+	"removedAPIs":        true,
+	"ruleId":             true,
+	"category":           true,
+	"severity":           true,
+	"impactLevel":        true,
+	"overallRisk":        true,
+	"Impact":             true,
+	"type":               true,
+	"Type":               true,
+	"level":              true,
+	"source":             true, // e.g. "manifest" or "crd"; see parentFields
+	"origin":             true,
+	"releaseStatus":      true,
+	"criticality":        true,
+	"operator":           true,
+	"mesh":               true,
+	"plugin":             true,
+	"addon":              true,
+	"component":          true,
+	"controller":         true,
+	"provisioner":        true,
+	"annotation":         true,
+	"flag":               true,
+	"setting":            true,
+	"values":             true, // matched node settings, e.g. feature gate names
+	"field":              true,
+	"path":               true,
+	"newPath":            true,
+	"unsupported":        true,
+	"metricTypes":        true,
+	"platform":           true,
+	"missingPlatforms":   true,
+	"recommendedImages":  true,
+	"extendedResources":  true,
+	"upstreamDocs":       true,
+	"docsUrl":            true,
+	"createdAt":          true,
+	"lastDeployed":       true,
+}
+
+// textFields hold free text such as messages and commands, shown with the names collected from
+// the other fields replaced
+var textFields = map[string]bool{
+	"description":         true,
+	"Description":         true,
+	"message":             true,
+	"summary":             true,
+	"notes":               true,
+	"migrationNotes":      true,
+	"reason":              true,
+	"recommendation":      true,
+	"problem":             true,
+	"change":              true,
+	"issues":              true,
+	"detail":              true,
+	"error":               true,
+	"incompleteInventory": true,
+	"commands":            true,
+	"Command":             true,
+	"ImageCommands":       true,
+	"Timeline":            true,
+	"Source":              true, // what deploys a plan image, e.g. "chart cert-manager v1.13.0"
+	"ID":                  true,
+	"Dependencies":        true,
+	"OrderedUpgradeSteps": true,
+	"orderedUpgradeSteps": true,
+}
+
+// mapFields hold maps, whose keys are kept and whose values are treated like the given field
+var mapFields = map[string]string{
+	"scanErrors": "error",
+	"rewrites":   "path",
+}
+
+// parentFields treat a field of some findings, as parent.field, like another field
+var parentFields = map[string]string{
+	"hpas.source":             "sourceFile",        // the HPA's manifest file
+	"devicePlugins.resources": "extendedResources", // e.g. nvidia.com/gpu, not object references
+}
+
+// wellKnownNamespaces are created by Kubernetes itself and reveal nothing
+var wellKnownNamespaces = []string{"default", "kube-system", "kube-public", "kube-node-lease"}
+
+// Redactor replaces the names found in values with stable pseudonyms such as namespace-1 and
+// workload-3, both in the fields holding them and wherever they appear in text, e.g. commands
+type Redactor struct {
+	pseudonyms map[string]string
+	counts     map[string]int
+	kept       map[string]bool
+}
+
+// NewRedactor creates a redactor
+func NewRedactor() *Redactor {
+	kept := make(map[string]bool)
+	for _, namespace := range wellKnownNamespaces {
+		kept[namespace] = true
+	}
+	return &Redactor{
+		pseudonyms: make(map[string]string),
+		counts:     make(map[string]int),
+		kept:       kept,
+	}
+}
+
+// Redact redacts values in place; each must be a non-nil pointer to a JSON-serializable value.
+// Names are collected from all values first, so a name gets the same pseudonym in all of them,
+// e.g. in an assessment and its plan.
+func (r *Redactor) Redact(values ...any) error {
+	documents := make([]any, len(values))
+	for i, value := range values {
+		if value == nil || reflect.ValueOf(value).IsNil() {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&documents[i]); err != nil {
+			return fmt.Errorf("failed to decode value: %w", err)
+		}
+		r.keep(documents[i], "")
+	}
+	for _, document := range documents {
+		r.collect(document, "", "")
+	}
+
+	for i, value := range values {
+		if documents[i] == nil {
+			continue
+		}
+		data, err := json.Marshal(r.replace(documents[i], "", ""))
+		if err != nil {
+			return fmt.Errorf("failed to marshal redacted value: %w", err)
+		}
+		target := reflect.ValueOf(value).Elem()
+		target.Set(reflect.Zero(target.Type()))
+		if err := json.Unmarshal(data, value); err != nil {
+			return fmt.Errorf("failed to decode redacted value: %w", err)
+		}
+	}
+	return nil
+}
+
+// keep records the GVKs and chart names of a document
+func (r *Redactor) keep(node any, key string) {
+	switch node := node.(type) {
+	case map[string]any:
+		for field, child := range node {
+			r.keep(child, field)
+		}
+	case []any:
+		for _, child := range node {
+			r.keep(child, key)
+		}
+	case string:
+		if keptFields[key] {
+			r.kept[node] = true
+		}
+	}
+}
+
+// collect assigns pseudonyms to the names of a document, walking fields in sorted order so the
+// numbering is stable across runs
+func (r *Redactor) collect(node any, key, parent string) {
+	switch node := node.(type) {
+	case map[string]any:
+		fields := make([]string, 0, len(node))
+		for field := range node {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			r.collect(node[field], childField(key, field), key)
+		}
+	case []any:
+		for _, child := range node {
+			r.collect(child, key, parent)
+		}
+	case string:
+		switch field := fieldOf(key, parent); {
+		case nameKinds[field] != "" && strings.Contains(node, "/"):
+			r.reference(node, nameKinds[field])
+		case nameKinds[field] != "":
+			r.pseudonym(node, nameKinds[field])
+		case sourceFields[field]:
+			r.pseudonym(node, "source")
+		case referenceFields[field]:
+			r.reference(node, "workload")
+		}
+	}
+}
+
+// childField returns the field a map entry is treated as: the map's value field for mapFields,
+// else the entry's own key
+func childField(key, field string) string {
+	if mapped := mapFields[key]; mapped != "" {
+		return mapped
+	}
+	return field
+}
+
+// fieldOf returns the field a value is treated as, applying parentFields
+func fieldOf(key, parent string) string {
+	if field, ok := parentFields[parent+"."+key]; ok {
+		return field
+	}
+	return key
+}
+
+// reference assigns pseudonyms to a name, or to the namespace and name of an object reference
+// such as namespace/name or mesh:namespace/name. GVKs, e.g. in baseline findings, are left alone,
+// and the names of Kind/name references are named after their kind.
+func (r *Redactor) reference(value, kind string) {
+	if _, rest, found := strings.Cut(value, ":"); found {
+		value = rest // the prefix is a mesh or device plugin, not a name
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) > 2 {
+		return
+	}
+	name := parts[len(parts)-1]
+	if isKind(name) {
+		return
+	}
+	if len(parts) == 2 {
+		if isKind(parts[0]) {
+			kind = strings.ToLower(parts[0])
+		} else {
+			r.pseudonym(parts[0], "namespace")
+		}
+	}
+	r.pseudonym(name, kind)
+}
+
+// pseudonym assigns the next pseudonym of a kind to a name, unless it has one or is kept
+func (r *Redactor) pseudonym(name, kind string) {
+	if name == "" || r.kept[name] || r.pseudonyms[name] != "" {
+		return
+	}
+	r.counts[kind]++
+	r.pseudonyms[name] = fmt.Sprintf("%s-%d", kind, r.counts[kind])
+}
+
+// replace returns a document with names replaced by their pseudonyms, free text with names
+// replaced and the values of fields that are neither names, text nor safe removed
+func (r *Redactor) replace(node any, key, parent string) any {
+	switch node := node.(type) {
+	case map[string]any:
+		for field, child := range node {
+			node[field] = r.replace(child, childField(key, field), key)
+		}
+		return node
+	case []any:
+		for i, child := range node {
+			node[i] = r.replace(child, key, parent)
+		}
+		return node
+	case string:
+		field := fieldOf(key, parent)
+		switch {
+		case keptFields[field] || safeFields[field] || node == "":
+			return node
+		case nameKinds[field] == "" && !referenceFields[field] && !sourceFields[field] && !textFields[field]:
+			return Redacted
+		case r.pseudonyms[node] != "":
+			return r.pseudonyms[node] // whole names, e.g. paths, aren't always single tokens
+		}
+		return r.Text(node)
+	}
+	return node
+}
+
+// Text replaces the names collected so far in free text. Names are matched as whole tokens, or as
+// the labels of a dotted name such as a service's DNS name.
+func (r *Redactor) Text(text string) string {
+	if len(r.pseudonyms) == 0 {
+		return text
+	}
+
+	var out strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			out.WriteString(r.token(text[start:end]))
+			start = -1
+		}
+	}
+	for i, c := range text {
+		if isNameRune(c) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		out.WriteRune(c)
+	}
+	flush(len(text))
+	return out.String()
+}
+
+// token replaces a run of name characters, keeping a trailing period that ends a sentence
+func (r *Redactor) token(token string) string {
+	trimmed := strings.TrimRight(token, ".")
+	suffix := token[len(trimmed):]
+	if pseudonym, ok := r.pseudonyms[trimmed]; ok {
+		return pseudonym + suffix
+	}
+	if !strings.Contains(trimmed, ".") {
+		return token
+	}
+	labels := strings.Split(trimmed, ".")
+	for i, label := range labels {
+		if pseudonym, ok := r.pseudonyms[label]; ok {
+			labels[i] = pseudonym
+		}
+	}
+	return strings.Join(labels, ".") + suffix
+}
+
+// isNameRune reports whether a rune can be part of a Kubernetes object name
+func isNameRune(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '.' || c == '_')
+}
+
+// isKind reports whether a reference part is a kind, e.g. StorageClass; object names are lowercase
+func isKind(part string) bool {
+	return part != "" && unicode.IsUpper(rune(part[0]))
+}
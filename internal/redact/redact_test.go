@@ -0,0 +1,183 @@
+package redact
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// secret prefixes every name the test fills in, so leaks are easy to find
+const secret = "acme"
+
+// populate sets every exported field of a value, recursively: strings to acme-<field>, free text
+// to a sentence mentioning names, numbers to 1, and slices and maps to a single element
+func populate(v reflect.Value, field, parent string, depth int) {
+	if depth > 12 {
+		return
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem(), field, parent, depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			populate(v.Field(i), name, field, depth+1)
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0), field, parent, depth+1)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key := reflect.New(v.Type().Key()).Elem()
+		populate(key, "key", field, depth+1)
+		value := reflect.New(v.Type().Elem()).Elem()
+		populate(value, mapFields[field], field, depth+1)
+		v.SetMapIndex(key, value)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(secret + "-" + field))
+	case reflect.String:
+		switch field := fieldOf(field, parent); {
+		case field == "key":
+			v.SetString("key")
+		case textFields[field]:
+			v.SetString("Move acme-namespace/acme-workload of acme-release off acme-node.")
+		case referenceFields[field]:
+			v.SetString("acme-namespace/acme-workload")
+		default:
+			v.SetString(secret + "-" + strings.ToLower(field))
+		}
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	}
+}
+
+func TestRedactAssessmentGolden(t *testing.T) {
+	response := &planner.UpgradeAssessmentWithPlan{}
+	populate(reflect.ValueOf(response).Elem(), "", "", 0)
+
+	if err := NewRedactor().Redact(response); err != nil {
+		t.Fatalf("Redact: %v", err)
+	}
+	redacted, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted = append(redacted, '\n')
+
+	golden := filepath.Join("testdata", "assessment.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, redacted, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(redacted) != string(want) {
+		t.Errorf("redacted assessment differs from %s; run go test -update and review the diff", golden)
+	}
+
+	var document any
+	if err := json.Unmarshal(redacted, &document); err != nil {
+		t.Fatal(err)
+	}
+	checkLeaks(t, document, "", "")
+}
+
+// checkLeaks fails for names left in fields that are not allowlisted as safe
+func checkLeaks(t *testing.T, node any, key, parent string) {
+	t.Helper()
+	switch node := node.(type) {
+	case map[string]any:
+		for field, child := range node {
+			checkLeaks(t, child, childField(key, field), key)
+		}
+	case []any:
+		for _, child := range node {
+			checkLeaks(t, child, key, parent)
+		}
+	case string:
+		field := fieldOf(key, parent)
+		if !keptFields[field] && !safeFields[field] && strings.Contains(node, secret) {
+			t.Errorf("%s.%s leaks %q", parent, key, node)
+		}
+	}
+}
+
+func TestRedactKeepsWellKnownNamespaces(t *testing.T) {
+	response := &planner.UpgradeAssessmentWithPlan{}
+	populate(reflect.ValueOf(response).Elem(), "", "", 0)
+	response.IncompatibleCharts[0].Namespace = "kube-system"
+	response.IncompatibleCharts[0].Message = "Upgrade acme-release in kube-system"
+
+	if err := NewRedactor().Redact(response); err != nil {
+		t.Fatalf("Redact: %v", err)
+	}
+	chart := response.IncompatibleCharts[0]
+	if chart.Namespace != "kube-system" {
+		t.Errorf("namespace = %s, want kube-system", chart.Namespace)
+	}
+	if chart.Message != "Upgrade "+chart.Release+" in kube-system" || strings.Contains(chart.Message, secret) {
+		t.Errorf("message = %q, want the release pseudonym and kube-system", chart.Message)
+	}
+}
+
+func TestRedactCSVRows(t *testing.T) {
+	assessment := &analysis.ImpactAssessment{
+		ClusterID: "acme-prod",
+		IncompatibleCharts: []analysis.ChartImpact{
+			{ChartName: "ingress-nginx", Namespace: "acme-edge", Release: "acme-public", ImpactLevel: analysis.ImpactHigh, Message: "acme-public is incompatible"},
+		},
+	}
+	analysis.AssignRuleIDs(assessment)
+	ownership := &analysis.OwnershipMap{Namespaces: map[string]string{"acme-edge": "acme-networking"}}
+	rows := analysis.CSVRows(assessment, ownership)
+
+	if err := NewRedactor().Redact(assessment, &rows); err != nil {
+		t.Fatalf("Redact: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	row := rows[0]
+	if len(row.Owners) != 1 || row.Owners[0] != "team-1" {
+		t.Errorf("owners = %v, want the pseudonym of the namespace's team", row.Owners)
+	}
+	chart := assessment.IncompatibleCharts[0]
+	if row.Object != chart.Namespace+"/"+chart.Release || row.Cluster != assessment.ClusterID {
+		t.Errorf("row = %+v, want the pseudonyms of the assessment", row)
+	}
+	if data, _ := analysis.RenderCSV(rows); strings.Contains(string(data), secret) {
+		t.Errorf("CSV leaks names:\n%s", data)
+	}
+}
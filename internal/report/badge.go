@@ -0,0 +1,98 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"unicode/utf8"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// badgeLabel is the left-hand text of readiness badges
+const badgeLabel = "upgrade-ready"
+
+// badgeColors maps the overall risk of an assessment to a badge color name and its hex value
+var badgeColors = map[analysis.ImpactLevel][2]string{
+	analysis.ImpactNone:     {"brightgreen", "#4c1"},
+	analysis.ImpactLow:      {"green", "#97ca00"},
+	analysis.ImpactMedium:   {"yellow", "#dfb317"},
+	analysis.ImpactHigh:     {"orange", "#fe7d37"},
+	analysis.ImpactCritical: {"red", "#e05d44"},
+}
+
+// unknownColor is the color of badges without an assessment
+var unknownColor = [2]string{"lightgrey", "#9f9f9f"}
+
+// Badge is an upgrade readiness badge. Its JSON form follows the shields.io endpoint schema, so
+// it can also be served through shields.io.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	hex           string
+}
+
+// NewBadge builds the readiness badge of an assessment for a target version from its issue
+// count and overall risk, e.g. "1.29 ✗ 12 blockers"
+func NewBadge(targetVersion string, totalIssues int, risk analysis.ImpactLevel) Badge {
+	color, ok := badgeColors[risk]
+	if !ok {
+		color = unknownColor
+	}
+
+	message := fmt.Sprintf("%s ✓", targetVersion)
+	switch {
+	case totalIssues == 1:
+		message = fmt.Sprintf("%s ✗ 1 blocker", targetVersion)
+	case totalIssues > 1:
+		message = fmt.Sprintf("%s ✗ %d blockers", targetVersion, totalIssues)
+	}
+
+	return Badge{SchemaVersion: 1, Label: badgeLabel, Message: message, Color: color[0], hex: color[1]}
+}
+
+// NewUnknownBadge builds the badge of a target version that has no stored assessment
+func NewUnknownBadge(targetVersion string) Badge {
+	return Badge{
+		SchemaVersion: 1,
+		Label:         badgeLabel,
+		Message:       fmt.Sprintf("%s not assessed", targetVersion),
+		Color:         unknownColor[0],
+		hex:           unknownColor[1],
+	}
+}
+
+// SVG renders the badge in the flat style of shields.io. Text widths are estimated from the
+// character count, since the font isn't available to measure them.
+func (b Badge) SVG() []byte {
+	labelWidth := badgeTextWidth(b.Label)
+	messageWidth := badgeTextWidth(b.Message)
+	width := labelWidth + messageWidth
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(&buf, `<title>%s: %s</title>`, label, message)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&buf, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&buf, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, b.hex, width)
+	buf.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, text := range []struct {
+		value  string
+		center int
+	}{{label, labelWidth / 2}, {message, labelWidth + messageWidth/2}} {
+		fmt.Fprintf(&buf, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`,
+			text.center, text.value, text.center, text.value)
+	}
+	buf.WriteString(`</g></svg>`)
+	return buf.Bytes()
+}
+
+// badgeTextWidth estimates the width of a badge half in pixels, with 5px padding on each side
+func badgeTextWidth(text string) int {
+	return utf8.RuneCountInString(text)*7 + 10
+}
@@ -0,0 +1,268 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// pdfLineHeight is the default text line height in millimeters
+const pdfLineHeight = 6
+
+// signOffRoles are the approval rows printed on the sign-off page
+var signOffRoles = []string{"Prepared by", "Technical review", "Application owner", "Change approval (CAB)"}
+
+// pdfUnsupportedLanguages are the report languages the cp1252 core fonts cannot represent
+var pdfUnsupportedLanguages = map[string]bool{"ja": true}
+
+// CheckPDFLanguage reports an error if a PDF report cannot be rendered in a language
+func CheckPDFLanguage(lang string) error {
+	if pdfUnsupportedLanguages[lang] {
+		return fmt.Errorf("PDF reports cannot be rendered in %q: the PDF core fonts only cover Western European scripts", lang)
+	}
+	return nil
+}
+
+// pdfWriter wraps fpdf with helpers for the assessment layout. The core fonts only cover
+// cp1252, so all text goes through the translator.
+type pdfWriter struct {
+	pdf       *fpdf.Fpdf
+	translate func(string) string
+	width     float64 // printable width
+}
+
+// WritePDF renders a change-management report: an executive summary page, finding tables,
+// the upgrade plan and a sign-off page with placeholders for approvers
+func WritePDF(w io.Writer, data *Data) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Upgrade Assessment %s", data.Assessment.ClusterID), true)
+	pdf.SetCreator("kube-upgrade-advisor", true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("{nb}")
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	p := &pdfWriter{
+		pdf:       pdf,
+		translate: pdf.UnicodeTranslatorFromDescriptor(""),
+		width:     pageWidth - left - right,
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 8, p.text(fmt.Sprintf("%s -> %s | Generated %s | Page %d/{nb}",
+			data.Assessment.CurrentVersion, data.Assessment.TargetVersion,
+			data.GeneratedAt.Format("2006-01-02 15:04 MST"), pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+
+	p.summaryPage(data)
+	p.findingsSection(data)
+	p.planSection(data.Plan)
+	p.signOffPage()
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// summaryPage prints the executive summary
+func (p *pdfWriter) summaryPage(data *Data) {
+	assessment := data.Assessment
+	p.pdf.AddPage()
+
+	p.pdf.SetFont("Helvetica", "B", 20)
+	p.pdf.CellFormat(0, 12, "Kubernetes Upgrade Assessment", "", 1, "L", false, 0, "")
+	p.pdf.Ln(4)
+
+	rows := [][2]string{
+		{"Cluster", assessment.ClusterID},
+		{"Current version", assessment.CurrentVersion},
+		{"Target version", assessment.TargetVersion},
+		{"Overall risk", strings.ToUpper(string(assessment.OverallRisk))},
+		{"Total issues", fmt.Sprintf("%d", assessment.TotalIssues)},
+		{"Generated", data.GeneratedAt.Format("2006-01-02 15:04 MST")},
+	}
+	for _, row := range rows {
+		p.pdf.SetFont("Helvetica", "B", 11)
+		p.pdf.CellFormat(50, 8, p.text(row[0]), "B", 0, "L", false, 0, "")
+		p.pdf.SetFont("Helvetica", "", 11)
+		p.pdf.CellFormat(p.width-50, 8, p.text(row[1]), "B", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(8)
+
+	counts := make(map[analysis.ImpactLevel]int)
+	for _, finding := range data.Findings {
+		counts[finding.Severity]++
+	}
+
+	p.heading("Executive Summary")
+	p.pdf.SetFont("Helvetica", "", 11)
+	summary := fmt.Sprintf("Upgrading %s from Kubernetes %s to %s is assessed as %s risk. The analysis found %d findings: "+
+		"%d critical, %d high, %d medium and %d low.",
+		assessment.ClusterID, assessment.CurrentVersion, assessment.TargetVersion, assessment.OverallRisk,
+		len(data.Findings), counts[analysis.ImpactCritical], counts[analysis.ImpactHigh], counts[analysis.ImpactMedium], counts[analysis.ImpactLow])
+	if counts[analysis.ImpactCritical] > 0 {
+		summary += " Critical findings must be resolved before the upgrade, as affected workloads will fail to deploy or run on the target version."
+	}
+	if assessment.Baseline != nil {
+		summary += fmt.Sprintf(" Compared to the accepted baseline, %d findings are new and %d were fixed.",
+			len(assessment.Baseline.NewFindings), assessment.Baseline.FixedCount)
+	}
+	if data.Plan != nil {
+		summary += fmt.Sprintf(" The upgrade plan has %d steps with an estimated duration of %s.",
+			data.Plan.TotalSteps, strings.ToLower(data.Plan.Timeline))
+	}
+	p.pdf.MultiCell(0, pdfLineHeight, p.text(summary), "", "L", false)
+}
+
+// findingsSection prints one table per rule category
+func (p *pdfWriter) findingsSection(data *Data) {
+	p.pdf.AddPage()
+	p.heading("Findings")
+
+	if len(data.Findings) == 0 {
+		p.pdf.SetFont("Helvetica", "", 11)
+		p.pdf.CellFormat(0, 8, "No findings.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	byCategory := make(map[string][]analysis.FindingRef)
+	var categories []string
+	for _, finding := range data.Findings {
+		category := "other"
+		if rule, found := analysis.RuleByID(finding.RuleID); found {
+			category = rule.Category
+		}
+		if _, seen := byCategory[category]; !seen {
+			categories = append(categories, category)
+		}
+		byCategory[category] = append(byCategory[category], finding)
+	}
+	sort.Strings(categories)
+
+	widths := []float64{26, 58, 20, p.width - 104}
+	for _, category := range categories {
+		p.pdf.SetFont("Helvetica", "B", 12)
+		p.pdf.CellFormat(0, 9, p.text(strings.ToUpper(category[:1])+category[1:]), "", 1, "L", false, 0, "")
+
+		p.tableRow(widths, []string{"Rule", "Resource", "Severity", "Summary"}, true)
+		for _, finding := range byCategory[category] {
+			p.tableRow(widths, []string{finding.RuleID, finding.Resource, string(finding.Severity), finding.Summary}, false)
+		}
+		p.pdf.Ln(6)
+	}
+}
+
+// planSection prints the ordered upgrade steps with their actions
+func (p *pdfWriter) planSection(plan *planner.UpgradePlan) {
+	if plan == nil {
+		return
+	}
+
+	p.pdf.AddPage()
+	p.heading("Upgrade Plan")
+	p.pdf.SetFont("Helvetica", "", 11)
+	p.pdf.CellFormat(0, 8, p.text(fmt.Sprintf("Estimated timeline: %s", plan.Timeline)), "", 1, "L", false, 0, "")
+	p.pdf.Ln(2)
+
+	steps := make([]planner.UpgradeStep, len(plan.Steps))
+	copy(steps, plan.Steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Order < steps[j].Order
+	})
+
+	for i, step := range steps {
+		p.pdf.SetFont("Helvetica", "B", 11)
+		p.pdf.MultiCell(0, pdfLineHeight, p.text(fmt.Sprintf("%d. %s", i+1, step.Description)), "", "L", false)
+		for _, action := range step.Actions {
+			p.pdf.SetFont("Helvetica", "", 10)
+			p.pdf.MultiCell(0, pdfLineHeight, p.text("    - "+action.Description), "", "L", false)
+			if action.Command != "" {
+				p.pdf.SetFont("Courier", "", 9)
+				p.pdf.MultiCell(0, 5, p.text("      "+action.Command), "", "L", false)
+			}
+		}
+		p.pdf.SetFont("Helvetica", "", 10)
+		p.pdf.CellFormat(0, pdfLineHeight, "    [ ] Completed      Operator: ______________      Time: ________", "", 1, "L", false, 0, "")
+		p.pdf.Ln(3)
+	}
+}
+
+// signOffPage prints approval placeholders
+func (p *pdfWriter) signOffPage() {
+	p.pdf.AddPage()
+	p.heading("Sign-off")
+
+	p.pdf.SetFont("Helvetica", "", 11)
+	p.pdf.MultiCell(0, pdfLineHeight, "By signing, approvers confirm they reviewed the findings and the upgrade plan above.", "", "L", false)
+	p.pdf.Ln(4)
+
+	widths := []float64{50, 50, 50, p.width - 150}
+	p.tableRow(widths, []string{"Role", "Name", "Signature", "Date"}, true)
+	for _, role := range signOffRoles {
+		p.pdf.SetFont("Helvetica", "", 10)
+		p.pdf.CellFormat(widths[0], 14, p.text(role), "1", 0, "L", false, 0, "")
+		for _, width := range widths[1:] {
+			p.pdf.CellFormat(width, 14, "", "1", 0, "L", false, 0, "")
+		}
+		p.pdf.Ln(-1)
+	}
+
+	p.pdf.Ln(8)
+	p.pdf.SetFont("Helvetica", "", 11)
+	p.pdf.CellFormat(0, 8, "Change ticket: ______________________", "", 1, "L", false, 0, "")
+	p.pdf.CellFormat(0, 8, "Planned maintenance window: ______________________", "", 1, "L", false, 0, "")
+}
+
+// heading prints a section heading
+func (p *pdfWriter) heading(title string) {
+	p.pdf.SetFont("Helvetica", "B", 15)
+	p.pdf.SetTextColor(0, 0, 0)
+	p.pdf.CellFormat(0, 10, p.text(title), "", 1, "L", false, 0, "")
+	p.pdf.Ln(2)
+}
+
+// tableRow prints a single-line table row, truncating cells that don't fit their column
+func (p *pdfWriter) tableRow(widths []float64, cells []string, header bool) {
+	if header {
+		p.pdf.SetFont("Helvetica", "B", 9)
+		p.pdf.SetFillColor(230, 230, 230)
+	} else {
+		p.pdf.SetFont("Helvetica", "", 9)
+	}
+
+	for i, cell := range cells {
+		p.pdf.CellFormat(widths[i], 7, p.fit(p.text(cell), widths[i]-2), "1", 0, "L", header, 0, "")
+	}
+	p.pdf.Ln(-1)
+}
+
+// fit truncates text with an ellipsis to fit a width in the current font
+func (p *pdfWriter) fit(text string, width float64) string {
+	if p.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && p.pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+// text converts UTF-8 to the core font encoding, dropping characters it can't represent (e.g. emoji)
+func (p *pdfWriter) text(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x2000 || r == '–' || r == '—' || r == '…' {
+			b.WriteRune(r)
+		}
+	}
+	return p.translate(b.String())
+}
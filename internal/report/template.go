@@ -0,0 +1,89 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// Data is the root object exposed to report templates
+type Data struct {
+	Assessment  *analysis.ImpactAssessment
+	Plan        *planner.UpgradePlan // nil when plan generation failed
+	Findings    []analysis.FindingRef
+	GeneratedAt time.Time
+}
+
+// NewData collects an assessment and its plan for rendering
+func NewData(assessment *analysis.ImpactAssessment, plan *planner.UpgradePlan) *Data {
+	return &Data{
+		Assessment:  assessment,
+		Plan:        plan,
+		Findings:    assessment.Findings(),
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// templateFuncs are helpers available in report templates in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"join":    strings.Join,
+	"replace": strings.ReplaceAll,
+	"repeat":  strings.Repeat,
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil || fmt.Sprint(value) == "" {
+			return fallback
+		}
+		return value
+	},
+	"json": func(value interface{}) (string, error) {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"atLeast": func(threshold string, level analysis.ImpactLevel) bool {
+		return level.AtLeast(analysis.ImpactLevel(threshold))
+	},
+}
+
+// RenderTemplate executes a user-provided Go template file against the report data. Files named
+// *.html or *.html.tmpl are parsed with html/template, so values are escaped for HTML.
+func RenderTemplate(w io.Writer, templatePath string, data *Data) error {
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read report template: %w", err)
+	}
+
+	name := filepath.Base(templatePath)
+	var tmpl interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+	if strings.HasSuffix(name, ".html") || strings.HasSuffix(name, ".html.tmpl") {
+		tmpl, err = htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Option("missingkey=error").Parse(string(content))
+	} else {
+		tmpl, err = template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report template: %w", err)
+	}
+	return nil
+}
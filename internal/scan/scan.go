@@ -0,0 +1,282 @@
+// Package scan stores the inventory of a live cluster, reporting its progress as events so the CLI
+// can print it and the server can stream it to clients
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/telemetry"
+)
+
+// Phases of a scan besides its subsystems (inventory.ScanServedAPIs and the like)
+const (
+	PhaseConnect = "connect"
+	PhaseDone    = "done"
+)
+
+// Event is a progress update of a scan: a message on entering or finishing a phase, a count of
+// the items processed so far, or the error of a subsystem that is skipped
+type Event struct {
+	Time      time.Time `json:"time"`
+	Phase     string    `json:"phase"`
+	Message   string    `json:"message,omitempty"`
+	Processed int       `json:"processed,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Options configures a cluster scan
+type Options struct {
+	// Kubeconfig, Context and Namespace select the Helm releases to scan, as for the kube client
+	Kubeconfig string
+	Context    string
+	Namespace  string
+	// HelmDrivers are the Helm storage drivers releases are read from; empty uses $HELM_DRIVER.
+	// Releases of other drivers than secret are always listed in full.
+	HelmDrivers []string
+	// HelmFilter selects the Helm releases stored, e.g. only deployed ones. Releases are always
+	// listed in full with a minimum age, since one becoming old enough changes nothing to track.
+	HelmFilter cluster.ReleaseFilter
+	// Incremental only processes the changes since the watermarks of the last scan
+	Incremental bool
+	// Progress receives the events of the scan; it is called from the scanning goroutine
+	Progress func(Event)
+	// Log receives the lines the CRD and Helm clients print per item stored; stdout when nil
+	Log io.Writer
+}
+
+func (o Options) report(event Event) {
+	if o.Progress == nil {
+		return
+	}
+	event.Time = time.Now()
+	o.Progress(event)
+}
+
+// Cluster stores the cluster's version, served APIs, node platforms, namespaces, CRDs, live objects
+// and Helm releases. A subsystem that fails, e.g. for lack of RBAC permissions, is recorded in the
+// returned scan errors and the others are still scanned. It returns the cluster version once the
+// cluster is stored, also when an interrupt stops a later step. The resourceVersion watermarks of
+// the subsystems scanned are stored for incremental scans, which then only process the changes
+// since; live objects are always listed in full, by their metadata only.
+func Cluster(ctx context.Context, store inventory.ScanStore, kubeClient *cluster.KubeClient, clusterID string, opts Options) (string, map[string]string, error) {
+	storeCtx := context.WithoutCancel(ctx)
+	scanErrors := make(map[string]string)
+
+	// Get cluster version
+	version, err := kubeClient.GetClusterVersion(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get cluster version: %w", err)
+	}
+	opts.report(Event{Phase: PhaseConnect, Message: fmt.Sprintf("Cluster version: %s", version)})
+
+	// Save cluster info
+	clusterRec, err := store.SaveCluster(storeCtx, clusterID, "my-cluster", version)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to save cluster: %w", err)
+	}
+	opts.report(Event{Phase: PhaseConnect, Message: fmt.Sprintf("Saved cluster: %s (version: %s)", clusterRec.ID, clusterRec.KubeVersion)})
+
+	// Watermarks of the subsystems scanned, replacing those of the last scan; a failed subsystem
+	// gets none, so the next incremental scan lists it in full
+	previous := clusterRec.ResourceVersions
+	watermarks := make(map[string]string)
+	defer func() {
+		if err := store.SetResourceVersions(storeCtx, clusterID, watermarks); err != nil {
+			opts.report(Event{Phase: PhaseDone, Error: fmt.Sprintf("%v; the next incremental scan lists everything", err)})
+		}
+	}()
+
+	// incremental applies the changes to a resource type since the last scan's watermark with
+	// update, or runs full without a usable watermark. The watermark is taken before either, so
+	// changes made meanwhile are picked up by the next scan.
+	incremental := func(ctx context.Context, phase string, rt cluster.ResourceType, update func([]cluster.Change) error, full func() error) error {
+		current, err := kubeClient.Watermark(ctx, rt)
+		if err != nil {
+			if opts.Incremental {
+				opts.report(Event{Phase: phase, Message: fmt.Sprintf("Listing %s in full: %v", rt.Name, err)})
+			}
+			return full()
+		}
+
+		if since := previous[rt.Name]; opts.Incremental && since != "" {
+			changes, err := kubeClient.ChangesSince(ctx, rt, since, current)
+			switch {
+			case err == nil:
+				opts.report(Event{Phase: phase, Message: fmt.Sprintf("%d changes to %s since the last scan", len(changes), rt.Name)})
+				err = update(changes)
+				if err == nil {
+					watermarks[rt.Name] = current
+				}
+				return err
+			case errors.Is(err, cluster.ErrWatermarkExpired):
+				opts.report(Event{Phase: phase, Message: fmt.Sprintf("Listing %s in full: the changes since the last scan are no longer available", rt.Name)})
+			default:
+				return err
+			}
+		} else if opts.Incremental {
+			opts.report(Event{Phase: phase, Message: fmt.Sprintf("No watermark for %s from the last scan, listing them in full", rt.Name)})
+		}
+
+		if err := full(); err != nil {
+			return err
+		}
+		watermarks[rt.Name] = current
+		return nil
+	}
+
+	// skip records a failed subsystem, unless the failure is an interrupt that stops the scan
+	skip := func(subsystem string, err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to scan %s: %w", subsystem, err)
+		}
+		scanErrors[subsystem] = err.Error()
+		opts.report(Event{Phase: subsystem, Error: err.Error()})
+		return nil
+	}
+
+	// progress reports the items of a subsystem processed so far
+	progress := func(phase string) func(processed, total int) {
+		return func(processed, total int) {
+			opts.report(Event{Phase: phase, Processed: processed, Total: total})
+		}
+	}
+
+	// Record the APIs the server serves, to cross-check manifests and replacement APIs
+	opts.report(Event{Phase: inventory.ScanServedAPIs, Message: "Discovering served APIs..."})
+	subsystemCtx, op := telemetry.StartScan(ctx, inventory.ScanServedAPIs)
+	served, err := kubeClient.ServedAPIs(subsystemCtx)
+	if err == nil {
+		err = store.SetServedAPIs(storeCtx, clusterID, served)
+	}
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanServedAPIs, err); err != nil {
+			return version, scanErrors, err
+		}
+	} else {
+		opts.report(Event{Phase: inventory.ScanServedAPIs, Message: fmt.Sprintf("Found %d served group versions", len(served))})
+	}
+
+	// Record node platforms, to check chart and addon images are published for them
+	subsystemCtx, op = telemetry.StartScan(ctx, inventory.ScanNodePlatforms)
+	storePlatforms := func() error {
+		platforms, err := kubeClient.NodePlatforms(subsystemCtx)
+		if err != nil {
+			return err
+		}
+		if err := store.SetNodePlatforms(storeCtx, clusterID, platforms); err != nil {
+			return err
+		}
+		opts.report(Event{Phase: inventory.ScanNodePlatforms, Message: fmt.Sprintf("Node platforms: %s", strings.Join(platforms, ", "))})
+		return nil
+	}
+	err = incremental(subsystemCtx, inventory.ScanNodePlatforms, cluster.NodeResources, func(changes []cluster.Change) error {
+		if len(changes) == 0 {
+			return nil
+		}
+		return storePlatforms()
+	}, storePlatforms)
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanNodePlatforms, err); err != nil {
+			return version, scanErrors, err
+		}
+	}
+
+	// Record the criticality namespaces declare, to weigh chart findings in them, and the
+	// namespaces being deleted
+	subsystemCtx, op = telemetry.StartScan(ctx, inventory.ScanNamespaces)
+	namespaces, err := kubeClient.Namespaces(subsystemCtx)
+	if err == nil {
+		err = store.SetNamespaces(storeCtx, clusterID, namespaces.Criticality, namespaces.Terminating)
+	}
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanNamespaces, err); err != nil {
+			return version, scanErrors, err
+		}
+	} else {
+		if len(namespaces.Criticality) > 0 {
+			opts.report(Event{Phase: inventory.ScanNamespaces, Message: fmt.Sprintf("Found %d namespaces with a criticality", len(namespaces.Criticality))})
+		}
+		if len(namespaces.Terminating) > 0 {
+			opts.report(Event{Phase: inventory.ScanNamespaces, Message: fmt.Sprintf("Found %d terminating namespaces", len(namespaces.Terminating))})
+		}
+	}
+
+	// List and store CRDs
+	opts.report(Event{Phase: inventory.ScanCRDs, Message: "Fetching CRDs..."})
+	subsystemCtx, op = telemetry.StartScan(ctx, inventory.ScanCRDs)
+	crdClient, err := cluster.NewCRDClientFromKubeClient(kubeClient)
+	if err == nil {
+		crdClient.Progress = progress(inventory.ScanCRDs)
+		crdClient.Log = opts.Log
+		err = incremental(subsystemCtx, inventory.ScanCRDs, cluster.CRDResources, func(changes []cluster.Change) error {
+			return crdClient.ApplyCRDChanges(subsystemCtx, clusterID, store, changes)
+		}, func() error {
+			return crdClient.StoreCRDsToInventory(subsystemCtx, clusterID, store)
+		})
+	}
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanCRDs, err); err != nil {
+			return version, scanErrors, err
+		}
+	}
+
+	// Store the live workloads and the objects around them, to find those written with an API
+	opts.report(Event{Phase: inventory.ScanResources, Message: "Fetching live objects..."})
+	subsystemCtx, op = telemetry.StartScan(ctx, inventory.ScanResources)
+	err = kubeClient.StoreLiveResources(subsystemCtx, clusterID, store, progress(inventory.ScanResources))
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanResources, err); err != nil {
+			return version, scanErrors, err
+		}
+	}
+
+	// List and store Helm releases
+	opts.report(Event{Phase: inventory.ScanHelm, Message: "Fetching Helm releases..."})
+	subsystemCtx, op = telemetry.StartScan(ctx, inventory.ScanHelm)
+	helmClient, err := cluster.NewHelmClientWithOptions(cluster.HelmOptions{
+		Kubeconfig: opts.Kubeconfig,
+		Context:    opts.Context,
+		Namespace:  opts.Namespace,
+		Drivers:    opts.HelmDrivers,
+		Filter:     opts.HelmFilter,
+	})
+	if err == nil {
+		helmClient.Progress = progress(inventory.ScanHelm)
+		helmClient.Log = opts.Log
+		// Only the Secrets of the secret driver are tracked for changes
+		if slices.Equal(helmClient.Drivers(), []string{"secret"}) && opts.HelmFilter.MinAge == 0 {
+			err = incremental(subsystemCtx, inventory.ScanHelm, cluster.HelmReleaseResources(opts.Namespace), func(changes []cluster.Change) error {
+				return helmClient.ApplyReleaseChanges(subsystemCtx, clusterID, store, changes)
+			}, func() error {
+				return helmClient.StoreReleasesToInventory(subsystemCtx, clusterID, store)
+			})
+		} else {
+			if opts.Incremental {
+				opts.report(Event{Phase: inventory.ScanHelm, Message: "Listing Helm releases in full: only the secret driver without a minimum release age is scanned incrementally"})
+			}
+			err = helmClient.StoreReleasesToInventory(subsystemCtx, clusterID, store)
+		}
+	}
+	op.End(err)
+	if err != nil {
+		if err := skip(inventory.ScanHelm, err); err != nil {
+			return version, scanErrors, err
+		}
+	}
+
+	return version, scanErrors, nil
+}
@@ -0,0 +1,81 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Verifier checks cosign-style signatures: base64 signatures over the SHA-256 digest of the
+// signed content, as created by 'cosign sign-blob --key' and 'cosign sign --key'
+type Verifier struct {
+	key crypto.PublicKey
+}
+
+// NewVerifier creates a verifier from a PEM-encoded public key file, e.g. cosign.pub
+func NewVerifier(keyPath string) (*Verifier, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", keyPath)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return &Verifier{key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// Verify checks a signature over content. The signature may be base64-encoded, as written by
+// cosign, or raw.
+func (v *Verifier) Verify(content, signature []byte) error {
+	signature = bytes.TrimSpace(signature)
+	if decoded, err := base64.StdEncoding.DecodeString(string(signature)); err == nil {
+		signature = decoded
+	}
+
+	digest := sha256.Sum256(content)
+	switch key := v.key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, content, signature) {
+			return errors.New("invalid signature")
+		}
+	}
+	return nil
+}
+
+// VerifyFile checks a signature file over content, e.g. an exported inventory and its .sig file
+func (v *Verifier) VerifyFile(content []byte, signaturePath string) error {
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	return v.Verify(content, signature)
+}
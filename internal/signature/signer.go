@@ -0,0 +1,74 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Signer creates signatures a Verifier with the matching public key accepts
+type Signer struct {
+	key crypto.Signer
+}
+
+// NewSigner creates a signer from an unencrypted PEM-encoded private key file (PKCS#8, SEC 1 or
+// PKCS#1), e.g. one created with 'openssl genpkey -algorithm ed25519'
+func NewSigner(keyPath string) (*Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", keyPath)
+	}
+	if strings.Contains(block.Type, "ENCRYPTED") {
+		return nil, fmt.Errorf("private key %s is encrypted; export it unencrypted, e.g. with 'openssl pkey'", keyPath)
+	}
+
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey, *rsa.PrivateKey, ed25519.PrivateKey:
+		return &Signer{key: key.(crypto.Signer)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// Sign signs content and returns the base64-encoded signature, as written by cosign
+func (s *Signer) Sign(content []byte) ([]byte, error) {
+	var signature []byte
+	var err error
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		signature, err = s.key.Sign(rand.Reader, content, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(content)
+		signature, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return []byte(base64.StdEncoding.EncodeToString(signature)), nil
+}
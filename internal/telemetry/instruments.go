@@ -0,0 +1,210 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Metric names
+const (
+	ScanDuration     = "kua.scan.duration"
+	AnalysisDuration = "kua.analysis.duration"
+	KubeAPIRequests  = "kua.kube_api.requests"
+	KubeAPIDuration  = "kua.kube_api.duration"
+	KubeAPIThrottled = "kua.kube_api.throttled"
+	KubeAPIRetries   = "kua.kube_api.retries"
+	DBWriteDuration  = "kua.db.write.duration"
+)
+
+// subsystemAttribute labels scan spans and durations with the subsystem
+const subsystemAttribute = "kua.scan.subsystem"
+
+// Instruments are created once, from the global meter provider; instruments created before Setup
+// forward to the provider it installs
+var (
+	instrumentsOnce  sync.Once
+	scanDuration     metric.Float64Histogram
+	analysisDuration metric.Float64Histogram
+	apiRequests      metric.Int64Counter
+	apiDuration      metric.Float64Histogram
+	apiThrottled     metric.Float64Histogram
+	apiRetries       metric.Int64Counter
+	dbWriteDuration  metric.Float64Histogram
+)
+
+func instruments() {
+	instrumentsOnce.Do(func() {
+		meter := otel.Meter(instrumentationName)
+		var err error
+		if scanDuration, err = meter.Float64Histogram(ScanDuration, metric.WithUnit("s"),
+			metric.WithDescription("Duration of a scan subsystem")); err != nil {
+			otel.Handle(err)
+		}
+		if analysisDuration, err = meter.Float64Histogram(AnalysisDuration, metric.WithUnit("s"),
+			metric.WithDescription("Duration of an impact analysis")); err != nil {
+			otel.Handle(err)
+		}
+		if apiRequests, err = meter.Int64Counter(KubeAPIRequests, metric.WithUnit("{request}"),
+			metric.WithDescription("Kubernetes API requests by method and status code")); err != nil {
+			otel.Handle(err)
+		}
+		if apiDuration, err = meter.Float64Histogram(KubeAPIDuration, metric.WithUnit("s"),
+			metric.WithDescription("Duration of Kubernetes API requests")); err != nil {
+			otel.Handle(err)
+		}
+		if apiThrottled, err = meter.Float64Histogram(KubeAPIThrottled, metric.WithUnit("s"),
+			metric.WithDescription("Time Kubernetes API requests waited for the client-side rate limit or a server Retry-After")); err != nil {
+			otel.Handle(err)
+		}
+		if apiRetries, err = meter.Int64Counter(KubeAPIRetries, metric.WithUnit("{request}"),
+			metric.WithDescription("Kubernetes API requests retried after a 429 or 5xx response, by status code")); err != nil {
+			otel.Handle(err)
+		}
+		if dbWriteDuration, err = meter.Float64Histogram(DBWriteDuration, metric.WithUnit("s"),
+			metric.WithDescription("Duration of database writes by entity and operation")); err != nil {
+			otel.Handle(err)
+		}
+	})
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Operation is a span whose duration is also recorded in a histogram
+type Operation struct {
+	ctx       context.Context
+	span      trace.Span
+	start     time.Time
+	histogram metric.Float64Histogram
+	attrs     []attribute.KeyValue
+}
+
+// StartScan starts a scan subsystem, such as inventory.ScanCRDs or "manifests"
+func StartScan(ctx context.Context, subsystem string) (context.Context, *Operation) {
+	instruments()
+	return start(ctx, "scan "+subsystem, scanDuration, attribute.String(subsystemAttribute, subsystem))
+}
+
+// StartAnalysis starts an analysis of a cluster for a target version
+func StartAnalysis(ctx context.Context, name, clusterID, targetVersion string) (context.Context, *Operation) {
+	instruments()
+	ctx, op := start(ctx, "analysis "+name, analysisDuration, attribute.String("kua.analysis", name))
+	op.span.SetAttributes(attribute.String("kua.cluster", clusterID), attribute.String("kua.target_version", targetVersion))
+	return ctx, op
+}
+
+func start(ctx context.Context, spanName string, histogram metric.Float64Histogram, attrs ...attribute.KeyValue) (context.Context, *Operation) {
+	ctx, span := tracer().Start(ctx, spanName, trace.WithAttributes(attrs...))
+	return ctx, &Operation{ctx: ctx, span: span, start: time.Now(), histogram: histogram, attrs: attrs}
+}
+
+// SetAttributes adds attributes to the span, e.g. the number of resources found
+func (o *Operation) SetAttributes(attrs ...attribute.KeyValue) {
+	o.span.SetAttributes(attrs...)
+}
+
+// End ends the span and records the duration, with status "error" when err is set
+func (o *Operation) End(err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+	if o.histogram != nil {
+		attrs := append(o.attrs[:len(o.attrs):len(o.attrs)], attribute.String("status", status))
+		o.histogram.Record(o.ctx, time.Since(o.start).Seconds(), metric.WithAttributes(attrs...))
+	}
+	o.span.End()
+}
+
+// RecordDBWrite records the duration of a database write, e.g. entity "ManifestAPI", op "create"
+func RecordDBWrite(ctx context.Context, entity, op string, duration time.Duration, err error) {
+	instruments()
+	if dbWriteDuration == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	dbWriteDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("db.entity", entity), attribute.String("db.operation", op), attribute.String("status", status)))
+}
+
+// Sources of throttling of Kubernetes API requests
+const (
+	ThrottleClient = "client"
+	ThrottleServer = "server"
+)
+
+// RecordThrottle records a Kubernetes API request held back by the client-side rate limit, or by
+// the API server's priority and fairness with a 429
+func RecordThrottle(ctx context.Context, source string, wait time.Duration) {
+	instruments()
+	if apiThrottled == nil {
+		return
+	}
+	apiThrottled.Record(ctx, wait.Seconds(), metric.WithAttributes(attribute.String("kua.throttle.source", source)))
+}
+
+// RecordRetry records a Kubernetes API request retried after a response with a status code
+func RecordRetry(ctx context.Context, method string, statusCode int) {
+	instruments()
+	if apiRetries == nil {
+		return
+	}
+	apiRetries.Add(ctx, 1, metric.WithAttributes(attribute.String("http.request.method", method),
+		attribute.String("http.response.status_code", strconv.Itoa(statusCode))))
+}
+
+// WrapTransport counts and times Kubernetes API requests, with a client span per request; pass it
+// to rest.Config.Wrap
+func WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return &apiTransport{next: next}
+}
+
+type apiTransport struct {
+	next http.RoundTripper
+}
+
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	instruments()
+	ctx, span := tracer().Start(req.Context(), "kube-api "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", req.Method), attribute.String("url.path", req.URL.Path)))
+	defer span.End()
+	started := time.Now()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	code := "error"
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		code = strconv.Itoa(resp.StatusCode)
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+
+	attrs := metric.WithAttributes(attribute.String("http.request.method", req.Method), attribute.String("http.response.status_code", code))
+	if apiRequests != nil {
+		apiRequests.Add(ctx, 1, attrs)
+	}
+	if apiDuration != nil {
+		apiDuration.Record(ctx, time.Since(started).Seconds(), attrs)
+	}
+	return resp, err
+}
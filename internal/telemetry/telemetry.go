@@ -0,0 +1,126 @@
+// Package telemetry instruments scans and analyses with OpenTelemetry traces and metrics, exported
+// over OTLP, to troubleshoot slow scans of large clusters. Nothing is exported until Setup is called
+// with an endpoint configured.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// instrumentationName names the tracer and meter
+const instrumentationName = "github.com/retr0-kernel/kube-upgrade-advisor"
+
+// Options configures the OTLP export
+type Options struct {
+	// ServiceName is the service.name resource attribute; OTEL_SERVICE_NAME overrides it
+	ServiceName string
+	// Endpoint is the OTLP base URL, e.g. http://localhost:4318; the OTEL_EXPORTER_OTLP_*ENDPOINT
+	// environment variables apply when empty
+	Endpoint string
+}
+
+// Enabled reports whether an OTLP endpoint is configured, by option or environment, and the SDK is
+// not disabled with OTEL_SDK_DISABLED
+func (o Options) Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return o.Endpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != ""
+}
+
+// Setup installs global tracer and meter providers exporting over OTLP, with the protocol from
+// OTEL_EXPORTER_OTLP_PROTOCOL: http/protobuf (default) or grpc. Headers, TLS and timeouts follow
+// the other OTEL_EXPORTER_OTLP_* variables. The returned shutdown flushes pending telemetry.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", opts.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	traceExporter, metricExporter, err := newExporters(ctx, opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+// newExporters creates the trace and metric exporters for the configured protocol
+func newExporters(ctx context.Context, endpoint string) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	switch protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol {
+	case "", "http/protobuf":
+		var traceOpts []otlptracehttp.Option
+		var metricOpts []otlpmetrichttp.Option
+		if endpoint != "" {
+			// Signal paths are appended to a base URL as for OTEL_EXPORTER_OTLP_ENDPOINT
+			traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"))
+			metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"))
+		}
+		traceOpts = append(traceOpts, otlptracehttp.WithProxy(network.Proxy))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithProxy(network.Proxy))
+		if config := network.TLSConfig(); config != nil {
+			traceOpts = append(traceOpts, otlptracehttp.WithTLSClientConfig(config))
+			metricOpts = append(metricOpts, otlpmetrichttp.WithTLSClientConfig(config))
+		}
+		traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		return traceExporter, metricExporter, nil
+
+	case "grpc":
+		var traceOpts []otlptracegrpc.Option
+		var metricOpts []otlpmetricgrpc.Option
+		if endpoint != "" {
+			traceOpts = append(traceOpts, otlptracegrpc.WithEndpointURL(endpoint))
+			metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpointURL(endpoint))
+		}
+		traceExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		return traceExporter, metricExporter, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q (supported: http/protobuf, grpc)", protocol)
+	}
+}
@@ -0,0 +1,205 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/network"
+)
+
+// SinkJira is the name of the Jira sink
+const SinkJira = "jira"
+
+// JiraOptions configure the Jira sink
+type JiraOptions struct {
+	// URL is the base URL of the Jira site, e.g. https://example.atlassian.net
+	URL string
+	// User and Token authenticate with basic auth, as Jira Cloud API tokens need; without User,
+	// Token is sent as a bearer personal access token, as Jira Data Center expects
+	User  string
+	Token string
+	// IssueType of created issues; Task when empty
+	IssueType string
+	// Labels set on created and updated issues, besides the label of each ticket
+	Labels []string
+}
+
+// JiraSink files tickets as issues through the Jira REST API v2
+type JiraSink struct {
+	baseURL    *url.URL
+	options    JiraOptions
+	httpClient *http.Client
+}
+
+// NewJiraSink creates a Jira sink
+func NewJiraSink(options JiraOptions) (*JiraSink, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(options.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Jira URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Jira URL %q: scheme must be http or https", options.URL)
+	}
+	if options.Token == "" {
+		return nil, fmt.Errorf("missing Jira API token")
+	}
+	if options.IssueType == "" {
+		options.IssueType = "Task"
+	}
+	return &JiraSink{baseURL: parsed, options: options, httpClient: network.Client(30 * time.Second)}, nil
+}
+
+// Name identifies the sink in idempotency records
+func (s *JiraSink) Name() string {
+	return SinkJira
+}
+
+// jiraFields are the issue fields the sink sets
+type jiraFields struct {
+	Project     *jiraRef `json:"project,omitempty"`
+	IssueType   *jiraRef `json:"issuetype,omitempty"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// jiraRef references a project by key or an issue type by name
+type jiraRef struct {
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Find returns the key of the issue of a project with a label, or "" when there is none
+func (s *JiraSink) Find(ctx context.Context, project, label string) (string, error) {
+	query := url.Values{
+		"jql":        {fmt.Sprintf("project = %q AND labels = %q", project, label)},
+		"fields":     {"key"},
+		"maxResults": {"1"},
+	}
+	var found struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found.Issues) == 0 {
+		return "", nil
+	}
+	return found.Issues[0].Key, nil
+}
+
+// Create files an issue and returns its key, e.g. K8SUP-123
+func (s *JiraSink) Create(ctx context.Context, project string, ticket Ticket) (string, error) {
+	fields := s.fields(ticket)
+	fields.Project = &jiraRef{Key: project}
+	fields.IssueType = &jiraRef{Name: s.options.IssueType}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	if created.Key == "" {
+		return "", fmt.Errorf("jira returned no issue key")
+	}
+	return created.Key, nil
+}
+
+// Update replaces the summary, description and labels of an issue
+func (s *JiraSink) Update(ctx context.Context, project, externalKey string, ticket Ticket) error {
+	path := "/rest/api/2/issue/" + url.PathEscape(externalKey)
+	return s.do(ctx, http.MethodPut, path, map[string]interface{}{"fields": s.fields(ticket)}, nil)
+}
+
+// fields returns the summary, description and labels of a ticket; Jira summaries are limited to
+// 255 characters
+func (s *JiraSink) fields(ticket Ticket) *jiraFields {
+	summary := ticket.Title
+	if runes := []rune(summary); len(runes) > 255 {
+		summary = string(runes[:252]) + "..."
+	}
+	labels := append(slices.Clip(s.options.Labels), ticket.Label())
+	return &jiraFields{Summary: summary, Description: ticket.Description, Labels: labels}
+}
+
+// do sends a request to the Jira API, with a query after the path if any and no body when body is
+// nil, and decodes the response into v, unless v is nil
+func (s *JiraSink) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	endpoint := *s.baseURL
+	path, endpoint.RawQuery, _ = strings.Cut(path, "?")
+	endpoint.Path += path
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if s.options.User != "" {
+		req.SetBasicAuth(s.options.User, s.options.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.options.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("jira returned %s: %s", resp.Status, jiraErrorMessage(body))
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode jira response: %w", err)
+	}
+	return nil
+}
+
+// jiraErrorMessage extracts the messages of a Jira error response, else returns the body
+func jiraErrorMessage(body []byte) string {
+	var response struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	messages := response.ErrorMessages
+	fields := make([]string, 0, len(response.Errors))
+	for field := range response.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, field+": "+response.Errors[field])
+	}
+	if len(messages) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+	return strings.Join(messages, "; ")
+}
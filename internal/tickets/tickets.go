@@ -0,0 +1,240 @@
+// Package tickets files the findings of an assessment as issues in a ticketing system, one per
+// owning team or per rule. Each issue is recorded in the database under an idempotency key, and
+// labeled with it in the sink, so re-runs update the issues of earlier runs instead of filing
+// duplicates.
+package tickets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/db/ent"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// Ways of grouping findings into tickets
+const (
+	GroupByOwner = "owner"
+	GroupByRule  = "rule"
+)
+
+// Actions Publish takes on a ticket
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionResolved = "resolved" // an earlier ticket whose findings are all gone
+)
+
+// Ticket is an issue filing a group of findings
+type Ticket struct {
+	Key         string                `json:"key"`   // idempotency key
+	Group       string                `json:"group"` // e.g. owner:payments or rule:KUA-API-001
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Severity    analysis.ImpactLevel  `json:"severity"` // highest severity of the findings
+	Findings    []analysis.FindingRef `json:"findings"`
+}
+
+// Label tags the issue of a ticket in the sink with its idempotency key, so an issue filed without
+// being recorded, e.g. when the advisor was killed right after filing it, is found again
+func (t Ticket) Label() string {
+	sum := sha256.Sum256([]byte(t.Key))
+	return "kua-" + hex.EncodeToString(sum[:8])
+}
+
+// Sink creates and updates issues in a ticketing system
+type Sink interface {
+	// Name identifies the sink in idempotency records, e.g. jira
+	Name() string
+	// Find returns the key of an issue of a project with a label, or "" when there is none
+	Find(ctx context.Context, project, label string) (string, error)
+	// Create files an issue labeled with the ticket's label and returns its key in the sink
+	Create(ctx context.Context, project string, ticket Ticket) (string, error)
+	// Update replaces the title and description of an issue
+	Update(ctx context.Context, project, externalKey string, ticket Ticket) error
+}
+
+// Result is what Publish did, or would do in a dry run, to a ticket
+type Result struct {
+	Ticket
+	Action      string `json:"action"`
+	ExternalKey string `json:"externalKey,omitempty"` // empty for tickets a dry run would create
+}
+
+// KeyPrefix is the start of the idempotency keys of the tickets of a cluster's upgrade. The target
+// version is reduced to its minor version, so 1.29, v1.29 and 1.29.0 file the same tickets.
+func KeyPrefix(clusterID, targetVersion string) string {
+	parts := strings.SplitN(strings.TrimPrefix(targetVersion, "v"), ".", 3)
+	return clusterID + "|" + strings.Join(parts[:min(len(parts), 2)], ".") + "|"
+}
+
+// Build groups the findings of an assessment into tickets, by the teams owning them or by rule
+func Build(assessment *analysis.ImpactAssessment, ownership *analysis.OwnershipMap, groupBy string) ([]Ticket, error) {
+	if groupBy != GroupByOwner && groupBy != GroupByRule {
+		return nil, fmt.Errorf("unknown grouping %q (supported: %s, %s)", groupBy, GroupByOwner, GroupByRule)
+	}
+
+	groups := make(map[string][]analysis.FindingRef)
+	for _, finding := range assessment.Findings() {
+		if groupBy == GroupByRule {
+			groups[GroupByRule+":"+finding.RuleID] = append(groups[GroupByRule+":"+finding.RuleID], finding)
+			continue
+		}
+		// a finding shared by several teams is filed to each of them
+		for _, owner := range ownership.FindingOwners(finding) {
+			groups[GroupByOwner+":"+owner] = append(groups[GroupByOwner+":"+owner], finding)
+		}
+	}
+
+	tickets := make([]Ticket, 0, len(groups))
+	for group, findings := range groups {
+		ticket := Ticket{
+			Key:      KeyPrefix(assessment.ClusterID, assessment.TargetVersion) + group,
+			Group:    group,
+			Severity: analysis.ImpactNone,
+			Findings: findings,
+		}
+		for _, finding := range findings {
+			if !ticket.Severity.AtLeast(finding.Severity) {
+				ticket.Severity = finding.Severity
+			}
+		}
+
+		_, name, _ := strings.Cut(group, ":")
+		subject := fmt.Sprintf("%d findings for %s", len(findings), name)
+		if groupBy == GroupByRule {
+			subject = fmt.Sprintf("%s (%d)", name, len(findings))
+			if rule, ok := analysis.RuleByID(name); ok {
+				subject = fmt.Sprintf("%s %s (%d)", name, rule.Title, len(findings))
+			}
+		}
+		ticket.Title = fmt.Sprintf("[%s] Kubernetes %s upgrade of %s: %s", ticket.Severity, assessment.TargetVersion, assessment.ClusterID, subject)
+		ticket.Description = describe(assessment, findings)
+		tickets = append(tickets, ticket)
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].Key < tickets[j].Key
+	})
+	return tickets, nil
+}
+
+// describe lists the findings of a ticket in Jira wiki markup, which other sinks show as plain text
+func describe(assessment *analysis.ImpactAssessment, findings []analysis.FindingRef) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Findings of kube-upgrade-advisor for the upgrade of %s from %s to %s.\n\n",
+		assessment.ClusterID, assessment.CurrentVersion, assessment.TargetVersion)
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "* *%s* %s {{%s}}: %s\n", finding.Severity, finding.RuleID, finding.Resource, finding.Summary)
+		if remediation := finding.Remediation().Summary(); remediation != "" {
+			fmt.Fprintf(&b, "** Fix: %s\n", remediation)
+		}
+		if rule, ok := analysis.RuleByID(finding.RuleID); ok {
+			fmt.Fprintf(&b, "** Docs: %s\n", rule.DocsURL())
+		}
+	}
+	b.WriteString("\nThis ticket is updated when the advisor files tickets again; edits to its description are overwritten.\n")
+	return b.String()
+}
+
+// Publish files the tickets in a project of the sink: tickets recorded by an earlier run or whose
+// label is already on an issue are updated, the others created, and earlier tickets of the same cluster and target version whose
+// findings are all gone are updated to say so. Every ticket filed is recorded in the store. With
+// dryRun nothing is sent or recorded, and the results tell what would be done.
+func Publish(ctx context.Context, store *inventory.Store, sink Sink, project, clusterID, targetVersion string, tickets []Ticket, dryRun bool) ([]Result, error) {
+	var results []Result
+	current := make(map[string]bool, len(tickets))
+
+	for _, ticket := range tickets {
+		current[ticket.Key] = true
+		result := Result{Ticket: ticket, Action: ActionCreated}
+
+		existing, err := store.GetTicket(ctx, sink.Name(), project, ticket.Key)
+		switch {
+		case err == nil:
+			result.Action = ActionUpdated
+			result.ExternalKey = existing.ExternalKey
+		case !ent.IsNotFound(err):
+			return results, fmt.Errorf("failed to get ticket %s: %w", ticket.Key, err)
+		}
+
+		if !dryRun {
+			if result.Action == ActionCreated {
+				// an issue filed by a run that stopped before recording it
+				if result.ExternalKey, err = sink.Find(ctx, project, ticket.Label()); err != nil {
+					return results, fmt.Errorf("failed to search ticket %s: %w", ticket.Key, err)
+				}
+				if result.ExternalKey != "" {
+					result.Action = ActionUpdated
+				}
+			}
+			if result.Action == ActionCreated {
+				if result.ExternalKey, err = sink.Create(ctx, project, ticket); err != nil {
+					return results, fmt.Errorf("failed to create ticket %s: %w", ticket.Key, err)
+				}
+			} else if err := sink.Update(ctx, project, result.ExternalKey, ticket); err != nil {
+				return results, fmt.Errorf("failed to update ticket %s: %w", result.ExternalKey, err)
+			}
+			if err := record(ctx, store, sink, project, clusterID, result.ExternalKey, ticket); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	earlier, err := store.ListTickets(ctx, sink.Name(), project, KeyPrefix(clusterID, targetVersion))
+	if err != nil {
+		return results, err
+	}
+	for _, stored := range earlier {
+		if current[stored.IdempotencyKey] || stored.FindingCount == 0 {
+			continue
+		}
+		group := strings.TrimPrefix(stored.IdempotencyKey, KeyPrefix(clusterID, targetVersion))
+		// replace the severity prefix of the title
+		title := stored.Title
+		if _, rest, found := strings.Cut(title, "] "); found && strings.HasPrefix(title, "[") {
+			title = rest
+		}
+		ticket := Ticket{
+			Key:   stored.IdempotencyKey,
+			Group: group,
+			Title: "[resolved] " + title,
+			Description: fmt.Sprintf("kube-upgrade-advisor finds no issues of this group anymore for the upgrade of %s to %s. "+
+				"Verify and close the ticket.\n", clusterID, targetVersion),
+			Severity: analysis.ImpactNone,
+		}
+		if !dryRun {
+			if err := sink.Update(ctx, project, stored.ExternalKey, ticket); err != nil {
+				return results, fmt.Errorf("failed to update ticket %s: %w", stored.ExternalKey, err)
+			}
+			if err := record(ctx, store, sink, project, clusterID, stored.ExternalKey, ticket); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, Result{Ticket: ticket, Action: ActionResolved, ExternalKey: stored.ExternalKey})
+	}
+	return results, nil
+}
+
+// record stores the idempotency record of a ticket filed in the sink
+func record(ctx context.Context, store *inventory.Store, sink Sink, project, clusterID, externalKey string, ticket Ticket) error {
+	_, err := store.SaveTicket(ctx, inventory.TicketEntry{
+		Sink:           sink.Name(),
+		Project:        project,
+		IdempotencyKey: ticket.Key,
+		ExternalKey:    externalKey,
+		ClusterID:      clusterID,
+		Title:          ticket.Title,
+		FindingCount:   len(ticket.Findings),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record ticket %s: %w", externalKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// fakeSink keeps issues in memory, by key
+type fakeSink struct {
+	issues  map[string]Ticket
+	created int
+}
+
+func newFakeSink() *fakeSink {
+	return &fakeSink{issues: make(map[string]Ticket)}
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Find(ctx context.Context, project, label string) (string, error) {
+	for key, ticket := range s.issues {
+		if ticket.Label() == label {
+			return key, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *fakeSink) Create(ctx context.Context, project string, ticket Ticket) (string, error) {
+	s.created++
+	key := fmt.Sprintf("%s-%d", project, s.created)
+	s.issues[key] = ticket
+	return key, nil
+}
+
+func (s *fakeSink) Update(ctx context.Context, project, externalKey string, ticket Ticket) error {
+	if _, ok := s.issues[externalKey]; !ok {
+		return fmt.Errorf("no issue %s", externalKey)
+	}
+	s.issues[externalKey] = ticket
+	return nil
+}
+
+// ticketAssessment has an Ingress using a removed API in each of two namespaces
+func ticketAssessment(targetVersion string, namespaces ...string) *analysis.ImpactAssessment {
+	api := analysis.DeprecatedAPIImpact{
+		Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress", ImpactLevel: analysis.ImpactHigh, RemovedIn: "1.22",
+	}
+	for _, namespace := range namespaces {
+		api.Provenance = append(api.Provenance, analysis.ResourceProvenance{Resource: namespace + "/web", Sources: []string{"cluster"}})
+	}
+	assessment := &analysis.ImpactAssessment{ClusterID: "prod", CurrentVersion: "1.21", TargetVersion: targetVersion}
+	if len(namespaces) > 0 {
+		assessment.DeprecatedManifestAPIs = []analysis.DeprecatedAPIImpact{api}
+	}
+	analysis.AssignRuleIDs(assessment)
+	return assessment
+}
+
+var ticketOwners = &analysis.OwnershipMap{Namespaces: map[string]string{"shop": "payments", "search": "discovery"}}
+
+func TestBuild(t *testing.T) {
+	byOwner, err := Build(ticketAssessment("v1.22", "shop", "search"), ticketOwners, GroupByOwner)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(byOwner) != 2 || byOwner[0].Key != "prod|1.22|owner:discovery" || byOwner[1].Key != "prod|1.22|owner:payments" {
+		t.Fatalf("tickets by owner = %+v, want one for discovery and one for payments", byOwner)
+	}
+	if byOwner[0].Severity != analysis.ImpactHigh || len(byOwner[0].Findings) != 1 {
+		t.Errorf("discovery ticket = %+v, want its high finding", byOwner[0])
+	}
+
+	byRule, err := Build(ticketAssessment("1.22", "shop", "search"), ticketOwners, GroupByRule)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(byRule) != 1 || len(byRule[0].Findings) != 2 {
+		t.Errorf("tickets by rule = %+v, want one with both findings", byRule)
+	}
+
+	if _, err := Build(ticketAssessment("1.22"), ticketOwners, "severity"); err == nil {
+		t.Error("Build accepted an unknown grouping")
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	for _, version := range []string{"1.29", "v1.29", "1.29.0"} {
+		if got := KeyPrefix("prod", version); got != "prod|1.29|" {
+			t.Errorf("KeyPrefix(prod, %s) = %s, want prod|1.29|", version, got)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	store, err := inventory.NewStore(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	sink := newFakeSink()
+
+	publish := func(targetVersion string, dryRun bool, namespaces ...string) map[string]string {
+		t.Helper()
+		built, err := Build(ticketAssessment(targetVersion, namespaces...), ticketOwners, GroupByOwner)
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		results, err := Publish(ctx, store, sink, "K8S", "prod", targetVersion, built, dryRun)
+		if err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		actions := make(map[string]string)
+		for _, result := range results {
+			actions[result.Group] = result.Action
+		}
+		return actions
+	}
+
+	if actions := publish("1.22", true, "shop"); actions["owner:payments"] != ActionCreated || sink.created != 0 {
+		t.Errorf("dry run: %v with %d issues created, want payments to be created and nothing sent", actions, sink.created)
+	}
+	if actions := publish("1.22", false, "shop"); actions["owner:payments"] != ActionCreated || sink.created != 1 {
+		t.Errorf("first run: %v with %d issues created, want payments created", actions, sink.created)
+	}
+	// v1.22 is the same target version
+	actions := publish("v1.22", false, "shop", "search")
+	if actions["owner:payments"] != ActionUpdated || actions["owner:discovery"] != ActionCreated || sink.created != 2 {
+		t.Errorf("second run: %v with %d issues created, want payments updated and discovery created", actions, sink.created)
+	}
+	actions = publish("1.22", false, "search")
+	if actions["owner:payments"] != ActionResolved || actions["owner:discovery"] != ActionUpdated {
+		t.Errorf("third run: %v, want payments resolved and discovery updated", actions)
+	}
+	if issue := sink.issues["K8S-1"]; !strings.HasPrefix(issue.Title, "[resolved] ") {
+		t.Errorf("payments issue title = %q, want it resolved", issue.Title)
+	}
+
+	// An issue filed by a run that stopped before recording it is found by its label
+	built, err := Build(ticketAssessment("1.23", "shop"), ticketOwners, GroupByOwner)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if _, err := sink.Create(ctx, "K8S", built[0]); err != nil {
+		t.Fatal(err)
+	}
+	if actions := publish("1.23", false, "shop"); actions["owner:payments"] != ActionUpdated || sink.created != 3 {
+		t.Errorf("run after a crash: %v with %d issues created, want the unrecorded issue updated", actions, sink.created)
+	}
+}
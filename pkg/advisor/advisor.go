@@ -0,0 +1,40 @@
+// Package advisor is the public Go API of kube-upgrade-advisor, for embedding cluster scans, upgrade
+// impact analysis and upgrade planning into other platform tooling. Nothing in it prints to stdout;
+// progress and warnings go to the writers set in the option structs.
+//
+// A typical embedding scans a cluster into a store, analyzes it and plans the upgrade:
+//
+//	store, err := advisor.OpenDatabase("kube-advisor.db")
+//	inv, err := advisor.NewScanner(advisor.ScanOptions{ClusterID: "prod", Cluster: true}).Scan(ctx)
+//	err = store.SaveInventory(ctx, inv)
+//	analyzer, err := advisor.NewAnalyzer(store, advisor.KnowledgeDir("knowledge-base"), advisor.AnalyzerOptions{})
+//	assessment, err := analyzer.Analyze(ctx, "prod", "1.29")
+//	plan, err := advisor.NewPlanner(advisor.PlannerOptions{}).Plan(assessment)
+package advisor
+
+import (
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// Inventory is a portable copy of a cluster's inventory, as written by 'inventory export'
+type Inventory = inventory.Export
+
+// Assessment is the upgrade impact assessment of a cluster
+type Assessment = analysis.ImpactAssessment
+
+// ImpactLevel is the severity of a finding or of an assessment
+type ImpactLevel = analysis.ImpactLevel
+
+// Plan is an ordered upgrade plan
+type Plan = planner.UpgradePlan
+
+// Severities of findings and assessments
+const (
+	ImpactCritical = analysis.ImpactCritical
+	ImpactHigh     = analysis.ImpactHigh
+	ImpactMedium   = analysis.ImpactMedium
+	ImpactLow      = analysis.ImpactLow
+	ImpactNone     = analysis.ImpactNone
+)
@@ -0,0 +1,91 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+)
+
+// Analyzer assesses the impact of upgrading a stored cluster to a target version
+type Analyzer interface {
+	Analyze(ctx context.Context, clusterID, targetVersion string) (*Assessment, error)
+	// Report renders an assessment as the human-readable report of 'impact'
+	Report(assessment *Assessment) string
+}
+
+// AnalyzerOptions customizes the analysis
+type AnalyzerOptions struct {
+	// Lang is the language of reports, e.g. "de"; English when empty
+	Lang string
+	// Warnings receives warnings such as a missing optional knowledge base; discarded when nil
+	Warnings io.Writer
+}
+
+// analyzer analyzes inventories of a Store
+type analyzer struct {
+	analyzer *analysis.Analyzer
+	// source is the store inventories are copied from into db before an analysis; nil when the
+	// store is a Database analyzed in place
+	source Store
+	db     *Database
+}
+
+// NewAnalyzer creates an analyzer of the inventories in a store, based on a knowledge base. A
+// Database is analyzed in place; inventories of other stores are copied into an in-memory database
+// for each analysis.
+func NewAnalyzer(store Store, kb KnowledgeBase, opts AnalyzerOptions) (Analyzer, error) {
+	db, ok := store.(*Database)
+	var source Store
+	if !ok {
+		var err error
+		db, err = OpenDatabase(":memory:")
+		if err != nil {
+			return nil, err
+		}
+		source = store
+	}
+
+	a, err := analysis.NewAnalyzer(kb.Path(APIKnowledge), kb.Path(ChartKnowledge), db.store)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := opts.Warnings
+	if warnings == nil {
+		warnings = io.Discard
+	}
+	for _, err := range a.LoadKnowledge(kb.Path) {
+		fmt.Fprintf(warnings, "Warning: %v\n", err)
+	}
+
+	if opts.Lang != "" {
+		localizer, err := i18n.New(opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		a.SetLocalizer(localizer)
+	}
+	return &analyzer{analyzer: a, source: source, db: db}, nil
+}
+
+// Analyze computes the impact assessment of a cluster
+func (a *analyzer) Analyze(ctx context.Context, clusterID, targetVersion string) (*Assessment, error) {
+	if a.source != nil {
+		inv, err := a.source.Inventory(ctx, clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get inventory: %w", err)
+		}
+		if _, err := a.db.store.ImportInventory(ctx, inv, clusterID); err != nil {
+			return nil, fmt.Errorf("failed to load inventory: %w", err)
+		}
+	}
+	return a.analyzer.ComputeUpgradeImpact(ctx, clusterID, targetVersion)
+}
+
+// Report renders an assessment as text
+func (a *analyzer) Report(assessment *Assessment) string {
+	return a.analyzer.GenerateReport(assessment)
+}
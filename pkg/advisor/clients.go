@@ -0,0 +1,145 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// CRD is a CRD of an inventory
+type CRD = inventory.ExportedCRD
+
+// HelmRelease is a Helm release of an inventory
+type HelmRelease = inventory.ExportedHelmRelease
+
+// Cluster subsystems a scan records failures of in Inventory.Cluster.ScanErrors
+const (
+	ScanServedAPIs    = inventory.ScanServedAPIs
+	ScanNodePlatforms = inventory.ScanNodePlatforms
+	ScanNamespaces    = inventory.ScanNamespaces
+	ScanCRDs          = inventory.ScanCRDs
+	ScanResources     = inventory.ScanResources
+	ScanHelm          = inventory.ScanHelm
+)
+
+// ReleaseFilter selects the Helm releases a live scan collects, e.g. only deployed ones
+type ReleaseFilter = cluster.ReleaseFilter
+
+// KubeClient reads the version, served APIs and node platforms of a cluster
+type KubeClient interface {
+	ClusterVersion(ctx context.Context) (string, error)
+	// ServedAPIs returns the kinds served per group/version, e.g. "apps/v1"
+	ServedAPIs(ctx context.Context) (map[string][]string, error)
+	// NodePlatforms returns the distinct os/arch of the nodes, e.g. "linux/arm64"
+	NodePlatforms(ctx context.Context) ([]string, error)
+}
+
+// CRDClient lists the CRDs of a cluster with their number of custom resources
+type CRDClient interface {
+	CRDs(ctx context.Context) ([]CRD, error)
+}
+
+// HelmClient lists the Helm releases of a cluster
+type HelmClient interface {
+	// Releases lists the releases of a namespace, or of all namespaces when namespace is empty
+	Releases(ctx context.Context, namespace string) ([]HelmRelease, error)
+}
+
+// Clients are the cluster clients a scanner reads from, e.g. fakes in tests
+type Clients struct {
+	Kube KubeClient
+	CRDs CRDClient
+	Helm HelmClient
+}
+
+// NewClients connects to the cluster of a kubeconfig and context; an empty kubeconfig means
+// $KUBECONFIG or ~/.kube/config, and an empty context the current one. Progress receives warnings
+// such as CRDs whose custom resources can't be counted; discarded when nil.
+func NewClients(kubeconfig, kubeContext string, progress io.Writer) (*Clients, error) {
+	kubeClient, err := cluster.NewKubeClientFromFlags(kubeconfig, kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+	crdClient, err := cluster.NewCRDClientFromKubeClient(kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRD client: %w", err)
+	}
+	helmClient, err := cluster.NewHelmClientWithContext(kubeconfig, kubeContext, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Helm client: %w", err)
+	}
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	return &Clients{
+		Kube: &kubeClientAdapter{client: kubeClient},
+		CRDs: &crdClientAdapter{client: crdClient, progress: progress},
+		Helm: &helmClientAdapter{client: helmClient},
+	}, nil
+}
+
+// kubeClientAdapter reads a live cluster through client-go
+type kubeClientAdapter struct {
+	client *cluster.KubeClient
+}
+
+func (a *kubeClientAdapter) ClusterVersion(ctx context.Context) (string, error) {
+	return a.client.GetClusterVersion(ctx)
+}
+
+func (a *kubeClientAdapter) ServedAPIs(ctx context.Context) (map[string][]string, error) {
+	return a.client.ServedAPIs(ctx)
+}
+
+func (a *kubeClientAdapter) NodePlatforms(ctx context.Context) ([]string, error) {
+	return a.client.NodePlatforms(ctx)
+}
+
+// crdClientAdapter lists CRDs of a live cluster
+type crdClientAdapter struct {
+	client   *cluster.CRDClient
+	progress io.Writer
+}
+
+// CRDs lists the CRDs; when ctx is done, the CRDs listed so far are returned with the error
+func (a *crdClientAdapter) CRDs(ctx context.Context) ([]CRD, error) {
+	crds, err := a.client.ListCRDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+
+	entries := make([]CRD, 0, len(crds))
+	for i, crd := range crds {
+		if err := ctx.Err(); err != nil {
+			return entries, fmt.Errorf("stopped after %d of %d CRDs: %w", i, len(crds), err)
+		}
+		entry, err := a.client.InventoryCRD(ctx, crd)
+		if err != nil {
+			fmt.Fprintf(a.progress, "Warning: %v\n", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// helmClientAdapter lists Helm releases of a live cluster
+type helmClientAdapter struct {
+	client *cluster.HelmClient
+}
+
+func (a *helmClientAdapter) Releases(ctx context.Context, namespace string) ([]HelmRelease, error) {
+	releases, err := a.client.ListReleasesInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HelmRelease, len(releases))
+	for i, rel := range releases {
+		entries[i] = inventory.NewExportedHelmRelease(rel.Entry())
+	}
+	return entries, nil
+}
@@ -0,0 +1,61 @@
+//go:build envtest
+
+package envtest_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor"
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor/envtest"
+)
+
+func TestScanAnalyzePlan(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set; run setup-envtest first")
+	}
+
+	env, err := envtest.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Stop()
+
+	ctx := context.Background()
+	inv, err := advisor.NewScanner(env.ScanOptions("envtest")).Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if inv.Cluster.KubeVersion == "" {
+		t.Error("scan recorded no cluster version")
+	}
+	if len(inv.Cluster.ServedAPIs) == 0 {
+		t.Errorf("scan recorded no served APIs; scan errors: %v", inv.Cluster.ScanErrors)
+	}
+
+	store, err := advisor.OpenDatabase(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.SaveInventory(ctx, inv); err != nil {
+		t.Fatalf("SaveInventory: %v", err)
+	}
+
+	analyzer, err := advisor.NewAnalyzer(store, advisor.KnowledgeDir("../../../knowledge-base"), advisor.AnalyzerOptions{})
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+	assessment, err := analyzer.Analyze(ctx, "envtest", "1.29")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	plan, err := advisor.NewPlanner(advisor.PlannerOptions{}).Plan(assessment)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.TotalSteps == 0 {
+		t.Error("plan has no steps")
+	}
+}
@@ -0,0 +1,146 @@
+// Package fake provides a fake cluster backed by inventory fixtures and an in-memory store, to
+// exercise scan, analyze and plan flows of package advisor without a live cluster.
+//
+//	cluster, err := fake.Fixture("legacy-1.21")
+//	cluster.Fail(advisor.ScanHelm, errors.New("secrets is forbidden"))
+//	inv, err := advisor.NewScanner(advisor.ScanOptions{ClusterID: "legacy", Cluster: true, Clients: cluster.Clients()}).Scan(ctx)
+package fake
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Fixtures lists the names of the built-in fixtures
+func Fixtures() []string {
+	entries, _ := fixtures.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fixture loads a built-in fixture, e.g. "legacy-1.21"
+func Fixture(name string) (*Cluster, error) {
+	data, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown fixture %q (available: %s)", name, strings.Join(Fixtures(), ", "))
+	}
+	return parseCluster(data)
+}
+
+// LoadCluster loads a fake cluster from an inventory export, e.g. one of a real cluster written
+// by 'inventory export'
+func LoadCluster(file string) (*Cluster, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return parseCluster(data)
+}
+
+// parseCluster parses an inventory export into a fake cluster
+func parseCluster(data []byte) (*Cluster, error) {
+	inv, err := inventory.ReadExport(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &Cluster{inventory: inv, errors: make(map[string]error)}, nil
+}
+
+// Cluster is a fake cluster serving the version, served APIs, node platforms, CRDs and Helm
+// releases of an inventory. Its manifest APIs are not served; scan them from files.
+type Cluster struct {
+	mu        sync.Mutex
+	inventory *advisor.Inventory
+	errors    map[string]error
+}
+
+// Fail makes a subsystem (advisor.ScanServedAPIs, ScanNodePlatforms, ScanCRDs or ScanHelm) fail
+// with err, e.g. to simulate missing RBAC permissions; a nil err makes it succeed again
+func (c *Cluster) Fail(subsystem string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.errors, subsystem)
+		return
+	}
+	c.errors[subsystem] = err
+}
+
+// Clients returns scanner clients reading the fake cluster
+func (c *Cluster) Clients() *advisor.Clients {
+	return &advisor.Clients{Kube: c, CRDs: c, Helm: c}
+}
+
+// failure returns the error a subsystem was made to fail with, or ctx's error
+func (c *Cluster) failure(ctx context.Context, subsystem string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errors[subsystem]
+}
+
+// ClusterVersion returns the fixture's Kubernetes version
+func (c *Cluster) ClusterVersion(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.inventory.Cluster.KubeVersion, nil
+}
+
+// ServedAPIs returns the fixture's served APIs
+func (c *Cluster) ServedAPIs(ctx context.Context) (map[string][]string, error) {
+	if err := c.failure(ctx, advisor.ScanServedAPIs); err != nil {
+		return nil, err
+	}
+	return c.inventory.Cluster.ServedAPIs, nil
+}
+
+// NodePlatforms returns the fixture's node platforms
+func (c *Cluster) NodePlatforms(ctx context.Context) ([]string, error) {
+	if err := c.failure(ctx, advisor.ScanNodePlatforms); err != nil {
+		return nil, err
+	}
+	return c.inventory.Cluster.NodePlatforms, nil
+}
+
+// CRDs returns the fixture's CRDs
+func (c *Cluster) CRDs(ctx context.Context) ([]advisor.CRD, error) {
+	if err := c.failure(ctx, advisor.ScanCRDs); err != nil {
+		return nil, err
+	}
+	return append([]advisor.CRD(nil), c.inventory.CRDs...), nil
+}
+
+// Releases returns the fixture's Helm releases of a namespace, or of all namespaces
+func (c *Cluster) Releases(ctx context.Context, namespace string) ([]advisor.HelmRelease, error) {
+	if err := c.failure(ctx, advisor.ScanHelm); err != nil {
+		return nil, err
+	}
+
+	var releases []advisor.HelmRelease
+	for _, rel := range c.inventory.HelmReleases {
+		if namespace == "" || rel.Namespace == namespace {
+			releases = append(releases, rel)
+		}
+	}
+	return releases, nil
+}
@@ -0,0 +1,102 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor"
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor/fake"
+)
+
+// knowledgeBase is the repository's knowledge base, relative to this package
+const knowledgeBase = advisor.KnowledgeDir("../../../knowledge-base")
+
+// scan scans a fake cluster into a fake store
+func scan(t *testing.T, cluster *fake.Cluster) (*advisor.Inventory, *fake.Store) {
+	t.Helper()
+	inv, err := advisor.NewScanner(advisor.ScanOptions{ClusterID: "legacy", Cluster: true, Clients: cluster.Clients()}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	store := fake.NewStore()
+	if err := store.SaveInventory(context.Background(), inv); err != nil {
+		t.Fatalf("SaveInventory: %v", err)
+	}
+	return inv, store
+}
+
+func TestScanAnalyzePlan(t *testing.T) {
+	for _, name := range fake.Fixtures() {
+		t.Run(name, func(t *testing.T) {
+			// Every fixture is scanned as cluster legacy, into an analyzer database of its own
+			t.Parallel()
+			cluster, err := fake.Fixture(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inv, store := scan(t, cluster)
+			if inv.Cluster.KubeVersion == "" {
+				t.Error("scan recorded no cluster version")
+			}
+			if len(inv.Cluster.ScanErrors) > 0 {
+				t.Errorf("scan errors: %v", inv.Cluster.ScanErrors)
+			}
+
+			analyzer, err := advisor.NewAnalyzer(store, knowledgeBase, advisor.AnalyzerOptions{})
+			if err != nil {
+				t.Fatalf("NewAnalyzer: %v", err)
+			}
+			assessment, err := analyzer.Analyze(context.Background(), "legacy", "1.29")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if assessment.CurrentVersion != inv.Cluster.KubeVersion {
+				t.Errorf("assessed version %s, scanned %s", assessment.CurrentVersion, inv.Cluster.KubeVersion)
+			}
+			if assessment.TotalIssues == 0 {
+				t.Error("no issues for a legacy cluster upgraded to 1.29")
+			}
+
+			plan, err := advisor.NewPlanner(advisor.PlannerOptions{}).Plan(assessment)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			if plan.TotalSteps == 0 || len(plan.OrderedUpgradeSteps) != plan.TotalSteps {
+				t.Errorf("plan has %d steps, %d ordered", plan.TotalSteps, len(plan.OrderedUpgradeSteps))
+			}
+		})
+	}
+}
+
+func TestScanSkipsFailedSubsystem(t *testing.T) {
+	cluster, err := fake.Fixture("legacy-1.21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.Fail(advisor.ScanHelm, errors.New("secrets is forbidden"))
+
+	inv, store := scan(t, cluster)
+	if inv.Cluster.ScanErrors[advisor.ScanHelm] == "" {
+		t.Errorf("no scan error for %s: %v", advisor.ScanHelm, inv.Cluster.ScanErrors)
+	}
+	if len(inv.HelmReleases) != 0 {
+		t.Errorf("got %d Helm releases from a failed scan", len(inv.HelmReleases))
+	}
+	if len(inv.CRDs) == 0 {
+		t.Error("CRDs were skipped with Helm")
+	}
+
+	stored, err := store.Inventory(context.Background(), "legacy")
+	if err != nil {
+		t.Fatalf("Inventory: %v", err)
+	}
+	if stored.Cluster.ScanErrors[advisor.ScanHelm] == "" {
+		t.Error("stored inventory lost the scan error")
+	}
+
+	cluster.Fail(advisor.ScanHelm, nil)
+	if inv, _ := scan(t, cluster); len(inv.HelmReleases) == 0 {
+		t.Error("no Helm releases after the failure was cleared")
+	}
+}
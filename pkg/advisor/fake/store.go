@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/pkg/advisor"
+)
+
+// Store is an in-memory advisor.Store
+type Store struct {
+	mu          sync.Mutex
+	inventories map[string][]byte
+}
+
+// NewStore creates an empty in-memory store
+func NewStore() *Store {
+	return &Store{inventories: make(map[string][]byte)}
+}
+
+// SaveInventory replaces the inventory of the inventory's cluster
+func (s *Store) SaveInventory(ctx context.Context, inv *advisor.Inventory) error {
+	if inv.Cluster.ID == "" {
+		return fmt.Errorf("inventory has no cluster ID")
+	}
+	// Keep a copy, so later changes by the caller don't leak into the store
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inventories[inv.Cluster.ID] = data
+	return nil
+}
+
+// Inventory returns a copy of the inventory of a cluster
+func (s *Store) Inventory(ctx context.Context, clusterID string) (*advisor.Inventory, error) {
+	s.mu.Lock()
+	data, ok := s.inventories[clusterID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("cluster %s not found", clusterID)
+	}
+
+	var inv advisor.Inventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inventory: %w", err)
+	}
+	return &inv, nil
+}
+
+// Close does nothing
+func (s *Store) Close() error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package advisor
+
+import (
+	"path/filepath"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/analysis"
+)
+
+// Knowledge base files read by the analyzer
+const (
+	APIKnowledge      = "apis.json"
+	ChartKnowledge    = "chart-matrix.json"
+	OperatorKnowledge = analysis.OperatorKnowledgeFile
+	BehaviorKnowledge = analysis.BehaviorKnowledgeFile
+	PodFieldKnowledge = analysis.PodFieldKnowledgeFile
+	MeshKnowledge     = analysis.MeshKnowledgeFile
+)
+
+// KnowledgeBase locates the curated knowledge base files (API deprecations, chart compatibility,
+// operator APIs, behavior changes, pod template fields, service meshes) the analysis is based on
+type KnowledgeBase interface {
+	// Path returns the path of a knowledge base file, e.g. APIKnowledge
+	Path(name string) string
+}
+
+// KnowledgeDir is a knowledge base directory such as the repository's knowledge-base/, or one
+// pulled with 'knowledge pull'
+type KnowledgeDir string
+
+// Path returns the file in the directory
+func (d KnowledgeDir) Path(name string) string {
+	return filepath.Join(string(d), name)
+}
@@ -0,0 +1,48 @@
+package advisor
+
+import (
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/i18n"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/planner"
+)
+
+// Planner orders the steps of an upgrade from an assessment
+type Planner interface {
+	Plan(assessment *Assessment) (*Plan, error)
+}
+
+// PlannerOptions customizes plan generation
+type PlannerOptions struct {
+	// Lang is the language of step and action descriptions, e.g. "ja"; English when empty
+	Lang string
+	// SkipBackup omits the backup step, for clusters backed up out of band
+	SkipBackup bool
+	// IncludeRollback adds a contingency step restoring the pre-upgrade state
+	IncludeRollback bool
+}
+
+// upgradePlanner generates plans with the dependency-ordered planner of the CLI and server
+type upgradePlanner struct {
+	opts PlannerOptions
+}
+
+// NewPlanner creates a planner
+func NewPlanner(opts PlannerOptions) Planner {
+	return &upgradePlanner{opts: opts}
+}
+
+// Plan generates the upgrade plan of an assessment
+func (p *upgradePlanner) Plan(assessment *Assessment) (*Plan, error) {
+	generator := planner.NewPlanner()
+	generator.SetOptions(planner.Options{
+		SkipBackup:      p.opts.SkipBackup,
+		IncludeRollback: p.opts.IncludeRollback,
+	})
+	if p.opts.Lang != "" {
+		localizer, err := i18n.New(p.opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		generator.SetLocalizer(localizer)
+	}
+	return generator.GeneratePlan(assessment)
+}
@@ -0,0 +1,310 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/cluster"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/manifests"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/scan"
+)
+
+// Scanner collects the inventory of a cluster
+type Scanner interface {
+	// Scan collects the inventory. When ctx is done mid-scan, the partial inventory is returned,
+	// marked incomplete, together with the error.
+	Scan(ctx context.Context) (*Inventory, error)
+}
+
+// ScanOptions selects what a scanner collects
+type ScanOptions struct {
+	// ClusterID is the ID the inventory is stored under
+	ClusterID string
+	// Name is the cluster's display name; ClusterID when empty
+	Name string
+	// Labels group clusters, e.g. env=prod
+	Labels map[string]string
+
+	// Cluster collects the version, served APIs, node platforms, CRDs and Helm releases of the
+	// cluster of Clients, or of Kubeconfig and Context when Clients is nil. A live cluster is
+	// scanned as by the CLI's scan command, which also collects its namespaces and live objects.
+	Cluster    bool
+	Clients    *Clients
+	Kubeconfig string
+	Context    string
+	// Namespace limits Helm releases to one namespace; all namespaces when empty
+	Namespace string
+	// HelmDrivers are the Helm storage drivers a live scan reads releases from; empty uses
+	// $HELM_DRIVER. HelmFilter selects the releases collected.
+	HelmDrivers []string
+	HelmFilter  ReleaseFilter
+	// KubeVersion is the version recorded without Cluster
+	KubeVersion string
+
+	// Manifests is a manifest folder, file, archive or https:// URL to collect API versions from
+	Manifests string
+	// ManifestSource is the source recorded with the manifest APIs: local (default) or git
+	ManifestSource string
+	// Renderers render templated sources before parsing: jsonnet, cue, helmfile
+	Renderers []string
+	// Offline rejects renderers that need network access
+	Offline bool
+	// TolerateTemplates parses raw Go templates such as un-rendered chart templates, marking the
+	// APIs only found in them as templated
+	TolerateTemplates bool
+	// ScanConfigMaps also collects the APIs of manifests embedded in ConfigMaps
+	ScanConfigMaps bool
+	// Excludes and Includes are gitignore-style patterns relative to Manifests, skipping the
+	// files and folders matching Excludes and, when set, the files matching none of Includes
+	Excludes []string
+	Includes []string
+	// FollowSymlinks follows symlinks in Manifests, skipping link cycles
+	FollowSymlinks bool
+	// ReadArchives also parses the archives found in Manifests
+	ReadArchives bool
+
+	// Terraform is a Terraform state file or plan/state JSON from 'terraform show -json'
+	Terraform string
+
+	// Progress receives progress and warnings; discarded when nil
+	Progress io.Writer
+}
+
+// scanner collects inventories as the CLI's scan command does
+type scanner struct {
+	opts ScanOptions
+}
+
+// NewScanner creates a scanner
+func NewScanner(opts ScanOptions) Scanner {
+	if opts.Name == "" {
+		opts.Name = opts.ClusterID
+	}
+	if opts.ManifestSource == "" {
+		opts.ManifestSource = "local"
+	}
+	if opts.Progress == nil {
+		opts.Progress = io.Discard
+	}
+	return &scanner{opts: opts}
+}
+
+// Scan collects the inventory. A cluster subsystem that fails, e.g. for lack of RBAC permissions,
+// is recorded in the inventory's scan errors and the others are still collected.
+func (s *scanner) Scan(ctx context.Context) (*Inventory, error) {
+	if s.opts.ClusterID == "" {
+		return nil, fmt.Errorf("scan options have no cluster ID")
+	}
+
+	inv := &Inventory{
+		ExportedAt: time.Now().UTC(),
+		Cluster: inventory.ExportedCluster{
+			ID:          s.opts.ClusterID,
+			Name:        s.opts.Name,
+			KubeVersion: s.opts.KubeVersion,
+			Labels:      s.opts.Labels,
+			ScanErrors:  make(map[string]string),
+		},
+	}
+
+	steps := []func(context.Context, *Inventory) error{s.scanManifests, s.scanTerraform}
+	if s.opts.Cluster {
+		steps = append([]func(context.Context, *Inventory) error{s.scanCluster}, steps...)
+	}
+	for _, step := range steps {
+		if err := step(ctx, inv); err != nil {
+			if ctx.Err() != nil {
+				inv.Cluster.IncompleteReason = err.Error()
+			}
+			return inv, err
+		}
+	}
+	if len(inv.Cluster.ScanErrors) == 0 {
+		inv.Cluster.ScanErrors = nil
+	}
+	return inv, nil
+}
+
+// scanCluster collects the inventory of the cluster of Kubeconfig and Context with scan.Cluster,
+// or reads it from the injected Clients
+func (s *scanner) scanCluster(ctx context.Context, inv *Inventory) error {
+	if s.opts.Clients != nil {
+		return s.scanClients(ctx, inv, s.opts.Clients)
+	}
+
+	kubeClient, err := cluster.NewKubeClientFromFlags(s.opts.Kubeconfig, s.opts.Context)
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %w", err)
+	}
+	builder := inventory.NewExportBuilder(inv.Cluster)
+	version, scanErrors, err := scan.Cluster(ctx, builder, kubeClient, s.opts.ClusterID, scan.Options{
+		Kubeconfig:  s.opts.Kubeconfig,
+		Context:     s.opts.Context,
+		Namespace:   s.opts.Namespace,
+		HelmDrivers: s.opts.HelmDrivers,
+		HelmFilter:  s.opts.HelmFilter,
+		Progress:    s.printScanEvent,
+		Log:         s.opts.Progress,
+	})
+	if version == "" {
+		return err
+	}
+
+	// The subsystems scanned before an interrupt are kept
+	scanned := builder.Export()
+	inv.Cluster = scanned.Cluster
+	for subsystem, scanErr := range scanErrors {
+		inv.Cluster.ScanErrors[subsystem] = scanErr
+	}
+	inv.CRDs = append(inv.CRDs, scanned.CRDs...)
+	inv.HelmReleases = append(inv.HelmReleases, scanned.HelmReleases...)
+	inv.Resources = append(inv.Resources, scanned.Resources...)
+	return err
+}
+
+// printScanEvent prints the messages of a live scan, and its skipped subsystems as warnings
+func (s *scanner) printScanEvent(event scan.Event) {
+	switch {
+	case event.Error != "":
+		fmt.Fprintf(s.opts.Progress, "Warning: skipping %s: %s\n", event.Phase, event.Error)
+	case event.Message != "":
+		fmt.Fprintln(s.opts.Progress, event.Message)
+	}
+}
+
+// scanClients collects the version, served APIs, node platforms, CRDs and Helm releases from
+// injected clients, e.g. fakes
+func (s *scanner) scanClients(ctx context.Context, inv *Inventory, clients *Clients) error {
+	version, err := clients.Kube.ClusterVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster version: %w", err)
+	}
+	inv.Cluster.KubeVersion = version
+	fmt.Fprintf(s.opts.Progress, "Cluster version: %s\n", version)
+
+	// skip records a failed subsystem, unless the failure is an interrupt that stops the scan
+	skip := func(subsystem string, err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to scan %s: %w", subsystem, err)
+		}
+		fmt.Fprintf(s.opts.Progress, "Warning: skipping %s: %v\n", subsystem, err)
+		inv.Cluster.ScanErrors[subsystem] = err.Error()
+		return nil
+	}
+
+	if served, err := clients.Kube.ServedAPIs(ctx); err != nil {
+		if err := skip(ScanServedAPIs, err); err != nil {
+			return err
+		}
+	} else {
+		inv.Cluster.ServedAPIs = served
+	}
+
+	if platforms, err := clients.Kube.NodePlatforms(ctx); err != nil {
+		if err := skip(ScanNodePlatforms, err); err != nil {
+			return err
+		}
+	} else {
+		inv.Cluster.NodePlatforms = platforms
+	}
+
+	// CRDs listed before an interrupt are kept
+	crds, err := clients.CRDs.CRDs(ctx)
+	inv.CRDs = append(inv.CRDs, crds...)
+	if err != nil {
+		if err := skip(ScanCRDs, err); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(s.opts.Progress, "Found %d CRDs\n", len(crds))
+	}
+
+	releases, err := clients.Helm.Releases(ctx, s.opts.Namespace)
+	if err != nil {
+		if err := skip(ScanHelm, err); err != nil {
+			return err
+		}
+	} else {
+		inv.HelmReleases = append(inv.HelmReleases, releases...)
+		fmt.Fprintf(s.opts.Progress, "Found %d Helm releases\n", len(releases))
+	}
+	return nil
+}
+
+// scanManifests collects the distinct APIs of the manifests
+func (s *scanner) scanManifests(ctx context.Context, inv *Inventory) error {
+	if s.opts.Manifests == "" {
+		return nil
+	}
+
+	parser := manifests.NewParser()
+	parser.Offline = s.opts.Offline
+	parser.TolerateTemplates = s.opts.TolerateTemplates
+	parser.ScanConfigMaps = s.opts.ScanConfigMaps
+	parser.FollowSymlinks = s.opts.FollowSymlinks
+	parser.ReadArchives = s.opts.ReadArchives
+	parser.Progress = s.opts.Progress
+	if err := parser.EnableRenderers(s.opts.Renderers); err != nil {
+		return err
+	}
+	if err := parser.SetFilters(s.opts.Excludes, s.opts.Includes); err != nil {
+		return err
+	}
+
+	// Manifests parsed before an interrupt are kept
+	resources, parseErr := parser.ParseInputContext(ctx, s.opts.Manifests)
+	if parseErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to parse manifests: %w", parseErr)
+	}
+	for _, api := range parser.UniqueAPIs(resources) {
+		inv.ManifestAPIs = append(inv.ManifestAPIs, inventory.ExportedManifestAPI{
+			Group:      api.Group,
+			Version:    api.Version,
+			Kind:       api.Kind,
+			Source:     s.opts.ManifestSource,
+			Templated:  api.Templated,
+			Namespaces: api.Namespaces,
+		})
+	}
+	fmt.Fprintf(s.opts.Progress, "Found %d Kubernetes resources in %s\n", len(resources), s.opts.Manifests)
+
+	if parseErr != nil {
+		return fmt.Errorf("failed to parse all manifests: %w", parseErr)
+	}
+	return nil
+}
+
+// scanTerraform collects the Kubernetes APIs and Helm releases managed by Terraform
+func (s *scanner) scanTerraform(ctx context.Context, inv *Inventory) error {
+	if s.opts.Terraform == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	terraformScanner := manifests.NewTerraformScanner()
+	terraformScanner.Progress = s.opts.Progress
+	result, err := terraformScanner.ScanFile(s.opts.Terraform)
+	if err != nil {
+		return fmt.Errorf("failed to scan terraform file: %w", err)
+	}
+
+	for _, api := range manifests.NewParser().UniqueAPIs(result.Resources) {
+		inv.ManifestAPIs = append(inv.ManifestAPIs, inventory.ExportedManifestAPI{
+			Group:      api.Group,
+			Version:    api.Version,
+			Kind:       api.Kind,
+			Source:     "terraform",
+			Namespaces: api.Namespaces,
+		})
+	}
+	for _, release := range result.HelmReleases {
+		inv.HelmReleases = append(inv.HelmReleases, inventory.NewExportedHelmRelease(release))
+	}
+	fmt.Fprintf(s.opts.Progress, "Found %d Kubernetes resources and %d Helm releases in %s\n", len(result.Resources), len(result.HelmReleases), s.opts.Terraform)
+	return nil
+}
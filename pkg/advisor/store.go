@@ -0,0 +1,51 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/retr0-kernel/kube-upgrade-advisor/internal/inventory"
+)
+
+// Store keeps cluster inventories for analysis
+type Store interface {
+	// SaveInventory replaces the inventory of the inventory's cluster, creating the cluster if needed
+	SaveInventory(ctx context.Context, inv *Inventory) error
+	// Inventory returns the inventory of a cluster
+	Inventory(ctx context.Context, clusterID string) (*Inventory, error)
+	// Close releases the store
+	Close() error
+}
+
+// Database is the SQLite store shared with the CLI and server
+type Database struct {
+	store *inventory.Store
+}
+
+// OpenDatabase opens or creates a SQLite database, migrating its schema
+func OpenDatabase(path string) (*Database, error) {
+	store, err := inventory.NewStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{store: store}, nil
+}
+
+// SaveInventory replaces the inventory of the inventory's cluster in a single transaction
+func (d *Database) SaveInventory(ctx context.Context, inv *Inventory) error {
+	if _, err := d.store.ImportInventory(ctx, inv, ""); err != nil {
+		return fmt.Errorf("failed to save inventory: %w", err)
+	}
+	return nil
+}
+
+// Inventory returns the inventory of a cluster
+func (d *Database) Inventory(ctx context.Context, clusterID string) (*Inventory, error) {
+	return d.store.ExportInventory(ctx, clusterID)
+}
+
+// Close closes the database
+func (d *Database) Close() error {
+	return d.store.Close()
+}